@@ -26,9 +26,10 @@ import (
 // ColorSpec fully specifies the color for rendering -- used in FillStyle and
 // StrokeStyle
 type ColorSpec struct {
-	Source   ColorSources      `desc:"source of color (solid, gradient)"`
+	Source   ColorSources      `desc:"source of color (solid, gradient, pattern)"`
 	Color    Color             `desc:"color for solid color source"`
 	Gradient *rasterx.Gradient `desc:"gradient parameters for gradient color source"`
+	Pattern  ColorPattern      `view:"-" json:"-" xml:"-" desc:"pattern for image pattern color source, e.g., an svg.Pattern element"`
 }
 
 var KiT_ColorSpec = kit.Types.AddType(&ColorSpec{}, nil)
@@ -42,6 +43,7 @@ const (
 	SolidColor ColorSources = iota
 	LinearGradient
 	RadialGradient
+	ImagePattern
 	ColorSourcesN
 )
 
@@ -52,6 +54,16 @@ var KiT_ColorSources = kit.Enums.AddEnumAltLower(ColorSourcesN, kit.NotBitFlag,
 func (ev ColorSources) MarshalJSON() ([]byte, error)  { return kit.EnumMarshalJSON(ev) }
 func (ev *ColorSources) UnmarshalJSON(b []byte) error { return kit.EnumUnmarshalJSON(ev, b) }
 
+// ColorPattern is implemented by elements that provide a repeating image
+// pattern as a color source, e.g., svg.Pattern, which are referred to by
+// url(#name) color specs
+type ColorPattern interface {
+	// PatternColor returns the color function for rendering the pattern
+	// with given opacity, for an object with given render bounds and
+	// transform
+	PatternColor(opacity float32, bounds image.Rectangle, xform mat32.Mat2) rasterx.ColorFunc
+}
+
 // GradientPoints defines points within the gradient
 type GradientPoints int32
 
@@ -65,8 +77,11 @@ const (
 
 // IsNil tests for nil solid or gradient colors
 func (cs *ColorSpec) IsNil() bool {
-	if cs.Source == SolidColor {
+	switch cs.Source {
+	case SolidColor:
 		return cs.Color.IsNil()
+	case ImagePattern:
+		return cs.Pattern == nil
 	}
 	return cs.Gradient == nil
 }
@@ -85,6 +100,7 @@ func (cs *ColorSpec) SetColor(cl color.Color) {
 	cs.Color.SetColor(cl)
 	cs.Source = SolidColor
 	cs.Gradient = nil
+	cs.Pattern = nil
 }
 
 // SetName sets a solid color by name
@@ -92,6 +108,7 @@ func (cs *ColorSpec) SetName(name string) {
 	cs.Color.SetName(name)
 	cs.Source = SolidColor
 	cs.Gradient = nil
+	cs.Pattern = nil
 }
 
 // Copy copies a gradient, making new copies of the stops instead of
@@ -144,6 +161,9 @@ func MatToRasterx(mat *mat32.Mat2) rasterx.Matrix2D {
 // RenderColor gets the color for rendering, applying opacity and bounds for
 // gradients
 func (cs *ColorSpec) RenderColor(opacity float32, bounds image.Rectangle, xform mat32.Mat2) interface{} {
+	if cs.Source == ImagePattern && cs.Pattern != nil {
+		return cs.Pattern.PatternColor(opacity, bounds, xform)
+	}
 	if cs.Source == SolidColor || cs.Gradient == nil {
 		return rasterx.ApplyOpacity(cs.Color, float64(opacity))
 	} else {
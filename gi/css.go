@@ -6,6 +6,7 @@ package gi
 
 import (
 	"log"
+	"strings"
 
 	"github.com/aymerick/douceur/css"
 	"github.com/aymerick/douceur/parser"
@@ -42,7 +43,9 @@ func (ss *StyleSheet) ParseString(str string) error {
 }
 
 // CSSProps returns the properties for each of the rules in this style sheet,
-// suitable for setting the CSS value of a node -- returns nil if empty sheet
+// suitable for setting the CSS value of a node -- returns nil if empty sheet.
+// Each selector is kept as a key, including full selectors with combinators,
+// attributes and pseudo-classes, which are matched in StyleCSS (see CSSRules).
 func (ss *StyleSheet) CSSProps() ki.Props {
 	if ss.Sheet == nil {
 		return nil
@@ -61,11 +64,15 @@ func (ss *StyleSheet) CSSProps() ki.Props {
 			continue
 		}
 		for _, sel := range r.Selectors {
-			sp := make(ki.Props, nd)
+			sel = strings.Join(strings.Fields(sel), " ") // normalize whitespace
+			sp, has := pr[sel].(ki.Props)                // later rules for same selector add to earlier
+			if !has {
+				sp = make(ki.Props, nd)
+				pr[sel] = sp
+			}
 			for _, de := range r.Declarations {
 				sp[de.Property] = de.Value
 			}
-			pr[sel] = sp
 		}
 	}
 	return pr
@@ -0,0 +1,112 @@
+// Copyright (c) 2020, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gi
+
+import (
+	"testing"
+
+	"github.com/goki/ki/ki"
+)
+
+func TestCSSSelectorParse(t *testing.T) {
+	tests := []struct {
+		sel  string
+		spec int
+		err  bool
+	}{
+		{"button", 1, false},
+		{".primary", 100, false},
+		{"#ok", 10000, false},
+		{"frame > button.primary:hover", 202, false},
+		{"frame   label + label", 3, false},
+		{"layout ~ *[text^=Fi]", 101, false},
+		{"label:nth-child(2n+1):not(.big)", 201, false},
+		{"frame >", 0, true},
+		{"> frame", 0, true},
+		{"label[text", 0, true},
+		{"label::before", 0, true},
+	}
+	for _, ts := range tests {
+		cs, err := ParseCSSSelector(ts.sel)
+		if ts.err {
+			if err == nil {
+				t.Errorf("selector: %q expected error", ts.sel)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("selector: %q unexpected error: %v", ts.sel, err)
+			continue
+		}
+		if sp := cs.Specificity(); sp != ts.spec {
+			t.Errorf("selector: %q specificity: %d != %d", ts.sel, sp, ts.spec)
+		}
+	}
+	for _, ts := range []struct {
+		arg  string
+		a, b int
+	}{{"odd", 2, 1}, {"even", 2, 0}, {"3", 0, 3}, {"-n+3", -1, 3}, {"2n - 1", 2, -1}} {
+		a, b, err := ParseCSSNth(ts.arg)
+		if err != nil || a != ts.a || b != ts.b {
+			t.Errorf("nth: %q got %d %d %v, expected %d %d", ts.arg, a, b, err, ts.a, ts.b)
+		}
+	}
+}
+
+func TestCSSRules(t *testing.T) {
+	fr := &Frame{}
+	fr.InitName(fr, "fr")
+	l1 := AddNewLabel(fr, "l1", "First")
+	l2 := AddNewLabel(fr, "l2", "Second")
+	l2.Class = "big"
+	sub := AddNewLayout(fr, "sub", LayoutVert)
+	l3 := AddNewLabel(sub, "l3", "Third")
+
+	css := ki.Props{
+		"label":                   ki.Props{"n": "type"},
+		".big":                    ki.Props{"n": "class"},
+		"frame > label":           ki.Props{"n": "child"},
+		"frame label":             ki.Props{"n": "desc"},
+		"label + label":           ki.Props{"n": "adj"},
+		"label:first-child":       ki.Props{"n": "first"},
+		"label:nth-child(even)":   ki.Props{"n": "even"},
+		"label:not(.big)":         ki.Props{"n": "notbig"},
+		"[text$=ird]":             ki.Props{"n": "attr"},
+		"label:hover":             ki.Props{"n": "hover"},
+		"frame > label.big:hover": ki.Props{"n": "bighover"},
+		"#l2":                     ki.Props{"n": "id", ":hover": ki.Props{"n": "idhover"}},
+	}
+	names := func(node ki.Ki, state string) []string {
+		var nms []string
+		for _, r := range CSSRules(node, css, state) {
+			nms = append(nms, r.Props["n"].(string))
+		}
+		return nms
+	}
+	tests := []struct {
+		node  ki.Ki
+		state string
+		want  []string
+	}{
+		{l1, "", []string{"type", "child", "desc", "first", "notbig"}},
+		{l2, "", []string{"type", "child", "desc", "adj", "class", "even", "id"}},
+		{l3, "", []string{"type", "desc", "attr", "first", "notbig"}},
+		{l1, ":hover", []string{"hover"}},
+		{l2, ":hover", []string{"hover", "bighover", "idhover"}},
+	}
+	for _, ts := range tests {
+		got := names(ts.node, ts.state)
+		if len(got) != len(ts.want) {
+			t.Errorf("node: %v state: %q got: %v expected: %v", ts.node.Name(), ts.state, got, ts.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != ts.want[i] {
+				t.Errorf("node: %v state: %q got: %v expected: %v", ts.node.Name(), ts.state, got, ts.want)
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,792 @@
+// Copyright (c) 2020, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gi
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/goki/ki/ki"
+	"github.com/goki/ki/kit"
+)
+
+// CSSSelector is a parsed CSS selector, consisting of a sequence of compound
+// selectors joined by combinators, e.g., "frame > button.primary:hover".
+// The last compound selector is the subject that must match the node being
+// styled, and the previous ones must match its ancestors or siblings
+// according to the combinators.
+type CSSSelector struct {
+	Src   string        `desc:"source string that this selector was parsed from"`
+	Parts []CSSCompound `desc:"compound selectors, in order from left to right -- the last one is the subject"`
+}
+
+// CSSCompound is a compound CSS selector, e.g., button.primary[text=OK]:focus,
+// which matches a single node, along with the combinator relating it to
+// the previous compound in the overall selector.
+type CSSCompound struct {
+	Comb    byte        `desc:"combinator relating this to the previous compound: ' ' = descendant, '>' = child, '+' = adjacent sibling, '~' = general sibling -- 0 for the first one"`
+	Type    string      `desc:"lower-case type name, or empty or * for any type"`
+	ID      string      `desc:"lower-case #name, if non-empty"`
+	Classes []string    `desc:"lower-case .class names, all of which must be present"`
+	Attrs   []CSSAttr   `desc:"[attr] attribute selectors"`
+	Pseudos []CSSPseudo `desc:":pseudo-class selectors"`
+}
+
+// CSSAttr is a CSS attribute selector, e.g., [name], [name=val], [name~=val]
+type CSSAttr struct {
+	Name string `desc:"attribute name"`
+	Op   string `desc:"operator: empty for existence, or one of = ~= |= ^= $= *="`
+	Val  string `desc:"value to compare with"`
+}
+
+// CSSPseudo is a CSS pseudo-class selector, e.g., :first-child, :nth-child(2n+1)
+type CSSPseudo struct {
+	Name string       `desc:"lower-case name, including the leading :"`
+	A, B int          `desc:"for :nth-child and :nth-last-child, the an+b parameters"`
+	Not  *CSSCompound `desc:"for :not, the compound selector to negate"`
+}
+
+// CSSStateSelectors are the pseudo-class selectors that refer to the dynamic
+// state of a node, rather than its position in the tree.  When they appear
+// on the subject of a selector, they are matched against the selector passed
+// to StyleCSS (e.g., ":hover"), as used for the StateStyles of widgets --
+// otherwise they are evaluated against the current state of the node.
+var CSSStateSelectors = map[string]bool{
+	":active":    true,
+	":inactive":  true,
+	":disabled":  true,
+	":enabled":   true,
+	":hover":     true,
+	":focus":     true,
+	":down":      true,
+	":selected":  true,
+	":highlight": true,
+	":value":     true,
+	":box":       true,
+}
+
+// CSSStateAliases maps standard CSS state pseudo-classes onto the
+// equivalent selectors used in the StateStyles of widgets.
+var CSSStateAliases = map[string]string{
+	":disabled": ":inactive",
+	":enabled":  ":active",
+}
+
+// Specificity returns the CSS specificity of the selector, encoded as
+// ids * 10000 + (classes, attributes and pseudo-classes) * 100 + types --
+// rules with higher specificity take precedence over lower ones.
+func (cs *CSSSelector) Specificity() int {
+	sp := 0
+	for i := range cs.Parts {
+		sp += cs.Parts[i].Specificity()
+	}
+	return sp
+}
+
+// Specificity returns the CSS specificity of the compound selector
+func (cc *CSSCompound) Specificity() int {
+	sp := 0
+	if cc.ID != "" {
+		sp += 10000
+	}
+	sp += 100 * (len(cc.Classes) + len(cc.Attrs))
+	for i := range cc.Pseudos {
+		ps := &cc.Pseudos[i]
+		if ps.Not != nil {
+			sp += ps.Not.Specificity() // :not itself does not count
+		} else {
+			sp += 100
+		}
+	}
+	if cc.Type != "" && cc.Type != "*" {
+		sp++
+	}
+	return sp
+}
+
+// IsSimple returns true if the selector is a single type, .class or #name,
+// as handled directly by StyleCSS
+func (cs *CSSSelector) IsSimple() bool {
+	if len(cs.Parts) != 1 {
+		return false
+	}
+	cc := &cs.Parts[0]
+	if len(cc.Attrs) > 0 || len(cc.Pseudos) > 0 {
+		return false
+	}
+	n := len(cc.Classes)
+	if cc.ID != "" {
+		n++
+	}
+	if cc.Type != "" {
+		n++
+	}
+	return n == 1 && cc.Type != "*"
+}
+
+// String returns the selector source string
+func (cs *CSSSelector) String() string {
+	return cs.Src
+}
+
+/////////////////////////////////////////////////////////////////
+//   Parsing
+
+// cssSelCache caches parsed selectors, keyed by source string -- nil
+// entries record selectors that failed to parse
+var cssSelCache = map[string]*CSSSelector{}
+var cssSelCacheMu sync.RWMutex
+
+// CSSSelectorCached returns the parsed selector for given string, using
+// a cache of previously-parsed selectors -- returns nil if the selector
+// could not be parsed.
+func CSSSelectorCached(sel string) *CSSSelector {
+	cssSelCacheMu.RLock()
+	cs, ok := cssSelCache[sel]
+	cssSelCacheMu.RUnlock()
+	if ok {
+		return cs
+	}
+	cs, _ = ParseCSSSelector(sel)
+	cssSelCacheMu.Lock()
+	cssSelCache[sel] = cs
+	cssSelCacheMu.Unlock()
+	return cs
+}
+
+// ParseCSSSelector parses given selector string, which can contain
+// type, .class, #name, [attr] and :pseudo-class selectors, joined by
+// the descendant (space), child (>), adjacent sibling (+) and general
+// sibling (~) combinators.  Selector groups (a, b) are not supported here
+// -- they are split apart by StyleSheet.CSSProps.
+func ParseCSSSelector(sel string) (*CSSSelector, error) {
+	p := cssSelParser{src: sel}
+	cs := &CSSSelector{Src: sel}
+	comb := byte(0)
+	for {
+		sawSpace := p.skipSpace()
+		if p.eof() {
+			break
+		}
+		c := p.peek()
+		if c == '>' || c == '+' || c == '~' {
+			if len(cs.Parts) == 0 || comb != 0 {
+				return nil, fmt.Errorf("gi.ParseCSSSelector: unexpected combinator %q at %d in: %v", c, p.pos, sel)
+			}
+			comb = c
+			p.pos++
+			continue
+		}
+		if len(cs.Parts) > 0 && comb == 0 {
+			if !sawSpace {
+				return nil, fmt.Errorf("gi.ParseCSSSelector: unexpected character %q at %d in: %v", c, p.pos, sel)
+			}
+			comb = ' '
+		}
+		cc, err := p.compound()
+		if err != nil {
+			return nil, err
+		}
+		cc.Comb = comb
+		cs.Parts = append(cs.Parts, *cc)
+		comb = 0
+	}
+	if len(cs.Parts) == 0 {
+		return nil, fmt.Errorf("gi.ParseCSSSelector: empty selector")
+	}
+	if comb != 0 {
+		return nil, fmt.Errorf("gi.ParseCSSSelector: dangling combinator at end of: %v", sel)
+	}
+	return cs, nil
+}
+
+// cssSelParser is the state for parsing a selector
+type cssSelParser struct {
+	src string
+	pos int
+}
+
+func (p *cssSelParser) eof() bool {
+	return p.pos >= len(p.src)
+}
+
+func (p *cssSelParser) peek() byte {
+	return p.src[p.pos]
+}
+
+// skipSpace skips over whitespace, returning true if there was any
+func (p *cssSelParser) skipSpace() bool {
+	st := p.pos
+	for !p.eof() && strings.IndexByte(" \t\n\r\f", p.peek()) >= 0 {
+		p.pos++
+	}
+	return p.pos > st
+}
+
+// ident reads an identifier (letters, digits, - and _)
+func (p *cssSelParser) ident() string {
+	st := p.pos
+	for !p.eof() {
+		c := p.peek()
+		if c == '-' || c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80 {
+			p.pos++
+			continue
+		}
+		break
+	}
+	return p.src[st:p.pos]
+}
+
+// compound parses one compound selector
+func (p *cssSelParser) compound() (*CSSCompound, error) {
+	cc := &CSSCompound{}
+	st := p.pos
+	if p.peek() == '*' {
+		cc.Type = "*"
+		p.pos++
+	} else {
+		cc.Type = strings.ToLower(p.ident())
+	}
+	for !p.eof() {
+		c := p.peek()
+		switch c {
+		case '.':
+			p.pos++
+			cl := p.ident()
+			if cl == "" {
+				return nil, fmt.Errorf("gi.ParseCSSSelector: empty class name at %d in: %v", p.pos, p.src)
+			}
+			cc.Classes = append(cc.Classes, strings.ToLower(cl))
+		case '#':
+			p.pos++
+			id := p.ident()
+			if id == "" {
+				return nil, fmt.Errorf("gi.ParseCSSSelector: empty #name at %d in: %v", p.pos, p.src)
+			}
+			cc.ID = strings.ToLower(id)
+		case '[':
+			p.pos++
+			at, err := p.attr()
+			if err != nil {
+				return nil, err
+			}
+			cc.Attrs = append(cc.Attrs, *at)
+		case ':':
+			p.pos++
+			if !p.eof() && p.peek() == ':' {
+				return nil, fmt.Errorf("gi.ParseCSSSelector: pseudo-elements are not supported in: %v", p.src)
+			}
+			ps, err := p.pseudo()
+			if err != nil {
+				return nil, err
+			}
+			cc.Pseudos = append(cc.Pseudos, *ps)
+		default:
+			if p.pos == st {
+				return nil, fmt.Errorf("gi.ParseCSSSelector: unexpected character %q at %d in: %v", c, p.pos, p.src)
+			}
+			return cc, nil
+		}
+	}
+	return cc, nil
+}
+
+// attr parses an attribute selector, after the opening [
+func (p *cssSelParser) attr() (*CSSAttr, error) {
+	p.skipSpace()
+	at := &CSSAttr{Name: p.ident()}
+	if at.Name == "" {
+		return nil, fmt.Errorf("gi.ParseCSSSelector: empty attribute name at %d in: %v", p.pos, p.src)
+	}
+	p.skipSpace()
+	if p.eof() {
+		return nil, fmt.Errorf("gi.ParseCSSSelector: unterminated attribute selector in: %v", p.src)
+	}
+	if p.peek() == ']' {
+		p.pos++
+		return at, nil
+	}
+	if strings.IndexByte("~|^$*", p.peek()) >= 0 {
+		at.Op = string(p.peek())
+		p.pos++
+	}
+	if p.eof() || p.peek() != '=' {
+		return nil, fmt.Errorf("gi.ParseCSSSelector: expected = in attribute selector at %d in: %v", p.pos, p.src)
+	}
+	at.Op += "="
+	p.pos++
+	p.skipSpace()
+	if p.eof() {
+		return nil, fmt.Errorf("gi.ParseCSSSelector: unterminated attribute selector in: %v", p.src)
+	}
+	if q := p.peek(); q == '"' || q == '\'' {
+		ed := strings.IndexByte(p.src[p.pos+1:], q)
+		if ed < 0 {
+			return nil, fmt.Errorf("gi.ParseCSSSelector: unterminated string in: %v", p.src)
+		}
+		at.Val = p.src[p.pos+1 : p.pos+1+ed]
+		p.pos += ed + 2
+	} else {
+		at.Val = p.ident()
+	}
+	p.skipSpace()
+	if p.eof() || p.peek() != ']' {
+		return nil, fmt.Errorf("gi.ParseCSSSelector: expected ] at %d in: %v", p.pos, p.src)
+	}
+	p.pos++
+	return at, nil
+}
+
+// pseudo parses a pseudo-class selector, after the :
+func (p *cssSelParser) pseudo() (*CSSPseudo, error) {
+	nm := strings.ToLower(p.ident())
+	if nm == "" {
+		return nil, fmt.Errorf("gi.ParseCSSSelector: empty pseudo-class at %d in: %v", p.pos, p.src)
+	}
+	ps := &CSSPseudo{Name: ":" + nm}
+	var arg string
+	hasArg := !p.eof() && p.peek() == '('
+	if hasArg {
+		ed := strings.IndexByte(p.src[p.pos:], ')')
+		if ed < 0 {
+			return nil, fmt.Errorf("gi.ParseCSSSelector: unterminated ( in: %v", p.src)
+		}
+		arg = strings.TrimSpace(p.src[p.pos+1 : p.pos+ed])
+		p.pos += ed + 1
+	}
+	switch ps.Name {
+	case ":nth-child", ":nth-last-child":
+		if !hasArg {
+			return nil, fmt.Errorf("gi.ParseCSSSelector: %v requires an argument in: %v", ps.Name, p.src)
+		}
+		a, b, err := ParseCSSNth(arg)
+		if err != nil {
+			return nil, err
+		}
+		ps.A, ps.B = a, b
+	case ":not":
+		if !hasArg {
+			return nil, fmt.Errorf("gi.ParseCSSSelector: :not requires an argument in: %v", p.src)
+		}
+		np := cssSelParser{src: arg}
+		if np.eof() {
+			return nil, fmt.Errorf("gi.ParseCSSSelector: empty :not() in: %v", p.src)
+		}
+		nc, err := np.compound()
+		if err != nil {
+			return nil, err
+		}
+		if !np.eof() {
+			return nil, fmt.Errorf("gi.ParseCSSSelector: :not only supports a compound selector, in: %v", p.src)
+		}
+		ps.Not = nc
+	default:
+		if hasArg {
+			return nil, fmt.Errorf("gi.ParseCSSSelector: unsupported pseudo-class with argument: %v in: %v", ps.Name, p.src)
+		}
+	}
+	return ps, nil
+}
+
+// ParseCSSNth parses the an+b argument of :nth-child, including the
+// odd and even keywords
+func ParseCSSNth(arg string) (a, b int, err error) {
+	s := strings.ToLower(strings.Replace(arg, " ", "", -1))
+	switch s {
+	case "odd":
+		return 2, 1, nil
+	case "even":
+		return 2, 0, nil
+	}
+	ni := strings.IndexByte(s, 'n')
+	if ni < 0 {
+		b, err = strconv.Atoi(s)
+		if err != nil {
+			err = fmt.Errorf("gi.ParseCSSNth: invalid argument: %v", arg)
+		}
+		return 0, b, err
+	}
+	switch as := s[:ni]; as {
+	case "", "+":
+		a = 1
+	case "-":
+		a = -1
+	default:
+		a, err = strconv.Atoi(as)
+		if err != nil {
+			return 0, 0, fmt.Errorf("gi.ParseCSSNth: invalid argument: %v", arg)
+		}
+	}
+	if bs := s[ni+1:]; bs != "" {
+		b, err = strconv.Atoi(bs)
+		if err != nil {
+			return 0, 0, fmt.Errorf("gi.ParseCSSNth: invalid argument: %v", arg)
+		}
+	}
+	return a, b, nil
+}
+
+/////////////////////////////////////////////////////////////////
+//   Matching
+
+// Matches returns true if the selector matches given node, where state
+// is the state selector being styled (e.g., ":hover"), or empty for the
+// base style -- see CSSStateSelectors.
+func (cs *CSSSelector) Matches(node ki.Ki, state string) bool {
+	n := len(cs.Parts)
+	if n == 0 || !cs.Parts[n-1].matchSubject(node, state) {
+		return false
+	}
+	return cs.matchFrom(n-1, node)
+}
+
+// matchFrom matches the parts before idx, given that part idx matched node
+func (cs *CSSSelector) matchFrom(idx int, node ki.Ki) bool {
+	if idx == 0 {
+		return true
+	}
+	prev := &cs.Parts[idx-1]
+	switch cs.Parts[idx].Comb {
+	case '>':
+		par := node.Parent()
+		return par != nil && prev.Matches(par) && cs.matchFrom(idx-1, par)
+	case '+':
+		sib := cssPrevSibling(node)
+		return sib != nil && prev.Matches(sib) && cs.matchFrom(idx-1, sib)
+	case '~':
+		for sib := cssPrevSibling(node); sib != nil; sib = cssPrevSibling(sib) {
+			if prev.Matches(sib) && cs.matchFrom(idx-1, sib) {
+				return true
+			}
+		}
+	default: // descendant
+		for par := node.Parent(); par != nil; par = par.Parent() {
+			if prev.Matches(par) && cs.matchFrom(idx-1, par) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// matchSubject matches the subject compound against node, with state
+// pseudo-classes matched against the given state selector.  If state is
+// non-empty, the compound must contain that state pseudo-class, and no other.
+func (cc *CSSCompound) matchSubject(node ki.Ki, state string) bool {
+	if !cc.matchNoPseudo(node) {
+		return false
+	}
+	gotState := false
+	for i := range cc.Pseudos {
+		ps := &cc.Pseudos[i]
+		if state != "" && CSSStateSelectors[ps.Name] {
+			if cssStateName(ps.Name) != cssStateName(state) {
+				return false
+			}
+			gotState = true
+			continue
+		}
+		if !ps.Matches(node) {
+			return false
+		}
+	}
+	return state == "" || gotState
+}
+
+// Matches returns true if the compound selector matches given node,
+// with any state pseudo-classes evaluated against the current state
+// of the node
+func (cc *CSSCompound) Matches(node ki.Ki) bool {
+	if !cc.matchNoPseudo(node) {
+		return false
+	}
+	for i := range cc.Pseudos {
+		if !cc.Pseudos[i].Matches(node) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchNoPseudo matches everything except the pseudo-classes
+func (cc *CSSCompound) matchNoPseudo(node ki.Ki) bool {
+	if cc.Type != "" && cc.Type != "*" && cc.Type != strings.ToLower(node.Type().Name()) {
+		return false
+	}
+	if cc.ID != "" && cc.ID != strings.ToLower(node.Name()) {
+		return false
+	}
+	if len(cc.Classes) > 0 {
+		classes := strings.Fields(strings.ToLower(cssNodeClass(node)))
+		for _, cl := range cc.Classes {
+			got := false
+			for _, ncl := range classes {
+				if ncl == cl {
+					got = true
+					break
+				}
+			}
+			if !got {
+				return false
+			}
+		}
+	}
+	for i := range cc.Attrs {
+		if !cc.Attrs[i].Matches(node) {
+			return false
+		}
+	}
+	return true
+}
+
+// Matches returns true if the attribute selector matches given node.
+// Attributes are looked up in the node's properties, then the "name",
+// "id", "class" and "label" (for Labeler nodes) pseudo-attributes, and
+// finally exported fields of the node (matched case-insensitively).
+func (at *CSSAttr) Matches(node ki.Ki) bool {
+	val, has := CSSAttrValue(node, at.Name)
+	if !has {
+		return false
+	}
+	switch at.Op {
+	case "":
+		return true
+	case "=":
+		return val == at.Val
+	case "~=":
+		for _, f := range strings.Fields(val) {
+			if f == at.Val {
+				return true
+			}
+		}
+		return false
+	case "|=":
+		return val == at.Val || strings.HasPrefix(val, at.Val+"-")
+	case "^=":
+		return at.Val != "" && strings.HasPrefix(val, at.Val)
+	case "$=":
+		return at.Val != "" && strings.HasSuffix(val, at.Val)
+	case "*=":
+		return at.Val != "" && strings.Contains(val, at.Val)
+	}
+	return false
+}
+
+// CSSAttrValue returns the value of given attribute for purposes of
+// matching CSS attribute selectors -- see CSSAttr.Matches.
+func CSSAttrValue(node ki.Ki, name string) (string, bool) {
+	if pv := node.Prop(name); pv != nil {
+		return kit.ToString(pv), true
+	}
+	switch strings.ToLower(name) {
+	case "name", "id":
+		return node.Name(), true
+	case "class":
+		return cssNodeClass(node), true
+	case "label":
+		if lb, ok := node.(Labeler); ok {
+			return lb.Label(), true
+		}
+	}
+	v := reflect.ValueOf(node)
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return "", false
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return "", false
+	}
+	fv := v.FieldByNameFunc(func(fn string) bool {
+		return strings.EqualFold(fn, name)
+	})
+	if !fv.IsValid() || !fv.CanInterface() {
+		return "", false
+	}
+	return kit.ToString(fv.Interface()), true
+}
+
+// Matches returns true if the pseudo-class matches given node, evaluating
+// any state pseudo-classes against the current state of the node
+func (ps *CSSPseudo) Matches(node ki.Ki) bool {
+	switch ps.Name {
+	case ":not":
+		return !ps.Not.Matches(node)
+	case ":first-child":
+		idx, ok := node.IndexInParent()
+		return ok && idx == 0
+	case ":last-child":
+		idx, ok := node.IndexInParent()
+		return ok && idx == node.Parent().NumChildren()-1
+	case ":only-child":
+		par := node.Parent()
+		return par != nil && par.NumChildren() == 1
+	case ":nth-child":
+		idx, ok := node.IndexInParent()
+		return ok && cssNthMatch(ps.A, ps.B, idx+1)
+	case ":nth-last-child":
+		idx, ok := node.IndexInParent()
+		return ok && cssNthMatch(ps.A, ps.B, node.Parent().NumChildren()-idx)
+	case ":empty":
+		return !node.HasChildren()
+	case ":root":
+		return node.Parent() == nil
+	}
+	nb, ok := node.Embed(KiT_NodeBase).(*NodeBase)
+	if !ok || nb == nil {
+		return false
+	}
+	switch ps.Name {
+	case ":focus":
+		return nb.HasFocus()
+	case ":inactive", ":disabled":
+		return nb.IsInactive()
+	case ":enabled", ":active":
+		return nb.IsActive()
+	case ":selected":
+		return nb.IsSelected()
+	}
+	return false // :hover, :down etc are only available as state styles
+}
+
+// cssNthMatch returns true if 1-based position pos is of the form a*n+b
+// for some n >= 0
+func cssNthMatch(a, b, pos int) bool {
+	if a == 0 {
+		return pos == b
+	}
+	d := pos - b
+	return d%a == 0 && d/a >= 0
+}
+
+// cssStateName returns the canonical name of a state selector
+func cssStateName(st string) string {
+	if al, ok := CSSStateAliases[st]; ok {
+		return al
+	}
+	return st
+}
+
+// cssNodeClass returns the Class of given node, if it is a gi node
+func cssNodeClass(node ki.Ki) string {
+	if nb, ok := node.Embed(KiT_NodeBase).(*NodeBase); ok && nb != nil {
+		return nb.Class
+	}
+	return ""
+}
+
+// cssPrevSibling returns the previous sibling of given node, or nil
+func cssPrevSibling(node ki.Ki) ki.Ki {
+	idx, ok := node.IndexInParent()
+	if !ok || idx == 0 {
+		return nil
+	}
+	return node.Parent().Child(idx - 1)
+}
+
+/////////////////////////////////////////////////////////////////
+//   Rules
+
+// CSSRule is a css rule that matches a node, as returned by CSSRules
+type CSSRule struct {
+	Key   string   `desc:"key of the rule in the css properties"`
+	State string   `desc:"state sub-selector (e.g., :hover) within the rule properties to apply, if non-empty -- as passed to ApplyCSS"`
+	Props ki.Props `desc:"the properties to apply, i.e., the State sub-properties if set"`
+	spec  int
+	order int
+}
+
+// CSSRules returns the rules from css that apply to given node,
+// sorted in increasing order of CSS specificity, so that they can be
+// applied in order (e.g., with ApplyCSS).  The simple type, .class and #name keys are looked
+// up directly as before, and all other keys are parsed as full selectors
+// (see ParseCSSSelector) and matched against the ki tree.  State is the
+// optional state selector being styled (e.g., ":hover"), which selects
+// the :state sub-properties of matching keys, and is matched against
+// state pseudo-classes on the subject of full selectors.  Rules of equal
+// specificity apply in the order type, classes, name, and then full
+// selectors in sorted key order.
+func CSSRules(node ki.Ki, css ki.Props, state string) []CSSRule {
+	if len(css) == 0 {
+		return nil
+	}
+	var rules []CSSRule
+	add := func(key string, spec, order int, substate bool) {
+		pp, got := css[key]
+		if !got {
+			return
+		}
+		pmap, ok := pp.(ki.Props) // must be a props map
+		if !ok {
+			return
+		}
+		st := ""
+		if substate && state != "" {
+			pmap, ok = SubProps(pmap, state)
+			if !ok {
+				return
+			}
+			st = state
+		}
+		rules = append(rules, CSSRule{Key: key, State: st, Props: pmap, spec: spec, order: order})
+	}
+	add(strings.ToLower(node.Type().Name()), 1, 0, true) // type is most general, first
+	classes := strings.Split(strings.ToLower(cssNodeClass(node)), " ")
+	for i, cl := range classes {
+		cl = strings.TrimSpace(cl)
+		if cl != "" {
+			add("."+cl, 100, 1+i, true)
+		}
+	}
+	add("#"+strings.ToLower(node.Name()), 10000, 1+len(classes), true) // then name
+
+	var keys []string
+	for key := range css {
+		if key != "" && (strings.IndexAny(key, " >+~[:*") >= 0 || strings.IndexAny(key[1:], ".#") >= 0) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for i, key := range keys {
+		sel := CSSSelectorCached(key)
+		if sel == nil || sel.IsSimple() {
+			continue
+		}
+		// without a state on the subject, the :state sub-props are used as for simple keys
+		substate := !sel.hasSubjectState()
+		st := state
+		if substate {
+			st = ""
+		}
+		if !sel.Matches(node, st) {
+			continue
+		}
+		add(key, sel.Specificity(), 2+len(classes)+i, substate)
+	}
+	sort.Slice(rules, func(i, j int) bool {
+		if rules[i].spec != rules[j].spec {
+			return rules[i].spec < rules[j].spec
+		}
+		return rules[i].order < rules[j].order
+	})
+	return rules
+}
+
+// hasSubjectState returns true if the subject of the selector has any
+// state pseudo-classes
+func (cs *CSSSelector) hasSubjectState() bool {
+	cc := &cs.Parts[len(cs.Parts)-1]
+	for i := range cc.Pseudos {
+		if CSSStateSelectors[cc.Pseudos[i].Name] {
+			return true
+		}
+	}
+	return false
+}
@@ -175,6 +175,9 @@ func (dlg *Dialog) Open(x, y int, avp *Viewport2D, cfgFunc func()) bool {
 		kf := KeyFun(kt.Chord())
 		switch kf {
 		case KeyFunAccept:
+			if okb := ddlg.OkButton(); okb != nil && okb.IsInactive() {
+				break // not valid yet
+			}
 			ddlg.Accept()
 			kt.SetProcessed()
 		}
@@ -423,6 +426,37 @@ func (dlg *Dialog) StdButtonConnect(ok, cancel bool, bb *Layout) {
 	}
 }
 
+// OkButton returns the standard Ok button of the dialog, or nil if it
+// does not have one
+func (dlg *Dialog) OkButton() *Button {
+	if !dlg.HasChildren() {
+		return nil
+	}
+	bb, _ := dlg.ButtonBox(dlg.Frame())
+	if bb == nil {
+		return nil
+	}
+	okk := bb.ChildByName("ok", 0)
+	if okk == nil {
+		return nil
+	}
+	return okk.Embed(KiT_Button).(*Button)
+}
+
+// SetOkActive sets the standard Ok button of the dialog to be active or
+// inactive, e.g., to prevent accepting the dialog until its contents are
+// valid -- the accept key function does not accept the dialog either
+// while the Ok button is inactive.
+func (dlg *Dialog) SetOkActive(act bool) {
+	okb := dlg.OkButton()
+	if okb == nil || okb.IsActive() == act {
+		return
+	}
+	okb.SetActiveState(act)
+	okb.UpdateButtonStyle()
+	okb.UpdateSig()
+}
+
 // StdDialog configures a basic standard dialog with a title, prompt, and ok /
 // cancel buttons -- any empty text will not be added
 func (dlg *Dialog) StdDialog(title, prompt string, ok, cancel bool) {
@@ -0,0 +1,140 @@
+// Copyright (c) 2020, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gitest
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/goki/gi/gi"
+	"github.com/goki/ki/ki"
+)
+
+// roots returns the roots to search for widgets: the current popup
+// (if any) first, then the window itself.
+func (h *Harness) roots() []ki.Ki {
+	var rts []ki.Ki
+	if pop := h.Win.CurPopup(); pop != nil {
+		rts = append(rts, pop)
+	}
+	return append(rts, h.Win.This())
+}
+
+// ByPathTry returns the widget at given unique path (see ki PathUnique),
+// relative to the window, or the current popup, or an error if not found.
+func (h *Harness) ByPathTry(path string) (ki.Ki, error) {
+	for _, rt := range h.roots() {
+		if k, err := rt.FindPathUniqueTry(path); err == nil {
+			return k, nil
+		}
+	}
+	return nil, fmt.Errorf("gitest: widget at path: %v not found in window: %v", path, h.Win.Nm)
+}
+
+// ByPath returns the widget at given unique path (see ki PathUnique),
+// relative to the window, or the current popup -- the test fails
+// immediately if not found.
+func (h *Harness) ByPath(path string) ki.Ki {
+	h.T.Helper()
+	k, err := h.ByPathTry(path)
+	if err != nil {
+		h.T.Fatal(err)
+	}
+	return k
+}
+
+// FindFunc returns the first node (depth-first, current popup first) for
+// which given function returns true, or nil if none.  Only visible nodes
+// are considered.
+func (h *Harness) FindFunc(fun func(k ki.Ki) bool) ki.Ki {
+	var fk ki.Ki
+	for _, rt := range h.roots() {
+		rt.FuncDownMeFirst(0, nil, func(k ki.Ki, level int, d interface{}) bool {
+			if fk != nil {
+				return false
+			}
+			if nii, ok := k.(gi.Node2D); ok {
+				if nii.AsNode2D().IsInvisible() {
+					return false // skip invisible branches
+				}
+			}
+			if fun(k) {
+				fk = k
+				return false
+			}
+			return true
+		})
+		if fk != nil {
+			return fk
+		}
+	}
+	return nil
+}
+
+// ByTypeTry returns the first visible widget that is of given type,
+// or embeds it, or an error if not found.
+func (h *Harness) ByTypeTry(typ reflect.Type) (ki.Ki, error) {
+	k := h.FindFunc(func(k ki.Ki) bool {
+		return k.TypeEmbeds(typ)
+	})
+	if k == nil {
+		return nil, fmt.Errorf("gitest: widget of type: %v not found in window: %v", typ.Name(), h.Win.Nm)
+	}
+	return k, nil
+}
+
+// ByType returns the first visible widget that is of given type,
+// or embeds it -- the test fails immediately if not found.
+func (h *Harness) ByType(typ reflect.Type) ki.Ki {
+	h.T.Helper()
+	k, err := h.ByTypeTry(typ)
+	if err != nil {
+		h.T.Fatal(err)
+	}
+	return k
+}
+
+// ByLabelTry returns the first visible widget whose label (per the
+// gi.Labeler interface, e.g., buttons, actions, labels) is given string,
+// or an error if not found.
+func (h *Harness) ByLabelTry(label string) (ki.Ki, error) {
+	k := h.FindFunc(func(k ki.Ki) bool {
+		lb, ok := k.(gi.Labeler)
+		return ok && lb.Label() == label
+	})
+	if k == nil {
+		return nil, fmt.Errorf("gitest: widget with label: %q not found in window: %v", label, h.Win.Nm)
+	}
+	return k, nil
+}
+
+// ByLabel returns the first visible widget whose label (per the gi.Labeler
+// interface, e.g., buttons, actions, labels) is given string -- the test
+// fails immediately if not found.
+func (h *Harness) ByLabel(label string) ki.Ki {
+	h.T.Helper()
+	k, err := h.ByLabelTry(label)
+	if err != nil {
+		h.T.Fatal(err)
+	}
+	return k
+}
+
+// Focus returns the widget that currently has keyboard focus, or nil
+func (h *Harness) Focus() ki.Ki {
+	return h.Win.EventMgr.CurFocus()
+}
+
+// Dialog returns the currently open dialog popup, or nil if there is none.
+// Dialogs only open as popups when gi.DialogsSepWindow is false, as set by
+// Main.
+func (h *Harness) Dialog() *gi.Dialog {
+	pop := h.Win.CurPopup()
+	if pop == nil {
+		return nil
+	}
+	dlg, _ := pop.Embed(gi.KiT_Dialog).(*gi.Dialog)
+	return dlg
+}
@@ -0,0 +1,469 @@
+// Copyright (c) 2020, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+/*
+Package gitest provides a harness for scripted testing of GoGi guis.
+
+A Harness drives a gi.Window by injecting synthetic mouse.Event,
+key.ChordEvent and window.Event values directly through
+Window.ProcessEvent, in the test goroutine -- the window's own event loop
+must NOT be running (i.e., do not call StartEventLoop on it).  Widgets can
+be found by ki path, type or label, and the rendered Viewport2D can be
+compared against golden PNG images with a tolerance.
+
+Tests are run under the offscreen oswin driver, which requires no display
+or GPU.  A typical test file has:
+
+	func TestMain(m *testing.M) {
+		gitest.Main(m)
+	}
+
+	func TestDialog(t *testing.T) {
+		win := gi.NewMainWindow("test", "Test", 640, 480)
+		... configure ...
+		h := gitest.NewHarness(t, win)
+		defer h.Close()
+		h.Click(h.ByLabel("Open"))
+		h.ExpectGolden("open-dialog")
+	}
+
+Golden images live in GoldenDir (testdata by default) -- set UpdateGolden
+or the GITEST_UPDATE environment variable to (re)write them from the
+current renders.
+*/
+package gitest
+
+import (
+	"fmt"
+	"image"
+	"os"
+	"sort"
+	"testing"
+	"time"
+	"unicode"
+
+	"github.com/goki/gi/gi"
+	"github.com/goki/gi/oswin"
+	"github.com/goki/gi/oswin/driver/offscreen"
+	"github.com/goki/gi/oswin/key"
+	"github.com/goki/gi/oswin/mouse"
+	"github.com/goki/gi/oswin/window"
+	"github.com/goki/ki/ki"
+)
+
+// IdleTimeout is the default maximum amount of time to wait for a
+// window to become idle (see Harness.WaitIdle).
+var IdleTimeout = 5 * time.Second
+
+// IdlePoll is the interval at which idle state is polled, and the
+// amount of time that a window must remain idle to be considered so.
+var IdlePoll = 10 * time.Millisecond
+
+// Main runs the tests in m under the offscreen driver, with gi initialized
+// and dialogs configured to open within their parent window instead of in a
+// separate window, so that they are driven by the same Harness.  Call this
+// from TestMain -- it does not return.
+func Main(m *testing.M) {
+	code := 0
+	offscreen.Main(func(app oswin.App) {
+		gi.Init()
+		gi.DialogsSepWindow = false
+		code = m.Run()
+	})
+	os.Exit(code)
+}
+
+// Harness drives a gi.Window for testing, by sending it events and
+// waiting for the resulting updates to be rendered.
+type Harness struct {
+
+	// T is the test that errors are reported to
+	T testing.TB
+
+	// Win is the window being driven
+	Win *gi.Window
+
+	// MousePos is the current position of the (virtual) mouse
+	MousePos image.Point
+
+	// Mods are the modifier key bits to apply to all mouse and key events
+	Mods int32
+
+	// Timeout is the maximum time to wait for the window to become idle
+	Timeout time.Duration
+
+	// GoldenDir is the directory for golden images -- defaults to
+	// DefaultGoldenDir
+	GoldenDir string
+
+	// Tolerance is the maximum per-channel color difference (0-255) that is
+	// allowed for pixels to be considered the same in golden image comparisons
+	Tolerance uint8
+
+	// MaxDiffFrac is the maximum fraction of pixels that can differ (beyond
+	// Tolerance) before a golden image comparison fails
+	MaxDiffFrac float64
+
+	// pressed is the currently pressed mouse button, for generating drags
+	pressed mouse.Buttons
+}
+
+// NewHarness returns a new Harness for given window, which is sent the
+// initial focus and paint events that the OS would send when the window is
+// opened, so it is fully rendered and ready to receive events on return.
+func NewHarness(t testing.TB, win *gi.Window) *Harness {
+	h := &Harness{T: t, Win: win, Timeout: IdleTimeout, GoldenDir: DefaultGoldenDir, Tolerance: DefaultTolerance, MaxDiffFrac: DefaultMaxDiffFrac}
+	h.MousePos = image.Point{-1, -1}
+	win.SetFlag(int(gi.WinFlagDoFullRender))
+	h.WindowEvent(window.Focus)
+	h.WindowEvent(window.Paint)
+	return h
+}
+
+// Close closes the window, processing the resulting events
+func (h *Harness) Close() {
+	if h.Win.IsClosed() {
+		return
+	}
+	h.Win.OSWin.Close()
+	h.Flush()
+}
+
+// Send sends given event to the window via ProcessEvent, and then waits
+// for the window to become idle.  The event is Init()'d first.
+func (h *Harness) Send(ev oswin.Event) {
+	h.T.Helper()
+	ev.Init()
+	h.Win.ProcessEvent(ev)
+	h.WaitIdle()
+}
+
+// Flush processes any events that are pending in the window's event queue,
+// e.g., those generated by the driver in response to window actions, or
+// sent by widgets.
+func (h *Harness) Flush() {
+	for {
+		ev, has := h.Win.OSWin.PollEvent()
+		if !has {
+			return
+		}
+		h.Win.ProcessEvent(ev)
+		if h.Win.IsClosed() {
+			return
+		}
+	}
+}
+
+// IsIdle returns true if there are no pending updates in the window
+// viewport or the current popup, and the window is not updating.
+func (h *Harness) IsIdle() bool {
+	if h.Win.IsWinUpdating() || !vpIsIdle(h.Win.Viewport) {
+		return false
+	}
+	if pop := h.Win.CurPopup(); pop != nil {
+		if pni, ok := pop.(gi.Node2D); ok {
+			if !vpIsIdle(pni.AsViewport2D()) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// vpIsIdle returns true if viewport has no pending updates
+func vpIsIdle(vp *gi.Viewport2D) bool {
+	if vp == nil {
+		return true
+	}
+	if vp.IsUpdatingNode() || vp.NeedsFullRender() || vp.IsDoingFullRender() {
+		return false
+	}
+	vp.StackMu.Lock()
+	pend := len(vp.ReStack) + len(vp.UpdtStack)
+	vp.StackMu.Unlock()
+	return pend == 0
+}
+
+// WaitIdle processes pending events and waits until the window has been
+// idle for at least IdlePoll time, reporting an error if that does not
+// happen within the Timeout.
+func (h *Harness) WaitIdle() {
+	h.T.Helper()
+	start := time.Now()
+	nidle := 0
+	for {
+		h.Flush()
+		if h.Win.IsClosed() {
+			return
+		}
+		if h.IsIdle() {
+			nidle++
+			if nidle >= 2 {
+				return
+			}
+		} else {
+			nidle = 0
+		}
+		if time.Since(start) > h.Timeout {
+			h.T.Errorf("gitest: window %v did not become idle within %v", h.Win.Nm, h.Timeout)
+			return
+		}
+		time.Sleep(IdlePoll)
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////
+//  Window events
+
+// WindowEvent sends a window.Event with given action
+func (h *Harness) WindowEvent(act window.Actions) {
+	h.T.Helper()
+	h.Send(&window.Event{Action: act})
+}
+
+// Resize resizes the window to given size in raw pixels, and processes
+// the resulting resize event.
+func (h *Harness) Resize(sz image.Point) {
+	h.T.Helper()
+	h.Win.SetPixSize(sz)
+	h.WaitIdle()
+}
+
+////////////////////////////////////////////////////////////////////////////
+//  Mouse events
+
+// MoveTo moves the mouse to given window position, sending a MoveEvent, or
+// a DragEvent if a button is currently pressed.
+func (h *Harness) MoveTo(pos image.Point) {
+	h.T.Helper()
+	from := h.MousePos
+	h.MousePos = pos
+	if h.pressed != mouse.NoButton {
+		h.Send(&mouse.DragEvent{
+			MoveEvent: mouse.MoveEvent{
+				Event: mouse.Event{Where: pos, Button: h.pressed, Action: mouse.Drag, Modifiers: h.Mods},
+				From:  from,
+			},
+		})
+		return
+	}
+	h.Send(&mouse.MoveEvent{
+		Event: mouse.Event{Where: pos, Button: mouse.NoButton, Action: mouse.Move, Modifiers: h.Mods},
+		From:  from,
+	})
+}
+
+// Press presses given mouse button at the current mouse position
+func (h *Harness) Press(but mouse.Buttons) {
+	h.T.Helper()
+	h.pressed = but
+	h.Send(&mouse.Event{Where: h.MousePos, Button: but, Action: mouse.Press, Modifiers: h.Mods})
+}
+
+// Release releases given mouse button at the current mouse position
+func (h *Harness) Release(but mouse.Buttons) {
+	h.T.Helper()
+	h.pressed = mouse.NoButton
+	h.Send(&mouse.Event{Where: h.MousePos, Button: but, Action: mouse.Release, Modifiers: h.Mods})
+}
+
+// ClickAt moves to given position and clicks the left mouse button there
+func (h *Harness) ClickAt(pos image.Point) {
+	h.T.Helper()
+	h.MoveTo(pos)
+	h.Press(mouse.Left)
+	h.Release(mouse.Left)
+}
+
+// DoubleClickAt moves to given position and double-clicks the left mouse
+// button there
+func (h *Harness) DoubleClickAt(pos image.Point) {
+	h.T.Helper()
+	h.ClickAt(pos)
+	h.Send(&mouse.Event{Where: pos, Button: mouse.Left, Action: mouse.DoubleClick, Modifiers: h.Mods})
+	h.Release(mouse.Left)
+}
+
+// RightClickAt moves to given position and clicks the right mouse button
+// there, which typically brings up a context menu
+func (h *Harness) RightClickAt(pos image.Point) {
+	h.T.Helper()
+	h.MoveTo(pos)
+	h.Press(mouse.Right)
+	h.Release(mouse.Right)
+}
+
+// DragTo drags with the left mouse button from the current position to
+// given position, in given number of intermediate steps (minimum 1).
+func (h *Harness) DragTo(pos image.Point, steps int) {
+	h.T.Helper()
+	if steps < 1 {
+		steps = 1
+	}
+	st := h.MousePos
+	h.Press(mouse.Left)
+	for i := 1; i <= steps; i++ {
+		p := image.Point{st.X + (pos.X-st.X)*i/steps, st.Y + (pos.Y-st.Y)*i/steps}
+		h.MoveTo(p)
+	}
+	h.Release(mouse.Left)
+}
+
+// Scroll sends a scroll event at the current mouse position with given delta
+func (h *Harness) Scroll(delta image.Point) {
+	h.T.Helper()
+	h.Send(&mouse.ScrollEvent{
+		Event: mouse.Event{Where: h.MousePos, Action: mouse.Scroll, Modifiers: h.Mods},
+		Delta: delta,
+	})
+}
+
+// Click clicks the left mouse button in the center of given widget
+func (h *Harness) Click(k ki.Ki) {
+	h.T.Helper()
+	h.ClickAt(h.Center(k))
+}
+
+// DoubleClick double-clicks the left mouse button in the center of given widget
+func (h *Harness) DoubleClick(k ki.Ki) {
+	h.T.Helper()
+	h.DoubleClickAt(h.Center(k))
+}
+
+// RightClick clicks the right mouse button in the center of given widget
+func (h *Harness) RightClick(k ki.Ki) {
+	h.T.Helper()
+	h.RightClickAt(h.Center(k))
+}
+
+// Hover moves the mouse to the center of given widget
+func (h *Harness) Hover(k ki.Ki) {
+	h.T.Helper()
+	h.MoveTo(h.Center(k))
+}
+
+// Center returns the center of given widget in window coordinates --
+// reports an error and returns the current mouse position if it is not
+// a visible Node2D.
+func (h *Harness) Center(k ki.Ki) image.Point {
+	h.T.Helper()
+	if k == nil || k.This() == nil {
+		h.T.Errorf("gitest: nil widget")
+		return h.MousePos
+	}
+	nii, ok := k.(gi.Node2D)
+	if !ok {
+		h.T.Errorf("gitest: %v is not a Node2D", k)
+		return h.MousePos
+	}
+	bb := nii.AsNode2D().WinBBox
+	if bb.Empty() {
+		h.T.Errorf("gitest: %v is not visible (empty WinBBox)", k.PathUnique())
+		return h.MousePos
+	}
+	return image.Point{(bb.Min.X + bb.Max.X) / 2, (bb.Min.Y + bb.Max.Y) / 2}
+}
+
+////////////////////////////////////////////////////////////////////////////
+//  Key events
+
+// Chord sends a key.ChordEvent for given chord, e.g., "Control+S",
+// "ReturnEnter" or "a" -- the Mods are added to those in the chord.
+// The chord must be one rune or the name of a key.Codes without the
+// Code prefix.
+func (h *Harness) Chord(ch key.Chord) {
+	h.T.Helper()
+	ev, err := chordEvent(ch, h.Mods)
+	if err != nil {
+		h.T.Errorf("gitest: %v", err)
+		return
+	}
+	h.Send(ev)
+}
+
+// chordEvent returns the key.ChordEvent for given chord, with given mods
+// added -- see Chord.
+func chordEvent(ch key.Chord, mods int32) (*key.ChordEvent, error) {
+	chmods, rest := key.ModsFmString(string(ch))
+	ev := &key.ChordEvent{Event: key.Event{Modifiers: chmods | mods, Action: key.Press}}
+	rs := []rune(rest)
+	if len(rs) == 1 {
+		ev.Rune = rs[0]
+		// codes are named by the upper case letter, e.g., CodeA for a or A
+		if c, ok := codeByName(string(unicode.ToUpper(rs[0]))); ok {
+			ev.Code = c
+		}
+		return ev, nil
+	}
+	c, ok := codeByName(rest)
+	if !ok {
+		return nil, fmt.Errorf("key chord %v has unknown key: %v", ch, rest)
+	}
+	ev.Code = c
+	ev.Rune = -1
+	return ev, nil
+}
+
+// KeyFun sends the key chord that maps to given key function in the active
+// keymap -- if there are several, the shortest is used, and of those the
+// first in sort order, so that the same chord is always sent.
+func (h *Harness) KeyFun(kf gi.KeyFuns) {
+	h.T.Helper()
+	ch := chordForFun(gi.ActiveKeyMap, kf)
+	if ch == "" {
+		h.T.Errorf("gitest: no key chord found for key function: %v", kf)
+		return
+	}
+	h.Chord(ch)
+}
+
+// chordForFun returns the key chord for given key function in given keymap
+// -- see KeyFun -- or "" if there is none.
+func chordForFun(km *gi.KeyMap, kf gi.KeyFuns) key.Chord {
+	var chs []string
+	for ch, f := range *km {
+		if f == kf {
+			chs = append(chs, string(ch))
+		}
+	}
+	if len(chs) == 0 {
+		return ""
+	}
+	sort.Slice(chs, func(i, j int) bool {
+		if len(chs[i]) != len(chs[j]) {
+			return len(chs[i]) < len(chs[j])
+		}
+		return chs[i] < chs[j]
+	})
+	return key.Chord(chs[0])
+}
+
+// Type sends a key.ChordEvent for each rune in given text, as if typed
+// on the keyboard -- use Chord for special keys.
+func (h *Harness) Type(text string) {
+	h.T.Helper()
+	for _, r := range text {
+		ev := &key.ChordEvent{Event: key.Event{Rune: r, Modifiers: h.Mods, Action: key.Press}}
+		h.Send(ev)
+	}
+}
+
+// codeNames maps key code names without the Code prefix to codes
+var codeNames map[string]key.Codes
+
+// codeByName returns the key code for given name (without Code prefix)
+func codeByName(nm string) (key.Codes, bool) {
+	if codeNames == nil {
+		codeNames = make(map[string]key.Codes)
+		for c := key.CodeUnknown; c <= key.CodeRightMeta; c++ {
+			cs := c.String()
+			if len(cs) > 4 && cs[:4] == "Code" {
+				codeNames[cs[4:]] = c
+			}
+		}
+		codeNames["Compose"] = key.CodeCompose
+	}
+	c, ok := codeNames[nm]
+	return c, ok
+}
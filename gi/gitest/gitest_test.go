@@ -0,0 +1,211 @@
+// Copyright (c) 2020, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gitest
+
+import (
+	"fmt"
+	"image"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/goki/gi/gi"
+	_ "github.com/goki/gi/giv" // view interface needed by gi.Init
+	"github.com/goki/gi/oswin"
+	"github.com/goki/gi/oswin/driver/offscreen"
+	"github.com/goki/gi/oswin/key"
+	_ "github.com/goki/gi/svg" // icons
+)
+
+func TestMain(m *testing.M) {
+	Main(m)
+}
+
+// recordTB is a testing.TB that records errors instead of reporting them
+type recordTB struct {
+	testing.TB
+	errs []string
+}
+
+func (r *recordTB) Errorf(format string, args ...interface{}) {
+	r.errs = append(r.errs, fmt.Sprintf(format, args...))
+}
+
+// newTestHarness returns a Harness for a new small window with a label and
+// a text field
+func newTestHarness(t *testing.T) (*Harness, *gi.TextField) {
+	win := gi.NewMainWindow("gitest", "GiTest", 240, 120)
+	vp := win.WinViewport2D()
+	updt := vp.UpdateStart()
+	mfr := win.SetMainFrame()
+	gi.AddNewLabel(mfr, "label", "Golden label")
+	tf := gi.AddNewTextField(mfr, "tf")
+	tf.SetStretchMaxWidth()
+	vp.UpdateEndNoSig(updt)
+	return NewHarness(t, win), tf
+}
+
+func TestMainDriver(t *testing.T) {
+	if oswin.TheApp == nil || oswin.TheApp.NScreens() == 0 {
+		t.Fatalf("Main did not start the offscreen driver")
+	}
+	if gi.DialogsSepWindow {
+		t.Errorf("Main did not set dialogs to open within their parent window")
+	}
+}
+
+func TestNewHarness(t *testing.T) {
+	h, tf := newTestHarness(t)
+	defer h.Close()
+	if !h.IsIdle() {
+		t.Errorf("window is not idle after NewHarness")
+	}
+	if !h.Win.OSWin.IsFocus() {
+		t.Errorf("window is not focused after NewHarness")
+	}
+	if offscreen.PublishCount(h.Win.OSWin) == 0 {
+		t.Errorf("window was not rendered by NewHarness")
+	}
+	if tf.WinBBox.Empty() {
+		t.Errorf("text field was not laid out by NewHarness")
+	}
+	if h.ByLabel("Golden label") == nil {
+		t.Errorf("label not found")
+	}
+	h.Close()
+	if !h.Win.IsClosed() {
+		t.Errorf("window is not closed after Close")
+	}
+}
+
+func TestWaitIdle(t *testing.T) {
+	h, tf := newTestHarness(t)
+	defer h.Close()
+	tf.SetText("changed")
+	h.WaitIdle()
+	if !h.IsIdle() {
+		t.Errorf("window is not idle after WaitIdle")
+	}
+
+	rt := &recordTB{TB: t}
+	h.T = rt
+	h.Timeout = 50 * time.Millisecond
+	h.Win.Viewport.SetFlag(int(gi.VpFlagNeedsFullRender))
+	st := time.Now()
+	h.WaitIdle()
+	h.Win.Viewport.ClearFlag(int(gi.VpFlagNeedsFullRender))
+	h.T = t
+	if len(rt.errs) != 1 {
+		t.Errorf("WaitIdle on a busy window: errors %v, want one timeout error", rt.errs)
+	}
+	if el := time.Since(st); el > time.Second {
+		t.Errorf("WaitIdle on a busy window took %v, with a Timeout of %v", el, h.Timeout)
+	}
+}
+
+func TestChordEvent(t *testing.T) {
+	tests := []struct {
+		ch   key.Chord
+		mods int32
+		rn   rune
+		code key.Codes
+	}{
+		{"a", 0, 'a', key.CodeA},
+		{"A", 0, 'A', key.CodeA},
+		{"1", 0, '1', key.Code1},
+		{"/", 0, '/', key.CodeUnknown},
+		{"Control+s", 1 << uint32(key.Control), 's', key.CodeS},
+		{"ReturnEnter", 0, -1, key.CodeReturnEnter},
+		{"Shift+LeftArrow", 1 << uint32(key.Shift), -1, key.CodeLeftArrow},
+	}
+	for _, tst := range tests {
+		ev, err := chordEvent(tst.ch, 0)
+		if err != nil {
+			t.Errorf("%v: %v", tst.ch, err)
+			continue
+		}
+		if ev.Rune != tst.rn || ev.Code != tst.code || ev.Modifiers != tst.mods {
+			t.Errorf("%v: rune %q code %v mods %v, want %q %v %v", tst.ch, ev.Rune, ev.Code, ev.Modifiers, tst.rn, tst.code, tst.mods)
+		}
+	}
+	ev, _ := chordEvent("b", 1<<uint32(key.Alt))
+	if ev.Modifiers != 1<<uint32(key.Alt) {
+		t.Errorf("mods not added: %v", ev.Modifiers)
+	}
+	if _, err := chordEvent("Control+NoSuchKey", 0); err == nil {
+		t.Errorf("no error for unknown key")
+	}
+}
+
+func TestChordForFun(t *testing.T) {
+	km := &gi.KeyMap{
+		"Control+b":       gi.KeyFunMoveLeft,
+		"LeftArrow":       gi.KeyFunMoveLeft,
+		"Meta+b":          gi.KeyFunMoveLeft,
+		"Alt+b":           gi.KeyFunMoveLeft,
+		"Shift+LeftArrow": gi.KeyFunMoveLeft,
+		"RightArrow":      gi.KeyFunMoveRight,
+	}
+	for i := 0; i < 20; i++ { // map order varies
+		if ch := chordForFun(km, gi.KeyFunMoveLeft); ch != "Alt+b" {
+			t.Fatalf("chord for MoveLeft: %v != Alt+b", ch)
+		}
+	}
+	if ch := chordForFun(km, gi.KeyFunMoveUp); ch != "" {
+		t.Errorf("chord for unmapped MoveUp: %v", ch)
+	}
+}
+
+func TestChordType(t *testing.T) {
+	h, tf := newTestHarness(t)
+	defer h.Close()
+	h.Click(tf)
+	if h.Focus() != tf.This() {
+		t.Fatalf("text field not focused after Click: %v", h.Focus())
+	}
+	h.Chord("h")
+	h.Type("ix")
+	h.KeyFun(gi.KeyFunBackspace)
+	if got := tf.EditTxt; string(got) != "hi" {
+		t.Errorf("text after typing: %q != hi", string(got))
+	}
+}
+
+func TestExpectGolden(t *testing.T) {
+	h, _ := newTestHarness(t)
+	defer h.Close()
+	h.ExpectGolden("label")
+	if UpdateGolden {
+		return
+	}
+
+	// a different render fails, and is saved with the differences
+	dir, err := ioutil.TempDir("", "gitest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	gold, err := ioutil.ReadFile(filepath.Join(h.GoldenDir, "label.png"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ioutil.WriteFile(filepath.Join(dir, "label.png"), gold, 0644)
+	rt := &recordTB{TB: t}
+	h.T = rt
+	h.GoldenDir = dir
+	img := image.NewRGBA(h.Snapshot().Bounds())
+	h.ExpectGoldenImage("label", img)
+	h.T = t
+	if len(rt.errs) != 1 {
+		t.Errorf("blank render: errors %v, want one difference error", rt.errs)
+	}
+	for _, fn := range []string{"label.got.png", "label.diff.png"} {
+		if _, err := os.Stat(filepath.Join(dir, fn)); err != nil {
+			t.Errorf("%v not saved: %v", fn, err)
+		}
+	}
+}
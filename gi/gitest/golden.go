@@ -0,0 +1,159 @@
+// Copyright (c) 2020, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gitest
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+
+	"github.com/goki/gi/gi"
+)
+
+// DefaultGoldenDir is the default directory for golden images, relative
+// to the package being tested.
+var DefaultGoldenDir = "testdata"
+
+// DefaultTolerance is the default maximum per-channel color difference
+// for pixels to be considered the same -- a small amount of tolerance
+// allows for minor differences in font rendering and anti-aliasing.
+var DefaultTolerance = uint8(8)
+
+// DefaultMaxDiffFrac is the default maximum fraction of pixels that can
+// differ beyond the Tolerance before a golden comparison fails.
+var DefaultMaxDiffFrac = 0.001
+
+// UpdateGolden causes golden images to be written from the current renders
+// instead of compared against.  It is also set if the GITEST_UPDATE
+// environment variable is non-empty.
+var UpdateGolden = os.Getenv("GITEST_UPDATE") != ""
+
+// Snapshot returns the current render of the window viewport, via
+// Viewport2D.EncodePNG -- the window is waited on until idle first.
+func (h *Harness) Snapshot() image.Image {
+	h.T.Helper()
+	h.WaitIdle()
+	return h.SnapshotVp(h.Win.Viewport)
+}
+
+// SnapshotVp returns the current render of given viewport (e.g., a popup
+// or dialog), via Viewport2D.EncodePNG.
+func (h *Harness) SnapshotVp(vp *gi.Viewport2D) image.Image {
+	h.T.Helper()
+	var b bytes.Buffer
+	if err := vp.EncodePNG(&b); err != nil {
+		h.T.Fatalf("gitest: could not encode viewport %v: %v", vp.Nm, err)
+	}
+	img, err := png.Decode(&b)
+	if err != nil {
+		h.T.Fatalf("gitest: could not decode viewport %v: %v", vp.Nm, err)
+	}
+	return img
+}
+
+// ExpectGolden compares the current render of the window to the golden
+// image of given name (GoldenDir/name.png), reporting an error if they
+// differ by more than Tolerance in more than MaxDiffFrac of pixels.  On
+// failure, the current render is saved as name.got.png and an image
+// highlighting the differences in red as name.diff.png.  If UpdateGolden
+// is set, the golden image is written instead.
+func (h *Harness) ExpectGolden(name string) {
+	h.T.Helper()
+	h.ExpectGoldenImage(name, h.Snapshot())
+}
+
+// ExpectGoldenVp is ExpectGolden for given viewport, e.g., a dialog
+func (h *Harness) ExpectGoldenVp(name string, vp *gi.Viewport2D) {
+	h.T.Helper()
+	h.WaitIdle()
+	h.ExpectGoldenImage(name, h.SnapshotVp(vp))
+}
+
+// ExpectGoldenImage compares given image to the golden image of given
+// name -- see ExpectGolden.
+func (h *Harness) ExpectGoldenImage(name string, got image.Image) {
+	h.T.Helper()
+	fnm := filepath.Join(h.GoldenDir, name+".png")
+	if UpdateGolden {
+		os.MkdirAll(h.GoldenDir, 0755)
+		if err := gi.SavePNG(fnm, got); err != nil {
+			h.T.Errorf("gitest: could not save golden image: %v", err)
+		}
+		return
+	}
+	want, err := gi.OpenImage(fnm)
+	if err != nil {
+		h.T.Errorf("gitest: could not open golden image (set GITEST_UPDATE=1 to create): %v", err)
+		return
+	}
+	ndiff, diff := CompareImages(got, want, h.Tolerance)
+	sz := got.Bounds().Size()
+	npix := sz.X * sz.Y
+	if ndiff == 0 || (npix > 0 && float64(ndiff)/float64(npix) <= h.MaxDiffFrac && got.Bounds().Size() == want.Bounds().Size()) {
+		return
+	}
+	gotfn := filepath.Join(h.GoldenDir, name+".got.png")
+	difffn := filepath.Join(h.GoldenDir, name+".diff.png")
+	gi.SavePNG(gotfn, got)
+	gi.SavePNG(difffn, diff)
+	if got.Bounds().Size() != want.Bounds().Size() {
+		h.T.Errorf("gitest: render size %v != golden %v size %v -- see %v", got.Bounds().Size(), fnm, want.Bounds().Size(), gotfn)
+		return
+	}
+	h.T.Errorf("gitest: render differs from golden %v in %d of %d pixels -- see %v and %v", fnm, ndiff, npix, gotfn, difffn)
+}
+
+// CompareImages compares two images pixel-by-pixel, returning the number of
+// pixels where any color channel differs by more than tol, and an image
+// showing the differing pixels in red on top of a faded version of got.
+// If the images are different sizes, all pixels outside of the common
+// region are counted as different.
+func CompareImages(got, want image.Image, tol uint8) (int, *image.RGBA) {
+	gb := got.Bounds()
+	wb := want.Bounds()
+	sz := gb.Size()
+	wsz := wb.Size()
+	if wsz.X > sz.X {
+		sz.X = wsz.X
+	}
+	if wsz.Y > sz.Y {
+		sz.Y = wsz.Y
+	}
+	diff := image.NewRGBA(image.Rectangle{Max: sz})
+	red := color.RGBA{255, 0, 0, 255}
+	ndiff := 0
+	for y := 0; y < sz.Y; y++ {
+		for x := 0; x < sz.X; x++ {
+			gp := image.Point{gb.Min.X + x, gb.Min.Y + y}
+			wp := image.Point{wb.Min.X + x, wb.Min.Y + y}
+			if !gp.In(gb) || !wp.In(wb) {
+				ndiff++
+				diff.SetRGBA(x, y, red)
+				continue
+			}
+			gc := color.RGBAModel.Convert(got.At(gp.X, gp.Y)).(color.RGBA)
+			wc := color.RGBAModel.Convert(want.At(wp.X, wp.Y)).(color.RGBA)
+			if chanDiff(gc.R, wc.R) > tol || chanDiff(gc.G, wc.G) > tol || chanDiff(gc.B, wc.B) > tol || chanDiff(gc.A, wc.A) > tol {
+				ndiff++
+				diff.SetRGBA(x, y, red)
+				continue
+			}
+			// faded version of matching pixel, for context
+			diff.SetRGBA(x, y, color.RGBA{gc.R/4 + 191, gc.G/4 + 191, gc.B/4 + 191, 255})
+		}
+	}
+	return ndiff, diff
+}
+
+// chanDiff returns the absolute difference between two color channel values
+func chanDiff(a, b uint8) uint8 {
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
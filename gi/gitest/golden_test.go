@@ -0,0 +1,39 @@
+// Copyright (c) 2020, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gitest
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestCompareImages(t *testing.T) {
+	a := image.NewRGBA(image.Rect(0, 0, 10, 10))
+	b := image.NewRGBA(image.Rect(0, 0, 10, 10))
+	for i := range a.Pix {
+		a.Pix[i] = 100
+		b.Pix[i] = 104
+	}
+	if nd, _ := CompareImages(a, b, 8); nd != 0 {
+		t.Errorf("within tolerance: got %d diffs, want 0", nd)
+	}
+	if nd, _ := CompareImages(a, b, 2); nd != 100 {
+		t.Errorf("beyond tolerance: got %d diffs, want 100", nd)
+	}
+	b.SetRGBA(3, 4, color.RGBA{255, 255, 255, 255})
+	nd, diff := CompareImages(a, b, 8)
+	if nd != 1 {
+		t.Errorf("one pixel: got %d diffs, want 1", nd)
+	}
+	if diff.RGBAAt(3, 4) != (color.RGBA{255, 0, 0, 255}) {
+		t.Errorf("diff image not red at differing pixel: %v", diff.RGBAAt(3, 4))
+	}
+	c := image.NewRGBA(image.Rect(0, 0, 10, 12))
+	copy(c.Pix, a.Pix)
+	if nd, _ := CompareImages(a, c, 8); nd != 20 {
+		t.Errorf("size mismatch: got %d diffs, want 20", nd)
+	}
+}
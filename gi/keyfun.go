@@ -79,6 +79,11 @@ const (
 	KeyFunFind   // Command+F full-dialog find
 	KeyFunReplace
 	KeyFunJump // jump to line
+	KeyFunFold // toggle code folding at cursor
+	KeyFunFoldAll
+	KeyFunUnfoldAll
+	KeyFunMultiCursorLines // add cursor per selected line, or on next line
+	KeyFunMultiCursorNext  // add cursor at next occurrence of selection
 	KeyFunHistPrev
 	KeyFunHistNext
 	KeyFunMenu // put focus on menu
@@ -584,6 +589,11 @@ var StdKeyMaps = KeyMaps{
 		"Meta+F":                  KeyFunFind,
 		"Meta+R":                  KeyFunReplace,
 		"Control+J":               KeyFunJump,
+		"Control+Alt+F":           KeyFunFold,
+		"Control+Alt+-":           KeyFunFoldAll,
+		"Control+Alt+=":           KeyFunUnfoldAll,
+		"Control+Alt+L":           KeyFunMultiCursorLines,
+		"Control+Alt+D":           KeyFunMultiCursorNext,
 		"Control+[":               KeyFunHistPrev,
 		"Control+]":               KeyFunHistNext,
 		"Meta+[":                  KeyFunHistPrev,
@@ -716,6 +726,11 @@ var StdKeyMaps = KeyMaps{
 		"Meta+R":                  KeyFunReplace,
 		"Control+R":               KeyFunReplace,
 		"Control+J":               KeyFunJump,
+		"Control+Alt+F":           KeyFunFold,
+		"Control+Alt+-":           KeyFunFoldAll,
+		"Control+Alt+=":           KeyFunUnfoldAll,
+		"Control+Alt+L":           KeyFunMultiCursorLines,
+		"Control+Alt+D":           KeyFunMultiCursorNext,
 		"Control+[":               KeyFunHistPrev,
 		"Control+]":               KeyFunHistNext,
 		"Meta+[":                  KeyFunHistPrev,
@@ -835,6 +850,11 @@ var StdKeyMaps = KeyMaps{
 		"Alt+F":                   KeyFunFind,
 		"Control+R":               KeyFunReplace,
 		"Control+J":               KeyFunJump,
+		"Control+Alt+F":           KeyFunFold,
+		"Control+Alt+-":           KeyFunFoldAll,
+		"Control+Alt+=":           KeyFunUnfoldAll,
+		"Control+Alt+L":           KeyFunMultiCursorLines,
+		"Control+Alt+D":           KeyFunMultiCursorNext,
 		"Control+[":               KeyFunHistPrev,
 		"Control+]":               KeyFunHistNext,
 		"F10":                     KeyFunMenu,
@@ -922,6 +942,11 @@ var StdKeyMaps = KeyMaps{
 		"Control+H":               KeyFunReplace,
 		"Control+R":               KeyFunReplace,
 		"Control+J":               KeyFunJump,
+		"Control+Alt+F":           KeyFunFold,
+		"Control+Alt+-":           KeyFunFoldAll,
+		"Control+Alt+=":           KeyFunUnfoldAll,
+		"Control+Alt+L":           KeyFunMultiCursorLines,
+		"Control+Alt+D":           KeyFunMultiCursorNext,
 		"Control+[":               KeyFunHistPrev,
 		"Control+]":               KeyFunHistNext,
 		"Control+N":               KeyFunMenuNew,
@@ -1008,6 +1033,11 @@ var StdKeyMaps = KeyMaps{
 		"Control+H":               KeyFunReplace,
 		"Control+R":               KeyFunReplace,
 		"Control+J":               KeyFunJump,
+		"Control+Alt+F":           KeyFunFold,
+		"Control+Alt+-":           KeyFunFoldAll,
+		"Control+Alt+=":           KeyFunUnfoldAll,
+		"Control+Alt+L":           KeyFunMultiCursorLines,
+		"Control+Alt+D":           KeyFunMultiCursorNext,
 		"Control+[":               KeyFunHistPrev,
 		"Control+]":               KeyFunHistNext,
 		"F10":                     KeyFunMenu,
@@ -1094,6 +1124,11 @@ var StdKeyMaps = KeyMaps{
 		"Control+H":               KeyFunReplace,
 		"Control+R":               KeyFunReplace,
 		"Control+J":               KeyFunJump,
+		"Control+Alt+F":           KeyFunFold,
+		"Control+Alt+-":           KeyFunFoldAll,
+		"Control+Alt+=":           KeyFunUnfoldAll,
+		"Control+Alt+L":           KeyFunMultiCursorLines,
+		"Control+Alt+D":           KeyFunMultiCursorNext,
 		"Control+[":               KeyFunHistPrev,
 		"Control+]":               KeyFunHistNext,
 		"F10":                     KeyFunMenu,
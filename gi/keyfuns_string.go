@@ -59,30 +59,35 @@ func _() {
 	_ = x[KeyFunFind-43]
 	_ = x[KeyFunReplace-44]
 	_ = x[KeyFunJump-45]
-	_ = x[KeyFunHistPrev-46]
-	_ = x[KeyFunHistNext-47]
-	_ = x[KeyFunMenu-48]
-	_ = x[KeyFunWinFocusNext-49]
-	_ = x[KeyFunWinClose-50]
-	_ = x[KeyFunWinSnapshot-51]
-	_ = x[KeyFunGoGiEditor-52]
-	_ = x[KeyFunMenuNew-53]
-	_ = x[KeyFunMenuNewAlt1-54]
-	_ = x[KeyFunMenuNewAlt2-55]
-	_ = x[KeyFunMenuOpen-56]
-	_ = x[KeyFunMenuOpenAlt1-57]
-	_ = x[KeyFunMenuOpenAlt2-58]
-	_ = x[KeyFunMenuSave-59]
-	_ = x[KeyFunMenuSaveAs-60]
-	_ = x[KeyFunMenuSaveAlt-61]
-	_ = x[KeyFunMenuCloseAlt1-62]
-	_ = x[KeyFunMenuCloseAlt2-63]
-	_ = x[KeyFunsN-64]
+	_ = x[KeyFunFold-46]
+	_ = x[KeyFunFoldAll-47]
+	_ = x[KeyFunUnfoldAll-48]
+	_ = x[KeyFunMultiCursorLines-49]
+	_ = x[KeyFunMultiCursorNext-50]
+	_ = x[KeyFunHistPrev-51]
+	_ = x[KeyFunHistNext-52]
+	_ = x[KeyFunMenu-53]
+	_ = x[KeyFunWinFocusNext-54]
+	_ = x[KeyFunWinClose-55]
+	_ = x[KeyFunWinSnapshot-56]
+	_ = x[KeyFunGoGiEditor-57]
+	_ = x[KeyFunMenuNew-58]
+	_ = x[KeyFunMenuNewAlt1-59]
+	_ = x[KeyFunMenuNewAlt2-60]
+	_ = x[KeyFunMenuOpen-61]
+	_ = x[KeyFunMenuOpenAlt1-62]
+	_ = x[KeyFunMenuOpenAlt2-63]
+	_ = x[KeyFunMenuSave-64]
+	_ = x[KeyFunMenuSaveAs-65]
+	_ = x[KeyFunMenuSaveAlt-66]
+	_ = x[KeyFunMenuCloseAlt1-67]
+	_ = x[KeyFunMenuCloseAlt2-68]
+	_ = x[KeyFunsN-69]
 }
 
-const _KeyFuns_name = "KeyFunNilKeyFunMoveUpKeyFunMoveDownKeyFunMoveRightKeyFunMoveLeftKeyFunPageUpKeyFunPageDownKeyFunHomeKeyFunEndKeyFunDocHomeKeyFunDocEndKeyFunWordRightKeyFunWordLeftKeyFunFocusNextKeyFunFocusPrevKeyFunEnterKeyFunAcceptKeyFunCancelSelectKeyFunSelectModeKeyFunSelectAllKeyFunAbortKeyFunCopyKeyFunCutKeyFunPasteKeyFunPasteHistKeyFunBackspaceKeyFunBackspaceWordKeyFunDeleteKeyFunDeleteWordKeyFunKillKeyFunDuplicateKeyFunUndoKeyFunRedoKeyFunInsertKeyFunInsertAfterKeyFunZoomOutKeyFunZoomInKeyFunPrefsKeyFunRefreshKeyFunRecenterKeyFunCompleteKeyFunLookupKeyFunSearchKeyFunFindKeyFunReplaceKeyFunJumpKeyFunHistPrevKeyFunHistNextKeyFunMenuKeyFunWinFocusNextKeyFunWinCloseKeyFunWinSnapshotKeyFunGoGiEditorKeyFunMenuNewKeyFunMenuNewAlt1KeyFunMenuNewAlt2KeyFunMenuOpenKeyFunMenuOpenAlt1KeyFunMenuOpenAlt2KeyFunMenuSaveKeyFunMenuSaveAsKeyFunMenuSaveAltKeyFunMenuCloseAlt1KeyFunMenuCloseAlt2KeyFunsN"
+const _KeyFuns_name = "KeyFunNilKeyFunMoveUpKeyFunMoveDownKeyFunMoveRightKeyFunMoveLeftKeyFunPageUpKeyFunPageDownKeyFunHomeKeyFunEndKeyFunDocHomeKeyFunDocEndKeyFunWordRightKeyFunWordLeftKeyFunFocusNextKeyFunFocusPrevKeyFunEnterKeyFunAcceptKeyFunCancelSelectKeyFunSelectModeKeyFunSelectAllKeyFunAbortKeyFunCopyKeyFunCutKeyFunPasteKeyFunPasteHistKeyFunBackspaceKeyFunBackspaceWordKeyFunDeleteKeyFunDeleteWordKeyFunKillKeyFunDuplicateKeyFunUndoKeyFunRedoKeyFunInsertKeyFunInsertAfterKeyFunZoomOutKeyFunZoomInKeyFunPrefsKeyFunRefreshKeyFunRecenterKeyFunCompleteKeyFunLookupKeyFunSearchKeyFunFindKeyFunReplaceKeyFunJumpKeyFunFoldKeyFunFoldAllKeyFunUnfoldAllKeyFunMultiCursorLinesKeyFunMultiCursorNextKeyFunHistPrevKeyFunHistNextKeyFunMenuKeyFunWinFocusNextKeyFunWinCloseKeyFunWinSnapshotKeyFunGoGiEditorKeyFunMenuNewKeyFunMenuNewAlt1KeyFunMenuNewAlt2KeyFunMenuOpenKeyFunMenuOpenAlt1KeyFunMenuOpenAlt2KeyFunMenuSaveKeyFunMenuSaveAsKeyFunMenuSaveAltKeyFunMenuCloseAlt1KeyFunMenuCloseAlt2"
 
-var _KeyFuns_index = [...]uint16{0, 9, 21, 35, 50, 64, 76, 90, 100, 109, 122, 134, 149, 163, 178, 193, 204, 216, 234, 250, 265, 276, 286, 295, 306, 321, 336, 355, 367, 383, 393, 408, 418, 428, 440, 457, 470, 482, 493, 506, 520, 534, 546, 558, 568, 581, 591, 605, 619, 629, 647, 661, 678, 694, 707, 724, 741, 755, 773, 791, 805, 821, 838, 857, 876, 884}
+var _KeyFuns_index = [...]uint16{0, 9, 21, 35, 50, 64, 76, 90, 100, 109, 122, 134, 149, 163, 178, 193, 204, 216, 234, 250, 265, 276, 286, 295, 306, 321, 336, 355, 367, 383, 393, 408, 418, 428, 440, 457, 470, 482, 493, 506, 520, 534, 546, 558, 568, 581, 591, 601, 614, 629, 651, 672, 686, 700, 710, 728, 742, 759, 775, 788, 805, 822, 836, 854, 872, 886, 902, 919, 938, 957}
 
 func (i KeyFuns) String() string {
 	if i < 0 || i >= KeyFuns(len(_KeyFuns_index)-1) {
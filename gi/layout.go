@@ -28,18 +28,18 @@ import (
 // within a layout -- includes computed values of style prefs -- everything is
 // concrete and specified here, whereas style may not be fully resolved
 type LayoutData struct {
-	Size          SizePrefs  `desc:"size constraints for this item -- from layout style"`
-	AllocSize     mat32.Vec2 `desc:"allocated size of this item, by the parent layout"`
-	AllocPos      mat32.Vec2 `desc:"position of this item, computed by adding in the AllocPosRel to parent position"`
-	AllocPosRel   mat32.Vec2 `desc:"allocated relative position of this item, computed by the parent layout"`
-	AllocSizeOrig mat32.Vec2 `desc:"original copy of allocated size of this item, by the parent layout -- some widgets will resize themselves within a given layout (e.g., a TextView), but still need access to their original allocated size"`
-	AllocPosOrig  mat32.Vec2 `desc:"original copy of allocated relative position of this item, by the parent layout -- need for scrolling which can update AllocPos"`
+	Size          SizePrefs   `desc:"size constraints for this item -- from layout style"`
+	AllocSize     mat32.Vec2  `desc:"allocated size of this item, by the parent layout"`
+	AllocPos      mat32.Vec2  `desc:"position of this item, computed by adding in the AllocPosRel to parent position"`
+	AllocPosRel   mat32.Vec2  `desc:"allocated relative position of this item, computed by the parent layout"`
+	AllocSizeOrig mat32.Vec2  `desc:"original copy of allocated size of this item, by the parent layout -- some widgets will resize themselves within a given layout (e.g., a TextView), but still need access to their original allocated size"`
+	AllocPosOrig  mat32.Vec2  `desc:"original copy of allocated relative position of this item, by the parent layout -- need for scrolling which can update AllocPos"`
+	GridPos       image.Point `desc:"position within a LayoutGridIrreg grid: X = col, Y = row -- computed by the parent layout"`
+	GridSpan      image.Point `desc:"number of grid elements that we take up in each direction within a LayoutGridIrreg grid: X = cols, Y = rows -- computed by the parent layout"`
 }
 
 // todo: not using yet:
 // Margins Margins   `desc:"margins around this item"`
-// GridPos      image.Point `desc:"position within a grid"`
-// GridSpan     image.Point `desc:"number of grid elements that we take up in each direction"`
 
 func (ld *LayoutData) Defaults() {
 }
@@ -84,6 +84,7 @@ type GridData struct {
 	SizeMax     float32
 	AllocSize   float32
 	AllocPosRel float32
+	Fr          float32 `desc:"for LayoutGridIrreg, fraction (fr) of the remaining space that this row or col gets -- if any are > 0, only these stretch"`
 }
 
 ////////////////////////////////////////////////////////////////////////////////////////
@@ -164,9 +165,6 @@ const (
 	// LayoutGrid arranges items according to a regular grid
 	LayoutGrid
 
-	// todo: add LayoutGridIrreg that deals with irregular grids with spans etc -- keep
-	// the basic grid for fully regular cases -- need high performance for large grids
-
 	// LayoutHorizFlow arranges items horizontally across a row, overflowing
 	// vertically as needed
 	LayoutHorizFlow
@@ -184,6 +182,13 @@ const (
 	// parent wants to take over the job of the layout
 	LayoutNil
 
+	// LayoutGridIrreg arranges items according to an irregular grid, where
+	// items can span multiple rows and columns (row-span, col-span), be
+	// placed into named areas (grid-template-areas, grid-area), and rows and
+	// columns can have fixed, fraction (fr) or auto sizes (grid-template-rows,
+	// grid-template-columns) -- LayoutGrid is faster for fully regular grids
+	LayoutGridIrreg
+
 	LayoutsN
 )
 
@@ -723,12 +728,20 @@ func (ly *Layout) LayoutGridDim(rowcol RowCol, dim mat32.Dims) {
 	}
 	extra = mat32.Max(extra, 0.0) // no negatives
 
+	frTot := float32(0.0)
+	for _, gd := range gds {
+		frTot += gd.Fr
+	}
+
 	nstretch := 0
 	stretchTot := float32(0.0)
-	stretchNeed := false        // stretch relative to need
-	stretchMax := false         // only stretch Max = neg
-	addSpace := false           // apply extra toward spacing -- for justify
-	if usePref && extra > 0.0 { // have some stretch extra
+	stretchFr := false   // only stretch Fr > 0, in proportion to Fr
+	stretchNeed := false // stretch relative to need
+	stretchMax := false  // only stretch Max = neg
+	addSpace := false    // apply extra toward spacing -- for justify
+	if frTot > 0 && extra > 0.0 {
+		stretchFr = true
+	} else if usePref && extra > 0.0 { // have some stretch extra
 		for _, gd := range gds {
 			if gd.SizeMax < 0 { // stretch
 				nstretch++
@@ -751,7 +764,7 @@ func (ly *Layout) LayoutGridDim(rowcol RowCol, dim mat32.Dims) {
 	}
 
 	extraSpace := float32(0.0)
-	if sz > 1 && extra > 0.0 && al == AlignJustify && !stretchNeed && !stretchMax {
+	if sz > 1 && extra > 0.0 && al == AlignJustify && !stretchFr && !stretchNeed && !stretchMax {
 		addSpace = true
 		// if neither, then just distribute as spacing for justify
 		extraSpace = extra / float32(sz-1)
@@ -761,7 +774,7 @@ func (ly *Layout) LayoutGridDim(rowcol RowCol, dim mat32.Dims) {
 	pos := spc
 
 	// todo: need a direction setting too
-	if IsAlignEnd(al) && !stretchNeed && !stretchMax {
+	if IsAlignEnd(al) && !stretchFr && !stretchNeed && !stretchMax {
 		pos += extra
 	}
 
@@ -775,7 +788,11 @@ func (ly *Layout) LayoutGridDim(rowcol RowCol, dim mat32.Dims) {
 		if usePref {
 			size = gd.SizePref
 		}
-		if stretchMax { // negative = stretch
+		if stretchFr {
+			if gd.Fr > 0 {
+				size += extra * (gd.Fr / frTot)
+			}
+		} else if stretchMax { // negative = stretch
 			if gd.SizeMax < 0 { // in proportion to pref
 				size += extra * (gd.SizePref / stretchTot)
 			}
@@ -1506,7 +1523,7 @@ func (ly *Layout) LayoutKeys(kt *key.ChordEvent) {
 		fmt.Printf("Layout KeyInput: %v\n", ly.PathUnique())
 	}
 	kf := KeyFun(kt.Chord())
-	if ly.Lay == LayoutHoriz || ly.Lay == LayoutGrid || ly.Lay == LayoutHorizFlow {
+	if ly.Lay == LayoutHoriz || ly.Lay == LayoutGrid || ly.Lay == LayoutGridIrreg || ly.Lay == LayoutHorizFlow {
 		switch kf {
 		case KeyFunMoveRight:
 			if ly.FocusNextChild(false) { // allow higher layers to try..
@@ -1520,7 +1537,7 @@ func (ly *Layout) LayoutKeys(kt *key.ChordEvent) {
 			return
 		}
 	}
-	if ly.Lay == LayoutVert || ly.Lay == LayoutGrid || ly.Lay == LayoutVertFlow {
+	if ly.Lay == LayoutVert || ly.Lay == LayoutGrid || ly.Lay == LayoutGridIrreg || ly.Lay == LayoutVertFlow {
 		switch kf {
 		case KeyFunMoveDown:
 			if ly.FocusNextChild(true) {
@@ -1736,9 +1753,12 @@ func (ly *Layout) Style2D() {
 
 func (ly *Layout) Size2D(iter int) {
 	ly.InitLayout2D()
-	if ly.Lay == LayoutGrid {
+	switch ly.Lay {
+	case LayoutGrid:
 		ly.GatherSizesGrid()
-	} else {
+	case LayoutGridIrreg:
+		ly.GatherSizesGridIrreg()
+	default:
 		ly.GatherSizes()
 	}
 }
@@ -1760,6 +1780,8 @@ func (ly *Layout) Layout2D(parBBox image.Rectangle, iter int) bool {
 		ly.LayoutSharedDim(mat32.X)
 	case LayoutGrid:
 		ly.LayoutGrid()
+	case LayoutGridIrreg:
+		ly.LayoutGridIrreg()
 	case LayoutStacked:
 		ly.LayoutSharedDim(mat32.X)
 		ly.LayoutSharedDim(mat32.Y)
@@ -0,0 +1,479 @@
+// Copyright (c) 2020, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gi
+
+import (
+	"fmt"
+	"image"
+	"strconv"
+	"strings"
+
+	"github.com/chewxy/math32"
+	"github.com/goki/gi/mat32"
+	"github.com/goki/gi/units"
+	"github.com/goki/ki/ints"
+)
+
+// GridTrack is the size specification for one row or column of a
+// LayoutGridIrreg layout, parsed from grid-template-rows or -columns.
+// If neither Fixed nor Fr are set, the track is auto-sized to its content.
+type GridTrack struct {
+	Fixed float32 `desc:"fixed size in dots, if > 0"`
+	Fr    float32 `desc:"fraction of the remaining space, if > 0"`
+}
+
+// ParseGridTracks parses a space-separated list of grid track sizes:
+// auto, Nfr, or a fixed size in any units (e.g., 10em, 120px), using
+// given unit context to convert to dots.
+func ParseGridTracks(str string, uc *units.Context) []GridTrack {
+	flds := strings.Fields(str)
+	if len(flds) == 0 {
+		return nil
+	}
+	trks := make([]GridTrack, len(flds))
+	for i, f := range flds {
+		f = strings.ToLower(f)
+		switch {
+		case f == "auto":
+		case strings.HasSuffix(f, "fr"):
+			fr, err := strconv.ParseFloat(strings.TrimSuffix(f, "fr"), 32)
+			if err != nil || fr < 0 {
+				StyleSetError("grid-template", f)
+				continue
+			}
+			trks[i].Fr = float32(fr)
+		default:
+			v := units.StringToValue(f)
+			trks[i].Fixed = v.ToDots(uc)
+		}
+	}
+	return trks
+}
+
+// ParseGridAreas parses a grid-template-areas string, with one quoted
+// string per row, each containing one space-separated area name per
+// column (. = unnamed cell), returning the bounding rectangle of each
+// named area in grid coordinates (X = col, Y = row), and the number of
+// rows and cols in the template.
+func ParseGridAreas(str string) (areas map[string]image.Rectangle, rows, cols int) {
+	str = strings.TrimSpace(str)
+	if str == "" {
+		return nil, 0, 0
+	}
+	var rowstrs []string
+	if strings.ContainsAny(str, "\"'") {
+		for _, rs := range strings.FieldsFunc(str, func(r rune) bool { return r == '"' || r == '\'' }) {
+			if strings.TrimSpace(rs) != "" {
+				rowstrs = append(rowstrs, rs)
+			}
+		}
+	} else {
+		rowstrs = []string{str}
+	}
+	areas = make(map[string]image.Rectangle)
+	for r, rs := range rowstrs {
+		names := strings.Fields(rs)
+		cols = ints.MaxInt(cols, len(names))
+		for c, nm := range names {
+			if strings.Trim(nm, ".") == "" {
+				continue
+			}
+			cr := image.Rect(c, r, c+1, r+1)
+			if ar, has := areas[nm]; has {
+				cr = ar.Union(cr)
+			}
+			areas[nm] = cr
+		}
+	}
+	return areas, len(rowstrs), cols
+}
+
+// gridOccupancy records which cells of a grid are occupied, growing in
+// rows as needed
+type gridOccupancy struct {
+	cols  int
+	cells [][]bool
+}
+
+// fits returns true if the given rect of cells is within cols and free
+func (og *gridOccupancy) fits(r image.Rectangle) bool {
+	if r.Min.X < 0 || r.Max.X > og.cols {
+		return false
+	}
+	for y := r.Min.Y; y < r.Max.Y && y < len(og.cells); y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			if og.cells[y][x] {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// occupy marks given rect of cells as occupied
+func (og *gridOccupancy) occupy(r image.Rectangle) {
+	for len(og.cells) < r.Max.Y {
+		og.cells = append(og.cells, make([]bool, og.cols))
+	}
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X && x < og.cols; x++ {
+			og.cells[y][x] = true
+		}
+	}
+}
+
+// PlaceGridIrreg computes the GridPos and GridSpan of each child within
+// a LayoutGridIrreg grid with given number of cols and named areas.
+// Children in a grid-area, or with both row and col set, are placed first.
+// As in LayoutGrid, a row or col of 0 means that it is placed
+// automatically: children with only a row or col set are placed in the
+// first free cell of that row or col, and the rest flow into the next
+// free cells in order, row by row.  A child whose row has no free cells
+// left, or whose col is beyond the number of cols, is reported, and flows
+// as if no row or col were set.  Returns the resulting number of rows.
+func (ly *Layout) PlaceGridIrreg(cols int, areas map[string]image.Rectangle) int {
+	occ := gridOccupancy{cols: cols}
+	rows := 0
+	place := func(ni *WidgetBase, r image.Rectangle) {
+		ni.LayData.GridPos = r.Min
+		ni.LayData.GridSpan = r.Size()
+		occ.occupy(r)
+		rows = ints.MaxInt(rows, r.Max.Y)
+	}
+	span := func(lst *LayoutStyle) image.Point {
+		sp := image.Point{ints.MaxInt(lst.ColSpan, 1), ints.MaxInt(lst.RowSpan, 1)}
+		sp.X = ints.MinInt(sp.X, cols)
+		return sp
+	}
+	// first pass: explicitly placed
+	var auto []*WidgetBase
+	for _, c := range ly.Kids {
+		if c == nil {
+			continue
+		}
+		ni := c.(Node2D).AsWidget()
+		if ni == nil {
+			continue
+		}
+		lst := &ni.Sty.Layout
+		if ar, has := areas[lst.GridArea]; has && lst.GridArea != "" {
+			place(ni, ar)
+			continue
+		}
+		if lst.Row > 0 && lst.Col > 0 {
+			sp := span(lst)
+			place(ni, image.Rectangle{Min: image.Point{lst.Col, lst.Row}, Max: image.Point{lst.Col + sp.X, lst.Row + sp.Y}})
+			continue
+		}
+		if lst.GridArea != "" {
+			fmt.Printf("gi.Layout: %v child: %v grid-area: %v not found in grid-template-areas\n", ly.PathUnique(), ni.Nm, lst.GridArea)
+		}
+		auto = append(auto, ni)
+	}
+	// second pass: automatic
+	cur := image.Point{}
+	flow := func(r image.Rectangle, sp image.Point) image.Rectangle {
+		for {
+			if cur.X+sp.X > cols {
+				cur.X = 0
+				cur.Y++
+			}
+			if occ.fits(r.Add(cur)) {
+				break
+			}
+			cur.X++
+		}
+		r = r.Add(cur)
+		cur.X += sp.X
+		return r
+	}
+	for _, ni := range auto {
+		lst := &ni.Sty.Layout
+		sp := span(lst)
+		r := image.Rectangle{Max: sp}
+		switch {
+		case lst.Row > 0: // first free col in row
+			found := false
+			rr := r.Add(image.Point{0, lst.Row})
+			for x := 0; x+sp.X <= cols; x++ {
+				if occ.fits(rr.Add(image.Point{x, 0})) {
+					r = rr.Add(image.Point{x, 0})
+					found = true
+					break
+				}
+			}
+			if !found {
+				fmt.Printf("gi.Layout: %v child: %v row: %v has no free cells for col-span: %v -- placed automatically instead\n", ly.PathUnique(), ni.Nm, lst.Row, sp.X)
+				r = flow(r, sp)
+			}
+		case lst.Col > 0: // first free row in col
+			if lst.Col+sp.X > cols {
+				fmt.Printf("gi.Layout: %v child: %v col: %v with col-span: %v is beyond the %v cols -- placed automatically instead\n", ly.PathUnique(), ni.Nm, lst.Col, sp.X, cols)
+				r = flow(r, sp)
+				break
+			}
+			r = r.Add(image.Point{lst.Col, 0})
+			for !occ.fits(r) {
+				r = r.Add(image.Point{0, 1})
+			}
+		default:
+			r = flow(r, sp)
+		}
+		place(ni, r)
+	}
+	return rows
+}
+
+// GatherSizesGridIrreg is size first pass: gather the size information from
+// the children, irregular grid version
+func (ly *Layout) GatherSizesGridIrreg() {
+	if len(ly.Kids) == 0 {
+		return
+	}
+
+	lst := &ly.Sty.Layout
+	ctrks := ParseGridTracks(lst.GridCols, &ly.Sty.UnContext)
+	rtrks := ParseGridTracks(lst.GridRows, &ly.Sty.UnContext)
+	areas, arows, acols := ParseGridAreas(lst.GridAreas)
+
+	cols := ints.MaxInt(ints.MaxInt(len(ctrks), acols), lst.Columns)
+	rows := ints.MaxInt(len(rtrks), arows)
+	sz := 0
+	for _, c := range ly.Kids {
+		if c == nil {
+			continue
+		}
+		ni := c.(Node2D).AsWidget()
+		if ni == nil {
+			continue
+		}
+		sz++
+		clst := &ni.Sty.Layout
+		if clst.Col > 0 {
+			cols = ints.MaxInt(cols, clst.Col+ints.MaxInt(clst.ColSpan, 1))
+		}
+	}
+	if cols == 0 {
+		cols = ints.MaxInt(int(math32.Sqrt(float32(sz))), 1) // whatever -- not well defined
+	}
+	rows = ints.MaxInt(rows, ly.PlaceGridIrreg(cols, areas))
+
+	ly.GridSize.X = cols
+	ly.GridSize.Y = rows
+
+	if len(ly.GridData[Row]) != rows {
+		ly.GridData[Row] = make([]GridData, rows)
+	}
+	if len(ly.GridData[Col]) != cols {
+		ly.GridData[Col] = make([]GridData, cols)
+	}
+	ly.initGridIrregData(Row, rtrks)
+	ly.initGridIrregData(Col, ctrks)
+
+	// first pass: single-span items set sizes of their row / col
+	for _, c := range ly.Kids {
+		if c == nil {
+			continue
+		}
+		ni := c.(Node2D).AsWidget()
+		if ni == nil {
+			continue
+		}
+		ni.LayData.UpdateSizes()
+		if ni.LayData.GridSpan.Y == 1 {
+			ly.gridIrregSingle(Row, rtrks, ni.LayData.GridPos.Y, ni, mat32.Y)
+		}
+		if ni.LayData.GridSpan.X == 1 {
+			ly.gridIrregSingle(Col, ctrks, ni.LayData.GridPos.X, ni, mat32.X)
+		}
+	}
+	// second pass: spanning items expand the non-fixed rows / cols they span
+	for _, c := range ly.Kids {
+		if c == nil {
+			continue
+		}
+		ni := c.(Node2D).AsWidget()
+		if ni == nil {
+			continue
+		}
+		if ni.LayData.GridSpan.Y > 1 {
+			ly.gridIrregSpan(Row, rtrks, ni.LayData.GridPos.Y, ni.LayData.GridSpan.Y, ni, mat32.Y)
+		}
+		if ni.LayData.GridSpan.X > 1 {
+			ly.gridIrregSpan(Col, ctrks, ni.LayData.GridPos.X, ni.LayData.GridSpan.X, ni, mat32.X)
+		}
+	}
+
+	// Y = sum across rows which have max's
+	var sumPref, sumNeed mat32.Vec2
+	for _, gd := range ly.GridData[Row] {
+		sumNeed.SetAddDim(mat32.Y, gd.SizeNeed)
+		sumPref.SetAddDim(mat32.Y, gd.SizePref)
+	}
+	// X = sum across cols which have max's
+	for _, gd := range ly.GridData[Col] {
+		sumNeed.SetAddDim(mat32.X, gd.SizeNeed)
+		sumPref.SetAddDim(mat32.X, gd.SizePref)
+	}
+
+	if ly.LayData.Size.Pref.X == 0 {
+		ly.LayData.Size.Need.X = mat32.Max(ly.LayData.Size.Need.X, sumNeed.X)
+		ly.LayData.Size.Pref.X = mat32.Max(ly.LayData.Size.Pref.X, sumPref.X)
+	} else { // use target size from style otherwise
+		ly.LayData.Size.Need.X = ly.LayData.Size.Pref.X
+	}
+	if ly.LayData.Size.Pref.Y == 0 {
+		ly.LayData.Size.Need.Y = mat32.Max(ly.LayData.Size.Need.Y, sumNeed.Y)
+		ly.LayData.Size.Pref.Y = mat32.Max(ly.LayData.Size.Pref.Y, sumPref.Y)
+	} else { // use target size from style otherwise
+		ly.LayData.Size.Need.Y = ly.LayData.Size.Pref.Y
+	}
+
+	spc := ly.Sty.BoxSpace()
+	ly.LayData.Size.Need.SetAddScalar(2.0 * spc)
+	ly.LayData.Size.Pref.SetAddScalar(2.0 * spc)
+
+	ly.LayData.Size.Need.X += float32(cols-1) * ly.Spacing.Dots
+	ly.LayData.Size.Pref.X += float32(cols-1) * ly.Spacing.Dots
+	ly.LayData.Size.Need.Y += float32(rows-1) * ly.Spacing.Dots
+	ly.LayData.Size.Pref.Y += float32(rows-1) * ly.Spacing.Dots
+
+	ly.LayData.UpdateSizes() // enforce max and normal ordering, etc
+	if Layout2DTrace {
+		fmt.Printf("Size:   %v gather sizes grid irreg need: %v, pref: %v\n", ly.PathUnique(), ly.LayData.Size.Need, ly.LayData.Size.Pref)
+	}
+}
+
+// initGridIrregData initializes the grid data for rows or cols from tracks
+// -- fixed tracks are set to their size, fr tracks stretch
+func (ly *Layout) initGridIrregData(rowcol RowCol, trks []GridTrack) {
+	for i := range ly.GridData[rowcol] {
+		gd := &ly.GridData[rowcol][i]
+		*gd = GridData{}
+		if i >= len(trks) {
+			continue
+		}
+		trk := trks[i]
+		switch {
+		case trk.Fixed > 0:
+			gd.SizeNeed = trk.Fixed
+			gd.SizePref = trk.Fixed
+			gd.SizeMax = trk.Fixed
+		case trk.Fr > 0:
+			gd.Fr = trk.Fr
+			gd.SizeMax = -1
+		}
+	}
+}
+
+// gridIrregFixed returns true if given row / col index has a fixed size
+func gridIrregFixed(trks []GridTrack, idx int) bool {
+	return idx < len(trks) && trks[idx].Fixed > 0
+}
+
+// gridIrregSingle updates the size of given row / col from a child that
+// occupies just that one row / col
+func (ly *Layout) gridIrregSingle(rowcol RowCol, trks []GridTrack, idx int, ni *WidgetBase, dim mat32.Dims) {
+	gds := ly.GridData[rowcol]
+	if idx < 0 || idx >= len(gds) || gridIrregFixed(trks, idx) {
+		return
+	}
+	gd := &gds[idx]
+	mat32.SetMax(&gd.SizeNeed, ni.LayData.Size.Need.Dim(dim))
+	mat32.SetMax(&gd.SizePref, ni.LayData.Size.Pref.Dim(dim))
+	// for max: any -1 stretch dominates, else accumulate any max
+	if gd.SizeMax >= 0 {
+		if ni.LayData.Size.HasMaxStretch(dim) {
+			gd.SizeMax = -1
+		} else {
+			mat32.SetMax(&gd.SizeMax, ni.LayData.Size.Max.Dim(dim))
+		}
+	}
+}
+
+// gridIrregSpan ensures that the rows / cols spanned by given child are
+// big enough for it, distributing any shortfall evenly across the spanned
+// rows / cols that are not fixed size
+func (ly *Layout) gridIrregSpan(rowcol RowCol, trks []GridTrack, idx, span int, ni *WidgetBase, dim mat32.Dims) {
+	gds := ly.GridData[rowcol]
+	ed := ints.MinInt(idx+span, len(gds))
+	if idx < 0 || idx >= ed {
+		return
+	}
+	nflex := 0
+	sumNeed := float32(ed-idx-1) * ly.Spacing.Dots
+	sumPref := sumNeed
+	for i := idx; i < ed; i++ {
+		sumNeed += gds[i].SizeNeed
+		sumPref += gds[i].SizePref
+		if !gridIrregFixed(trks, i) {
+			nflex++
+		}
+	}
+	if nflex == 0 {
+		return
+	}
+	addNeed := mat32.Max(ni.LayData.Size.Need.Dim(dim)-sumNeed, 0) / float32(nflex)
+	addPref := mat32.Max(ni.LayData.Size.Pref.Dim(dim)-sumPref, 0) / float32(nflex)
+	stretch := ni.LayData.Size.HasMaxStretch(dim)
+	for i := idx; i < ed; i++ {
+		if gridIrregFixed(trks, i) {
+			continue
+		}
+		gd := &gds[i]
+		gd.SizeNeed += addNeed
+		gd.SizePref += addPref
+		gd.SizePref = mat32.Max(gd.SizePref, gd.SizeNeed)
+		if stretch {
+			gd.SizeMax = -1
+		}
+	}
+}
+
+// LayoutGridIrreg manages overall irregular grid layout of children
+func (ly *Layout) LayoutGridIrreg() {
+	if len(ly.Kids) == 0 {
+		return
+	}
+
+	ly.LayoutGridDim(Row, mat32.Y)
+	ly.LayoutGridDim(Col, mat32.X)
+
+	for _, c := range ly.Kids {
+		if c == nil {
+			continue
+		}
+		ni := c.(Node2D).AsWidget()
+		if ni == nil {
+			continue
+		}
+		ly.layoutGridIrregDim(Col, mat32.X, ni.LayData.GridPos.X, ni.LayData.GridSpan.X, ni)
+		ly.layoutGridIrregDim(Row, mat32.Y, ni.LayData.GridPos.Y, ni.LayData.GridSpan.Y, ni)
+
+		if Layout2DTrace {
+			fmt.Printf("Layout: %v grid irreg pos: %v span: %v alloc pos: %v size: %v\n", ly.PathUnique(), ni.LayData.GridPos, ni.LayData.GridSpan, ni.LayData.AllocPosRel, ni.LayData.AllocSize)
+		}
+	}
+}
+
+// layoutGridIrregDim lays out given child along one dim, within the
+// rows / cols that it spans
+func (ly *Layout) layoutGridIrregDim(rowcol RowCol, dim mat32.Dims, idx, span int, ni *WidgetBase) {
+	gds := ly.GridData[rowcol]
+	ed := ints.MinInt(idx+ints.MaxInt(span, 1), len(gds))
+	if idx < 0 || idx >= ed {
+		return
+	}
+	st := gds[idx]
+	lst := gds[ed-1]
+	avail := lst.AllocPosRel + lst.AllocSize - st.AllocPosRel
+	al := ni.Sty.Layout.AlignDim(dim)
+	pref := ni.LayData.Size.Pref.Dim(dim)
+	need := ni.LayData.Size.Need.Dim(dim)
+	max := ni.LayData.Size.Max.Dim(dim)
+	pos, size := ly.LayoutSharedDimImpl(avail, need, pref, max, 0, al)
+	ni.LayData.AllocSize.SetDim(dim, size)
+	ni.LayData.AllocPosRel.SetDim(dim, pos+st.AllocPosRel)
+}
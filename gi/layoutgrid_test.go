@@ -0,0 +1,173 @@
+// Copyright (c) 2020, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gi
+
+import (
+	"fmt"
+	"image"
+	"testing"
+	"time"
+)
+
+func TestParseGridAreas(t *testing.T) {
+	areas, rows, cols := ParseGridAreas(`"head head head" "side main main" "side . foot"`)
+	if rows != 3 || cols != 3 {
+		t.Errorf("rows, cols: %d, %d != 3, 3", rows, cols)
+	}
+	want := map[string]image.Rectangle{
+		"head": image.Rect(0, 0, 3, 1),
+		"side": image.Rect(0, 1, 1, 3),
+		"main": image.Rect(1, 1, 3, 2),
+		"foot": image.Rect(2, 2, 3, 3),
+	}
+	if len(areas) != len(want) {
+		t.Errorf("areas: %v != %v", areas, want)
+	}
+	for nm, r := range want {
+		if areas[nm] != r {
+			t.Errorf("area: %v: %v != %v", nm, areas[nm], r)
+		}
+	}
+}
+
+func TestPlaceGridIrreg(t *testing.T) {
+	ly := &Layout{}
+	ly.InitName(ly, "grid")
+	ly.Lay = LayoutGridIrreg
+	hd := AddNewLabel(ly, "hd", "Header")
+	hd.Sty.Layout.GridArea = "head"
+	big := AddNewLabel(ly, "big", "Big")
+	big.Sty.Layout.RowSpan = 2
+	a := AddNewLabel(ly, "a", "A")
+	b := AddNewLabel(ly, "b", "B")
+	c := AddNewLabel(ly, "c", "C")
+	c.Sty.Layout.Col = 2
+	d := AddNewLabel(ly, "d", "D")
+
+	areas, _, _ := ParseGridAreas(`"head head head"`)
+	rows := ly.PlaceGridIrreg(3, areas)
+	if rows != 3 {
+		t.Errorf("rows: %d != 3", rows)
+	}
+	tests := []struct {
+		lb   *Label
+		pos  image.Point
+		span image.Point
+	}{
+		{hd, image.Point{0, 0}, image.Point{3, 1}},
+		{big, image.Point{0, 1}, image.Point{1, 2}},
+		{a, image.Point{1, 1}, image.Point{1, 1}},
+		{b, image.Point{2, 1}, image.Point{1, 1}},
+		{c, image.Point{2, 2}, image.Point{1, 1}},
+		{d, image.Point{1, 2}, image.Point{1, 1}},
+	}
+	for _, ts := range tests {
+		ld := &ts.lb.LayData
+		if ld.GridPos != ts.pos || ld.GridSpan != ts.span {
+			t.Errorf("%v: pos: %v span: %v != %v %v", ts.lb.Nm, ld.GridPos, ld.GridSpan, ts.pos, ts.span)
+		}
+	}
+}
+
+func TestPlaceGridIrregRowFull(t *testing.T) {
+	ly := &Layout{}
+	ly.InitName(ly, "grid")
+	ly.Lay = LayoutGridIrreg
+	var lbs []*Label
+	for _, nm := range []string{"a", "b", "c"} {
+		lb := AddNewLabel(ly, nm, nm)
+		lb.Sty.Layout.Row = 1
+		lbs = append(lbs, lb)
+	}
+	rows := ly.PlaceGridIrreg(2, nil)
+	if rows != 2 {
+		t.Errorf("rows: %d != 2", rows)
+	}
+	// c does not fit in row 1, so flows into the first free cell instead
+	// of overlapping a
+	want := []image.Point{{0, 1}, {1, 1}, {0, 0}}
+	for i, lb := range lbs {
+		if lb.LayData.GridPos != want[i] {
+			t.Errorf("%v: pos: %v != %v", lb.Nm, lb.LayData.GridPos, want[i])
+		}
+	}
+}
+
+func TestPlaceGridIrregColRange(t *testing.T) {
+	ly := &Layout{}
+	ly.InitName(ly, "grid")
+	ly.Lay = LayoutGridIrreg
+	a := AddNewLabel(ly, "a", "a")
+	a.Sty.Layout.Col = 1
+	b := AddNewLabel(ly, "b", "b")
+	b.Sty.Layout.Col = 5 // beyond the cols
+	c := AddNewLabel(ly, "c", "c")
+	c.Sty.Layout.Col = 1
+	c.Sty.Layout.ColSpan = 2 // col + span beyond the cols
+	done := make(chan int)
+	go func() {
+		done <- ly.PlaceGridIrreg(2, nil)
+	}()
+	var rows int
+	select {
+	case rows = <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("PlaceGridIrreg did not return for out of range cols")
+	}
+	if rows != 2 {
+		t.Errorf("rows: %d != 2", rows)
+	}
+	want := []image.Point{{1, 0}, {0, 0}, {0, 1}}
+	for i, lb := range []*Label{a, b, c} {
+		if lb.LayData.GridPos != want[i] {
+			t.Errorf("%v: pos: %v != %v", lb.Nm, lb.LayData.GridPos, want[i])
+		}
+	}
+}
+
+func TestLayoutGridIrregTracks(t *testing.T) {
+	ly := &Layout{}
+	ly.InitName(ly, "grid")
+	ly.Lay = LayoutGridIrreg
+	ly.Sty.UnContext.Defaults()
+	ly.Sty.Layout.GridCols = "100px 1fr 3fr"
+	ly.Sty.Layout.GridRows = "20px auto"
+	var lbs []*Label
+	for i := 0; i < 6; i++ {
+		lb := AddNewLabel(ly, fmt.Sprintf("lb%d", i), "")
+		lb.LayData.Size.Need.Set(10, 30)
+		lb.LayData.Size.Pref.Set(10, 30)
+		lbs = append(lbs, lb)
+	}
+	ly.GatherSizesGridIrreg()
+	if ly.GridSize != (image.Point{3, 2}) {
+		t.Fatalf("GridSize: %v != (3,2)", ly.GridSize)
+	}
+	ly.LayData.AllocSize.Set(500, 100)
+	ly.LayoutGridIrreg()
+
+	// fixed cols and rows keep their size, even if the content is bigger,
+	// fr cols share the extra space in proportion, and auto rows fit
+	tests := []struct {
+		rowcol    RowCol
+		idx       int
+		pos, size float32
+	}{
+		{Col, 0, 0, 100},
+		{Col, 1, 100, 10 + 380*0.25},
+		{Col, 2, 205, 10 + 380*0.75},
+		{Row, 0, 0, 20},
+		{Row, 1, 20, 30},
+	}
+	for _, ts := range tests {
+		gd := ly.GridData[ts.rowcol][ts.idx]
+		if gd.AllocPosRel != ts.pos || gd.AllocSize != ts.size {
+			t.Errorf("%v %d: pos: %v size: %v != %v %v", ts.rowcol, ts.idx, gd.AllocPosRel, gd.AllocSize, ts.pos, ts.size)
+		}
+	}
+	if pos := lbs[5].LayData.AllocPosRel; pos.X != 205 || pos.Y != 20 {
+		t.Errorf("child in col 2, row 1: pos: %v != (205, 20)", pos)
+	}
+}
@@ -20,12 +20,13 @@ func _() {
 	_ = x[LayoutVertFlow-4]
 	_ = x[LayoutStacked-5]
 	_ = x[LayoutNil-6]
-	_ = x[LayoutsN-7]
+	_ = x[LayoutGridIrreg-7]
+	_ = x[LayoutsN-8]
 }
 
-const _Layouts_name = "LayoutHorizLayoutVertLayoutGridLayoutHorizFlowLayoutVertFlowLayoutStackedLayoutNilLayoutsN"
+const _Layouts_name = "LayoutHorizLayoutVertLayoutGridLayoutHorizFlowLayoutVertFlowLayoutStackedLayoutNilLayoutGridIrregLayoutsN"
 
-var _Layouts_index = [...]uint8{0, 11, 21, 31, 46, 60, 73, 82, 90}
+var _Layouts_index = [...]uint8{0, 11, 21, 31, 46, 60, 73, 82, 97, 105}
 
 func (i Layouts) String() string {
 	if i < 0 || i >= Layouts(len(_Layouts_index)-1) {
@@ -50,8 +50,12 @@ type LayoutStyle struct {
 	Columns        int         `xml:"columns" alt:"grid-cols" desc:"prop: columns = number of columns to use in a grid layout -- used as a constraint in layout if individual elements do not specify their row, column positions"`
 	Row            int         `xml:"row" desc:"prop: row = specifies the row that this element should appear within a grid layout"`
 	Col            int         `xml:"col" desc:"prop: col = specifies the column that this element should appear within a grid layout"`
-	RowSpan        int         `xml:"row-span" desc:"prop: row-span = specifies the number of sequential rows that this element should occupy within a grid layout (todo: not currently supported)"`
-	ColSpan        int         `xml:"col-span" desc:"prop: col-span = specifies the number of sequential columns that this element should occupy within a grid layout"`
+	RowSpan        int         `xml:"row-span" desc:"prop: row-span = specifies the number of sequential rows that this element should occupy within a grid layout (only supported in LayoutGridIrreg)"`
+	ColSpan        int         `xml:"col-span" desc:"prop: col-span = specifies the number of sequential columns that this element should occupy within a grid layout (only supported in LayoutGridIrreg)"`
+	GridArea       string      `xml:"grid-area" desc:"prop: grid-area = name of the area within the grid-template-areas of a LayoutGridIrreg parent that this element occupies -- supersedes row, col and spans"`
+	GridCols       string      `xml:"grid-template-columns" desc:"prop: grid-template-columns = space-separated sizes of the columns of a LayoutGridIrreg layout: auto (sized to content), Nfr (fraction of remaining space), or a fixed size in any units (e.g., 10em)"`
+	GridRows       string      `xml:"grid-template-rows" desc:"prop: grid-template-rows = space-separated sizes of the rows of a LayoutGridIrreg layout: auto (sized to content), Nfr (fraction of remaining space), or a fixed size in any units (e.g., 10em)"`
+	GridAreas      string      `xml:"grid-template-areas" desc:"prop: grid-template-areas = named areas of a LayoutGridIrreg layout, as one quoted string per row, with one space-separated area name per column, e.g., \"head head\" \"side main\" -- use . for unnamed cells"`
 	ScrollBarWidth units.Value `xml:"scrollbar-width" desc:"prop: scrollbar-width = width of a layout scrollbar"`
 }
 
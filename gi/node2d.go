@@ -985,6 +985,7 @@ func (nb *Node2DBase) SetFixedHeight(val units.Value) {
 type MetaData2D struct {
 	Node2DBase
 	MetaData string
+	Element  string `desc:"full XML element name, including any namespace prefix (e.g., sodipodi:namedview), for writing the element back out"`
 }
 
 var KiT_MetaData2D = kit.Types.AddType(&MetaData2D{}, nil)
@@ -993,4 +994,5 @@ func (g *MetaData2D) CopyFieldsFrom(frm interface{}) {
 	fr := frm.(*MetaData2D)
 	g.Node2DBase.CopyFieldsFrom(&fr.Node2DBase)
 	g.MetaData = fr.MetaData
+	g.Element = fr.Element
 }
@@ -641,6 +641,9 @@ type EditorPrefs struct {
 	AutoIndent   bool `xml:"auto-indent" desc:"automatically indent lines when enter, tab, }, etc pressed"`
 	EmacsUndo    bool `xml:"emacs-undo" desc:"use emacs-style undo, where after a non-undo command, all the current undo actions are added to the undo stack, such that a subsequent undo is actually a redo"`
 	DepthColor   bool `xml:"depth-color" desc:"colorize the background according to nesting depth"`
+	SaveUndo     bool `xml:"save-undo" desc:"save the undo history of files when they are saved or closed, and restore it when they are reopened unchanged"`
+	Minimap      bool `xml:"minimap" desc:"show a minimap overview of the whole document at the right edge of the editor, with markers for search matches, line colors, spelling errors, and diffs"`
+	LangServer   bool `xml:"lang-server" desc:"use a language server (e.g., gopls), if one is configured and installed for the language, for completion, lookup and diagnostics"`
 }
 
 // Defaults are the defaults for EditorPrefs
@@ -701,6 +704,18 @@ func (pf *EditorPrefs) StyleFromProps(props ki.Props) {
 			if iv, ok := kit.ToBool(val); ok {
 				pf.DepthColor = iv
 			}
+		case "save-undo":
+			if iv, ok := kit.ToBool(val); ok {
+				pf.SaveUndo = iv
+			}
+		case "minimap":
+			if iv, ok := kit.ToBool(val); ok {
+				pf.Minimap = iv
+			}
+		case "lang-server":
+			if iv, ok := kit.ToBool(val); ok {
+				pf.LangServer = iv
+			}
 		}
 	}
 }
@@ -295,19 +295,14 @@ func (s *Style) ApplyCSS(node Node2D, css ki.Props, key, selector string, vp *Vi
 	return true
 }
 
-// StyleCSS applies css style properties to given Widget node, parsing out
-// type, .class, and #name selectors, along with optional sub-selector
-// (:hover, :active etc)
+// StyleCSS applies css style properties to given Widget node, matching
+// type, .class, and #name selectors, along with full CSS selectors with
+// combinators, attributes and pseudo-classes (see CSSRules), in order of
+// specificity, with optional sub-selector (:hover, :active etc)
 func (s *Style) StyleCSS(node Node2D, css ki.Props, selector string, vp *Viewport2D) {
-	tyn := strings.ToLower(node.Type().Name()) // type is most general, first
-	s.ApplyCSS(node, css, tyn, selector, vp)
-	classes := strings.Split(strings.ToLower(node.AsNode2D().Class), " ")
-	for _, cl := range classes {
-		cln := "." + strings.TrimSpace(cl)
-		s.ApplyCSS(node, css, cln, selector, vp)
+	for _, r := range CSSRules(node, css, selector) {
+		s.ApplyCSS(node, css, r.Key, r.State, vp)
 	}
-	idnm := "#" + strings.ToLower(node.Name()) // then name
-	s.ApplyCSS(node, css, idnm, selector, vp)
 }
 
 // SubProps returns a sub-property map from given prop map for a given styling
@@ -465,6 +465,54 @@ var StyleLayoutFuncs = map[string]StyleFunc{
 			ly.ColSpan = int(iv)
 		}
 	},
+	"grid-area": func(obj interface{}, key string, val interface{}, par interface{}, vp *Viewport2D) {
+		ly := obj.(*LayoutStyle)
+		if inh, init := StyleInhInit(val, par); inh || init {
+			if inh {
+				ly.GridArea = par.(*LayoutStyle).GridArea
+			} else if init {
+				ly.GridArea = ""
+			}
+			return
+		}
+		ly.GridArea = kit.ToString(val)
+	},
+	"grid-template-columns": func(obj interface{}, key string, val interface{}, par interface{}, vp *Viewport2D) {
+		ly := obj.(*LayoutStyle)
+		if inh, init := StyleInhInit(val, par); inh || init {
+			if inh {
+				ly.GridCols = par.(*LayoutStyle).GridCols
+			} else if init {
+				ly.GridCols = ""
+			}
+			return
+		}
+		ly.GridCols = kit.ToString(val)
+	},
+	"grid-template-rows": func(obj interface{}, key string, val interface{}, par interface{}, vp *Viewport2D) {
+		ly := obj.(*LayoutStyle)
+		if inh, init := StyleInhInit(val, par); inh || init {
+			if inh {
+				ly.GridRows = par.(*LayoutStyle).GridRows
+			} else if init {
+				ly.GridRows = ""
+			}
+			return
+		}
+		ly.GridRows = kit.ToString(val)
+	},
+	"grid-template-areas": func(obj interface{}, key string, val interface{}, par interface{}, vp *Viewport2D) {
+		ly := obj.(*LayoutStyle)
+		if inh, init := StyleInhInit(val, par); inh || init {
+			if inh {
+				ly.GridAreas = par.(*LayoutStyle).GridAreas
+			} else if init {
+				ly.GridAreas = ""
+			}
+			return
+		}
+		ly.GridAreas = kit.ToString(val)
+	},
 	"scrollbar-width": func(obj interface{}, key string, val interface{}, par interface{}, vp *Viewport2D) {
 		ly := obj.(*LayoutStyle)
 		if inh, init := StyleInhInit(val, par); inh || init {
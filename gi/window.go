@@ -270,11 +270,47 @@ func SetQuitReqFunc(fun func()) {
 	oswin.TheApp.SetQuitReqFunc(fun)
 }
 
+// quitClean has the functions called when the app is about to quit -- see
+// SetQuitCleanFunc and AddQuitCleanFunc
+var quitClean struct {
+	mu    sync.Mutex
+	fun   func()
+	added []func()
+}
+
 // SetQuitCleanFunc sets the function that is called whenever app is
 // actually about to quit (irrevocably) -- can do any necessary
 // last-minute cleanup here.
 func SetQuitCleanFunc(fun func()) {
-	oswin.TheApp.SetQuitCleanFunc(fun)
+	quitClean.mu.Lock()
+	quitClean.fun = fun
+	quitClean.mu.Unlock()
+	oswin.TheApp.SetQuitCleanFunc(QuitCleanFuncs)
+}
+
+// AddQuitCleanFunc adds a function that is called when the app is about to
+// quit, after the one set by SetQuitCleanFunc, e.g., for packages to
+// release the resources they use, such as other processes.
+func AddQuitCleanFunc(fun func()) {
+	quitClean.mu.Lock()
+	quitClean.added = append(quitClean.added, fun)
+	quitClean.mu.Unlock()
+}
+
+// QuitCleanFuncs calls the functions set by SetQuitCleanFunc and
+// AddQuitCleanFunc -- it is the quit clean function of the app, set in
+// Init.
+func QuitCleanFuncs() {
+	quitClean.mu.Lock()
+	fun := quitClean.fun
+	added := append([]func(){}, quitClean.added...)
+	quitClean.mu.Unlock()
+	if fun != nil {
+		fun()
+	}
+	for _, af := range added {
+		af()
+	}
 }
 
 // Quit closes all windows and exits the program.
@@ -731,7 +767,11 @@ func (w *Window) Closed() {
 		return
 	}
 	w.SetInactive() // marks as closed
+	// the focus can stop a blinking cursor, which waits for a blink that
+	// may be waiting for UpMu to render the cursor sprite
+	w.UpMu.Unlock()
 	w.FocusInactivate()
+	w.UpMu.Lock()
 	WindowGlobalMu.Lock()
 	if len(FocusWindows) > 0 {
 		pf := FocusWindows[0]
@@ -831,6 +871,7 @@ func Init() {
 		TheViewIFace.HiStyleInit()
 		WinGeomPrefs.NeedToReload() // gets time stamp associated with open, so it doesn't re-open
 		WinGeomPrefs.Open()
+		oswin.TheApp.SetQuitCleanFunc(QuitCleanFuncs)
 	}
 }
 
@@ -876,6 +917,22 @@ func (w *Window) SendCustomEvent(data interface{}) {
 	oswin.SendCustomEvent(w.OSWin, data)
 }
 
+// winEventFunc is the data of a custom event that runs the function on the
+// event loop -- see GoRunOnEventLoop
+type winEventFunc func()
+
+// GoRunOnEventLoop runs given function on the event loop of this window,
+// after the events that are already pending, and returns immediately.
+// Widgets must only be updated on the event loop, so other goroutines, e.g.,
+// that get data or watch files in the background, must use this to update
+// them.  Does nothing if the window is closed.
+func (w *Window) GoRunOnEventLoop(fun func()) {
+	if w.IsClosed() {
+		return
+	}
+	w.SendCustomEvent(winEventFunc(fun))
+}
+
 /////////////////////////////////////////////////////////////////////////////
 //                   Rendering
 
@@ -1629,6 +1686,12 @@ func (w *Window) FilterEvent(evi oswin.Event) bool {
 // returns true if processing should continue and false if was handled
 func (w *Window) HiPriorityEvents(evi oswin.Event) bool {
 	switch e := evi.(type) {
+	case *oswin.CustomEvent:
+		if fun, ok := e.Data.(winEventFunc); ok {
+			e.SetProcessed()
+			fun()
+			return false
+		}
 	case *window.Event:
 		switch e.Action {
 		// case window.Resize: // note: already handled earlier in lag process
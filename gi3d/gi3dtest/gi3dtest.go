@@ -0,0 +1,36 @@
+// Copyright (c) 2020, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package gi3dtest provides a standard gi3d.Scene for tests, e.g., of
+// the encoders and decoders in gi3d/io.
+package gi3dtest
+
+import (
+	"github.com/goki/gi/gi3d"
+)
+
+// Scene returns a scene with a unit "box" mesh used by two solids: a green
+// "cube" scaled by 2 within a "group" at 1,2,3, and a red "cube2" at the
+// top level, both with back-face culling off, lit by a "sun" directional
+// light, with the camera at 0,0,8 with a FOV of 40.
+func Scene() *gi3d.Scene {
+	sc := &gi3d.Scene{}
+	sc.InitName(sc, "scene")
+	sc.Defaults()
+	gi3d.AddNewBox(sc, "box", 1, 1, 1)
+	gp := gi3d.AddNewGroup(sc, sc, "group")
+	gp.Pose.Pos.Set(1, 2, 3)
+	sld := gi3d.AddNewSolid(sc, gp, "cube", "box")
+	sld.Pose.Scale.Set(2, 2, 2)
+	sld.Mat.Color.SetUInt8(0, 255, 0, 255)
+	sld.Mat.CullBack = false
+	sld2 := gi3d.AddNewSolid(sc, sc, "cube2", "box")
+	sld2.Mat.Color.SetUInt8(255, 0, 0, 255)
+	sld2.Mat.CullBack = false
+	dl := gi3d.AddNewDirLight(sc, "sun", 0.5, gi3d.DirectSun)
+	dl.Pos.Set(0, 1, 0)
+	sc.Camera.Pose.Pos.Set(0, 0, 8)
+	sc.Camera.FOV = 40
+	return sc
+}
@@ -11,6 +11,7 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/goki/gi/mat32"
 	"github.com/goki/ki/ki"
 )
 
@@ -49,6 +50,7 @@ type Decoder interface {
 
 // Decoders is the master list of decoders, indexed by the primary extension.
 // .obj = Wavefront object file -- only has mesh data, not scene info.
+// .gltf, .glb = glTF 2.0 JSON and binary formats -- full scene info.
 var Decoders = map[string]Decoder{}
 
 // DecodeFile decodes the given file using a decoder based on the file
@@ -56,6 +58,7 @@ var Decoders = map[string]Decoder{}
 // Supported formats include:
 // .obj = Wavefront OBJ format, including associated materials (.mtl) which
 //        must have same name as .obj, or a default material is used.
+// .gltf, .glb = glTF 2.0 format, with linked files in the same directory.
 func DecodeFile(fname string) (Decoder, error) {
 	ext := filepath.Ext(fname)
 	dt, has := Decoders[ext]
@@ -96,6 +99,7 @@ func DecodeFile(fname string) (Decoder, error) {
 // Supported formats include:
 // .obj = Wavefront OBJ format, including associated materials (.mtl) which
 //        must have same name as .obj, or a default material is used.
+// .gltf, .glb = glTF 2.0 format, with linked files in the same directory.
 func (sc *Scene) OpenObj(fname string, gp *Group) error {
 	dec, err := DecodeFile(fname)
 	if err != nil {
@@ -113,6 +117,7 @@ func (sc *Scene) OpenObj(fname string, gp *Group) error {
 // Supported formats include:
 // .obj = Wavefront OBJ format, including associated materials (.mtl) which
 //        must have same name as .obj, or a default material is used.
+// .gltf, .glb = glTF 2.0 format, with linked files in the same directory.
 func (sc *Scene) OpenNewObj(fname string, parent ki.Ki) (*Group, error) {
 	dec, err := DecodeFile(fname)
 	if err != nil {
@@ -134,6 +139,7 @@ func (sc *Scene) OpenNewObj(fname string, parent ki.Ki) (*Group, error) {
 // Supported formats include:
 // .obj = Wavefront OBJ format, including associated materials (.mtl) which
 //        must have same name as .obj, or a default material is used.
+// .gltf, .glb = glTF 2.0 format, with linked files in the same directory.
 func (sc *Scene) OpenToLibrary(fname string, libnm string) (*Group, error) {
 	dec, err := DecodeFile(fname)
 	if err != nil {
@@ -156,6 +162,8 @@ func (sc *Scene) OpenToLibrary(fname string, libnm string) (*Group, error) {
 //        must have same name as .obj, or a default material is used.
 //        Does not support full scene data so only objects are loaded
 //        into a new group in scene.
+// .gltf, .glb = glTF 2.0 format, including the node hierarchy, cameras
+//        and lights.
 func (sc *Scene) OpenScene(fname string) error {
 	dec, err := DecodeFile(fname)
 	if err != nil {
@@ -204,6 +212,8 @@ func (sc *Scene) ReadObj(fname string, rs []io.Reader, gp *Group) error {
 //        must have same name as .obj, or a default material is used.
 //        Does not support full scene data so only objects are loaded
 //        into a new group in scene.
+// .gltf, .glb = glTF 2.0 format, including the node hierarchy, cameras
+//        and lights.
 func (sc *Scene) ReadScene(fname string, rs []io.Reader, gp *Group) error {
 	ext := filepath.Ext(fname)
 	dt, has := Decoders[ext]
@@ -222,3 +232,191 @@ func (sc *Scene) ReadScene(fname string, rs []io.Reader, gp *Group) error {
 	sc.UpdateEnd(updt)
 	return nil
 }
+
+/////////////////////////////////////////////////////////////////////////////
+//   Encoders
+
+// Encoder writes a Scene or a Group subtree to 3D object / scene file(s).
+// This interface is implemented by the different format-specific encoders.
+type Encoder interface {
+	// New returns a new instance of the encoder used for a specific encoding
+	New() Encoder
+
+	// Desc returns the description of this encoder
+	Desc() string
+
+	// SetFile sets the file name being used for encoding -- needed for
+	// naming, and for referring to other files such as textures relative
+	// to it.  Returns the list of files to be written, in order of the
+	// writers passed to Encode.  For example, .obj encoder adds a
+	// corresponding .mtl file.
+	SetFile(fname string) []string
+
+	// SetGroup sets the objects to be encoded from the children of the
+	// given group within the given scene.
+	SetGroup(sc *Scene, gp *Group)
+
+	// HasScene returns true if this encoder saves full scene information
+	// (node hierarchy, camera, lights) -- otherwise the Pose transforms are
+	// applied to the mesh data, and only the objects are saved.
+	HasScene() bool
+
+	// SetScene sets the whole scene to be encoded.
+	SetScene(sc *Scene)
+
+	// Encode writes the encoded data to the given writers, corresponding
+	// to the files returned by SetFile.
+	Encode(ws []io.Writer) error
+}
+
+// Encoders is the master list of encoders, indexed by the primary extension.
+// .obj = Wavefront object file -- only has mesh data, not scene info.
+// .gltf, .glb = glTF 2.0 JSON and binary formats -- full scene info.
+// .stl = binary STL format -- only has triangles, not materials or scene info.
+var Encoders = map[string]Encoder{}
+
+// EncodeFile returns a new encoder for given file, based on the file
+// extension, along with the list of files to be written.
+func EncodeFile(fname string) (Encoder, []string, error) {
+	ext := filepath.Ext(fname)
+	et, has := Encoders[ext]
+	if !has {
+		return nil, nil, fmt.Errorf("gi3d.EncodeFile: file extension: %v not found in Encoders list for file %v", ext, fname)
+	}
+	enc := et.New()
+	return enc, enc.SetFile(fname), nil
+}
+
+// writeFiles creates the given files and encodes into them
+func writeFiles(enc Encoder, files []string) error {
+	var err error
+	nf := len(files)
+	fs := make([]*os.File, nf)
+	ws := make([]io.Writer, nf)
+	defer func() {
+		for _, f := range fs {
+			if f != nil {
+				f.Close()
+			}
+		}
+	}()
+	for i, f := range files {
+		fs[i], err = os.Create(f)
+		if err != nil {
+			return err
+		}
+		ws[i] = fs[i]
+	}
+	return enc.Encode(ws)
+}
+
+// SaveObj saves the children of given group in scene to given file, using
+// an encoder based on the file extension.
+// Supported formats include:
+// .obj = Wavefront OBJ format, with materials saved to a .mtl file of the
+//        same name.
+// .gltf, .glb = glTF 2.0 format.
+// .stl = binary STL format.
+func (sc *Scene) SaveObj(fname string, gp *Group) error {
+	enc, files, err := EncodeFile(fname)
+	if err != nil {
+		return err
+	}
+	enc.SetGroup(sc, gp)
+	return writeFiles(enc, files)
+}
+
+// SaveScene saves the scene to given file, using an encoder based on the
+// file extension.
+// Supported formats include:
+// .obj = Wavefront OBJ format, with materials saved to a .mtl file of the
+//        same name.  Does not support full scene data so only objects are
+//        saved, with their Pose transforms applied.
+// .gltf, .glb = glTF 2.0 format, including the node hierarchy, camera
+//        and lights.
+// .stl = binary STL format.  Only triangles are saved.
+func (sc *Scene) SaveScene(fname string) error {
+	enc, files, err := EncodeFile(fname)
+	if err != nil {
+		return err
+	}
+	enc.SetScene(sc)
+	return writeFiles(enc, files)
+}
+
+// WriteObj writes the children of given group in scene to given writer(s),
+// using an encoder based on the extension of the given file name -- even
+// though the file name is not directly used to write the file, it is
+// required for naming and encoding selection.
+// .obj = Wavefront OBJ format, with materials written to the 2nd writer.
+func (sc *Scene) WriteObj(fname string, ws []io.Writer, gp *Group) error {
+	ext := filepath.Ext(fname)
+	et, has := Encoders[ext]
+	if !has {
+		return fmt.Errorf("gi3d.WriteObj: file extension: %v not found in Encoders list", ext)
+	}
+	enc := et.New()
+	enc.SetFile(fname)
+	enc.SetGroup(sc, gp)
+	return enc.Encode(ws)
+}
+
+// WriteScene writes the scene to given writer(s), using an encoder based on
+// the extension of the given file name -- even though the file name is not
+// directly used to write the file, it is required for naming and encoding
+// selection.
+// .obj = Wavefront OBJ format, with materials written to the 2nd writer.
+func (sc *Scene) WriteScene(fname string, ws []io.Writer) error {
+	ext := filepath.Ext(fname)
+	et, has := Encoders[ext]
+	if !has {
+		return fmt.Errorf("gi3d.WriteScene: file extension: %v not found in Encoders list", ext)
+	}
+	enc := et.New()
+	enc.SetFile(fname)
+	enc.SetScene(sc)
+	return enc.Encode(ws)
+}
+
+// SolidMesh returns the mesh of given solid for encoding, making its
+// vertex data if not already made (e.g., for the primitive shapes, which
+// are made on Init3D) -- returns nil if the solid has no mesh.
+func (sc *Scene) SolidMesh(sld *Solid) Mesh {
+	ms := sld.MeshPtr
+	if ms == nil {
+		ms = sc.MeshByName(string(sld.Mesh))
+	}
+	if ms == nil {
+		return nil
+	}
+	if len(ms.AsMeshBase().Vtx) == 0 {
+		ms.Make(sc)
+	}
+	return ms
+}
+
+// SolidsXForm calls given function for each Solid within given parent
+// (not including the parent itself), with the transform from the
+// coordinates of the solid to those of the parent, given by the Pose
+// transforms of the solid and its parents up to the given one.  This is
+// used by encoders that apply the Pose transforms to the mesh data.
+func SolidsXForm(par ki.Ki, fun func(sld *Solid, xf *mat32.Mat4)) {
+	var solidsXForm func(par ki.Ki, pxf *mat32.Mat4)
+	solidsXForm = func(par ki.Ki, pxf *mat32.Mat4) {
+		for _, kid := range *par.Children() {
+			nii, ni := KiToNode3D(kid)
+			if nii == nil {
+				continue
+			}
+			ps := ni.Pose
+			ps.UpdateMatrix()
+			var xf mat32.Mat4
+			xf.MulMatrices(pxf, &ps.Matrix)
+			if sld, ok := kid.Embed(KiT_Solid).(*Solid); ok && sld != nil {
+				fun(sld, &xf)
+			}
+			solidsXForm(kid, &xf)
+		}
+	}
+	solidsXForm(par, mat32.NewMat4())
+}
@@ -0,0 +1,488 @@
+// Copyright (c) 2020, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gltf
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"image"
+	"image/png"
+	"io"
+	"io/ioutil"
+	"math"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/goki/gi/gi"
+	"github.com/goki/gi/gi3d"
+	"github.com/goki/gi/mat32"
+	"github.com/goki/ki/ki"
+)
+
+// Encoder writes a scene or the children of a group to glTF 2.0 format,
+// either as .gltf JSON with the buffer and images embedded as data URIs,
+// or as binary .glb.  The node hierarchy and Pose transforms are preserved,
+// and the full scene also includes the camera and lights (using the
+// KHR_lights_punctual extension).
+// It implements the gi3d.Encoder interface and an instance
+// is registered to handle .gltf and .glb files.
+type Encoder struct {
+	File     string      // .gltf or .glb filename (without path)
+	Dir      string      // path to the file
+	GLB      bool        // write binary .glb format -- set from file extension
+	Scene    *gi3d.Scene // scene with the meshes and textures
+	Par      ki.Ki       // parent of the nodes to encode
+	Full     bool        // encode the full scene, including camera and lights
+	Doc      GLTF        // the document being encoded
+	bin      bytes.Buffer
+	meshes   map[string]map[string]int // mesh name -> attribute accessors
+	idxs     map[string]int            // mesh name -> indices accessor
+	gmeshes  map[meshKey]int
+	mats     map[matKey]int
+	textures map[gi3d.Texture]int
+}
+
+// meshKey is a glTF mesh: a gi3d mesh with a given material
+type meshKey struct {
+	mesh string
+	mat  int
+}
+
+// matKey has the material params that are encoded, to share materials
+type matKey struct {
+	color, emissive, specular gi.Color
+	shiny                     float32
+	tex                       gi3d.Texture
+	cullBack                  bool
+}
+
+func (enc *Encoder) New() gi3d.Encoder {
+	return new(Encoder)
+}
+
+func (enc *Encoder) Desc() string {
+	return ".gltf, .glb = glTF 2.0 format, in JSON with embedded buffer and images, or binary form.  Supports full Scene: node hierarchy, meshes, materials, textures, camera and lights (KHR_lights_punctual)."
+}
+
+func (enc *Encoder) HasScene() bool {
+	return true
+}
+
+func (enc *Encoder) SetFile(fname string) []string {
+	enc.Dir, enc.File = filepath.Split(fname)
+	enc.GLB = strings.ToLower(filepath.Ext(fname)) == ".glb"
+	return []string{fname}
+}
+
+func (enc *Encoder) SetGroup(sc *gi3d.Scene, gp *gi3d.Group) {
+	enc.Scene = sc
+	enc.Par = gp
+	enc.Full = false
+}
+
+func (enc *Encoder) SetScene(sc *gi3d.Scene) {
+	enc.Scene = sc
+	enc.Par = sc
+	enc.Full = true
+}
+
+// Encode writes the gltf or glb data to the first writer
+func (enc *Encoder) Encode(ws []io.Writer) error {
+	if len(ws) == 0 {
+		return errors.New("gltf.Encoder: no writers passed")
+	}
+	if enc.Scene == nil || enc.Par == nil {
+		return errors.New("gltf.Encoder: SetGroup or SetScene must be called first")
+	}
+	if err := enc.Build(); err != nil {
+		return err
+	}
+	w := bufio.NewWriter(ws[0])
+	if enc.GLB {
+		if err := enc.writeGLB(w); err != nil {
+			return err
+		}
+	} else {
+		js, err := json.MarshalIndent(&enc.Doc, "", "  ")
+		if err != nil {
+			return err
+		}
+		w.Write(js)
+	}
+	return w.Flush()
+}
+
+// Build builds the glTF document in Doc and the binary buffer data
+// from the scene or group
+func (enc *Encoder) Build() error {
+	enc.Doc = GLTF{Asset: Asset{Version: "2.0", Generator: "GoKi gi3d"}}
+	enc.bin.Reset()
+	enc.meshes = make(map[string]map[string]int)
+	enc.idxs = make(map[string]int)
+	enc.gmeshes = make(map[meshKey]int)
+	enc.mats = make(map[matKey]int)
+	enc.textures = make(map[gi3d.Texture]int)
+	roots := enc.addNodes(enc.Par)
+	if enc.Full {
+		if ni := enc.addCamera(); ni >= 0 {
+			roots = append(roots, ni)
+		}
+		roots = append(roots, enc.addLights()...)
+	}
+	sci := 0
+	enc.Doc.Scene = &sci
+	enc.Doc.Scenes = []Scene{{Name: enc.Scene.Name(), Nodes: roots}}
+	if enc.bin.Len() > 0 {
+		buf := Buffer{ByteLength: enc.bin.Len()}
+		if !enc.GLB {
+			buf.URI = "data:application/octet-stream;base64," + base64.StdEncoding.EncodeToString(enc.bin.Bytes())
+		}
+		enc.Doc.Buffers = []Buffer{buf}
+	}
+	return nil
+}
+
+// writeGLB writes the document and buffer in glb format
+func (enc *Encoder) writeGLB(w io.Writer) error {
+	js, err := json.Marshal(&enc.Doc)
+	if err != nil {
+		return err
+	}
+	for len(js)%4 != 0 {
+		js = append(js, ' ')
+	}
+	bin := enc.bin.Bytes()
+	for len(bin)%4 != 0 {
+		bin = append(bin, 0)
+	}
+	tlen := 12 + 8 + len(js)
+	if len(bin) > 0 {
+		tlen += 8 + len(bin)
+	}
+	le := binary.LittleEndian
+	binary.Write(w, le, []uint32{glbMagic, 2, uint32(tlen), uint32(len(js)), glbChunkJSON})
+	w.Write(js)
+	if len(bin) > 0 {
+		binary.Write(w, le, []uint32{uint32(len(bin)), glbChunkBIN})
+		_, err = w.Write(bin)
+	}
+	return err
+}
+
+// addNodes adds nodes for the Node3D children of given parent,
+// returning their indexes
+func (enc *Encoder) addNodes(par ki.Ki) []int {
+	var nis []int
+	for _, kid := range *par.Children() {
+		nii, ni := gi3d.KiToNode3D(kid)
+		if nii == nil {
+			continue
+		}
+		nd := Node{Name: kid.Name()}
+		setNodePose(&nd, &ni.Pose)
+		if sld, ok := kid.Embed(gi3d.KiT_Solid).(*gi3d.Solid); ok && sld != nil {
+			if mi := enc.addMesh(sld); mi >= 0 {
+				nd.Mesh = &mi
+			}
+		}
+		nd.Children = enc.addNodes(kid)
+		nis = append(nis, len(enc.Doc.Nodes))
+		enc.Doc.Nodes = append(enc.Doc.Nodes, nd)
+	}
+	return nis
+}
+
+// setNodePose sets the node TRS properties from given pose,
+// leaving out the default values
+func setNodePose(nd *Node, ps *gi3d.Pose) {
+	if ps.Pos != (mat32.Vec3{}) {
+		nd.Translation = []float32{ps.Pos.X, ps.Pos.Y, ps.Pos.Z}
+	}
+	if q := ps.Quat; q != (mat32.Quat{W: 1}) && q != (mat32.Quat{}) {
+		nd.Rotation = []float32{q.X, q.Y, q.Z, q.W}
+	}
+	if s := ps.Scale; s != (mat32.Vec3{X: 1, Y: 1, Z: 1}) && s != (mat32.Vec3{}) {
+		nd.Scale = []float32{s.X, s.Y, s.Z}
+	}
+}
+
+// addView adds given data as a new buffer view (4 byte aligned),
+// returning its index
+func (enc *Encoder) addView(data []byte, target int) int {
+	for enc.bin.Len()%4 != 0 {
+		enc.bin.WriteByte(0)
+	}
+	bvi := len(enc.Doc.BufferViews)
+	enc.Doc.BufferViews = append(enc.Doc.BufferViews, BufferView{ByteOffset: enc.bin.Len(), ByteLength: len(data), Target: target})
+	enc.bin.Write(data)
+	return bvi
+}
+
+// addFloats adds an accessor for given float values, with given type,
+// returning its index -- min and max are set for positions
+func (enc *Encoder) addFloats(vals []float32, typ string, minMax bool) int {
+	nc := NComps(typ)
+	data := make([]byte, 4*len(vals))
+	for i, v := range vals {
+		binary.LittleEndian.PutUint32(data[4*i:], math.Float32bits(v))
+	}
+	bvi := enc.addView(data, 34962) // ARRAY_BUFFER
+	acc := Accessor{BufferView: &bvi, ComponentType: Float, Count: len(vals) / nc, Type: typ}
+	if minMax && len(vals) >= nc {
+		acc.Min = append([]float32{}, vals[:nc]...)
+		acc.Max = append([]float32{}, vals[:nc]...)
+		for i := nc; i < len(vals); i++ {
+			c := i % nc
+			acc.Min[c] = mat32.Min(acc.Min[c], vals[i])
+			acc.Max[c] = mat32.Max(acc.Max[c], vals[i])
+		}
+	}
+	ai := len(enc.Doc.Accessors)
+	enc.Doc.Accessors = append(enc.Doc.Accessors, acc)
+	return ai
+}
+
+// addIndexes adds an accessor for given vertex indexes, returning its index
+func (enc *Encoder) addIndexes(idx []uint32) int {
+	data := make([]byte, 4*len(idx))
+	for i, ix := range idx {
+		binary.LittleEndian.PutUint32(data[4*i:], ix)
+	}
+	bvi := enc.addView(data, 34963) // ELEMENT_ARRAY_BUFFER
+	ai := len(enc.Doc.Accessors)
+	enc.Doc.Accessors = append(enc.Doc.Accessors, Accessor{BufferView: &bvi, ComponentType: UnsignedInt, Count: len(idx), Type: "SCALAR"})
+	return ai
+}
+
+// addMesh returns the glTF mesh index for given solid, adding its mesh
+// data the first time it is used, and a mesh for each different material.
+// returns -1 if solid has no valid mesh.
+func (enc *Encoder) addMesh(sld *gi3d.Solid) int {
+	ms := enc.Scene.SolidMesh(sld)
+	if ms == nil {
+		return -1
+	}
+	mb := ms.AsMeshBase()
+	nv := len(mb.Vtx) / 3
+	if nv == 0 || len(mb.Idx) < 3 {
+		return -1
+	}
+	nm := ms.Name()
+	attrs, has := enc.meshes[nm]
+	if !has {
+		attrs = map[string]int{"POSITION": enc.addFloats(mb.Vtx, "VEC3", true)}
+		if len(mb.Norm) == len(mb.Vtx) {
+			attrs["NORMAL"] = enc.addFloats(mb.Norm, "VEC3", false)
+		}
+		if len(mb.Tex)/2 == nv {
+			attrs["TEXCOORD_0"] = enc.addFloats(mb.Tex, "VEC2", false)
+		}
+		if len(mb.Color)/4 == nv {
+			attrs["COLOR_0"] = enc.addFloats(mb.Color, "VEC4", false)
+		}
+		enc.meshes[nm] = attrs
+		enc.idxs[nm] = enc.addIndexes(mb.Idx[:len(mb.Idx)/3*3])
+	}
+	mk := meshKey{nm, enc.addMat(&sld.Mat)}
+	if gmi, has := enc.gmeshes[mk]; has {
+		return gmi
+	}
+	idx := enc.idxs[nm]
+	mat := mk.mat
+	gmi := len(enc.Doc.Meshes)
+	enc.Doc.Meshes = append(enc.Doc.Meshes, Mesh{Name: nm, Primitives: []Primitive{{Attributes: attrs, Indices: &idx, Material: &mat}}})
+	enc.gmeshes[mk] = gmi
+	return gmi
+}
+
+// addMat returns the glTF material index for given material,
+// adding it the first time -- this is the inverse of SetMaterial
+func (enc *Encoder) addMat(mt *gi3d.Material) int {
+	tex := mt.TexPtr
+	if tex == nil && mt.Texture != "" {
+		tex = enc.Scene.Textures[string(mt.Texture)]
+	}
+	mk := matKey{mt.Color, mt.Emissive, mt.Specular, mt.Shiny, tex, mt.CullBack}
+	if mi, has := enc.mats[mk]; has {
+		return mi
+	}
+	spc := mat32.Sqrt(mat32.Clamp((mt.Shiny-1)/127, 0, 1))
+	rough := 1 - spc
+	metal := float32(0)
+	c := mt.Color
+	mat := Material{DoubleSided: !mt.CullBack}
+	mat.PbrMetallicRoughness = &PBR{
+		BaseColorFactor: []float32{float32(c.R) / 255, float32(c.G) / 255, float32(c.B) / 255, float32(c.A) / 255},
+		MetallicFactor:  &metal,
+		RoughnessFactor: &rough,
+	}
+	if c.A < 255 {
+		mat.AlphaMode = "BLEND"
+	}
+	if e := mt.Emissive; e.A > 0 && (e.R > 0 || e.G > 0 || e.B > 0) {
+		mat.EmissiveFactor = []float32{float32(e.R) / 255, float32(e.G) / 255, float32(e.B) / 255}
+	}
+	if tex != nil {
+		if ti := enc.addTexture(tex); ti >= 0 {
+			mat.PbrMetallicRoughness.BaseColorTexture = &TextureInfo{Index: ti}
+		}
+	}
+	mi := len(enc.Doc.Materials)
+	enc.Doc.Materials = append(enc.Doc.Materials, mat)
+	enc.mats[mk] = mi
+	return mi
+}
+
+// addTexture returns the glTF texture index for given texture, adding it
+// the first time -- returns -1 if the texture image is not available.
+// Texture files are referred to by relative path in .gltf and embedded
+// in .glb, while other textures are encoded as embedded png images.
+func (enc *Encoder) addTexture(tex gi3d.Texture) int {
+	if ti, has := enc.textures[tex]; has {
+		return ti
+	}
+	img := Image{Name: tex.Name()}
+	var ok bool
+	switch tx := tex.(type) {
+	case *gi3d.TextureFile:
+		ok = enc.setImageFile(&img, string(tx.File))
+	case *gi3d.TextureImage:
+		ok = enc.setImagePNG(&img, tx.Img)
+	case *gi3d.TextureGi2D:
+		if tx.Viewport != nil && tx.Viewport.Pixels != nil {
+			ok = enc.setImagePNG(&img, tx.Viewport.Pixels)
+		}
+	}
+	ti := -1
+	if ok {
+		ii := len(enc.Doc.Images)
+		enc.Doc.Images = append(enc.Doc.Images, img)
+		ti = len(enc.Doc.Textures)
+		enc.Doc.Textures = append(enc.Doc.Textures, Texture{Source: &ii})
+	}
+	enc.textures[tex] = ti
+	return ti
+}
+
+// setImageFile sets the image to refer to given file, which is embedded for glb
+func (enc *Encoder) setImageFile(img *Image, fn string) bool {
+	if fn == "" {
+		return false
+	}
+	if !enc.GLB {
+		if rel, err := filepath.Rel(enc.Dir, fn); err == nil && enc.Dir != "" {
+			fn = rel
+		}
+		img.URI = filepath.ToSlash(fn)
+		return true
+	}
+	data, err := ioutil.ReadFile(fn)
+	if err != nil {
+		return false
+	}
+	img.MimeType = "image/png"
+	if ext := strings.ToLower(filepath.Ext(fn)); ext == ".jpg" || ext == ".jpeg" {
+		img.MimeType = "image/jpeg"
+	}
+	bvi := enc.addView(data, 0)
+	img.BufferView = &bvi
+	return true
+}
+
+// setImagePNG sets the image to given image data, encoded as png
+func (enc *Encoder) setImagePNG(img *Image, im image.Image) bool {
+	if im == nil {
+		return false
+	}
+	var b bytes.Buffer
+	if err := png.Encode(&b, im); err != nil {
+		return false
+	}
+	if enc.GLB {
+		bvi := enc.addView(b.Bytes(), 0)
+		img.BufferView = &bvi
+		img.MimeType = "image/png"
+	} else {
+		img.URI = "data:image/png;base64," + base64.StdEncoding.EncodeToString(b.Bytes())
+	}
+	return true
+}
+
+// addCamera adds the scene camera and its node, returning the node index
+func (enc *Encoder) addCamera() int {
+	cm := &enc.Scene.Camera
+	cam := Camera{Name: "camera"}
+	if cm.Ortho {
+		ymag := cm.Pose.Pos.Sub(cm.Target).Length() * mat32.Tan(mat32.DegToRad(cm.FOV)/2)
+		cam.Type = "orthographic"
+		cam.Orthographic = &Orthographic{Xmag: ymag * cm.Aspect, Ymag: ymag, Znear: cm.Near, Zfar: cm.Far}
+	} else {
+		cam.Type = "perspective"
+		cam.Perspective = &Perspective{AspectRatio: cm.Aspect, Yfov: mat32.DegToRad(cm.FOV), Znear: cm.Near, Zfar: cm.Far}
+	}
+	ci := len(enc.Doc.Cameras)
+	enc.Doc.Cameras = append(enc.Doc.Cameras, cam)
+	nd := Node{Name: "camera", Camera: &ci}
+	setNodePose(&nd, &cm.Pose)
+	ni := len(enc.Doc.Nodes)
+	enc.Doc.Nodes = append(enc.Doc.Nodes, nd)
+	return ni
+}
+
+// addLights adds the scene lights and their nodes, returning the node
+// indexes -- ambient lights are not supported in glTF
+func (enc *Encoder) addLights() []int {
+	var nis []int
+	var lights []Light
+	ltnms := make([]string, 0, len(enc.Scene.Lights))
+	for ltnm := range enc.Scene.Lights {
+		ltnms = append(ltnms, ltnm)
+	}
+	sort.Strings(ltnms)
+	for _, ltnm := range ltnms {
+		lt := enc.Scene.Lights[ltnm]
+		nd := Node{Name: ltnm}
+		gl := Light{Name: ltnm}
+		var lb *gi3d.LightBase
+		switch l := lt.(type) {
+		case *gi3d.DirLight:
+			gl.Type = "directional"
+			// lights point along -Z, and Pos is direction from origin
+			var q mat32.Quat
+			q.SetFromUnitVectors(mat32.Vec3{Z: -1}, l.Pos.Normal().Negate())
+			nd.Rotation = []float32{q.X, q.Y, q.Z, q.W}
+			lb = &l.LightBase
+		case *gi3d.PointLight:
+			gl.Type = "point"
+			nd.Translation = []float32{l.Pos.X, l.Pos.Y, l.Pos.Z}
+			lb = &l.LightBase
+		case *gi3d.SpotLight:
+			gl.Type = "spot"
+			outer := mat32.DegToRad(l.CutoffAngle)
+			gl.Spot = &Spot{OuterConeAngle: &outer}
+			setNodePose(&nd, &l.Pose)
+			lb = &l.LightBase
+		default:
+			continue
+		}
+		lumns := lb.Lumns
+		gl.Intensity = &lumns
+		gl.Color = []float32{float32(lb.Clr.R) / 255, float32(lb.Clr.G) / 255, float32(lb.Clr.B) / 255}
+		nd.Extensions = &NodeExtensions{LightsPunctual: &NodeLight{Light: len(lights)}}
+		lights = append(lights, gl)
+		nis = append(nis, len(enc.Doc.Nodes))
+		enc.Doc.Nodes = append(enc.Doc.Nodes, nd)
+	}
+	if len(lights) > 0 {
+		enc.Doc.Extensions = &Extensions{LightsPunctual: &Lights{Lights: lights}}
+		enc.Doc.ExtensionsUsed = []string{"KHR_lights_punctual"}
+	}
+	return nis
+}
@@ -0,0 +1,79 @@
+// Copyright (c) 2020, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gltf
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/goki/gi/gi3d"
+	"github.com/goki/gi/gi3d/gi3dtest"
+	"github.com/goki/gi/mat32"
+)
+
+func TestEncodeScene(t *testing.T) {
+	for _, fn := range []string{"test.gltf", "test.glb"} {
+		sc := gi3dtest.Scene()
+		// same color as cube, so the material is shared
+		sc.ChildByName("cube2", 0).(*gi3d.Solid).Mat.Color.SetUInt8(0, 255, 0, 255)
+		enc := gi3d.Encoders[".gltf"].New()
+		enc.SetFile(fn)
+		enc.SetScene(sc)
+		var b bytes.Buffer
+		if err := enc.Encode([]io.Writer{&b}); err != nil {
+			t.Fatalf("%v: %v", fn, err)
+		}
+		doc := &enc.(*Encoder).Doc
+		if len(doc.Meshes) != 1 || len(doc.Materials) != 1 {
+			t.Errorf("%v: mesh and material should be shared: meshes: %d materials: %d", fn, len(doc.Meshes), len(doc.Materials))
+		}
+
+		dec := gi3d.Decoders[".gltf"].New()
+		dec.SetFile(fn)
+		if err := dec.Decode([]io.Reader{&b}); err != nil {
+			t.Fatalf("%v: decode: %v", fn, err)
+		}
+		dsc := &gi3d.Scene{}
+		dsc.InitName(dsc, "scene")
+		dsc.Defaults()
+		dec.SetScene(dsc)
+		if w := dec.(*Decoder).Warnings; len(w) > 0 {
+			t.Errorf("%v: warnings: %v", fn, w)
+		}
+		gp, ok := dsc.ChildByName("group", 0).(*gi3d.Group)
+		if !ok {
+			t.Fatalf("%v: group not found: %v", fn, dsc.Kids)
+		}
+		if gp.Pose.Pos != (mat32.Vec3{X: 1, Y: 2, Z: 3}) {
+			t.Errorf("%v: group pos: %v", fn, gp.Pose.Pos)
+		}
+		sld, ok := gp.ChildByName("cube", 0).(*gi3d.Solid)
+		if !ok {
+			t.Fatalf("%v: solid not found: %v", fn, gp.Kids)
+		}
+		if sld.Pose.Scale != (mat32.Vec3{X: 2, Y: 2, Z: 2}) {
+			t.Errorf("%v: solid scale: %v", fn, sld.Pose.Scale)
+		}
+		if sld.Mat.Color.G != 255 || sld.Mat.Color.R != 0 || sld.Mat.CullBack {
+			t.Errorf("%v: material not set: %v", fn, sld.Mat)
+		}
+		omb := sc.MeshByName("box").AsMeshBase()
+		mb := dsc.MeshByName(string(sld.Mesh)).AsMeshBase()
+		if len(mb.Vtx) != len(omb.Vtx) || len(mb.Idx) != len(omb.Idx) || len(mb.Tex) != len(omb.Tex) {
+			t.Errorf("%v: mesh data sizes differ: vtx: %d idx: %d tex: %d", fn, len(mb.Vtx), len(mb.Idx), len(mb.Tex))
+		}
+		if mat32.Abs(dsc.Camera.FOV-40) > 1e-3 || dsc.Camera.Pose.Pos.Z != 8 {
+			t.Errorf("%v: camera not set: %v", fn, dsc.Camera)
+		}
+		lt, ok := dsc.Lights["sun"].(*gi3d.DirLight)
+		if !ok {
+			t.Fatalf("%v: light not found: %v", fn, dsc.Lights)
+		}
+		if lt.Lumns != 0.5 || lt.Pos.Y < .999 {
+			t.Errorf("%v: light not set: %v", fn, lt)
+		}
+	}
+}
@@ -0,0 +1,677 @@
+// Copyright (c) 2020, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package gltf is used to parse the glTF 2.0 file format, in both the JSON
+// (*.gltf) and binary (*.glb) forms, including the full scene information:
+// node hierarchy, meshes, materials, embedded and linked textures, cameras,
+// and lights (KHR_lights_punctual extension).
+// Format spec: https://github.com/KhronosGroup/glTF/tree/master/specification/2.0
+// Not supported: skins, animations, morph targets, and compressed meshes.
+package gltf
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"github.com/goki/gi/gi3d"
+)
+
+// note: gimain imports "github.com/goki/gi/gi3d/io/gltf" to get this code
+func init() {
+	gi3d.Decoders[".gltf"] = &Decoder{}
+	gi3d.Decoders[".glb"] = &Decoder{}
+	gi3d.Encoders[".gltf"] = &Encoder{}
+	gi3d.Encoders[".glb"] = &Encoder{}
+}
+
+// GLTF is the top-level glTF json document -- only the parts that are
+// used in decoding are represented
+type GLTF struct {
+	Asset              Asset        `json:"asset"`
+	ExtensionsUsed     []string     `json:"extensionsUsed,omitempty"`
+	ExtensionsRequired []string     `json:"extensionsRequired,omitempty"`
+	Scene              *int         `json:"scene,omitempty"`
+	Scenes             []Scene      `json:"scenes,omitempty"`
+	Nodes              []Node       `json:"nodes,omitempty"`
+	Meshes             []Mesh       `json:"meshes,omitempty"`
+	Accessors          []Accessor   `json:"accessors,omitempty"`
+	BufferViews        []BufferView `json:"bufferViews,omitempty"`
+	Buffers            []Buffer     `json:"buffers,omitempty"`
+	Materials          []Material   `json:"materials,omitempty"`
+	Textures           []Texture    `json:"textures,omitempty"`
+	Images             []Image      `json:"images,omitempty"`
+	Cameras            []Camera     `json:"cameras,omitempty"`
+	Extensions         *Extensions  `json:"extensions,omitempty"`
+}
+
+// Extensions are the supported top-level extensions
+type Extensions struct {
+	LightsPunctual *Lights `json:"KHR_lights_punctual,omitempty"`
+}
+
+// Lights are the lights defined in the KHR_lights_punctual extension
+type Lights struct {
+	Lights []Light `json:"lights"`
+}
+
+// Asset has the metadata about the glTF asset
+type Asset struct {
+	Version   string `json:"version"`
+	Generator string `json:"generator,omitempty"`
+}
+
+// Scene is a set of root nodes
+type Scene struct {
+	Name  string `json:"name,omitempty"`
+	Nodes []int  `json:"nodes"`
+}
+
+// Node is an element of the node hierarchy, with an optional mesh, camera
+// or light, and a local transform given either as a matrix or as
+// translation, rotation, and scale
+type Node struct {
+	Name        string          `json:"name,omitempty"`
+	Children    []int           `json:"children,omitempty"`
+	Mesh        *int            `json:"mesh,omitempty"`
+	Camera      *int            `json:"camera,omitempty"`
+	Matrix      []float32       `json:"matrix,omitempty"`
+	Translation []float32       `json:"translation,omitempty"`
+	Rotation    []float32       `json:"rotation,omitempty"`
+	Scale       []float32       `json:"scale,omitempty"`
+	Extensions  *NodeExtensions `json:"extensions,omitempty"`
+}
+
+// NodeExtensions are the supported node extensions
+type NodeExtensions struct {
+	LightsPunctual *NodeLight `json:"KHR_lights_punctual,omitempty"`
+}
+
+// NodeLight is the index of the light of a node
+type NodeLight struct {
+	Light int `json:"light"`
+}
+
+// Mesh is a set of primitives to be rendered
+type Mesh struct {
+	Name       string      `json:"name,omitempty"`
+	Primitives []Primitive `json:"primitives"`
+}
+
+// Primitive modes
+const (
+	ModePoints        = 0
+	ModeLines         = 1
+	ModeLineLoop      = 2
+	ModeLineStrip     = 3
+	ModeTriangles     = 4
+	ModeTriangleStrip = 5
+	ModeTriangleFan   = 6
+)
+
+// Primitive is geometry to be rendered with a given material, with vertex
+// attributes (POSITION, NORMAL, TEXCOORD_0, COLOR_0) given as accessor
+// indexes
+type Primitive struct {
+	Attributes map[string]int `json:"attributes"`
+	Indices    *int           `json:"indices,omitempty"`
+	Material   *int           `json:"material,omitempty"`
+	Mode       *int           `json:"mode,omitempty"`
+}
+
+// Accessor component types
+const (
+	Byte          = 5120
+	UnsignedByte  = 5121
+	Short         = 5122
+	UnsignedShort = 5123
+	UnsignedInt   = 5125
+	Float         = 5126
+)
+
+// Accessor is a typed view into a buffer view
+type Accessor struct {
+	BufferView    *int      `json:"bufferView,omitempty"`
+	ByteOffset    int       `json:"byteOffset,omitempty"`
+	ComponentType int       `json:"componentType"`
+	Normalized    bool      `json:"normalized,omitempty"`
+	Count         int       `json:"count"`
+	Type          string    `json:"type"`
+	Max           []float32 `json:"max,omitempty"`
+	Min           []float32 `json:"min,omitempty"`
+	Sparse        *Sparse   `json:"sparse,omitempty"`
+}
+
+// Sparse has sparse substitutions of accessor values
+type Sparse struct {
+	Count   int `json:"count"`
+	Indices struct {
+		BufferView    int `json:"bufferView"`
+		ByteOffset    int `json:"byteOffset"`
+		ComponentType int `json:"componentType"`
+	} `json:"indices"`
+	Values struct {
+		BufferView int `json:"bufferView"`
+		ByteOffset int `json:"byteOffset"`
+	} `json:"values"`
+}
+
+// BufferView is a view into a buffer
+type BufferView struct {
+	Buffer     int `json:"buffer"`
+	ByteOffset int `json:"byteOffset,omitempty"`
+	ByteLength int `json:"byteLength"`
+	ByteStride int `json:"byteStride,omitempty"`
+	Target     int `json:"target,omitempty"`
+}
+
+// Buffer is binary data, from a file, data URI, or the .glb binary chunk
+type Buffer struct {
+	URI        string `json:"uri,omitempty"`
+	ByteLength int    `json:"byteLength"`
+}
+
+// Material is a physically-based (metallic-roughness) material
+type Material struct {
+	Name                 string       `json:"name,omitempty"`
+	PbrMetallicRoughness *PBR         `json:"pbrMetallicRoughness,omitempty"`
+	EmissiveFactor       []float32    `json:"emissiveFactor,omitempty"`
+	AlphaMode            string       `json:"alphaMode,omitempty"`
+	DoubleSided          bool         `json:"doubleSided,omitempty"`
+	EmissiveTexture      *TextureInfo `json:"emissiveTexture,omitempty"`
+}
+
+// PBR has the metallic-roughness material parameters
+type PBR struct {
+	BaseColorFactor  []float32    `json:"baseColorFactor,omitempty"`
+	BaseColorTexture *TextureInfo `json:"baseColorTexture,omitempty"`
+	MetallicFactor   *float32     `json:"metallicFactor,omitempty"`
+	RoughnessFactor  *float32     `json:"roughnessFactor,omitempty"`
+}
+
+// TextureInfo is a reference to a texture
+type TextureInfo struct {
+	Index    int `json:"index"`
+	TexCoord int `json:"texCoord,omitempty"`
+}
+
+// Texture refers to the image source for a texture
+type Texture struct {
+	Source *int `json:"source,omitempty"`
+}
+
+// Image is image data, from a file, data URI, or buffer view
+type Image struct {
+	Name       string `json:"name,omitempty"`
+	URI        string `json:"uri,omitempty"`
+	MimeType   string `json:"mimeType,omitempty"`
+	BufferView *int   `json:"bufferView,omitempty"`
+}
+
+// Camera is a perspective or orthographic camera
+type Camera struct {
+	Name         string        `json:"name,omitempty"`
+	Type         string        `json:"type"`
+	Perspective  *Perspective  `json:"perspective,omitempty"`
+	Orthographic *Orthographic `json:"orthographic,omitempty"`
+}
+
+// Perspective has the perspective camera parameters
+type Perspective struct {
+	AspectRatio float32 `json:"aspectRatio,omitempty"`
+	Yfov        float32 `json:"yfov"`
+	Zfar        float32 `json:"zfar,omitempty"`
+	Znear       float32 `json:"znear"`
+}
+
+// Orthographic has the orthographic camera parameters
+type Orthographic struct {
+	Xmag  float32 `json:"xmag"`
+	Ymag  float32 `json:"ymag"`
+	Zfar  float32 `json:"zfar"`
+	Znear float32 `json:"znear"`
+}
+
+// Light is a KHR_lights_punctual light: directional, point, or spot
+type Light struct {
+	Name      string    `json:"name,omitempty"`
+	Type      string    `json:"type"`
+	Color     []float32 `json:"color,omitempty"`
+	Intensity *float32  `json:"intensity,omitempty"`
+	Spot      *Spot     `json:"spot,omitempty"`
+}
+
+// Spot has the spot light cone parameters
+type Spot struct {
+	InnerConeAngle float32  `json:"innerConeAngle,omitempty"`
+	OuterConeAngle *float32 `json:"outerConeAngle,omitempty"`
+}
+
+// SupportedExtensions are the extensions that can be listed as required
+var SupportedExtensions = map[string]bool{
+	"KHR_lights_punctual": true,
+	"KHR_materials_unlit": true,
+}
+
+// glb chunk and header constants
+const (
+	glbMagic     = 0x46546C67 // glTF
+	glbChunkJSON = 0x4E4F534A
+	glbChunkBIN  = 0x004E4942
+)
+
+// Decoder contains all decoded data from the glTF file.
+// It also implements the gi3d.Decoder interface and an instance
+// is registered to handle .gltf and .glb files.
+type Decoder struct {
+	File     string               // .gltf or .glb filename (without path)
+	Dir      string               // path to the file
+	Doc      GLTF                 // decoded json document
+	Buffers  [][]byte             // buffer data, loaded from files, data URIs, or glb
+	Warnings []string             // warning messages
+	meshes   map[int][]string     // gi3d mesh names for each primitive of each mesh
+	textures map[int]gi3d.Texture // textures for each image
+}
+
+func (dec *Decoder) New() gi3d.Decoder {
+	return new(Decoder)
+}
+
+func (dec *Decoder) Desc() string {
+	return ".gltf, .glb = glTF 2.0 format, in JSON with external or embedded buffers and images, or binary form.  Supports full Scene: node hierarchy, meshes, materials, textures, cameras and lights (KHR_lights_punctual)."
+}
+
+func (dec *Decoder) HasScene() bool {
+	return true
+}
+
+func (dec *Decoder) SetFile(fname string) []string {
+	dec.Dir, dec.File = filepath.Split(fname)
+	return []string{fname}
+}
+
+// Decode reads the given data and decodes it -- the first reader is the
+// .gltf or .glb data: any other files referred to in it (buffers, images)
+// are loaded relative to the directory of the file set by SetFile.
+func (dec *Decoder) Decode(rs []io.Reader) error {
+	if len(rs) == 0 {
+		return errors.New("gltf.Decoder: no readers passed")
+	}
+	data, err := ioutil.ReadAll(rs[0])
+	if err != nil {
+		return err
+	}
+	var bin []byte
+	if len(data) >= 12 && binary.LittleEndian.Uint32(data) == glbMagic {
+		data, bin, err = dec.parseGLB(data)
+		if err != nil {
+			return err
+		}
+	}
+	if err := json.Unmarshal(data, &dec.Doc); err != nil {
+		return fmt.Errorf("gltf.Decoder: json error: %v", err)
+	}
+	if !strings.HasPrefix(dec.Doc.Asset.Version, "2") {
+		return fmt.Errorf("gltf.Decoder: only version 2.x is supported, file has version: %v", dec.Doc.Asset.Version)
+	}
+	for _, ex := range dec.Doc.ExtensionsRequired {
+		if !SupportedExtensions[ex] {
+			return fmt.Errorf("gltf.Decoder: required extension: %v is not supported", ex)
+		}
+	}
+	dec.Buffers = make([][]byte, len(dec.Doc.Buffers))
+	for i := range dec.Doc.Buffers {
+		buf := &dec.Doc.Buffers[i]
+		if buf.URI == "" {
+			if i != 0 || bin == nil {
+				return fmt.Errorf("gltf.Decoder: buffer %d has no uri", i)
+			}
+			dec.Buffers[i] = bin
+		} else {
+			dec.Buffers[i], err = dec.loadURI(buf.URI)
+			if err != nil {
+				return err
+			}
+		}
+		if len(dec.Buffers[i]) < buf.ByteLength {
+			return fmt.Errorf("gltf.Decoder: buffer %d has length: %d < byteLength: %d", i, len(dec.Buffers[i]), buf.ByteLength)
+		}
+	}
+	return nil
+}
+
+// parseGLB returns the json and binary chunks from glb format data
+func (dec *Decoder) parseGLB(data []byte) (js, bin []byte, err error) {
+	if ver := binary.LittleEndian.Uint32(data[4:]); ver != 2 {
+		return nil, nil, fmt.Errorf("gltf.Decoder: only glb version 2 is supported, file has version: %d", ver)
+	}
+	ln := int(binary.LittleEndian.Uint32(data[8:]))
+	if ln < 12 {
+		return nil, nil, fmt.Errorf("gltf.Decoder: glb length: %d is less than the header length", ln)
+	}
+	if ln > len(data) {
+		return nil, nil, errors.New("gltf.Decoder: glb data is truncated")
+	}
+	data = data[12:ln]
+	for len(data) >= 8 {
+		cln := int(binary.LittleEndian.Uint32(data))
+		ctyp := binary.LittleEndian.Uint32(data[4:])
+		if cln > len(data)-8 {
+			return nil, nil, errors.New("gltf.Decoder: glb chunk is truncated")
+		}
+		chunk := data[8 : 8+cln]
+		switch ctyp {
+		case glbChunkJSON:
+			js = chunk
+		case glbChunkBIN:
+			if bin == nil {
+				bin = chunk
+			}
+		}
+		data = data[8+cln:]
+	}
+	if js == nil {
+		return nil, nil, errors.New("gltf.Decoder: glb has no JSON chunk")
+	}
+	return js, bin, nil
+}
+
+// loadURI loads the data from given uri, which is either a data URI or
+// a file name relative to the directory of the file
+func (dec *Decoder) loadURI(uri string) ([]byte, error) {
+	if strings.HasPrefix(uri, "data:") {
+		ci := strings.IndexByte(uri, ',')
+		if ci < 0 || !strings.HasSuffix(uri[:ci], ";base64") {
+			return nil, fmt.Errorf("gltf.Decoder: only base64 data uris are supported")
+		}
+		return base64.StdEncoding.DecodeString(uri[ci+1:])
+	}
+	fn, err := url.PathUnescape(uri)
+	if err != nil {
+		fn = uri
+	}
+	if !filepath.IsAbs(fn) {
+		fn = filepath.Join(dec.Dir, fn)
+	}
+	return ioutil.ReadFile(fn)
+}
+
+// bufferViewData returns the data for given buffer view index
+func (dec *Decoder) bufferViewData(bvi int) ([]byte, *BufferView, error) {
+	if bvi < 0 || bvi >= len(dec.Doc.BufferViews) {
+		return nil, nil, fmt.Errorf("gltf.Decoder: bufferView index: %d out of range", bvi)
+	}
+	bv := &dec.Doc.BufferViews[bvi]
+	if bv.Buffer < 0 || bv.Buffer >= len(dec.Buffers) {
+		return nil, nil, fmt.Errorf("gltf.Decoder: buffer index: %d out of range", bv.Buffer)
+	}
+	buf := dec.Buffers[bv.Buffer]
+	if bv.ByteOffset < 0 || bv.ByteLength < 0 || bv.ByteStride < 0 {
+		return nil, nil, fmt.Errorf("gltf.Decoder: bufferView: %d has a negative byteOffset, byteLength or byteStride", bvi)
+	}
+	if bv.ByteOffset > len(buf) || bv.ByteLength > len(buf)-bv.ByteOffset {
+		return nil, nil, fmt.Errorf("gltf.Decoder: bufferView: %d extends beyond buffer", bvi)
+	}
+	return buf[bv.ByteOffset : bv.ByteOffset+bv.ByteLength], bv, nil
+}
+
+// NComps returns the number of components for given accessor type
+func NComps(typ string) int {
+	switch typ {
+	case "SCALAR":
+		return 1
+	case "VEC2":
+		return 2
+	case "VEC3":
+		return 3
+	case "VEC4", "MAT2":
+		return 4
+	case "MAT3":
+		return 9
+	case "MAT4":
+		return 16
+	}
+	return 0
+}
+
+// CompSize returns the size in bytes of given component type
+func CompSize(ctyp int) int {
+	switch ctyp {
+	case Byte, UnsignedByte:
+		return 1
+	case Short, UnsignedShort:
+		return 2
+	case UnsignedInt, Float:
+		return 4
+	}
+	return 0
+}
+
+// readComp reads one component of given type from data, as a float,
+// normalizing integer values if norm is set
+func readComp(data []byte, ctyp int, norm bool) float32 {
+	switch ctyp {
+	case Byte:
+		v := float32(int8(data[0]))
+		if norm {
+			v /= 127
+			if v < -1 {
+				v = -1
+			}
+		}
+		return v
+	case UnsignedByte:
+		v := float32(data[0])
+		if norm {
+			v /= 255
+		}
+		return v
+	case Short:
+		v := float32(int16(binary.LittleEndian.Uint16(data)))
+		if norm {
+			v /= 32767
+			if v < -1 {
+				v = -1
+			}
+		}
+		return v
+	case UnsignedShort:
+		v := float32(binary.LittleEndian.Uint16(data))
+		if norm {
+			v /= 65535
+		}
+		return v
+	case UnsignedInt:
+		return float32(binary.LittleEndian.Uint32(data))
+	case Float:
+		return math.Float32frombits(binary.LittleEndian.Uint32(data))
+	}
+	return 0
+}
+
+// readIndex reads one unsigned integer index of given type from data --
+// the type must be UnsignedByte, UnsignedShort or UnsignedInt
+func readIndex(data []byte, ctyp int) uint32 {
+	switch ctyp {
+	case UnsignedByte:
+		return uint32(data[0])
+	case UnsignedShort:
+		return uint32(binary.LittleEndian.Uint16(data))
+	default:
+		return binary.LittleEndian.Uint32(data)
+	}
+}
+
+// IsIndexType returns true if given component type is valid for indexes,
+// i.e., an unsigned integer type
+func IsIndexType(ctyp int) bool {
+	return ctyp == UnsignedByte || ctyp == UnsignedShort || ctyp == UnsignedInt
+}
+
+// MaxAccessorCount is the maximum number of elements in an accessor that
+// has no bufferView, for which the (zero) values are all allocated
+var MaxAccessorCount = 1 << 24
+
+// elementsRange returns the stride for count elements of esz bytes each,
+// in a bufferView of n bytes with given byteStride (0 = tightly packed),
+// starting at byte offset off, or an error if they do not fit
+func elementsRange(n, off, count, bstride, esz int) (int, error) {
+	stride := bstride
+	if stride == 0 {
+		stride = esz
+	}
+	switch {
+	case off < 0 || count < 0:
+		return 0, fmt.Errorf("negative byteOffset: %d or count: %d", off, count)
+	case stride < esz:
+		return 0, fmt.Errorf("byteStride: %d is less than the element size: %d", stride, esz)
+	case count == 0:
+		return stride, nil
+	case off > n-esz || count-1 > (n-esz-off)/stride:
+		return 0, fmt.Errorf("%d elements of %d bytes at byteOffset: %d extend beyond the %d bytes", count, esz, off, n)
+	}
+	return stride, nil
+}
+
+// readElements reads count elements of ncomp components each, of given
+// component type, from given buffer view starting at given byte offset
+func (dec *Decoder) readElements(bvi, off, count, ncomp, ctyp int, norm bool) ([]float32, error) {
+	data, bv, err := dec.bufferViewData(bvi)
+	if err != nil {
+		return nil, err
+	}
+	csz := CompSize(ctyp)
+	stride, err := elementsRange(len(data), off, count, bv.ByteStride, ncomp*csz)
+	if err != nil {
+		return nil, fmt.Errorf("gltf.Decoder: bufferView: %d: %v", bvi, err)
+	}
+	vals := make([]float32, count*ncomp)
+	for i := 0; i < count; i++ {
+		eo := off + i*stride
+		for c := 0; c < ncomp; c++ {
+			vals[i*ncomp+c] = readComp(data[eo+c*csz:], ctyp, norm)
+		}
+	}
+	return vals, nil
+}
+
+// AccessorFloats returns the values of given accessor as floats, along
+// with the number of components per element
+func (dec *Decoder) AccessorFloats(ai int) ([]float32, int, error) {
+	if ai < 0 || ai >= len(dec.Doc.Accessors) {
+		return nil, 0, fmt.Errorf("gltf.Decoder: accessor index: %d out of range", ai)
+	}
+	ac := &dec.Doc.Accessors[ai]
+	ncomp := NComps(ac.Type)
+	if ncomp == 0 || CompSize(ac.ComponentType) == 0 {
+		return nil, 0, fmt.Errorf("gltf.Decoder: accessor: %d has invalid type: %v or component type: %d", ai, ac.Type, ac.ComponentType)
+	}
+	var vals []float32
+	var err error
+	switch {
+	case ac.Count < 0:
+		return nil, 0, fmt.Errorf("gltf.Decoder: accessor: %d has negative count: %d", ai, ac.Count)
+	case ac.BufferView != nil:
+		if vals, err = dec.readElements(*ac.BufferView, ac.ByteOffset, ac.Count, ncomp, ac.ComponentType, ac.Normalized); err != nil {
+			return nil, 0, err
+		}
+	case ac.Count > MaxAccessorCount:
+		return nil, 0, fmt.Errorf("gltf.Decoder: accessor: %d without a bufferView has count: %d > MaxAccessorCount", ai, ac.Count)
+	default:
+		vals = make([]float32, ac.Count*ncomp)
+	}
+	if sp := ac.Sparse; sp != nil && sp.Count != 0 {
+		if !IsIndexType(sp.Indices.ComponentType) {
+			return nil, 0, fmt.Errorf("gltf.Decoder: accessor: %d has invalid sparse indices component type: %d", ai, sp.Indices.ComponentType)
+		}
+		idxs, err := dec.readElements(sp.Indices.BufferView, sp.Indices.ByteOffset, sp.Count, 1, sp.Indices.ComponentType, false)
+		if err != nil {
+			return nil, 0, err
+		}
+		svals, err := dec.readElements(sp.Values.BufferView, sp.Values.ByteOffset, sp.Count, ncomp, ac.ComponentType, ac.Normalized)
+		if err != nil {
+			return nil, 0, err
+		}
+		for i, fi := range idxs {
+			ei := int(fi)
+			if ei < ac.Count {
+				copy(vals[ei*ncomp:(ei+1)*ncomp], svals[i*ncomp:(i+1)*ncomp])
+			}
+		}
+	}
+	return vals, ncomp, nil
+}
+
+// AccessorIndexes returns the values of given scalar integer accessor,
+// used for vertex indexes
+func (dec *Decoder) AccessorIndexes(ai int) ([]uint32, error) {
+	if ai < 0 || ai >= len(dec.Doc.Accessors) {
+		return nil, fmt.Errorf("gltf.Decoder: accessor index: %d out of range", ai)
+	}
+	ac := &dec.Doc.Accessors[ai]
+	if ac.Type != "SCALAR" || !IsIndexType(ac.ComponentType) {
+		return nil, fmt.Errorf("gltf.Decoder: index accessor: %d has type: %v and component type: %d, instead of an unsigned integer SCALAR", ai, ac.Type, ac.ComponentType)
+	}
+	if ac.BufferView == nil || ac.Sparse != nil {
+		vals, _, err := dec.AccessorFloats(ai)
+		if err != nil {
+			return nil, err
+		}
+		idxs := make([]uint32, len(vals))
+		for i, v := range vals {
+			idxs[i] = uint32(v)
+		}
+		return idxs, nil
+	}
+	data, bv, err := dec.bufferViewData(*ac.BufferView)
+	if err != nil {
+		return nil, err
+	}
+	stride, err := elementsRange(len(data), ac.ByteOffset, ac.Count, bv.ByteStride, CompSize(ac.ComponentType))
+	if err != nil {
+		return nil, fmt.Errorf("gltf.Decoder: accessor: %d: %v", ai, err)
+	}
+	idxs := make([]uint32, ac.Count)
+	for i := range idxs {
+		idxs[i] = readIndex(data[ac.ByteOffset+i*stride:], ac.ComponentType)
+	}
+	return idxs, nil
+}
+
+// ImageData returns the encoded data for given image index, from a buffer
+// view or data URI, or "" and the file name for linked images
+func (dec *Decoder) ImageData(ii int) ([]byte, string, error) {
+	if ii < 0 || ii >= len(dec.Doc.Images) {
+		return nil, "", fmt.Errorf("gltf.Decoder: image index: %d out of range", ii)
+	}
+	im := &dec.Doc.Images[ii]
+	if im.BufferView != nil {
+		data, _, err := dec.bufferViewData(*im.BufferView)
+		return data, "", err
+	}
+	if strings.HasPrefix(im.URI, "data:") {
+		data, err := dec.loadURI(im.URI)
+		return data, "", err
+	}
+	fn, err := url.PathUnescape(im.URI)
+	if err != nil {
+		fn = im.URI
+	}
+	if !filepath.IsAbs(fn) {
+		fn = filepath.Join(dec.Dir, fn)
+	}
+	return nil, fn, nil
+}
+
+func (dec *Decoder) appendWarn(msg string) {
+	dec.Warnings = append(dec.Warnings, msg)
+}
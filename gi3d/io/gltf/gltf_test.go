@@ -0,0 +1,228 @@
+// Copyright (c) 2020, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gltf
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/goki/gi/gi3d"
+	"github.com/goki/gi/mat32"
+)
+
+// testBuffer returns the binary data for a single triangle:
+// 3 float VEC3 positions followed by 3 ushort indexes (+ padding)
+func testBuffer() []byte {
+	var b bytes.Buffer
+	for _, v := range []float32{0, 0, 0, 1, 0, 0, 0, 1, 0} {
+		binary.Write(&b, binary.LittleEndian, math.Float32bits(v))
+	}
+	for _, i := range []uint16{0, 1, 2, 0} {
+		binary.Write(&b, binary.LittleEndian, i)
+	}
+	return b.Bytes()
+}
+
+func testJSON(uri string) string {
+	return fmt.Sprintf(`{
+"asset": {"version": "2.0"},
+"extensionsUsed": ["KHR_lights_punctual"],
+"extensions": {"KHR_lights_punctual": {"lights": [{"name": "sun", "type": "directional", "color": [1, 0.5, 0], "intensity": 0.8}]}},
+"scene": 0,
+"scenes": [{"nodes": [0, 2, 3]}],
+"nodes": [
+	{"name": "parent", "translation": [1, 2, 3], "children": [1]},
+	{"name": "tri", "mesh": 0, "scale": [2, 2, 2]},
+	{"name": "cam", "camera": 0, "translation": [0, 0, 5]},
+	{"name": "sunnode", "extensions": {"KHR_lights_punctual": {"light": 0}}}
+],
+"meshes": [{"name": "triangle", "primitives": [{"attributes": {"POSITION": 0}, "indices": 1, "material": 0}]}],
+"materials": [{"name": "red", "pbrMetallicRoughness": {"baseColorFactor": [1, 0, 0, 1], "metallicFactor": 0, "roughnessFactor": 0.5}, "doubleSided": true}],
+"cameras": [{"type": "perspective", "perspective": {"yfov": 0.5, "znear": 0.1, "zfar": 100}}],
+"accessors": [
+	{"bufferView": 0, "componentType": 5126, "count": 3, "type": "VEC3"},
+	{"bufferView": 1, "componentType": 5123, "count": 3, "type": "SCALAR"}
+],
+"bufferViews": [{"buffer": 0, "byteOffset": 0, "byteLength": 36}, {"buffer": 0, "byteOffset": 36, "byteLength": 6}],
+"buffers": [{%s"byteLength": 44}]
+}`, uri)
+}
+
+// testGLB returns the test scene in glb format
+func testGLB() []byte {
+	js := []byte(testJSON(""))
+	for len(js)%4 != 0 {
+		js = append(js, ' ')
+	}
+	bin := testBuffer()
+	var b bytes.Buffer
+	le := binary.LittleEndian
+	binary.Write(&b, le, uint32(glbMagic))
+	binary.Write(&b, le, uint32(2))
+	binary.Write(&b, le, uint32(12+8+len(js)+8+len(bin)))
+	binary.Write(&b, le, uint32(len(js)))
+	binary.Write(&b, le, uint32(glbChunkJSON))
+	b.Write(js)
+	binary.Write(&b, le, uint32(len(bin)))
+	binary.Write(&b, le, uint32(glbChunkBIN))
+	b.Write(bin)
+	return b.Bytes()
+}
+
+func TestDecodeScene(t *testing.T) {
+	uri := `"uri": "data:application/octet-stream;base64,` + base64.StdEncoding.EncodeToString(testBuffer()) + `", `
+	for fn, data := range map[string][]byte{"test.gltf": []byte(testJSON(uri)), "test.glb": testGLB()} {
+		dec := gi3d.Decoders[".gltf"].New()
+		dec.SetFile(fn)
+		if err := dec.Decode([]io.Reader{bytes.NewReader(data)}); err != nil {
+			t.Fatalf("%v: %v", fn, err)
+		}
+		sc := &gi3d.Scene{}
+		sc.InitName(sc, "scene")
+		sc.Defaults()
+		dec.SetScene(sc)
+		if w := dec.(*Decoder).Warnings; len(w) > 0 {
+			t.Errorf("%v: warnings: %v", fn, w)
+		}
+
+		par, ok := sc.ChildByName("parent", 0).(*gi3d.Group)
+		if !ok {
+			t.Fatalf("%v: parent group not found: %v", fn, sc.Kids)
+		}
+		if par.Pose.Pos != (mat32.Vec3{X: 1, Y: 2, Z: 3}) {
+			t.Errorf("%v: parent pos: %v", fn, par.Pose.Pos)
+		}
+		sld, ok := par.ChildByName("tri", 0).(*gi3d.Solid)
+		if !ok {
+			t.Fatalf("%v: solid not found: %v", fn, par.Kids)
+		}
+		if sld.Pose.Scale != (mat32.Vec3{X: 2, Y: 2, Z: 2}) {
+			t.Errorf("%v: solid scale: %v", fn, sld.Pose.Scale)
+		}
+		if sld.Mat.Color.R != 255 || sld.Mat.Color.G != 0 || sld.Mat.CullBack {
+			t.Errorf("%v: material not set: %v", fn, sld.Mat)
+		}
+		ms := sc.MeshByName(string(sld.Mesh))
+		if ms == nil {
+			t.Fatalf("%v: mesh: %v not found", fn, sld.Mesh)
+		}
+		mb := ms.AsMeshBase()
+		if len(mb.Vtx) != 9 || len(mb.Idx) != 3 || len(mb.Norm) != 9 {
+			t.Errorf("%v: mesh data: vtx: %v idx: %v norm: %v", fn, mb.Vtx, mb.Idx, mb.Norm)
+		}
+		if nz := mb.Norm[2]; nz < .999 {
+			t.Errorf("%v: computed normal should be +Z: %v", fn, mb.Norm)
+		}
+
+		if mat32.Abs(sc.Camera.FOV-mat32.RadToDeg(0.5)) > 1e-4 || sc.Camera.Pose.Pos.Z != 5 || sc.Camera.Far != 100 {
+			t.Errorf("%v: camera not set: %v", fn, sc.Camera)
+		}
+		lt, ok := sc.Lights["sun"].(*gi3d.DirLight)
+		if !ok {
+			t.Fatalf("%v: light not found: %v", fn, sc.Lights)
+		}
+		if lt.Lumns != 0.8 || lt.Clr.R != 255 || lt.Clr.B != 0 || lt.Pos.Z < .999 {
+			t.Errorf("%v: light not set: %v", fn, lt)
+		}
+	}
+}
+
+func TestTriangles(t *testing.T) {
+	strip := Triangles([]uint32{0, 1, 2, 3}, ModeTriangleStrip)
+	if fmt.Sprint(strip) != "[0 1 2 2 1 3]" {
+		t.Errorf("strip: %v", strip)
+	}
+	fan := Triangles([]uint32{0, 1, 2, 3}, ModeTriangleFan)
+	if fmt.Sprint(fan) != "[0 1 2 0 2 3]" {
+		t.Errorf("fan: %v", fan)
+	}
+}
+
+// decodeNoPanic decodes given data and sets it into a new scene, failing
+// the test if that panics, and returns the Decoder and Decode error
+func decodeNoPanic(t *testing.T, what string, data []byte) (dec *Decoder, err error) {
+	t.Helper()
+	defer func() {
+		if r := recover(); r != nil {
+			t.Errorf("%v: panic: %v", what, r)
+		}
+	}()
+	dec = gi3d.Decoders[".gltf"].New().(*Decoder)
+	dec.SetFile("test.glb")
+	if err = dec.Decode([]io.Reader{bytes.NewReader(data)}); err != nil {
+		return dec, err
+	}
+	sc := &gi3d.Scene{}
+	sc.InitName(sc, "scene")
+	sc.Defaults()
+	dec.SetScene(sc)
+	return dec, nil
+}
+
+func TestDecodeMalformed(t *testing.T) {
+	glb := testGLB()
+	hdr := append([]byte{}, glb...)
+	binary.LittleEndian.PutUint32(hdr[8:], 4)
+	if _, err := decodeNoPanic(t, "header length 4", hdr); err == nil {
+		t.Errorf("no error for glb header length < 12")
+	}
+	for n := 0; n < len(glb); n++ {
+		if _, err := decodeNoPanic(t, fmt.Sprintf("truncated to %d", n), glb[:n]); err == nil && n > 0 {
+			t.Errorf("truncated to %d: no error", n)
+		}
+	}
+	rnd := rand.New(rand.NewSource(1))
+	for i := 0; i < 2000; i++ {
+		mut := append([]byte{}, glb...)
+		for j := rnd.Intn(4); j >= 0; j-- {
+			mut[12+rnd.Intn(len(mut)-12)] = byte(rnd.Intn(256))
+		}
+		decodeNoPanic(t, fmt.Sprintf("mutation %d", i), mut)
+	}
+
+	uri := `"uri": "data:application/octet-stream;base64,` + base64.StdEncoding.EncodeToString(testBuffer()) + `", `
+	js := testJSON(uri)
+	tests := []struct {
+		what, old, new string
+		acc            int // accessor that must have an error
+	}{
+		{"negative bufferView offset", `"byteOffset": 36, "byteLength": 6`, `"byteOffset": -4, "byteLength": 6`, 1},
+		{"negative bufferView length", `"byteOffset": 0, "byteLength": 36`, `"byteOffset": 0, "byteLength": -36`, 0},
+		{"negative byteStride", `"byteOffset": 0, "byteLength": 36}`, `"byteOffset": 0, "byteLength": 36, "byteStride": -12}`, 0},
+		{"negative accessor offset", `{"bufferView": 0, "componentType"`, `{"bufferView": 0, "byteOffset": -12, "componentType"`, 0},
+		{"negative count", `"count": 3, "type": "VEC3"`, `"count": -3, "type": "VEC3"`, 0},
+		{"huge count", `"count": 3, "type": "VEC3"`, `"count": 3000000000, "type": "VEC3"`, 0},
+		{"negative index count", `"count": 3, "type": "SCALAR"`, `"count": -3, "type": "SCALAR"`, 1},
+		{"float indexes", `"componentType": 5123`, `"componentType": 5126`, 1},
+		{"unknown index type", `"componentType": 5123`, `"componentType": 9999`, 1},
+		{"vector indexes", `"count": 3, "type": "SCALAR"`, `"count": 3, "type": "VEC3"`, 1},
+	}
+	for _, tst := range tests {
+		mjs := strings.Replace(js, tst.old, tst.new, 1)
+		if mjs == js {
+			t.Fatalf("%v: %v not found in the test json", tst.what, tst.old)
+		}
+		dec, err := decodeNoPanic(t, tst.what, []byte(mjs))
+		if err != nil {
+			t.Errorf("%v: Decode error: %v", tst.what, err)
+			continue
+		}
+		if tst.acc == 0 {
+			_, _, err = dec.AccessorFloats(0)
+		} else {
+			_, err = dec.AccessorIndexes(1)
+		}
+		if err == nil {
+			t.Errorf("%v: no error for accessor: %d", tst.what, tst.acc)
+		}
+	}
+}
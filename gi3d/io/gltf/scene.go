@@ -0,0 +1,490 @@
+// Copyright (c) 2020, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gltf
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"path/filepath"
+	"strings"
+
+	"github.com/goki/gi/gi3d"
+	"github.com/goki/gi/mat32"
+	"github.com/goki/ki/ki"
+)
+
+// SetScene sets the scene with the nodes of the default scene in the file
+// (or all root nodes if no scenes are defined), along with the first
+// camera found in the node hierarchy, and all lights.
+func (dec *Decoder) SetScene(sc *gi3d.Scene) {
+	dec.setRoots(sc, sc, true)
+}
+
+// SetGroup sets group with the nodes of the default scene in the file --
+// cameras and lights are ignored.
+func (dec *Decoder) SetGroup(sc *gi3d.Scene, gp *gi3d.Group) {
+	dec.setRoots(sc, gp, false)
+}
+
+// RootNodes returns the indexes of the root nodes of the default scene,
+// or all nodes that are not children of other nodes if there are no scenes
+func (dec *Decoder) RootNodes() []int {
+	doc := &dec.Doc
+	if len(doc.Scenes) > 0 {
+		si := 0
+		if doc.Scene != nil && *doc.Scene < len(doc.Scenes) {
+			si = *doc.Scene
+		}
+		return doc.Scenes[si].Nodes
+	}
+	isKid := make([]bool, len(doc.Nodes))
+	for _, nd := range doc.Nodes {
+		for _, ci := range nd.Children {
+			if ci >= 0 && ci < len(isKid) {
+				isKid[ci] = true
+			}
+		}
+	}
+	var roots []int
+	for i, kid := range isKid {
+		if !kid {
+			roots = append(roots, i)
+		}
+	}
+	return roots
+}
+
+// setRoots adds the root nodes to given parent, with full scene info
+// (cameras and lights) if full is set
+func (dec *Decoder) setRoots(sc *gi3d.Scene, par ki.Ki, full bool) {
+	dec.meshes = make(map[int][]string)
+	dec.textures = make(map[int]gi3d.Texture)
+	ident := mat32.NewMat4()
+	hasCam := false
+	visited := make(map[int]bool)
+	for _, ni := range dec.RootNodes() {
+		dec.setNode(sc, par, ni, ident, full, &hasCam, visited)
+	}
+}
+
+// setNode adds node of given index and its children to given parent,
+// with parWorld the world matrix of the parent
+func (dec *Decoder) setNode(sc *gi3d.Scene, par ki.Ki, ni int, parWorld *mat32.Mat4, full bool, hasCam *bool, visited map[int]bool) {
+	if ni < 0 || ni >= len(dec.Doc.Nodes) || visited[ni] {
+		dec.appendWarn(fmt.Sprintf("node: %d is out of range or has multiple parents -- skipped", ni))
+		return
+	}
+	visited[ni] = true
+	nd := &dec.Doc.Nodes[ni]
+	nm := nd.Name
+	if nm == "" {
+		nm = fmt.Sprintf("node%d", ni)
+	}
+	var pose gi3d.Pose
+	SetPose(&pose, nd)
+	var world mat32.Mat4
+	world.MulMatrices(parWorld, &pose.Matrix)
+
+	var prims []int // valid primitives, with mesh names in pnms
+	var pnms []string
+	if nd.Mesh != nil {
+		for pi, pnm := range dec.setMesh(sc, *nd.Mesh) {
+			if pnm != "" {
+				prims = append(prims, pi)
+				pnms = append(pnms, pnm)
+			}
+		}
+	}
+	var n3 *gi3d.Node3DBase
+	if len(prims) == 1 && len(nd.Children) == 0 {
+		sld := gi3d.AddNewSolid(sc, par, nm, pnms[0])
+		dec.setMat(sc, sld, *nd.Mesh, prims[0])
+		n3 = sld.AsNode3D()
+	} else {
+		gp := gi3d.AddNewGroup(sc, par, nm)
+		for i, pi := range prims {
+			sld := gi3d.AddNewSolid(sc, gp, fmt.Sprintf("%s_%d", nm, pi), pnms[i])
+			dec.setMat(sc, sld, *nd.Mesh, pi)
+		}
+		n3 = gp.AsNode3D()
+	}
+	n3.Pose = pose
+
+	if full {
+		if nd.Camera != nil && !*hasCam {
+			*hasCam = dec.setCamera(sc, *nd.Camera, &world)
+		}
+		if nd.Extensions != nil && nd.Extensions.LightsPunctual != nil {
+			dec.setLight(sc, nd.Extensions.LightsPunctual.Light, nm, &world)
+		}
+	}
+	for _, ci := range nd.Children {
+		dec.setNode(sc, n3.This(), ci, &world, full, hasCam, visited)
+	}
+}
+
+// SetPose sets the pose from the node transform
+func SetPose(ps *gi3d.Pose, nd *Node) {
+	if len(nd.Matrix) == 16 {
+		var m mat32.Mat4
+		m.FromArray(nd.Matrix, 0)
+		ps.SetMatrix(&m)
+		return
+	}
+	ps.Scale.Set(1, 1, 1)
+	ps.Quat.SetIdentity()
+	if len(nd.Translation) == 3 {
+		ps.Pos.Set(nd.Translation[0], nd.Translation[1], nd.Translation[2])
+	}
+	if len(nd.Rotation) == 4 {
+		ps.Quat.Set(nd.Rotation[0], nd.Rotation[1], nd.Rotation[2], nd.Rotation[3])
+	}
+	if len(nd.Scale) == 3 {
+		ps.Scale.Set(nd.Scale[0], nd.Scale[1], nd.Scale[2])
+	}
+	ps.UpdateMatrix()
+}
+
+// setMesh makes the gi3d meshes for the primitives of given mesh index,
+// returning their names -- meshes are only made once, and shared by all
+// nodes that use them.  Primitives that cannot be decoded have an empty name.
+func (dec *Decoder) setMesh(sc *gi3d.Scene, mi int) []string {
+	if nms, has := dec.meshes[mi]; has {
+		return nms
+	}
+	var nms []string
+	if mi < 0 || mi >= len(dec.Doc.Meshes) {
+		dec.appendWarn(fmt.Sprintf("mesh index: %d out of range", mi))
+		dec.meshes[mi] = nms
+		return nms
+	}
+	mesh := &dec.Doc.Meshes[mi]
+	mnm := mesh.Name
+	if mnm == "" {
+		mnm = fmt.Sprintf("mesh%d", mi)
+	}
+	for pi := range mesh.Primitives {
+		ms := &gi3d.GenMesh{}
+		ms.Nm = fmt.Sprintf("%s_%d", mnm, pi)
+		if err := dec.SetPrimitive(&ms.MeshBase, &mesh.Primitives[pi]); err != nil {
+			dec.appendWarn(fmt.Sprintf("mesh: %v primitive: %d: %v", mnm, pi, err))
+			nms = append(nms, "")
+			continue
+		}
+		sc.AddMeshUnique(ms)
+		nms = append(nms, ms.Nm)
+	}
+	dec.meshes[mi] = nms
+	return nms
+}
+
+// SetPrimitive sets the mesh data from given primitive: vertex positions,
+// normals (computed if not present), texture coordinates, colors, and
+// triangle indexes
+func (dec *Decoder) SetPrimitive(ms *gi3d.MeshBase, pr *Primitive) error {
+	mode := ModeTriangles
+	if pr.Mode != nil {
+		mode = *pr.Mode
+	}
+	if mode != ModeTriangles && mode != ModeTriangleStrip && mode != ModeTriangleFan {
+		return fmt.Errorf("primitive mode: %d not supported -- only triangles", mode)
+	}
+	pai, has := pr.Attributes["POSITION"]
+	if !has {
+		return fmt.Errorf("no POSITION attribute")
+	}
+	vtx, nc, err := dec.AccessorFloats(pai)
+	if err != nil {
+		return err
+	}
+	if nc != 3 {
+		return fmt.Errorf("POSITION must be VEC3")
+	}
+	nv := len(vtx) / 3
+	ms.Vtx = vtx
+	if ai, has := pr.Attributes["NORMAL"]; has {
+		if nrm, nc, err := dec.AccessorFloats(ai); err == nil && nc == 3 && len(nrm) == len(vtx) {
+			ms.Norm = nrm
+		}
+	}
+	if ai, has := pr.Attributes["TEXCOORD_0"]; has {
+		if tex, nc, err := dec.AccessorFloats(ai); err == nil && nc == 2 && len(tex)/2 == nv {
+			ms.Tex = tex
+		}
+	}
+	if ai, has := pr.Attributes["COLOR_0"]; has {
+		if clr, nc, err := dec.AccessorFloats(ai); err == nil && (nc == 3 || nc == 4) && len(clr)/nc == nv {
+			if nc == 3 {
+				ms.Color = make(mat32.ArrayF32, 0, nv*4)
+				for i := 0; i < nv; i++ {
+					ms.Color.Append(clr[i*3], clr[i*3+1], clr[i*3+2], 1)
+				}
+			} else {
+				ms.Color = clr
+			}
+		}
+	}
+	var idx []uint32
+	if pr.Indices != nil {
+		if idx, err = dec.AccessorIndexes(*pr.Indices); err != nil {
+			return err
+		}
+	} else {
+		idx = make([]uint32, nv)
+		for i := range idx {
+			idx[i] = uint32(i)
+		}
+	}
+	for _, ix := range idx {
+		if int(ix) >= nv {
+			return fmt.Errorf("vertex index: %d out of range", ix)
+		}
+	}
+	ms.Idx = Triangles(idx, mode)
+	if len(ms.Norm) == 0 {
+		ms.Norm = ComputeNorms(ms.Vtx, ms.Idx)
+	}
+	return nil
+}
+
+// Triangles returns the triangle indexes for given indexes in given
+// primitive mode (triangles, triangle strip or fan)
+func Triangles(idx []uint32, mode int) mat32.ArrayU32 {
+	switch mode {
+	case ModeTriangleStrip:
+		tri := make(mat32.ArrayU32, 0, 3*len(idx))
+		for i := 2; i < len(idx); i++ {
+			if i%2 == 0 {
+				tri.Append(idx[i-2], idx[i-1], idx[i])
+			} else {
+				tri.Append(idx[i-1], idx[i-2], idx[i])
+			}
+		}
+		return tri
+	case ModeTriangleFan:
+		tri := make(mat32.ArrayU32, 0, 3*len(idx))
+		for i := 2; i < len(idx); i++ {
+			tri.Append(idx[0], idx[i-1], idx[i])
+		}
+		return tri
+	}
+	return mat32.ArrayU32(idx[:len(idx)/3*3])
+}
+
+// ComputeNorms returns smooth vertex normals for given vertex positions
+// and triangle indexes, as the normalized sum of the face normals
+// of the triangles sharing each vertex
+func ComputeNorms(vtx mat32.ArrayF32, idx mat32.ArrayU32) mat32.ArrayF32 {
+	norm := make(mat32.ArrayF32, len(vtx))
+	var a, b, c mat32.Vec3
+	for i := 0; i+2 < len(idx); i += 3 {
+		vtx.GetVec3(3*int(idx[i]), &a)
+		vtx.GetVec3(3*int(idx[i+1]), &b)
+		vtx.GetVec3(3*int(idx[i+2]), &c)
+		fn := b.Sub(a).Cross(c.Sub(a)) // area-weighted
+		for _, vi := range idx[i : i+3] {
+			var n mat32.Vec3
+			norm.GetVec3(3*int(vi), &n)
+			norm.SetVec3(3*int(vi), n.Add(fn))
+		}
+	}
+	for i := 0; i < len(norm); i += 3 {
+		var n mat32.Vec3
+		norm.GetVec3(i, &n)
+		norm.SetVec3(i, n.Normal())
+	}
+	return norm
+}
+
+// setMat sets the material for solid from given primitive of given mesh
+func (dec *Decoder) setMat(sc *gi3d.Scene, sld *gi3d.Solid, mi, pi int) {
+	pr := &dec.Doc.Meshes[mi].Primitives[pi]
+	if pr.Material == nil || *pr.Material < 0 || *pr.Material >= len(dec.Doc.Materials) {
+		return // default material
+	}
+	mat := &dec.Doc.Materials[*pr.Material]
+	SetMaterial(&sld.Mat, mat)
+	mt := &sld.Mat
+	if pbr := mat.PbrMetallicRoughness; pbr != nil && pbr.BaseColorTexture != nil {
+		if tex := dec.texture(sc, pbr.BaseColorTexture.Index); tex != nil {
+			if mat.AlphaMode == "BLEND" {
+				tex.SetTransparent(true)
+			}
+			mt.SetTexture(sc, tex)
+		}
+	}
+}
+
+// SetMaterial sets the gi3d material from given glTF material.  The
+// physically-based metallic-roughness parameters are approximated in
+// the Phong model: the specular color is white for non-metallic surfaces
+// and the base color for metallic ones, and is dimmer and spread more
+// broadly as roughness increases.
+func SetMaterial(mt *gi3d.Material, mat *Material) {
+	mt.Defaults()
+	base := []float32{1, 1, 1, 1}
+	metal := float32(1)
+	rough := float32(1)
+	if pbr := mat.PbrMetallicRoughness; pbr != nil {
+		if len(pbr.BaseColorFactor) == 4 {
+			base = pbr.BaseColorFactor
+		}
+		if pbr.MetallicFactor != nil {
+			metal = *pbr.MetallicFactor
+		}
+		if pbr.RoughnessFactor != nil {
+			rough = *pbr.RoughnessFactor
+		}
+	}
+	alpha := base[3]
+	if mat.AlphaMode != "BLEND" {
+		alpha = 1
+	}
+	mt.Color.SetFloat32(base[0], base[1], base[2], alpha)
+	if len(mat.EmissiveFactor) == 3 {
+		ef := mat.EmissiveFactor
+		if ef[0] != 0 || ef[1] != 0 || ef[2] != 0 {
+			mt.Emissive.SetFloat32(ef[0], ef[1], ef[2], 1)
+		}
+	}
+	spc := 1 - mat32.Clamp(rough, 0, 1)
+	var sc [3]float32
+	for i := range sc {
+		sc[i] = spc * (1 - metal + metal*base[i])
+	}
+	mt.Specular.SetFloat32(sc[0], sc[1], sc[2], 1)
+	mt.Shiny = 1 + 127*spc*spc
+	mt.CullBack = !mat.DoubleSided
+}
+
+// texture returns the gi3d texture for given texture index, adding it to
+// the scene the first time -- embedded images are decoded, while linked
+// ones are loaded from their files
+func (dec *Decoder) texture(sc *gi3d.Scene, ti int) gi3d.Texture {
+	if ti < 0 || ti >= len(dec.Doc.Textures) || dec.Doc.Textures[ti].Source == nil {
+		dec.appendWarn(fmt.Sprintf("texture index: %d out of range or has no source", ti))
+		return nil
+	}
+	ii := *dec.Doc.Textures[ti].Source
+	if tex, has := dec.textures[ii]; has {
+		return tex
+	}
+	data, fn, err := dec.ImageData(ii)
+	if err != nil {
+		dec.appendWarn(err.Error())
+		dec.textures[ii] = nil
+		return nil
+	}
+	var tex gi3d.Texture
+	if fn != "" {
+		_, tfn := filepath.Split(fn)
+		tex = gi3d.AddNewTextureFile(sc, tfn, fn)
+	} else {
+		img, _, err := image.Decode(bytes.NewReader(data))
+		if err != nil {
+			dec.appendWarn(fmt.Sprintf("image: %d: %v", ii, err))
+			dec.textures[ii] = nil
+			return nil
+		}
+		nm := dec.Doc.Images[ii].Name
+		if nm == "" {
+			nm = fmt.Sprintf("%s_image%d", strings.TrimSuffix(dec.File, filepath.Ext(dec.File)), ii)
+		}
+		tex = gi3d.AddNewTextureImage(sc, nm, img)
+	}
+	dec.textures[ii] = tex
+	return tex
+}
+
+// setCamera sets the scene camera from given camera index, with given
+// world matrix of its node -- returns false if not valid
+func (dec *Decoder) setCamera(sc *gi3d.Scene, ci int, world *mat32.Mat4) bool {
+	if ci < 0 || ci >= len(dec.Doc.Cameras) {
+		dec.appendWarn(fmt.Sprintf("camera index: %d out of range", ci))
+		return false
+	}
+	cam := &dec.Doc.Cameras[ci]
+	cm := &sc.Camera
+	switch {
+	case cam.Perspective != nil:
+		cm.Ortho = false
+		cm.FOV = mat32.RadToDeg(cam.Perspective.Yfov)
+		if cam.Perspective.AspectRatio > 0 {
+			cm.Aspect = cam.Perspective.AspectRatio
+		}
+		cm.Near = cam.Perspective.Znear
+		if cam.Perspective.Zfar > 0 {
+			cm.Far = cam.Perspective.Zfar
+		}
+	case cam.Orthographic != nil:
+		cm.Ortho = true
+		cm.Near = cam.Orthographic.Znear
+		cm.Far = cam.Orthographic.Zfar
+	default:
+		return false
+	}
+	cm.Pose.SetMatrix(world)
+	cm.UpDir = mat32.Vec3Y.MulMat4AsVec4(world, 0).Normal()
+	dist := cm.Pose.Pos.Length()
+	if dist == 0 {
+		dist = 10
+	}
+	fwd := mat32.Vec3{Z: -1}.MulMat4AsVec4(world, 0).Normal()
+	cm.Target = cm.Pose.Pos.Add(fwd.MulScalar(dist))
+	return true
+}
+
+// setLight adds the light of given index to the scene, with given node
+// name and world matrix.  The light intensity is used as the Lumens.
+func (dec *Decoder) setLight(sc *gi3d.Scene, li int, ndnm string, world *mat32.Mat4) {
+	var lp *Lights
+	if dec.Doc.Extensions != nil {
+		lp = dec.Doc.Extensions.LightsPunctual
+	}
+	if lp == nil || li < 0 || li >= len(lp.Lights) {
+		dec.appendWarn(fmt.Sprintf("light index: %d out of range", li))
+		return
+	}
+	lt := &lp.Lights[li]
+	nm := lt.Name
+	if nm == "" {
+		nm = ndnm
+	}
+	if _, has := sc.Lights[nm]; has {
+		nm = ndnm + "_" + nm
+	}
+	lumens := float32(1)
+	if lt.Intensity != nil {
+		lumens = *lt.Intensity
+	}
+	var lb *gi3d.LightBase
+	switch lt.Type {
+	case "directional":
+		dl := gi3d.AddNewDirLight(sc, nm, lumens, gi3d.DirectSun)
+		// lights point along -Z, and Pos is direction from origin
+		dl.Pos = mat32.Vec3{Z: 1}.MulMat4AsVec4(world, 0).Normal()
+		lb = &dl.LightBase
+	case "point":
+		pl := gi3d.AddNewPointLight(sc, nm, lumens, gi3d.DirectSun)
+		pl.Pos = world.Pos()
+		lb = &pl.LightBase
+	case "spot":
+		sl := gi3d.AddNewSpotLight(sc, nm, lumens, gi3d.DirectSun)
+		sl.Pose.SetMatrix(world)
+		if lt.Spot != nil && lt.Spot.OuterConeAngle != nil {
+			sl.CutoffAngle = mat32.RadToDeg(*lt.Spot.OuterConeAngle)
+		}
+		lb = &sl.LightBase
+	default:
+		dec.appendWarn(fmt.Sprintf("light type: %v not supported", lt.Type))
+		return
+	}
+	if len(lt.Color) == 3 {
+		lb.Clr.SetFloat32(lt.Color[0], lt.Color[1], lt.Color[2], 1)
+	}
+}
@@ -0,0 +1,195 @@
+// Copyright (c) 2020, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package obj
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/goki/gi/gi"
+	"github.com/goki/gi/gi3d"
+	"github.com/goki/gi/mat32"
+	"github.com/goki/ki/ki"
+)
+
+// Encoder writes the solids in a scene or group to the obj and mtl files,
+// with the Pose transforms applied to the vertex data.
+// It implements the gi3d.Encoder interface and an instance
+// is registered to handle .obj files.
+type Encoder struct {
+	Objfile string      // .obj filename (without path)
+	Objdir  string      // path to .obj file
+	Scene   *gi3d.Scene // scene with the meshes and textures
+	Par     ki.Ki       // parent of the solids to encode
+	matNms  map[string]bool
+}
+
+func (enc *Encoder) New() gi3d.Encoder {
+	return new(Encoder)
+}
+
+func (enc *Encoder) Desc() string {
+	return ".obj = Wavefront OBJ format, with materials (.mtl) written to a file of the same name.  Only supports Object-level data, not full Scene (camera, lights etc) -- Pose transforms are applied to the vertex data."
+}
+
+func (enc *Encoder) HasScene() bool {
+	return false
+}
+
+func (enc *Encoder) SetFile(fname string) []string {
+	enc.Objdir, enc.Objfile = filepath.Split(fname)
+	return []string{fname, strings.TrimSuffix(fname, ".obj") + ".mtl"}
+}
+
+func (enc *Encoder) SetGroup(sc *gi3d.Scene, gp *gi3d.Group) {
+	enc.Scene = sc
+	enc.Par = gp
+}
+
+func (enc *Encoder) SetScene(sc *gi3d.Scene) {
+	enc.Scene = sc
+	enc.Par = sc
+}
+
+// Encode writes the obj data to the first writer, and the mtl data to
+// the second if present.
+func (enc *Encoder) Encode(ws []io.Writer) error {
+	if len(ws) == 0 {
+		return errors.New("obj.Encoder: no writers passed")
+	}
+	if enc.Scene == nil || enc.Par == nil {
+		return errors.New("obj.Encoder: SetGroup or SetScene must be called first")
+	}
+	ow := bufio.NewWriter(ws[0])
+	var mw *bufio.Writer
+	if len(ws) > 1 {
+		mw = bufio.NewWriter(ws[1])
+		fmt.Fprintf(ow, "mtllib %s\n", strings.TrimSuffix(enc.Objfile, ".obj")+".mtl")
+	}
+	enc.matNms = make(map[string]bool)
+	nvtx, ntex, nnrm := 0, 0, 0
+	gi3d.SolidsXForm(enc.Par, func(sld *gi3d.Solid, xf *mat32.Mat4) {
+		ms := enc.Scene.SolidMesh(sld)
+		if ms == nil {
+			return
+		}
+		mb := ms.AsMeshBase()
+		nv := len(mb.Vtx) / 3
+		if nv == 0 {
+			return
+		}
+		hasTex := len(mb.Tex)/2 == nv
+		hasNrm := len(mb.Norm)/3 == nv
+		hasClr := len(mb.Color)/4 == nv
+		var nxf mat32.Mat3
+		nxf.SetNormalMatrix(xf)
+
+		fmt.Fprintf(ow, "o %s\n", sld.Name())
+		var v mat32.Vec3
+		for i := 0; i < nv; i++ {
+			mb.Vtx.GetVec3(3*i, &v)
+			v = v.MulMat4(xf)
+			if hasClr {
+				c := mb.Color[4*i:]
+				fmt.Fprintf(ow, "v %g %g %g %g %g %g\n", v.X, v.Y, v.Z, c[0], c[1], c[2])
+			} else {
+				fmt.Fprintf(ow, "v %g %g %g\n", v.X, v.Y, v.Z)
+			}
+		}
+		if hasTex {
+			for i := 0; i < nv; i++ {
+				fmt.Fprintf(ow, "vt %g %g\n", mb.Tex[2*i], mb.Tex[2*i+1])
+			}
+		}
+		if hasNrm {
+			for i := 0; i < nv; i++ {
+				mb.Norm.GetVec3(3*i, &v)
+				v = v.MulMat3(&nxf).Normal()
+				fmt.Fprintf(ow, "vn %g %g %g\n", v.X, v.Y, v.Z)
+			}
+		}
+		if mw != nil {
+			fmt.Fprintf(ow, "usemtl %s\n", enc.writeMat(mw, sld))
+		}
+		for i := 0; i+2 < len(mb.Idx); i += 3 {
+			ow.WriteString("f")
+			for _, ix := range mb.Idx[i : i+3] {
+				vi := int(ix) + 1
+				switch {
+				case hasTex && hasNrm:
+					fmt.Fprintf(ow, " %d/%d/%d", nvtx+vi, ntex+vi, nnrm+vi)
+				case hasTex:
+					fmt.Fprintf(ow, " %d/%d", nvtx+vi, ntex+vi)
+				case hasNrm:
+					fmt.Fprintf(ow, " %d//%d", nvtx+vi, nnrm+vi)
+				default:
+					fmt.Fprintf(ow, " %d", nvtx+vi)
+				}
+			}
+			ow.WriteString("\n")
+		}
+		nvtx += nv
+		if hasTex {
+			ntex += nv
+		}
+		if hasNrm {
+			nnrm += nv
+		}
+	})
+	if mw != nil {
+		if err := mw.Flush(); err != nil {
+			return err
+		}
+	}
+	return ow.Flush()
+}
+
+// writeMat writes the material for given solid to the mtl file,
+// returning its unique name
+func (enc *Encoder) writeMat(mw *bufio.Writer, sld *gi3d.Solid) string {
+	nm := strings.Replace(sld.Name(), " ", "_", -1)
+	if enc.matNms[nm] {
+		nm = fmt.Sprintf("%s_%d", nm, len(enc.matNms))
+	}
+	enc.matNms[nm] = true
+	mt := &sld.Mat
+	clr := func(c gi.Color) string {
+		return fmt.Sprintf("%g %g %g", float32(c.R)/255, float32(c.G)/255, float32(c.B)/255)
+	}
+	fmt.Fprintf(mw, "newmtl %s\n", nm)
+	fmt.Fprintf(mw, "Ka %s\n", clr(mt.Color))
+	fmt.Fprintf(mw, "Kd %s\n", clr(mt.Color))
+	fmt.Fprintf(mw, "Ks %s\n", clr(mt.Specular))
+	if mt.Emissive.A > 0 {
+		fmt.Fprintf(mw, "Ke %s\n", clr(mt.Emissive))
+	}
+	fmt.Fprintf(mw, "Ns %g\n", mt.Shiny)
+	fmt.Fprintf(mw, "d %g\n", float32(mt.Color.A)/255)
+	fmt.Fprintf(mw, "illum 2\n")
+	tex := mt.TexPtr
+	if tex == nil && mt.Texture != "" {
+		tex = enc.Scene.Textures[string(mt.Texture)]
+	}
+	if tf, ok := tex.(*gi3d.TextureFile); ok && tf.File != "" {
+		fn := string(tf.File)
+		if rel, err := filepath.Rel(enc.Objdir, fn); err == nil && enc.Objdir != "" {
+			fn = rel
+		}
+		ts := ""
+		if !mt.Tiling.Repeat.IsNil() && mt.Tiling.Repeat != (mat32.Vec2{X: 1, Y: 1}) {
+			ts += fmt.Sprintf("-s %g %g ", mt.Tiling.Repeat.X, mt.Tiling.Repeat.Y)
+		}
+		if mt.Tiling.Off != (mat32.Vec2{}) {
+			ts += fmt.Sprintf("-o %g %g ", mt.Tiling.Off.X, mt.Tiling.Off.Y)
+		}
+		fmt.Fprintf(mw, "map_Kd %s%s\n", ts, fn)
+	}
+	fmt.Fprintf(mw, "\n")
+	return nm
+}
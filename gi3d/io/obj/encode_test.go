@@ -0,0 +1,112 @@
+// Copyright (c) 2020, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package obj
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/goki/gi/gi3d"
+	"github.com/goki/gi/gi3d/gi3dtest"
+	"github.com/goki/gi/mat32"
+)
+
+func TestEncodeDecode(t *testing.T) {
+	sc := gi3dtest.Scene()
+	enc := gi3d.Encoders[".obj"].New()
+	if fns := enc.SetFile("/tmp/test.obj"); len(fns) != 2 || fns[1] != "/tmp/test.mtl" {
+		t.Errorf("files: %v", fns)
+	}
+	enc.SetScene(sc)
+	var ob, mb bytes.Buffer
+	if err := enc.Encode([]io.Writer{&ob, &mb}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(ob.String(), "mtllib test.mtl\n") {
+		t.Errorf("no mtllib: %q", ob.String())
+	}
+
+	dec := gi3d.Decoders[".obj"].New()
+	dec.SetFile("/tmp/test.obj")
+	if err := dec.Decode([]io.Reader{&ob, &mb}); err != nil {
+		t.Fatal(err)
+	}
+	dsc := &gi3d.Scene{}
+	dsc.InitName(dsc, "scene")
+	dsc.Defaults()
+	dec.SetScene(dsc)
+	if w := dec.(*Decoder).Warnings; len(w) > 0 {
+		t.Errorf("warnings: %v", w)
+	}
+	gp, ok := dsc.ChildByName("test.obj", 0).(*gi3d.Group)
+	if !ok {
+		t.Fatalf("file group not found: %v", dsc.Kids)
+	}
+
+	omb := sc.MeshByName("box").AsMeshBase()
+	tests := []struct {
+		obj  string
+		clr  [3]uint8
+		pose gi3d.Pose
+	}{
+		{"cube", [3]uint8{0, 255, 0}, gi3d.Pose{Pos: mat32.Vec3{X: 1, Y: 2, Z: 3}, Scale: mat32.NewVec3Scalar(2)}},
+		{"cube2", [3]uint8{255, 0, 0}, gi3d.Pose{}},
+	}
+	for _, tst := range tests {
+		ogp, ok := gp.ChildByName(tst.obj, 0).(*gi3d.Group)
+		if !ok {
+			t.Errorf("%v: object group not found: %v", tst.obj, gp.Kids)
+			continue
+		}
+		sld, ok := ogp.ChildByName(tst.obj+"_0", 0).(*gi3d.Solid)
+		if !ok {
+			t.Errorf("%v: solid not found: %v", tst.obj, ogp.Kids)
+			continue
+		}
+		if c := sld.Mat.Color; c.R != tst.clr[0] || c.G != tst.clr[1] || c.B != tst.clr[2] || c.A != 255 {
+			t.Errorf("%v: color %v, want %v", tst.obj, c, tst.clr)
+		}
+		dmb := dsc.MeshByName(string(sld.Mesh)).AsMeshBase()
+		if len(dmb.Idx) != len(omb.Idx) || len(dmb.Tex)/2 != len(dmb.Vtx)/3 || len(dmb.Norm) != len(dmb.Vtx) {
+			t.Fatalf("%v: mesh data sizes: vtx: %d idx: %d tex: %d norm: %d", tst.obj, len(dmb.Vtx), len(dmb.Idx), len(dmb.Tex), len(dmb.Norm))
+		}
+		tst.pose.Defaults()
+		tst.pose.UpdateMatrix()
+		var ov, dv, on, dn mat32.Vec3
+		var ot, dt mat32.Vec2
+		for i, oi := range omb.Idx {
+			di := int(dmb.Idx[i])
+			omb.Vtx.GetVec3(3*int(oi), &ov)
+			dmb.Vtx.GetVec3(3*di, &dv)
+			if wv := ov.MulMat4(&tst.pose.Matrix); wv.Sub(dv).Length() > 1e-5 {
+				t.Errorf("%v: vertex %d: %v != %v", tst.obj, i, dv, wv)
+			}
+			omb.Norm.GetVec3(3*int(oi), &on)
+			dmb.Norm.GetVec3(3*di, &dn)
+			if on.Sub(dn).Length() > 1e-5 {
+				t.Errorf("%v: normal %d: %v != %v", tst.obj, i, dn, on)
+			}
+			omb.Tex.GetVec2(2*int(oi), &ot)
+			dmb.Tex.GetVec2(2*di, &dt)
+			if ot != dt {
+				t.Errorf("%v: tex %d: %v != %v", tst.obj, i, dt, ot)
+			}
+		}
+	}
+}
+
+func TestEncodeErrors(t *testing.T) {
+	enc := gi3d.Encoders[".obj"].New()
+	var b bytes.Buffer
+	if err := enc.Encode([]io.Writer{&b}); err == nil {
+		t.Errorf("no error without a scene")
+	}
+	enc.SetScene(gi3dtest.Scene())
+	if err := enc.Encode(nil); err == nil {
+		t.Errorf("no error without a writer")
+	}
+}
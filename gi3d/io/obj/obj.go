@@ -31,6 +31,7 @@ import (
 // note: gimain imports "github.com/goki/gi/gi3d/io/obj" to get this code
 func init() {
 	gi3d.Decoders[".obj"] = &Decoder{}
+	gi3d.Encoders[".obj"] = &Encoder{}
 }
 
 // Decoder contains all decoded data from the obj and mtl files.
@@ -232,14 +233,17 @@ func (dec *Decoder) addNorms(ms *gi3d.GenMesh, ai, bi, ci int, idxs []int) {
 	}
 }
 
+// setIndex appends the mesh index of the face vertex at given index,
+// copying the vertex to the mesh if it is not already in idxs
 func (dec *Decoder) setIndex(ms *gi3d.GenMesh, face *Face, idx int, idxs *[]int) {
-	if len(*idxs) > idx {
-		ms.Idx.Append(uint32((*idxs)[idx]))
-	} else {
+	if len(*idxs) <= idx {
 		*idxs = append(*idxs, dec.copyVertex(ms, face, idx))
 	}
+	ms.Idx.Append(uint32((*idxs)[idx]))
 }
 
+// copyVertex appends the face vertex at given index to the mesh,
+// returning its mesh vertex index -- the index itself is added by setIndex
 func (dec *Decoder) copyVertex(ms *gi3d.GenMesh, face *Face, idx int) int {
 	var vec3 mat32.Vec3
 	var vec2 mat32.Vec2
@@ -266,7 +270,6 @@ func (dec *Decoder) copyVertex(ms *gi3d.GenMesh, face *Face, idx int) int {
 		}
 		ms.Tex.AppendVec2(vec2)
 	}
-	ms.Idx.Append(uint32(vidx))
 	return vidx
 }
 
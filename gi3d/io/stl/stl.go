@@ -0,0 +1,125 @@
+// Copyright (c) 2020, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package stl is used to write the binary STL file format (*.stl), which
+// is widely used for 3D printing and CAD.  It only has triangles, without
+// any materials or scene information.
+// Basic format info: https://en.wikipedia.org/wiki/STL_(file_format)
+package stl
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"path/filepath"
+
+	"github.com/goki/gi/gi3d"
+	"github.com/goki/gi/mat32"
+	"github.com/goki/ki/ki"
+)
+
+// note: gimain imports "github.com/goki/gi/gi3d/io/stl" to get this code
+func init() {
+	gi3d.Encoders[".stl"] = &Encoder{}
+}
+
+// Encoder writes the solids in a scene or group to a binary STL file,
+// with the Pose transforms applied to the vertex data.
+// It implements the gi3d.Encoder interface and an instance
+// is registered to handle .stl files.
+type Encoder struct {
+	File  string      // .stl filename (without path)
+	Scene *gi3d.Scene // scene with the meshes
+	Par   ki.Ki       // parent of the solids to encode
+}
+
+func (enc *Encoder) New() gi3d.Encoder {
+	return new(Encoder)
+}
+
+func (enc *Encoder) Desc() string {
+	return ".stl = binary STL format.  Only supports triangles, not materials or Scene (camera, lights etc) -- Pose transforms are applied to the vertex data."
+}
+
+func (enc *Encoder) HasScene() bool {
+	return false
+}
+
+func (enc *Encoder) SetFile(fname string) []string {
+	_, enc.File = filepath.Split(fname)
+	return []string{fname}
+}
+
+func (enc *Encoder) SetGroup(sc *gi3d.Scene, gp *gi3d.Group) {
+	enc.Scene = sc
+	enc.Par = gp
+}
+
+func (enc *Encoder) SetScene(sc *gi3d.Scene) {
+	enc.Scene = sc
+	enc.Par = sc
+}
+
+// Triangles returns all of the triangles to be encoded, as sets of
+// 3 vertex positions, with the Pose transforms applied
+func (enc *Encoder) Triangles() []mat32.Vec3 {
+	var tris []mat32.Vec3
+	gi3d.SolidsXForm(enc.Par, func(sld *gi3d.Solid, xf *mat32.Mat4) {
+		ms := enc.Scene.SolidMesh(sld)
+		if ms == nil {
+			return
+		}
+		mb := ms.AsMeshBase()
+		nv := len(mb.Vtx) / 3
+		var v mat32.Vec3
+		for i := 0; i+2 < len(mb.Idx); i += 3 {
+			tri := mb.Idx[i : i+3]
+			if int(tri[0]) >= nv || int(tri[1]) >= nv || int(tri[2]) >= nv {
+				continue
+			}
+			for _, ix := range tri {
+				mb.Vtx.GetVec3(3*int(ix), &v)
+				tris = append(tris, v.MulMat4(xf))
+			}
+		}
+	})
+	return tris
+}
+
+// Encode writes the binary STL data to the first writer
+func (enc *Encoder) Encode(ws []io.Writer) error {
+	if len(ws) == 0 {
+		return errors.New("stl.Encoder: no writers passed")
+	}
+	if enc.Scene == nil || enc.Par == nil {
+		return errors.New("stl.Encoder: SetGroup or SetScene must be called first")
+	}
+	tris := enc.Triangles()
+	w := bufio.NewWriter(ws[0])
+	var hdr [80]byte
+	copy(hdr[:], "binary STL: "+enc.File)
+	w.Write(hdr[:])
+	var buf [50]byte
+	le := binary.LittleEndian
+	le.PutUint32(buf[:4], uint32(len(tris)/3))
+	w.Write(buf[:4])
+	put := func(off int, v mat32.Vec3) {
+		le.PutUint32(buf[off:], math.Float32bits(v.X))
+		le.PutUint32(buf[off+4:], math.Float32bits(v.Y))
+		le.PutUint32(buf[off+8:], math.Float32bits(v.Z))
+	}
+	for i := 0; i < len(tris); i += 3 {
+		put(0, mat32.Normal(tris[i], tris[i+1], tris[i+2]))
+		put(12, tris[i])
+		put(24, tris[i+1])
+		put(36, tris[i+2])
+		buf[48], buf[49] = 0, 0
+		if _, err := w.Write(buf[:]); err != nil {
+			return err
+		}
+	}
+	return w.Flush()
+}
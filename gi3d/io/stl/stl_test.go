@@ -0,0 +1,113 @@
+// Copyright (c) 2020, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package stl
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/goki/gi/gi3d"
+	"github.com/goki/gi/gi3d/gi3dtest"
+	"github.com/goki/gi/mat32"
+)
+
+// decode reads binary STL data, returning the header, and the normal
+// and 3 vertex positions of each triangle
+func decode(r io.Reader) (string, []mat32.Vec3, []mat32.Vec3, error) {
+	var hdr [80]byte
+	if _, err := io.ReadFull(r, hdr[:]); err != nil {
+		return "", nil, nil, err
+	}
+	var n uint32
+	if err := binary.Read(r, binary.LittleEndian, &n); err != nil {
+		return "", nil, nil, err
+	}
+	var nrms, tris []mat32.Vec3
+	var buf [50]byte
+	get := func(off int) mat32.Vec3 {
+		le := binary.LittleEndian
+		return mat32.Vec3{X: math.Float32frombits(le.Uint32(buf[off:])), Y: math.Float32frombits(le.Uint32(buf[off+4:])), Z: math.Float32frombits(le.Uint32(buf[off+8:]))}
+	}
+	for i := uint32(0); i < n; i++ {
+		if _, err := io.ReadFull(r, buf[:]); err != nil {
+			return "", nil, nil, fmt.Errorf("triangle %d: %v", i, err)
+		}
+		nrms = append(nrms, get(0))
+		tris = append(tris, get(12), get(24), get(36))
+	}
+	if m, _ := r.Read(buf[:]); m != 0 {
+		return "", nil, nil, fmt.Errorf("%d extra bytes after %d triangles", m, n)
+	}
+	return strings.TrimRight(string(hdr[:]), "\x00"), nrms, tris, nil
+}
+
+func TestEncodeDecode(t *testing.T) {
+	sc := gi3dtest.Scene()
+	enc := gi3d.Encoders[".stl"].New()
+	if fns := enc.SetFile("/tmp/test.stl"); len(fns) != 1 {
+		t.Errorf("files: %v", fns)
+	}
+	enc.SetScene(sc)
+	var b bytes.Buffer
+	if err := enc.Encode([]io.Writer{&b}); err != nil {
+		t.Fatal(err)
+	}
+	hdr, nrms, tris, err := decode(&b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hdr != "binary STL: test.stl" {
+		t.Errorf("header: %q", hdr)
+	}
+	if len(nrms) != 24 {
+		t.Fatalf("triangles: %d != 24, for 2 boxes of 12", len(nrms))
+	}
+
+	// cube is at 0..2, 1..3, 2..4, and cube2 at -.5...5
+	in := func(v, min, max mat32.Vec3) bool {
+		for _, d := range []mat32.Dims{mat32.X, mat32.Y, mat32.Z} {
+			c := v.Dim(d)
+			if mat32.Abs(c-min.Dim(d)) > 1e-5 && mat32.Abs(c-max.Dim(d)) > 1e-5 {
+				return false
+			}
+		}
+		return true
+	}
+	ncube := 0
+	for i, nrm := range nrms {
+		a, bv, c := tris[3*i], tris[3*i+1], tris[3*i+2]
+		if in(a, mat32.Vec3{X: 0, Y: 1, Z: 2}, mat32.Vec3{X: 2, Y: 3, Z: 4}) {
+			ncube++
+			if !in(bv, mat32.Vec3{X: 0, Y: 1, Z: 2}, mat32.Vec3{X: 2, Y: 3, Z: 4}) || !in(c, mat32.Vec3{X: 0, Y: 1, Z: 2}, mat32.Vec3{X: 2, Y: 3, Z: 4}) {
+				t.Errorf("triangle %d not on cube: %v %v %v", i, a, bv, c)
+			}
+		} else if !in(a, mat32.NewVec3Scalar(-.5), mat32.NewVec3Scalar(.5)) || !in(bv, mat32.NewVec3Scalar(-.5), mat32.NewVec3Scalar(.5)) || !in(c, mat32.NewVec3Scalar(-.5), mat32.NewVec3Scalar(.5)) {
+			t.Errorf("triangle %d not on either cube: %v %v %v", i, a, bv, c)
+		}
+		if wn := mat32.Normal(a, bv, c); nrm.Sub(wn).Length() > 1e-5 || mat32.Abs(nrm.Length()-1) > 1e-5 {
+			t.Errorf("triangle %d: normal %v != %v", i, nrm, wn)
+		}
+	}
+	if ncube != 12 {
+		t.Errorf("triangles on the transformed cube: %d != 12", ncube)
+	}
+}
+
+func TestEncodeErrors(t *testing.T) {
+	enc := gi3d.Encoders[".stl"].New()
+	var b bytes.Buffer
+	if err := enc.Encode([]io.Writer{&b}); err == nil {
+		t.Errorf("no error without a scene")
+	}
+	enc.SetScene(gi3dtest.Scene())
+	if err := enc.Encode(nil); err == nil {
+		t.Errorf("no error without a writer")
+	}
+}
@@ -5,8 +5,6 @@
 package gi3d
 
 import (
-	"strings"
-
 	"github.com/goki/gi/gi"
 	"github.com/goki/ki/ki"
 	"github.com/goki/ki/kit"
@@ -52,19 +50,14 @@ func (mt *Material) ApplyCSS(node Node3D, css ki.Props, key, selector string, vp
 	return true
 }
 
-// StyleCSS applies css style properties to given node, parsing out
-// type, .class, and #name selectors, along with optional sub-selector
+// StyleCSS applies css style properties to given node, matching
+// type, .class, and #name selectors, along with full CSS selectors
+// (see gi.CSSRules), in order of specificity, with optional sub-selector
 // (:hover, :active etc)
 func (mt *Material) StyleCSS(node Node3D, css ki.Props, selector string, vp *gi.Viewport2D) {
-	tyn := strings.ToLower(node.Type().Name()) // type is most general, first
-	mt.ApplyCSS(node, css, tyn, selector, vp)
-	classes := strings.Split(strings.ToLower(node.AsNode3D().Class), " ")
-	for _, cl := range classes {
-		cln := "." + strings.TrimSpace(cl)
-		mt.ApplyCSS(node, css, cln, selector, vp)
+	for _, r := range gi.CSSRules(node, css, selector) {
+		mt.ApplyCSS(node, css, r.Key, r.State, vp)
 	}
-	idnm := "#" + strings.ToLower(node.Name()) // then name
-	mt.ApplyCSS(node, css, idnm, selector, vp)
 }
 
 // StyleMatFuncs are functions for styling the Material
@@ -6,6 +6,7 @@ package gi3d
 
 import (
 	"fmt"
+	"image"
 	"log"
 
 	"github.com/goki/gi/gi"
@@ -178,6 +179,58 @@ func (tx *TextureFile) Activate(sc *Scene, texNo int) {
 	tx.Tex.Activate(texNo)
 }
 
+// TextureImage is a texture from an image held in memory, e.g., one that
+// was embedded in a 3D object file
+type TextureImage struct {
+	TextureBase
+	Img image.Image `view:"-" desc:"the image for the texture"`
+}
+
+var KiT_TextureImage = kit.Types.AddType(&TextureImage{}, nil)
+
+// AddNewTextureImage adds a new texture from given image, with given name
+func AddNewTextureImage(sc *Scene, name string, img image.Image) *TextureImage {
+	tx := &TextureImage{}
+	tx.Nm = name
+	tx.Img = img
+	sc.AddTexture(tx)
+	return tx
+}
+
+// Init initializes the texture and uploads the image to the GPU
+// Must be called in context on main thread
+func (tx *TextureImage) Init(sc *Scene) error {
+	if tx.Tex != nil {
+		tx.Tex.SetBotZero(tx.Bot0)
+		tx.Tex.Activate(0)
+		return nil
+	}
+	if tx.Img == nil {
+		err := fmt.Errorf("gi3d.Texture: %v Img must be set to load texture from", tx.Nm)
+		log.Println(err)
+		return err
+	}
+	tx.Tex = gpu.TheGPU.NewTexture2D(tx.Nm)
+	tx.Tex.SetBotZero(tx.Bot0)
+	err := tx.Tex.SetImage(tx.Img)
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+	tx.Tex.Activate(0)
+	return nil
+}
+
+// Activate activates this texture on the GPU, in preparation for rendering
+// Must be called in context on main thread
+func (tx *TextureImage) Activate(sc *Scene, texNo int) {
+	if tx.Tex == nil {
+		tx.Init(sc)
+	}
+	tx.Tex.SetBotZero(tx.Bot0)
+	tx.Tex.Activate(texNo)
+}
+
 // TextureGi2D is a dynamic texture material driven by a gi.Viewport2D viewport
 // anything rendered to the viewport will be projected onto the surface of any
 // solid using this texture.
@@ -11,7 +11,9 @@ import (
 	"sync/atomic"
 
 	"github.com/goki/gi/gi"
+	_ "github.com/goki/gi/gi3d/io/gltf"
 	_ "github.com/goki/gi/gi3d/io/obj"
+	_ "github.com/goki/gi/gi3d/io/stl"
 	"github.com/goki/gi/giv"
 	"github.com/goki/gi/oswin"
 	"github.com/goki/gi/oswin/driver"
@@ -594,13 +594,13 @@ func (vv *IntValueView) ConfigWidget(widg gi.Node2D) {
 	if vk >= reflect.Uint && vk <= reflect.Uint64 {
 		sb.SetMin(0)
 	}
-	if mintag, ok := vv.Tag("min"); ok {
+	if mintag, ok := vv.SpinBoxLimitTag("min"); ok {
 		minv, ok := kit.ToFloat32(mintag)
 		if ok {
 			sb.SetMin(minv)
 		}
 	}
-	if maxtag, ok := vv.Tag("max"); ok {
+	if maxtag, ok := vv.SpinBoxLimitTag("max"); ok {
 		maxv, ok := kit.ToFloat32(maxtag)
 		if ok {
 			sb.SetMax(maxv)
@@ -661,14 +661,14 @@ func (vv *FloatValueView) ConfigWidget(widg gi.Node2D) {
 	sb.Defaults()
 	sb.Step = 1.0
 	sb.PageStep = 10.0
-	if mintag, ok := vv.Tag("min"); ok {
+	if mintag, ok := vv.SpinBoxLimitTag("min"); ok {
 		minv, ok := kit.ToFloat32(mintag)
 		if ok {
 			sb.HasMin = true
 			sb.Min = minv
 		}
 	}
-	if maxtag, ok := vv.Tag("max"); ok {
+	if maxtag, ok := vv.SpinBoxLimitTag("max"); ok {
 		maxv, ok := kit.ToFloat32(maxtag)
 		if ok {
 			sb.HasMax = true
@@ -104,6 +104,15 @@ func StructViewDialog(avp *gi.Viewport2D, stru interface{}, opts DlgOpts, recv k
 	sv.ViewPath = opts.ViewPath
 	sv.TmpSave = opts.TmpSave
 	sv.SetStruct(stru)
+	if !opts.Inactive && opts.Ok {
+		// Ok is only active while the struct is valid -- see StructView.UpdateValid
+		dlg.SetOkActive(!sv.Invalid)
+		sv.ViewSig.Connect(dlg.This(), func(recv, send ki.Ki, sig int64, data interface{}) {
+			ddlg := recv.Embed(gi.KiT_Dialog).(*gi.Dialog)
+			svv := send.Embed(KiT_StructView).(*StructView)
+			ddlg.SetOkActive(!svv.Invalid)
+		})
+	}
 	if recv != nil && dlgFunc != nil {
 		dlg.DialogSig.Connect(recv, dlgFunc)
 	}
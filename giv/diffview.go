@@ -354,6 +354,8 @@ func (dv *DiffView) DiffStrings(astr, bstr []string) {
 	dv.BufB.ReMarkup(false)
 	av.UpdateEnd(aupdt)
 	bv.UpdateEnd(bupdt)
+	av.SetMinimapDiffs(dv.AlignD)
+	bv.SetMinimapDiffs(dv.AlignD.Reverse())
 }
 
 // TagWordDiffs goes through replace diffs and tags differences at the
@@ -17,10 +17,12 @@ import (
 	"reflect"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/Masterminds/vcs"
 	"github.com/goki/gi/gi"
+	"github.com/goki/gi/giv/fswatch"
 	"github.com/goki/gi/giv/textbuf"
 	"github.com/goki/gi/histyle"
 	"github.com/goki/gi/oswin"
@@ -63,10 +65,13 @@ const (
 // interface into it.
 type FileTree struct {
 	FileNode
-	ExtFiles  []string     `desc:"external files outside the root path of the tree -- abs paths are stored -- these are shown in the first sub-node if present -- use AddExtFile to add and update"`
-	OpenDirs  OpenDirMap   `desc:"records which directories within the tree (encoded using paths relative to root) are open (i.e., have been opened by the user) -- can persist this to restore prior view of a tree"`
-	DirsOnTop bool         `desc:"if true, then all directories are placed at the top of the tree view -- otherwise everything is alpha sorted"`
-	NodeType  reflect.Type `view:"-" json:"-" xml:"-" desc:"type of node to create -- defaults to giv.FileNode but can use custom node types"`
+	ExtFiles  []string         `desc:"external files outside the root path of the tree -- abs paths are stored -- these are shown in the first sub-node if present -- use AddExtFile to add and update"`
+	OpenDirs  OpenDirMap       `desc:"records which directories within the tree (encoded using paths relative to root) are open (i.e., have been opened by the user) -- can persist this to restore prior view of a tree"`
+	DirsOnTop bool             `desc:"if true, then all directories are placed at the top of the tree view -- otherwise everything is alpha sorted"`
+	NodeType  reflect.Type     `view:"-" json:"-" xml:"-" desc:"type of node to create -- defaults to giv.FileNode but can use custom node types"`
+	Watcher   *fswatch.Watcher `view:"-" json:"-" xml:"-" copy:"-" desc:"watches the open directories for changes on disk, if FileWatchOn -- see StartWatch"`
+	watchWin  *gi.Window       `desc:"window of a view of the tree, on whose event loop the changes on disk are applied -- see SetWatchWin"`
+	watchMu   sync.Mutex       `desc:"protects watchWin, which is used on the goroutine of the Watcher"`
 }
 
 var KiT_FileTree = kit.Types.AddType(&FileTree{}, FileTreeProps)
@@ -91,6 +96,7 @@ func (ft *FileTree) OpenPath(path string) {
 		ft.NodeType = KiT_FileNode
 	}
 	ft.OpenDirs.ClearFlags()
+	ft.StartWatch()
 	ft.ReadDir(path)
 }
 
@@ -375,6 +381,7 @@ func (fn *FileNode) UpdateDir() {
 	// fmt.Printf("path: %v  node: %v\n", path, fn.PathUnique())
 	repo, rnode := fn.Repo()
 	fn.SetOpen()
+	fn.WatchDir()
 	config := fn.ConfigOfFiles(path)
 	hasExtFiles := false
 	if fn.This() == fn.FRoot.This() {
@@ -529,6 +536,7 @@ func (fn *FileNode) OpenDir() {
 func (fn *FileNode) CloseDir() {
 	fn.SetClosed()
 	fn.FRoot.SetDirClosed(fn.FPath)
+	fn.UnwatchDir()
 	// todo: do anything with open files within directory??
 }
 
@@ -550,7 +558,30 @@ func (fn *FileNode) OpenBuf() (bool, error) {
 		fn.Buf.AddFileNode(fn)
 	}
 	fn.Buf.Hi.Style = FileNodeHiStyle
-	return true, fn.Buf.Open(fn.FPath)
+	err := fn.Buf.Open(fn.FPath)
+	if err == nil {
+		fn.SetBufVcs()
+	}
+	return true, err
+}
+
+// SetBufVcs sets the version control repository for the open buffer, to
+// show the change gutter relative to HEAD -- not for untracked files.
+func (fn *FileNode) SetBufVcs() {
+	if fn.Buf == nil {
+		return
+	}
+	repo, _ := fn.Repo()
+	if repo == nil || fn.Info.Vcs == vci.Untracked {
+		fn.Buf.SetVcsRepo(nil)
+		return
+	}
+	fn.Buf.Vcs.StatusFun = func() {
+		fn.Info.Vcs, _ = repo.Status(string(fn.FPath))
+		fn.UpdateSig()
+		fn.FRoot.UpdateSig()
+	}
+	fn.Buf.SetVcsRepo(repo)
 }
 
 // CloseBuf closes the file in its buffer if it is open -- returns true if closed
@@ -962,6 +993,7 @@ func (fn *FileNode) AddToVcs() {
 	err := repo.Add(string(fn.FPath))
 	if err == nil {
 		fn.Info.Vcs = vci.Added
+		fn.SetBufVcs()
 		fn.UpdateSig()
 		fn.FRoot.UpdateSig()
 		return
@@ -1000,6 +1032,9 @@ func (fn *FileNode) CommitToVcs(message string) (err error) {
 		return err
 	}
 	fn.Info.Vcs = vci.Stored
+	if fn.Buf != nil {
+		fn.Buf.UpdateVcsBase()
+	}
 	fn.UpdateSig()
 	fn.FRoot.UpdateSig()
 	return err
@@ -1143,6 +1178,41 @@ func (fn *FileNode) BlameVcs() ([]byte, error) {
 	return blm, nil
 }
 
+// MergeVcs opens a MergeView for resolving the merge conflicts in this
+// file, which marks it as resolved in version control when the result is
+// saved without any remaining conflicts.
+func (fn *FileNode) MergeVcs() error {
+	repo, _ := fn.Repo()
+	if repo == nil {
+		return errors.New("file not in vcs repo: " + string(fn.FPath))
+	}
+	mv, err := MergeViewDialogFromFile(nil, repo, string(fn.FPath), fn.Buf)
+	if err != nil {
+		return err
+	}
+	mv.ResolvedFun = func() {
+		fn.Info.Vcs, _ = repo.Status(string(fn.FPath))
+		fn.UpdateSig()
+		fn.FRoot.UpdateSig()
+	}
+	return nil
+}
+
+// ChangesVcs opens a VcsChangesView showing the files with staged and
+// unstaged changes in the git repository containing this file, from which
+// the staged changes can be committed.
+func (fn *FileNode) ChangesVcs() error {
+	repo, _ := fn.Repo()
+	if repo == nil {
+		return errors.New("file not in vcs repo: " + string(fn.FPath))
+	}
+	if repo.Vcs() != "git" {
+		return errors.New("staged changes are only supported for git repositories")
+	}
+	VcsChangesViewDialog(repo)
+	return nil
+}
+
 // UpdateAllVcs does an update on any repositories below this one in file tree
 func (fn *FileNode) UpdateAllVcs() {
 	fn.FuncDownMeFirst(0, fn, func(k ki.Ki, level int, d interface{}) bool {
@@ -1338,6 +1408,9 @@ func (ftv *FileTreeView) UpdateAllFiles() {
 
 func (ftv *FileTreeView) ConnectEvents2D() {
 	ftv.FileTreeViewEvents()
+	if fn := ftv.FileNode(); fn != nil && fn.FRoot != nil && ftv.Viewport != nil {
+		fn.FRoot.SetWatchWin(ftv.Viewport.Win)
+	}
 }
 
 func (ftv *FileTreeView) FileTreeViewEvents() {
@@ -1594,6 +1667,16 @@ func (ftv *FileTreeView) OpenDirs() {
 	}
 }
 
+// FindInFiles opens a FindView for finding and replacing text in all the
+// files within this directory
+func (ftv *FileTreeView) FindInFiles() {
+	fn := ftv.FileNode()
+	if fn == nil {
+		return
+	}
+	FindViewDialog(ftv.Viewport, fn, nil)
+}
+
 // NewFile makes a new file in given selected directory node
 func (ftv *FileTreeView) NewFile(filename string, addToVcs bool) {
 	sels := ftv.SelectedViews()
@@ -1752,6 +1835,40 @@ func (ftv *FileTreeView) BlameVcs() {
 	}
 }
 
+// MergeVcs opens a MergeView for resolving the merge conflicts in this
+// file, which marks it as resolved in version control when the result is
+// saved without any remaining conflicts.
+func (ftv *FileTreeView) MergeVcs() {
+	sels := ftv.SelectedViews()
+	sz := len(sels)
+	if sz == 0 { // shouldn't happen
+		return
+	}
+	for i := len(sels) - 1; i >= 0; i-- {
+		sn := sels[i]
+		ftvv := sn.Embed(KiT_FileTreeView).(*FileTreeView)
+		fn := ftvv.FileNode()
+		if fn != nil {
+			if err := fn.MergeVcs(); err != nil {
+				gi.PromptDialog(ftv.Viewport, gi.DlgOpts{Title: "Could not Merge", Prompt: err.Error()}, gi.AddOk, gi.NoCancel, nil, nil)
+			}
+		}
+	}
+}
+
+// ChangesVcs opens a VcsChangesView showing the files with staged and
+// unstaged changes in the git repository containing this file, from which
+// the staged changes can be committed.
+func (ftv *FileTreeView) ChangesVcs() {
+	fn := ftv.FileNode()
+	if fn == nil {
+		return
+	}
+	if err := fn.ChangesVcs(); err != nil {
+		gi.PromptDialog(ftv.Viewport, gi.DlgOpts{Title: "Could not show Changes", Prompt: err.Error()}, gi.AddOk, gi.NoCancel, nil, nil)
+	}
+}
+
 // RemoveFromExterns removes file from list of external files
 func (ftv *FileTreeView) RemoveFromExterns() {
 	sels := ftv.SelectedViews()
@@ -2077,6 +2194,32 @@ var FileTreeActiveInVcsModifiedFunc = ActionUpdateFunc(func(fni interface{}, act
 	}
 })
 
+// FileTreeActiveInVcsConflictedFunc is an ActionUpdateFunc that activates action if node is under version control
+// and the file has merge conflicts
+var FileTreeActiveInVcsConflictedFunc = ActionUpdateFunc(func(fni interface{}, act *gi.Action) {
+	ftv := fni.(ki.Ki).Embed(KiT_FileTreeView).(*FileTreeView)
+	fn := ftv.FileNode()
+	if fn != nil {
+		repo, _ := fn.Repo()
+		if repo == nil || fn.IsDir() {
+			act.SetActiveState((false))
+			return
+		}
+		act.SetActiveState((fn.Info.Vcs == vci.Conflicted))
+	}
+})
+
+// FileTreeActiveInRepoFunc is an ActionUpdateFunc that activates action if node
+// is within a version control repository, including directories
+var FileTreeActiveInRepoFunc = ActionUpdateFunc(func(fni interface{}, act *gi.Action) {
+	ftv := fni.(ki.Ki).Embed(KiT_FileTreeView).(*FileTreeView)
+	fn := ftv.FileNode()
+	if fn != nil {
+		repo, _ := fn.Repo()
+		act.SetActiveState(repo != nil)
+	}
+})
+
 // VcsGetRemoveLabelFunc gets the appropriate label for removing from version control
 var VcsLabelFunc = LabelFunc(func(fni interface{}, act *gi.Action) string {
 	ftv := fni.(ki.Ki).Embed(KiT_FileTreeView).(*FileTreeView)
@@ -2193,6 +2336,11 @@ var FileTreeViewProps = ki.Props{
 			"desc":     "open given folder to see files within",
 			"updtfunc": FileTreeActiveDirFunc,
 		}},
+		{"FindInFiles", ki.Props{
+			"label":    "Find In Files...",
+			"desc":     "find, and optionally replace, text in all the files within this folder, including closed folders",
+			"updtfunc": FileTreeActiveDirFunc,
+		}},
 		{"NewFile", ki.Props{
 			"label":    "New File...",
 			"desc":     "make a new file in this folder",
@@ -2261,6 +2409,16 @@ var FileTreeViewProps = ki.Props{
 			"updtfunc":   FileTreeActiveInVcsFunc,
 			"label-func": VcsLabelFunc,
 		}},
+		{"MergeVcs", ki.Props{
+			"desc":       "resolve the merge conflicts in this file, showing the base, our and their versions with an editable merged result -- saving the result without remaining conflicts marks it as resolved",
+			"updtfunc":   FileTreeActiveInVcsConflictedFunc,
+			"label-func": VcsLabelFunc,
+		}},
+		{"ChangesVcs", ki.Props{
+			"desc":       "shows the files with staged and unstaged changes in the repository, to stage, unstage, diff and commit them -- individual changes can be staged from the change gutter in the editor",
+			"updtfunc":   FileTreeActiveInRepoFunc,
+			"label-func": VcsLabelFunc,
+		}},
 		{"sep-extrn", ki.BlankProp{}},
 		{"RemoveFromExterns", ki.Props{
 			"desc":       "Remove file from external files listt",
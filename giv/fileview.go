@@ -318,6 +318,7 @@ func (fv *FileView) ConfigFilesRow() {
 	sv.SetStretchMaxHeight()
 	sv.SetProp("max-width", 0) // no stretch
 	sv.SetProp("index", false)
+	sv.SetProp("filters", false)       // files are filtered by the view itself
 	sv.SetProp("inact-key-nav", false) // can only have one active -- files..
 	sv.SetInactive()                   // select only
 	sv.SelectedIdx = -1
@@ -338,7 +339,8 @@ func (fv *FileView) ConfigFilesRow() {
 			},
 		},
 	}
-	sv.SetProp("index", false) // no index
+	sv.SetProp("index", false)   // no index
+	sv.SetProp("filters", false) // files are filtered by the view itself
 	sv.SetStretchMax()
 	sv.SetInactive() // select only
 	sv.StyleFunc = FileViewStyleFunc
@@ -0,0 +1,430 @@
+// Copyright (c) 2020, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package giv
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/goki/gi/gi"
+	"github.com/goki/gi/giv/fswatch"
+	"github.com/goki/ki/ki"
+	"github.com/goki/ki/kit"
+	"github.com/goki/pi/vci"
+)
+
+// FileWatchOn determines whether the directories of a FileTree and the files
+// of open TextBufs are watched for changes on disk -- see fswatch for
+// settings.  Changes by other programs then update the tree, and reload
+// unmodified buffers, instead of waiting for an explicit update.
+var FileWatchOn = true
+
+// fileWatchGitFiles are the files in the .git directory whose change
+// indicates that the version control status of files may have changed
+var fileWatchGitFiles = map[string]bool{"index": true, "HEAD": true, "ORIG_HEAD": true, "MERGE_HEAD": true}
+
+/////////////////////////////////////////////////////////////////////////////
+//   FileTree
+
+// StartWatch starts watching the open directories in the tree for changes
+// on disk, if FileWatchOn -- called in OpenPath.
+func (ft *FileTree) StartWatch() {
+	if !FileWatchOn || ft.Watcher != nil {
+		return
+	}
+	ft.Watcher = fswatch.New(ft.WatchEvents)
+}
+
+// SetWatchWin sets the window on whose event loop the changes on disk are
+// applied to the tree -- called by FileTreeView when it is connected to its
+// window.  Without a window, they are applied on the goroutine of the
+// Watcher.
+func (ft *FileTree) SetWatchWin(win *gi.Window) {
+	ft.watchMu.Lock()
+	ft.watchWin = win
+	ft.watchMu.Unlock()
+}
+
+// StopWatch stops watching the directories in the tree for changes on disk
+func (ft *FileTree) StopWatch() {
+	if ft.Watcher == nil {
+		return
+	}
+	ft.Watcher.Close()
+	ft.Watcher = nil
+}
+
+// WatchDir starts watching this directory for changes on disk, if the tree
+// is being watched, along with its .git directory if it is the root of a
+// git repository, for changes in version control status.
+func (fn *FileNode) WatchDir() {
+	if fn.FRoot == nil || fn.FRoot.Watcher == nil || fn.IsExternal() {
+		return
+	}
+	path := string(fn.FPath)
+	fn.FRoot.Watcher.Add(path)
+	if fn.DirRepo != nil && fn.DirRepo.Vcs() == "git" {
+		fn.FRoot.Watcher.Add(filepath.Join(path, ".git"))
+	}
+}
+
+// UnwatchDir stops watching this directory for changes on disk
+func (fn *FileNode) UnwatchDir() {
+	if fn.FRoot == nil || fn.FRoot.Watcher == nil || fn.This() == fn.FRoot.This() {
+		return
+	}
+	fn.FRoot.Watcher.Remove(string(fn.FPath))
+}
+
+// NodeByPath returns the existing node for given full path, without opening
+// any directories (unlike FindFile)
+func (ft *FileTree) NodeByPath(path string) (*FileNode, bool) {
+	rel, err := filepath.Rel(string(ft.FPath), path)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return nil, false
+	}
+	fn := &ft.FileNode
+	if rel == "." {
+		return fn, true
+	}
+	for _, nm := range strings.Split(rel, string(filepath.Separator)) {
+		k := fn.ChildByName(nm, 0)
+		if k == nil {
+			return nil, false
+		}
+		fn = k.Embed(KiT_FileNode).(*FileNode)
+	}
+	return fn, true
+}
+
+// WatchEvents is called on the goroutine of the Watcher with the changes on
+// disk: events in the .git directory of a repository other than for the
+// fileWatchGitFiles (e.g., lock and object files) are ignored, and the
+// remaining ones are applied in ApplyWatchEvents on the event loop of the
+// window of the view of the tree (see SetWatchWin).
+func (ft *FileTree) WatchEvents(evs []fswatch.Event) {
+	fevs := make([]fswatch.Event, 0, len(evs))
+	for _, ev := range evs {
+		dir, nm := filepath.Split(ev.Path)
+		if nm == ".git" || (filepath.Base(filepath.Clean(dir)) == ".git" && !fileWatchGitFiles[nm]) {
+			continue
+		}
+		fevs = append(fevs, ev)
+	}
+	if len(fevs) == 0 {
+		return
+	}
+	ft.watchMu.Lock()
+	win := ft.watchWin
+	ft.watchMu.Unlock()
+	if win != nil && !win.IsClosed() {
+		win.GoRunOnEventLoop(func() { ft.ApplyWatchEvents(fevs) })
+		return
+	}
+	ft.ApplyWatchEvents(fevs)
+}
+
+// ApplyWatchEvents updates the tree for changes on disk: renamed nodes are
+// renamed in place, the directories with files created, removed or renamed
+// are updated incrementally, and the version control status of files is
+// updated for any changes in the files or the repository.  Each repository
+// is only updated once for all the events.
+func (ft *FileTree) ApplyWatchEvents(evs []fswatch.Event) {
+	if ft.This() == nil || ft.IsDeleted() || ft.Watcher == nil {
+		return
+	}
+	dirs := make(map[string]map[string]bool) // dir -> changed names
+	repos := make(map[*FileNode]bool)        // repo root nodes with index changes
+	addName := func(path string) {
+		dir, nm := filepath.Split(path)
+		dir = filepath.Clean(dir)
+		if dirs[dir] == nil {
+			dirs[dir] = make(map[string]bool)
+		}
+		dirs[dir][nm] = true
+	}
+	updt := ft.UpdateStart()
+	for _, ev := range evs {
+		dir := filepath.Dir(ev.Path)
+		if filepath.Base(dir) == ".git" {
+			if rn, ok := ft.NodeByPath(filepath.Dir(dir)); ok && rn.DirRepo != nil {
+				repos[rn] = true
+			}
+			continue
+		}
+		if ev.Op == fswatch.Rename {
+			ft.WatchRename(ev.OldPath, ev.Path)
+			addName(ev.OldPath)
+		}
+		addName(ev.Path)
+	}
+	rfiles := make(map[*FileNode]bool) // repos needing updated status of files
+	for dir := range dirs {
+		if dn, ok := ft.NodeByPath(dir); ok {
+			if repo, rn := dn.Repo(); repo != nil {
+				rfiles[rn] = true
+			}
+		}
+	}
+	for rn := range repos {
+		rfiles[rn] = true
+	}
+	for rn := range rfiles {
+		rn.UpdateRepoFiles()
+	}
+	for dir, names := range dirs {
+		dn, ok := ft.NodeByPath(dir)
+		if !ok || !dn.IsDir() || !ft.IsDirOpen(dn.FPath) {
+			continue
+		}
+		dn.UpdateDirNames(names)
+	}
+	for rn := range repos {
+		rn.UpdateVcsStatus()
+	}
+	ft.UpdateEnd(updt)
+}
+
+// WatchRename renames the node for a file renamed on disk within the same
+// directory, in place, so any open buffer or other state is preserved --
+// if the old file still exists (e.g., it was moved aside for a backup),
+// or a node for the new name already exists, nothing is done here.
+func (ft *FileTree) WatchRename(oldpath, newpath string) {
+	if filepath.Dir(oldpath) != filepath.Dir(newpath) {
+		return
+	}
+	if _, err := os.Stat(oldpath); err == nil {
+		return
+	}
+	if _, has := ft.NodeByPath(newpath); has {
+		return
+	}
+	fn, ok := ft.NodeByPath(oldpath)
+	if !ok || fn.This() == ft.This() {
+		return
+	}
+	if fn.IsDir() && ft.IsDirOpen(fn.FPath) {
+		ft.SetDirOpen(gi.FileName(newpath))
+		fn.UnwatchDir() // watch follows the directory, but with the old path
+	}
+	fn.SetName(filepath.Base(newpath))
+}
+
+// UpdateDirNames updates this directory for changes to the files with given
+// names: nodes are added for new files and removed for deleted ones, and
+// nodes for the changed names are updated -- unlike UpdateDir, other
+// existing nodes are not updated.
+func (fn *FileNode) UpdateDirNames(names map[string]bool) {
+	path := string(fn.FPath)
+	repo, rnode := fn.Repo()
+	config := fn.ConfigOfFiles(path)
+	hasExtFiles := false
+	if fn.This() == fn.FRoot.This() && len(fn.FRoot.ExtFiles) > 0 {
+		config = append([]kit.TypeAndName{kit.TypeAndName{Type: fn.FRoot.NodeType, Name: FileTreeExtFilesName}}, config...)
+		hasExtFiles = true
+	}
+	mods, updt := fn.ConfigChildren(config, ki.NonUniqueNames)
+	for _, sfk := range fn.Kids {
+		sf := sfk.Embed(KiT_FileNode).(*FileNode)
+		if hasExtFiles && sf.Nm == FileTreeExtFilesName {
+			continue
+		}
+		if sf.FPath != "" && !names[sf.Nm] {
+			continue
+		}
+		sf.FRoot = fn.FRoot
+		sf.SetNodePath(filepath.Join(path, sf.Nm))
+		if sf.IsDir() {
+			sf.Info.Vcs = vci.Stored // always
+		} else if repo != nil {
+			sf.Info.Vcs = rnode.RepoFiles.Status(repo, string(sf.FPath))
+		} else {
+			sf.Info.Vcs = vci.Stored
+		}
+		sf.UpdateSig()
+	}
+	if mods {
+		fn.UpdateEnd(updt)
+	}
+}
+
+// UpdateVcsStatus updates the version control status of all the files in
+// the repository based at this node, from its current RepoFiles, and
+// updates the change gutter of any open buffers, e.g., after the index or
+// HEAD has changed from outside.
+func (fn *FileNode) UpdateVcsStatus() {
+	repo := fn.DirRepo
+	if repo == nil {
+		return
+	}
+	fn.FuncDownMeFirst(0, fn, func(k ki.Ki, level int, d interface{}) bool {
+		sfni := k.Embed(KiT_FileNode)
+		if sfni == nil {
+			return false
+		}
+		sfn := sfni.(*FileNode)
+		if sfn.This() != fn.This() && sfn.DirRepo != nil {
+			return false // nested repo
+		}
+		if sfn.IsDir() || sfn.FPath == "" {
+			return true
+		}
+		sfn.Info.Vcs = fn.RepoFiles.Status(repo, string(sfn.FPath))
+		if sfn.Buf != nil && sfn.Buf.Vcs.Repo != nil {
+			sfn.Buf.UpdateVcsBase()
+		}
+		sfn.UpdateSig()
+		return true
+	})
+}
+
+/////////////////////////////////////////////////////////////////////////////
+//   TextBuf
+
+// textBufWatch watches the files of all open TextBufs
+var textBufWatch struct {
+	Mu      sync.Mutex
+	Watcher *fswatch.Watcher
+	Bufs    map[string][]*TextBuf // by file
+	Files   map[*TextBuf]string
+}
+
+// StartFileWatch starts watching the file of the buffer for changes on
+// disk, if FileWatchOn -- called in Open and after saving to a file.
+func (tb *TextBuf) StartFileWatch() {
+	if !FileWatchOn || tb.Filename == "" {
+		return
+	}
+	fnm := filepath.Clean(string(tb.Filename))
+	tw := &textBufWatch
+	tw.Mu.Lock()
+	defer tw.Mu.Unlock()
+	if ofn, has := tw.Files[tb]; has {
+		if ofn == fnm {
+			return
+		}
+		tb.stopFileWatch()
+	}
+	if tw.Watcher == nil {
+		tw.Watcher = fswatch.New(textBufWatchEvents)
+		tw.Bufs = make(map[string][]*TextBuf)
+		tw.Files = make(map[*TextBuf]string)
+	}
+	tw.Bufs[fnm] = append(tw.Bufs[fnm], tb)
+	tw.Files[tb] = fnm
+	tw.Watcher.Add(filepath.Dir(fnm))
+}
+
+// StopFileWatch stops watching the file of the buffer -- called in Close
+func (tb *TextBuf) StopFileWatch() {
+	tw := &textBufWatch
+	tw.Mu.Lock()
+	tb.stopFileWatch()
+	tw.Mu.Unlock()
+}
+
+// stopFileWatch stops watching the file of the buffer -- textBufWatch
+// must be locked
+func (tb *TextBuf) stopFileWatch() {
+	tw := &textBufWatch
+	fnm, has := tw.Files[tb]
+	if !has {
+		return
+	}
+	delete(tw.Files, tb)
+	bufs := tw.Bufs[fnm]
+	for i, b := range bufs {
+		if b == tb {
+			bufs = append(bufs[:i], bufs[i+1:]...)
+			break
+		}
+	}
+	if len(bufs) > 0 {
+		tw.Bufs[fnm] = bufs
+		return
+	}
+	delete(tw.Bufs, fnm)
+	dir := filepath.Dir(fnm)
+	for ofn := range tw.Bufs {
+		if filepath.Dir(ofn) == dir {
+			return
+		}
+	}
+	tw.Watcher.Remove(dir)
+}
+
+// textBufWatchEvents handles changes on disk to the files of open buffers
+func textBufWatchEvents(evs []fswatch.Event) {
+	tw := &textBufWatch
+	changed := make(map[*TextBuf]bool)
+	renamed := make(map[*TextBuf]string)
+	tw.Mu.Lock()
+	for _, ev := range evs {
+		for _, tb := range tw.Bufs[ev.Path] {
+			changed[tb] = true
+		}
+		if ev.Op == fswatch.Rename {
+			if _, err := os.Stat(ev.OldPath); err != nil { // really moved
+				for _, tb := range tw.Bufs[ev.OldPath] {
+					renamed[tb] = ev.Path
+				}
+			}
+		}
+	}
+	tw.Mu.Unlock()
+	for tb, fnm := range renamed {
+		tb, fnm := tb, fnm
+		tb.goRunOnViewLoop(func() { tb.FileRenamedOnDisk(fnm) })
+	}
+	for tb := range changed {
+		if _, has := renamed[tb]; !has {
+			tb.goRunOnViewLoop(tb.FileChangedOnDisk)
+		}
+	}
+}
+
+// goRunOnViewLoop runs given function on the event loop of the window of the
+// first view of the buffer, as it updates the views and may open a dialog,
+// or directly if the buffer has no view
+func (tb *TextBuf) goRunOnViewLoop(fun func()) {
+	if vp := tb.ViewportFromView(); vp != nil && vp.Win != nil && !vp.Win.IsClosed() {
+		vp.Win.GoRunOnEventLoop(fun)
+		return
+	}
+	fun()
+}
+
+// FileChangedOnDisk is called when the file of the buffer has been changed
+// on disk, e.g., by another program: if the buffer has not been modified,
+// it is reloaded, and otherwise the user is prompted as in FileModCheck.
+// Nothing is done if the file still has the modification time from when
+// it was last opened or saved.
+func (tb *TextBuf) FileChangedOnDisk() {
+	if tb.Filename == "" {
+		return
+	}
+	info, err := os.Stat(string(tb.Filename))
+	if err != nil || info.ModTime() == time.Time(tb.Info.ModTime) {
+		return
+	}
+	if !tb.IsChanged() {
+		tb.Revert()
+		return
+	}
+	tb.FileModCheck()
+}
+
+// FileRenamedOnDisk is called when the file of the buffer has been renamed
+// or moved on disk to given file, e.g., by another program: the buffer then
+// refers to the new file.
+func (tb *TextBuf) FileRenamedOnDisk(fnm string) {
+	tb.Filename = gi.FileName(fnm)
+	tb.SetName(fnm)
+	tb.Stat()
+	tb.StartFileWatch()
+}
@@ -0,0 +1,649 @@
+// Copyright (c) 2020, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package giv
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"html"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/goki/gi/gi"
+	"github.com/goki/gi/giv/textbuf"
+	"github.com/goki/gi/units"
+	"github.com/goki/ki/ki"
+	"github.com/goki/ki/kit"
+	"github.com/goki/pi/filecat"
+)
+
+// FindParams are the parameters for finding, and optionally replacing, text
+// within the files of a FileTree
+type FindParams struct {
+	Find       string        `width:"60" desc:"text to find"`
+	Replace    string        `width:"60" desc:"text to replace each match with -- if Regexp, can refer to capture groups using $1, ${name} etc (use $$ for a literal $)"`
+	IgnoreCase bool          `desc:"ignore case when matching"`
+	Regexp     bool          `desc:"find is a regular expression"`
+	WholeWord  bool          `desc:"only match find at word boundaries"`
+	MultiLine  bool          `desc:"matches can span multiple lines, e.g., using \\n in a regexp, and ^ and $ match at the start and end of each line"`
+	Gitignore  bool          `desc:"skip the files and directories ignored by .gitignore files, along with the .git directory itself"`
+	Cats       []filecat.Cat `desc:"only search files in these categories (e.g., Code, Doc) -- searches all files if empty"`
+}
+
+// FindRegexp returns the compiled regexp for the find parameters
+func (fp *FindParams) FindRegexp() (*regexp.Regexp, error) {
+	if fp.Find == "" {
+		return nil, errors.New("giv.FindParams: nothing to find")
+	}
+	return textbuf.SearchRegexpFor(fp.Find, fp.Regexp, fp.IgnoreCase, fp.WholeWord, fp.MultiLine)
+}
+
+// CatOk returns true if the file at given path is in one of the Cats
+// categories, or there are none
+func (fp *FindParams) CatOk(path string) bool {
+	if len(fp.Cats) == 0 {
+		return true
+	}
+	mtyp, _, err := filecat.MimeFromFile(path)
+	if err != nil {
+		return false
+	}
+	cat := filecat.CatFromMime(mtyp)
+	for _, c := range fp.Cats {
+		if c == cat {
+			return true
+		}
+	}
+	return false
+}
+
+// FindResult is the matches for the find text within one file
+type FindResult struct {
+	Path    string          `desc:"full path to the file"`
+	Matches []textbuf.Match `desc:"matches within the file -- column positions are in runes"`
+}
+
+// FindInFilesWorkers is the number of files that are searched in parallel
+var FindInFilesWorkers = runtime.NumCPU()
+
+// OpenBufs returns the open buffers for the files in the FileTree containing
+// this node, by full path
+func (fn *FileNode) OpenBufs() map[string]*TextBuf {
+	bufs := make(map[string]*TextBuf)
+	if fn.FRoot == nil {
+		return bufs
+	}
+	fn.FRoot.FuncDownMeFirst(0, fn.FRoot, func(k ki.Ki, level int, d interface{}) bool {
+		sfn := k.Embed(KiT_FileNode).(*FileNode)
+		if sfn.Buf != nil {
+			bufs[string(sfn.FPath)] = sfn.Buf
+		}
+		return true
+	})
+	return bufs
+}
+
+// FindInFiles finds the text given by the params in all the files within
+// this directory (including closed directories, which are read from disk),
+// or in this file.  Files open in a buffer are searched first, using the
+// current contents of the buffer, and then the other files are searched
+// in parallel, reading them from disk, and skipping binary files and
+// those excluded by the .gitignore and category filters.  fun is called
+// with the matches for each file having any, one file at a time, from other
+// goroutines -- if it returns false the search stops.
+func (fn *FileNode) FindInFiles(fp *FindParams, fun func(fr FindResult) bool) error {
+	re, err := fp.FindRegexp()
+	if err != nil {
+		return err
+	}
+	root := string(fn.FPath)
+	bufs := fn.OpenBufs()
+	bpaths := make([]string, 0, len(bufs))
+	for path := range bufs {
+		if path == root || strings.HasPrefix(path, root+string(filepath.Separator)) {
+			bpaths = append(bpaths, path)
+		}
+	}
+	sort.Strings(bpaths)
+	var ign textbuf.Ignores
+	if fp.Gitignore {
+		ign = fn.ParentIgnores()
+	}
+	for _, path := range bpaths {
+		if fp.Gitignore && ignoredUnder(ign, root, path) {
+			continue
+		}
+		if !fp.CatOk(path) {
+			continue
+		}
+		_, ms := bufs[path].SearchRegexp(re, false, fp.MultiLine)
+		if len(ms) > 0 && !fun(FindResult{Path: path, Matches: ms}) {
+			return nil
+		}
+	}
+	var stop int32
+	files := make(chan string, 100)
+	go func() {
+		defer close(files)
+		filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return nil
+			}
+			if atomic.LoadInt32(&stop) != 0 {
+				return filepath.SkipDir
+			}
+			if info.IsDir() {
+				if !fp.Gitignore {
+					return nil
+				}
+				if info.Name() == ".git" || (path != root && ign.Ignored(path, true)) {
+					return filepath.SkipDir
+				}
+				ign = append(ign, textbuf.ReadIgnoreFile(path)...)
+				return nil
+			}
+			if !info.Mode().IsRegular() || bufs[path] != nil {
+				return nil
+			}
+			if fp.Gitignore && ign.Ignored(path, false) {
+				return nil
+			}
+			if fp.CatOk(path) {
+				files <- path
+			}
+			return nil
+		})
+	}()
+	textbuf.SearchFilesRegexp(files, re, fp.MultiLine, FindInFilesWorkers, func(path string, ms []textbuf.Match) bool {
+		if !fun(FindResult{Path: path, Matches: ms}) {
+			atomic.StoreInt32(&stop, 1)
+			return false
+		}
+		return true
+	})
+	return nil
+}
+
+// ParentIgnores returns the .gitignore rules from the directories above this
+// node, up to the root of its repository (or just its parent directory if
+// it is not in a repository), which apply to the files within this node
+func (fn *FileNode) ParentIgnores() textbuf.Ignores {
+	top := filepath.Dir(string(fn.FPath))
+	if _, rnode := fn.Repo(); rnode != nil {
+		top = string(rnode.FPath)
+	}
+	var dirs []string
+	for dir := filepath.Dir(string(fn.FPath)); strings.HasPrefix(dir, top); dir = filepath.Dir(dir) {
+		dirs = append(dirs, dir)
+		if dir == top || dir == filepath.Dir(dir) {
+			break
+		}
+	}
+	var ign textbuf.Ignores
+	for i := len(dirs) - 1; i >= 0; i-- {
+		ign = append(ign, textbuf.ReadIgnoreFile(dirs[i])...)
+	}
+	return ign
+}
+
+// ignoredUnder returns true if the file at given path within root is
+// ignored, by the given rules for root or the .gitignore files in the
+// directories from root down to the file, or because one of those
+// directories is ignored
+func ignoredUnder(ign textbuf.Ignores, root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	if err != nil || rel == "." {
+		return ign.Ignored(path, false)
+	}
+	ign = append(textbuf.Ignores{}, ign...)
+	els := strings.Split(rel, string(filepath.Separator))
+	dir := root
+	for i, el := range els {
+		ign = append(ign, textbuf.ReadIgnoreFile(dir)...)
+		dir = filepath.Join(dir, el)
+		isDir := i < len(els)-1
+		if (isDir && el == ".git") || ign.Ignored(dir, isDir) {
+			return true
+		}
+	}
+	return false
+}
+
+// FindReplace is the replacement of the matches within one file, as shown
+// in the preview of a replace in files
+type FindReplace struct {
+	File         string          `width:"50" desc:"file, relative to the root of the find"`
+	Replacements int             `desc:"number of matches replaced"`
+	Path         string          `tableview:"-" desc:"full path to the file"`
+	Buf          *TextBuf        `tableview:"-" view:"-" json:"-" xml:"-" desc:"buffer the file is open in, if any -- the replacements are made in the buffer instead of the file"`
+	Matches      []textbuf.Match `tableview:"-" view:"-" json:"-" xml:"-" desc:"matches in the current contents of the file"`
+	Old          []string        `tableview:"-" view:"-" json:"-" xml:"-" desc:"lines of the file before replacing"`
+	New          []string        `tableview:"-" view:"-" json:"-" xml:"-" desc:"lines of the file after replacing"`
+	CRLF         bool            `tableview:"-" view:"-" json:"-" xml:"-" desc:"file on disk has CRLF line endings (based on the first line), which are kept when writing it"`
+}
+
+// runeLinesToStrings returns the lines as strings
+func runeLinesToStrings(lns [][]rune) []string {
+	strs := make([]string, len(lns))
+	for i, ln := range lns {
+		strs[i] = string(ln)
+	}
+	return strs
+}
+
+// ReplaceInFiles returns the replacements of the text given by the params
+// in each of the given files, searching the current contents again so the
+// replacements are up-to-date -- files open in a buffer use the buffer.
+// The replacements are made by ApplyReplace.
+func ReplaceInFiles(fp *FindParams, paths []string, bufs map[string]*TextBuf, root string) ([]FindReplace, error) {
+	re, err := fp.FindRegexp()
+	if err != nil {
+		return nil, err
+	}
+	var rre *regexp.Regexp
+	if fp.Regexp {
+		rre = re
+	}
+	var reps []FindReplace
+	for _, path := range paths {
+		fr := FindReplace{File: RelFilePath(path, root), Path: path, Buf: bufs[path]}
+		var src [][]rune
+		if fr.Buf != nil {
+			_, fr.Matches = fr.Buf.SearchRegexp(re, false, fp.MultiLine)
+			fr.Buf.LinesMu.RLock()
+			src = make([][]rune, len(fr.Buf.Lines))
+			copy(src, fr.Buf.Lines)
+			fr.Buf.LinesMu.RUnlock()
+		} else {
+			txt, err := textbuf.FileBytes(path)
+			if err != nil || textbuf.IsBinary(txt) {
+				continue
+			}
+			if li := bytes.IndexByte(txt, '\n'); li > 0 && txt[li-1] == '\r' {
+				fr.CRLF = true
+			}
+			src = textbuf.BytesToRuneLines(txt)
+			if fp.MultiLine {
+				_, fr.Matches = textbuf.SearchMultiLineRegexp(src, re)
+			} else {
+				_, fr.Matches = textbuf.SearchRuneLinesRegexp(src, re)
+			}
+		}
+		if len(fr.Matches) == 0 {
+			continue
+		}
+		fr.Replacements = len(fr.Matches)
+		fr.Old = runeLinesToStrings(src)
+		fr.New = runeLinesToStrings(textbuf.ReplaceMatches(src, fr.Matches, rre, []byte(fp.Replace)))
+		reps = append(reps, fr)
+	}
+	return reps, nil
+}
+
+// ApplyReplace makes the replacements from ReplaceInFiles: in the buffer if
+// the file is open, as one group of edits that is undone together, and
+// otherwise directly in the file on disk.  Returns the number of files
+// changed.
+func ApplyReplace(fp *FindParams, reps []FindReplace) (int, error) {
+	var rre *regexp.Regexp
+	if fp.Regexp {
+		re, err := fp.FindRegexp()
+		if err != nil {
+			return 0, err
+		}
+		rre = re
+	}
+	n := 0
+	for i := range reps {
+		fr := &reps[i]
+		if fr.Buf != nil {
+			fr.Buf.ReplaceMatches(fr.Matches, rre, fp.Replace)
+			n++
+			continue
+		}
+		eol := "\n"
+		if fr.CRLF {
+			eol = "\r\n"
+		}
+		perm := os.FileMode(0644)
+		if st, err := os.Stat(fr.Path); err == nil {
+			perm = st.Mode().Perm()
+		}
+		if err := ioutil.WriteFile(fr.Path, []byte(strings.Join(fr.New, eol)), perm); err != nil {
+			return n, fmt.Errorf("giv.ApplyReplace: %v", err)
+		}
+		n++
+	}
+	return n, nil
+}
+
+/////////////////////////////////////////////////////////////////////////////
+//   FindView
+
+// FindView finds, and optionally replaces, text within the files of a
+// FileTree, showing the results in a TextView with a link to each match.
+type FindView struct {
+	gi.Layout
+	Files      *FileNode    `json:"-" xml:"-" copy:"-" desc:"file or directory whose files are searched"`
+	Params     FindParams   `desc:"find parameters"`
+	Results    []FindResult `json:"-" xml:"-" desc:"results of the last find, in the order found"`
+	ResultsBuf *TextBuf     `json:"-" xml:"-" desc:"buffer showing the results, with links to each match"`
+	Mu         sync.Mutex   `json:"-" xml:"-" view:"-" desc:"mutex protecting Results"`
+	stop       *int32
+}
+
+var KiT_FindView = kit.Types.AddType(&FindView{}, FindViewProps)
+
+// Config configures to search within given file node
+func (fv *FindView) Config(root *FileNode) {
+	fv.Files = root
+	fv.Lay = gi.LayoutVert
+	config := kit.TypeAndNameList{}
+	config.Add(gi.KiT_ToolBar, "toolbar")
+	config.Add(KiT_StructView, "params")
+	config.Add(gi.KiT_Layout, "results-lay")
+	mods, updt := fv.ConfigChildren(config, ki.UniqueNames)
+	if mods {
+		fv.ConfigToolBar()
+		fv.ParamsView().SetStruct(&fv.Params)
+		fv.ConfigResults()
+	} else {
+		updt = fv.UpdateStart()
+	}
+	fv.UpdateEnd(updt)
+}
+
+// ToolBar returns the toolbar
+func (fv *FindView) ToolBar() *gi.ToolBar {
+	return fv.ChildByName("toolbar", 0).(*gi.ToolBar)
+}
+
+// ParamsView returns the structview of the find params
+func (fv *FindView) ParamsView() *StructView {
+	return fv.ChildByName("params", 1).(*StructView)
+}
+
+// ResultsView returns the textview showing the results
+func (fv *FindView) ResultsView() *TextView {
+	return fv.ChildByName("results-lay", 2).Child(0).Embed(KiT_TextView).(*TextView)
+}
+
+// ConfigResults configures the results textview and buffer
+func (fv *FindView) ConfigResults() {
+	if fv.ResultsBuf == nil {
+		fv.ResultsBuf = &TextBuf{}
+		fv.ResultsBuf.InitName(fv.ResultsBuf, "find-results-buf")
+	}
+	rl := fv.ChildByName("results-lay", 2).(*gi.Layout)
+	rl.SetStretchMax()
+	rl.SetMinPrefWidth(units.NewEm(20))
+	rl.SetMinPrefHeight(units.NewEm(10))
+	tv := AddNewTextView(rl, "results")
+	tv.SetProp("font-family", gi.Prefs.MonoFont)
+	tv.SetProp("white-space", gi.WhiteSpacePre)
+	tv.SetInactive()
+	tv.SetBuf(fv.ResultsBuf)
+	tv.LinkSig.Connect(fv.This(), func(recv, send ki.Ki, sig int64, data interface{}) {
+		fvv := recv.Embed(KiT_FindView).(*FindView)
+		fvv.OpenFindURL(data.(string))
+	})
+}
+
+// Find finds the text given by the params, in a separate goroutine,
+// streaming the results into the results view as they are found
+func (fv *FindView) Find() {
+	fv.Stop()
+	if _, err := fv.Params.FindRegexp(); err != nil {
+		gi.PromptDialog(fv.Viewport, gi.DlgOpts{Title: "Could not Find", Prompt: err.Error()}, gi.AddOk, gi.NoCancel, nil, nil)
+		return
+	}
+	stop := new(int32)
+	fv.Mu.Lock()
+	fv.stop = stop
+	fv.Results = nil
+	fv.Mu.Unlock()
+	fv.ResultsBuf.New(0)
+	fv.ResultsBuf.Undos.Off = true
+	fp := fv.Params
+	go func() {
+		nm := 0
+		nf := 0
+		fv.Files.FindInFiles(&fp, func(fr FindResult) bool {
+			if atomic.LoadInt32(stop) != 0 {
+				return false
+			}
+			fv.Mu.Lock()
+			fv.Results = append(fv.Results, fr)
+			fv.Mu.Unlock()
+			fv.AppendResult(fr)
+			nm += len(fr.Matches)
+			nf++
+			return true
+		})
+		sum := fmt.Sprintf("Found %d matches in %d files", nm, nf)
+		if atomic.LoadInt32(stop) != 0 {
+			sum += " (stopped)"
+		}
+		fv.ResultsBuf.AppendTextLineMarkup([]byte(sum), []byte("<i>"+sum+"</i>"), EditSignal)
+	}()
+}
+
+// Stop stops the current find, if running
+func (fv *FindView) Stop() {
+	fv.Mu.Lock()
+	if fv.stop != nil {
+		atomic.StoreInt32(fv.stop, 1)
+		fv.stop = nil
+	}
+	fv.Mu.Unlock()
+}
+
+// FindURL returns the URL of the link to given region in given file, of the
+// form file:///path#LxxCxx-LxxCxx as decoded by textbuf.Region.FromString
+func FindURL(path string, reg textbuf.Region) string {
+	return fmt.Sprintf("file://%s#L%dC%d-L%dC%d", filepath.ToSlash(path), reg.Start.Ln+1, reg.Start.Ch+1, reg.End.Ln+1, reg.End.Ch+1)
+}
+
+// findMatchText returns the plain text and markup of the text of a match,
+// which has the match itself within <mark> tags
+func findMatchText(mt []byte) (txt, mu []byte) {
+	st := bytes.Index(mt, []byte("<mark>"))
+	ed := bytes.Index(mt, []byte("</mark>"))
+	if st < 0 || ed < st {
+		return mt, HTMLEscapeBytes(mt)
+	}
+	pre, fnd, post := mt[:st], mt[st+len("<mark>"):ed], mt[ed+len("</mark>"):]
+	txt = append(append(append([]byte{}, pre...), fnd...), post...)
+	mu = append(HTMLEscapeBytes(pre), "<mark>"...)
+	mu = append(mu, HTMLEscapeBytes(fnd)...)
+	mu = append(mu, "</mark>"...)
+	mu = append(mu, HTMLEscapeBytes(post)...)
+	return
+}
+
+// AppendResult appends the result for one file to the results buffer: a
+// line with the file and the number of matches, and then a line for each
+// match, with a link to it
+func (fv *FindView) AppendResult(fr FindResult) {
+	var tb, mb bytes.Buffer
+	rel := RelFilePath(fr.Path, string(fv.Files.FPath))
+	if rel == "" || rel == "." {
+		rel = DirAndFile(fr.Path)
+	}
+	cnt := fmt.Sprintf(": %d matches\n", len(fr.Matches))
+	tb.WriteString(rel + cnt)
+	fmt.Fprintf(&mb, `<b><a href="%s">%s</a></b>%s`, html.EscapeString(FindURL(fr.Path, textbuf.Region{})), HTMLEscapeBytes([]byte(rel)), cnt)
+	for _, m := range fr.Matches {
+		pos := fmt.Sprintf("%d:%d", m.Reg.Start.Ln+1, m.Reg.Start.Ch+1)
+		txt, mu := findMatchText(m.Text)
+		fmt.Fprintf(&tb, "    %s: %s\n", pos, txt)
+		fmt.Fprintf(&mb, `    <a href="%s">%s</a>: %s`+"\n", html.EscapeString(FindURL(fr.Path, m.Reg)), pos, mu)
+	}
+	fv.ResultsBuf.AppendTextMarkup(tb.Bytes(), mb.Bytes(), EditSignal)
+	fv.ResultsBuf.AutoScrollViews()
+}
+
+// OpenFindURL opens the file at the region given by a URL from FindURL: in
+// the view of its buffer if it is open in one, otherwise with the
+// gi.TextLinkHandler if that handles it, and otherwise in a new dialog
+func (fv *FindView) OpenFindURL(url string) {
+	if !strings.HasPrefix(url, "file://") {
+		return
+	}
+	path := strings.TrimPrefix(url, "file://")
+	reg := textbuf.Region{}
+	if hi := strings.LastIndex(path, "#"); hi >= 0 {
+		reg.FromString(path[hi:])
+		path = path[:hi]
+	}
+	path = filepath.FromSlash(path)
+	var fn *FileNode
+	if fv.Files.FRoot != nil {
+		fn, _ = fv.Files.FRoot.NodeByPath(path)
+	}
+	if fn != nil && fn.Buf != nil && len(fn.Buf.Views) > 0 {
+		tv := fn.Buf.Views[0]
+		tv.GrabFocus()
+		tv.SavePosHistory(tv.GoToFilePos(reg.Start))
+		tv.HighlightRegion(tv.Buf.AdjustReg(tv.Buf.RegionFromFile(reg)))
+		return
+	}
+	if gi.TextLinkHandler != nil && gi.TextLinkHandler(gi.TextLink{URL: url, Widget: fv.ResultsView().This().(gi.Node2D)}) {
+		return
+	}
+	var tb *TextBuf
+	if fn != nil {
+		if _, err := fn.OpenBuf(); err != nil {
+			log.Println(err)
+			return
+		}
+		tb = fn.Buf
+	} else {
+		tb = &TextBuf{}
+		tb.InitName(tb, "find-file-buf")
+		if err := tb.Open(gi.FileName(path)); err != nil {
+			log.Println(err)
+			return
+		}
+	}
+	tv := TextViewDialog(fv.Viewport, nil, DlgOpts{Title: DirAndFile(path), Ok: true})
+	tv.SetInactiveState(false)
+	tv.SetBuf(tb)
+	tv.GoToFilePos(reg.Start)
+	tv.HighlightRegion(tb.RegionFromFile(reg))
+}
+
+// ReplacePreview shows the replacements of the text given by the params in
+// the files of the last find, in a dialog from which the changes in each
+// file can be shown, and all the replacements made
+func (fv *FindView) ReplacePreview() {
+	fv.Mu.Lock()
+	paths := make([]string, len(fv.Results))
+	for i, fr := range fv.Results {
+		paths[i] = fr.Path
+	}
+	fv.Mu.Unlock()
+	reps, err := ReplaceInFiles(&fv.Params, paths, fv.Files.OpenBufs(), string(fv.Files.FPath))
+	if err != nil {
+		gi.PromptDialog(fv.Viewport, gi.DlgOpts{Title: "Could not Replace", Prompt: err.Error()}, gi.AddOk, gi.NoCancel, nil, nil)
+		return
+	}
+	if len(reps) == 0 {
+		gi.PromptDialog(fv.Viewport, gi.DlgOpts{Title: "Nothing to Replace", Prompt: "No matches were found in the current contents of the files -- use Find to update the results"}, gi.AddOk, gi.NoCancel, nil, nil)
+		return
+	}
+	nrep := 0
+	for _, fr := range reps {
+		nrep += fr.Replacements
+	}
+	prompt := fmt.Sprintf("Replace %d matches in %d files with: <b>%s</b> -- double-click on a file to show its changes, and select Ok to make all the replacements (in the editor for open files, where they can be undone, and otherwise on disk)", nrep, len(reps), html.EscapeString(fv.Params.Replace))
+	dlg := gi.NewStdDialog(gi.DlgOpts{Title: "Replace in Files", Prompt: prompt}, gi.AddOk, gi.AddCancel)
+	frame := dlg.Frame()
+	_, prIdx := dlg.PromptWidget(frame)
+	sv := frame.InsertNewChild(KiT_TableView, prIdx+1, "replaces").(*TableView)
+	sv.Viewport = dlg.Embed(gi.KiT_Viewport2D).(*gi.Viewport2D)
+	sv.SetInactiveState(true)
+	sv.SetSlice(&reps)
+	sv.SliceViewSig.Connect(dlg.This(), func(recv, send ki.Ki, sig int64, data interface{}) {
+		if sig == int64(SliceViewDoubleClicked) {
+			idx := data.(int)
+			if idx >= 0 && idx < len(reps) {
+				fr := &reps[idx]
+				DiffViewDialog(sv.Viewport, fr.Old, fr.New, fr.Path, fr.Path, "", "replaced", DlgOpts{Title: "Replace in: " + fr.File})
+			}
+		}
+	})
+	dlg.DialogSig.Connect(fv.This(), func(recv, send ki.Ki, sig int64, data interface{}) {
+		if sig == int64(gi.DialogAccepted) {
+			fvv := recv.Embed(KiT_FindView).(*FindView)
+			if _, err := ApplyReplace(&fvv.Params, reps); err != nil {
+				gi.PromptDialog(fvv.Viewport, gi.DlgOpts{Title: "Could not Replace", Prompt: err.Error()}, gi.AddOk, gi.NoCancel, nil, nil)
+			}
+			fvv.Find()
+		}
+	})
+	dlg.SetProp("min-width", units.NewEm(50))
+	dlg.SetProp("min-height", units.NewEm(30))
+	dlg.UpdateEndNoSig(true)
+	dlg.Open(0, 0, fv.Viewport, nil)
+}
+
+// ConfigToolBar
+func (fv *FindView) ConfigToolBar() {
+	tb := fv.ToolBar()
+	tb.AddAction(gi.ActOpts{Label: "Find", Icon: "search", Tooltip: "find the text in all the files, showing a link to each match"}, fv.This(),
+		func(recv, send ki.Ki, sig int64, data interface{}) {
+			fvv := recv.Embed(KiT_FindView).(*FindView)
+			fvv.Find()
+		})
+	tb.AddAction(gi.ActOpts{Label: "Stop", Icon: "stop", Tooltip: "stop the current find"}, fv.This(),
+		func(recv, send ki.Ki, sig int64, data interface{}) {
+			fvv := recv.Embed(KiT_FindView).(*FindView)
+			fvv.Stop()
+		})
+	tb.AddSeparator("rsep")
+	tb.AddAction(gi.ActOpts{Label: "Replace...", Icon: "edit", Tooltip: "replace the matches in the files found by the last find, after showing a preview of the changes in each file"}, fv.This(),
+		func(recv, send ki.Ki, sig int64, data interface{}) {
+			fvv := recv.Embed(KiT_FindView).(*FindView)
+			fvv.ReplacePreview()
+		})
+}
+
+// FindViewProps are style properties for FindView
+var FindViewProps = ki.Props{
+	"EnumType:Flag": gi.KiT_NodeFlags,
+	"max-width":     -1,
+	"max-height":    -1,
+}
+
+// FindViewDialog opens a FindView for finding text within the files of
+// given file node, which is typically a directory in a FileTree
+func FindViewDialog(avp *gi.Viewport2D, root *FileNode, fp *FindParams) *FindView {
+	title := "Find In Files: " + DirAndFile(string(root.FPath))
+	dlg := gi.NewStdDialog(gi.DlgOpts{Title: title}, gi.NoOk, gi.NoCancel)
+	frame := dlg.Frame()
+	_, prIdx := dlg.PromptWidget(frame)
+
+	fv := frame.InsertNewChild(KiT_FindView, prIdx+1, "findview").(*FindView)
+	fv.Viewport = dlg.Embed(gi.KiT_Viewport2D).(*gi.Viewport2D)
+	if fp != nil {
+		fv.Params = *fp
+	}
+	fv.Config(root)
+	dlg.SetProp("min-width", units.NewEm(60))
+	dlg.SetProp("min-height", units.NewEm(40))
+	dlg.UpdateEndNoSig(true)
+	dlg.Open(0, 0, avp, nil)
+	return fv
+}
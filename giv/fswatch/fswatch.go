@@ -0,0 +1,331 @@
+// Copyright (c) 2020, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package fswatch watches directories for changes to the files within them,
+// using inotify on linux, and polling the directories otherwise (or if a
+// directory cannot be watched natively, e.g., due to the inotify watch
+// limit).  Events are delivered in batches after a short delay, so that
+// bursts of changes (e.g., from a build or a git checkout) are handled
+// together.  Directories are not watched recursively.
+package fswatch
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Ops are the kinds of changes to files
+type Ops int32
+
+const (
+	// Create is a new file or directory
+	Create Ops = iota
+
+	// Write is a change to the contents of a file
+	Write
+
+	// Remove is a file or directory that was deleted (or moved out of the
+	// watched directories)
+	Remove
+
+	// Rename is a file or directory that was renamed or moved within the
+	// watched directories, from OldPath to Path
+	Rename
+
+	OpsN
+)
+
+var opsNames = [...]string{"Create", "Write", "Remove", "Rename"}
+
+func (op Ops) String() string {
+	if op < 0 || op >= OpsN {
+		return "Ops(?)"
+	}
+	return opsNames[op]
+}
+
+// Event is a change to a file within a watched directory
+type Event struct {
+	Op      Ops    `desc:"kind of change"`
+	Path    string `desc:"full path of the file"`
+	OldPath string `desc:"for Rename, the full path of the file before it was renamed"`
+}
+
+// DelayMSec is the delay in msec after the first event before all the events
+// received in the meantime are delivered as a batch
+var DelayMSec = 200
+
+// PollMSec is the interval in msec between polls of directories that are
+// not watched natively
+var PollMSec = 2000
+
+// native is the interface to the native (OS) watching mechanism
+type native interface {
+	add(dir string) error
+	remove(dir string) error
+	close() error
+}
+
+// fileStat is the state of a file in a polled directory
+type fileStat struct {
+	mod  time.Time
+	size int64
+	dir  bool
+}
+
+// same returns true if the file state is the same
+func (fs fileStat) same(o fileStat) bool {
+	return fs.mod.Equal(o.mod) && fs.size == o.size && fs.dir == o.dir
+}
+
+// dirState is the state of a watched directory -- files is nil if the
+// directory is watched natively, and otherwise has the state as of the
+// last poll
+type dirState struct {
+	files map[string]fileStat
+}
+
+// Watcher watches a set of directories for changes to the files within
+// them, calling Fun with each batch of events -- Fun is called in a
+// separate goroutine.
+type Watcher struct {
+	Fun     func(evs []Event) `desc:"function called with each batch of events"`
+	mu      sync.Mutex
+	dirs    map[string]*dirState
+	nat     native
+	pend    []Event
+	timer   *time.Timer
+	polling bool
+	done    chan struct{}
+	closed  bool
+}
+
+// New returns a new Watcher calling given function with each batch of
+// events, using native watching if available, and polling otherwise
+func New(fun func(evs []Event)) *Watcher {
+	w := newWatcher(fun)
+	if nat, err := newNative(w.send); err == nil {
+		w.nat = nat
+	}
+	return w
+}
+
+// NewPolling returns a new Watcher calling given function with each batch
+// of events, which polls the directories instead of using native watching
+func NewPolling(fun func(evs []Event)) *Watcher {
+	return newWatcher(fun)
+}
+
+func newWatcher(fun func(evs []Event)) *Watcher {
+	return &Watcher{Fun: fun, dirs: make(map[string]*dirState), done: make(chan struct{})}
+}
+
+// Add starts watching given directory, if not already being watched
+func (w *Watcher) Add(dir string) error {
+	dir = filepath.Clean(dir)
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return errors.New("fswatch.Add: watcher is closed")
+	}
+	if _, has := w.dirs[dir]; has {
+		return nil
+	}
+	if w.nat != nil && w.nat.add(dir) == nil {
+		w.dirs[dir] = &dirState{}
+		return nil
+	}
+	files, err := readDirStat(dir)
+	if err != nil {
+		return err
+	}
+	w.dirs[dir] = &dirState{files: files}
+	if !w.polling {
+		w.polling = true
+		go w.pollLoop()
+	}
+	return nil
+}
+
+// Remove stops watching given directory
+func (w *Watcher) Remove(dir string) {
+	dir = filepath.Clean(dir)
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	ds, has := w.dirs[dir]
+	if !has {
+		return
+	}
+	if ds.files == nil && w.nat != nil {
+		w.nat.remove(dir)
+	}
+	delete(w.dirs, dir)
+}
+
+// IsWatched returns true if given directory is being watched
+func (w *Watcher) IsWatched(dir string) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	_, has := w.dirs[filepath.Clean(dir)]
+	return has
+}
+
+// Close stops watching all directories -- no more events are delivered
+func (w *Watcher) Close() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return
+	}
+	w.closed = true
+	if w.nat != nil {
+		w.nat.close()
+	}
+	if w.timer != nil {
+		w.timer.Stop()
+	}
+	close(w.done)
+	w.dirs = nil
+	w.pend = nil
+}
+
+// send adds the event to the pending batch
+func (w *Watcher) send(ev Event) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return
+	}
+	if ev.Op == Remove || ev.Op == Rename {
+		dir := ev.Path
+		if ev.Op == Rename {
+			dir = ev.OldPath
+		}
+		if ds, has := w.dirs[dir]; has { // watched dir itself is gone
+			if ds.files == nil && w.nat != nil {
+				w.nat.remove(dir)
+			}
+			delete(w.dirs, dir)
+		}
+	}
+	w.pend = append(w.pend, ev)
+	if w.timer == nil {
+		w.timer = time.AfterFunc(time.Duration(DelayMSec)*time.Millisecond, w.flush)
+	}
+}
+
+// flush delivers the pending batch of events, without duplicates
+func (w *Watcher) flush() {
+	w.mu.Lock()
+	evs := w.pend
+	w.pend = nil
+	w.timer = nil
+	closed := w.closed
+	w.mu.Unlock()
+	if closed || len(evs) == 0 || w.Fun == nil {
+		return
+	}
+	seen := make(map[Event]bool, len(evs))
+	uevs := evs[:0]
+	for _, ev := range evs {
+		if !seen[ev] {
+			seen[ev] = true
+			uevs = append(uevs, ev)
+		}
+	}
+	w.Fun(uevs)
+}
+
+/////////////////////////////////////////////////////////////////////////////
+//   Polling
+
+// readDirStat returns the state of the files in given directory
+func readDirStat(dir string) (map[string]fileStat, error) {
+	fis, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	files := make(map[string]fileStat, len(fis))
+	for _, fi := range fis {
+		files[fi.Name()] = fileStat{mod: fi.ModTime(), size: fi.Size(), dir: fi.IsDir()}
+	}
+	return files, nil
+}
+
+// pollLoop polls the directories not watched natively, until closed
+func (w *Watcher) pollLoop() {
+	for {
+		select {
+		case <-w.done:
+			return
+		case <-time.After(time.Duration(PollMSec) * time.Millisecond):
+			w.poll()
+		}
+	}
+}
+
+// poll checks each polled directory for changes since the last poll --
+// a file removed and another created with the same size and modification
+// time is reported as a rename
+func (w *Watcher) poll() {
+	w.mu.Lock()
+	var dirs []string
+	for dir, ds := range w.dirs {
+		if ds.files != nil {
+			dirs = append(dirs, dir)
+		}
+	}
+	w.mu.Unlock()
+	for _, dir := range dirs {
+		files, err := readDirStat(dir)
+		w.mu.Lock()
+		ds, has := w.dirs[dir]
+		if !has {
+			w.mu.Unlock()
+			continue
+		}
+		if err != nil {
+			if os.IsNotExist(err) && len(ds.files) > 0 {
+				files = make(map[string]fileStat)
+			} else {
+				w.mu.Unlock()
+				continue
+			}
+		}
+		old := ds.files
+		ds.files = files
+		w.mu.Unlock()
+		var removed []string
+		for nm := range old {
+			if _, has := files[nm]; !has {
+				removed = append(removed, nm)
+			}
+		}
+		for nm, st := range files {
+			ost, has := old[nm]
+			switch {
+			case !has:
+				ev := Event{Op: Create, Path: filepath.Join(dir, nm)}
+				for i, rnm := range removed {
+					if old[rnm].same(st) {
+						ev.Op = Rename
+						ev.OldPath = filepath.Join(dir, rnm)
+						removed = append(removed[:i], removed[i+1:]...)
+						break
+					}
+				}
+				w.send(ev)
+			case !ost.same(st) && !st.dir:
+				w.send(Event{Op: Write, Path: filepath.Join(dir, nm)})
+			}
+		}
+		for _, nm := range removed {
+			w.send(Event{Op: Remove, Path: filepath.Join(dir, nm)})
+		}
+	}
+}
@@ -0,0 +1,128 @@
+// Copyright (c) 2020, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build linux
+// +build linux
+
+package fswatch
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"unsafe"
+)
+
+// inotifyMask is the set of inotify events watched for each directory
+const inotifyMask = syscall.IN_CREATE | syscall.IN_DELETE | syscall.IN_MODIFY | syscall.IN_CLOSE_WRITE |
+	syscall.IN_MOVED_FROM | syscall.IN_MOVED_TO | syscall.IN_DELETE_SELF | syscall.IN_MOVE_SELF | syscall.IN_ONLYDIR
+
+// inotify watches directories using the linux inotify api
+type inotify struct {
+	f    *os.File
+	fd   int
+	send func(ev Event)
+	mu   sync.Mutex
+	wds  map[int32]string
+	dirs map[string]int32
+}
+
+func newNative(send func(ev Event)) (native, error) {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return nil, err
+	}
+	in := &inotify{f: os.NewFile(uintptr(fd), "inotify"), fd: fd, send: send, wds: make(map[int32]string), dirs: make(map[string]int32)}
+	go in.readLoop()
+	return in, nil
+}
+
+func (in *inotify) add(dir string) error {
+	wd, err := syscall.InotifyAddWatch(in.fd, dir, inotifyMask)
+	if err != nil {
+		return err
+	}
+	in.mu.Lock()
+	in.wds[int32(wd)] = dir
+	in.dirs[dir] = int32(wd)
+	in.mu.Unlock()
+	return nil
+}
+
+func (in *inotify) remove(dir string) error {
+	in.mu.Lock()
+	wd, has := in.dirs[dir]
+	if has {
+		delete(in.dirs, dir)
+		delete(in.wds, wd)
+	}
+	in.mu.Unlock()
+	if !has {
+		return nil
+	}
+	_, err := syscall.InotifyRmWatch(in.fd, uint32(wd))
+	return err
+}
+
+func (in *inotify) close() error {
+	return in.f.Close() // unblocks readLoop
+}
+
+// readLoop reads and translates events until closed -- a move within the
+// watched directories is reported as a Rename when both halves of it are
+// read together, and otherwise as a Remove or Create
+func (in *inotify) readLoop() {
+	var buf [64 * 1024]byte
+	for {
+		n, err := in.f.Read(buf[:])
+		if err != nil {
+			return
+		}
+		moves := make(map[uint32]string)
+		var cookies []uint32
+		for off := 0; off+syscall.SizeofInotifyEvent <= n; {
+			raw := (*syscall.InotifyEvent)(unsafe.Pointer(&buf[off]))
+			nst := off + syscall.SizeofInotifyEvent
+			off = nst + int(raw.Len)
+			if raw.Mask&syscall.IN_IGNORED != 0 {
+				continue
+			}
+			in.mu.Lock()
+			dir, has := in.wds[raw.Wd]
+			in.mu.Unlock()
+			if !has {
+				continue
+			}
+			path := dir
+			if nm := strings.TrimRight(string(buf[nst:off]), "\x00"); nm != "" {
+				path = filepath.Join(dir, nm)
+			}
+			switch {
+			case raw.Mask&syscall.IN_MOVED_FROM != 0:
+				moves[raw.Cookie] = path
+				cookies = append(cookies, raw.Cookie)
+			case raw.Mask&syscall.IN_MOVED_TO != 0:
+				if opath, has := moves[raw.Cookie]; has {
+					delete(moves, raw.Cookie)
+					in.send(Event{Op: Rename, Path: path, OldPath: opath})
+				} else {
+					in.send(Event{Op: Create, Path: path})
+				}
+			case raw.Mask&syscall.IN_CREATE != 0:
+				in.send(Event{Op: Create, Path: path})
+			case raw.Mask&(syscall.IN_DELETE|syscall.IN_DELETE_SELF|syscall.IN_MOVE_SELF) != 0:
+				in.send(Event{Op: Remove, Path: path})
+			case raw.Mask&(syscall.IN_MODIFY|syscall.IN_CLOSE_WRITE) != 0:
+				in.send(Event{Op: Write, Path: path})
+			}
+		}
+		for _, ck := range cookies {
+			if opath, has := moves[ck]; has {
+				in.send(Event{Op: Remove, Path: opath})
+			}
+		}
+	}
+}
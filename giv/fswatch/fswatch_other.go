@@ -0,0 +1,15 @@
+// Copyright (c) 2020, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !linux
+// +build !linux
+
+package fswatch
+
+import "errors"
+
+// newNative is not supported on this platform, so directories are polled
+func newNative(send func(ev Event)) (native, error) {
+	return nil, errors.New("fswatch: native watching not supported")
+}
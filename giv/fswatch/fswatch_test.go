@@ -0,0 +1,75 @@
+// Copyright (c) 2020, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fswatch
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// waitFor waits for an event matching given op and path
+func waitFor(t *testing.T, evc chan Event, op Ops, path string) Event {
+	t.Helper()
+	tmo := time.After(5 * time.Second)
+	for {
+		select {
+		case ev := <-evc:
+			if ev.Op == op && ev.Path == path {
+				return ev
+			}
+		case <-tmo:
+			t.Fatalf("no %v event for: %s", op, path)
+			return Event{}
+		}
+	}
+}
+
+func testWatcher(t *testing.T, newFun func(fun func(evs []Event)) *Watcher) {
+	DelayMSec = 20
+	PollMSec = 50
+	dir, err := ioutil.TempDir("", "fswatch")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	evc := make(chan Event, 100)
+	w := newFun(func(evs []Event) {
+		for _, ev := range evs {
+			evc <- ev
+		}
+	})
+	defer w.Close()
+	if err := w.Add(dir); err != nil {
+		t.Fatal(err)
+	}
+	if !w.IsWatched(dir) {
+		t.Errorf("dir should be watched")
+	}
+	fa := filepath.Join(dir, "a.txt")
+	fb := filepath.Join(dir, "b.txt")
+	ioutil.WriteFile(fa, []byte("a"), 0644)
+	waitFor(t, evc, Create, fa)
+	time.Sleep(100 * time.Millisecond)
+	ioutil.WriteFile(fa, []byte("abc"), 0644)
+	waitFor(t, evc, Write, fa)
+	time.Sleep(100 * time.Millisecond)
+	os.Rename(fa, fb)
+	if ev := waitFor(t, evc, Rename, fb); ev.OldPath != fa {
+		t.Errorf("rename from wrong path: %v", ev)
+	}
+	os.Remove(fb)
+	waitFor(t, evc, Remove, fb)
+}
+
+func TestNative(t *testing.T) {
+	testWatcher(t, New)
+}
+
+func TestPolling(t *testing.T) {
+	testWatcher(t, NewPolling)
+}
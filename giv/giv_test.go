@@ -0,0 +1,29 @@
+// Copyright (c) 2020, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package giv_test
+
+import (
+	"testing"
+
+	"github.com/goki/gi/gi"
+	"github.com/goki/gi/gi/gitest"
+	_ "github.com/goki/gi/svg" // icons
+)
+
+func TestMain(m *testing.M) {
+	gitest.Main(m)
+}
+
+// newTestWindow returns a new window with the main frame configured by
+// given function, and a Harness driving it
+func newTestWindow(t *testing.T, config func(mfr *gi.Frame)) *gitest.Harness {
+	win := gi.NewMainWindow("giv-test", "GiV Test", 640, 480)
+	vp := win.WinViewport2D()
+	updt := vp.UpdateStart()
+	mfr := win.SetMainFrame()
+	config(mfr)
+	vp.UpdateEndNoSig(updt)
+	return gitest.NewHarness(t, win)
+}
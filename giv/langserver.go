@@ -0,0 +1,598 @@
+// Copyright (c) 2020, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package giv
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/goki/gi/gi"
+	"github.com/goki/gi/giv/lsp"
+	"github.com/goki/gi/giv/textbuf"
+	"github.com/goki/ki/ki"
+	"github.com/goki/pi/complete"
+	"github.com/goki/pi/filecat"
+)
+
+// TextBufLSPDelayMSec is the number of milliseconds after the last edit
+// before the changed text is sent to the language server
+var TextBufLSPDelayMSec = 500
+
+// DiagnosticColors are the line colors used for diagnostics from the
+// language server, for each severity level
+var DiagnosticColors = map[lsp.DiagnosticSeverity]string{
+	lsp.SeverityError:       "#f44",
+	lsp.SeverityWarning:     "#f90",
+	lsp.SeverityInformation: "#4af",
+	lsp.SeverityHint:        "#4af",
+}
+
+// lspBufs are the buffers open on language servers, by document URI,
+// used to apply edits from the server to open buffers
+var (
+	lspBufsMu sync.Mutex
+	lspBufs   = make(map[string]*TextBuf)
+)
+
+func init() {
+	gi.AddQuitCleanFunc(lsp.ShutdownAll)
+}
+
+// LangServerState has the state of a TextBuf's document on the language
+// server
+type LangServerState struct {
+	Client      *lsp.Client      `desc:"client for the language server, nil if not using one"`
+	URI         string           `desc:"URI of the document on the server"`
+	Diagnostics []lsp.Diagnostic `desc:"current diagnostics for the document, as last published by the server"`
+	Mu          sync.Mutex       `desc:"mutex for the state"`
+	dirty       bool
+	timer       *time.Timer
+	diagLines   []int
+	gen         int
+}
+
+// StartLangServer starts using the language server configured for the
+// language of the file (see lsp.Servers), if enabled by the LangServer
+// option, for completion, lookup and diagnostics -- returns false if not.
+// The server is started in the background if not yet running, and the
+// buffer starts using it when it is ready, on the event loop of its view.
+func (tb *TextBuf) StartLangServer() bool {
+	if !tb.Opts.LangServer || tb.Filename == "" {
+		return false
+	}
+	cfg := lsp.Servers[tb.Info.Sup]
+	if cfg == nil {
+		return false
+	}
+	uri := lsp.FileURI(string(tb.Filename))
+	tb.LSP.Mu.Lock()
+	if tb.LSP.Client != nil {
+		tb.LSP.Mu.Unlock()
+		return false
+	}
+	tb.LSP.gen++
+	gen := tb.LSP.gen
+	tb.LSP.Mu.Unlock()
+	lsp.GoClientFor(tb.Info.Sup, string(tb.Filename), func(cl *lsp.Client) {
+		tb.goRunOnViewLoop(func() { tb.attachLangServer(cl, cfg, uri, gen) })
+	})
+	return true
+}
+
+// attachLangServer starts using given client of the language server that
+// is now ready, with the document of given URI, unless the buffer has
+// stopped or restarted using a server since it was started, as given by
+// the generation of the LangServerState
+func (tb *TextBuf) attachLangServer(cl *lsp.Client, cfg *lsp.ServerConfig, uri string, gen int) {
+	tb.LSP.Mu.Lock()
+	if tb.LSP.gen != gen || tb.LSP.Client != nil || tb.This() == nil {
+		tb.LSP.Mu.Unlock()
+		lsp.Release(cl) // shut down if not used by other buffers
+		return
+	}
+	tb.LSP.Client = cl
+	tb.LSP.URI = uri
+	tb.LSP.dirty = false
+	tb.LSP.Mu.Unlock()
+	lspBufsMu.Lock()
+	lspBufs[uri] = tb
+	lspBufsMu.Unlock()
+	cl.SetDiagnosticsHandler(uri, tb.SetDiagnostics)
+	if err := cl.DidOpen(uri, cfg.LangID, string(tb.LinesToBytesCopy())); err != nil {
+		log.Printf("giv.TextBuf StartLangServer: %v\n", err)
+	}
+	tb.SetCompleter(tb, CompleteLSP, CompleteEditLSP, LookupLSP)
+}
+
+// StopLangServer closes the document on the language server, if in use,
+// and reverts to GoPi completion -- the server is shut down when it has
+// no more open documents
+func (tb *TextBuf) StopLangServer() {
+	tb.LSP.Mu.Lock()
+	tb.LSP.gen++ // not to use a server that is still starting
+	cl := tb.LSP.Client
+	uri := tb.LSP.URI
+	if cl == nil {
+		tb.LSP.Mu.Unlock()
+		return
+	}
+	tb.LSP.Client = nil
+	tb.LSP.URI = ""
+	tb.LSP.dirty = false
+	if tb.LSP.timer != nil {
+		tb.LSP.timer.Stop()
+		tb.LSP.timer = nil
+	}
+	tb.LSP.Mu.Unlock()
+	lspBufsMu.Lock()
+	if lspBufs[uri] == tb {
+		delete(lspBufs, uri)
+	}
+	lspBufsMu.Unlock()
+	cl.DidClose(uri)
+	lsp.Release(cl)
+	tb.SetDiagnostics(nil)
+	if tb.Info.Sup != filecat.NoSupport {
+		tb.SetCompleter(&tb.PiState, CompletePi, CompleteEditPi, LookupPi)
+	} else {
+		tb.SetCompleter(nil, nil, nil, nil)
+	}
+}
+
+// LangServerChanged records that the text has changed, and sends it to
+// the language server after TextBufLSPDelayMSec without further changes.
+// Called for every edit -- does nothing if not using a server.
+func (tb *TextBuf) LangServerChanged() {
+	tb.LSP.Mu.Lock()
+	defer tb.LSP.Mu.Unlock()
+	if tb.LSP.Client == nil {
+		return
+	}
+	tb.LSP.dirty = true
+	if tb.LSP.timer != nil {
+		tb.LSP.timer.Stop()
+	}
+	tb.LSP.timer = time.AfterFunc(time.Duration(TextBufLSPDelayMSec)*time.Millisecond, func() {
+		tb.LangServerSync()
+	})
+}
+
+// LangServerSync sends the current text to the language server if it has
+// changed since last sent -- called prior to any requests, so they are
+// relative to the current text.  Returns the client, nil if not using one,
+// and the URI of the document on it, for the requests.
+func (tb *TextBuf) LangServerSync() (*lsp.Client, string) {
+	tb.LSP.Mu.Lock()
+	cl := tb.LSP.Client
+	uri := tb.LSP.URI
+	dirty := tb.LSP.dirty
+	tb.LSP.dirty = false
+	if tb.LSP.timer != nil {
+		tb.LSP.timer.Stop()
+		tb.LSP.timer = nil
+	}
+	tb.LSP.Mu.Unlock()
+	if cl == nil {
+		return nil, ""
+	}
+	if dirty {
+		if err := cl.DidChange(uri, string(tb.LinesToBytesCopy())); err != nil {
+			log.Printf("giv.TextBuf LangServerSync: %v\n", err)
+		}
+	}
+	return cl, uri
+}
+
+// LangServer returns the client of the language server in use, nil if
+// none, and the URI of the document on it
+func (tb *TextBuf) LangServer() (*lsp.Client, string) {
+	tb.LSP.Mu.Lock()
+	defer tb.LSP.Mu.Unlock()
+	return tb.LSP.Client, tb.LSP.URI
+}
+
+// LangServerSaved tells the language server that the file has been saved,
+// restarting it for the new file if the filename has changed
+func (tb *TextBuf) LangServerSaved() {
+	cl, uri := tb.LangServerSync()
+	if cl == nil {
+		tb.StartLangServer()
+		return
+	}
+	if uri != lsp.FileURI(string(tb.Filename)) {
+		tb.StopLangServer()
+		tb.StartLangServer()
+		return
+	}
+	cl.DidSave(uri)
+}
+
+// LSPPos returns the language server position for given position
+func (tb *TextBuf) LSPPos(pos textbuf.Pos) lsp.Position {
+	return lsp.Position{Line: pos.Ln, Character: lsp.UTF16Col(tb.Line(pos.Ln), pos.Ch)}
+}
+
+// PosFromLSP returns the position in the buffer for given language server
+// position
+func (tb *TextBuf) PosFromLSP(lp lsp.Position) textbuf.Pos {
+	return tb.ValidPos(textbuf.Pos{Ln: lp.Line, Ch: lsp.RuneCol(tb.Line(lp.Line), lp.Character)})
+}
+
+// SetDiagnostics sets the diagnostics for the buffer, as published by the
+// language server, marking the lines with diagnostics with line colors
+// and icons according to severity
+func (tb *TextBuf) SetDiagnostics(diags []lsp.Diagnostic) {
+	tb.LSP.Mu.Lock()
+	olns := tb.LSP.diagLines
+	tb.LSP.Diagnostics = diags
+	tb.LSP.diagLines = nil
+	tb.LSP.Mu.Unlock()
+	for _, ln := range olns {
+		tb.DeleteLineColor(ln)
+		tb.DeleteLineIcon(ln)
+	}
+	sev := make(map[int]lsp.DiagnosticSeverity)
+	for _, dg := range diags {
+		ln := dg.Range.Start.Line
+		s := dg.Severity
+		if s == 0 {
+			s = lsp.SeverityError
+		}
+		if cs, has := sev[ln]; !has || s < cs {
+			sev[ln] = s
+		}
+	}
+	lns := make([]int, 0, len(sev))
+	for ln, s := range sev {
+		lns = append(lns, ln)
+		tb.SetLineColor(ln, DiagnosticColors[s])
+		if s == lsp.SeverityError {
+			tb.SetLineIcon(ln, "cancel")
+		} else {
+			tb.SetLineIcon(ln, "info")
+		}
+	}
+	tb.LSP.Mu.Lock()
+	tb.LSP.diagLines = lns
+	tb.LSP.Mu.Unlock()
+	if len(olns) > 0 || len(lns) > 0 {
+		tb.TextBufSig.Emit(tb.This(), int64(TextBufMarkUpdt), tb.Txt)
+	}
+}
+
+// DiagnosticsAt returns the diagnostics for given line
+func (tb *TextBuf) DiagnosticsAt(ln int) []lsp.Diagnostic {
+	tb.LSP.Mu.Lock()
+	defer tb.LSP.Mu.Unlock()
+	var dgs []lsp.Diagnostic
+	for _, dg := range tb.LSP.Diagnostics {
+		if dg.Range.Start.Line == ln {
+			dgs = append(dgs, dg)
+		}
+	}
+	return dgs
+}
+
+// ApplyTextEdits applies edits from the language server, which are all
+// relative to the current text, as one undo group
+func (tb *TextBuf) ApplyTextEdits(edits []lsp.TextEdit) {
+	if len(edits) == 0 {
+		return
+	}
+	regs := make([]textbuf.Region, len(edits))
+	for i, te := range edits {
+		regs[i] = textbuf.Region{Start: tb.PosFromLSP(te.Range.Start), End: tb.PosFromLSP(te.Range.End)}
+	}
+	idxs := make([]int, len(edits))
+	for i := range idxs {
+		idxs[i] = i
+	}
+	sort.SliceStable(idxs, func(i, j int) bool { // last first, so earlier positions stay valid
+		return regs[idxs[j]].Start.IsLess(regs[idxs[i]].Start)
+	})
+	bufUpdt, winUpdt, autoSave := tb.BatchUpdateStart()
+	tb.Undos.BeginGroup()
+	for _, i := range idxs {
+		reg := regs[i]
+		if reg.Start != reg.End {
+			tb.DeleteText(reg.Start, reg.End, EditSignal)
+		}
+		tb.InsertText(reg.Start, []byte(edits[i].NewText), EditSignal)
+	}
+	tb.Undos.EndGroup()
+	tb.BatchUpdateEnd(bufUpdt, winUpdt, autoSave)
+}
+
+// ApplyWorkspaceEdit applies edits from the language server to possibly
+// many files -- edits to files open in a buffer are applied to the buffer,
+// and others directly to the file.  Returns the number of files edited.
+func ApplyWorkspaceEdit(we *lsp.WorkspaceEdit) (int, error) {
+	n := 0
+	for uri, edits := range we.Changes {
+		lspBufsMu.Lock()
+		tb := lspBufs[uri]
+		lspBufsMu.Unlock()
+		if tb != nil {
+			tb.ApplyTextEdits(edits)
+			n++
+			continue
+		}
+		fn := lsp.URIPath(uri)
+		ob := &TextBuf{}
+		ob.InitName(ob, "lsp-edit-tmp")
+		if err := ob.OpenFile(gi.FileName(fn)); err != nil {
+			return n, fmt.Errorf("giv.ApplyWorkspaceEdit: %v", err)
+		}
+		ob.ApplyTextEdits(edits)
+		if err := ioutil.WriteFile(fn, ob.LinesToBytesCopy(), 0644); err != nil {
+			return n, fmt.Errorf("giv.ApplyWorkspaceEdit: %v", err)
+		}
+		n++
+	}
+	return n, nil
+}
+
+// LangServerFormat formats the text using the language server
+func (tb *TextBuf) LangServerFormat() error {
+	cl, uri := tb.LangServerSync()
+	if cl == nil {
+		return fmt.Errorf("giv.TextBuf LangServerFormat: not using a language server")
+	}
+	edits, err := cl.Formatting(uri, lsp.FormattingOptions{TabSize: tb.Opts.TabSize, InsertSpaces: tb.Opts.SpaceIndent})
+	if err != nil {
+		return err
+	}
+	tb.ApplyTextEdits(edits)
+	return nil
+}
+
+// lspSeed returns the identifier being typed at the end of text
+func lspSeed(text string) string {
+	rs := []rune(text)
+	st := len(rs)
+	for st > 0 && (unicode.IsLetter(rs[st-1]) || unicode.IsDigit(rs[st-1]) || rs[st-1] == '_') {
+		st--
+	}
+	return string(rs[st:])
+}
+
+// CompleteLSP gets completions from the language server -- the string is
+// a line of text up to point where user has typed.
+// The data must be the *TextBuf.
+func CompleteLSP(data interface{}, text string, posLn, posCh int) (md complete.Matches) {
+	tb := data.(*TextBuf)
+	cl, uri := tb.LangServerSync()
+	if cl == nil {
+		return md
+	}
+	md.Seed = lspSeed(text)
+	items, err := cl.Completion(uri, tb.LSPPos(textbuf.Pos{Ln: posLn, Ch: posCh}))
+	if err != nil {
+		log.Printf("CompleteLSP: %v\n", err)
+		return md
+	}
+	for i := range items {
+		it := &items[i]
+		md.Matches = append(md.Matches, complete.Completion{Text: it.Text(), Label: it.Label, Icon: it.Kind.IconName(), Desc: it.Detail})
+	}
+	if md.Seed != "" {
+		md.Matches = complete.MatchSeedCompletion(md.Matches, md.Seed)
+	}
+	return md
+}
+
+// CompleteEditLSP uses the selected completion to edit the text
+func CompleteEditLSP(data interface{}, text string, cursorPos int, comp complete.Completion, seed string) (ed complete.Edit) {
+	return complete.EditWord(text, cursorPos, comp.Text, seed)
+}
+
+// LookupLSP looks up the symbol at given position using the language
+// server, showing its hover documentation if available, and otherwise
+// the source where it is defined.
+// The data must be the *TextBuf.
+func LookupLSP(data interface{}, text string, posLn, posCh int) (ld complete.Lookup) {
+	tb := data.(*TextBuf)
+	cl, uri := tb.LangServerSync()
+	if cl == nil {
+		return ld
+	}
+	lp := tb.LSPPos(textbuf.Pos{Ln: posLn, Ch: posCh})
+	if hv, err := cl.Hover(uri, lp); err == nil && hv != "" {
+		ld.Text = []byte(hv)
+		TextViewDialog(nil, ld.Text, DlgOpts{Title: "Lookup: " + text})
+		return ld
+	}
+	locs, err := cl.Definition(uri, lp)
+	if err != nil || len(locs) == 0 {
+		return ld
+	}
+	fn := lsp.URIPath(locs[0].URI)
+	stln := locs[0].Range.Start.Line + 1
+	ld.SetFile(fn, stln, locs[0].Range.End.Line+1)
+	txt := textbuf.FileRegionBytes(ld.Filename, ld.StLine, ld.EdLine, true, 10) // comments, 10 lines back max
+	prmpt := fmt.Sprintf("%v [%d:%d]", ld.Filename, ld.StLine, ld.EdLine)
+	TextViewDialog(nil, txt, DlgOpts{Title: "Lookup: " + text, Prompt: prmpt, Filename: ld.Filename, LineNos: true})
+	return ld
+}
+
+/////////////////////////////////////////////////////////////////////////////
+//   TextView
+
+// LSPLocation is a location returned from the language server, for
+// selecting in a dialog
+type LSPLocation struct {
+	File string `desc:"file name"`
+	Line int    `desc:"line number, starting at 1"`
+	Text string `desc:"text of the line, for open buffers"`
+	loc  lsp.Location
+}
+
+// HasLangServer returns true if the buffer is using a language server
+func (tv *TextView) HasLangServer() bool {
+	if tv.Buf == nil {
+		return false
+	}
+	cl, _ := tv.Buf.LangServer()
+	return cl != nil
+}
+
+// langServerPos returns the language server position of the cursor
+func (tv *TextView) langServerPos() lsp.Position {
+	return tv.Buf.LSPPos(tv.CursorPos)
+}
+
+// GoToLocation moves to given location from the language server --
+// if it is in another file, and that file is open in a buffer with a
+// view, the cursor is moved there, and otherwise a file:// link to it,
+// with #L<line> suffix, is opened via OpenLink
+func (tv *TextView) GoToLocation(loc lsp.Location) {
+	tb := tv.Buf
+	if _, uri := tb.LangServer(); loc.URI != uri {
+		lspBufsMu.Lock()
+		tb = lspBufs[loc.URI]
+		lspBufsMu.Unlock()
+		if tb == nil || len(tb.Views) == 0 {
+			url := fmt.Sprintf("%s#L%d", loc.URI, loc.Range.Start.Line+1)
+			tv.OpenLink(&gi.TextLink{URL: url})
+			return
+		}
+	}
+	pos := tb.PosFromLSP(loc.Range.Start)
+	tvv := tv
+	if tb != tv.Buf {
+		tvv = tb.Views[0]
+		tvv.GrabFocus()
+	}
+	tvv.SavePosHistory(tvv.CursorPos)
+	tvv.SetCursorShow(pos)
+	tvv.SavePosHistory(pos)
+}
+
+// LSPDefinition moves to where the symbol at the cursor is defined, using
+// the language server
+func (tv *TextView) LSPDefinition() {
+	cl, uri := tv.Buf.LangServerSync()
+	if cl == nil {
+		return
+	}
+	locs, err := cl.Definition(uri, tv.langServerPos())
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	if len(locs) == 0 {
+		gi.PromptDialog(tv.Viewport, gi.DlgOpts{Title: "No Definition Found", Prompt: "The language server did not find a definition for the symbol at the cursor"}, gi.AddOk, gi.NoCancel, nil, nil)
+		return
+	}
+	tv.GoToLocation(locs[0])
+}
+
+// LSPReferences shows a dialog listing all the references to the symbol
+// at the cursor, using the language server, and moves to the selected one
+func (tv *TextView) LSPReferences() {
+	cl, uri := tv.Buf.LangServerSync()
+	if cl == nil {
+		return
+	}
+	locs, err := cl.References(uri, tv.langServerPos())
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	refs := make([]LSPLocation, len(locs))
+	for i, loc := range locs {
+		refs[i] = LSPLocation{File: DirAndFile(lsp.URIPath(loc.URI)), Line: loc.Range.Start.Line + 1, loc: loc}
+		lspBufsMu.Lock()
+		tb := lspBufs[loc.URI]
+		lspBufsMu.Unlock()
+		if tb != nil {
+			refs[i].Text = strings.TrimSpace(string(tb.Line(loc.Range.Start.Line)))
+		}
+	}
+	TableViewSelectDialog(tv.Viewport, &refs, DlgOpts{Title: "References", Prompt: fmt.Sprintf("%d references to the symbol at the cursor -- select one to go to it", len(refs))}, -1, nil,
+		tv.This(), func(recv, send ki.Ki, sig int64, data interface{}) {
+			if sig == int64(gi.DialogAccepted) {
+				ddlg := send.Embed(gi.KiT_Dialog).(*gi.Dialog)
+				si := TableViewSelectDialogValue(ddlg)
+				if si >= 0 {
+					tvv := recv.Embed(KiT_TextView).(*TextView)
+					tvv.GoToLocation(refs[si].loc)
+				}
+			}
+		})
+}
+
+// LSPRename renames the symbol at the cursor to given name, across the
+// workspace, using the language server
+func (tv *TextView) LSPRename(newName string) error {
+	cl, uri := tv.Buf.LangServerSync()
+	if cl == nil {
+		return fmt.Errorf("giv.TextView LSPRename: not using a language server")
+	}
+	we, err := cl.Rename(uri, tv.langServerPos(), newName)
+	if err != nil {
+		return err
+	}
+	_, err = ApplyWorkspaceEdit(we)
+	return err
+}
+
+// LSPRenamePrompt prompts for a new name for the symbol at the cursor,
+// and renames it using the language server
+func (tv *TextView) LSPRenamePrompt() {
+	wd := string(tv.Buf.Region(tv.WordAt().Start, tv.WordAt().End).ToBytes())
+	gi.StringPromptDialog(tv.Viewport, wd, "New name", gi.DlgOpts{Title: "Rename Symbol", Prompt: "Rename the symbol at the cursor everywhere it is used"},
+		tv.This(), func(recv, send ki.Ki, sig int64, data interface{}) {
+			if sig == int64(gi.DialogAccepted) {
+				dlg := send.(*gi.Dialog)
+				nm := gi.StringPromptDialogValue(dlg)
+				if nm == "" || nm == wd {
+					return
+				}
+				tvv := recv.Embed(KiT_TextView).(*TextView)
+				if err := tvv.LSPRename(nm); err != nil {
+					gi.PromptDialog(tvv.Viewport, gi.DlgOpts{Title: "Rename Failed", Prompt: err.Error()}, gi.AddOk, gi.NoCancel, nil, nil)
+				}
+			}
+		})
+}
+
+// LSPFormat formats the text using the language server
+func (tv *TextView) LSPFormat() {
+	if err := tv.Buf.LangServerFormat(); err != nil {
+		gi.PromptDialog(tv.Viewport, gi.DlgOpts{Title: "Format Failed", Prompt: err.Error()}, gi.AddOk, gi.NoCancel, nil, nil)
+	}
+}
+
+// LSPContextMenu adds the language server actions to given menu
+func (tv *TextView) LSPContextMenu(m *gi.Menu) {
+	m.AddSeparator("sep-lsp")
+	m.AddAction(gi.ActOpts{Label: "Go To Definition"},
+		tv.This(), func(recv, send ki.Ki, sig int64, data interface{}) {
+			txf := recv.Embed(KiT_TextView).(*TextView)
+			txf.LSPDefinition()
+		})
+	m.AddAction(gi.ActOpts{Label: "Find References..."},
+		tv.This(), func(recv, send ki.Ki, sig int64, data interface{}) {
+			txf := recv.Embed(KiT_TextView).(*TextView)
+			txf.LSPReferences()
+		})
+	m.AddAction(gi.ActOpts{Label: "Rename Symbol..."},
+		tv.This(), func(recv, send ki.Ki, sig int64, data interface{}) {
+			txf := recv.Embed(KiT_TextView).(*TextView)
+			txf.LSPRenamePrompt()
+		})
+	m.AddAction(gi.ActOpts{Label: "Format"},
+		tv.This(), func(recv, send ki.Ki, sig int64, data interface{}) {
+			txf := recv.Embed(KiT_TextView).(*TextView)
+			txf.LSPFormat()
+		})
+}
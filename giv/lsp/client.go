@@ -0,0 +1,279 @@
+// Copyright (c) 2020, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lsp
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+)
+
+// DiagnosticsFunc is called when diagnostics are published for a document,
+// in the goroutine reading from the server
+type DiagnosticsFunc func(diags []Diagnostic)
+
+// Client is a Language Server Protocol client, connected to a server via
+// a Conn.  It keeps track of the documents open on the server, and routes
+// the diagnostics published by the server to handlers for each document.
+type Client struct {
+	Conn     *Conn           `desc:"connection to the server"`
+	RootURI  string          `desc:"URI of the root directory of the workspace, set by Initialize"`
+	Caps     json.RawMessage `desc:"capabilities of the server, as returned from Initialize"`
+	mu       sync.Mutex
+	versions map[string]int
+	diags    map[string]DiagnosticsFunc
+}
+
+// NewClient returns a new client talking to a server that reads from w and
+// writes to r -- Initialize must then be called before anything else
+func NewClient(r io.Reader, w io.Writer) *Client {
+	cl := &Client{versions: make(map[string]int), diags: make(map[string]DiagnosticsFunc)}
+	cl.Conn = NewConn(r, w, cl.handle)
+	return cl
+}
+
+// handle handles requests and notifications from the server
+func (cl *Client) handle(method string, params json.RawMessage) (interface{}, error) {
+	switch method {
+	case "textDocument/publishDiagnostics":
+		var pd PublishDiagnosticsParams
+		if err := json.Unmarshal(params, &pd); err != nil {
+			return nil, err
+		}
+		cl.mu.Lock()
+		fun := cl.diags[pd.URI]
+		cl.mu.Unlock()
+		if fun != nil {
+			fun(pd.Diagnostics)
+		}
+	case "workspace/configuration":
+		var cp struct {
+			Items []json.RawMessage `json:"items"`
+		}
+		json.Unmarshal(params, &cp)
+		return make([]interface{}, len(cp.Items)), nil
+	}
+	return nil, nil
+}
+
+// Initialize initializes the server for the workspace rooted at given
+// directory, and must be called first
+func (cl *Client) Initialize(rootDir string) error {
+	cl.RootURI = FileURI(rootDir)
+	params := map[string]interface{}{
+		"processId": os.Getpid(),
+		"rootUri":   cl.RootURI,
+		"capabilities": map[string]interface{}{
+			"textDocument": map[string]interface{}{
+				"synchronization":    map[string]interface{}{"didSave": true},
+				"completion":         map[string]interface{}{"completionItem": map[string]interface{}{"snippetSupport": false}},
+				"hover":              map[string]interface{}{"contentFormat": []string{"plaintext", "markdown"}},
+				"publishDiagnostics": map[string]interface{}{},
+			},
+			"workspace": map[string]interface{}{"configuration": true, "workspaceEdit": map[string]interface{}{"documentChanges": true}},
+		},
+	}
+	var res struct {
+		Capabilities json.RawMessage `json:"capabilities"`
+	}
+	if err := cl.Conn.Call("initialize", params, &res); err != nil {
+		return err
+	}
+	cl.Caps = res.Capabilities
+	return cl.Conn.Notify("initialized", struct{}{})
+}
+
+// Shutdown asks the server to shut down and exit, and closes the connection
+func (cl *Client) Shutdown() error {
+	err := cl.Conn.Call("shutdown", nil, nil)
+	cl.Conn.Notify("exit", nil)
+	cl.Conn.Close(nil)
+	return err
+}
+
+// SetDiagnosticsHandler sets the function called with the diagnostics
+// published for given document -- nil removes it
+func (cl *Client) SetDiagnosticsHandler(uri string, fun DiagnosticsFunc) {
+	cl.mu.Lock()
+	defer cl.mu.Unlock()
+	if fun == nil {
+		delete(cl.diags, uri)
+	} else {
+		cl.diags[uri] = fun
+	}
+}
+
+/////////////////////////////////////////////////////////////////////////////
+//   Document sync
+
+// DidOpen tells the server that given document is open, with given text
+func (cl *Client) DidOpen(uri, langID, text string) error {
+	cl.mu.Lock()
+	cl.versions[uri] = 1
+	cl.mu.Unlock()
+	return cl.Conn.Notify("textDocument/didOpen", map[string]interface{}{
+		"textDocument": TextDocumentItem{URI: uri, LanguageID: langID, Version: 1, Text: text},
+	})
+}
+
+// DidChange sends the full new text of given open document to the server
+func (cl *Client) DidChange(uri, text string) error {
+	cl.mu.Lock()
+	cl.versions[uri]++
+	vers := cl.versions[uri]
+	cl.mu.Unlock()
+	return cl.Conn.Notify("textDocument/didChange", map[string]interface{}{
+		"textDocument":   VersionedTextDocumentIdentifier{URI: uri, Version: vers},
+		"contentChanges": []map[string]string{{"text": text}},
+	})
+}
+
+// DidSave tells the server that given document has been saved
+func (cl *Client) DidSave(uri string) error {
+	return cl.Conn.Notify("textDocument/didSave", map[string]interface{}{
+		"textDocument": TextDocumentIdentifier{URI: uri},
+	})
+}
+
+// DidClose tells the server that given document has been closed
+func (cl *Client) DidClose(uri string) error {
+	cl.mu.Lock()
+	delete(cl.versions, uri)
+	delete(cl.diags, uri)
+	cl.mu.Unlock()
+	return cl.Conn.Notify("textDocument/didClose", map[string]interface{}{
+		"textDocument": TextDocumentIdentifier{URI: uri},
+	})
+}
+
+// NOpen returns the number of documents open on the server
+func (cl *Client) NOpen() int {
+	cl.mu.Lock()
+	defer cl.mu.Unlock()
+	return len(cl.versions)
+}
+
+/////////////////////////////////////////////////////////////////////////////
+//   Requests
+
+// posParams returns the params for a request at given position
+func posParams(uri string, pos Position) TextDocumentPositionParams {
+	return TextDocumentPositionParams{TextDocument: TextDocumentIdentifier{URI: uri}, Position: pos}
+}
+
+// Completion returns the possible completions at given position
+func (cl *Client) Completion(uri string, pos Position) ([]CompletionItem, error) {
+	var raw json.RawMessage
+	if err := cl.Conn.Call("textDocument/completion", posParams(uri, pos), &raw); err != nil {
+		return nil, err
+	}
+	var items []CompletionItem
+	if json.Unmarshal(raw, &items) == nil {
+		return items, nil
+	}
+	var cl2 completionList
+	if err := json.Unmarshal(raw, &cl2); err != nil {
+		return nil, err
+	}
+	return cl2.Items, nil
+}
+
+// Hover returns the hover text (documentation, type info, etc) for the
+// symbol at given position, as plain text or markdown
+func (cl *Client) Hover(uri string, pos Position) (string, error) {
+	var res struct {
+		Contents json.RawMessage `json:"contents"`
+	}
+	if err := cl.Conn.Call("textDocument/hover", posParams(uri, pos), &res); err != nil {
+		return "", err
+	}
+	if len(res.Contents) == 0 {
+		return "", nil
+	}
+	return hoverText(res.Contents), nil
+}
+
+// locations converts the various forms of a location result to a list
+func locations(raw json.RawMessage) []Location {
+	var loc Location
+	if json.Unmarshal(raw, &loc) == nil && loc.URI != "" {
+		return []Location{loc}
+	}
+	var lnks []locationLink
+	if json.Unmarshal(raw, &lnks) == nil && len(lnks) > 0 && lnks[0].TargetURI != "" {
+		locs := make([]Location, len(lnks))
+		for i, lk := range lnks {
+			locs[i] = Location{URI: lk.TargetURI, Range: lk.TargetSelectionRange}
+		}
+		return locs
+	}
+	var locs []Location
+	json.Unmarshal(raw, &locs)
+	return locs
+}
+
+// Definition returns the location(s) where the symbol at given position
+// is defined
+func (cl *Client) Definition(uri string, pos Position) ([]Location, error) {
+	var raw json.RawMessage
+	if err := cl.Conn.Call("textDocument/definition", posParams(uri, pos), &raw); err != nil {
+		return nil, err
+	}
+	return locations(raw), nil
+}
+
+// References returns the locations of all references to the symbol at
+// given position, including its declaration
+func (cl *Client) References(uri string, pos Position) ([]Location, error) {
+	params := map[string]interface{}{
+		"textDocument": TextDocumentIdentifier{URI: uri},
+		"position":     pos,
+		"context":      map[string]bool{"includeDeclaration": true},
+	}
+	var raw json.RawMessage
+	if err := cl.Conn.Call("textDocument/references", params, &raw); err != nil {
+		return nil, err
+	}
+	return locations(raw), nil
+}
+
+// Rename returns the edits needed to rename the symbol at given position
+// to newName, across the workspace
+func (cl *Client) Rename(uri string, pos Position, newName string) (*WorkspaceEdit, error) {
+	params := map[string]interface{}{
+		"textDocument": TextDocumentIdentifier{URI: uri},
+		"position":     pos,
+		"newName":      newName,
+	}
+	var res struct {
+		Changes         map[string][]TextEdit `json:"changes"`
+		DocumentChanges []textDocumentEdit    `json:"documentChanges"`
+	}
+	if err := cl.Conn.Call("textDocument/rename", params, &res); err != nil {
+		return nil, err
+	}
+	we := &WorkspaceEdit{Changes: res.Changes}
+	if we.Changes == nil {
+		we.Changes = make(map[string][]TextEdit)
+	}
+	for _, dc := range res.DocumentChanges {
+		we.Changes[dc.TextDocument.URI] = append(we.Changes[dc.TextDocument.URI], dc.Edits...)
+	}
+	return we, nil
+}
+
+// Formatting returns the edits needed to format given document
+func (cl *Client) Formatting(uri string, opts FormattingOptions) ([]TextEdit, error) {
+	params := map[string]interface{}{
+		"textDocument": TextDocumentIdentifier{URI: uri},
+		"options":      opts,
+	}
+	var edits []TextEdit
+	if err := cl.Conn.Call("textDocument/formatting", params, &edits); err != nil {
+		return nil, err
+	}
+	return edits, nil
+}
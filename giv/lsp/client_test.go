@@ -0,0 +1,149 @@
+// Copyright (c) 2020, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lsp
+
+import (
+	"encoding/json"
+	"io"
+	"testing"
+	"time"
+)
+
+// fakeServer is a minimal language server talking over pipes
+type fakeServer struct {
+	conn *Conn
+	text map[string]string
+	got  chan string
+}
+
+func newFakeServer(t *testing.T) (*Client, *fakeServer) {
+	cr, sw := io.Pipe()
+	sr, cw := io.Pipe()
+	fs := &fakeServer{text: make(map[string]string), got: make(chan string, 10)}
+	fs.conn = NewConn(sr, sw, fs.handle)
+	return NewClient(cr, cw), fs
+}
+
+func (fs *fakeServer) handle(method string, params json.RawMessage) (interface{}, error) {
+	var p struct {
+		TextDocument   TextDocumentItem    `json:"textDocument"`
+		Position       Position            `json:"position"`
+		NewName        string              `json:"newName"`
+		ContentChanges []map[string]string `json:"contentChanges"`
+	}
+	json.Unmarshal(params, &p)
+	uri := p.TextDocument.URI
+	switch method {
+	case "initialize":
+		return map[string]interface{}{"capabilities": map[string]interface{}{"hoverProvider": true}}, nil
+	case "initialized", "exit":
+		fs.got <- method
+	case "shutdown":
+		return nil, nil
+	case "textDocument/didOpen":
+		fs.text[uri] = p.TextDocument.Text
+		fs.conn.Notify("textDocument/publishDiagnostics", PublishDiagnosticsParams{URI: uri, Diagnostics: []Diagnostic{
+			{Range: Range{Start: Position{1, 0}, End: Position{1, 3}}, Severity: SeverityError, Message: "bad"}}})
+	case "textDocument/didChange":
+		fs.text[uri] = p.ContentChanges[0]["text"]
+		fs.got <- fs.text[uri]
+	case "textDocument/completion":
+		return completionList{Items: []CompletionItem{{Label: "Println", Kind: CompletionFunction}, {Label: "Printf", InsertText: "Printf("}}}, nil
+	case "textDocument/hover":
+		return map[string]interface{}{"contents": markupContent{Kind: "markdown", Value: "func Println()"}}, nil
+	case "textDocument/definition":
+		return []locationLink{{TargetURI: "file:///a/b.go", TargetSelectionRange: Range{Start: Position{p.Position.Line + 10, 2}}}}, nil
+	case "textDocument/references":
+		return []Location{{URI: uri, Range: Range{Start: p.Position}}, {URI: "file:///a/c.go"}}, nil
+	case "textDocument/rename":
+		return map[string]interface{}{"documentChanges": []textDocumentEdit{{TextDocument: VersionedTextDocumentIdentifier{URI: uri}, Edits: []TextEdit{{NewText: p.NewName}}}}}, nil
+	case "textDocument/formatting":
+		return nil, nil
+	case "slow":
+		time.Sleep(time.Second)
+	}
+	return nil, nil
+}
+
+func TestClient(t *testing.T) {
+	cl, fs := newFakeServer(t)
+	if err := cl.Initialize("/a"); err != nil {
+		t.Fatal(err)
+	}
+	if m := <-fs.got; m != "initialized" {
+		t.Errorf("expected initialized, got: %s", m)
+	}
+	if string(cl.Caps) != `{"hoverProvider":true}` {
+		t.Errorf("caps wrong: %s", cl.Caps)
+	}
+	uri := "file:///a/a.go"
+	dch := make(chan []Diagnostic, 1)
+	cl.SetDiagnosticsHandler(uri, func(diags []Diagnostic) { dch <- diags })
+	cl.DidOpen(uri, "go", "package a\nfoo\n")
+	select {
+	case diags := <-dch:
+		if len(diags) != 1 || diags[0].Message != "bad" || diags[0].Range.Start.Line != 1 {
+			t.Errorf("diagnostics wrong: %v", diags)
+		}
+	case <-time.After(time.Second):
+		t.Errorf("diagnostics not published")
+	}
+	cl.DidChange(uri, "package b\n")
+	if txt := <-fs.got; txt != "package b\n" {
+		t.Errorf("change not sent: %q", txt)
+	}
+
+	items, err := cl.Completion(uri, Position{1, 2})
+	if err != nil || len(items) != 2 || items[0].Kind.IconName() != "function" || items[1].Text() != "Printf(" {
+		t.Errorf("completion wrong: %v %v", items, err)
+	}
+	hv, err := cl.Hover(uri, Position{})
+	if err != nil || hv != "func Println()" {
+		t.Errorf("hover wrong: %q %v", hv, err)
+	}
+	locs, err := cl.Definition(uri, Position{1, 0})
+	if err != nil || len(locs) != 1 || locs[0].URI != "file:///a/b.go" || locs[0].Range.Start.Line != 11 {
+		t.Errorf("definition wrong: %v %v", locs, err)
+	}
+	locs, err = cl.References(uri, Position{3, 4})
+	if err != nil || len(locs) != 2 || locs[0].Range.Start != (Position{3, 4}) {
+		t.Errorf("references wrong: %v %v", locs, err)
+	}
+	we, err := cl.Rename(uri, Position{}, "bar")
+	if err != nil || len(we.Changes[uri]) != 1 || we.Changes[uri][0].NewText != "bar" {
+		t.Errorf("rename wrong: %v %v", we, err)
+	}
+	edits, err := cl.Formatting(uri, FormattingOptions{TabSize: 4})
+	if err != nil || len(edits) != 0 {
+		t.Errorf("formatting wrong: %v %v", edits, err)
+	}
+
+	cl.Conn.Timeout = 50 * time.Millisecond
+	if err := cl.Conn.Call("slow", nil, nil); err == nil {
+		t.Errorf("slow call should time out")
+	}
+	if err := cl.Shutdown(); err != nil {
+		t.Error(err)
+	}
+	if m := <-fs.got; m != "exit" {
+		t.Errorf("expected exit, got: %s", m)
+	}
+	if err := cl.DidSave(uri); err == nil {
+		t.Errorf("notify after shutdown should fail")
+	}
+}
+
+func TestPositions(t *testing.T) {
+	ln := []rune("a😀b")
+	if c := UTF16Col(ln, 2); c != 3 {
+		t.Errorf("UTF16Col: expected 3, got %d", c)
+	}
+	if c := RuneCol(ln, 3); c != 2 {
+		t.Errorf("RuneCol: expected 2, got %d", c)
+	}
+	if p := URIPath(FileURI("/a b/c.go")); p != "/a b/c.go" {
+		t.Errorf("URI round trip wrong: %s", p)
+	}
+}
@@ -0,0 +1,285 @@
+// Copyright (c) 2020, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RequestTimeout is the default time to wait for the response to a request
+var RequestTimeout = 5 * time.Second
+
+// Trace -- set to true to print all messages sent and received
+var Trace = false
+
+// HandlerFunc handles notifications and requests received from the other
+// side of a Conn -- the result is returned for requests, and ignored for
+// notifications
+type HandlerFunc func(method string, params json.RawMessage) (interface{}, error)
+
+// RespError is a JSON-RPC error returned in a response
+type RespError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// Error satisfies the error interface
+func (re *RespError) Error() string {
+	return fmt.Sprintf("lsp: error %d: %s", re.Code, re.Message)
+}
+
+// message is any JSON-RPC message as received: a request has an ID and
+// Method, a notification only a Method, and a response only an ID
+type message struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *RespError      `json:"error,omitempty"`
+}
+
+// request is a request or notification (without ID) as sent
+type request struct {
+	JSONRPC string      `json:"jsonrpc"`
+	ID      *int64      `json:"id,omitempty"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params,omitempty"`
+}
+
+// response is a response as sent -- Result is always present, even if null
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result"`
+	Error   *RespError      `json:"error,omitempty"`
+}
+
+// Conn is a JSON-RPC 2.0 connection over a reader and writer (e.g., the
+// stdio of a server process), using the LSP base protocol framing with a
+// Content-Length header before each message.  It can be used by either
+// side of the connection.
+type Conn struct {
+	Handler HandlerFunc   `desc:"handles notifications and requests from the other side -- if nil, requests get a null result"`
+	Timeout time.Duration `desc:"time to wait for the response to a request -- RequestTimeout if 0"`
+	w       io.Writer
+	r       *bufio.Reader
+	wmu     sync.Mutex
+	mu      sync.Mutex
+	seq     int64
+	pending map[int64]chan *message
+	err     error
+	done    chan struct{}
+}
+
+// NewConn returns a new connection reading from r and writing to w, and
+// starts reading messages in a separate goroutine
+func NewConn(r io.Reader, w io.Writer, handler HandlerFunc) *Conn {
+	cn := &Conn{Handler: handler, w: w, r: bufio.NewReader(r), pending: make(map[int64]chan *message), done: make(chan struct{})}
+	go cn.readLoop()
+	return cn
+}
+
+// Done returns a channel that is closed when the connection is closed,
+// after which Err returns the reason
+func (cn *Conn) Done() <-chan struct{} {
+	return cn.done
+}
+
+// Err returns the error that closed the connection, nil if still open
+func (cn *Conn) Err() error {
+	cn.mu.Lock()
+	defer cn.mu.Unlock()
+	return cn.err
+}
+
+// Call sends a request with given method and params, and waits for the
+// response, decoding its result into result if non-nil
+func (cn *Conn) Call(method string, params, result interface{}) error {
+	cn.mu.Lock()
+	if cn.err != nil {
+		cn.mu.Unlock()
+		return cn.err
+	}
+	cn.seq++
+	id := cn.seq
+	rch := make(chan *message, 1)
+	cn.pending[id] = rch
+	cn.mu.Unlock()
+
+	err := cn.write(&request{JSONRPC: "2.0", ID: &id, Method: method, Params: params})
+	if err != nil {
+		cn.cancel(id)
+		return err
+	}
+	tout := cn.Timeout
+	if tout == 0 {
+		tout = RequestTimeout
+	}
+	tmr := time.NewTimer(tout)
+	defer tmr.Stop()
+	select {
+	case msg := <-rch:
+		if msg == nil {
+			return cn.Err()
+		}
+		if msg.Error != nil {
+			return msg.Error
+		}
+		if result != nil && len(msg.Result) > 0 {
+			return json.Unmarshal(msg.Result, result)
+		}
+		return nil
+	case <-tmr.C:
+		cn.cancel(id)
+		cn.Notify("$/cancelRequest", map[string]int64{"id": id})
+		return fmt.Errorf("lsp: request %s timed out after %v", method, tout)
+	}
+}
+
+// cancel removes the pending request with given id
+func (cn *Conn) cancel(id int64) {
+	cn.mu.Lock()
+	delete(cn.pending, id)
+	cn.mu.Unlock()
+}
+
+// Notify sends a notification with given method and params
+func (cn *Conn) Notify(method string, params interface{}) error {
+	if err := cn.Err(); err != nil {
+		return err
+	}
+	return cn.write(&request{JSONRPC: "2.0", Method: method, Params: params})
+}
+
+// Close closes the connection, with given reason returned by Err, failing
+// any pending requests, and closes the writer if it is an io.Closer
+func (cn *Conn) Close(reason error) {
+	cn.mu.Lock()
+	if cn.err != nil {
+		cn.mu.Unlock()
+		return
+	}
+	if reason == nil {
+		reason = fmt.Errorf("lsp: connection closed")
+	}
+	cn.err = reason
+	for id, rch := range cn.pending {
+		close(rch)
+		delete(cn.pending, id)
+	}
+	close(cn.done)
+	cn.mu.Unlock()
+	if wc, ok := cn.w.(io.Closer); ok {
+		wc.Close()
+	}
+}
+
+// write writes given message with its header
+func (cn *Conn) write(msg interface{}) error {
+	b, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	if Trace {
+		fmt.Printf("lsp: send: %s\n", b)
+	}
+	cn.wmu.Lock()
+	defer cn.wmu.Unlock()
+	_, err = fmt.Fprintf(cn.w, "Content-Length: %d\r\n\r\n%s", len(b), b)
+	return err
+}
+
+// read reads the next message
+func (cn *Conn) read() (*message, error) {
+	clen := -1
+	for {
+		ln, err := cn.r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		ln = strings.TrimSpace(ln)
+		if ln == "" {
+			break
+		}
+		if strings.HasPrefix(strings.ToLower(ln), "content-length:") {
+			clen, err = strconv.Atoi(strings.TrimSpace(ln[len("content-length:"):]))
+			if err != nil {
+				return nil, fmt.Errorf("lsp: bad header: %q", ln)
+			}
+		}
+	}
+	if clen < 0 {
+		return nil, fmt.Errorf("lsp: missing Content-Length header")
+	}
+	b := make([]byte, clen)
+	if _, err := io.ReadFull(cn.r, b); err != nil {
+		return nil, err
+	}
+	if Trace {
+		fmt.Printf("lsp: recv: %s\n", b)
+	}
+	msg := &message{}
+	if err := json.Unmarshal(b, msg); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+// readLoop reads and dispatches messages until the connection fails
+func (cn *Conn) readLoop() {
+	for {
+		msg, err := cn.read()
+		if err != nil {
+			cn.Close(err)
+			return
+		}
+		if msg.Method != "" {
+			cn.handle(msg)
+			continue
+		}
+		id, err := strconv.ParseInt(string(msg.ID), 10, 64)
+		if err != nil {
+			continue // not one of ours
+		}
+		cn.mu.Lock()
+		rch, has := cn.pending[id]
+		delete(cn.pending, id)
+		cn.mu.Unlock()
+		if has {
+			rch <- msg
+		}
+	}
+}
+
+// handle handles a request or notification -- requests are handled in a
+// separate goroutine, so the handler can itself make calls
+func (cn *Conn) handle(msg *message) {
+	if len(msg.ID) == 0 {
+		if cn.Handler != nil {
+			cn.Handler(msg.Method, msg.Params)
+		}
+		return
+	}
+	go func() {
+		resp := &response{JSONRPC: "2.0", ID: msg.ID}
+		if cn.Handler != nil {
+			res, err := cn.Handler(msg.Method, msg.Params)
+			if err != nil {
+				resp.Error = &RespError{Code: -32603, Message: err.Error()}
+			} else {
+				resp.Result = res
+			}
+		}
+		cn.write(resp)
+	}()
+}
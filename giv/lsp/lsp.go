@@ -0,0 +1,277 @@
+// Copyright (c) 2020, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package lsp is a client for the Language Server Protocol, which runs a
+// language server (e.g., gopls, pyright) as a separate process and talks
+// to it over stdio, to provide completion, lookup, diagnostics, etc for
+// TextBuf's.  Only the subset of the protocol needed for that is defined
+// here -- see https://microsoft.github.io/language-server-protocol/
+package lsp
+
+import (
+	"encoding/json"
+	"net/url"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"unicode/utf16"
+)
+
+// Position is a zero-based line and character offset in a document,
+// where the character offset is in UTF-16 code units, per the protocol
+type Position struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+// Range is a range in a document, with an exclusive End
+type Range struct {
+	Start Position `json:"start"`
+	End   Position `json:"end"`
+}
+
+// Location is a Range within a given document
+type Location struct {
+	URI   string `json:"uri"`
+	Range Range  `json:"range"`
+}
+
+// locationLink is an alternative form of Location returned for definitions
+type locationLink struct {
+	TargetURI            string `json:"targetUri"`
+	TargetRange          Range  `json:"targetRange"`
+	TargetSelectionRange Range  `json:"targetSelectionRange"`
+}
+
+// TextDocumentIdentifier identifies a document by its URI
+type TextDocumentIdentifier struct {
+	URI string `json:"uri"`
+}
+
+// VersionedTextDocumentIdentifier identifies a given version of a document
+type VersionedTextDocumentIdentifier struct {
+	URI     string `json:"uri"`
+	Version int    `json:"version"`
+}
+
+// TextDocumentItem is a document sent to the server when opened
+type TextDocumentItem struct {
+	URI        string `json:"uri"`
+	LanguageID string `json:"languageId"`
+	Version    int    `json:"version"`
+	Text       string `json:"text"`
+}
+
+// TextDocumentPositionParams are the params for requests at a position
+type TextDocumentPositionParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+	Position     Position               `json:"position"`
+}
+
+// TextEdit replaces the text in Range with NewText
+type TextEdit struct {
+	Range   Range  `json:"range"`
+	NewText string `json:"newText"`
+}
+
+// textDocumentEdit is the documentChanges form of edits in a WorkspaceEdit
+type textDocumentEdit struct {
+	TextDocument VersionedTextDocumentIdentifier `json:"textDocument"`
+	Edits        []TextEdit                      `json:"edits"`
+}
+
+// WorkspaceEdit is a set of edits to possibly many documents, keyed by URI
+type WorkspaceEdit struct {
+	Changes map[string][]TextEdit `json:"changes"`
+}
+
+// CompletionItemKind is the kind of a completion item
+type CompletionItemKind int
+
+// The CompletionItemKinds -- only the ones used for icons are named
+const (
+	CompletionMethod        CompletionItemKind = 2
+	CompletionFunction      CompletionItemKind = 3
+	CompletionConstructor   CompletionItemKind = 4
+	CompletionField         CompletionItemKind = 5
+	CompletionVariable      CompletionItemKind = 6
+	CompletionClass         CompletionItemKind = 7
+	CompletionInterface     CompletionItemKind = 8
+	CompletionModule        CompletionItemKind = 9
+	CompletionProperty      CompletionItemKind = 10
+	CompletionEnum          CompletionItemKind = 13
+	CompletionKeyword       CompletionItemKind = 14
+	CompletionEnumMember    CompletionItemKind = 20
+	CompletionConstant      CompletionItemKind = 21
+	CompletionStruct        CompletionItemKind = 22
+	CompletionTypeParameter CompletionItemKind = 25
+)
+
+// IconName returns the name of the icon to use for this kind of completion,
+// consistent with those used for GoPi completions
+func (ck CompletionItemKind) IconName() string {
+	switch ck {
+	case CompletionMethod:
+		return "method"
+	case CompletionFunction, CompletionConstructor:
+		return "function"
+	case CompletionField, CompletionProperty:
+		return "field"
+	case CompletionVariable:
+		return "var"
+	case CompletionClass, CompletionInterface, CompletionStruct, CompletionEnum, CompletionTypeParameter:
+		return "type"
+	case CompletionConstant, CompletionEnumMember:
+		return "const"
+	case CompletionModule:
+		return "package"
+	}
+	return ""
+}
+
+// CompletionItem is one possible completion
+type CompletionItem struct {
+	Label         string             `json:"label"`
+	Kind          CompletionItemKind `json:"kind,omitempty"`
+	Detail        string             `json:"detail,omitempty"`
+	Documentation json.RawMessage    `json:"documentation,omitempty"`
+	SortText      string             `json:"sortText,omitempty"`
+	FilterText    string             `json:"filterText,omitempty"`
+	InsertText    string             `json:"insertText,omitempty"`
+	TextEdit      *TextEdit          `json:"textEdit,omitempty"`
+}
+
+// Text returns the text to insert for this completion
+func (ci *CompletionItem) Text() string {
+	switch {
+	case ci.TextEdit != nil:
+		return ci.TextEdit.NewText
+	case ci.InsertText != "":
+		return ci.InsertText
+	}
+	return ci.Label
+}
+
+// completionList is the list form of a completion result
+type completionList struct {
+	IsIncomplete bool             `json:"isIncomplete"`
+	Items        []CompletionItem `json:"items"`
+}
+
+// DiagnosticSeverity is the severity of a Diagnostic
+type DiagnosticSeverity int
+
+// The DiagnosticSeverity levels
+const (
+	SeverityError       DiagnosticSeverity = 1
+	SeverityWarning     DiagnosticSeverity = 2
+	SeverityInformation DiagnosticSeverity = 3
+	SeverityHint        DiagnosticSeverity = 4
+)
+
+// Diagnostic is an error, warning, etc reported by the server for a document
+type Diagnostic struct {
+	Range    Range              `json:"range"`
+	Severity DiagnosticSeverity `json:"severity,omitempty"`
+	Source   string             `json:"source,omitempty"`
+	Message  string             `json:"message"`
+}
+
+// PublishDiagnosticsParams are the params of a publishDiagnostics notification
+type PublishDiagnosticsParams struct {
+	URI         string       `json:"uri"`
+	Diagnostics []Diagnostic `json:"diagnostics"`
+}
+
+// FormattingOptions are the options for formatting a document
+type FormattingOptions struct {
+	TabSize      int  `json:"tabSize"`
+	InsertSpaces bool `json:"insertSpaces"`
+}
+
+// markupContent is the structured form of hover contents
+type markupContent struct {
+	Kind     string `json:"kind"`
+	Language string `json:"language"`
+	Value    string `json:"value"`
+}
+
+// hoverText converts the various forms of hover contents to plain text
+func hoverText(raw json.RawMessage) string {
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		return s
+	}
+	var mc markupContent
+	if json.Unmarshal(raw, &mc) == nil && mc.Value != "" {
+		return mc.Value
+	}
+	var arr []json.RawMessage
+	if json.Unmarshal(raw, &arr) == nil {
+		strs := make([]string, 0, len(arr))
+		for _, a := range arr {
+			if t := hoverText(a); t != "" {
+				strs = append(strs, t)
+			}
+		}
+		return strings.Join(strs, "\n\n")
+	}
+	return ""
+}
+
+/////////////////////////////////////////////////////////////////////////////
+//   URIs and positions
+
+// FileURI returns the file URI for given file path
+func FileURI(path string) string {
+	if ap, err := filepath.Abs(path); err == nil {
+		path = ap
+	}
+	path = filepath.ToSlash(path)
+	if !strings.HasPrefix(path, "/") { // windows drive
+		path = "/" + path
+	}
+	u := url.URL{Scheme: "file", Path: path}
+	return u.String()
+}
+
+// URIPath returns the file path for given file URI -- if it is not a file
+// URI then it is returned as is
+func URIPath(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return uri
+	}
+	path := u.Path
+	if runtime.GOOS == "windows" && len(path) > 2 && path[0] == '/' && path[2] == ':' {
+		path = path[1:]
+	}
+	return filepath.FromSlash(path)
+}
+
+// UTF16Col returns the UTF-16 offset of given rune index in line, for
+// converting rune-based positions to protocol positions
+func UTF16Col(line []rune, ch int) int {
+	if ch > len(line) {
+		ch = len(line)
+	}
+	col := 0
+	for _, r := range line[:ch] {
+		col += len(utf16.Encode([]rune{r}))
+	}
+	return col
+}
+
+// RuneCol returns the rune index of given UTF-16 offset in line, for
+// converting protocol positions to rune-based positions
+func RuneCol(line []rune, col int) int {
+	u := 0
+	for i, r := range line {
+		if u >= col {
+			return i
+		}
+		u += len(utf16.Encode([]rune{r}))
+	}
+	return len(line)
+}
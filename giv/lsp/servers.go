@@ -0,0 +1,238 @@
+// Copyright (c) 2020, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lsp
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+
+	"github.com/goki/pi/filecat"
+)
+
+// ServerConfig configures the language server to run for a language
+type ServerConfig struct {
+	Cmd         string   `desc:"command to run the server -- must be on the PATH or an absolute path"`
+	Args        []string `desc:"args to pass to the command, e.g., to select the stdio transport"`
+	LangID      string   `desc:"language identifier sent to the server for documents"`
+	RootMarkers []string `desc:"names of files or directories that mark the root of a workspace, e.g., go.mod -- the directory of the file is used if none are found"`
+}
+
+// Servers is the configuration of the language servers to use for each
+// supported language -- languages not listed here do not use a server.
+// Entries can be modified or added by apps, prior to opening files.
+var Servers = map[filecat.Supported]*ServerConfig{
+	filecat.Go:         {Cmd: "gopls", LangID: "go", RootMarkers: []string{"go.mod", ".git"}},
+	filecat.Python:     {Cmd: "pyright-langserver", Args: []string{"--stdio"}, LangID: "python", RootMarkers: []string{"pyproject.toml", "setup.py", ".git"}},
+	filecat.C:          {Cmd: "clangd", LangID: "cpp", RootMarkers: []string{"compile_commands.json", ".git"}},
+	filecat.Rust:       {Cmd: "rust-analyzer", LangID: "rust", RootMarkers: []string{"Cargo.toml", ".git"}},
+	filecat.JavaScript: {Cmd: "typescript-language-server", Args: []string{"--stdio"}, LangID: "javascript", RootMarkers: []string{"package.json", ".git"}},
+}
+
+// FindRoot returns the root directory of the workspace containing given
+// file, as the nearest enclosing directory containing one of the markers,
+// or the directory of the file if none are found
+func FindRoot(filename string, markers []string) string {
+	fdir := filepath.Dir(filename)
+	if ad, err := filepath.Abs(fdir); err == nil {
+		fdir = ad
+	}
+	dir := fdir
+	for {
+		for _, mk := range markers {
+			if _, err := os.Stat(filepath.Join(dir, mk)); err == nil {
+				return dir
+			}
+		}
+		pdir := filepath.Dir(dir)
+		if pdir == dir {
+			return fdir
+		}
+		dir = pdir
+	}
+}
+
+// Process is a language server process with its client -- see ClientFor
+type Process struct {
+	Client *Client       `desc:"client for the server, set when it is Ready"`
+	Config *ServerConfig `desc:"config of the server"`
+	Root   string        `desc:"root directory of the workspace of the server"`
+	Cmd    *exec.Cmd     `desc:"the server command, set when it is Ready"`
+	Err    error         `desc:"error starting the server, set when it is Ready"`
+	Ready  chan struct{} `desc:"closed when the server has been started and initialized, or failed to be, as given by Err"`
+}
+
+var (
+	procsMu sync.Mutex
+	procs   = make(map[string]*Process)
+	failed  = make(map[string]error)
+)
+
+// Start starts a language server with given config, for the workspace
+// rooted at given directory, and initializes it, waiting for it to be
+// ready -- see ClientFor for starting it in the background
+func Start(cfg *ServerConfig, root string) (*Process, error) {
+	cmd := exec.Command(cfg.Cmd, cfg.Args...)
+	cmd.Dir = root
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	pr := &Process{Client: NewClient(stdout, stdin), Config: cfg, Root: root, Cmd: cmd, Ready: make(chan struct{})}
+	if err := pr.Client.Initialize(root); err != nil {
+		pr.Kill()
+		return nil, fmt.Errorf("lsp.Start: initializing %s: %v", cfg.Cmd, err)
+	}
+	go func() {
+		<-pr.Client.Conn.Done()
+		cmd.Wait()
+	}()
+	close(pr.Ready)
+	return pr, nil
+}
+
+// Kill kills the server process without shutting it down
+func (pr *Process) Kill() {
+	pr.Client.Conn.Close(nil)
+	if pr.Cmd.Process != nil {
+		pr.Cmd.Process.Kill()
+	}
+}
+
+// start starts the server of this process, which is stored under given
+// key, and closes Ready when done -- a server that fails to start is
+// recorded as failed, and logged
+func (pr *Process) start(key string) {
+	spr, err := Start(pr.Config, pr.Root)
+	procsMu.Lock()
+	if err != nil {
+		log.Printf("lsp.ClientFor: could not start language server %s: %v\n", pr.Config.Cmd, err)
+		pr.Err = err
+		failed[key] = err
+		if procs[key] == pr {
+			delete(procs, key)
+		}
+	} else {
+		pr.Client = spr.Client
+		pr.Cmd = spr.Cmd
+	}
+	procsMu.Unlock()
+	close(pr.Ready)
+}
+
+// ProcessFor returns the process of the language server configured for
+// given language, for the workspace containing given file, starting the
+// server in the background if not already running -- wait for its Ready
+// channel before using it.  Returns nil if there is no server configured for
+// the language, or if it failed to start before.
+func ProcessFor(sup filecat.Supported, filename string) *Process {
+	cfg, has := Servers[sup]
+	if !has || cfg == nil || cfg.Cmd == "" {
+		return nil
+	}
+	root := FindRoot(filename, cfg.RootMarkers)
+	key := cfg.Cmd + "\x00" + root
+	procsMu.Lock()
+	defer procsMu.Unlock()
+	if pr, has := procs[key]; has {
+		select {
+		case <-pr.Ready:
+			if pr.Err == nil && pr.Client.Conn.Err() == nil {
+				return pr
+			}
+			delete(procs, key)
+		default:
+			return pr // still starting
+		}
+	}
+	if _, has := failed[key]; has {
+		return nil
+	}
+	pr := &Process{Config: cfg, Root: root, Ready: make(chan struct{})}
+	procs[key] = pr
+	go pr.start(key)
+	return pr
+}
+
+// ClientFor returns the client for the language server configured for
+// given language, for the workspace containing given file, starting the
+// server if not already running, and waiting for it to be ready.  Returns
+// nil if there is no server configured for the language, or if it failed
+// to start, which is logged only the first time.  See GoClientFor to not
+// wait for the server.
+func ClientFor(sup filecat.Supported, filename string) *Client {
+	pr := ProcessFor(sup, filename)
+	if pr == nil {
+		return nil
+	}
+	<-pr.Ready
+	if pr.Err != nil {
+		return nil
+	}
+	return pr.Client
+}
+
+// GoClientFor gets the client for the language server for given file as
+// in ClientFor, but returns immediately, calling given function with the
+// client on another goroutine when the server is ready -- the function is
+// not called if there is no server, or it fails to start.
+func GoClientFor(sup filecat.Supported, filename string, fun func(cl *Client)) {
+	pr := ProcessFor(sup, filename)
+	if pr == nil {
+		return
+	}
+	go func() {
+		<-pr.Ready
+		if pr.Err == nil {
+			fun(pr.Client)
+		}
+	}()
+}
+
+// Release is called when a document using given client is closed -- the
+// server is shut down when it has no more open documents
+func Release(cl *Client) {
+	if cl.NOpen() > 0 {
+		return
+	}
+	procsMu.Lock()
+	for key, pr := range procs {
+		if pr.Client == cl {
+			delete(procs, key)
+			go cl.Shutdown()
+			break
+		}
+	}
+	procsMu.Unlock()
+}
+
+// ShutdownAll shuts down all running language servers, including those
+// still starting, when they are ready -- it is called when the app quits
+// (see gi.AddQuitCleanFunc in giv).
+func ShutdownAll() {
+	procsMu.Lock()
+	prs := make([]*Process, 0, len(procs))
+	for key, pr := range procs {
+		prs = append(prs, pr)
+		delete(procs, key)
+	}
+	procsMu.Unlock()
+	for _, pr := range prs {
+		<-pr.Ready
+		if pr.Err == nil {
+			pr.Client.Shutdown()
+		}
+	}
+}
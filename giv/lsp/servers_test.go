@@ -0,0 +1,92 @@
+// Copyright (c) 2020, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lsp
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/goki/pi/filecat"
+)
+
+// TestMain runs the test binary as the fakeServer over stdin and stdout,
+// taking a while to start, if LSP_TEST_SERVER is set
+func TestMain(m *testing.M) {
+	if os.Getenv("LSP_TEST_SERVER") != "" {
+		time.Sleep(200 * time.Millisecond)
+		fs := &fakeServer{text: make(map[string]string), got: make(chan string, 100)}
+		fs.conn = NewConn(os.Stdin, os.Stdout, fs.handle)
+		<-fs.conn.Done()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+func TestClientFor(t *testing.T) {
+	os.Setenv("LSP_TEST_SERVER", "1")
+	defer os.Unsetenv("LSP_TEST_SERVER")
+	ogo, opy := Servers[filecat.Go], Servers[filecat.Python]
+	defer func() {
+		Servers[filecat.Go], Servers[filecat.Python] = ogo, opy
+	}()
+	Servers[filecat.Go] = &ServerConfig{Cmd: os.Args[0], LangID: "go"}
+	Servers[filecat.Python] = &ServerConfig{Cmd: "no-such-lsp-server", LangID: "python"}
+	dir, err := ioutil.TempDir("", "lsp-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	fnm := filepath.Join(dir, "a.go")
+	pfnm := filepath.Join(dir, "a.py")
+
+	// GoClientFor does not wait for the server to start
+	st := time.Now()
+	gotc := make(chan *Client, 1)
+	GoClientFor(filecat.Go, fnm, func(cl *Client) { gotc <- cl })
+	if d := time.Since(st); d > 100*time.Millisecond {
+		t.Errorf("GoClientFor waited %v for the server", d)
+	}
+	cl := ClientFor(filecat.Go, fnm) // waits for the same server
+	if cl == nil {
+		t.Fatalf("ClientFor: no client")
+	}
+	select {
+	case gcl := <-gotc:
+		if gcl != cl {
+			t.Errorf("GoClientFor and ClientFor got different clients")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("GoClientFor: function not called")
+	}
+	if string(cl.Caps) != `{"hoverProvider":true}` {
+		t.Errorf("server not initialized, caps: %s", cl.Caps)
+	}
+
+	// a server that fails to start is not started again
+	called := make(chan bool, 1)
+	GoClientFor(filecat.Python, pfnm, func(cl *Client) { called <- true })
+	if cl := ClientFor(filecat.Python, pfnm); cl != nil {
+		t.Errorf("ClientFor: client for a missing server")
+	}
+	if pr := ProcessFor(filecat.Python, pfnm); pr != nil {
+		t.Errorf("ProcessFor: process for a server that failed to start")
+	}
+	select {
+	case <-called:
+		t.Errorf("GoClientFor: function called for a missing server")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	ShutdownAll()
+	if err := cl.DidSave("file:///a.go"); err == nil {
+		t.Errorf("notify after ShutdownAll should fail")
+	}
+	procsMu.Lock()
+	delete(failed, "no-such-lsp-server\x00"+dir)
+	procsMu.Unlock()
+}
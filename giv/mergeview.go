@@ -0,0 +1,627 @@
+// Copyright (c) 2020, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package giv
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/goki/gi/gi"
+	"github.com/goki/gi/giv/textbuf"
+	"github.com/goki/gi/units"
+	"github.com/goki/ki/ki"
+	"github.com/goki/ki/kit"
+	"github.com/goki/pi/vci"
+)
+
+// MergeViewDialogFromFile opens a dialog for resolving the merge conflicts
+// in given file, which is open in fbuf if non-nil.  If repo is a git repo,
+// the base, ours and theirs versions are the stages of the file in the
+// git index, and otherwise they are reconstructed from the conflict markers
+// in the file (with an empty base for each conflict unless the markers
+// are in diff3 style).  Saving the result with the repo non-nil marks the
+// file as resolved if there are no remaining conflicts.
+func MergeViewDialogFromFile(avp *gi.Viewport2D, repo vci.Repo, file string, fbuf *TextBuf) (*MergeView, error) {
+	var base, ours, theirs []string
+	if repo != nil && repo.Vcs() == "git" {
+		bb, berr := repo.FileContents(file, ":1")
+		ob, oerr := repo.FileContents(file, ":2")
+		tb, terr := repo.FileContents(file, ":3")
+		if oerr == nil && terr == nil {
+			if berr != nil { // no common ancestor: both added
+				bb = nil
+			}
+			base = textbuf.BytesToLineStrings(bb, false)
+			ours = textbuf.BytesToLineStrings(ob, false)
+			theirs = textbuf.BytesToLineStrings(tb, false)
+		}
+	}
+	if ours == nil {
+		var lns []string
+		if fbuf != nil {
+			lns = fbuf.Strings(false)
+		} else {
+			fb, err := textbuf.FileBytes(file)
+			if err != nil {
+				return nil, err
+			}
+			lns = textbuf.BytesToLineStrings(fb, false)
+		}
+		if !textbuf.HasConflicts(lns) {
+			return nil, errors.New("no merge conflict markers found in file: " + file)
+		}
+		base, ours, theirs = textbuf.ConflictVersions(lns)
+	}
+	mv := MergeViewDialog(avp, base, ours, theirs, file, DlgOpts{Title: "Merge: " + DirAndFile(file)})
+	mv.Repo = repo
+	mv.FileBuf = fbuf
+	return mv, nil
+}
+
+// MergeViewDialog opens a dialog for merging the ours and theirs versions
+// of given file, relative to their common base, as line-strings
+func MergeViewDialog(avp *gi.Viewport2D, base, ours, theirs []string, file string, opts DlgOpts) *MergeView {
+	dlg := gi.NewStdDialog(opts.ToGiOpts(), opts.Ok, opts.Cancel)
+
+	frame := dlg.Frame()
+	_, prIdx := dlg.PromptWidget(frame)
+
+	mv := frame.InsertNewChild(KiT_MergeView, prIdx+1, "merge-view").(*MergeView)
+	mv.SetStretchMax()
+	mv.File = file
+	mv.MergeStrings(base, ours, theirs)
+
+	dlg.UpdateEndNoSig(true) // going to be shown
+	dlg.Open(0, 0, avp, nil)
+	return mv
+}
+
+///////////////////////////////////////////////////////////////////
+// MergeView
+
+// MergeHunk is a chunk of a three-way merge, and its location and state
+// in the result
+type MergeHunk struct {
+	textbuf.MergeChunk
+	St       int  `desc:"starting line of the hunk in the result"`
+	Ed       int  `desc:"ending line (exclusive) of the hunk in the result"`
+	Resolved bool `desc:"whether the hunk has been resolved -- conflicts are unresolved until accepted or edited"`
+}
+
+// MergeView presents the base, ours and theirs versions of a file being
+// merged side-by-side, above an editable result pane, for resolving
+// merge conflicts.  Chunks changed on only one side are merged
+// automatically, and conflicts are shown with conflict markers in the
+// result, where they can be resolved by accepting ours, theirs or both,
+// or by editing.
+type MergeView struct {
+	gi.Frame
+	File        string      `desc:"file being merged"`
+	OursLabel   string      `desc:"label for ours, e.g., HEAD"`
+	TheirsLabel string      `desc:"label for theirs, e.g., the branch being merged"`
+	Base        []string    `json:"-" xml:"-" desc:"lines of the base version"`
+	Ours        []string    `json:"-" xml:"-" desc:"lines of our version"`
+	Theirs      []string    `json:"-" xml:"-" desc:"lines of their version"`
+	Hunks       []MergeHunk `json:"-" xml:"-" desc:"the merge hunks, in order"`
+	Repo        vci.Repo    `json:"-" xml:"-" desc:"repository, if any, used to mark the file as resolved when saved"`
+	FileBuf     *TextBuf    `json:"-" xml:"-" desc:"buffer with the file open for editing, if any, which is reverted after saving"`
+	BufBase     *TextBuf    `json:"-" xml:"-" desc:"textbuf for base"`
+	BufOurs     *TextBuf    `json:"-" xml:"-" desc:"textbuf for ours"`
+	BufTheirs   *TextBuf    `json:"-" xml:"-" desc:"textbuf for theirs"`
+	BufResult   *TextBuf    `json:"-" xml:"-" desc:"textbuf for the merged result"`
+	ResolvedFun func()      `json:"-" xml:"-" view:"-" desc:"if set, called after the file has been saved and marked as resolved in the Repo"`
+	updating    bool
+}
+
+var KiT_MergeView = kit.Types.AddType(&MergeView{}, MergeViewProps)
+
+// AddNewMergeView adds a new mergeview to given parent node, with given name.
+func AddNewMergeView(parent ki.Ki, name string) *MergeView {
+	return parent.AddNewChild(KiT_MergeView, name).(*MergeView)
+}
+
+// MergeViewColors are the line colors used for each kind of merge chunk
+var MergeViewColors = map[textbuf.MergeKinds]string{
+	textbuf.MergeOurs:     "blue",
+	textbuf.MergeTheirs:   "green",
+	textbuf.MergeSame:     "blue",
+	textbuf.MergeConflict: "red",
+}
+
+// MergeStrings merges the ours and theirs versions relative to the base,
+// as lines of strings, and displays the result
+func (mv *MergeView) MergeStrings(base, ours, theirs []string) {
+	if !mv.IsConfiged() {
+		mv.Config()
+	}
+	if mv.OursLabel == "" {
+		mv.OursLabel = "ours"
+	}
+	if mv.TheirsLabel == "" {
+		mv.TheirsLabel = "theirs"
+	}
+	mv.Base = base
+	mv.Ours = ours
+	mv.Theirs = theirs
+	mc := textbuf.MergeLines(base, ours, theirs)
+	mv.Hunks = make([]MergeHunk, len(mc))
+	var res []string
+	for i := range mc {
+		h := &mv.Hunks[i]
+		h.MergeChunk = mc[i]
+		h.Resolved = h.Kind != textbuf.MergeConflict
+		h.St = len(res)
+		res = append(res, mv.hunkLines(i, h.Kind)...)
+		h.Ed = len(res)
+	}
+	bufs := []*TextBuf{mv.BufBase, mv.BufOurs, mv.BufTheirs, mv.BufResult}
+	for bi, lns := range [][]string{base, ours, theirs, res} {
+		tb := bufs[bi]
+		tb.LineColors = nil
+		bl := make([][]byte, len(lns))
+		for i, ln := range lns {
+			bl[i] = []byte(ln)
+		}
+		tb.SetTextLines(bl, false)
+	}
+	mv.BufResult.Undos.Reset()
+	mv.SetColors()
+	for _, tb := range bufs {
+		tb.ReMarkup(false)
+	}
+	mv.UpdateToolBar()
+}
+
+// hunkLines returns the lines for given hunk resolved as given kind
+// (MergeConflict = with conflict markers)
+func (mv *MergeView) hunkLines(hi int, kind textbuf.MergeKinds) []string {
+	ch := mv.Hunks[hi].MergeChunk
+	ch.Kind = kind
+	return ch.Merged(mv.Base, mv.Ours, mv.Theirs, false, mv.OursLabel, mv.TheirsLabel)
+}
+
+// SetColors sets the line colors of the buffers according to the hunks
+func (mv *MergeView) SetColors() {
+	mv.BufBase.LineColors = nil
+	mv.BufOurs.LineColors = nil
+	mv.BufTheirs.LineColors = nil
+	mv.BufResult.LineColors = nil
+	for _, h := range mv.Hunks {
+		clr, has := MergeViewColors[h.Kind]
+		if !has {
+			continue
+		}
+		for ln := h.BaseSt; ln < h.BaseEd; ln++ {
+			mv.BufBase.SetLineColor(ln, clr)
+		}
+		if h.Kind != textbuf.MergeTheirs {
+			for ln := h.OursSt; ln < h.OursEd; ln++ {
+				mv.BufOurs.SetLineColor(ln, clr)
+			}
+		}
+		if h.Kind != textbuf.MergeOurs {
+			for ln := h.TheirsSt; ln < h.TheirsEd; ln++ {
+				mv.BufTheirs.SetLineColor(ln, clr)
+			}
+		}
+		if h.Resolved && h.Kind == textbuf.MergeConflict {
+			clr = "purple"
+		}
+		for ln := h.St; ln < h.Ed; ln++ {
+			mv.BufResult.SetLineColor(ln, clr)
+		}
+	}
+}
+
+// NConflicts returns the number of unresolved conflicts
+func (mv *MergeView) NConflicts() int {
+	n := 0
+	for _, h := range mv.Hunks {
+		if !h.Resolved {
+			n++
+		}
+	}
+	return n
+}
+
+// HunkAt returns the index of the hunk at given line in the result,
+// or -1 if none
+func (mv *MergeView) HunkAt(ln int) int {
+	for i, h := range mv.Hunks {
+		if ln >= h.St && (ln < h.Ed || ln == h.St) {
+			return i
+		}
+	}
+	return -1
+}
+
+// curConflict returns the index of the conflict hunk at the cursor in the
+// result, or the first unresolved conflict after it, or -1 if none
+func (mv *MergeView) curConflict() int {
+	_, _, _, rv := mv.TextViews()
+	hi := mv.HunkAt(rv.CursorPos.Ln)
+	if hi >= 0 && mv.Hunks[hi].Kind == textbuf.MergeConflict {
+		return hi
+	}
+	return mv.nextConflict(rv.CursorPos.Ln, false)
+}
+
+// nextConflict returns the index of the next unresolved conflict hunk
+// after (or before if prev) given result line, or -1 if none
+func (mv *MergeView) nextConflict(ln int, prev bool) int {
+	if prev {
+		for i := len(mv.Hunks) - 1; i >= 0; i-- {
+			h := mv.Hunks[i]
+			if !h.Resolved && h.Ed <= ln {
+				return i
+			}
+		}
+		return -1
+	}
+	for i, h := range mv.Hunks {
+		if !h.Resolved && h.St > ln {
+			return i
+		}
+	}
+	return -1
+}
+
+// ShowHunk moves the cursor in all the views to given hunk
+func (mv *MergeView) ShowHunk(hi int) {
+	if hi < 0 || hi >= len(mv.Hunks) {
+		return
+	}
+	h := mv.Hunks[hi]
+	bv, ov, tv, rv := mv.TextViews()
+	for i, v := range []*TextView{bv, ov, tv, rv} {
+		ln := []int{h.BaseSt, h.OursSt, h.TheirsSt, h.St}[i]
+		v.SetCursorShow(textbuf.Pos{Ln: ln})
+		v.ScrollCursorToVertCenter()
+	}
+}
+
+// NextConflict moves to the next unresolved conflict -- returns false if none
+func (mv *MergeView) NextConflict() bool {
+	_, _, _, rv := mv.TextViews()
+	hi := mv.nextConflict(rv.CursorPos.Ln, false)
+	if hi < 0 {
+		return false
+	}
+	mv.ShowHunk(hi)
+	return true
+}
+
+// PrevConflict moves to the previous unresolved conflict -- returns false
+// if none
+func (mv *MergeView) PrevConflict() bool {
+	_, _, _, rv := mv.TextViews()
+	hi := mv.nextConflict(rv.CursorPos.Ln, true)
+	if hi < 0 {
+		return false
+	}
+	mv.ShowHunk(hi)
+	return true
+}
+
+// ResolveHunk replaces the lines of given hunk in the result with given
+// lines, marking it as resolved
+func (mv *MergeView) ResolveHunk(hi int, lns []string) {
+	if hi < 0 || hi >= len(mv.Hunks) {
+		return
+	}
+	h := &mv.Hunks[hi]
+	tb := mv.BufResult
+	mv.updating = true
+	bufUpdt, winUpdt, autoSave := tb.BatchUpdateStart()
+	tb.Undos.BeginGroup()
+	if h.Ed > h.St {
+		tb.DeleteText(textbuf.Pos{Ln: h.St}, textbuf.Pos{Ln: h.Ed}, EditSignal)
+	}
+	if len(lns) > 0 {
+		tb.InsertText(textbuf.Pos{Ln: h.St}, []byte(strings.Join(lns, "\n")+"\n"), EditSignal)
+	}
+	tb.Undos.EndGroup()
+	tb.BatchUpdateEnd(bufUpdt, winUpdt, autoSave)
+	mv.updating = false
+	dl := len(lns) - (h.Ed - h.St)
+	h.Ed = h.St + len(lns)
+	h.Resolved = true
+	for i := hi + 1; i < len(mv.Hunks); i++ {
+		mv.Hunks[i].St += dl
+		mv.Hunks[i].Ed += dl
+	}
+	mv.SetColors()
+	mv.UpdateToolBar()
+}
+
+// AcceptOurs resolves given conflict hunk (-1 = at or after the cursor in
+// the result) by taking our version, and moves to the next conflict
+func (mv *MergeView) AcceptOurs(hi int) {
+	mv.accept(hi, textbuf.MergeOurs)
+}
+
+// AcceptTheirs resolves given conflict hunk (-1 = at or after the cursor in
+// the result) by taking their version, and moves to the next conflict
+func (mv *MergeView) AcceptTheirs(hi int) {
+	mv.accept(hi, textbuf.MergeTheirs)
+}
+
+// AcceptBoth resolves given conflict hunk (-1 = at or after the cursor in
+// the result) by taking our version followed by theirs, and moves to the
+// next conflict
+func (mv *MergeView) AcceptBoth(hi int) {
+	mv.accept(hi, textbuf.MergeSame)
+}
+
+func (mv *MergeView) accept(hi int, kind textbuf.MergeKinds) {
+	if hi < 0 {
+		hi = mv.curConflict()
+	}
+	if hi < 0 {
+		return
+	}
+	var lns []string
+	switch kind {
+	case textbuf.MergeSame:
+		lns = append(mv.hunkLines(hi, textbuf.MergeOurs), mv.hunkLines(hi, textbuf.MergeTheirs)...)
+	default:
+		lns = mv.hunkLines(hi, kind)
+	}
+	mv.ResolveHunk(hi, lns)
+	if nhi := mv.nextConflict(mv.Hunks[hi].St, false); nhi >= 0 {
+		mv.ShowHunk(nhi)
+	} else {
+		mv.ShowHunk(hi)
+	}
+}
+
+// AutoMerge resolves all the hunks that were changed on only one side,
+// or the same on both sides, by taking the change, and shows all the
+// conflicts with conflict markers -- this discards any edits of the result.
+func (mv *MergeView) AutoMerge() {
+	mv.MergeStrings(mv.Base, mv.Ours, mv.Theirs)
+}
+
+// resultEdited updates the hunks for an edit of the result made by the
+// user -- hunks after the edit are moved, and a conflict hunk being
+// edited is considered resolved when its conflict markers are gone
+func (mv *MergeView) resultEdited(tbe *textbuf.Edit) {
+	if mv.updating || tbe == nil {
+		return
+	}
+	stln := tbe.Reg.Start.Ln
+	dl := tbe.Reg.End.Ln - stln
+	if tbe.Delete {
+		dl = -dl
+	}
+	for i := range mv.Hunks {
+		h := &mv.Hunks[i]
+		switch {
+		case h.St > stln:
+			h.St += dl
+			h.Ed += dl
+			if h.St < stln { // deleted start
+				h.St = stln
+			}
+		case h.Ed > stln || h.St == stln:
+			h.Ed += dl
+		}
+		if h.Ed < h.St {
+			h.Ed = h.St
+		}
+		if h.Kind == textbuf.MergeConflict && h.St <= stln && stln <= h.Ed {
+			h.Resolved = !textbuf.HasConflictMarkers(mv.BufResult.Strings(false)[h.St:h.Ed])
+		}
+	}
+	mv.SetColors()
+	mv.UpdateToolBar()
+}
+
+// SaveResult saves the merged result to given file, and if there are no
+// remaining conflicts, marks it as resolved in the Repo, if set -- any
+// FileBuf with the file open is reverted to the saved result
+func (mv *MergeView) SaveResult(fname gi.FileName) error {
+	mv.BufResult.EditDone()
+	if err := mv.BufResult.SaveFile(fname); err != nil {
+		return err
+	}
+	mv.BufResult.ClearChanged()
+	if mv.FileBuf != nil && mv.FileBuf.Filename == fname {
+		mv.FileBuf.Revert()
+	}
+	if mv.NConflicts() == 0 && mv.Repo != nil && string(fname) == mv.File {
+		if err := mv.Repo.Add(mv.File); err != nil {
+			gi.PromptDialog(mv.Viewport, gi.DlgOpts{Title: "Could not Mark as Resolved", Prompt: err.Error()}, gi.AddOk, gi.NoCancel, nil, nil)
+			return err
+		}
+		if mv.ResolvedFun != nil {
+			mv.ResolvedFun()
+		}
+	}
+	mv.UpdateToolBar()
+	return nil
+}
+
+// SaveResultPrompt saves the merged result to the File, prompting first
+// if there are unresolved conflicts
+func (mv *MergeView) SaveResultPrompt() {
+	nc := mv.NConflicts()
+	if nc == 0 {
+		mv.SaveResult(gi.FileName(mv.File))
+		return
+	}
+	gi.ChoiceDialog(mv.Viewport, gi.DlgOpts{Title: "Unresolved Conflicts",
+		Prompt: fmt.Sprintf("There are %d unresolved conflicts -- save anyway, with conflict markers, without marking the file as resolved?", nc)},
+		[]string{"Save", "Cancel"},
+		mv.This(), func(recv, send ki.Ki, sig int64, data interface{}) {
+			if sig == 0 {
+				mvv := recv.Embed(KiT_MergeView).(*MergeView)
+				mvv.SaveResult(gi.FileName(mvv.File))
+			}
+		})
+}
+
+func (mv *MergeView) Config() {
+	mv.Lay = gi.LayoutVert
+	config := kit.TypeAndNameList{}
+	config.Add(gi.KiT_ToolBar, "toolbar")
+	config.Add(gi.KiT_Layout, "merge-lay")
+	config.Add(gi.KiT_Layout, "result-lay")
+	mods, updt := mv.ConfigChildren(config, ki.UniqueNames)
+	if !mods {
+		updt = mv.UpdateStart()
+	} else {
+		mv.ConfigToolBar()
+		mv.ConfigTexts()
+	}
+	mv.SetFullReRender()
+	mv.UpdateEnd(updt)
+}
+
+func (mv *MergeView) HasConflictsUpdate(act *gi.Action) {
+	act.SetActiveStateUpdt(mv.NConflicts() > 0)
+}
+
+func (mv *MergeView) ConfigToolBar() {
+	tb := mv.ToolBar()
+	tb.SetStretchMaxWidth()
+	gi.AddNewLabel(tb, "label", "Merge: "+DirAndFile(mv.File))
+	tb.AddAction(gi.ActOpts{Label: "Next", Icon: "wedge-down", Tooltip: "move down to next unresolved conflict", UpdateFunc: mv.HasConflictsUpdate},
+		mv.This(), func(recv, send ki.Ki, sig int64, data interface{}) {
+			mvv := recv.Embed(KiT_MergeView).(*MergeView)
+			mvv.NextConflict()
+		})
+	tb.AddAction(gi.ActOpts{Label: "Prev", Icon: "wedge-up", Tooltip: "move up to previous unresolved conflict", UpdateFunc: mv.HasConflictsUpdate},
+		mv.This(), func(recv, send ki.Ki, sig int64, data interface{}) {
+			mvv := recv.Embed(KiT_MergeView).(*MergeView)
+			mvv.PrevConflict()
+		})
+	tb.AddAction(gi.ActOpts{Label: "Accept Ours", Icon: "wedge-left", Tooltip: "resolve the conflict at the cursor in the result by taking our version, and move to the next conflict", UpdateFunc: mv.HasConflictsUpdate},
+		mv.This(), func(recv, send ki.Ki, sig int64, data interface{}) {
+			mvv := recv.Embed(KiT_MergeView).(*MergeView)
+			mvv.AcceptOurs(-1)
+		})
+	tb.AddAction(gi.ActOpts{Label: "Accept Theirs", Icon: "wedge-right", Tooltip: "resolve the conflict at the cursor in the result by taking their version, and move to the next conflict", UpdateFunc: mv.HasConflictsUpdate},
+		mv.This(), func(recv, send ki.Ki, sig int64, data interface{}) {
+			mvv := recv.Embed(KiT_MergeView).(*MergeView)
+			mvv.AcceptTheirs(-1)
+		})
+	tb.AddAction(gi.ActOpts{Label: "Accept Both", Icon: "copy", Tooltip: "resolve the conflict at the cursor in the result by taking our version followed by theirs, and move to the next conflict", UpdateFunc: mv.HasConflictsUpdate},
+		mv.This(), func(recv, send ki.Ki, sig int64, data interface{}) {
+			mvv := recv.Embed(KiT_MergeView).(*MergeView)
+			mvv.AcceptBoth(-1)
+		})
+	tb.AddAction(gi.ActOpts{Label: "Auto Merge", Icon: "update", Tooltip: "start over, automatically merging all changes that do not conflict -- discards all edits of the result"},
+		mv.This(), func(recv, send ki.Ki, sig int64, data interface{}) {
+			mvv := recv.Embed(KiT_MergeView).(*MergeView)
+			mvv.AutoMerge()
+		})
+	gi.AddNewStretch(tb, "str")
+	tb.AddAction(gi.ActOpts{Label: "Save", Icon: "file-save", Tooltip: "save the merged result to the file, and mark it as resolved in version control if there are no remaining conflicts"},
+		mv.This(), func(recv, send ki.Ki, sig int64, data interface{}) {
+			mvv := recv.Embed(KiT_MergeView).(*MergeView)
+			mvv.SaveResultPrompt()
+		})
+}
+
+func (mv *MergeView) UpdateToolBar() {
+	tb := mv.ToolBar()
+	tb.UpdateActions()
+}
+
+func (mv *MergeView) ToolBar() *gi.ToolBar {
+	return mv.ChildByName("toolbar", 0).(*gi.ToolBar)
+}
+
+func (mv *MergeView) MergeLay() *gi.Layout {
+	return mv.ChildByName("merge-lay", 1).(*gi.Layout)
+}
+
+func (mv *MergeView) ResultLay() *gi.Layout {
+	return mv.ChildByName("result-lay", 2).(*gi.Layout)
+}
+
+// TextViews returns the base, ours, theirs and result views
+func (mv *MergeView) TextViews() (bv, ov, tv, rv *TextView) {
+	lay := mv.MergeLay()
+	bv = lay.Child(0).(*gi.Layout).Child(0).(*TextView)
+	ov = lay.Child(1).(*gi.Layout).Child(0).(*TextView)
+	tv = lay.Child(2).(*gi.Layout).Child(0).(*TextView)
+	rv = mv.ResultLay().Child(0).(*TextView)
+	return
+}
+
+func (mv *MergeView) ConfigTexts() {
+	if mv.BufBase == nil {
+		mv.BufBase = &TextBuf{}
+		mv.BufBase.InitName(mv.BufBase, "merge-buf-base")
+		mv.BufOurs = &TextBuf{}
+		mv.BufOurs.InitName(mv.BufOurs, "merge-buf-ours")
+		mv.BufTheirs = &TextBuf{}
+		mv.BufTheirs.InitName(mv.BufTheirs, "merge-buf-theirs")
+		mv.BufResult = &TextBuf{}
+		mv.BufResult.InitName(mv.BufResult, "merge-buf-result")
+		mv.BufResult.TextBufSig.Connect(mv.This(), func(recv, send ki.Ki, sig int64, data interface{}) {
+			if sig == int64(TextBufInsert) || sig == int64(TextBufDelete) {
+				mvv := recv.Embed(KiT_MergeView).(*MergeView)
+				mvv.resultEdited(data.(*textbuf.Edit))
+			}
+		})
+	}
+	bufs := []*TextBuf{mv.BufBase, mv.BufOurs, mv.BufTheirs, mv.BufResult}
+	for _, tb := range bufs {
+		tb.Filename = gi.FileName(mv.File)
+		tb.Opts.LineNos = true
+		tb.Stat() // update markup
+	}
+	lay := mv.MergeLay()
+	lay.Lay = gi.LayoutHoriz
+	lay.SetStretchMax()
+	rlay := mv.ResultLay()
+	rlay.Lay = gi.LayoutHoriz
+	rlay.SetStretchMax()
+	rlay.SetMinPrefWidth(units.NewEm(10))
+	rlay.SetMinPrefHeight(units.NewEm(10))
+	config := kit.TypeAndNameList{}
+	config.Add(gi.KiT_Layout, "text-base-lay")
+	config.Add(gi.KiT_Layout, "text-ours-lay")
+	config.Add(gi.KiT_Layout, "text-theirs-lay")
+	mods, updt := lay.ConfigChildren(config, ki.UniqueNames)
+	if !mods {
+		updt = lay.UpdateStart()
+	} else {
+		for i, nm := range []string{"text-base", "text-ours", "text-theirs"} {
+			tl := lay.Child(i).(*gi.Layout)
+			tl.SetStretchMax()
+			tl.SetMinPrefWidth(units.NewEm(10))
+			tl.SetMinPrefHeight(units.NewEm(10))
+			tv := AddNewTextView(tl, nm)
+			tv.SetProp("font-family", gi.Prefs.MonoFont)
+			tv.SetInactive()
+			tv.SetBuf(bufs[i])
+		}
+		rv := AddNewTextView(rlay, "text-result")
+		rv.SetProp("font-family", gi.Prefs.MonoFont)
+		rv.SetBuf(mv.BufResult)
+	}
+	lay.UpdateEnd(updt)
+}
+
+func (mv *MergeView) IsConfiged() bool {
+	if mv.NumChildren() > 0 && mv.BufBase != nil {
+		return true
+	}
+	return false
+}
+
+// MergeViewProps are style properties for MergeView
+var MergeViewProps = ki.Props{
+	"EnumType:Flag":    gi.KiT_NodeFlags,
+	"max-width":        -1,
+	"max-height":       -1,
+	"background-color": &gi.Prefs.Colors.Background,
+	"color":            &gi.Prefs.Colors.Font,
+}
@@ -55,6 +55,15 @@ type SliceViewer interface {
 	// SliceSize returns the current size of the slice and sets SliceSize
 	UpdtSliceSize() int
 
+	// SliceIdx returns the index in the slice of given index in the view
+	// (i.e., StartIdx + row), which differ if the view does not show all of
+	// the slice in order
+	SliceIdx(idx int) int
+
+	// ViewIdx returns the index in the view of given index in the slice, or
+	// -1 if it is not shown -- the inverse of SliceIdx
+	ViewIdx(sidx int) int
+
 	// LayoutSliceGrid does the proper layout of slice grid depending on allocated size
 	// returns true if UpdateSliceGrid should be called after this
 	LayoutSliceGrid() bool
@@ -327,6 +336,18 @@ func (sv *SliceViewBase) UpdtSliceSize() int {
 	return sz
 }
 
+// SliceIdx returns the index in the slice of given index in the view, which
+// are the same for SliceViewBase
+func (sv *SliceViewBase) SliceIdx(idx int) int {
+	return idx
+}
+
+// ViewIdx returns the index in the view of given index in the slice, which
+// are the same for SliceViewBase
+func (sv *SliceViewBase) ViewIdx(sidx int) int {
+	return sidx
+}
+
 // ConfigSliceGrid configures the SliceGrid for the current slice
 // it is only called once at start, under overall Config
 func (sv *SliceViewBase) ConfigSliceGrid() {
@@ -682,7 +703,8 @@ func (sv *SliceViewBase) SetChanged() {
 
 // SliceNewAtRow inserts a new blank element at given display row
 func (sv *SliceViewBase) SliceNewAtRow(row int) {
-	sv.This().(SliceViewer).SliceNewAt(sv.StartIdx + row)
+	svr := sv.This().(SliceViewer)
+	svr.SliceNewAt(svr.SliceIdx(sv.StartIdx + row))
 }
 
 // SliceNewAt inserts a new blank element at given index in the slice -- -1
@@ -758,7 +780,8 @@ func (sv *SliceViewBase) SliceNewAt(idx int) {
 // SliceDeleteAtRow deletes element at given display row
 // if updt is true, then update the grid after
 func (sv *SliceViewBase) SliceDeleteAtRow(row int, updt bool) {
-	sv.This().(SliceViewer).SliceDeleteAt(sv.StartIdx+row, updt)
+	svr := sv.This().(SliceViewer)
+	svr.SliceDeleteAt(svr.SliceIdx(sv.StartIdx+row), updt)
 }
 
 // SliceDeleteAt deletes element at given index from slice
@@ -905,7 +928,7 @@ func (sv *SliceViewBase) HasFocus2D() bool {
 
 // SliceVal returns value interface at given slice index
 func (sv *SliceViewBase) SliceVal(idx int) interface{} {
-	if idx < 0 || idx >= sv.SliceSize {
+	if idx < 0 || idx >= sv.SliceNPVal.Len() {
 		fmt.Printf("giv.SliceViewBase: slice index out of range: %v\n", idx)
 		return nil
 	}
@@ -921,7 +944,8 @@ func (sv *SliceViewBase) IsRowInBounds(row int) bool {
 
 // IsIdxVisible returns true if slice index is currently visible
 func (sv *SliceViewBase) IsIdxVisible(idx int) bool {
-	return sv.IsRowInBounds(idx - sv.StartIdx)
+	vidx := sv.This().(SliceViewer).ViewIdx(idx)
+	return vidx >= 0 && sv.IsRowInBounds(vidx-sv.StartIdx)
 }
 
 // RowFirstWidget returns the first widget for given row (could be index or
@@ -963,12 +987,13 @@ func (sv *SliceViewBase) RowGrabFocus(row int) *gi.WidgetBase {
 // returns that element or nil if not successful
 func (sv *SliceViewBase) IdxGrabFocus(idx int) *gi.WidgetBase {
 	sv.ScrollToIdx(idx)
-	return sv.This().(SliceViewer).RowGrabFocus(idx - sv.StartIdx)
+	svr := sv.This().(SliceViewer)
+	return svr.RowGrabFocus(svr.ViewIdx(idx) - sv.StartIdx)
 }
 
 // IdxPos returns center of window position of index label for idx (ContextMenuPos)
 func (sv *SliceViewBase) IdxPos(idx int) image.Point {
-	row := idx - sv.StartIdx
+	row := sv.This().(SliceViewer).ViewIdx(idx) - sv.StartIdx
 	if row < 0 {
 		row = 0
 	}
@@ -1003,19 +1028,24 @@ func (sv *SliceViewBase) IdxFromPos(posY int) (int, bool) {
 	if !ok {
 		return -1, false
 	}
-	return row + sv.StartIdx, true
+	return sv.This().(SliceViewer).SliceIdx(row + sv.StartIdx), true
 }
 
-// ScrollToIdx ensures that given slice idx is visible by scrolling display as needed
+// ScrollToIdx ensures that given slice idx is visible by scrolling display as
+// needed -- returns false if it is not shown at all
 func (sv *SliceViewBase) ScrollToIdx(idx int) bool {
-	if idx < sv.StartIdx {
-		sv.StartIdx = idx
+	vidx := sv.This().(SliceViewer).ViewIdx(idx)
+	if vidx < 0 && idx >= 0 {
+		return false
+	}
+	if vidx < sv.StartIdx {
+		sv.StartIdx = vidx
 		sv.StartIdx = ints.MaxInt(0, sv.StartIdx)
 		sv.UpdateScroll()
 		sv.This().(SliceViewer).UpdateSliceGrid()
 		return true
-	} else if idx >= sv.StartIdx+sv.DispRows {
-		sv.StartIdx = idx - (sv.DispRows - 1)
+	} else if vidx >= sv.StartIdx+sv.DispRows {
+		sv.StartIdx = vidx - (sv.DispRows - 1)
 		sv.StartIdx = ints.MaxInt(0, sv.StartIdx)
 		sv.UpdateScroll()
 		sv.This().(SliceViewer).UpdateSliceGrid()
@@ -1060,11 +1090,13 @@ func SliceIdxByValue(slc interface{}, fldVal interface{}) (int, bool) {
 // MoveDown moves the selection down to next row, using given select mode
 // (from keyboard modifiers) -- returns newly selected row or -1 if failed
 func (sv *SliceViewBase) MoveDown(selMode mouse.SelectModes) int {
-	if sv.SelectedIdx >= sv.SliceSize-1 {
-		sv.SelectedIdx = sv.SliceSize - 1
+	svr := sv.This().(SliceViewer)
+	vidx := svr.ViewIdx(sv.SelectedIdx)
+	if vidx >= sv.SliceSize-1 {
+		sv.SelectedIdx = svr.SliceIdx(sv.SliceSize - 1)
 		return -1
 	}
-	sv.SelectedIdx++
+	sv.SelectedIdx = svr.SliceIdx(vidx + 1)
 	sv.SelectIdxAction(sv.SelectedIdx, selMode)
 	return sv.SelectedIdx
 }
@@ -1084,11 +1116,13 @@ func (sv *SliceViewBase) MoveDownAction(selMode mouse.SelectModes) int {
 // MoveUp moves the selection up to previous idx, using given select mode
 // (from keyboard modifiers) -- returns newly selected idx or -1 if failed
 func (sv *SliceViewBase) MoveUp(selMode mouse.SelectModes) int {
-	if sv.SelectedIdx <= 0 {
-		sv.SelectedIdx = 0
+	svr := sv.This().(SliceViewer)
+	vidx := svr.ViewIdx(sv.SelectedIdx)
+	if vidx <= 0 {
+		sv.SelectedIdx = svr.SliceIdx(0)
 		return -1
 	}
-	sv.SelectedIdx--
+	sv.SelectedIdx = svr.SliceIdx(vidx - 1)
 	sv.SelectIdxAction(sv.SelectedIdx, selMode)
 	return sv.SelectedIdx
 }
@@ -1107,12 +1141,15 @@ func (sv *SliceViewBase) MoveUpAction(selMode mouse.SelectModes) int {
 // MovePageDown moves the selection down to next page, using given select mode
 // (from keyboard modifiers) -- returns newly selected idx or -1 if failed
 func (sv *SliceViewBase) MovePageDown(selMode mouse.SelectModes) int {
-	if sv.SelectedIdx >= sv.SliceSize-1 {
-		sv.SelectedIdx = sv.SliceSize - 1
+	svr := sv.This().(SliceViewer)
+	vidx := svr.ViewIdx(sv.SelectedIdx)
+	if vidx >= sv.SliceSize-1 {
+		sv.SelectedIdx = svr.SliceIdx(sv.SliceSize - 1)
 		return -1
 	}
-	sv.SelectedIdx += sv.VisRows
-	sv.SelectedIdx = ints.MinInt(sv.SelectedIdx, sv.SliceSize-1)
+	vidx += sv.VisRows
+	vidx = ints.MinInt(vidx, sv.SliceSize-1)
+	sv.SelectedIdx = svr.SliceIdx(vidx)
 	sv.SelectIdxAction(sv.SelectedIdx, selMode)
 	return sv.SelectedIdx
 }
@@ -1131,12 +1168,15 @@ func (sv *SliceViewBase) MovePageDownAction(selMode mouse.SelectModes) int {
 // MovePageUp moves the selection up to previous page, using given select mode
 // (from keyboard modifiers) -- returns newly selected idx or -1 if failed
 func (sv *SliceViewBase) MovePageUp(selMode mouse.SelectModes) int {
-	if sv.SelectedIdx <= 0 {
-		sv.SelectedIdx = 0
+	svr := sv.This().(SliceViewer)
+	vidx := svr.ViewIdx(sv.SelectedIdx)
+	if vidx <= 0 {
+		sv.SelectedIdx = svr.SliceIdx(0)
 		return -1
 	}
-	sv.SelectedIdx -= sv.VisRows
-	sv.SelectedIdx = ints.MaxInt(0, sv.SelectedIdx)
+	vidx -= sv.VisRows
+	vidx = ints.MaxInt(0, vidx)
+	sv.SelectedIdx = svr.SliceIdx(vidx)
 	sv.SelectIdxAction(sv.SelectedIdx, selMode)
 	return sv.SelectedIdx
 }
@@ -1185,14 +1225,15 @@ func (sv *SliceViewBase) SelectIdxWidgets(idx int, sel bool) bool {
 	if !sv.IsIdxVisible(idx) {
 		return false
 	}
-	sv.This().(SliceViewer).SelectRowWidgets(idx-sv.StartIdx, sel)
+	svr := sv.This().(SliceViewer)
+	svr.SelectRowWidgets(svr.ViewIdx(idx)-sv.StartIdx, sel)
 	return true
 }
 
 // UpdateSelectRow updates the selection for the given row
 // callback from widgetsig select
 func (sv *SliceViewBase) UpdateSelectRow(row int, sel bool) {
-	idx := row + sv.StartIdx
+	idx := sv.This().(SliceViewer).SliceIdx(row + sv.StartIdx)
 	sv.UpdateSelectIdx(idx, sel)
 }
 
@@ -1283,7 +1324,9 @@ func (sv *SliceViewBase) SelectAllIdxs() {
 	wupdt := sv.TopUpdateStart()
 	sv.UnselectAllIdxs()
 	sv.SelectedIdxs = make(map[int]struct{}, sv.SliceSize)
-	for idx := 0; idx < sv.SliceSize; idx++ {
+	svr := sv.This().(SliceViewer)
+	for vidx := 0; vidx < sv.SliceSize; vidx++ {
+		idx := svr.SliceIdx(vidx)
 		sv.SelectedIdxs[idx] = struct{}{}
 		sv.SelectIdxWidgets(idx, true)
 	}
@@ -1297,11 +1340,13 @@ func (sv *SliceViewBase) SelectIdxAction(idx int, mode mouse.SelectModes) {
 	if mode == mouse.NoSelect {
 		return
 	}
-	idx = ints.MinInt(idx, sv.SliceSize-1)
-	if idx < 0 {
-		idx = 0
+	svr := sv.This().(SliceViewer)
+	vidx := ints.MinInt(svr.ViewIdx(idx), sv.SliceSize-1)
+	if vidx < 0 {
+		vidx = 0
 	}
-	// row := idx - sv.StartIdx // note: could be out of bounds
+	idx = svr.SliceIdx(vidx)
+	// row := vidx - sv.StartIdx // note: could be out of bounds
 	wupdt := sv.TopUpdateStart()
 	switch mode {
 	case mouse.SelectOne:
@@ -1326,9 +1371,13 @@ func (sv *SliceViewBase) SelectIdxAction(idx int, mode mouse.SelectModes) {
 			sv.IdxGrabFocus(idx)
 			sv.WidgetSig.Emit(sv.This(), int64(gi.WidgetSelected), sv.SelectedIdx)
 		} else {
-			minIdx := -1
+			minIdx := -1 // in the view
 			maxIdx := 0
 			for r, _ := range sv.SelectedIdxs {
+				r = svr.ViewIdx(r)
+				if r < 0 {
+					continue
+				}
 				if minIdx < 0 {
 					minIdx = r
 				} else {
@@ -1336,18 +1385,24 @@ func (sv *SliceViewBase) SelectIdxAction(idx int, mode mouse.SelectModes) {
 				}
 				maxIdx = ints.MaxInt(maxIdx, r)
 			}
-			cidx := idx
+			cidx := vidx
 			sv.SelectedIdx = idx
 			sv.SelectIdx(idx)
-			if idx < minIdx {
+			if vidx < minIdx {
 				for cidx < minIdx {
 					r := sv.MoveDown(mouse.SelectQuiet) // just select
-					cidx = r
+					if r < 0 {
+						break
+					}
+					cidx = svr.ViewIdx(r)
 				}
-			} else if idx > maxIdx {
+			} else if vidx > maxIdx {
 				for cidx > maxIdx {
 					r := sv.MoveUp(mouse.SelectQuiet) // just select
-					cidx = r
+					if r < 0 {
+						break
+					}
+					cidx = svr.ViewIdx(r)
 				}
 			}
 			sv.IdxGrabFocus(idx)
@@ -1912,29 +1967,32 @@ func (sv *SliceViewBase) KeyInputInactive(kt *key.ChordEvent) {
 		fmt.Printf("SliceViewBase Inactive KeyInput: %v\n", sv.PathUnique())
 	}
 	kf := gi.KeyFun(kt.Chord())
-	idx := sv.SelectedIdx
+	svr := sv.This().(SliceViewer)
+	vidx := svr.ViewIdx(sv.SelectedIdx) // moves are in the view
 	switch {
 	case kf == gi.KeyFunMoveDown:
-		ni := idx + 1
+		ni := vidx + 1
 		if ni < sv.SliceSize {
+			ni = svr.SliceIdx(ni)
 			sv.ScrollToIdx(ni)
 			sv.UpdateSelectIdx(ni, true)
 			kt.SetProcessed()
 		}
 	case kf == gi.KeyFunMoveUp:
-		ni := idx - 1
+		ni := vidx - 1
 		if ni >= 0 {
+			ni = svr.SliceIdx(ni)
 			sv.ScrollToIdx(ni)
 			sv.UpdateSelectIdx(ni, true)
 			kt.SetProcessed()
 		}
 	case kf == gi.KeyFunPageDown:
-		ni := ints.MinInt(idx+sv.VisRows-1, sv.SliceSize-1)
+		ni := svr.SliceIdx(ints.MinInt(vidx+sv.VisRows-1, sv.SliceSize-1))
 		sv.ScrollToIdx(ni)
 		sv.UpdateSelectIdx(ni, true)
 		kt.SetProcessed()
 	case kf == gi.KeyFunPageUp:
-		ni := ints.MaxInt(idx-(sv.VisRows-1), 0)
+		ni := svr.SliceIdx(ints.MaxInt(vidx-(sv.VisRows-1), 0))
 		sv.ScrollToIdx(ni)
 		sv.UpdateSelectIdx(ni, true)
 		kt.SetProcessed()
@@ -6,6 +6,7 @@ package giv
 
 import (
 	"fmt"
+	"html"
 	"reflect"
 	"strconv"
 	"strings"
@@ -32,6 +33,8 @@ type StructView struct {
 	ToolbarStru   interface{}       `desc:"the struct that we successfully set a toolbar for"`
 	HasDefs       bool              `json:"-" xml:"-" view:"inactive" desc:"if true, some fields have default values -- update labels when values change"`
 	TypeFieldTags map[string]string `json:"-" xml:"-" view:"inactive" desc:"extra tags by field name -- from type properties"`
+	Invalid       bool              `json:"-" xml:"-" view:"inactive" desc:"if true, some fields, or the struct as a whole, are not valid according to their validation tags or the Validate method of the struct -- updated by UpdateValid"`
+	ValidErr      error             `json:"-" xml:"-" view:"-" desc:"the error from the Validate method of the struct, for the struct as a whole -- shown in the valid-err label"`
 }
 
 var KiT_StructView = kit.Types.AddType(&StructView{}, StructViewProps)
@@ -60,6 +63,7 @@ func (sv *StructView) SetStruct(st interface{}) {
 	updt := false
 	if sv.Struct != st {
 		sv.Changed = false
+		sv.FieldViews = nil // no errors from the last struct
 		updt = sv.UpdateStart()
 		if sv.Struct != nil {
 			if k, ok := sv.Struct.(ki.Ki); ok {
@@ -95,8 +99,10 @@ func (sv *StructView) SetStruct(st interface{}) {
 func (sv *StructView) UpdateFields() {
 	updt := sv.UpdateStart()
 	for _, vv := range sv.FieldViews {
+		vv.AsValueViewBase().ValidErr = nil
 		vv.UpdateWidget()
 	}
+	sv.UpdateValid()
 	sv.UpdateEnd(updt)
 }
 
@@ -124,6 +130,9 @@ func (sv *StructView) Config() {
 	config := kit.TypeAndNameList{}
 	config.Add(gi.KiT_ToolBar, "toolbar")
 	config.Add(gi.KiT_Frame, "struct-grid")
+	if _, ok := sv.Struct.(Validator); ok {
+		config.Add(gi.KiT_Label, "valid-err")
+	}
 	mods, updt := sv.ConfigChildren(config, ki.UniqueNames)
 	sv.ConfigStructGrid()
 	sv.ConfigToolbar()
@@ -214,6 +223,15 @@ func (sv *StructView) ConfigStructGrid() {
 	sg.SetStretchMax() // for this to work, ALL layers above need it too
 	sg.SetProp("columns", 2)
 	config := kit.TypeAndNameList{}
+	// the errors for values that were not set (see CheckValid) are kept
+	// for the new views, as the widgets are updated with them
+	verrs := make(map[string]error)
+	for _, vv := range sv.FieldViews {
+		if verr := vv.AsValueViewBase().ValidErr; verr != nil {
+			fnm, _ := vv.Prop("sv-field").(string)
+			verrs[fnm] = verr
+		}
+	}
 	// always start fresh!
 	sv.FieldViews = make([]ValueView, 0)
 	kit.FlatFieldsValueFunc(sv.Struct, func(fval interface{}, typ reflect.Type, field reflect.StructField, fieldVal reflect.Value) bool {
@@ -247,6 +265,8 @@ func (sv *StructView) ConfigStructGrid() {
 				// todo: other things with view tag..
 				fnm := field.Name + "." + sfield.Name
 				svv.SetTag("label", fnm)
+				svv.SetProp("sv-field", fnm)
+				svv.AsValueViewBase().ValidErr = verrs[fnm]
 				labnm := fmt.Sprintf("label-%v", fnm)
 				valnm := fmt.Sprintf("value-%v", fnm)
 				config.Add(gi.KiT_Label, labnm)
@@ -262,6 +282,8 @@ func (sv *StructView) ConfigStructGrid() {
 		}
 		vvp := fieldVal.Addr()
 		vv.SetStructValue(vvp, sv.Struct, &field, sv.TmpSave, sv.ViewPath)
+		vv.SetProp("sv-field", field.Name)
+		vv.AsValueViewBase().ValidErr = verrs[field.Name]
 		vtyp := vv.WidgetType()
 		// todo: other things with view tag..
 		labnm := fmt.Sprintf("label-%v", field.Name)
@@ -294,6 +316,7 @@ func (sv *StructView) ConfigStructGrid() {
 			vvb.ViewSig.ConnectOnly(sv.This(), func(recv, send ki.Ki, sig int64, data interface{}) {
 				svv := recv.Embed(KiT_StructView).(*StructView)
 				svv.UpdateDefaults()
+				svv.UpdateValid()
 				// note: updating vv here is redundant -- relevant field will have already updated
 				svv.Changed = true
 				if svv.ChangeFlag != nil {
@@ -316,6 +339,7 @@ func (sv *StructView) ConfigStructGrid() {
 			})
 		}
 	}
+	sv.UpdateValid()
 	sg.UpdateEnd(updt)
 }
 
@@ -339,6 +363,55 @@ func (sv *StructView) UpdateDefaults() {
 	sg.UpdateEnd(updt)
 }
 
+// UpdateValid checks the struct with ValidateStruct, and shows the errors on
+// the fields (see SetValidErrStyle), along with any errors from values that
+// were not set (see ValueViewBase.CheckValid), and the error for the struct
+// as a whole in the valid-err label -- updates Invalid and returns true if
+// all is valid.  Called after each edit -- the ViewSig signal can be used to
+// monitor Invalid, e.g., as StructViewDialog does for its Ok button.
+func (sv *StructView) UpdateValid() bool {
+	if kit.IfaceIsNil(sv.Struct) || !sv.IsConfiged() || sv.IsInactive() {
+		sv.Invalid = false
+		return true
+	}
+	flds, err := ValidateStruct(sv.Struct)
+	sv.ValidErr = err
+	sv.Invalid = err != nil
+	sg := sv.StructGrid()
+	updt := sg.UpdateStart()
+	for _, vv := range sv.FieldViews {
+		vvb := vv.AsValueViewBase()
+		ferr := vvb.ValidErr
+		if ferr == nil {
+			fnm, _ := vv.Prop("sv-field").(string)
+			ferr = flds[fnm]
+		}
+		if ferr != nil {
+			sv.Invalid = true
+		}
+		if vvb.Widget != nil {
+			SetValidErrStyle(vvb.Widget, ferr)
+		}
+	}
+	sg.UpdateEnd(updt)
+	if elk := sv.ChildByName("valid-err", 2); elk != nil {
+		el := elk.(*gi.Label)
+		el.Redrawable = true
+		el.SetProp("color", ValidErrColor)
+		txt := ""
+		if err != nil {
+			txt = html.EscapeString(err.Error())
+		}
+		if el.Text != txt {
+			updt := sv.UpdateStart()
+			el.SetText(txt)
+			sv.SetFullReRender()
+			sv.UpdateEnd(updt)
+		}
+	}
+	return !sv.Invalid
+}
+
 func (sv *StructView) Render2D() {
 	sv.ToolBar().UpdateActions()
 	if win := sv.ParentWindow(); win != nil {
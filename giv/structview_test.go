@@ -0,0 +1,86 @@
+// Copyright (c) 2020, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package giv_test
+
+import (
+	"testing"
+
+	"github.com/goki/gi/gi"
+	"github.com/goki/gi/giv"
+)
+
+type dialogTestStruct struct {
+	Name string `required:"+"`
+	Age  int    `min:"0" max:"10" maxval:"150"`
+}
+
+// fieldView returns the ValueView of given field in the StructView
+func fieldView(sv *giv.StructView, fnm string) giv.ValueView {
+	for _, vv := range sv.FieldViews {
+		if vv.AsValueViewBase().Field.Name == fnm {
+			return vv
+		}
+	}
+	return nil
+}
+
+func TestStructViewDialogValid(t *testing.T) {
+	h := newTestWindow(t, func(mfr *gi.Frame) {})
+	defer h.Close()
+
+	stru := &dialogTestStruct{}
+	dlg := giv.StructViewDialog(h.Win.Viewport, stru, giv.DlgOpts{Title: "Valid", Ok: true, Cancel: true}, nil, nil)
+	h.WaitIdle()
+	sv := dlg.Frame().ChildByName("struct-view", 0).(*giv.StructView)
+	okb := dlg.OkButton()
+	if okb == nil {
+		t.Fatalf("no Ok button")
+	}
+
+	// Ok and the accept key are inactive while a required field is empty
+	if !sv.Invalid || okb.IsActive() {
+		t.Errorf("empty Name: Invalid %v, Ok active %v", sv.Invalid, okb.IsActive())
+	}
+	h.KeyFun(gi.KeyFunAccept)
+	if dlg.State == gi.DialogAccepted {
+		t.Fatalf("invalid dialog accepted")
+	}
+
+	if !fieldView(sv, "Name").SetValue("bob") {
+		t.Errorf("SetValue of valid Name failed")
+	}
+	h.WaitIdle()
+	if sv.Invalid || okb.IsInactive() {
+		t.Errorf("valid: Invalid %v, Ok active %v", sv.Invalid, okb.IsActive())
+	}
+
+	// a value outside of min, max is valid, and one outside of maxval is not
+	// set, and makes Ok inactive until a valid value is set
+	// (the field views are looked up each time, as the view can be rebuilt)
+	if !fieldView(sv, "Age").SetValue(20) || stru.Age != 20 || sv.Invalid {
+		t.Errorf("SetValue outside of the spinbox limits: Age %v, Invalid %v", stru.Age, sv.Invalid)
+	}
+	h.WaitIdle()
+	if fieldView(sv, "Age").SetValue(200) || stru.Age != 20 {
+		t.Errorf("SetValue of invalid Age was set: %v", stru.Age)
+	}
+	h.WaitIdle()
+	if !sv.Invalid || okb.IsActive() {
+		t.Errorf("invalid Age: Invalid %v, Ok active %v", sv.Invalid, okb.IsActive())
+	}
+	fieldView(sv, "Age").SetValue(30)
+	h.WaitIdle()
+	if sv.Invalid || okb.IsInactive() {
+		t.Errorf("valid Age: Invalid %v, Ok active %v", sv.Invalid, okb.IsActive())
+	}
+
+	h.KeyFun(gi.KeyFunAccept)
+	if dlg.State != gi.DialogAccepted {
+		t.Errorf("valid dialog not accepted: %v", dlg.State)
+	}
+	if stru.Name != "bob" || stru.Age != 30 {
+		t.Errorf("struct after dialog: %+v", stru)
+	}
+}
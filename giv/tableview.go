@@ -15,6 +15,8 @@ import (
 	"github.com/goki/gi/gi"
 	"github.com/goki/gi/oswin"
 	"github.com/goki/gi/oswin/cursor"
+	"github.com/goki/gi/oswin/key"
+	"github.com/goki/gi/oswin/mouse"
 	"github.com/goki/gi/units"
 	"github.com/goki/ki/ints"
 	"github.com/goki/ki/ki"
@@ -34,16 +36,38 @@ import (
 // WidgetSelected signal, and TableViewDoubleClick for double clicks (can be
 // used for closing dialogs).  If !Inactive, it is a full-featured editor with
 // multiple-selection, cut-and-paste, and drag-and-drop, reporting each action
-// taken using the TableViewSig signals
+// taken using the TableViewSig signals.  Instead of a slice, it can view a
+// TableModel, which gets only the rows in view (see SetModel).
+//
+// Clicking on a column header sorts by that column, and shift-clicking adds
+// it as a further sort key.  The rows can be filtered by the fields under the
+// header, the columns can be resized and moved by dragging their headers, and
+// right-clicking on the header pops up a menu for hiding columns -- these
+// settings are in Prefs, which are saved per table if PrefsKey is set.
+// Sorting and filtering only change the order of the rows in the view (see
+// Idxs), not the slice itself, and all of the indexes (e.g., SelectedIdx)
+// are those of the slice.
 type TableView struct {
 	SliceViewBase
-	StyleFunc  TableViewStyleFunc    `copy:"-" view:"-" json:"-" xml:"-" desc:"optional styling function"`
-	SelField   string                `copy:"-" view:"-" json:"-" xml:"-" desc:"current selection field -- initially select value in this field"`
-	SortIdx    int                   `desc:"current sort index"`
-	SortDesc   bool                  `desc:"whether current sort order is descending"`
-	StruType   reflect.Type          `copy:"-" view:"-" json:"-" xml:"-" desc:"struct type for each row"`
-	NVisFields int                   `copy:"-" view:"-" json:"-" xml:"-" desc:"number of visible fields"`
-	VisFields  []reflect.StructField `copy:"-" view:"-" json:"-" xml:"-" desc:"the visible fields"`
+	StyleFunc   TableViewStyleFunc    `copy:"-" view:"-" json:"-" xml:"-" desc:"optional styling function"`
+	SelField    string                `copy:"-" view:"-" json:"-" xml:"-" desc:"current selection field -- initially select value in this field"`
+	SortIdx     int                   `desc:"current sort index -- the first of the sort keys in Prefs"`
+	SortDesc    bool                  `desc:"whether current sort order is descending"`
+	ShowFilters bool                  `xml:"filters" desc:"whether to show the row of filter fields under the header -- updated from 'filters' property (bool)"`
+	Prefs       TableViewPrefs        `copy:"-" desc:"the order, widths and visibility of the columns, the sort keys and the filters, as changed by the user -- saved under PrefsKey"`
+	PrefsKey    string                `desc:"key for saving Prefs in TableViewPrefsAll -- if empty, the 'prefs-key' property is used, and if neither is set, the Prefs are not saved"`
+	Idxs        []int                 `copy:"-" view:"-" json:"-" xml:"-" desc:"indexes in the slice of the rows shown when sorting or filtering, in order -- nil if all rows are shown in the order of the slice -- see SliceIdx"`
+	Model       TableModel            `copy:"-" view:"-" json:"-" xml:"-" desc:"the model of the data viewed instead of a slice, if set -- see SetModel"`
+	StruType    reflect.Type          `copy:"-" view:"-" json:"-" xml:"-" desc:"struct type for each row"`
+	NVisFields  int                   `copy:"-" view:"-" json:"-" xml:"-" desc:"number of visible fields"`
+	VisFields   []reflect.StructField `copy:"-" view:"-" json:"-" xml:"-" desc:"the visible fields, in column order"`
+	AllFields   []reflect.StructField `copy:"-" view:"-" json:"-" xml:"-" desc:"all the fields that can be shown as columns, in struct order, including hidden ones"`
+	viewIdxs    []int
+	colDrag     *tableColDrag
+	fetching    bool
+	fetchSt     int
+	fetchEd     int
+	modelSort   []TableSortKey
 }
 
 var KiT_TableView = kit.Types.AddType(&TableView{}, TableViewProps)
@@ -73,6 +97,10 @@ func (tv *TableView) SetSlice(sl interface{}) {
 		tv.Update()
 		return
 	}
+	if tv.Model != nil {
+		tv.Model.SetNotify(nil)
+		tv.Model = nil
+	}
 	if !tv.IsInactive() {
 		tv.SelectedIdx = -1
 	}
@@ -99,7 +127,15 @@ func (tv *TableView) SetSlice(sl interface{}) {
 	tv.ResetSelectedIdxs()
 	tv.SelectMode = false
 	tv.SetFullReRender()
+	tv.viewProps()
+	tv.Idxs = nil
+	tv.LoadPrefs()
+	tv.Config()
+	tv.UpdateEnd(updt)
+}
 
+// viewProps sets the options for the view from the properties
+func (tv *TableView) viewProps() {
 	tv.ShowIndex = true
 	if sidxp, err := tv.PropTry("index"); err == nil {
 		tv.ShowIndex, _ = kit.ToBool(sidxp)
@@ -108,8 +144,10 @@ func (tv *TableView) SetSlice(sl interface{}) {
 	if siknp, err := tv.PropTry("inact-key-nav"); err == nil {
 		tv.InactKeyNav, _ = kit.ToBool(siknp)
 	}
-	tv.Config()
-	tv.UpdateEnd(updt)
+	tv.ShowFilters = true
+	if sfltp, err := tv.PropTry("filters"); err == nil {
+		tv.ShowFilters, _ = kit.ToBool(sfltp)
+	}
 }
 
 var TableViewProps = ki.Props{
@@ -128,10 +166,21 @@ func (tv *TableView) StructType() reflect.Type {
 }
 
 // CacheVisFields computes the number of visible fields in nVisFields and
-// caches those to skip in fieldSkip
+// caches those to skip in fieldSkip -- the visible fields are those in
+// AllFields that are not hidden, in the column order in Prefs
 func (tv *TableView) CacheVisFields() {
+	if tv.Model != nil {
+		tv.AllFields = tv.modelFields()
+	} else {
+		tv.cacheStructFields()
+	}
+	tv.cacheVisFields()
+}
+
+// cacheStructFields sets AllFields to the fields of the struct type
+func (tv *TableView) cacheStructFields() {
 	styp := tv.StructType()
-	tv.VisFields = make([]reflect.StructField, 0, 20)
+	tv.AllFields = make([]reflect.StructField, 0, 20)
 	kit.FlatFieldsTypeFunc(styp, func(typ reflect.Type, fld reflect.StructField) bool {
 		tvtag := fld.Tag.Get("tableview")
 		add := true
@@ -152,17 +201,47 @@ func (tv *TableView) CacheVisFields() {
 			if typ != styp {
 				rfld, has := styp.FieldByName(fld.Name)
 				if has {
-					tv.VisFields = append(tv.VisFields, rfld)
+					tv.AllFields = append(tv.AllFields, rfld)
 				} else {
 					fmt.Printf("TableView: Field name: %v is ambiguous from base struct type: %v, cannot be used in view!\n", fld.Name, styp.String())
 				}
 			} else {
-				tv.VisFields = append(tv.VisFields, fld)
+				tv.AllFields = append(tv.AllFields, fld)
 			}
 		}
 		return true
 	})
+}
+
+// cacheVisFields sets VisFields from AllFields and the Prefs
+func (tv *TableView) cacheVisFields() {
+	tv.VisFields = make([]reflect.StructField, 0, len(tv.AllFields))
+	for _, nm := range tv.Prefs.Order {
+		if fld, has := tv.ColFieldByName(nm); has && !tv.Prefs.IsHidden(nm) {
+			tv.VisFields = append(tv.VisFields, fld)
+		}
+	}
+	for _, fld := range tv.AllFields {
+		if tv.Prefs.IsHidden(fld.Name) {
+			continue
+		}
+		has := false
+		for _, nm := range tv.Prefs.Order {
+			if nm == fld.Name {
+				has = true
+				break
+			}
+		}
+		if !has {
+			tv.VisFields = append(tv.VisFields, fld)
+		}
+	}
+	if len(tv.VisFields) == 0 && len(tv.AllFields) > 0 { // can't hide everything
+		tv.Prefs.Hidden = nil
+		tv.VisFields = append(tv.VisFields, tv.AllFields[0])
+	}
 	tv.NVisFields = len(tv.VisFields)
+	tv.sortIdxFromKeys()
 }
 
 // IsConfiged returns true if the widget is fully configured
@@ -253,13 +332,13 @@ func (tv *TableView) RowWidgetNs() (nWidgPerRow, idxOff int) {
 // ConfigSliceGrid configures the SliceGrid for the current slice
 // this is only called by global Config and updates are guarded by that
 func (tv *TableView) ConfigSliceGrid() {
-	if kit.IfaceIsNil(tv.Slice) {
+	if kit.IfaceIsNil(tv.source()) {
 		return
 	}
 
 	tv.CacheVisFields()
 
-	sz := tv.This().(SliceViewer).UpdtSliceSize()
+	sz := tv.sourceLen() // not the filtered size: need the header and filters regardless
 	if sz == 0 {
 		return
 	}
@@ -276,6 +355,9 @@ func (tv *TableView) ConfigSliceGrid() {
 
 	sgcfg := kit.TypeAndNameList{}
 	sgcfg.Add(gi.KiT_ToolBar, "header")
+	if tv.ShowFilters {
+		sgcfg.Add(gi.KiT_ToolBar, "filters")
+	}
 	sgcfg.Add(gi.KiT_Layout, "grid-lay")
 	sg.ConfigChildren(sgcfg, ki.UniqueNames)
 
@@ -338,16 +420,8 @@ func (tv *TableView) ConfigSliceGrid() {
 	for fli := 0; fli < tv.NVisFields; fli++ {
 		field := tv.VisFields[fli]
 		hdr := sgh.Child(idxOff + fli).(*gi.Action)
-		hdr.SetText(field.Name)
-		if fli == tv.SortIdx {
-			if tv.SortDesc {
-				hdr.SetIcon("wedge-down")
-			} else {
-				hdr.SetIcon("wedge-up")
-			}
-		}
 		hdr.Data = fli
-		hdr.Tooltip = field.Name + " (click to sort by)"
+		hdr.Tooltip = field.Name + " (click to sort by, shift-click to add as a further sort key, drag to move or resize, right-click for columns)"
 		dsc := field.Tag.Get("desc")
 		if dsc != "" {
 			hdr.Tooltip += ": " + dsc
@@ -359,20 +433,35 @@ func (tv *TableView) ConfigSliceGrid() {
 			tvv.SortSliceAction(fldIdx)
 		})
 
-		val := kit.OnePtrUnderlyingValue(tv.SliceNPVal.Index(0)) // deal with pointer lists
-		stru := val.Interface()
-		fval := val.Elem().FieldByIndex(field.Index)
+		var fval reflect.Value
+		var stru interface{}
+		if tv.Model != nil {
+			fval = tv.modelValue(-1, &field).Elem()
+		} else {
+			val := kit.OnePtrUnderlyingValue(tv.SliceNPVal.Index(0)) // deal with pointer lists
+			stru = val.Interface()
+			fval = val.Elem().FieldByIndex(field.Index)
+		}
 		vv := ToValueView(fval.Interface(), "")
 		if vv == nil { // shouldn't happen
 			continue
 		}
-		vv.SetStructValue(fval.Addr(), stru, &field, tv.TmpSave, tv.ViewPath)
+		if tv.Model != nil {
+			vv.SetSoloValue(fval.Addr())
+		} else {
+			vv.SetStructValue(fval.Addr(), stru, &field, tv.TmpSave, tv.ViewPath)
+		}
 		vtyp := vv.WidgetType()
 		valnm := fmt.Sprintf("value-%v.%v", fli, itxt)
 		cidx := idxOff + fli
 		widg := ki.NewOfType(vtyp).(gi.Node2D)
 		sgf.SetChild(widg, cidx, valnm)
 		vv.ConfigWidget(widg)
+		tv.setColWidth(widg, field.Name)
+	}
+	tv.ConfigHeaderSort()
+	if tv.ShowFilters {
+		tv.ConfigFilters()
 	}
 
 	if !tv.IsInactive() {
@@ -399,9 +488,8 @@ func (tv *TableView) ConfigSliceGrid() {
 		}
 	}
 
-	if tv.SortIdx >= 0 {
-		tv.SortSlice()
-	}
+	tv.SortSlice()
+	tv.UpdtSliceSize()
 
 	tv.ConfigScroll()
 }
@@ -410,7 +498,7 @@ func (tv *TableView) ConfigSliceGrid() {
 // returns true if UpdateSliceGrid should be called after this
 func (tv *TableView) LayoutSliceGrid() bool {
 	sg := tv.SliceGrid()
-	if kit.IfaceIsNil(tv.Slice) {
+	if kit.IfaceIsNil(tv.source()) {
 		if sg != nil {
 			sg.DeleteChildren(ki.DestroyKids)
 		}
@@ -430,6 +518,10 @@ func (tv *TableView) LayoutSliceGrid() bool {
 	if sgHt == 0 {
 		return false
 	}
+	if len(sg.GridData[gi.Row]) == 0 { // emptied by filtering, and not yet laid out again
+		tv.LayoutHeight = 0
+		return false
+	}
 	nWidgPerRow, _ := tv.RowWidgetNs()
 	tv.RowHeight = sg.GridData[gi.Row][0].AllocSize + sg.Spacing.Dots
 	tv.RowHeight = math32.Max(tv.RowHeight, tv.Sty.Font.Face.Metrics.Height)
@@ -451,18 +543,24 @@ func (tv *TableView) LayoutSliceGrid() bool {
 	return true
 }
 
-// LayoutHeader updates the header layout based on field widths
+// LayoutHeader updates the header (and filter row) layout based on field widths
 func (tv *TableView) LayoutHeader() {
 	_, idxOff := tv.RowWidgetNs()
 	nfld := tv.NVisFields + idxOff
-	sgh := tv.SliceHeader()
 	sgf := tv.SliceGrid()
 	spc := sgf.Spacing.Dots
 	gd := sgf.GridData[gi.Col]
 	if gd == nil {
 		return
 	}
-	if len(sgf.Kids) >= nfld {
+	if len(sgf.Kids) < nfld {
+		return
+	}
+	bars := []*gi.ToolBar{tv.SliceHeader()}
+	if tv.ShowFilters {
+		bars = append(bars, tv.FilterBar())
+	}
+	for _, sgh := range bars {
 		sumwd := float32(0)
 		for fli := 0; fli < nfld; fli++ {
 			lbl := sgh.Child(fli).(gi.Node2D).AsWidget()
@@ -487,7 +585,7 @@ func (tv *TableView) LayoutHeader() {
 
 // UpdateSliceGrid updates grid display -- robust to any time calling
 func (tv *TableView) UpdateSliceGrid() {
-	if kit.IfaceIsNil(tv.Slice) {
+	if kit.IfaceIsNil(tv.source()) {
 		return
 	}
 	sz := tv.This().(SliceViewer).UpdtSliceSize()
@@ -496,6 +594,10 @@ func (tv *TableView) UpdateSliceGrid() {
 	}
 	sg := tv.SliceGrid()
 	tv.DispRows = ints.MinInt(tv.SliceSize, tv.VisRows)
+	svnp := tv.SliceNPVal
+	if tv.Model != nil {
+		svnp = reflect.ValueOf(tv.Model)
+	}
 
 	nWidgPerRow, idxOff := tv.RowWidgetNs()
 	nWidg := nWidgPerRow * tv.DispRows
@@ -526,13 +628,17 @@ func (tv *TableView) UpdateSliceGrid() {
 
 	for i := 0; i < tv.DispRows; i++ {
 		ridx := i * nWidgPerRow
-		si := tv.StartIdx + i // slice idx
-		issel := tv.IdxIsSelected(si)
-		val := kit.OnePtrUnderlyingValue(tv.SliceNPVal.Index(si)) // deal with pointer lists
-		stru := val.Interface()
+		sidx := tv.SliceIdx(tv.StartIdx + i)
+		issel := tv.IdxIsSelected(sidx)
+		var val reflect.Value
+		var stru interface{}
+		if tv.Model == nil {
+			val = kit.OnePtrUnderlyingValue(tv.SliceNPVal.Index(sidx)) // deal with pointer lists
+			stru = val.Interface()
+		}
 
 		itxt := fmt.Sprintf("%05d", i)
-		sitxt := fmt.Sprintf("%05d", si)
+		sitxt := fmt.Sprintf("%05d", sidx)
 		labnm := fmt.Sprintf("index-%v", itxt)
 		if tv.ShowIndex {
 			var idxlab *gi.Label
@@ -560,15 +666,20 @@ func (tv *TableView) UpdateSliceGrid() {
 		}
 
 		vpath := tv.ViewPath + "[" + sitxt + "]"
-		if lblr, ok := tv.Slice.(gi.SliceLabeler); ok {
-			slbl := lblr.ElemLabel(si)
+		if lblr, ok := tv.source().(gi.SliceLabeler); ok {
+			slbl := lblr.ElemLabel(sidx)
 			if slbl != "" {
 				vpath = tv.ViewPath + "[" + slbl + "]"
 			}
 		}
 		for fli := 0; fli < tv.NVisFields; fli++ {
 			field := tv.VisFields[fli]
-			fval := val.Elem().FieldByIndex(field.Index)
+			var fval reflect.Value
+			if tv.Model != nil {
+				fval = tv.modelValue(sidx, &field).Elem()
+			} else {
+				fval = val.Elem().FieldByIndex(field.Index)
+			}
 			vvi := i*tv.NVisFields + fli
 			var vv ValueView
 			if tv.Values[vvi] == nil {
@@ -581,7 +692,13 @@ func (tv *TableView) UpdateSliceGrid() {
 				fmt.Printf("field: %v %v has nil valueview: %v -- should not happen -- fix ToValueView\n", fli, field.Name, fval.String())
 				continue
 			}
-			vv.SetStructValue(fval.Addr(), stru, &field, tv.TmpSave, vpath)
+			if tv.Model != nil {
+				vv.SetSoloValue(fval.Addr())
+				vv.SetProp("tv-model-row", sidx)
+				vv.SetProp("tv-model-col", field.Index[0])
+			} else {
+				vv.SetStructValue(fval.Addr(), stru, &field, tv.TmpSave, vpath)
+			}
 
 			vtyp := vv.WidgetType()
 			valnm := fmt.Sprintf("value-%v.%v", fli, itxt)
@@ -589,6 +706,7 @@ func (tv *TableView) UpdateSliceGrid() {
 			var widg gi.Node2D
 			if sg.Kids[cidx] != nil {
 				widg = sg.Kids[cidx].(gi.Node2D)
+				vv.AsValueViewBase().ValidErr = nil // widget is reset to the value
 				vv.UpdateWidget()
 				if tv.IsInactive() {
 					widg.AsNode2D().SetInactive()
@@ -598,6 +716,7 @@ func (tv *TableView) UpdateSliceGrid() {
 				widg = ki.NewOfType(vtyp).(gi.Node2D)
 				sg.SetChild(widg, cidx, valnm)
 				vv.ConfigWidget(widg)
+				tv.setColWidth(widg, field.Name)
 				wb := widg.AsWidget()
 				if wb != nil {
 					// totally not worth it now:
@@ -622,12 +741,22 @@ func (tv *TableView) UpdateSliceGrid() {
 					vvb.ViewSig.ConnectOnly(tv.This(), // todo: do we need this?
 						func(recv, send ki.Ki, sig int64, data interface{}) {
 							tvv, _ := recv.Embed(KiT_TableView).(*TableView)
+							svb := send.(ValueView).AsValueViewBase()
+							if tvv.Model != nil {
+								tvv.modelSetValue(send.(ValueView))
+							}
+							if svb.Widget != nil {
+								if row, ok := svb.Widget.AsNode2D().Prop("tv-row").(int); ok {
+									tvv.UpdateRowValid(row)
+								}
+							}
 							tvv.SetChanged()
 						})
 				}
 			}
-			tv.This().(SliceViewer).StyleRow(tv.SliceNPVal, widg, si, fli, vv)
+			tv.This().(SliceViewer).StyleRow(svnp, widg, sidx, fli, vv)
 		}
+		tv.UpdateRowValid(i)
 
 		if !tv.IsInactive() {
 			cidx := ridx + tv.NVisFields + idxOff
@@ -668,13 +797,62 @@ func (tv *TableView) UpdateSliceGrid() {
 		}
 	}
 
-	if tv.SelField != "" && tv.SelVal != nil {
+	if tv.Model == nil && tv.SelField != "" && tv.SelVal != nil {
 		tv.SelectedIdx, _ = StructSliceIdxByValue(tv.Slice, tv.SelField, tv.SelVal)
 	}
 	if tv.IsInactive() && tv.SelectedIdx >= 0 {
 		tv.SelectIdx(tv.SelectedIdx)
 	}
 	tv.UpdateScroll()
+	if tv.Model != nil {
+		tv.modelFetch()
+	}
+}
+
+// UpdateRowValid checks the struct at given display row with ValidateStruct,
+// and shows the errors on the fields (see SetValidErrStyle), along with any
+// errors from values that were not set (see ValueViewBase.CheckValid), and
+// the error for the struct as a whole on the index label.  With a Model,
+// the rows are not structs, so nothing is checked -- its SetValue method
+// can reject invalid values instead.
+func (tv *TableView) UpdateRowValid(row int) {
+	if tv.IsInactive() || row < 0 || row >= tv.DispRows {
+		return
+	}
+	var flds map[string]error
+	var err error
+	if tv.Model == nil {
+		sidx := tv.SliceIdx(tv.StartIdx + row)
+		if sidx < 0 || sidx >= tv.SliceNPVal.Len() {
+			return
+		}
+		flds, err = ValidateStruct(kit.OnePtrUnderlyingValue(tv.SliceNPVal.Index(sidx)).Interface())
+	}
+	sg := tv.SliceGrid()
+	nWidgPerRow, idxOff := tv.RowWidgetNs()
+	ridx := row * nWidgPerRow
+	if ridx+idxOff+tv.NVisFields > sg.NumChildren() || (row+1)*tv.NVisFields > len(tv.Values) {
+		return
+	}
+	updt := sg.UpdateStart()
+	defer sg.UpdateEnd(updt)
+	for fli := 0; fli < tv.NVisFields; fli++ {
+		vv := tv.Values[row*tv.NVisFields+fli]
+		widg, ok := sg.Kids[ridx+idxOff+fli].(gi.Node2D)
+		if vv == nil || !ok {
+			continue
+		}
+		ferr := vv.AsValueViewBase().ValidErr
+		if ferr == nil {
+			ferr = flds[tv.VisFields[fli].Name]
+		}
+		SetValidErrStyle(widg, ferr)
+	}
+	if tv.ShowIndex {
+		if idxlab, ok := sg.Kids[ridx].(gi.Node2D); ok {
+			SetValidErrStyle(idxlab, err)
+		}
+	}
 }
 
 func (tv *TableView) StyleRow(svnp reflect.Value, widg gi.Node2D, idx, fidx int, vv ValueView) {
@@ -683,8 +861,27 @@ func (tv *TableView) StyleRow(svnp reflect.Value, widg gi.Node2D, idx, fidx int,
 	}
 }
 
+// UpdtSliceSize updates and returns the size of the view, which is the
+// number of rows that pass the filters, and sets SliceSize -- the rows are
+// sorted and filtered again if the size of the slice has changed
+func (tv *TableView) UpdtSliceSize() int {
+	if tv.Model != nil {
+		tv.SliceSize = tv.Model.NumRows()
+		return tv.SliceSize
+	}
+	if tv.Idxs != nil && tv.SliceNPVal.Len() != len(tv.viewIdxs) {
+		tv.FilterSlice()
+	}
+	if tv.Idxs == nil {
+		return tv.SliceViewBase.UpdtSliceSize()
+	}
+	tv.SliceSize = len(tv.Idxs)
+	return tv.SliceSize
+}
+
 // SliceNewAt inserts a new blank element at given index in the slice -- -1
-// means the end
+// means the end.  When sorting or filtering, it is shown in place of the row
+// that was at that index (or at the end), until the rows are sorted again.
 func (tv *TableView) SliceNewAt(idx int) {
 	wupdt := tv.TopUpdateStart()
 	defer tv.TopUpdateEnd(wupdt)
@@ -692,10 +889,16 @@ func (tv *TableView) SliceNewAt(idx int) {
 	updt := tv.UpdateStart()
 	defer tv.UpdateEnd(updt)
 
-	kit.SliceNewAt(tv.Slice, idx)
-	if idx < 0 {
-		idx = tv.SliceSize
+	if sz := tv.SliceNPVal.Len(); idx < 0 || idx > sz {
+		idx = sz
+	}
+	vidx := tv.ViewIdx(idx)
+	if vidx < 0 {
+		vidx = len(tv.Idxs)
 	}
+	kit.SliceNewAt(tv.Slice, idx)
+	tv.SliceNPVal = kit.NonPtrValue(reflect.ValueOf(tv.Slice))
+	tv.idxsInsert(vidx, idx)
 
 	if tv.TmpSave != nil {
 		tv.TmpSave.SaveTmp()
@@ -721,6 +924,8 @@ func (tv *TableView) SliceDeleteAt(idx int, doupdt bool) {
 	defer tv.UpdateEnd(updt)
 
 	kit.SliceDeleteAt(tv.Slice, idx)
+	tv.SliceNPVal = kit.NonPtrValue(reflect.ValueOf(tv.Slice))
+	tv.idxsDelete(idx)
 
 	if tv.TmpSave != nil {
 		tv.TmpSave.SaveTmp()
@@ -735,18 +940,27 @@ func (tv *TableView) SliceDeleteAt(idx int, doupdt bool) {
 	tv.SliceViewSig.Emit(tv.This(), int64(SliceViewDeleted), idx)
 }
 
-// SortSlice sorts the slice according to current settings
+// SortSlice sorts the rows of the view according to current settings -- by
+// each of the sort keys in Prefs in turn, the first of which follows SortIdx
+// and SortDesc -- and then applies the filters again (see FilterSlice).  The
+// slice itself is not changed.  A Model is sorted by itself if it is a
+// TableModelSorter, when the keys change.
 func (tv *TableView) SortSlice() {
-	if tv.SortIdx < 0 || tv.SortIdx >= len(tv.VisFields) {
+	tv.syncSortKeys()
+	if tv.Model != nil {
+		tv.modelSortRows()
 		return
 	}
-	rawIdx := tv.VisFields[tv.SortIdx].Index
-	kit.StructSliceSort(tv.Slice, rawIdx, !tv.SortDesc)
+	tv.FilterSlice()
 }
 
 // SortSliceAction sorts the slice for given field index -- toggles ascending
-// vs. descending if already sorting on this dimension
+// vs. descending if already sorting on this dimension -- if shift is held
+// down, the field is added as a further sort key (see AddSortKey)
 func (tv *TableView) SortSliceAction(fldIdx int) {
+	if _, ok := tv.Model.(TableModelSorter); tv.Model != nil && !ok {
+		return
+	}
 	oswin.TheApp.Cursor(tv.Viewport.Win.OSWin).Push(cursor.Wait)
 	defer oswin.TheApp.Cursor(tv.Viewport.Win.OSWin).Pop()
 
@@ -756,47 +970,31 @@ func (tv *TableView) SortSliceAction(fldIdx int) {
 	updt := tv.UpdateStart()
 	sgh := tv.SliceHeader()
 	sgh.SetFullReRender()
-	_, idxOff := tv.RowWidgetNs()
 
-	ascending := true
+	add := key.HasAnyModifierBits(tv.Viewport.Win.EventMgr.LastModBits, key.Shift)
+	tv.syncSortKeys()
+	tv.AddSortKey(fldIdx, add)
+	tv.ConfigHeaderSort()
 
-	for fli := 0; fli < tv.NVisFields; fli++ {
-		hdr := sgh.Child(idxOff + fli).(*gi.Action)
-		if fli == fldIdx {
-			if tv.SortIdx == fli {
-				tv.SortDesc = !tv.SortDesc
-				ascending = !tv.SortDesc
-			} else {
-				tv.SortDesc = false
-			}
-			if ascending {
-				hdr.SetIcon("wedge-up")
-			} else {
-				hdr.SetIcon("wedge-down")
-			}
-		} else {
-			hdr.SetIcon("none")
-		}
-	}
-
-	tv.SortIdx = fldIdx
 	tv.SortSlice()
 	tv.UpdateSliceGrid()
 	tv.UpdateEnd(updt)
+	tv.SavePrefs()
 }
 
 // ConfigToolbar configures the toolbar actions
 func (tv *TableView) ConfigToolbar() {
-	if kit.IfaceIsNil(tv.Slice) || tv.IsInactive() {
+	src := tv.source()
+	if kit.IfaceIsNil(src) || tv.IsInactive() {
 		return
 	}
-	if tv.ToolbarSlice == tv.Slice {
+	if tv.ToolbarSlice == src {
 		return
 	}
 	tb := tv.ToolBar()
-	ndef := 2 // number of default actions
+	ndef := 3 // number of default actions
 	if tv.isArray || tv.IsInactive() || tv.NoAdd {
-		ndef = 1
+		ndef = 2
 	}
 	if len(*tb.Children()) < ndef {
 		tb.SetStretchMaxWidth()
@@ -805,7 +1003,11 @@ func (tv *TableView) ConfigToolbar() {
 				tvv := recv.Embed(KiT_TableView).(*TableView)
 				tvv.UpdateSliceGrid()
 			})
-		if ndef > 1 {
+		cols := tb.AddAction(gi.ActOpts{Label: "Columns", Tooltip: "show or hide columns, and reset the columns, sorting and filters"}, nil, nil)
+		cols.MakeMenuFunc = func(obj ki.Ki, m *gi.Menu) {
+			tv.ColsMenu(m)
+		}
+		if ndef > 2 {
 			tb.AddAction(gi.ActOpts{Label: "Add", Icon: "plus", Tooltip: "add a new element to the table"},
 				tv.This(), func(recv, send ki.Ki, sig int64, data interface{}) {
 					tvv := recv.Embed(KiT_TableView).(*TableView)
@@ -819,11 +1021,11 @@ func (tv *TableView) ConfigToolbar() {
 			tb.DeleteChildAtIndex(i, ki.DestroyKids)
 		}
 	}
-	if HasToolBarView(tv.Slice) {
-		ToolBarView(tv.Slice, tv.Viewport, tb)
+	if HasToolBarView(src) {
+		ToolBarView(src, tv.Viewport, tb)
 		tb.SetFullReRender()
 	}
-	tv.ToolbarSlice = tv.Slice
+	tv.ToolbarSlice = src
 }
 
 // SortFieldName returns the name of the field being sorted, along with :up or
@@ -861,6 +1063,7 @@ func (tv *TableView) SetSortFieldName(nm string) {
 			tv.SortDesc = false
 		}
 	}
+	tv.syncSortKeys()
 }
 
 func (tv *TableView) Layout2D(parBBox image.Rectangle, iter int) bool {
@@ -870,9 +1073,77 @@ func (tv *TableView) Layout2D(parBBox image.Rectangle, iter int) bool {
 	}
 	tv.LayoutHeader()
 	tv.SliceHeader().Layout2D(parBBox, iter)
+	if fb := tv.FilterBar(); fb != nil {
+		fb.Layout2D(parBBox, iter)
+	}
 	return redo
 }
 
+func (tv *TableView) ConnectEvents2D() {
+	tv.SliceViewBaseEvents()
+	tv.TableViewEvents()
+	if tv.Model != nil { // rows cannot be dragged or dropped
+		tv.DisconnectEvent(oswin.DNDEvent, gi.RegPri)
+	}
+}
+
+// filterFocus returns true if one of the filter fields has the focus, in
+// which case keys are not used for navigating and editing the rows
+func (tv *TableView) filterFocus() bool {
+	fb := tv.FilterBar()
+	return fb != nil && fb.ContainsFocus()
+}
+
+// TableViewEvents connects the events for sorting, moving and resizing the
+// columns by their headers, on top of the SliceViewBase events
+func (tv *TableView) TableViewEvents() {
+	tv.ConnectEvent(oswin.MouseDragEvent, gi.RegPri, func(recv, send ki.Ki, sig int64, d interface{}) {
+		me := d.(*mouse.DragEvent)
+		tvv := recv.Embed(KiT_TableView).(*TableView)
+		if tvv.HeaderDrag(me.From, me.Where) {
+			me.SetProcessed()
+		}
+	})
+	tv.ConnectEvent(oswin.MouseEvent, gi.HiPri, func(recv, send ki.Ki, sig int64, d interface{}) {
+		me := d.(*mouse.Event)
+		tvv := recv.Embed(KiT_TableView).(*TableView)
+		switch {
+		case me.Button == mouse.Left && me.Action == mouse.Press:
+			tvv.colDrag = nil
+		case me.Button == mouse.Left && me.Action == mouse.Release:
+			if tvv.colDrag != nil {
+				tvv.colDrag = nil
+				tvv.SavePrefs()
+			}
+		case me.Button == mouse.Right && me.Action == mouse.Press:
+			if _, _, ok := tvv.HeaderColAt(me.Where); ok {
+				tvv.ColsCtxtMenu(me.Where)
+				me.SetProcessed()
+			}
+		}
+	})
+	// replaces the key handlers of SliceViewBaseEvents, skipping keys typed in filters
+	if tv.IsInactive() {
+		if tv.InactKeyNav {
+			tv.ConnectEvent(oswin.KeyChordEvent, gi.RegPri, func(recv, send ki.Ki, sig int64, d interface{}) {
+				tvv := recv.Embed(KiT_TableView).(*TableView)
+				if tvv.filterFocus() {
+					return
+				}
+				tvv.KeyInputInactive(d.(*key.ChordEvent))
+			})
+		}
+	} else {
+		tv.ConnectEvent(oswin.KeyChordEvent, gi.HiPri, func(recv, send ki.Ki, sig int64, d interface{}) {
+			tvv := recv.Embed(KiT_TableView).(*TableView)
+			if tvv.filterFocus() {
+				return
+			}
+			tvv.KeyInputActive(d.(*key.ChordEvent))
+		})
+	}
+}
+
 // RowFirstVisWidget returns the first visible widget for given row (could be
 // index or not) -- false if out of range
 func (tv *TableView) RowFirstVisWidget(row int) (*gi.WidgetBase, bool) {
@@ -958,7 +1229,7 @@ func (tv *TableView) SelectRowWidgets(row int, sel bool) {
 func (tv *TableView) SelectFieldVal(fld, val string) bool {
 	tv.SelField = fld
 	tv.SelVal = val
-	if tv.SelField != "" && tv.SelVal != nil {
+	if tv.Model == nil && tv.SelField != "" && tv.SelVal != nil {
 		idx, _ := StructSliceIdxByValue(tv.Slice, tv.SelField, tv.SelVal)
 		if idx >= 0 {
 			tv.ScrollToIdx(idx)
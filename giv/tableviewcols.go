@@ -0,0 +1,973 @@
+// Copyright (c) 2020, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package giv
+
+import (
+	"encoding/json"
+	"fmt"
+	"image"
+	"io/ioutil"
+	"log"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/chewxy/math32"
+	"github.com/goki/gi/gi"
+	"github.com/goki/gi/oswin"
+	"github.com/goki/gi/units"
+	"github.com/goki/ki/ints"
+	"github.com/goki/ki/ki"
+	"github.com/goki/ki/kit"
+)
+
+////////////////////////////////////////////////////////////////////////////////////////
+//  TableViewPrefs
+
+// TableSortKey is one of the keys for sorting the rows of a TableView, by
+// the values of the given field
+type TableSortKey struct {
+	Field string `desc:"name of the field to sort by"`
+	Desc  bool   `desc:"sort in descending order"`
+}
+
+// TableViewPrefs are the settings of a TableView that the user can change
+// while viewing it: the order, widths and visibility of the columns, the
+// sort keys and the column filters.  They are saved per table, under its
+// PrefsKey, in TableViewPrefsAll.
+type TableViewPrefs struct {
+	Order   []string           `desc:"names of the fields in the order of their columns, as rearranged by dragging the column headers -- fields not listed follow in struct order"`
+	Hidden  []string           `desc:"names of the fields whose columns are hidden, using the column menu"`
+	Widths  map[string]float32 `desc:"widths of the columns that have been resized by dragging the right edge of their headers, in ch units, by field name"`
+	Sort    []TableSortKey     `desc:"keys for sorting the rows, in order of priority -- clicking on a column header sorts by it, and shift-clicking adds it as a further key"`
+	Filters map[string]string  `desc:"filters on the values of the fields, by field name, set in the filter row under the header -- only the rows matching all of them are shown -- see TableFilterMatch"`
+}
+
+// IsEmpty returns true if all the settings are the defaults
+func (tp *TableViewPrefs) IsEmpty() bool {
+	return len(tp.Order) == 0 && len(tp.Hidden) == 0 && len(tp.Widths) == 0 && len(tp.Sort) == 0 && len(tp.Filters) == 0
+}
+
+// Copy returns a deep copy of the prefs
+func (tp *TableViewPrefs) Copy() TableViewPrefs {
+	cp := TableViewPrefs{}
+	cp.Order = append(cp.Order, tp.Order...)
+	cp.Hidden = append(cp.Hidden, tp.Hidden...)
+	cp.Sort = append(cp.Sort, tp.Sort...)
+	if len(tp.Widths) > 0 {
+		cp.Widths = make(map[string]float32, len(tp.Widths))
+		for k, v := range tp.Widths {
+			cp.Widths[k] = v
+		}
+	}
+	if len(tp.Filters) > 0 {
+		cp.Filters = make(map[string]string, len(tp.Filters))
+		for k, v := range tp.Filters {
+			cp.Filters[k] = v
+		}
+	}
+	return cp
+}
+
+// IsHidden returns true if the column for given field is hidden
+func (tp *TableViewPrefs) IsHidden(fld string) bool {
+	for _, h := range tp.Hidden {
+		if h == fld {
+			return true
+		}
+	}
+	return false
+}
+
+// TableViewPrefsMap is a map of TableViewPrefs by the PrefsKey of the table
+type TableViewPrefsMap map[string]*TableViewPrefs
+
+// TableViewPrefsAll are the saved TableViewPrefs of all the tables
+var TableViewPrefsAll = TableViewPrefsMap{}
+
+// TableViewPrefsFileName is the name of the file in the GoGi prefs directory
+// where TableViewPrefsAll are saved
+var TableViewPrefsFileName = "tableview_prefs.json"
+
+// tableViewPrefsOpened records if TableViewPrefsAll have been opened
+var tableViewPrefsOpened = false
+
+// OpenJSON opens table prefs from a JSON-formatted file.
+func (tm *TableViewPrefsMap) OpenJSON(filename string) error {
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, tm)
+}
+
+// SaveJSON saves table prefs to a JSON-formatted file.
+func (tm *TableViewPrefsMap) SaveJSON(filename string) error {
+	b, err := json.MarshalIndent(tm, "", "  ")
+	if err != nil {
+		log.Println(err) // unlikely
+		return err
+	}
+	err = ioutil.WriteFile(filename, b, 0644)
+	if err != nil {
+		log.Println(err)
+	}
+	return err
+}
+
+// OpenTableViewPrefs opens TableViewPrefsAll from the GoGi prefs directory
+func OpenTableViewPrefs() {
+	tableViewPrefsOpened = true
+	if oswin.TheApp == nil {
+		return
+	}
+	pnm := filepath.Join(oswin.TheApp.GoGiPrefsDir(), TableViewPrefsFileName)
+	TableViewPrefsAll.OpenJSON(pnm)
+}
+
+// SaveTableViewPrefs saves TableViewPrefsAll to the GoGi prefs directory
+func SaveTableViewPrefs() {
+	if oswin.TheApp == nil {
+		return
+	}
+	pnm := filepath.Join(oswin.TheApp.GoGiPrefsDir(), TableViewPrefsFileName)
+	TableViewPrefsAll.SaveJSON(pnm)
+}
+
+// PrefsKeyName returns the key for saving the prefs of this table: PrefsKey
+// if set, else the prefs-key property -- if neither is set, the prefs are
+// neither loaded nor saved
+func (tv *TableView) PrefsKeyName() string {
+	if tv.PrefsKey != "" {
+		return tv.PrefsKey
+	}
+	if pk, err := tv.PropTry("prefs-key"); err == nil {
+		return kit.ToString(pk)
+	}
+	return ""
+}
+
+// LoadPrefs sets Prefs to those saved for this table in TableViewPrefsAll,
+// or to the defaults if none or if there is no PrefsKeyName
+func (tv *TableView) LoadPrefs() {
+	tv.Prefs = TableViewPrefs{}
+	key := tv.PrefsKeyName()
+	if key == "" {
+		return
+	}
+	if !tableViewPrefsOpened {
+		OpenTableViewPrefs()
+	}
+	if tp, ok := TableViewPrefsAll[key]; ok && tp != nil {
+		tv.Prefs = tp.Copy()
+	}
+}
+
+// SavePrefs saves the Prefs for this table into TableViewPrefsAll, and saves
+// those to the prefs file -- does nothing if there is no PrefsKeyName
+func (tv *TableView) SavePrefs() {
+	key := tv.PrefsKeyName()
+	if key == "" {
+		return
+	}
+	if tv.Prefs.IsEmpty() {
+		if _, has := TableViewPrefsAll[key]; !has {
+			return
+		}
+		delete(TableViewPrefsAll, key)
+	} else {
+		tp := tv.Prefs.Copy()
+		TableViewPrefsAll[key] = &tp
+	}
+	SaveTableViewPrefs()
+}
+
+////////////////////////////////////////////////////////////////////////////////////////
+//  Sorting
+
+// tableTimeType is the type of time.Time
+var tableTimeType = reflect.TypeOf(time.Time{})
+
+// tableCompare returns -1, 0 or 1 as a is less than, equal to or greater
+// than b, which are values of the same field: numbers and times are compared
+// by value, and everything else as lowercase strings
+func tableCompare(a, b reflect.Value) int {
+	switch {
+	case a.Kind() >= reflect.Int && a.Kind() <= reflect.Int64:
+		av, bv := a.Int(), b.Int()
+		if av < bv {
+			return -1
+		} else if av > bv {
+			return 1
+		}
+		return 0
+	case a.Kind() >= reflect.Uint && a.Kind() <= reflect.Uintptr:
+		av, bv := a.Uint(), b.Uint()
+		if av < bv {
+			return -1
+		} else if av > bv {
+			return 1
+		}
+		return 0
+	case a.Kind() == reflect.Float32 || a.Kind() == reflect.Float64:
+		av, bv := a.Float(), b.Float()
+		if av < bv {
+			return -1
+		} else if av > bv {
+			return 1
+		}
+		return 0
+	case a.Kind() == reflect.Bool:
+		av, bv := a.Bool(), b.Bool()
+		if av == bv {
+			return 0
+		} else if bv {
+			return -1
+		}
+		return 1
+	}
+	if a.Kind() == reflect.Struct && a.Type().ConvertibleTo(tableTimeType) { // includes FileTime etc
+		at := a.Convert(tableTimeType).Interface().(time.Time)
+		bt := b.Convert(tableTimeType).Interface().(time.Time)
+		if at.Before(bt) {
+			return -1
+		} else if bt.Before(at) {
+			return 1
+		}
+		return 0
+	}
+	return strings.Compare(strings.ToLower(kit.ToString(a.Interface())), strings.ToLower(kit.ToString(b.Interface())))
+}
+
+// StructSliceSortKeys sorts a slice of structs (or pointers to them) stably
+// by the values of the fields with the given indexes, in order of priority,
+// each in descending order if the corresponding desc is true
+func StructSliceSortKeys(struSlice interface{}, fldIdxs [][]int, desc []bool) {
+	svnp := kit.NonPtrValue(reflect.ValueOf(struSlice))
+	if svnp.Len() < 2 || len(fldIdxs) == 0 {
+		return
+	}
+	sort.SliceStable(svnp.Interface(), structSliceLess(svnp, fldIdxs, desc))
+}
+
+// StructSliceSortIdxs sorts the given indexes into a slice of structs (or
+// pointers to them) stably, as StructSliceSortKeys would sort the structs at
+// those indexes, without changing the slice itself
+func StructSliceSortIdxs(struSlice interface{}, idxs []int, fldIdxs [][]int, desc []bool) {
+	svnp := kit.NonPtrValue(reflect.ValueOf(struSlice))
+	if len(idxs) < 2 || len(fldIdxs) == 0 {
+		return
+	}
+	less := structSliceLess(svnp, fldIdxs, desc)
+	sort.SliceStable(idxs, func(i, j int) bool {
+		return less(idxs[i], idxs[j])
+	})
+}
+
+// structSliceLess returns the less function for StructSliceSortKeys, which
+// compares the structs at the given indexes of the slice
+func structSliceLess(svnp reflect.Value, fldIdxs [][]int, desc []bool) func(i, j int) bool {
+	return func(i, j int) bool {
+		ival := kit.OnePtrUnderlyingValue(svnp.Index(i)).Elem()
+		jval := kit.OnePtrUnderlyingValue(svnp.Index(j)).Elem()
+		for k, fi := range fldIdxs {
+			c := tableCompare(ival.FieldByIndex(fi), jval.FieldByIndex(fi))
+			if c == 0 {
+				continue
+			}
+			if desc[k] {
+				return c > 0
+			}
+			return c < 0
+		}
+		return false
+	}
+}
+
+// ColFieldByName returns the field of given name among the fields that can be
+// shown as columns, whether visible or hidden
+func (tv *TableView) ColFieldByName(nm string) (reflect.StructField, bool) {
+	for _, fld := range tv.AllFields {
+		if fld.Name == nm {
+			return fld, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+// syncSortKeys makes the first sort key follow SortIdx and SortDesc, which
+// can be set directly
+func (tv *TableView) syncSortKeys() {
+	if tv.SortIdx < 0 || tv.SortIdx >= tv.NVisFields {
+		tv.Prefs.Sort = nil
+		return
+	}
+	pk := TableSortKey{Field: tv.VisFields[tv.SortIdx].Name, Desc: tv.SortDesc}
+	if len(tv.Prefs.Sort) == 0 || tv.Prefs.Sort[0] != pk {
+		tv.Prefs.Sort = []TableSortKey{pk}
+	}
+}
+
+// sortIdxFromKeys sets SortIdx and SortDesc from the first sort key
+func (tv *TableView) sortIdxFromKeys() {
+	tv.SortIdx = -1
+	tv.SortDesc = false
+	if len(tv.Prefs.Sort) == 0 {
+		return
+	}
+	pk := tv.Prefs.Sort[0]
+	for fli, fld := range tv.VisFields {
+		if fld.Name == pk.Field {
+			tv.SortIdx = fli
+			tv.SortDesc = pk.Desc
+			return
+		}
+	}
+	tv.Prefs.Sort = nil // primary key not visible
+}
+
+// SortKeyIdx returns the position in the sort keys of given field, or -1
+func (tv *TableView) SortKeyIdx(fld string) int {
+	for i, sk := range tv.Prefs.Sort {
+		if sk.Field == fld {
+			return i
+		}
+	}
+	return -1
+}
+
+// AddSortKey sorts by the field with given index in VisFields: if add is
+// false, it becomes the only sort key, and otherwise it is added as a
+// further key -- in either case, the direction is toggled if already sorting
+// by that field
+func (tv *TableView) AddSortKey(fldIdx int, add bool) {
+	if fldIdx < 0 || fldIdx >= tv.NVisFields {
+		return
+	}
+	nm := tv.VisFields[fldIdx].Name
+	ski := tv.SortKeyIdx(nm)
+	switch {
+	case !add && ski == 0:
+		tv.Prefs.Sort = []TableSortKey{{Field: nm, Desc: !tv.Prefs.Sort[0].Desc}}
+	case !add:
+		tv.Prefs.Sort = []TableSortKey{{Field: nm}}
+	case ski >= 0:
+		tv.Prefs.Sort[ski].Desc = !tv.Prefs.Sort[ski].Desc
+	default:
+		tv.Prefs.Sort = append(tv.Prefs.Sort, TableSortKey{Field: nm})
+	}
+	tv.sortIdxFromKeys()
+}
+
+// ConfigHeaderSort sets the icons and labels of the column headers to show
+// the sort keys, which are numbered when there is more than one
+func (tv *TableView) ConfigHeaderSort() {
+	sgh := tv.SliceHeader()
+	_, idxOff := tv.RowWidgetNs()
+	for fli := 0; fli < tv.NVisFields; fli++ {
+		fld := tv.VisFields[fli]
+		hdr := sgh.Child(idxOff + fli).(*gi.Action)
+		ski := tv.SortKeyIdx(fld.Name)
+		switch {
+		case ski < 0:
+			hdr.SetIcon("none")
+		case tv.Prefs.Sort[ski].Desc:
+			hdr.SetIcon("wedge-down")
+		default:
+			hdr.SetIcon("wedge-up")
+		}
+		if ski >= 0 && len(tv.Prefs.Sort) > 1 {
+			hdr.SetText(fmt.Sprintf("%v %d", fld.Name, ski+1))
+		} else {
+			hdr.SetText(fld.Name)
+		}
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////////////
+//  Filtering
+
+// TableFieldEnum returns true if given field type is an enum whose values
+// can be chosen as a set in a filter -- bit flags are filtered as text
+func TableFieldEnum(typ reflect.Type) bool {
+	return kit.Enums.TypeRegistered(typ) && !kit.Enums.IsBitFlag(typ)
+}
+
+// TableFilterMatch returns whether given value of a field matches given
+// filter, which is interpreted according to the type of the value: for
+// numbers, it is a range lo..hi (either end can be omitted), a comparison
+// such as >x or <=x, or a value to equal; for enums, a comma-separated set
+// of value names; and otherwise a case-insensitive substring of the value as
+// a string.  An empty filter matches everything.
+func TableFilterMatch(val interface{}, flt string) bool {
+	flt = strings.TrimSpace(flt)
+	if flt == "" {
+		return true
+	}
+	typ := reflect.TypeOf(val)
+	if typ != nil && TableFieldEnum(typ) {
+		nm := kit.EnumIfaceToString(val)
+		for _, s := range strings.Split(flt, ",") {
+			if strings.TrimSpace(s) == nm {
+				return true
+			}
+		}
+		return false
+	}
+	if typ != nil {
+		switch k := typ.Kind(); {
+		case k >= reflect.Int && k <= reflect.Float64:
+			v, _ := kit.ToFloat(val)
+			if m, ok := tableNumMatch(v, flt); ok {
+				return m
+			}
+		}
+	}
+	return strings.Contains(strings.ToLower(kit.ToString(val)), strings.ToLower(flt))
+}
+
+// tableNumMatch returns whether given number matches given numeric filter,
+// and false for ok if the filter is not numeric
+func tableNumMatch(v float64, flt string) (match, ok bool) {
+	if i := strings.Index(flt, ".."); i >= 0 {
+		lo := strings.TrimSpace(flt[:i])
+		hi := strings.TrimSpace(flt[i+2:])
+		match = true
+		if lo != "" {
+			l, err := strconv.ParseFloat(lo, 64)
+			if err != nil {
+				return false, false
+			}
+			match = match && v >= l
+		}
+		if hi != "" {
+			h, err := strconv.ParseFloat(hi, 64)
+			if err != nil {
+				return false, false
+			}
+			match = match && v <= h
+		}
+		return match, true
+	}
+	op := ""
+	for _, o := range []string{">=", "<=", "!=", ">", "<", "="} {
+		if strings.HasPrefix(flt, o) {
+			op = o
+			flt = strings.TrimSpace(flt[len(o):])
+			break
+		}
+	}
+	x, err := strconv.ParseFloat(flt, 64)
+	if err != nil {
+		return false, false
+	}
+	switch op {
+	case ">=":
+		return v >= x, true
+	case "<=":
+		return v <= x, true
+	case "!=":
+		return v != x, true
+	case ">":
+		return v > x, true
+	case "<":
+		return v < x, true
+	}
+	return v == x, true
+}
+
+// HasFilters returns true if any of the fields have a filter
+func (tv *TableView) HasFilters() bool {
+	for _, flt := range tv.Prefs.Filters {
+		if flt != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// FilterSlice sets Idxs to the indexes of the rows of the slice that match
+// all of the filters, in the order of the sort keys in Prefs (see SortSlice)
+// -- Idxs is nil if all of the rows are shown in the order of the slice,
+// which is never changed itself
+func (tv *TableView) FilterSlice() {
+	tv.Idxs = nil
+	tv.viewIdxs = nil
+	if kit.IfaceIsNil(tv.Slice) {
+		return
+	}
+	var sflds [][]int
+	var desc []bool
+	for _, sk := range tv.Prefs.Sort {
+		if fld, has := tv.ColFieldByName(sk.Field); has {
+			sflds = append(sflds, fld.Index)
+			desc = append(desc, sk.Desc)
+		}
+	}
+	hasFlt := tv.HasFilters()
+	if len(sflds) == 0 && !hasFlt {
+		return
+	}
+	sz := tv.SliceNPVal.Len()
+	idxs := make([]int, sz)
+	for i := range idxs {
+		idxs[i] = i
+	}
+	StructSliceSortIdxs(tv.Slice, idxs, sflds, desc)
+	if hasFlt {
+		var flds []reflect.StructField
+		var flts []string
+		for _, fld := range tv.AllFields {
+			if flt := tv.Prefs.Filters[fld.Name]; flt != "" {
+				flds = append(flds, fld)
+				flts = append(flts, flt)
+			}
+		}
+		fidxs := idxs[:0]
+		for _, i := range idxs {
+			val := kit.OnePtrUnderlyingValue(tv.SliceNPVal.Index(i)).Elem()
+			match := true
+			for fi, fld := range flds {
+				if !TableFilterMatch(val.FieldByIndex(fld.Index).Interface(), flts[fi]) {
+					match = false
+					break
+				}
+			}
+			if match {
+				fidxs = append(fidxs, i)
+			}
+		}
+		idxs = fidxs
+	}
+	tv.Idxs = idxs
+	tv.updtViewIdxs(sz)
+}
+
+// updtViewIdxs updates the index in the view of each of the given number of
+// rows in the slice, from Idxs
+func (tv *TableView) updtViewIdxs(sz int) {
+	if cap(tv.viewIdxs) >= sz {
+		tv.viewIdxs = tv.viewIdxs[:sz]
+	} else {
+		tv.viewIdxs = make([]int, sz)
+	}
+	for i := range tv.viewIdxs {
+		tv.viewIdxs[i] = -1
+	}
+	for vi, si := range tv.Idxs {
+		tv.viewIdxs[si] = vi
+	}
+}
+
+// SliceIdx returns the index in the slice of given index in the view (i.e.,
+// StartIdx + row), which differ when the rows are sorted or filtered out --
+// an index past the end of the view maps to the end of the slice
+func (tv *TableView) SliceIdx(idx int) int {
+	if tv.Idxs == nil || idx < 0 {
+		return idx
+	}
+	if idx >= len(tv.Idxs) {
+		return len(tv.viewIdxs)
+	}
+	return tv.Idxs[idx]
+}
+
+// ViewIdx returns the index in the view of given index in the slice, or -1
+// if that row is filtered out -- an index past the end of the slice maps to
+// the end of the view
+func (tv *TableView) ViewIdx(sidx int) int {
+	if tv.Idxs == nil || sidx < 0 {
+		return sidx
+	}
+	if sidx >= len(tv.viewIdxs) {
+		return len(tv.Idxs)
+	}
+	return tv.viewIdxs[sidx]
+}
+
+// idxsInsert records the insertion of a row in the slice at sidx, which is
+// shown at idx in the view
+func (tv *TableView) idxsInsert(idx, sidx int) {
+	if tv.Idxs == nil {
+		return
+	}
+	for i, si := range tv.Idxs {
+		if si >= sidx {
+			tv.Idxs[i]++
+		}
+	}
+	idx = ints.MinInt(ints.MaxInt(idx, 0), len(tv.Idxs))
+	tv.Idxs = append(tv.Idxs, 0)
+	copy(tv.Idxs[idx+1:], tv.Idxs[idx:])
+	tv.Idxs[idx] = sidx
+	tv.updtViewIdxs(len(tv.viewIdxs) + 1)
+}
+
+// idxsDelete records the deletion of the row at sidx in the slice
+func (tv *TableView) idxsDelete(sidx int) {
+	if tv.Idxs == nil || sidx < 0 || sidx >= len(tv.viewIdxs) {
+		return
+	}
+	if vi := tv.viewIdxs[sidx]; vi >= 0 {
+		tv.Idxs = append(tv.Idxs[:vi], tv.Idxs[vi+1:]...)
+	}
+	for i, si := range tv.Idxs {
+		if si > sidx {
+			tv.Idxs[i]--
+		}
+	}
+	tv.updtViewIdxs(len(tv.viewIdxs) - 1)
+}
+
+// SetFilter sets the filter for the field of given name (see
+// TableFilterMatch), so that only the matching rows are shown, and saves it
+// in the prefs -- an empty filter removes it
+func (tv *TableView) SetFilter(fld, flt string) {
+	flt = strings.TrimSpace(flt)
+	if tv.Prefs.Filters[fld] == flt {
+		return
+	}
+	if flt == "" {
+		delete(tv.Prefs.Filters, fld)
+	} else {
+		if tv.Prefs.Filters == nil {
+			tv.Prefs.Filters = make(map[string]string)
+		}
+		tv.Prefs.Filters[fld] = flt
+	}
+	tv.ColsChanged()
+}
+
+// ClearFilters removes all of the filters
+func (tv *TableView) ClearFilters() {
+	if !tv.HasFilters() {
+		return
+	}
+	tv.Prefs.Filters = nil
+	tv.ColsChanged()
+}
+
+// ToggleFilterEnum adds or removes given enum value name from the set in the
+// filter for the field of given name
+func (tv *TableView) ToggleFilterEnum(fld, val string) {
+	var set []string
+	has := false
+	for _, s := range strings.Split(tv.Prefs.Filters[fld], ",") {
+		s = strings.TrimSpace(s)
+		switch {
+		case s == "":
+		case s == val:
+			has = true
+		default:
+			set = append(set, s)
+		}
+	}
+	if !has {
+		set = append(set, val)
+	}
+	tv.SetFilter(fld, strings.Join(set, ","))
+}
+
+// FilterBar returns the ToolBar with the filter fields under the header, or
+// nil if not showing filters
+func (tv *TableView) FilterBar() *gi.ToolBar {
+	if !tv.ShowFilters {
+		return nil
+	}
+	return tv.SliceFrame().ChildByName("filters", 1).(*gi.ToolBar)
+}
+
+// ConfigFilters configures the filter row under the header, with a text
+// field for the filter of each column, or a menu of the values for enums
+func (tv *TableView) ConfigFilters() {
+	sgf := tv.FilterBar()
+	sgf.Lay = gi.LayoutHoriz
+	sgf.SetProp("overflow", gi.OverflowHidden) // no scrollbars!
+	sgf.SetProp("spacing", 0)
+
+	fcfg := kit.TypeAndNameList{}
+	if tv.ShowIndex {
+		fcfg.Add(gi.KiT_Label, "filt-idx")
+	}
+	for fli := 0; fli < tv.NVisFields; fli++ {
+		fld := tv.VisFields[fli]
+		if TableFieldEnum(fld.Type) {
+			fcfg.Add(gi.KiT_Action, "filt-"+fld.Name)
+		} else {
+			fcfg.Add(gi.KiT_TextField, "filt-"+fld.Name)
+		}
+	}
+	if !tv.IsInactive() {
+		fcfg.Add(gi.KiT_Label, "filt-add")
+		fcfg.Add(gi.KiT_Label, "filt-del")
+	}
+	sgf.ConfigChildren(fcfg, ki.UniqueNames)
+
+	_, idxOff := tv.RowWidgetNs()
+	for fli := 0; fli < tv.NVisFields; fli++ {
+		fld := tv.VisFields[fli]
+		flt := tv.Prefs.Filters[fld.Name]
+		switch fw := sgf.Child(idxOff + fli).(type) {
+		case *gi.Action:
+			fw.Data = fld.Name
+			if flt == "" {
+				fw.SetText("all")
+			} else {
+				fw.SetText(flt)
+			}
+			fw.Tooltip = "filter " + fld.Name + " to the chosen values"
+			fw.MakeMenuFunc = func(obj ki.Ki, m *gi.Menu) {
+				tv.FilterEnumMenu(obj.(*gi.Action).Data.(string), m)
+			}
+		case *gi.TextField:
+			fw.SetProp("tv-field", fld.Name)
+			fw.SetText(flt)
+			fw.Tooltip = "filter " + fld.Name + " to values containing this text"
+			if k := fld.Type.Kind(); k >= reflect.Int && k <= reflect.Float64 {
+				fw.Placeholder = "lo..hi"
+				fw.Tooltip = "filter " + fld.Name + " to values in a range lo..hi (either can be omitted), or compared with a value, e.g., >=10"
+			} else {
+				fw.Placeholder = "filter"
+			}
+			fw.TextFieldSig.ConnectOnly(tv.This(), func(recv, send ki.Ki, sig int64, data interface{}) {
+				if sig == int64(gi.TextFieldDone) || sig == int64(gi.TextFieldCleared) {
+					tvv := recv.Embed(KiT_TableView).(*TableView)
+					tf := send.(*gi.TextField)
+					tvv.SetFilter(tf.Prop("tv-field").(string), tf.Text())
+				}
+			})
+		}
+	}
+}
+
+// FilterEnumMenu makes the menu of values for the filter of given enum field
+func (tv *TableView) FilterEnumMenu(fld string, m *gi.Menu) {
+	*m = (*m)[:0]
+	sf, ok := tv.ColFieldByName(fld)
+	if !ok {
+		return
+	}
+	set := make(map[string]bool)
+	for _, s := range strings.Split(tv.Prefs.Filters[fld], ",") {
+		set[strings.TrimSpace(s)] = true
+	}
+	m.AddAction(gi.ActOpts{Label: "All", Icon: "update", Tooltip: "show all values"}, tv.This(), func(recv, send ki.Ki, sig int64, data interface{}) {
+		tvv := recv.Embed(KiT_TableView).(*TableView)
+		tvv.SetFilter(fld, "")
+	})
+	m.AddSeparator("sep-all")
+	for _, ev := range kit.Enums.TypeValues(sf.Type, false) {
+		icon := "unchecked-box"
+		if set[ev.Name] {
+			icon = "checked-box"
+		}
+		m.AddAction(gi.ActOpts{Label: ev.Name, Icon: icon, Data: ev.Name}, tv.This(), func(recv, send ki.Ki, sig int64, data interface{}) {
+			tvv := recv.Embed(KiT_TableView).(*TableView)
+			tvv.ToggleFilterEnum(fld, data.(string))
+		})
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////////////
+//  Columns
+
+// TableViewResizeMargin is the distance in dots from the right edge of a
+// column header within which dragging resizes the column instead of moving it
+var TableViewResizeMargin = 6
+
+// tableColDrag is the state of a drag on a column header
+type tableColDrag struct {
+	fld    string
+	resize bool
+	start  image.Point
+	width  float32
+}
+
+// ColsChanged rebuilds the view after a change in the columns, filters or
+// sorting, and saves the prefs
+func (tv *TableView) ColsChanged() {
+	tv.reconfig()
+	tv.SavePrefs()
+}
+
+// reconfig rebuilds the view, keeping the selected row
+func (tv *TableView) reconfig() {
+	wupdt := tv.TopUpdateStart()
+	defer tv.TopUpdateEnd(wupdt)
+
+	updt := tv.UpdateStart()
+	tv.ResetSelectedIdxs()
+	tv.SetFullReRender()
+	tv.Values = nil
+	tv.Config()
+	tv.UpdateEnd(updt)
+}
+
+// setColWidth sets the width of given widget in the column of given field,
+// if the column has been resized
+func (tv *TableView) setColWidth(widg gi.Node2D, fld string) {
+	wd, ok := tv.Prefs.Widths[fld]
+	if !ok {
+		return
+	}
+	w := units.NewCh(wd)
+	nb := widg.AsNode2D()
+	nb.SetProp("width", w)
+	nb.SetProp("min-width", w)
+	nb.SetProp("max-width", w)
+}
+
+// HeaderColAt returns the index in VisFields of the column whose header is
+// at given window position, and whether the position is at the right edge
+// of the header, where dragging resizes the column
+func (tv *TableView) HeaderColAt(pt image.Point) (fldIdx int, edge bool, ok bool) {
+	if !tv.IsConfiged() {
+		return -1, false, false
+	}
+	sgh := tv.SliceHeader()
+	_, idxOff := tv.RowWidgetNs()
+	for fli := 0; fli < tv.NVisFields; fli++ {
+		hdr := sgh.Child(idxOff + fli).(gi.Node2D).AsWidget()
+		bb := hdr.WinBBox
+		if pt.In(bb) {
+			return fli, pt.X >= bb.Max.X-TableViewResizeMargin, true
+		}
+	}
+	return -1, false, false
+}
+
+// HeaderDrag handles a drag on the column headers from given previous to
+// current window position: dragging the right edge of a header resizes its
+// column, and dragging elsewhere moves the column to where it is dragged --
+// returns false if the drag did not start on a header
+func (tv *TableView) HeaderDrag(from, where image.Point) bool {
+	if tv.colDrag == nil {
+		fli, edge, ok := tv.HeaderColAt(from)
+		if !ok {
+			return false
+		}
+		cd := &tableColDrag{fld: tv.VisFields[fli].Name, resize: edge, start: from}
+		if edge {
+			_, idxOff := tv.RowWidgetNs()
+			hdr := tv.SliceHeader().Child(idxOff + fli).(gi.Node2D).AsWidget()
+			cd.width = hdr.LayData.AllocSize.X / tv.Sty.Font.Face.Metrics.Ch
+		}
+		tv.colDrag = cd
+	}
+	cd := tv.colDrag
+	if cd.resize {
+		wd := cd.width + float32(where.X-cd.start.X)/tv.Sty.Font.Face.Metrics.Ch
+		wd = math32.Max(float32(int(wd+0.5)), 2)
+		if tv.Prefs.Widths[cd.fld] == wd {
+			return true
+		}
+		if tv.Prefs.Widths == nil {
+			tv.Prefs.Widths = make(map[string]float32)
+		}
+		tv.Prefs.Widths[cd.fld] = wd
+		tv.ColsChanged()
+		return true
+	}
+	fli, _, ok := tv.HeaderColAt(where)
+	if ok && tv.VisFields[fli].Name != cd.fld {
+		tv.MoveCol(cd.fld, fli)
+	}
+	return true
+}
+
+// MoveCol moves the column of the field of given name to given index among
+// the visible columns
+func (tv *TableView) MoveCol(fld string, to int) {
+	var order []string
+	for _, f := range tv.VisFields {
+		if f.Name != fld {
+			order = append(order, f.Name)
+		}
+	}
+	to = ints.MaxInt(0, ints.MinInt(to, len(order)))
+	order = append(order[:to], append([]string{fld}, order[to:]...)...)
+	order = append(order, tv.Prefs.Hidden...)
+	tv.Prefs.Order = order
+	tv.ColsChanged()
+}
+
+// HideCol hides or shows the column of the field of given name, removing any
+// filter on it when hiding -- the last visible column cannot be hidden
+func (tv *TableView) HideCol(fld string, hide bool) {
+	if hide == tv.Prefs.IsHidden(fld) {
+		return
+	}
+	if hide {
+		if tv.NVisFields <= 1 {
+			return
+		}
+		tv.Prefs.Hidden = append(tv.Prefs.Hidden, fld)
+		delete(tv.Prefs.Filters, fld)
+	} else {
+		for i, h := range tv.Prefs.Hidden {
+			if h == fld {
+				tv.Prefs.Hidden = append(tv.Prefs.Hidden[:i], tv.Prefs.Hidden[i+1:]...)
+				break
+			}
+		}
+	}
+	tv.ColsChanged()
+}
+
+// ResetCols restores the default order, widths and visibility of the columns
+func (tv *TableView) ResetCols() {
+	tv.Prefs.Order = nil
+	tv.Prefs.Hidden = nil
+	tv.Prefs.Widths = nil
+	tv.ColsChanged()
+}
+
+// ColsMenu makes the column chooser menu, listing all of the fields that can
+// be shown, with those shown checked, and actions for clearing the sort and
+// filters and resetting the columns
+func (tv *TableView) ColsMenu(m *gi.Menu) {
+	*m = (*m)[:0]
+	m.AddLabel("Columns:")
+	for _, fld := range tv.AllFields {
+		icon := "checked-box"
+		if tv.Prefs.IsHidden(fld.Name) {
+			icon = "unchecked-box"
+		}
+		m.AddAction(gi.ActOpts{Label: fld.Name, Icon: icon, Data: fld.Name, Tooltip: "show or hide this column"},
+			tv.This(), func(recv, send ki.Ki, sig int64, data interface{}) {
+				tvv := recv.Embed(KiT_TableView).(*TableView)
+				nm := data.(string)
+				tvv.HideCol(nm, !tvv.Prefs.IsHidden(nm))
+			})
+	}
+	m.AddSeparator("sep-cols")
+	m.AddAction(gi.ActOpts{Label: "Reset Columns", Tooltip: "restore the default order, widths and visibility of the columns"},
+		tv.This(), func(recv, send ki.Ki, sig int64, data interface{}) {
+			tvv := recv.Embed(KiT_TableView).(*TableView)
+			tvv.ResetCols()
+		})
+	m.AddAction(gi.ActOpts{Label: "Clear Sort", Tooltip: "remove all of the sort keys, leaving the rows in their current order"},
+		tv.This(), func(recv, send ki.Ki, sig int64, data interface{}) {
+			tvv := recv.Embed(KiT_TableView).(*TableView)
+			tvv.SortIdx = -1
+			tvv.Prefs.Sort = nil
+			tvv.ColsChanged()
+		})
+	m.AddAction(gi.ActOpts{Label: "Clear Filters", Tooltip: "remove all of the filters, showing all the rows"},
+		tv.This(), func(recv, send ki.Ki, sig int64, data interface{}) {
+			tvv := recv.Embed(KiT_TableView).(*TableView)
+			tvv.ClearFilters()
+		})
+}
+
+// ColsCtxtMenu pops up the column chooser menu at given window position
+func (tv *TableView) ColsCtxtMenu(pos image.Point) {
+	var men gi.Menu
+	tv.ColsMenu(&men)
+	gi.PopupMenu(men, pos.X, pos.Y, tv.Viewport, tv.Nm+"-cols-menu")
+}
@@ -0,0 +1,207 @@
+// Copyright (c) 2020, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package giv
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/goki/gi/gi"
+	"github.com/goki/ki/kit"
+)
+
+type tableTestRow struct {
+	Name  string
+	Size  int
+	Align gi.Align
+}
+
+func tableTestRows() []tableTestRow {
+	return []tableTestRow{
+		{"delta", 3, gi.AlignLeft},
+		{"Alpha", 10, gi.AlignCenter},
+		{"charlie", 3, gi.AlignRight},
+		{"bravo", 7, gi.AlignLeft},
+	}
+}
+
+// newTestTableView returns a TableView of given rows that is not configured,
+// which is enough for the sorting and filtering
+func newTestTableView(rows *[]tableTestRow) *TableView {
+	tv := &TableView{}
+	tv.Slice = rows
+	tv.SliceNPVal = kit.NonPtrValue(reflect.ValueOf(rows))
+	typ := reflect.TypeOf(tableTestRow{})
+	for i := 0; i < typ.NumField(); i++ {
+		tv.AllFields = append(tv.AllFields, typ.Field(i))
+	}
+	return tv
+}
+
+func TestTableFilterMatch(t *testing.T) {
+	tm := time.Date(2020, 5, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		val  interface{}
+		flt  string
+		want bool
+	}{
+		{"Hello World", "", true},
+		{"Hello World", "lo wo", true},
+		{"Hello World", "WORLD", true},
+		{"Hello World", "xyz", false},
+		{5, "5", true},
+		{5, "6", false},
+		{5, "3..7", true},
+		{5, "6..", false},
+		{5, "..5", true},
+		{5.5, ">5", true},
+		{5, ">=5", true},
+		{5, "<5", false},
+		{5, "<=5", true},
+		{5, "!=5", false},
+		{5, "=5", true},
+		{15, "5x", false}, // not numeric: substring of "15"
+		{15, "x", false},
+		{uint8(200), "100..", true},
+		{gi.AlignLeft, "AlignLeft", true},
+		{gi.AlignLeft, "AlignRight, AlignLeft", true},
+		{gi.AlignLeft, "AlignRight", false},
+		{gi.AlignLeft, "Left", false}, // enums are not substrings
+		{tm, "2020-05", true},
+		{true, "tr", true},
+	}
+	for _, tst := range tests {
+		if got := TableFilterMatch(tst.val, tst.flt); got != tst.want {
+			t.Errorf("TableFilterMatch(%v, %q) = %v, want %v", tst.val, tst.flt, got, tst.want)
+		}
+	}
+}
+
+func TestStructSliceSortKeys(t *testing.T) {
+	nameIdx := []int{0}
+	sizeIdx := []int{1}
+	tests := []struct {
+		fldIdxs [][]int
+		desc    []bool
+		want    []string
+	}{
+		{nil, nil, []string{"delta", "Alpha", "charlie", "bravo"}},
+		{[][]int{nameIdx}, []bool{false}, []string{"Alpha", "bravo", "charlie", "delta"}},
+		{[][]int{nameIdx}, []bool{true}, []string{"delta", "charlie", "bravo", "Alpha"}},
+		{[][]int{sizeIdx}, []bool{false}, []string{"delta", "charlie", "bravo", "Alpha"}}, // stable
+		{[][]int{sizeIdx, nameIdx}, []bool{false, false}, []string{"charlie", "delta", "bravo", "Alpha"}},
+		{[][]int{sizeIdx, nameIdx}, []bool{true, true}, []string{"Alpha", "bravo", "delta", "charlie"}},
+	}
+	for ti, tst := range tests {
+		rows := tableTestRows()
+		idxs := []int{0, 1, 2, 3}
+		StructSliceSortIdxs(&rows, idxs, tst.fldIdxs, tst.desc)
+		for i, si := range idxs {
+			if rows[si].Name != tst.want[i] {
+				t.Errorf("test %d: StructSliceSortIdxs: row %d: %v != %v", ti, i, rows[si].Name, tst.want[i])
+			}
+		}
+		if rows[0].Name != "delta" {
+			t.Errorf("test %d: StructSliceSortIdxs changed the slice", ti)
+		}
+		StructSliceSortKeys(&rows, tst.fldIdxs, tst.desc)
+		for i, r := range rows {
+			if r.Name != tst.want[i] {
+				t.Errorf("test %d: StructSliceSortKeys: row %d: %v != %v", ti, i, r.Name, tst.want[i])
+			}
+		}
+	}
+	prows := []*tableTestRow{{Name: "b"}, {Name: "a"}}
+	StructSliceSortKeys(&prows, [][]int{nameIdx}, []bool{false})
+	if prows[0].Name != "a" {
+		t.Errorf("StructSliceSortKeys of pointers: %v != a", prows[0].Name)
+	}
+}
+
+func TestFilterSlice(t *testing.T) {
+	tests := []struct {
+		sort    []TableSortKey
+		filters map[string]string
+		want    []int
+	}{
+		{nil, nil, nil},
+		{nil, map[string]string{"Name": ""}, nil},
+		{[]TableSortKey{{Field: "Name"}}, nil, []int{1, 3, 2, 0}},
+		{nil, map[string]string{"Size": "<5"}, []int{0, 2}},
+		{[]TableSortKey{{Field: "Name", Desc: true}}, map[string]string{"Size": "3..7"}, []int{0, 2, 3}},
+		{[]TableSortKey{{Field: "Size"}}, map[string]string{"Align": "AlignLeft", "Name": "a"}, []int{0, 3}},
+		{[]TableSortKey{{Field: "NoSuchField"}}, nil, nil},
+		{nil, map[string]string{"Name": "xyz"}, []int{}},
+	}
+	for ti, tst := range tests {
+		rows := tableTestRows()
+		tv := newTestTableView(&rows)
+		tv.Prefs.Sort = tst.sort
+		tv.Prefs.Filters = tst.filters
+		tv.FilterSlice()
+		if !reflect.DeepEqual(tv.Idxs, tst.want) {
+			t.Errorf("test %d: Idxs: %v != %v", ti, tv.Idxs, tst.want)
+		}
+		if !reflect.DeepEqual(rows, tableTestRows()) {
+			t.Errorf("test %d: FilterSlice changed the slice", ti)
+		}
+	}
+}
+
+func TestTableViewIdxs(t *testing.T) {
+	rows := tableTestRows()
+	tv := newTestTableView(&rows)
+	for i := -1; i < 5; i++ {
+		if si := tv.SliceIdx(i); si != i {
+			t.Errorf("SliceIdx(%d) without Idxs: %d", i, si)
+		}
+		if vi := tv.ViewIdx(i); vi != i {
+			t.Errorf("ViewIdx(%d) without Idxs: %d", i, vi)
+		}
+	}
+
+	tv.Prefs.Sort = []TableSortKey{{Field: "Name"}}
+	tv.Prefs.Filters = map[string]string{"Size": "<10"}
+	tv.FilterSlice() // view: bravo, charlie, delta
+	checkIdxs := func(what string, idxs []int, views []int) {
+		t.Helper()
+		if !reflect.DeepEqual(tv.Idxs, idxs) {
+			t.Errorf("%v: Idxs: %v != %v", what, tv.Idxs, idxs)
+		}
+		for vi, si := range idxs {
+			if got := tv.SliceIdx(vi); got != si {
+				t.Errorf("%v: SliceIdx(%d): %d != %d", what, vi, got, si)
+			}
+		}
+		if got := tv.SliceIdx(len(idxs)); got != len(views) {
+			t.Errorf("%v: SliceIdx past the end: %d != %d", what, got, len(views))
+		}
+		for si, vi := range views {
+			if got := tv.ViewIdx(si); got != vi {
+				t.Errorf("%v: ViewIdx(%d): %d != %d", what, si, got, vi)
+			}
+		}
+		if got := tv.ViewIdx(len(views)); got != len(idxs) {
+			t.Errorf("%v: ViewIdx past the end: %d != %d", what, got, len(idxs))
+		}
+	}
+	checkIdxs("filter", []int{3, 2, 0}, []int{2, -1, 1, 0})
+
+	tv.idxsInsert(1, 2) // new row at 2 in the slice, shown in place of charlie
+	checkIdxs("insert", []int{4, 2, 3, 0}, []int{3, -1, 1, 2, 0})
+
+	tv.idxsInsert(4, 5) // at the end
+	checkIdxs("insert end", []int{4, 2, 3, 0, 5}, []int{3, -1, 1, 2, 0, 4})
+
+	tv.idxsDelete(1) // filtered out row
+	checkIdxs("delete hidden", []int{3, 1, 2, 0, 4}, []int{3, 1, 2, 0, 4})
+
+	tv.idxsDelete(3) // first row in the view
+	checkIdxs("delete", []int{1, 2, 0, 3}, []int{2, 0, 1, 3})
+
+	tv.idxsDelete(7) // out of range
+	checkIdxs("delete out of range", []int{1, 2, 0, 3}, []int{2, 0, 1, 3})
+}
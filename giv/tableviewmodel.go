@@ -0,0 +1,389 @@
+// Copyright (c) 2020, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package giv
+
+import (
+	"log"
+	"reflect"
+	"strings"
+
+	"github.com/goki/gi/gi"
+	"github.com/goki/gi/oswin/key"
+	"github.com/goki/gi/oswin/mimedata"
+	"github.com/goki/ki/ints"
+	"github.com/goki/ki/ki"
+	"github.com/goki/ki/kit"
+)
+
+// TableModel is a source of rows of data for a TableView, as an alternative
+// to a slice of structs (see TableView.SetModel), for data that is too
+// large to hold in memory as a slice, e.g., rows paged from a database
+// cursor or read from a memory-mapped file.  The TableView only asks for
+// the values of the rows in view.  Rows are identified by their index, and
+// columns by their index, name and type -- each column is shown as if it
+// were a field of a struct, using the ValueView for its type.  A model that
+// takes time to get its rows should also implement TableModelFetcher, and
+// one that can sort its rows should implement TableModelSorter.
+type TableModel interface {
+	// NumRows returns the number of rows
+	NumRows() int
+
+	// NumCols returns the number of columns
+	NumCols() int
+
+	// ColName returns the name of given column, which must be unique -- it
+	// is shown in the header and used as the key in the TableViewPrefs
+	ColName(col int) string
+
+	// ColType returns the type of the values in given column
+	ColType(col int) reflect.Type
+
+	// Value returns the value at given row and column, which must be
+	// assignable or convertible to the type of the column -- nil shows as the
+	// zero value
+	Value(row, col int) interface{}
+
+	// SetValue sets the value at given row and column, as edited in the view
+	// -- returns an error if the value cannot be set
+	SetValue(row, col int, val interface{}) error
+
+	// SetNotify sets the function that the model calls when it changes (nil
+	// for none), from any goroutine -- a TableView sets this when viewing the
+	// model, so a model can only be viewed by one TableView at a time
+	SetNotify(fun TableModelNotifyFunc)
+}
+
+// TableModelNotifyFunc is the type of function that a TableModel calls
+// when it changes: the rows from st up to ed for TableModelRowsChanged, and
+// st and ed are not used for the other signals
+type TableModelNotifyFunc func(sig TableModelSignals, st, ed int)
+
+// TableModelSignals are the changes that a TableModel notifies
+type TableModelSignals int
+
+const (
+	// TableModelRowsChanged means that the values in the rows from st up to
+	// ed have changed, e.g., as they have been fetched
+	TableModelRowsChanged TableModelSignals = iota
+
+	// TableModelRowsReset means that rows have been inserted, deleted or
+	// reordered, so any of the rows may have changed
+	TableModelRowsReset
+
+	// TableModelColsReset means that the columns have changed
+	TableModelColsReset
+
+	TableModelSignalsN
+)
+
+//go:generate stringer -type=TableModelSignals
+
+// TableModelFetcher is an optional interface for a TableModel whose rows
+// take time to get, e.g., from a database cursor.  The TableView shows the
+// rows that are not yet fetched with zero values, and calls Fetch in a
+// separate goroutine for the rows that come into view, with up to
+// TableModelFetchAhead rows on either side, updating the view when it
+// returns -- so the model must be safe for concurrent use.
+type TableModelFetcher interface {
+	// IsFetched returns true if given row has been fetched, so that Value
+	// returns its values without waiting
+	IsFetched(row int) bool
+
+	// Fetch gets the rows from st up to ed, returning when done -- the
+	// model can keep as many rows as it sees fit
+	Fetch(st, ed int) error
+}
+
+// TableModelSorter is an optional interface for a TableModel that can sort
+// its rows, by each of the given keys in turn, where the fields of the keys
+// are the names of the columns -- the model notifies TableModelRowsReset
+// when done.  Without it, clicking on the headers does not sort.
+type TableModelSorter interface {
+	SortRows(keys []TableSortKey)
+}
+
+// TableModelFetchAhead is the number of rows beyond those in view that
+// TableView fetches from a TableModelFetcher, in each direction, so that
+// scrolling a little does not need another fetch
+var TableModelFetchAhead = 100
+
+// SetModel sets the model of the data that we are viewing instead of a
+// slice -- see TableModel.  Rows cannot be inserted, deleted, pasted or
+// dragged, and there are no filters, but otherwise the view works the same
+// as for a slice, with indexes being the rows of the model.
+func (tv *TableView) SetModel(m TableModel) {
+	if m == nil {
+		tv.Model = nil
+		return
+	}
+	if tv.Model == m && tv.IsConfiged() {
+		tv.Update()
+		return
+	}
+	if tv.Model != nil {
+		tv.Model.SetNotify(nil)
+	}
+	if !tv.IsInactive() {
+		tv.SelectedIdx = -1
+	}
+	tv.StartIdx = 0
+	tv.SortIdx = -1
+	tv.SortDesc = false
+	tv.Slice = nil
+	tv.SliceNPVal = reflect.Value{}
+	tv.StruType = nil
+	tv.Model = m
+	updt := tv.UpdateStart()
+	tv.ResetSelectedIdxs()
+	tv.SelectMode = false
+	tv.SetFullReRender()
+	tv.viewProps()
+	tv.ShowFilters = false
+	tv.NoAdd = true
+	tv.NoDelete = true
+	tv.Idxs = nil
+	tv.fetchSt, tv.fetchEd = 0, 0
+	tv.modelSort = nil
+	m.SetNotify(func(sig TableModelSignals, st, ed int) {
+		win := tv.ParentWindow()
+		if win == nil {
+			tv.ModelChanged(m, sig, st, ed)
+			return
+		}
+		win.GoRunOnEventLoop(func() {
+			tv.ModelChanged(m, sig, st, ed)
+		})
+	})
+	tv.LoadPrefs()
+	tv.Config()
+	tv.UpdateEnd(updt)
+}
+
+// source returns the Model if set, and otherwise the Slice
+func (tv *TableView) source() interface{} {
+	if tv.Model != nil {
+		return tv.Model
+	}
+	return tv.Slice
+}
+
+// sourceLen returns the number of rows of the Model or Slice, whether
+// shown or filtered out
+func (tv *TableView) sourceLen() int {
+	if tv.Model != nil {
+		return tv.Model.NumRows()
+	}
+	return tv.SliceNPVal.Len()
+}
+
+// ModelChanged updates the view for a change in given model, as notified
+// by it -- see TableModelSignals.  It must be called on the event loop of the
+// window, as the notify function set on the model does.
+func (tv *TableView) ModelChanged(m TableModel, sig TableModelSignals, st, ed int) {
+	if tv.Model != m || tv.IsDestroyed() {
+		return
+	}
+	switch sig {
+	case TableModelColsReset:
+		tv.ColsChanged()
+	case TableModelRowsReset:
+		tv.fetchSt, tv.fetchEd = 0, 0
+		if !tv.IsConfiged() {
+			tv.reconfig()
+			return
+		}
+		tv.ScrollBar().SetFullReRender()
+		tv.Update()
+	case TableModelRowsChanged:
+		if ed <= tv.StartIdx || st >= tv.StartIdx+tv.DispRows {
+			return
+		}
+		tv.UpdateSliceGrid()
+	}
+}
+
+// modelFields returns the columns of the Model as fields, with the index of
+// each column as the index of the field
+func (tv *TableView) modelFields() []reflect.StructField {
+	nc := tv.Model.NumCols()
+	flds := make([]reflect.StructField, nc)
+	for c := 0; c < nc; c++ {
+		flds[c] = reflect.StructField{Name: tv.Model.ColName(c), Type: tv.Model.ColType(c), Index: []int{c}}
+	}
+	return flds
+}
+
+// modelFetched returns true if given row of the Model is available
+func (tv *TableView) modelFetched(row int) bool {
+	if f, ok := tv.Model.(TableModelFetcher); ok {
+		return f.IsFetched(row)
+	}
+	return true
+}
+
+// modelValue returns a pointer to a new value for given row of the Model
+// (-1 for none) in the column of given field, set to the value in the
+// model if that row has been fetched, and otherwise the zero value
+func (tv *TableView) modelValue(row int, field *reflect.StructField) reflect.Value {
+	ptr := reflect.New(field.Type)
+	if row < 0 || !tv.modelFetched(row) {
+		return ptr
+	}
+	if val := tv.Model.Value(row, field.Index[0]); val != nil {
+		kit.SetRobust(ptr.Interface(), val)
+	}
+	return ptr
+}
+
+// modelSetValue sets the value of the cell of given value view in the
+// Model, after it has been edited
+func (tv *TableView) modelSetValue(vv ValueView) {
+	vvb := vv.AsValueViewBase()
+	row, rok := vvb.Prop("tv-model-row").(int)
+	col, cok := vvb.Prop("tv-model-col").(int)
+	if !rok || !cok {
+		return
+	}
+	err := tv.Model.SetValue(row, col, kit.NonPtrValue(vv.Val()).Interface())
+	if err != nil {
+		gi.PromptDialog(tv.Viewport, gi.DlgOpts{Title: "Could not Set Value", Prompt: err.Error()}, gi.AddOk, gi.NoCancel, nil, nil)
+		log.Println(err)
+		tv.UpdateSliceGrid() // show the value in the model
+	}
+}
+
+// modelFetch fetches any rows in view that have not been fetched from a
+// TableModelFetcher, in a separate goroutine, unless already fetching --
+// when done, the view is updated on the event loop of the window, which
+// calls modelFetch again for any rows that have come into view meanwhile
+func (tv *TableView) modelFetch() {
+	f, ok := tv.Model.(TableModelFetcher)
+	if !ok || tv.fetching {
+		return
+	}
+	st, ed := -1, -1
+	for row := tv.StartIdx; row < tv.StartIdx+tv.DispRows; row++ {
+		if !f.IsFetched(row) {
+			if st < 0 {
+				st = row
+			}
+			ed = row + 1
+		}
+	}
+	if st < 0 || (st >= tv.fetchSt && ed <= tv.fetchEd) { // don't fetch the same again
+		return
+	}
+	win := tv.ParentWindow()
+	if win == nil {
+		return
+	}
+	st = ints.MaxInt(st-TableModelFetchAhead, 0)
+	ed = ints.MinInt(ed+TableModelFetchAhead, tv.Model.NumRows())
+	tv.fetchSt, tv.fetchEd = st, ed
+	tv.fetching = true
+	m := tv.Model
+	go func() {
+		err := f.Fetch(st, ed)
+		win.GoRunOnEventLoop(func() {
+			tv.modelFetchDone(m, err)
+		})
+	}()
+}
+
+// modelFetchDone is called on the event loop when a fetch from given model
+// is done, with its error, if any -- a failed fetch is tried again when the
+// view is next updated
+func (tv *TableView) modelFetchDone(m TableModel, err error) {
+	tv.fetching = false
+	if err != nil {
+		log.Println(err)
+		tv.fetchSt, tv.fetchEd = 0, 0
+		return
+	}
+	if tv.Model == nil || tv.IsDestroyed() || !tv.IsConfiged() {
+		return
+	}
+	if tv.Model != m { // fetch for the new model instead
+		tv.modelFetch()
+		return
+	}
+	tv.UpdateSliceGrid() // calls modelFetch
+}
+
+// modelSortRows sorts the rows of a TableModelSorter by the sort keys, if
+// they have changed since last sorted
+func (tv *TableView) modelSortRows() {
+	srt, ok := tv.Model.(TableModelSorter)
+	if !ok {
+		return
+	}
+	same := len(tv.modelSort) == len(tv.Prefs.Sort)
+	for i := 0; same && i < len(tv.modelSort); i++ {
+		same = tv.modelSort[i] == tv.Prefs.Sort[i]
+	}
+	if same {
+		return
+	}
+	tv.modelSort = append([]TableSortKey(nil), tv.Prefs.Sort...)
+	srt.SortRows(tv.modelSort)
+}
+
+// CopySelToMime copies selected rows to mime data -- for a Model, the rows
+// are copied as text, with the values separated by tabs
+func (tv *TableView) CopySelToMime() mimedata.Mimes {
+	if tv.Model == nil {
+		return tv.SliceViewBase.CopySelToMime()
+	}
+	ixs := tv.SelectedIdxsList(false) // ascending
+	if len(ixs) == 0 {
+		return nil
+	}
+	var b strings.Builder
+	for _, row := range ixs {
+		fetched := tv.modelFetched(row)
+		for fli, fld := range tv.VisFields {
+			if fli > 0 {
+				b.WriteByte('\t')
+			}
+			if fetched {
+				b.WriteString(kit.ToString(tv.Model.Value(row, fld.Index[0])))
+			}
+		}
+		b.WriteByte('\n')
+	}
+	return mimedata.NewText(b.String())
+}
+
+// ItemCtxtMenu pulls up the context menu for given slice index -- for a
+// Model, rows can only be copied
+func (tv *TableView) ItemCtxtMenu(idx int) {
+	if tv.Model == nil {
+		tv.SliceViewBase.ItemCtxtMenu(idx)
+		return
+	}
+	if idx < 0 || idx >= tv.SliceSize {
+		return
+	}
+	var men gi.Menu
+	men.AddAction(gi.ActOpts{Label: "Copy", Data: idx},
+		tv.This(), func(recv, send ki.Ki, sig int64, data interface{}) {
+			tvv := recv.Embed(KiT_TableView).(*TableView)
+			tvv.CopyIdxs(true)
+		})
+	pos := tv.IdxPos(idx)
+	gi.PopupMenu(men, pos.X, pos.Y, tv.Viewport, tv.Nm+"-menu")
+}
+
+// KeyInputActive handles keys in the active mode -- for a Model, the keys
+// for inserting, pasting and cutting rows are not used
+func (tv *TableView) KeyInputActive(kt *key.ChordEvent) {
+	if tv.Model != nil {
+		switch gi.KeyFun(kt.Chord()) {
+		case gi.KeyFunDuplicate, gi.KeyFunInsert, gi.KeyFunInsertAfter, gi.KeyFunCut, gi.KeyFunPaste:
+			return
+		}
+	}
+	tv.SliceViewBase.KeyInputActive(kt)
+}
@@ -0,0 +1,217 @@
+// Copyright (c) 2020, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package giv_test
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/goki/gi/gi"
+	"github.com/goki/gi/giv"
+)
+
+// testTableModel is a TableModelFetcher with a Name and a Size column, whose
+// rows are fetched when asked to, and which records the fetches
+type testTableModel struct {
+	mu       sync.Mutex
+	names    []string
+	sizes    []int
+	fetched  map[int]bool
+	fetches  [][2]int
+	fetchErr error
+	notify   giv.TableModelNotifyFunc
+}
+
+func newTestTableModel(n int) *testTableModel {
+	m := &testTableModel{fetched: map[int]bool{}}
+	for i := 0; i < n; i++ {
+		m.names = append(m.names, fmt.Sprintf("row %d", i))
+		m.sizes = append(m.sizes, i*10)
+	}
+	return m
+}
+
+func (m *testTableModel) NumRows() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.names)
+}
+
+func (m *testTableModel) NumCols() int { return 2 }
+
+func (m *testTableModel) ColName(col int) string {
+	return []string{"Name", "Size"}[col]
+}
+
+func (m *testTableModel) ColType(col int) reflect.Type {
+	if col == 0 {
+		return reflect.TypeOf("")
+	}
+	return reflect.TypeOf(0)
+}
+
+func (m *testTableModel) Value(row, col int) interface{} {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if col == 0 {
+		return m.names[row]
+	}
+	return m.sizes[row]
+}
+
+func (m *testTableModel) SetValue(row, col int, val interface{}) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if col == 0 {
+		m.names[row] = val.(string)
+		return nil
+	}
+	sz := val.(int)
+	if sz < 0 {
+		return errors.New("size must not be negative")
+	}
+	m.sizes[row] = sz
+	return nil
+}
+
+func (m *testTableModel) SetNotify(fun giv.TableModelNotifyFunc) {
+	m.mu.Lock()
+	m.notify = fun
+	m.mu.Unlock()
+}
+
+func (m *testTableModel) IsFetched(row int) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.fetched[row]
+}
+
+func (m *testTableModel) Fetch(st, ed int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.fetches = append(m.fetches, [2]int{st, ed})
+	if m.fetchErr != nil {
+		return m.fetchErr
+	}
+	for row := st; row < ed; row++ {
+		m.fetched[row] = true
+	}
+	return nil
+}
+
+func (m *testTableModel) numFetches() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.fetches)
+}
+
+// waitFetches waits for the model to have been fetched n times, and for the
+// view to be updated
+func (m *testTableModel) waitFetches(t *testing.T, h interface{ WaitIdle() }, n int) {
+	t.Helper()
+	start := time.Now()
+	for m.numFetches() < n {
+		if time.Since(start) > 5*time.Second {
+			t.Fatalf("waiting for %d fetches: got %d", n, m.numFetches())
+		}
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(10 * time.Millisecond) // let the fetch post its update
+	h.WaitIdle()
+}
+
+// cellText returns the text in the cell of the TableView at given row and
+// column in the view
+func cellText(tv *giv.TableView, row, col int) string {
+	sg := tv.SliceGrid()
+	nWidgPerRow, idxOff := tv.RowWidgetNs()
+	switch w := sg.Child(row*nWidgPerRow + idxOff + col).(type) {
+	case *gi.TextField:
+		return w.Text()
+	case *gi.SpinBox:
+		return fmt.Sprintf("%v", w.Value)
+	}
+	return "?"
+}
+
+func TestTableViewModel(t *testing.T) {
+	m := newTestTableModel(1000)
+	var tv *giv.TableView
+	h := newTestWindow(t, func(mfr *gi.Frame) {
+		tv = giv.AddNewTableView(mfr, "tv")
+		tv.SetStretchMax()
+		tv.SetModel(m)
+	})
+	defer h.Close()
+
+	if tv.SliceSize != 1000 {
+		t.Errorf("SliceSize: %d != NumRows 1000", tv.SliceSize)
+	}
+	if tv.NVisFields != 2 || tv.VisFields[0].Name != "Name" || tv.VisFields[1].Name != "Size" {
+		t.Errorf("columns: %v", tv.VisFields)
+	}
+	if tv.DispRows == 0 {
+		t.Fatalf("no rows shown")
+	}
+
+	// the rows in view are fetched in the background, with the rows ahead
+	m.waitFetches(t, h, 1)
+	if ft := m.fetches[0]; ft[0] != 0 || ft[1] != tv.DispRows+giv.TableModelFetchAhead {
+		t.Errorf("first fetch: %v, want [0 %d]", ft, tv.DispRows+giv.TableModelFetchAhead)
+	}
+	if got := cellText(tv, 1, 0); got != "row 1" {
+		t.Errorf("cell 1, 0 after fetch: %q != row 1", got)
+	}
+	if got := cellText(tv, 2, 1); got != "20" {
+		t.Errorf("cell 2, 1 after fetch: %q != 20", got)
+	}
+
+	// values set in the view are set in the model
+	vv := tv.Values[1*tv.NVisFields+1]
+	if !vv.SetValue(42) {
+		t.Errorf("SetValue in view failed")
+	}
+	if got := m.Value(1, 1); got != 42 {
+		t.Errorf("model value after SetValue: %v != 42", got)
+	}
+
+	// RowsChanged updates the rows in view
+	m.SetValue(2, 0, "changed")
+	tv.ModelChanged(m, giv.TableModelRowsChanged, 2, 3)
+	h.WaitIdle()
+	if got := cellText(tv, 2, 0); got != "changed" {
+		t.Errorf("cell 2, 0 after RowsChanged: %q != changed", got)
+	}
+
+	// RowsReset updates the size, and fetches again after a failed fetch
+	m.mu.Lock()
+	m.names = m.names[:500]
+	m.sizes = m.sizes[:500]
+	m.fetched = map[int]bool{}
+	m.fetchErr = errors.New("test fetch error")
+	m.mu.Unlock()
+	nf := m.numFetches()
+	tv.ModelChanged(m, giv.TableModelRowsReset, 0, 0)
+	h.WaitIdle()
+	if tv.SliceSize != 500 {
+		t.Errorf("SliceSize after RowsReset: %d != 500", tv.SliceSize)
+	}
+	m.waitFetches(t, h, nf+1)
+	if got := cellText(tv, 1, 0); got != "" {
+		t.Errorf("cell 1, 0 not fetched: %q is not empty", got)
+	}
+	m.mu.Lock()
+	m.fetchErr = nil
+	m.mu.Unlock()
+	tv.ModelChanged(m, giv.TableModelRowsChanged, 0, 1)
+	m.waitFetches(t, h, nf+2)
+	if got := cellText(tv, 1, 0); got != "row 1" {
+		t.Errorf("cell 1, 0 after fetching again: %q != row 1", got)
+	}
+}
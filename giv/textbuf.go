@@ -6,11 +6,14 @@ package giv
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io/ioutil"
 	"log"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
 	"sync"
 	"time"
@@ -18,6 +21,7 @@ import (
 	"github.com/goki/gi/gi"
 	"github.com/goki/gi/giv/textbuf"
 	"github.com/goki/gi/histyle"
+	"github.com/goki/gi/oswin"
 	"github.com/goki/gi/units"
 	"github.com/goki/ki/indent"
 	"github.com/goki/ki/ints"
@@ -88,10 +92,14 @@ type TextBuf struct {
 	TextBufSig       ki.Signal           `json:"-" xml:"-" view:"-" desc:"signal for buffer -- see TextBufSignals for the types"`
 	Views            []*TextView         `json:"-" xml:"-" desc:"the TextViews that are currently viewing this buffer"`
 	Undos            textbuf.Undo        `json:"-" xml:"-" desc:"undo manager"`
+	UndoHistFile     string              `json:"-" xml:"-" desc:"file where the undo history for the current file was last saved or opened from -- see SaveUndoHist"`
 	PosHistory       []textbuf.Pos       `json:"-" xml:"-" desc:"history of cursor positions -- can move back through them"`
 	Complete         *gi.Complete        `json:"-" xml:"-" desc:"functions and data for text completion"`
 	SpellCorrect     *gi.SpellCorrect    `json:"-" xml:"-" desc:"functions and data for spelling correction"`
 	CurView          *TextView           `json:"-" xml:"-" desc:"current textview -- e.g., the one that initiated Complete or Correct process -- update cursor position in this view -- is reset to nil after usage always"`
+	LSP              LangServerState     `json:"-" xml:"-" view:"-" desc:"state of the document on the language server, if using one -- see StartLangServer"`
+	Vcs              VcsChangeState      `json:"-" xml:"-" view:"-" copy:"-" desc:"state of the change gutter for a file in version control, showing changes relative to HEAD -- see SetVcsRepo"`
+	Large            *LargeFile          `json:"-" xml:"-" view:"-" copy:"-" desc:"state of large-file mode, where only a window of the lines of the file is in the buffer -- nil if not in large-file mode -- see OpenLarge"`
 }
 
 var KiT_TextBuf = kit.Types.AddType(&TextBuf{}, TextBufProps)
@@ -332,6 +340,7 @@ func (tb *TextBuf) New(nlines int) {
 	tb.LinesMu.Lock()
 	tb.MarkupMu.Lock()
 	tb.Undos.Reset()
+	tb.Vcs.SetDirty()
 	tb.Lines = make([][]rune, nlines)
 	tb.LineBytes = make([][]byte, nlines)
 	tb.Tags = make([]lex.Line, nlines)
@@ -421,6 +430,11 @@ func (tb *TextBuf) FileModCheck() bool {
 // Open loads text from a file into the buffer
 func (tb *TextBuf) Open(filename gi.FileName) error {
 	tb.Defaults()
+	tb.StopLangServer()
+	if fi, err := os.Stat(string(filename)); err == nil && fi.Size() > int64(TextBufLargeSize) {
+		return tb.OpenLarge(filename)
+	}
+	tb.CloseLarge()
 	err := tb.OpenFile(filename)
 	if err != nil {
 		vp := tb.ViewportFromView()
@@ -429,6 +443,9 @@ func (tb *TextBuf) Open(filename gi.FileName) error {
 		return err
 	}
 	tb.SetName(string(filename)) // todo: modify in any way?
+	tb.StartFileWatch()
+	tb.OpenUndoHist()
+	tb.StartLangServer()
 
 	tb.InitialMarkup()
 
@@ -462,6 +479,9 @@ func (tb *TextBuf) Revert() bool {
 	if tb.Filename == "" {
 		return false
 	}
+	if tb.IsLarge() {
+		return tb.RevertLarge()
+	}
 
 	didDiff := false
 	if tb.NLines < TextBufDiffRevertLines {
@@ -489,7 +509,9 @@ func (tb *TextBuf) Revert() bool {
 		tb.OpenFile(tb.Filename)
 	}
 	tb.ClearChanged()
+	tb.Undos.MarkSaved()
 	tb.AutoSaveDelete()
+	tb.LangServerChanged()
 	tb.ReMarkup(false)
 	return true
 }
@@ -533,6 +555,9 @@ func (tb *TextBuf) SaveAs(filename gi.FileName) {
 
 // SaveFile writes current buffer to file, with no prompting, etc
 func (tb *TextBuf) SaveFile(filename gi.FileName) error {
+	if tb.IsLarge() {
+		return tb.SaveLarge(filename)
+	}
 	err := ioutil.WriteFile(string(filename), tb.Txt, 0644)
 	if err != nil {
 		gi.PromptDialog(nil, gi.DlgOpts{Title: "Could not Save to File", Prompt: err.Error()}, gi.AddOk, gi.NoCancel, nil, nil)
@@ -541,6 +566,10 @@ func (tb *TextBuf) SaveFile(filename gi.FileName) error {
 		tb.Filename = filename
 		tb.SetName(string(filename)) // todo: modify in any way?
 		tb.Stat()
+		tb.Undos.MarkSaved()
+		tb.SaveUndoHist(tb.Txt)
+		tb.LangServerSaved()
+		tb.StartFileWatch()
 	}
 	return err
 }
@@ -615,6 +644,16 @@ func (tb *TextBuf) Close(afterFun func(canceled bool)) bool {
 		}
 		return false // awaiting decisions..
 	}
+	if tb.Filename != "" && !tb.IsLarge() {
+		if txt, err := ioutil.ReadFile(string(tb.Filename)); err == nil {
+			tb.SaveUndoHist(txt)
+		}
+	}
+	tb.StopLangServer()
+	tb.SetVcsRepo(nil)
+	tb.Vcs.StatusFun = nil
+	tb.StopFileWatch()
+	tb.CloseLarge()
 	tb.TextBufSig.Emit(tb.This(), int64(TextBufClosed), nil)
 	// for _, tve := range tb.Views {
 	// 	tve.SetBuf(nil) // automatically disconnects signals, views
@@ -657,7 +696,7 @@ func (tb *TextBuf) AutoSaveFilename() string {
 
 // AutoSave does the autosave -- safe to call in a separate goroutine
 func (tb *TextBuf) AutoSave() error {
-	if tb.HasFlag(int(TextBufAutoSaving)) {
+	if tb.HasFlag(int(TextBufAutoSaving)) || tb.IsLarge() { // only has the window of a large file
 		return nil
 	}
 	tb.SetFlag(int(TextBufAutoSaving))
@@ -973,6 +1012,54 @@ func (tb *TextBuf) Search(find []byte, ignoreCase, lexItems bool) (int, []textbu
 	}
 }
 
+// SearchRegexp looks for a regular expression within buffer, returning
+// number of occurrences and specific match position list.  If lexItems
+// is set, only entire lexically tagged items match, and otherwise if
+// multiLine is set, matches can span multiple lines (see
+// textbuf.SearchRegexpFor for compiling the regexp).
+func (tb *TextBuf) SearchRegexp(re *regexp.Regexp, lexItems, multiLine bool) (int, []textbuf.Match) {
+	tb.LinesMu.RLock()
+	defer tb.LinesMu.RUnlock()
+	switch {
+	case lexItems:
+		return textbuf.SearchLexItemsRegexp(tb.Lines, tb.HiTags, re)
+	case multiLine:
+		return textbuf.SearchMultiLineRegexp(tb.Lines, re)
+	default:
+		return textbuf.SearchRuneLinesRegexp(tb.Lines, re)
+	}
+}
+
+// ReplaceRegexpText returns the replacement text for the match of given
+// regexp at given region, expanding capture group references ($1 etc) in repl
+func (tb *TextBuf) ReplaceRegexpText(reg textbuf.Region, re *regexp.Regexp, repl string) []byte {
+	tb.LinesMu.RLock()
+	defer tb.LinesMu.RUnlock()
+	return textbuf.ReplaceRegexp(tb.Lines, reg, re, []byte(repl))
+}
+
+// ReplaceMatches replaces each of the matches with repl, expanding capture
+// group references in repl if re is non-nil, as one group of edits that is
+// undone together.  The matches must be in order and not overlap.
+func (tb *TextBuf) ReplaceMatches(matches []textbuf.Match, re *regexp.Regexp, repl string) {
+	if len(matches) == 0 {
+		return
+	}
+	bufUpdt, winUpdt, autoSave := tb.BatchUpdateStart()
+	tb.Undos.BeginGroup()
+	for mi := len(matches) - 1; mi >= 0; mi-- { // last first, so earlier positions stay valid
+		reg := matches[mi].Reg
+		rtxt := []byte(repl)
+		if re != nil {
+			rtxt = tb.ReplaceRegexpText(reg, re, repl)
+		}
+		tb.DeleteText(reg.Start, reg.End, EditSignal)
+		tb.InsertText(reg.Start, rtxt, EditSignal)
+	}
+	tb.Undos.EndGroup()
+	tb.BatchUpdateEnd(bufUpdt, winUpdt, autoSave)
+}
+
 // BraceMatch finds the brace, bracket, or parens that is the partner
 // of the one passed to function.
 func (tb *TextBuf) BraceMatch(r rune, st textbuf.Pos) (en textbuf.Pos, found bool) {
@@ -981,6 +1068,52 @@ func (tb *TextBuf) BraceMatch(r rune, st textbuf.Pos) (en textbuf.Pos, found boo
 	return textbuf.BraceMatch(tb.Lines, r, st, TextBufMaxScopeLines)
 }
 
+// FoldIndentLangs are the languages that are folded by indentation
+// instead of by brace structure
+var FoldIndentLangs = map[filecat.Supported]bool{
+	filecat.Python:   true,
+	filecat.Yaml:     true,
+	filecat.Makefile: true,
+}
+
+// FoldRegions returns the foldable regions in the buffer, sorted by starting
+// line.  For languages in FoldIndentLangs, or if there are no syntax
+// highlighting tags, folding is by indentation level (from LineIndent),
+// and otherwise it is by brace and comment block structure from the tags.
+// See textbuf.FoldBraces for the format of the fold regions.
+func (tb *TextBuf) FoldRegions() []textbuf.Region {
+	if !FoldIndentLangs[tb.Info.Sup] {
+		tb.MarkupMu.RLock()
+		hasTags := false
+		for _, tl := range tb.HiTags {
+			if len(tl) > 0 {
+				hasTags = true
+				break
+			}
+		}
+		if hasTags {
+			tags := make([]lex.Line, len(tb.HiTags))
+			copy(tags, tb.HiTags)
+			tb.MarkupMu.RUnlock()
+			tb.LinesMu.RLock()
+			defer tb.LinesMu.RUnlock()
+			return textbuf.FoldBraces(tb.Lines, tags)
+		}
+		tb.MarkupMu.RUnlock()
+	}
+	nln := tb.NumLines()
+	indents := make([]int, nln)
+	for ln := 0; ln < nln; ln++ {
+		indents[ln], _ = tb.LineIndent(ln, 1)
+	}
+	tb.LinesMu.RLock()
+	defer tb.LinesMu.RUnlock()
+	if len(tb.Lines) != nln {
+		return nil
+	}
+	return textbuf.FoldIndent(tb.Lines, indents)
+}
+
 /////////////////////////////////////////////////////////////////////////////
 //   Edits
 
@@ -1098,6 +1231,8 @@ func (tb *TextBuf) DeleteTextImpl(st, ed textbuf.Pos) *textbuf.Edit {
 		tb.NLines = len(tb.Lines)
 		tb.LinesDeleted(tbe)
 	}
+	tb.LangServerChanged()
+	tb.Vcs.SetDirty()
 	return tbe
 }
 
@@ -1148,6 +1283,8 @@ func (tb *TextBuf) InsertTextImpl(st textbuf.Pos, text []byte) *textbuf.Edit {
 		tbe = tb.RegionImpl(st, ed)
 		tb.LinesInserted(tbe)
 	}
+	tb.LangServerChanged()
+	tb.Vcs.SetDirty()
 	return tbe
 }
 
@@ -1400,7 +1537,9 @@ func (tb *TextBuf) StartDelayedReMarkup() {
 	tb.MarkupDelayTimer = time.AfterFunc(time.Duration(TextBufMarkupDelayMSec)*time.Millisecond,
 		func() {
 			// fmt.Printf("delayed remarkup\n")
+			tb.MarkupDelayMu.Lock()
 			tb.MarkupDelayTimer = nil
+			tb.MarkupDelayMu.Unlock()
 			tb.ReMarkup(false) // not an echo
 		})
 }
@@ -1534,14 +1673,19 @@ func (tb *TextBuf) MarkupAllLines(fromEcho bool) {
 	tb.MarkupMu.Unlock()
 	tb.LinesMu.Unlock()
 	tb.ClearFlag(int(TextBufMarkingUp))
+	tb.UpdateVcsChangesIfDirty()
 	tb.TextBufSig.Emit(tb.This(), int64(TextBufMarkUpdt), tb.Txt)
 	if !fromEcho {
+		tb.MarkupDelayMu.Lock()
 		tb.MarkupEchoTimer = time.AfterFunc(time.Duration(TextBufMarkupDelayMSec)*time.Millisecond,
 			func() {
+				tb.MarkupDelayMu.Lock()
 				tb.MarkupEchoTimer = nil
+				tb.MarkupDelayMu.Unlock()
 				// fmt.Printf("echo remarkup\n")
 				tb.ReMarkup(true) // this is now an echo
 			})
+		tb.MarkupDelayMu.Unlock()
 	}
 }
 
@@ -1619,8 +1763,10 @@ func (tb *TextBuf) Undo() *textbuf.Edit {
 	tbe := tb.Undos.UndoPop()
 	if tbe == nil {
 		tb.LinesMu.Unlock()
-		tb.ClearChanged()
-		tb.AutoSaveDelete()
+		if tb.Undos.Pos == tb.Undos.SavePos {
+			tb.ClearChanged()
+			tb.AutoSaveDelete()
+		}
 		return nil
 	}
 	bufUpdt, winUpdt, autoSave := tb.BatchUpdateStart()
@@ -1653,7 +1799,7 @@ func (tb *TextBuf) Undo() *textbuf.Edit {
 		last = tbe
 	}
 	tb.LinesMu.Unlock()
-	if tb.Undos.Pos == 0 {
+	if tb.Undos.Pos == tb.Undos.SavePos {
 		tb.ClearChanged()
 		tb.AutoSaveDelete()
 	}
@@ -1701,9 +1847,167 @@ func (tb *TextBuf) Redo() *textbuf.Edit {
 		last = tbe
 	}
 	tb.LinesMu.Unlock()
+	if tb.Undos.Pos == tb.Undos.SavePos {
+		tb.ClearChanged()
+		tb.AutoSaveDelete()
+	}
+	return last
+}
+
+// UndoTo moves the buffer to given state in the undo tree, as returned by
+// Undos.States(), undoing and redoing as needed.  If the state is on a
+// different branch, it first undoes back to the point where that branch
+// diverged from the current history, and switches to it, so the current
+// history in turn becomes a branch.  Returns the last edit processed,
+// nil if none.
+func (tb *TextBuf) UndoTo(st textbuf.UndoState) *textbuf.Edit {
+	var last *textbuf.Edit
+	step := func(fun func() *textbuf.Edit) bool {
+		tbe := fun()
+		if tbe == nil {
+			return false
+		}
+		last = tbe
+		return true
+	}
+	if st.Branch >= 0 {
+		if st.Branch >= len(tb.Undos.Branches) {
+			return nil
+		}
+		cp := tb.Undos.CommonPos(st.Branch)
+		for tb.Undos.Pos > cp && step(tb.Undo) {
+		}
+		if err := tb.Undos.SwitchBranch(st.Branch); err != nil {
+			log.Println(err)
+			return last
+		}
+	}
+	for tb.Undos.Pos > st.Pos && step(tb.Undo) {
+	}
+	for tb.Undos.Pos < st.Pos && step(tb.Redo) {
+	}
+	tb.Undos.Mu.Lock()
+	tb.Undos.UndoStack = nil // not a sequence of emacs undos
+	tb.Undos.Mu.Unlock()
 	return last
 }
 
+/////////////////////////////////////////////////////////////////////////////
+//   Undo History
+
+// TextBufUndoDir is the directory where undo history files are saved --
+// if empty, an "undo" directory within the app prefs directory is used
+var TextBufUndoDir = ""
+
+// TextBufUndoMaxAge is the age beyond which undo history files are deleted
+var TextBufUndoMaxAge = 30 * 24 * time.Hour
+
+// textBufUndoPrune ensures that old undo history files are pruned once per session
+var textBufUndoPrune sync.Once
+
+// UndoHistDir returns the directory where undo history files are saved,
+// creating it if needed, and pruning any files older than TextBufUndoMaxAge
+// the first time it is called -- returns "" if not available
+func UndoHistDir() string {
+	dir := TextBufUndoDir
+	if dir == "" {
+		if oswin.TheApp == nil {
+			return ""
+		}
+		dir = filepath.Join(oswin.TheApp.AppPrefsDir(), "undo")
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		log.Println(err)
+		return ""
+	}
+	textBufUndoPrune.Do(func() {
+		fis, err := ioutil.ReadDir(dir)
+		if err != nil {
+			return
+		}
+		for _, fi := range fis {
+			if filepath.Ext(fi.Name()) == ".undo" && time.Since(fi.ModTime()) > TextBufUndoMaxAge {
+				os.Remove(filepath.Join(dir, fi.Name()))
+			}
+		}
+	})
+	return dir
+}
+
+// UndoHistHash returns the hash of the given file name and contents,
+// which identifies the undo history for the file
+func UndoHistHash(filename gi.FileName, txt []byte) string {
+	h := sha256.New()
+	if afn, err := filepath.Abs(string(filename)); err == nil {
+		filename = gi.FileName(afn)
+	}
+	h.Write([]byte(filename))
+	h.Write([]byte{0})
+	h.Write(txt)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// UndoHistFilename returns the name of the undo history file for given hash
+func UndoHistFilename(dir, hash string) string {
+	return filepath.Join(dir, hash[:32]+".undo")
+}
+
+// SaveUndoHist saves the undo history for the current file, given its
+// contents as saved on disk, if the SaveUndo option is set.  Any previous
+// history file for this buffer is removed, as it no longer matches the file.
+func (tb *TextBuf) SaveUndoHist(txt []byte) error {
+	if !tb.Opts.SaveUndo || tb.Filename == "" || tb.Undos.Off || tb.Undos.SavePos < 0 {
+		return nil
+	}
+	if len(tb.Undos.Stack) == 0 && len(tb.Undos.Branches) == 0 {
+		return nil
+	}
+	dir := UndoHistDir()
+	if dir == "" {
+		return nil
+	}
+	hash := UndoHistHash(tb.Filename, txt)
+	fn := UndoHistFilename(dir, hash)
+	if tb.UndoHistFile != "" && tb.UndoHistFile != fn {
+		os.Remove(tb.UndoHistFile)
+	}
+	tb.UndoHistFile = ""
+	err := tb.Undos.SaveHistory(fn, hash)
+	if err != nil {
+		log.Printf("giv.TextBuf: Could not save undo history for file: %v, error: %v\n", tb.Filename, err)
+		return err
+	}
+	tb.UndoHistFile = fn
+	return nil
+}
+
+// OpenUndoHist restores the undo history saved for the current file, if
+// the SaveUndo option is set and the file is unchanged since the history
+// was saved -- returns true if restored
+func (tb *TextBuf) OpenUndoHist() bool {
+	tb.UndoHistFile = ""
+	if !tb.Opts.SaveUndo || tb.Filename == "" || tb.Undos.Off {
+		return false
+	}
+	dir := UndoHistDir()
+	if dir == "" {
+		return false
+	}
+	hash := UndoHistHash(tb.Filename, tb.Txt)
+	fn := UndoHistFilename(dir, hash)
+	if _, err := os.Stat(fn); err != nil {
+		return false
+	}
+	err := tb.Undos.OpenHistory(fn, hash)
+	if err != nil {
+		log.Printf("giv.TextBuf: Could not open undo history for file: %v, error: %v\n", tb.Filename, err)
+		tb.Undos.Reset()
+		return false
+	}
+	tb.UndoHistFile = fn
+	return true
+}
+
 // AdjustPos adjusts given text position, which was recorded at given time
 // for any edits that have taken place since that time (using the Undo stack).
 // del determines what to do with positions within a deleted region -- either move
@@ -68,3 +68,65 @@ func DiffLinesUnified(astr, bstr []string, context int, afile, adate, bfile, bda
 	difflib.WriteUnifiedDiff(&buf, ud)
 	return buf.Bytes()
 }
+
+// OverlapA returns the non-equal diff operations that overlap, or are
+// adjacent to, the given range of lines in buffer a (start inclusive,
+// end exclusive)
+func (di Diffs) OverlapA(st, ed int) Diffs {
+	var ov Diffs
+	for _, df := range di {
+		if df.Tag != 'e' && df.I1 <= ed && st <= df.I2 {
+			ov = append(ov, df)
+		}
+	}
+	return ov
+}
+
+// OverlapB returns the non-equal diff operations that overlap, or are
+// adjacent to, the given range of lines in buffer b (start inclusive,
+// end exclusive)
+func (di Diffs) OverlapB(st, ed int) Diffs {
+	var ov Diffs
+	for _, df := range di {
+		if df.Tag != 'e' && df.J1 <= ed && st <= df.J2 {
+			ov = append(ov, df)
+		}
+	}
+	return ov
+}
+
+// Reverse returns the diff operations that would convert buffer b into
+// buffer a, i.e., with the ranges of lines in a and b swapped, and the
+// deletes and inserts swapped.
+func (di Diffs) Reverse() Diffs {
+	rd := make(Diffs, len(di))
+	for i, df := range di {
+		switch df.Tag {
+		case 'd':
+			df.Tag = 'i'
+		case 'i':
+			df.Tag = 'd'
+		}
+		df.I1, df.I2, df.J1, df.J2 = df.J1, df.J2, df.I1, df.I2
+		rd[i] = df
+	}
+	return rd
+}
+
+// PatchLines returns a copy of the lines of buffer a with the given diff
+// operations (a subset of those from DiffLines(a, b), in order) applied,
+// replacing lines I1:I2 of a with lines J1:J2 of b for each -- e.g., to
+// apply just one hunk of the changes from a to b
+func PatchLines(astr, bstr []string, diffs Diffs) []string {
+	res := make([]string, len(astr))
+	copy(res, astr)
+	for i := len(diffs) - 1; i >= 0; i-- { // go in reverse so changes are valid!
+		df := diffs[i]
+		nr := make([]string, 0, len(res)+(df.J2-df.J1)-(df.I2-df.I1))
+		nr = append(nr, res[:df.I1]...)
+		nr = append(nr, bstr[df.J1:df.J2]...)
+		nr = append(nr, res[df.I2:]...)
+		res = nr
+	}
+	return res
+}
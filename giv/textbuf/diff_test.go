@@ -0,0 +1,50 @@
+// Copyright (c) 2020, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package textbuf
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPatchLines(t *testing.T) {
+	a := strings.Split("a\nb\nc\nd\ne\nf", "\n")
+	b := strings.Split("a\nB\nc\nd\nf\ng", "\n")
+	dfs := DiffLines(a, b)
+	if res := strings.Join(PatchLines(a, b, dfs), "\n"); res != strings.Join(b, "\n") {
+		t.Errorf("patching all diffs should give b, got: %q", res)
+	}
+	ov := dfs.OverlapB(1, 2)
+	if len(ov) != 1 || ov[0].Tag != 'r' {
+		t.Fatalf("expected one replace overlapping line 1 of b, got: %v", ov)
+	}
+	if res := strings.Join(PatchLines(a, b, ov), ""); res != "aBcdef" {
+		t.Errorf("patching one hunk wrong: %q", res)
+	}
+	ov = dfs.OverlapA(4, 5)
+	if len(ov) != 1 || ov[0].Tag != 'd' {
+		t.Fatalf("expected one delete overlapping line 4 of a, got: %v", ov)
+	}
+	if res := strings.Join(PatchLines(a, b, ov), ""); res != "abcdf" {
+		t.Errorf("patching delete hunk wrong: %q", res)
+	}
+}
+
+func TestDiffsReverse(t *testing.T) {
+	a := strings.Split("a\nb\nc\nd\ne\nf", "\n")
+	b := strings.Split("a\nB\nc\nd\nf\ng", "\n")
+	rd := DiffLines(a, b).Reverse()
+	if res := strings.Join(PatchLines(b, a, rd), "\n"); res != strings.Join(a, "\n") {
+		t.Errorf("patching all reversed diffs should give a, got: %q", res)
+	}
+	for i, df := range DiffLines(b, a) {
+		if df.Tag != rd[i].Tag {
+			t.Errorf("reversed diff %d: tag %c != %c", i, rd[i].Tag, df.Tag)
+		}
+	}
+	if rrd := rd.Reverse(); rrd.String() != DiffLines(a, b).String() {
+		t.Errorf("reversing twice should give the diffs, got: %v", rrd)
+	}
+}
@@ -91,17 +91,13 @@ func (te *Edit) AdjustPos(pos Pos, del AdjustPosDel) Pos {
 			}
 		}
 		// this means pos.Ln == te.Reg.End.Ln, Ch >= end
-		if dl == 0 {
-			pos.Ch -= (te.Reg.End.Ch - te.Reg.Start.Ch)
-		} else {
-			pos.Ch -= te.Reg.End.Ch
-		}
+		pos.Ln = te.Reg.Start.Ln
+		pos.Ch = te.Reg.Start.Ch + pos.Ch - te.Reg.End.Ch
 	} else {
-		if dl == 0 {
-			pos.Ch += (te.Reg.End.Ch - te.Reg.Start.Ch)
-		} else {
-			pos.Ln += dl
+		if pos.Ln == te.Reg.Start.Ln {
+			pos.Ch = te.Reg.End.Ch + pos.Ch - te.Reg.Start.Ch
 		}
+		pos.Ln += dl
 	}
 	return pos
 }
@@ -141,3 +137,25 @@ func (te *Edit) AdjustReg(reg Region) Region {
 	}
 	return reg
 }
+
+// AdjustCursor adjusts the position and selected region (RegionNil if none)
+// of a cursor as a function of the given edits, made in turn at another
+// cursor, for multi-cursor editing -- nil edits are skipped.  Positions
+// within a deleted region are moved to its start, so a selection that is
+// deleted entirely becomes RegionNil.
+func AdjustCursor(pos Pos, sel Region, edits []*Edit) (Pos, Region) {
+	for _, te := range edits {
+		if te == nil {
+			continue
+		}
+		pos = te.AdjustPos(pos, AdjustPosDelStart)
+		if !sel.IsNil() {
+			sel.Start = te.AdjustPos(sel.Start, AdjustPosDelStart)
+			sel.End = te.AdjustPos(sel.End, AdjustPosDelStart)
+			if sel.IsNil() {
+				sel = RegionNil
+			}
+		}
+	}
+	return pos, sel
+}
@@ -0,0 +1,85 @@
+// Copyright (c) 2020, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package textbuf
+
+import (
+	"testing"
+)
+
+func TestAdjustPos(t *testing.T) {
+	ins := &Edit{Reg: NewRegion(1, 2, 1, 5)}                // 3 chars inserted at 1:2
+	insl := &Edit{Reg: NewRegion(1, 2, 3, 1)}               // 2 lines inserted at 1:2
+	del := &Edit{Reg: NewRegion(1, 2, 1, 5), Delete: true}  // 3 chars deleted at 1:2
+	dell := &Edit{Reg: NewRegion(1, 2, 3, 1), Delete: true} // lines deleted from 1:2 to 3:1
+	tests := []struct {
+		te   *Edit
+		pos  Pos
+		del  AdjustPosDel
+		wpos Pos
+	}{
+		{nil, Pos{1, 8}, AdjustPosDelErr, Pos{1, 8}},
+		{ins, Pos{0, 8}, AdjustPosDelErr, Pos{0, 8}},
+		{ins, Pos{1, 2}, AdjustPosDelErr, Pos{1, 2}}, // at the start: not moved
+		{ins, Pos{1, 3}, AdjustPosDelErr, Pos{1, 6}},
+		{ins, Pos{1, 8}, AdjustPosDelErr, Pos{1, 11}},
+		{ins, Pos{2, 4}, AdjustPosDelErr, Pos{2, 4}},
+		{insl, Pos{1, 8}, AdjustPosDelErr, Pos{3, 7}},
+		{insl, Pos{2, 4}, AdjustPosDelErr, Pos{4, 4}},
+		{insl, Pos{4, 0}, AdjustPosDelErr, Pos{6, 0}},
+		{del, Pos{0, 8}, AdjustPosDelErr, Pos{0, 8}},
+		{del, Pos{1, 8}, AdjustPosDelErr, Pos{1, 5}},
+		{del, Pos{1, 5}, AdjustPosDelErr, Pos{1, 2}}, // at the end: moved to the start
+		{del, Pos{1, 3}, AdjustPosDelErr, PosErr},
+		{del, Pos{1, 3}, AdjustPosDelStart, Pos{1, 2}},
+		{del, Pos{1, 3}, AdjustPosDelEnd, Pos{1, 5}},
+		{del, Pos{2, 4}, AdjustPosDelErr, Pos{2, 4}},
+		{dell, Pos{2, 9}, AdjustPosDelErr, PosErr},
+		{dell, Pos{3, 0}, AdjustPosDelStart, Pos{1, 2}},
+		{dell, Pos{3, 0}, AdjustPosDelEnd, Pos{3, 1}},
+		{dell, Pos{3, 4}, AdjustPosDelErr, Pos{1, 5}},
+		{dell, Pos{5, 4}, AdjustPosDelErr, Pos{3, 4}},
+	}
+	for i, tst := range tests {
+		if pos := tst.te.AdjustPos(tst.pos, tst.del); pos != tst.wpos {
+			t.Errorf("test %d: AdjustPos(%v, %v): %v, want %v", i, tst.pos, tst.del, pos, tst.wpos)
+		}
+	}
+}
+
+func TestAdjustCursor(t *testing.T) {
+	ins := &Edit{Reg: NewRegion(1, 2, 1, 5)}                // 3 chars inserted at 1:2
+	insl := &Edit{Reg: NewRegion(1, 2, 3, 1)}               // 2 lines inserted at 1:2
+	del := &Edit{Reg: NewRegion(1, 2, 1, 5), Delete: true}  // 3 chars deleted at 1:2
+	dell := &Edit{Reg: NewRegion(1, 2, 3, 1), Delete: true} // lines deleted from 1:2 to 3:1
+	tests := []struct {
+		pos   Pos
+		sel   Region
+		edits []*Edit
+		wpos  Pos
+		wsel  Region
+	}{
+		{Pos{1, 8}, RegionNil, nil, Pos{1, 8}, RegionNil},
+		{Pos{1, 8}, RegionNil, []*Edit{nil, ins}, Pos{1, 11}, RegionNil},
+		{Pos{0, 8}, RegionNil, []*Edit{ins}, Pos{0, 8}, RegionNil},
+		{Pos{1, 2}, RegionNil, []*Edit{ins}, Pos{1, 2}, RegionNil}, // at the start: not moved
+		{Pos{2, 4}, RegionNil, []*Edit{ins}, Pos{2, 4}, RegionNil},
+		{Pos{1, 8}, RegionNil, []*Edit{insl}, Pos{3, 7}, RegionNil},
+		{Pos{2, 4}, RegionNil, []*Edit{insl}, Pos{4, 4}, RegionNil},
+		{Pos{1, 8}, RegionNil, []*Edit{del}, Pos{1, 5}, RegionNil},
+		{Pos{1, 3}, RegionNil, []*Edit{del}, Pos{1, 2}, RegionNil}, // within: at the start
+		{Pos{3, 4}, RegionNil, []*Edit{dell}, Pos{1, 5}, RegionNil},
+		{Pos{5, 4}, RegionNil, []*Edit{dell}, Pos{3, 4}, RegionNil},
+		{Pos{1, 8}, NewRegion(1, 6, 1, 8), []*Edit{del, ins}, Pos{1, 8}, NewRegion(1, 6, 1, 8)},
+		{Pos{1, 9}, NewRegion(1, 6, 1, 9), []*Edit{insl}, Pos{3, 8}, NewRegion(3, 5, 3, 8)},
+		{Pos{1, 4}, NewRegion(1, 3, 1, 4), []*Edit{del}, Pos{1, 2}, RegionNil}, // selection deleted
+		{Pos{1, 7}, NewRegion(1, 3, 1, 7), []*Edit{del}, Pos{1, 4}, NewRegion(1, 2, 1, 4)},
+	}
+	for i, tst := range tests {
+		pos, sel := AdjustCursor(tst.pos, tst.sel, tst.edits)
+		if pos != tst.wpos || !sameReg(sel, tst.wsel) {
+			t.Errorf("test %d: AdjustCursor(%v, %v): %v, %v, want %v, %v", i, tst.pos, tst.sel, pos, sel, tst.wpos, tst.wsel)
+		}
+	}
+}
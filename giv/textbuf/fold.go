@@ -0,0 +1,164 @@
+// Copyright (c) 2020, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package textbuf
+
+import (
+	"sort"
+
+	"github.com/goki/pi/lex"
+	"github.com/goki/pi/token"
+)
+
+// Folds are represented as a Region where Start is the end of the header
+// line that remains visible when folded, and End is the end of the last
+// line that is hidden -- i.e., lines Start.Ln+1 .. End.Ln are hidden.
+
+// FoldIndent returns the foldable regions in given source based on indentation:
+// a fold starts at any line whose following non-blank lines are indented
+// more deeply than it, and extends through the last such line.
+// indents gives the indentation width of each line, which is ignored for
+// blank lines (len(src[ln]) == 0 or all whitespace).
+func FoldIndent(src [][]rune, indents []int) []Region {
+	nln := len(src)
+	blank := make([]bool, nln)
+	for ln, txt := range src {
+		blank[ln] = isBlank(txt)
+	}
+	var folds []Region
+	for ln := 0; ln < nln; ln++ {
+		if blank[ln] {
+			continue
+		}
+		ind := indents[ln]
+		last := -1
+		for l := ln + 1; l < nln; l++ {
+			if blank[l] {
+				continue
+			}
+			if indents[l] <= ind {
+				break
+			}
+			last = l
+		}
+		if last > ln {
+			folds = append(folds, NewRegion(ln, len(src[ln]), last, len(src[last])))
+		}
+	}
+	return folds
+}
+
+// FoldBraces returns the foldable regions in given source based on
+// the brace, bracket and paren grouping punctuation in the lexer tags,
+// and on blocks of consecutive comment lines.  The fold for a group runs
+// from the line with the opening punctuation through the line before the
+// closing one, which thus remains visible.  Only the outermost group starting
+// on a given line is used.
+func FoldBraces(src [][]rune, tags []lex.Line) []Region {
+	nln := len(src)
+	if len(tags) < nln {
+		nln = len(tags)
+	}
+	type gpSt struct {
+		tok token.Tokens
+		ln  int
+	}
+	var stack []gpSt
+	starts := make(map[int]int) // start line -> end line
+	for ln := 0; ln < nln; ln++ {
+		for _, t := range tags[ln] {
+			tk := t.Tok.Tok
+			switch {
+			case tk.IsPunctGpLeft():
+				stack = append(stack, gpSt{tk, ln})
+			case tk.IsPunctGpRight():
+				sz := len(stack)
+				if sz == 0 || stack[sz-1].tok.PunctGpMatch() != tk {
+					continue // unbalanced -- ignore
+				}
+				st := stack[sz-1].ln
+				stack = stack[:sz-1]
+				ed := ln - 1
+				if ed <= st {
+					continue
+				}
+				if ced, has := starts[st]; !has || ed > ced {
+					starts[st] = ed
+				}
+			}
+		}
+	}
+	// blocks of comment-only lines
+	for ln := 0; ln < nln; ln++ {
+		if !isCommentLine(tags[ln]) {
+			continue
+		}
+		ed := ln
+		for ed+1 < nln && isCommentLine(tags[ed+1]) {
+			ed++
+		}
+		if ed > ln {
+			if _, has := starts[ln]; !has {
+				starts[ln] = ed
+			}
+		}
+		ln = ed
+	}
+	folds := make([]Region, 0, len(starts))
+	for st, ed := range starts {
+		folds = append(folds, NewRegion(st, len(src[st]), ed, len(src[ed])))
+	}
+	sort.Slice(folds, func(i, j int) bool {
+		return folds[i].Start.Ln < folds[j].Start.Ln
+	})
+	return folds
+}
+
+// AdjustFold adjusts the given fold region as a function of the edit,
+// returning false if the edit modified any of the hidden lines, or the
+// line breaks around them, in which case the fold is no longer valid
+// and should be removed.
+func AdjustFold(reg Region, tbe *Edit) (Region, bool) {
+	if tbe == nil {
+		return reg, true
+	}
+	hst := Pos{Ln: reg.Start.Ln + 1}
+	hed := Pos{Ln: reg.End.Ln + 1}
+	st := tbe.Reg.Start
+	if tbe.Delete {
+		if st.IsLess(hed) && !tbe.Reg.End.IsLess(hst) {
+			return reg, false
+		}
+	} else {
+		if st.Ln >= reg.Start.Ln && st.IsLess(hed) && (st.Ln > reg.Start.Ln || tbe.Reg.End.Ln > st.Ln) {
+			return reg, false
+		}
+	}
+	reg.Start = tbe.AdjustPos(reg.Start, AdjustPosDelStart)
+	reg.End = tbe.AdjustPos(reg.End, AdjustPosDelEnd)
+	return reg, true
+}
+
+// isBlank returns true if line is empty or only whitespace
+func isBlank(txt []rune) bool {
+	for _, r := range txt {
+		if r != ' ' && r != '\t' {
+			return false
+		}
+	}
+	return true
+}
+
+// isCommentLine returns true if the line has only comment tokens
+func isCommentLine(tags lex.Line) bool {
+	if len(tags) == 0 {
+		return false
+	}
+	for _, t := range tags {
+		if t.Tok.Tok.Cat() != token.Comment {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,79 @@
+// Copyright (c) 2020, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package textbuf
+
+import (
+	"testing"
+
+	"github.com/goki/pi/lex"
+	"github.com/goki/pi/token"
+)
+
+func testTag(tk token.Tokens, st int) lex.Lex {
+	return lex.NewLex(token.KeyToken{Tok: tk}, st, st+1)
+}
+
+func TestFoldIndent(t *testing.T) {
+	src := testLines("def f():", "    x = 1", "", "    if x:", "        y", "z")
+	indents := []int{0, 4, 0, 4, 8, 0}
+	folds := FoldIndent(src, indents)
+	if len(folds) != 2 {
+		t.Fatalf("expected 2 folds, got: %v", folds)
+	}
+	if folds[0].Start.Ln != 0 || folds[0].End.Ln != 4 || folds[1].Start.Ln != 3 || folds[1].End.Ln != 4 {
+		t.Errorf("indent folds wrong: %v", folds)
+	}
+}
+
+func TestFoldBraces(t *testing.T) {
+	src := testLines("// a", "// b", "func f() {", "	x := []int{", "		1,", "	}", "}")
+	tags := []lex.Line{
+		{testTag(token.CommentSingle, 0)},
+		{testTag(token.CommentSingle, 0)},
+		{testTag(token.PunctGpLParen, 6), testTag(token.PunctGpRParen, 7), testTag(token.PunctGpLBrace, 9)},
+		{testTag(token.PunctGpLBrace, 11)},
+		{},
+		{testTag(token.PunctGpRBrace, 1)},
+		{testTag(token.PunctGpRBrace, 0)},
+	}
+	folds := FoldBraces(src, tags)
+	if len(folds) != 3 {
+		t.Fatalf("expected 3 folds, got: %v", folds)
+	}
+	exp := [][2]int{{0, 1}, {2, 5}, {3, 4}}
+	for i, f := range folds {
+		if f.Start.Ln != exp[i][0] || f.End.Ln != exp[i][1] {
+			t.Errorf("fold %d: expected lines %v, got: %v", i, exp[i], f)
+		}
+	}
+}
+
+func TestAdjustFold(t *testing.T) {
+	reg := NewRegion(2, 10, 5, 3)
+	ins := &Edit{Reg: NewRegion(0, 0, 2, 0)}
+	if nr, ok := AdjustFold(reg, ins); !ok || nr.Start.Ln != 4 || nr.End.Ln != 7 {
+		t.Errorf("insert before: %v %v", ok, nr)
+	}
+	ins = &Edit{Reg: NewRegion(2, 4, 2, 6)}
+	if nr, ok := AdjustFold(reg, ins); !ok || nr.Start != (Pos{2, 12}) || nr.End.Ln != 5 {
+		t.Errorf("insert in header: %v %v", ok, nr)
+	}
+	ins = &Edit{Reg: NewRegion(2, 10, 3, 0)}
+	if _, ok := AdjustFold(reg, ins); ok {
+		t.Errorf("newline at end of header should remove fold")
+	}
+	del := &Edit{Reg: NewRegion(4, 0, 4, 2), Delete: true}
+	if _, ok := AdjustFold(reg, del); ok {
+		t.Errorf("delete in hidden lines should remove fold")
+	}
+	del = &Edit{Reg: NewRegion(6, 0, 8, 0), Delete: true}
+	if nr, ok := AdjustFold(reg, del); !ok || nr != reg {
+		t.Errorf("delete after: %v %v", ok, nr)
+	}
+	del = &Edit{Reg: NewRegion(1, 3, 2, 0), Delete: true}
+	if nr, ok := AdjustFold(reg, del); !ok || nr.Start != (Pos{1, 13}) || nr.End.Ln != 4 {
+		t.Errorf("delete joining header: %v %v", ok, nr)
+	}
+}
@@ -0,0 +1,159 @@
+// Copyright (c) 2020, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package textbuf
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// IgnoreRule is one pattern from a .gitignore file, which applies to the
+// files and directories within the directory containing the file
+type IgnoreRule struct {
+	Dir     string         `desc:"directory containing the .gitignore file -- the pattern applies to paths within it"`
+	Pattern string         `desc:"pattern as given in the file"`
+	Negate  bool           `desc:"pattern started with ! -- matching paths are included again"`
+	DirOnly bool           `desc:"pattern ended with / -- only matches directories"`
+	Re      *regexp.Regexp `desc:"compiled pattern, matched against the slash-separated path relative to Dir"`
+}
+
+// Ignores is a list of .gitignore rules, in order of precedence: the last
+// rule matching a path determines whether it is ignored
+type Ignores []IgnoreRule
+
+// IgnoreFileName is the name of the files containing ignore rules
+var IgnoreFileName = ".gitignore"
+
+// ParseIgnore parses the rules in the contents of a .gitignore file in given
+// directory -- blank lines and # comments are skipped, patterns without a
+// slash (other than at the end) match a name at any level below dir, and
+// others match relative to dir, with * and ? not matching a / and ** matching
+// any number of directories.
+func ParseIgnore(data []byte, dir string) Ignores {
+	var ig Ignores
+	for _, bl := range bytes.Split(data, []byte("\n")) {
+		ln := strings.TrimRight(string(bl), "\r")
+		if !strings.HasSuffix(ln, `\ `) {
+			ln = strings.TrimRight(ln, " ")
+		}
+		if ln == "" || ln[0] == '#' {
+			continue
+		}
+		ir := IgnoreRule{Dir: dir, Pattern: ln}
+		if ln[0] == '!' {
+			ir.Negate = true
+			ln = ln[1:]
+		} else if ln[0] == '\\' && len(ln) > 1 && (ln[1] == '!' || ln[1] == '#') {
+			ln = ln[1:]
+		}
+		if strings.HasSuffix(ln, "/") {
+			ir.DirOnly = true
+			ln = strings.TrimRight(ln, "/")
+		}
+		if ln == "" {
+			continue
+		}
+		anchored := strings.Contains(ln, "/")
+		ln = strings.TrimPrefix(ln, "/")
+		rs := ignoreRegexp(ln)
+		if !anchored {
+			rs = "(.*/)?" + rs
+		}
+		re, err := regexp.Compile("^" + rs + "$")
+		if err != nil {
+			continue
+		}
+		ir.Re = re
+		ig = append(ig, ir)
+	}
+	return ig
+}
+
+// ignoreRegexp returns the regexp for a glob pattern in a .gitignore file
+func ignoreRegexp(pat string) string {
+	var b strings.Builder
+	rs := []rune(pat)
+	for i := 0; i < len(rs); i++ {
+		r := rs[i]
+		switch r {
+		case '*':
+			if i+1 < len(rs) && rs[i+1] == '*' {
+				i++
+				switch {
+				case i+1 < len(rs) && rs[i+1] == '/': // **/ = zero or more dirs
+					i++
+					b.WriteString("(.*/)?")
+				default:
+					b.WriteString(".*")
+				}
+			} else {
+				b.WriteString("[^/]*")
+			}
+		case '?':
+			b.WriteString("[^/]")
+		case '[':
+			ed := i + 1
+			for ed < len(rs) && rs[ed] != ']' {
+				ed++
+			}
+			if ed == len(rs) {
+				b.WriteString(`\[`)
+				continue
+			}
+			cls := string(rs[i+1 : ed])
+			i = ed
+			if strings.HasPrefix(cls, "!") {
+				cls = "^" + cls[1:]
+			}
+			b.WriteString("[" + cls + "]")
+		case '\\':
+			if i+1 < len(rs) {
+				i++
+				b.WriteString(regexp.QuoteMeta(string(rs[i])))
+			}
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	return b.String()
+}
+
+// ReadIgnoreFile reads the .gitignore file in given directory, returning
+// nil if there is none
+func ReadIgnoreFile(dir string) Ignores {
+	data, err := ioutil.ReadFile(filepath.Join(dir, IgnoreFileName))
+	if err != nil {
+		return nil
+	}
+	return ParseIgnore(data, dir)
+}
+
+// Ignored returns true if the file or directory at given path is ignored by
+// the rules.  It does not check whether a directory containing the path is
+// itself ignored -- when walking a tree, ignored directories should not be
+// entered.
+func (ig Ignores) Ignored(path string, isDir bool) bool {
+	ignored := false
+	for i := range ig {
+		ir := &ig[i]
+		if ir.DirOnly && !isDir {
+			continue
+		}
+		if ir.Negate != ignored { // can only change the result
+			continue
+		}
+		rel, err := filepath.Rel(ir.Dir, path)
+		if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+			continue
+		}
+		if ir.Re.MatchString(filepath.ToSlash(rel)) {
+			ignored = !ir.Negate
+		}
+	}
+	return ignored
+}
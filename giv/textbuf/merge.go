@@ -0,0 +1,338 @@
+// Copyright (c) 2020, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package textbuf
+
+import (
+	"strings"
+)
+
+// MergeKinds are the kinds of chunks in a three-way merge
+type MergeKinds int
+
+const (
+	// MergeEqual is a chunk that is the same in all three versions
+	MergeEqual MergeKinds = iota
+
+	// MergeOurs is a chunk changed only in ours, which is taken
+	MergeOurs
+
+	// MergeTheirs is a chunk changed only in theirs, which is taken
+	MergeTheirs
+
+	// MergeSame is a chunk changed in the same way in ours and theirs
+	MergeSame
+
+	// MergeConflict is a chunk changed differently in ours and theirs,
+	// which must be resolved
+	MergeConflict
+)
+
+// MergeChunk is one chunk of a three-way merge, covering a range of lines
+// (start inclusive, end exclusive) in each of the base, ours and theirs
+// versions.
+type MergeChunk struct {
+	Kind     MergeKinds
+	BaseSt   int
+	BaseEd   int
+	OursSt   int
+	OursEd   int
+	TheirsSt int
+	TheirsEd int
+}
+
+// MergeChunks are the chunks of a three-way merge, in order, which
+// together cover all the lines of each version
+type MergeChunks []MergeChunk
+
+// the conflict markers, as written by git
+const (
+	ConflictOursMarker   = "<<<<<<<"
+	ConflictBaseMarker   = "|||||||"
+	ConflictSepMarker    = "======="
+	ConflictTheirsMarker = ">>>>>>>"
+)
+
+// MergeLines computes the three-way merge of the ours and theirs versions
+// of the lines of text, relative to their common base version.  Chunks of
+// lines changed on only one side relative to the base are taken from
+// that side, and overlapping (or adjacent) changes on both sides are
+// conflicts, unless they are the same.
+func MergeLines(base, ours, theirs []string) MergeChunks {
+	type hunk struct{ I1, I2, J1, J2 int }
+	hunks := func(df Diffs) []hunk {
+		var hs []hunk
+		for _, d := range df {
+			if d.Tag != 'e' {
+				hs = append(hs, hunk{d.I1, d.I2, d.J1, d.J2})
+			}
+		}
+		return hs
+	}
+	ho := hunks(DiffLines(base, ours))
+	ht := hunks(DiffLines(base, theirs))
+	var mc MergeChunks
+	bpos, offo, offt := 0, 0, 0 // offsets from base to ours, theirs lines
+	oi, ti := 0, 0
+	for oi < len(ho) || ti < len(ht) {
+		var st int
+		switch {
+		case oi == len(ho):
+			st = ht[ti].I1
+		case ti == len(ht):
+			st = ho[oi].I1
+		default:
+			st = ho[oi].I1
+			if ht[ti].I1 < st {
+				st = ht[ti].I1
+			}
+		}
+		ed := st
+		oSt, tSt := oi, ti
+		for { // extend region with all overlapping or adjacent hunks
+			ext := false
+			if oi < len(ho) && ho[oi].I1 <= ed {
+				if ho[oi].I2 > ed {
+					ed = ho[oi].I2
+				}
+				oi++
+				ext = true
+			}
+			if ti < len(ht) && ht[ti].I1 <= ed {
+				if ht[ti].I2 > ed {
+					ed = ht[ti].I2
+				}
+				ti++
+				ext = true
+			}
+			if !ext {
+				break
+			}
+		}
+		if st > bpos {
+			mc = append(mc, MergeChunk{Kind: MergeEqual, BaseSt: bpos, BaseEd: st, OursSt: bpos + offo, OursEd: st + offo, TheirsSt: bpos + offt, TheirsEd: st + offt})
+		}
+		ch := MergeChunk{BaseSt: st, BaseEd: ed, OursSt: st + offo, TheirsSt: st + offt}
+		for _, h := range ho[oSt:oi] {
+			offo += (h.J2 - h.J1) - (h.I2 - h.I1)
+		}
+		for _, h := range ht[tSt:ti] {
+			offt += (h.J2 - h.J1) - (h.I2 - h.I1)
+		}
+		ch.OursEd = ed + offo
+		ch.TheirsEd = ed + offt
+		switch {
+		case oi == oSt:
+			ch.Kind = MergeTheirs
+		case ti == tSt:
+			ch.Kind = MergeOurs
+		case linesEqual(ours[ch.OursSt:ch.OursEd], theirs[ch.TheirsSt:ch.TheirsEd]):
+			ch.Kind = MergeSame
+		default:
+			ch.Kind = MergeConflict
+		}
+		mc = append(mc, ch)
+		bpos = ed
+	}
+	if bpos < len(base) || len(mc) == 0 {
+		mc = append(mc, MergeChunk{Kind: MergeEqual, BaseSt: bpos, BaseEd: len(base), OursSt: bpos + offo, OursEd: len(base) + offo, TheirsSt: bpos + offt, TheirsEd: len(base) + offt})
+	}
+	return mc
+}
+
+func linesEqual(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// NConflicts returns the number of conflict chunks
+func (mc MergeChunks) NConflicts() int {
+	n := 0
+	for _, ch := range mc {
+		if ch.Kind == MergeConflict {
+			n++
+		}
+	}
+	return n
+}
+
+// Ours returns the lines of ours for this chunk
+func (ch *MergeChunk) Ours(ours []string) []string {
+	return ours[ch.OursSt:ch.OursEd]
+}
+
+// Theirs returns the lines of theirs for this chunk
+func (ch *MergeChunk) Theirs(theirs []string) []string {
+	return theirs[ch.TheirsSt:ch.TheirsEd]
+}
+
+// Base returns the lines of base for this chunk
+func (ch *MergeChunk) Base(base []string) []string {
+	return base[ch.BaseSt:ch.BaseEd]
+}
+
+// Merged returns the merged lines for this chunk: for a conflict, this
+// is both versions (and the base if withBase), with conflict markers
+// using given labels for ours and theirs
+func (ch *MergeChunk) Merged(base, ours, theirs []string, withBase bool, oursLabel, theirsLabel string) []string {
+	switch ch.Kind {
+	case MergeEqual, MergeOurs, MergeSame:
+		return ch.Ours(ours)
+	case MergeTheirs:
+		return ch.Theirs(theirs)
+	}
+	var lns []string
+	lns = append(lns, strings.TrimSpace(ConflictOursMarker+" "+oursLabel))
+	lns = append(lns, ch.Ours(ours)...)
+	if withBase {
+		lns = append(lns, ConflictBaseMarker+" base")
+		lns = append(lns, ch.Base(base)...)
+	}
+	lns = append(lns, ConflictSepMarker)
+	lns = append(lns, ch.Theirs(theirs)...)
+	lns = append(lns, strings.TrimSpace(ConflictTheirsMarker+" "+theirsLabel))
+	return lns
+}
+
+// Merged returns the merged lines for all the chunks -- see
+// MergeChunk.Merged
+func (mc MergeChunks) Merged(base, ours, theirs []string, withBase bool, oursLabel, theirsLabel string) []string {
+	var lns []string
+	for i := range mc {
+		lns = append(lns, mc[i].Merged(base, ours, theirs, withBase, oursLabel, theirsLabel)...)
+	}
+	return lns
+}
+
+/////////////////////////////////////////////////////////////////////////////
+//   Conflict markers
+
+// Conflict is a region of text with conflict markers, as written by git
+// for a merge conflict
+type Conflict struct {
+	St          int      `desc:"line of the starting <<<<<<< marker"`
+	Ed          int      `desc:"line after the ending >>>>>>> marker"`
+	Ours        []string `desc:"lines of ours, between the <<<<<<< marker and the next marker"`
+	Base        []string `desc:"lines of the base, between the ||||||| and ======= markers, if present (diff3 style)"`
+	Theirs      []string `desc:"lines of theirs, between the ======= and >>>>>>> markers"`
+	HasBase     bool     `desc:"whether the base was present"`
+	OursLabel   string   `desc:"label after the <<<<<<< marker, e.g., HEAD"`
+	TheirsLabel string   `desc:"label after the >>>>>>> marker, e.g., the branch being merged"`
+}
+
+// markerLabel returns true and the label after the marker if line starts
+// with given marker, followed by a space or nothing
+func markerLabel(ln, marker string) (string, bool) {
+	if !strings.HasPrefix(ln, marker) {
+		return "", false
+	}
+	rest := ln[len(marker):]
+	if rest != "" && rest[0] != ' ' {
+		return "", false
+	}
+	return strings.TrimSpace(rest), true
+}
+
+// FindConflicts returns all the complete conflict regions marked in the
+// lines of text
+func FindConflicts(lines []string) []Conflict {
+	var cfs []Conflict
+	for i := 0; i < len(lines); i++ {
+		lbl, ok := markerLabel(lines[i], ConflictOursMarker)
+		if !ok {
+			continue
+		}
+		cf := Conflict{St: i, OursLabel: lbl}
+		part := 0 // 0 = ours, 1 = base, 2 = theirs
+		done := false
+		j := i + 1
+		for ; j < len(lines) && !done; j++ {
+			ln := lines[j]
+			if _, ok := markerLabel(ln, ConflictOursMarker); ok {
+				break // nested start -- treat as new conflict
+			}
+			if _, ok := markerLabel(ln, ConflictBaseMarker); ok && part == 0 {
+				part = 1
+				cf.HasBase = true
+				continue
+			}
+			if ln == ConflictSepMarker && part < 2 {
+				part = 2
+				continue
+			}
+			if lbl, ok := markerLabel(ln, ConflictTheirsMarker); ok && part == 2 {
+				cf.TheirsLabel = lbl
+				cf.Ed = j + 1
+				done = true
+				continue
+			}
+			switch part {
+			case 0:
+				cf.Ours = append(cf.Ours, ln)
+			case 1:
+				cf.Base = append(cf.Base, ln)
+			case 2:
+				cf.Theirs = append(cf.Theirs, ln)
+			}
+		}
+		if done {
+			cfs = append(cfs, cf)
+			i = cf.Ed - 1
+		} else {
+			i = j - 1
+		}
+	}
+	return cfs
+}
+
+// HasConflicts returns true if the lines of text have any conflict markers
+func HasConflicts(lines []string) bool {
+	return len(FindConflicts(lines)) > 0
+}
+
+// HasConflictMarkers returns true if any of the lines of text is a
+// conflict marker, even if not part of a complete conflict region
+func HasConflictMarkers(lines []string) bool {
+	for _, ln := range lines {
+		if ln == ConflictSepMarker {
+			return true
+		}
+		for _, mk := range []string{ConflictOursMarker, ConflictBaseMarker, ConflictTheirsMarker} {
+			if _, ok := markerLabel(ln, mk); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ConflictVersions reconstructs the base, ours and theirs versions of text
+// with conflict markers -- the base of a conflict is empty if it was not
+// present in the markers
+func ConflictVersions(lines []string) (base, ours, theirs []string) {
+	cfs := FindConflicts(lines)
+	pos := 0
+	for _, cf := range cfs {
+		com := lines[pos:cf.St]
+		base = append(base, com...)
+		ours = append(ours, com...)
+		theirs = append(theirs, com...)
+		base = append(base, cf.Base...)
+		ours = append(ours, cf.Ours...)
+		theirs = append(theirs, cf.Theirs...)
+		pos = cf.Ed
+	}
+	com := lines[pos:]
+	base = append(base, com...)
+	ours = append(ours, com...)
+	theirs = append(theirs, com...)
+	return
+}
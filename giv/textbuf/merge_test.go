@@ -0,0 +1,63 @@
+// Copyright (c) 2020, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package textbuf
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMergeLines(t *testing.T) {
+	base := strings.Split("a\nb\nc\nd\ne\nf", "\n")
+	ours := strings.Split("a\nB\nc\nd\nE1\nf", "\n")
+	theirs := strings.Split("a\nb\nc\nC2\nd\nE2\nf\ng", "\n")
+	mc := MergeLines(base, ours, theirs)
+	kinds := []MergeKinds{MergeEqual, MergeOurs, MergeEqual, MergeTheirs, MergeEqual, MergeConflict, MergeEqual, MergeTheirs}
+	if len(mc) != len(kinds) {
+		t.Fatalf("expected %d chunks, got: %+v", len(kinds), mc)
+	}
+	for i, ch := range mc {
+		if ch.Kind != kinds[i] {
+			t.Errorf("chunk %d: expected kind %d, got %+v", i, kinds[i], ch)
+		}
+	}
+	if mc.NConflicts() != 1 {
+		t.Errorf("expected 1 conflict")
+	}
+	res := strings.Join(mc.Merged(base, ours, theirs, false, "ours", "theirs"), "\n")
+	exp := "a\nB\nc\nC2\nd\n<<<<<<< ours\nE1\n=======\nE2\n>>>>>>> theirs\nf\ng"
+	if res != exp {
+		t.Errorf("merged wrong:\n%s\nexpected:\n%s", res, exp)
+	}
+
+	same := MergeLines(base, ours, ours)
+	if same.NConflicts() != 0 || strings.Join(same.Merged(base, ours, ours, false, "", ""), "\n") != strings.Join(ours, "\n") {
+		t.Errorf("identical changes should merge: %+v", same)
+	}
+}
+
+func TestConflicts(t *testing.T) {
+	lns := strings.Split("a\n<<<<<<< HEAD\nb1\n||||||| base\nb\n=======\nb2\n>>>>>>> topic\nc\n<<<<<<< HEAD\nd1\n=======\n>>>>>>> topic\ne", "\n")
+	cfs := FindConflicts(lns)
+	if len(cfs) != 2 {
+		t.Fatalf("expected 2 conflicts, got: %+v", cfs)
+	}
+	if cfs[0].St != 1 || cfs[0].Ed != 8 || !cfs[0].HasBase || cfs[0].OursLabel != "HEAD" || cfs[0].TheirsLabel != "topic" || cfs[0].Base[0] != "b" {
+		t.Errorf("first conflict wrong: %+v", cfs[0])
+	}
+	if cfs[1].HasBase || len(cfs[1].Ours) != 1 || len(cfs[1].Theirs) != 0 {
+		t.Errorf("second conflict wrong: %+v", cfs[1])
+	}
+	base, ours, theirs := ConflictVersions(lns)
+	if strings.Join(base, "") != "abce" || strings.Join(ours, "") != "ab1cd1e" || strings.Join(theirs, "") != "ab2ce" {
+		t.Errorf("versions wrong: %v %v %v", base, ours, theirs)
+	}
+	if HasConflicts(base) || HasConflictMarkers(base) {
+		t.Errorf("base should have no conflicts")
+	}
+	if HasConflicts(lns[:4]) || !HasConflictMarkers(lns[:4]) {
+		t.Errorf("incomplete conflict should only have markers")
+	}
+}
@@ -0,0 +1,20 @@
+// Copyright (c) 2020, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !linux && !darwin && !freebsd && !netbsd && !openbsd && !dragonfly
+// +build !linux,!darwin,!freebsd,!netbsd,!openbsd,!dragonfly
+
+package textbuf
+
+import (
+	"io/ioutil"
+	"os"
+)
+
+// mmapFile reads the contents of given file into memory, as memory-mapping
+// is not supported on this platform
+func mmapFile(f *os.File, size int64) ([]byte, func() error, error) {
+	b, err := ioutil.ReadAll(f)
+	return b, nil, err
+}
@@ -0,0 +1,371 @@
+// Copyright (c) 2020, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package textbuf
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+)
+
+// piece is a range of bytes in either the original or the add buffer
+type piece struct {
+	add bool
+	off int
+	len int
+}
+
+// PieceTable is storage for the text of a large file, which keeps the
+// original contents of the file read-only (memory-mapped where supported,
+// see OpenPieceTable), and records edits as a sequence of pieces of the
+// original and of an append-only buffer of added text, so that editing
+// never copies the whole file.  Lines are terminated by \n, with the
+// byte offsets of the line starts indexed lazily, only as far as needed
+// to find the lines asked for -- edits discard the index after the edit.
+// It is not safe for concurrent use.
+type PieceTable struct {
+	orig      []byte
+	add       []byte
+	pieces    []piece
+	size      int
+	lnOffs    []int
+	lnDone    bool
+	file      *os.File
+	unmap     func() error
+	truncated bool
+}
+
+// NewPieceTable returns a new PieceTable with given original text, which
+// must not be modified while the table is in use
+func NewPieceTable(txt []byte) *PieceTable {
+	pt := &PieceTable{orig: txt, size: len(txt), lnOffs: []int{0}}
+	if len(txt) > 0 {
+		pt.pieces = []piece{{off: 0, len: len(txt)}}
+	}
+	return pt
+}
+
+// OpenPieceTable returns a new PieceTable for the contents of given file,
+// which are memory-mapped where supported, and otherwise read into
+// memory.  The file must not be modified while the table is in use --
+// Close the table before writing the file, or write a new file and rename
+// it over the original.  If the file is truncated anyway, its contents are
+// read into memory as far as they still exist -- see Truncated.
+func OpenPieceTable(filename string) (*PieceTable, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	st, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	txt, unmap, err := mmapFile(f, st.Size())
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("textbuf.OpenPieceTable: %v", err)
+	}
+	pt := NewPieceTable(txt)
+	if unmap == nil {
+		f.Close()
+		return pt, nil
+	}
+	pt.file = f // kept open to check its size, see checkOrig
+	pt.unmap = unmap
+	return pt, nil
+}
+
+// checkOrig checks that the file of memory-mapped original contents has
+// not been truncated, as accessing the mapping past the end of the file
+// would crash the program (SIGBUS) -- if it has, the mapping is replaced by
+// the contents that still exist, read into memory, with zeros for the rest.
+// Called before each access to the original contents.
+func (pt *PieceTable) checkOrig() {
+	if pt.file == nil {
+		return
+	}
+	if st, err := pt.file.Stat(); err == nil && st.Size() >= int64(len(pt.orig)) {
+		return
+	}
+	orig := make([]byte, len(pt.orig))
+	pt.file.ReadAt(orig, 0) // short read up to the new end
+	pt.releaseOrig()
+	pt.orig = orig
+	pt.truncated = true
+}
+
+// Truncated returns true if the file of memory-mapped original contents
+// has been found to be truncated while in use, so the contents past its new
+// end were lost, and are zeros in the table
+func (pt *PieceTable) Truncated() bool {
+	return pt.truncated
+}
+
+// releaseOrig unmaps the original contents and closes their file, if
+// memory-mapped
+func (pt *PieceTable) releaseOrig() error {
+	if pt.file == nil {
+		return nil
+	}
+	err := pt.unmap()
+	pt.file.Close()
+	pt.file = nil
+	pt.unmap = nil
+	return err
+}
+
+// Close releases the memory-mapped original contents -- the table must not
+// be used after closing
+func (pt *PieceTable) Close() error {
+	pt.pieces = nil
+	pt.orig = nil
+	pt.add = nil
+	pt.size = 0
+	pt.lnOffs = []int{0}
+	pt.lnDone = false
+	return pt.releaseOrig()
+}
+
+// Len returns the total number of bytes
+func (pt *PieceTable) Len() int {
+	return pt.size
+}
+
+// bytes returns the bytes of given piece
+func (pt *PieceTable) bytes(p piece) []byte {
+	if p.add {
+		return pt.add[p.off : p.off+p.len]
+	}
+	return pt.orig[p.off : p.off+p.len]
+}
+
+// pieceAt returns the index of the piece containing given offset, and the
+// offset of the start of that piece -- returns len(pieces) at the end
+func (pt *PieceTable) pieceAt(off int) (int, int) {
+	st := 0
+	for i, p := range pt.pieces {
+		if off < st+p.len {
+			return i, st
+		}
+		st += p.len
+	}
+	return len(pt.pieces), st
+}
+
+// Slice returns a copy of the bytes from st up to ed
+func (pt *PieceTable) Slice(st, ed int) []byte {
+	if st < 0 {
+		st = 0
+	}
+	if ed > pt.size {
+		ed = pt.size
+	}
+	if ed <= st {
+		return nil
+	}
+	pt.checkOrig()
+	b := make([]byte, 0, ed-st)
+	pi, pst := pt.pieceAt(st)
+	for ; pi < len(pt.pieces) && pst < ed; pi++ {
+		p := pt.pieces[pi]
+		pb := pt.bytes(p)
+		s := 0
+		if st > pst {
+			s = st - pst
+		}
+		e := p.len
+		if ed < pst+p.len {
+			e = ed - pst
+		}
+		b = append(b, pb[s:e]...)
+		pst += p.len
+	}
+	return b
+}
+
+// Bytes returns a copy of all the bytes
+func (pt *PieceTable) Bytes() []byte {
+	return pt.Slice(0, pt.size)
+}
+
+// WriteTo writes all the bytes to given writer, without copying them
+func (pt *PieceTable) WriteTo(w io.Writer) (int64, error) {
+	pt.checkOrig()
+	var n int64
+	for _, p := range pt.pieces {
+		m, err := w.Write(pt.bytes(p))
+		n += int64(m)
+		if err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
+
+// indexTo indexes the starts of lines until line ln+1 is indexed, or the
+// end is reached
+func (pt *PieceTable) indexTo(ln int) {
+	if pt.lnDone || len(pt.lnOffs) > ln+1 {
+		return
+	}
+	pt.checkOrig()
+	off := pt.lnOffs[len(pt.lnOffs)-1]
+	pi, pst := pt.pieceAt(off)
+	for ; pi < len(pt.pieces); pi++ {
+		p := pt.pieces[pi]
+		pb := pt.bytes(p)
+		s := 0
+		if off > pst {
+			s = off - pst
+		}
+		for {
+			i := bytes.IndexByte(pb[s:], '\n')
+			if i < 0 {
+				break
+			}
+			s += i + 1
+			pt.lnOffs = append(pt.lnOffs, pst+s)
+			if len(pt.lnOffs) > ln+1 {
+				return
+			}
+		}
+		pst += p.len
+	}
+	pt.lnDone = true
+}
+
+// IndexedLines returns the number of lines whose start has been indexed so
+// far, which is the total number of lines if all have been indexed
+func (pt *PieceTable) IndexedLines() int {
+	n := len(pt.lnOffs)
+	if pt.lnOffs[n-1] >= pt.size {
+		n--
+	}
+	return n
+}
+
+// NumLines returns the number of lines, indexing all of them if not yet
+// done -- the text after the last \n is a line if not empty
+func (pt *PieceTable) NumLines() int {
+	pt.indexTo(pt.size + 1)
+	return pt.IndexedLines()
+}
+
+// LineStart returns the byte offset of the start of given line, or the
+// total number of bytes if the line is at or past the end
+func (pt *PieceTable) LineStart(ln int) int {
+	if ln <= 0 {
+		return 0
+	}
+	pt.indexTo(ln)
+	if ln < len(pt.lnOffs) {
+		return pt.lnOffs[ln]
+	}
+	return pt.size
+}
+
+// Line returns a copy of the bytes of given line, without the \n
+func (pt *PieceTable) Line(ln int) []byte {
+	st := pt.LineStart(ln)
+	ed := pt.LineStart(ln + 1)
+	if ed > st && ed <= pt.size {
+		if lb := pt.Slice(ed-1, ed); len(lb) == 1 && lb[0] == '\n' {
+			ed--
+		}
+	}
+	return pt.Slice(st, ed)
+}
+
+// Lines returns copies of the bytes of the lines from st up to ed, without
+// the \n -- fewer lines are returned if ed is past the end
+func (pt *PieceTable) Lines(st, ed int) [][]byte {
+	pt.indexTo(ed)
+	if n := pt.IndexedLines(); ed > n {
+		ed = n
+	}
+	if st < 0 {
+		st = 0
+	}
+	if ed <= st {
+		return nil
+	}
+	txt := pt.Slice(pt.LineStart(st), pt.LineStart(ed))
+	lns := bytes.Split(txt, []byte("\n"))
+	return lns[:ed-st]
+}
+
+// invalidate discards the index of line starts after given offset
+func (pt *PieceTable) invalidate(off int) {
+	n := len(pt.lnOffs)
+	for n > 1 && pt.lnOffs[n-1] > off {
+		n--
+	}
+	pt.lnOffs = pt.lnOffs[:n]
+	pt.lnDone = false
+}
+
+// split splits the pieces at given offset, returning the index of the
+// first piece starting at or after the offset
+func (pt *PieceTable) split(off int) int {
+	pi, pst := pt.pieceAt(off)
+	if pi == len(pt.pieces) || pst == off {
+		return pi
+	}
+	p := pt.pieces[pi]
+	a, b := p, p
+	a.len = off - pst
+	b.off += a.len
+	b.len -= a.len
+	pt.pieces = append(pt.pieces[:pi], append([]piece{a, b}, pt.pieces[pi+1:]...)...)
+	return pi + 1
+}
+
+// Replace replaces n bytes at given offset with given text, either of
+// which can be empty to just insert or delete
+func (pt *PieceTable) Replace(off, n int, txt []byte) {
+	if off < 0 || off > pt.size {
+		return
+	}
+	if off+n > pt.size {
+		n = pt.size - off
+	}
+	if n <= 0 && len(txt) == 0 {
+		return
+	}
+	si := pt.split(off)
+	ei := pt.split(off + n)
+	var np []piece
+	if len(txt) > 0 {
+		np = []piece{{add: true, off: len(pt.add), len: len(txt)}}
+		pt.add = append(pt.add, txt...)
+	}
+	pt.pieces = append(pt.pieces[:si], append(np, pt.pieces[ei:]...)...)
+	pt.size += len(txt) - n
+	pt.invalidate(off)
+}
+
+// Insert inserts given text at given offset
+func (pt *PieceTable) Insert(off int, txt []byte) {
+	pt.Replace(off, 0, txt)
+}
+
+// Delete deletes n bytes at given offset
+func (pt *PieceTable) Delete(off, n int) {
+	pt.Replace(off, n, nil)
+}
+
+// ReplaceLines replaces the lines from st up to ed with given lines, each
+// of which is terminated by a \n
+func (pt *PieceTable) ReplaceLines(st, ed int, lns [][]byte) {
+	sto := pt.LineStart(st)
+	edo := pt.LineStart(ed)
+	var txt []byte
+	if len(lns) > 0 {
+		txt = append(bytes.Join(lns, []byte("\n")), '\n')
+	}
+	pt.Replace(sto, edo-sto, txt)
+}
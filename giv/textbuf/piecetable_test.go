@@ -0,0 +1,120 @@
+// Copyright (c) 2020, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package textbuf
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPieceTable(t *testing.T) {
+	pt := NewPieceTable([]byte("one\ntwo\nthree\n"))
+	if n := pt.IndexedLines(); n > 1 {
+		t.Errorf("lines should be indexed lazily, indexed: %d", n)
+	}
+	if ln := string(pt.Line(1)); ln != "two" {
+		t.Errorf("line 1: %q", ln)
+	}
+	if n := pt.IndexedLines(); n != 3 {
+		t.Errorf("should index only up to line 2, indexed: %d", n)
+	}
+	if n := pt.NumLines(); n != 3 {
+		t.Errorf("NumLines: %d", n)
+	}
+	pt.Insert(4, []byte("1.5\n"))
+	pt.Delete(0, 1)
+	pt.Replace(pt.LineStart(3), 5, []byte("THREE"))
+	if s := string(pt.Bytes()); s != "ne\n1.5\ntwo\nTHREE\n" {
+		t.Errorf("after edits: %q", s)
+	}
+	if n := pt.NumLines(); n != 4 {
+		t.Errorf("NumLines after edits: %d", n)
+	}
+	pt.ReplaceLines(1, 3, [][]byte{[]byte("x")})
+	lns := pt.Lines(0, 10)
+	if len(lns) != 3 || string(lns[0]) != "ne" || string(lns[1]) != "x" || string(lns[2]) != "THREE" {
+		t.Errorf("lines after ReplaceLines: %q", lns)
+	}
+	var b bytes.Buffer
+	pt.WriteTo(&b)
+	if b.String() != "ne\nx\nTHREE\n" {
+		t.Errorf("WriteTo: %q", b.String())
+	}
+
+	pt = NewPieceTable([]byte("a\nb"))
+	if n := pt.NumLines(); n != 2 || string(pt.Line(1)) != "b" {
+		t.Errorf("unterminated last line: %d %q", n, pt.Line(1))
+	}
+	if n := NewPieceTable(nil).NumLines(); n != 0 {
+		t.Errorf("empty: %d", n)
+	}
+}
+
+func TestOpenPieceTable(t *testing.T) {
+	dir, err := ioutil.TempDir("", "piecetable")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	fnm := filepath.Join(dir, "big.txt")
+	var sb strings.Builder
+	for i := 0; i < 10000; i++ {
+		sb.WriteString("line of text\n")
+	}
+	ioutil.WriteFile(fnm, []byte(sb.String()), 0644)
+	pt, err := OpenPieceTable(fnm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pt.Close()
+	if n := pt.NumLines(); n != 10000 {
+		t.Errorf("NumLines: %d", n)
+	}
+	pt.ReplaceLines(5000, 5001, [][]byte{[]byte("edited")})
+	if ln := string(pt.Line(5000)); ln != "edited" {
+		t.Errorf("edited line: %q", ln)
+	}
+	if ln := string(pt.Line(9999)); ln != "line of text" {
+		t.Errorf("last line: %q", ln)
+	}
+}
+
+func TestOpenPieceTableTruncated(t *testing.T) {
+	dir, err := ioutil.TempDir("", "piecetable")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	fnm := filepath.Join(dir, "big.txt")
+	ioutil.WriteFile(fnm, bytes.Repeat([]byte("line of text\n"), 10000), 0644)
+	pt, err := OpenPieceTable(fnm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pt.Close()
+	if ln := string(pt.Line(10)); ln != "line of text" {
+		t.Errorf("line 10: %q", ln)
+	}
+	if err := os.Truncate(fnm, 13*100); err != nil {
+		t.Fatal(err)
+	}
+	// accessing the whole file must not crash past the new end
+	if n := pt.NumLines(); n != 100+1 {
+		t.Errorf("NumLines after truncating: %d", n)
+	}
+	if ln := string(pt.Line(99)); ln != "line of text" {
+		t.Errorf("line 99 after truncating: %q", ln)
+	}
+	if b := pt.Bytes(); len(b) != 13*10000 {
+		t.Errorf("Bytes after truncating: %d bytes", len(b))
+	}
+	if !pt.Truncated() {
+		t.Errorf("Truncated should be true after truncating the file")
+	}
+}
@@ -10,6 +10,12 @@ import (
 	"io"
 	"log"
 	"os"
+	"regexp"
+	"regexp/syntax"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/goki/ki/ints"
 	"github.com/goki/ki/runes"
@@ -43,7 +49,7 @@ func NewMatch(rn []rune, st, ed, ln int) Match {
 	tlen := mstsz + medsz + len(sctx) + len(fstr) + len(ectx)
 	txt := make([]byte, tlen)
 	copy(txt, sctx)
-	ti := st - cist
+	ti := len(sctx)
 	copy(txt[ti:], mst)
 	ti += mstsz
 	copy(txt[ti:], fstr)
@@ -191,3 +197,217 @@ func SearchFile(filename string, find []byte, ignoreCase bool) (int, []Match) {
 	defer fp.Close()
 	return Search(fp, find, ignoreCase)
 }
+
+// SearchRegexpFor returns a regular expression for searching for given
+// find string, which is a regexp if isRegexp is true, or otherwise a
+// literal string.  wholeWord only matches at word boundaries, and
+// multiLine makes ^ and $ match at the start and end of each line,
+// for use with SearchMultiLineRegexp.
+func SearchRegexpFor(find string, isRegexp, ignoreCase, wholeWord, multiLine bool) (*regexp.Regexp, error) {
+	if !isRegexp {
+		find = regexp.QuoteMeta(find)
+	}
+	if wholeWord {
+		find = `\b(?:` + find + `)\b`
+	}
+	flags := ""
+	if ignoreCase {
+		flags += "i"
+	}
+	if multiLine {
+		flags += "m"
+	}
+	if flags != "" {
+		find = "(?" + flags + ")" + find
+	}
+	return regexp.Compile(find)
+}
+
+// RegexpHasUpperCase returns true if the literal text of given regexp
+// has an upper-case letter, for inferring case sensitivity -- escapes
+// and character classes such as \S or \W do not count.  An invalid
+// regexp falls back on the upper-case letters anywhere in it.
+func RegexpHasUpperCase(find string) bool {
+	re, err := syntax.Parse(find, syntax.Perl)
+	if err != nil {
+		return strings.IndexFunc(find, unicode.IsUpper) >= 0
+	}
+	return regexpLitHasUpper(re)
+}
+
+// regexpLitHasUpper returns true if any case-sensitive literal within
+// given parsed regexp has an upper-case letter
+func regexpLitHasUpper(re *syntax.Regexp) bool {
+	if re.Op == syntax.OpLiteral && re.Flags&syntax.FoldCase == 0 {
+		for _, r := range re.Rune {
+			if unicode.IsUpper(r) {
+				return true
+			}
+		}
+	}
+	for _, sub := range re.Sub {
+		if regexpLitHasUpper(sub) {
+			return true
+		}
+	}
+	return false
+}
+
+// runeCounter converts increasing byte offsets within a string into rune offsets
+type runeCounter struct {
+	str    string
+	bi, ri int
+}
+
+// runeOff returns the rune offset for given byte offset, which must
+// be >= the previous one
+func (rc *runeCounter) runeOff(bo int) int {
+	rc.ri += utf8.RuneCountInString(rc.str[rc.bi:bo])
+	rc.bi = bo
+	return rc.ri
+}
+
+// searchLineRegexp appends matches for regexp within given line of runes
+func searchLineRegexp(matches []Match, rn []rune, ln int, re *regexp.Regexp) []Match {
+	str := string(rn)
+	idxs := re.FindAllStringIndex(str, -1)
+	if len(idxs) == 0 {
+		return matches
+	}
+	rc := runeCounter{str: str}
+	for _, ix := range idxs {
+		st := rc.runeOff(ix[0])
+		ed := rc.runeOff(ix[1])
+		matches = append(matches, NewMatch(rn, st, ed, ln))
+	}
+	return matches
+}
+
+// SearchRuneLinesRegexp looks for a regexp within lines of runes,
+// returning number of occurrences and specific match position list.
+// Each line is searched separately.  Column positions are in runes.
+func SearchRuneLinesRegexp(src [][]rune, re *regexp.Regexp) (int, []Match) {
+	var matches []Match
+	for ln, rn := range src {
+		matches = searchLineRegexp(matches, rn, ln, re)
+	}
+	return len(matches), matches
+}
+
+// SearchMultiLineRegexp looks for a regexp within lines of runes, which
+// are joined with newlines so that matches can span multiple lines.
+// Returns number of occurrences and specific match position list.
+// Column positions are in runes.
+func SearchMultiLineRegexp(src [][]rune, re *regexp.Regexp) (int, []Match) {
+	var b strings.Builder
+	lnSt := make([]int, len(src))
+	for ln, rn := range src {
+		if ln > 0 {
+			b.WriteByte('\n')
+		}
+		lnSt[ln] = b.Len()
+		b.WriteString(string(rn))
+	}
+	str := b.String()
+	idxs := re.FindAllStringIndex(str, -1)
+	var matches []Match
+	pos := func(bo int) Pos {
+		ln := sort.Search(len(lnSt), func(i int) bool { return lnSt[i] > bo }) - 1
+		return Pos{Ln: ln, Ch: utf8.RuneCountInString(str[lnSt[ln]:bo])}
+	}
+	for _, ix := range idxs {
+		st := pos(ix[0])
+		ed := pos(ix[1])
+		rn := src[st.Ln]
+		if ed.Ln == st.Ln {
+			matches = append(matches, NewMatch(rn, st.Ch, ed.Ch, st.Ln))
+			continue
+		}
+		mat := NewMatch(rn, st.Ch, len(rn), st.Ln)
+		mat.Reg.End = ed
+		matches = append(matches, mat)
+	}
+	return len(matches), matches
+}
+
+// SearchLexItemsRegexp looks for a regexp that matches entire lexically
+// tagged items, returning number of occurrences and specific match
+// position list.  Column positions are in runes.
+func SearchLexItemsRegexp(src [][]rune, lexs []lex.Line, re *regexp.Regexp) (int, []Match) {
+	var matches []Match
+	mx := ints.MinInt(len(src), len(lexs))
+	for ln := 0; ln < mx; ln++ {
+		rln := src[ln]
+		for _, lx := range lexs[ln] {
+			if lx.St < 0 || lx.Ed > len(rln) || lx.St >= lx.Ed {
+				continue
+			}
+			str := string(rln[lx.St:lx.Ed])
+			loc := re.FindStringIndex(str)
+			if loc == nil || loc[0] != 0 || loc[1] != len(str) {
+				continue
+			}
+			matches = append(matches, NewMatch(rln, lx.St, lx.Ed, ln))
+		}
+	}
+	return len(matches), matches
+}
+
+// SearchRegexp looks for a regexp from an io.Reader input stream,
+// searching each line separately.
+// Returns number of occurrences and specific match position list.
+// Column positions are in runes.
+func SearchRegexp(reader io.Reader, re *regexp.Regexp) (int, []Match) {
+	var matches []Match
+	scan := bufio.NewScanner(reader)
+	ln := 0
+	for scan.Scan() {
+		matches = searchLineRegexp(matches, bytes.Runes(scan.Bytes()), ln, re)
+		ln++
+	}
+	return len(matches), matches
+}
+
+// SearchFileRegexp looks for a regexp within a file, searching each
+// line separately, returning number of occurrences and specific match
+// position list -- column positions are in runes.
+func SearchFileRegexp(filename string, re *regexp.Regexp) (int, []Match) {
+	fp, err := os.Open(filename)
+	if err != nil {
+		log.Printf("textbuf.SearchFileRegexp: open error: %v\n", err)
+		return 0, nil
+	}
+	defer fp.Close()
+	return SearchRegexp(fp, re)
+}
+
+// ReplaceRegexp returns the replacement text for the match of given regexp
+// at given region within lines of runes, with capture group references
+// in repl ($1, ${name} etc -- use $$ for a literal $) expanded from the match.
+// The lines spanned by the region are searched again to get the submatches,
+// so regexps that depend on surrounding text are handled properly.
+// If the match is no longer found, repl is returned as-is.
+func ReplaceRegexp(src [][]rune, reg Region, re *regexp.Regexp, repl []byte) []byte {
+	if reg.Start.Ln < 0 || reg.Start.Ln >= len(src) {
+		return repl
+	}
+	var b bytes.Buffer
+	for ln := reg.Start.Ln; ln <= reg.End.Ln && ln < len(src); ln++ {
+		if ln > reg.Start.Ln {
+			b.WriteByte('\n')
+		}
+		b.WriteString(string(src[ln]))
+	}
+	txt := b.Bytes()
+	sln := src[reg.Start.Ln]
+	bst := len(string(sln[:ints.MinInt(reg.Start.Ch, len(sln))]))
+	for _, sm := range re.FindAllSubmatchIndex(txt, -1) {
+		if sm[0] == bst {
+			return re.Expand(nil, repl, txt, sm)
+		}
+		if sm[0] > bst {
+			break
+		}
+	}
+	return repl
+}
@@ -0,0 +1,99 @@
+// Copyright (c) 2020, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package textbuf
+
+import (
+	"testing"
+)
+
+func testLines(strs ...string) [][]rune {
+	src := make([][]rune, len(strs))
+	for i, s := range strs {
+		src[i] = []rune(s)
+	}
+	return src
+}
+
+// sameReg compares regions ignoring the time stamp
+func sameReg(a, b Region) bool {
+	return a.Start == b.Start && a.End == b.End
+}
+
+func TestSearchRegexp(t *testing.T) {
+	src := testLines("héllo foo(1), foobar", "x := foo(22)", "end")
+	re, err := SearchRegexpFor(`foo\((\d+)\)`, true, false, false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n, ms := SearchRuneLinesRegexp(src, re)
+	if n != 2 {
+		t.Fatalf("expected 2 matches, got: %d", n)
+	}
+	if !sameReg(ms[0].Reg, NewRegion(0, 6, 0, 12)) || !sameReg(ms[1].Reg, NewRegion(1, 5, 1, 12)) {
+		t.Errorf("match regions (in runes) wrong: %v %v", ms[0].Reg, ms[1].Reg)
+	}
+	if rs := string(ReplaceRegexp(src, ms[1].Reg, re, []byte("bar($1, $$)"))); rs != "bar(22, $)" {
+		t.Errorf("replace expansion: %v", rs)
+	}
+
+	re, _ = SearchRegexpFor("FOO", false, true, true, false)
+	n, ms = SearchRuneLinesRegexp(src, re)
+	if n != 2 || ms[0].Reg.Start.Ch != 6 {
+		t.Errorf("whole word ignore case: %d %v", n, ms)
+	}
+
+	re, _ = SearchRegexpFor(`foobar\nx`, true, false, false, true)
+	n, ms = SearchMultiLineRegexp(src, re)
+	if n != 1 || !sameReg(ms[0].Reg, NewRegion(0, 14, 1, 1)) {
+		t.Errorf("multi-line: %d %v", n, ms)
+	} else if mt := string(ms[0].Text); mt != "héllo foo(1), <mark>foobar</mark>" {
+		t.Errorf("multi-line match text: %q", mt)
+	}
+	re, _ = SearchRegexpFor(`^\w+$`, true, false, false, true)
+	n, ms = SearchMultiLineRegexp(src, re)
+	if n != 1 || !sameReg(ms[0].Reg, NewRegion(2, 0, 2, 3)) {
+		t.Errorf("multi-line anchors: %d %v", n, ms)
+	}
+}
+
+func TestRegexpHasUpperCase(t *testing.T) {
+	tests := []struct {
+		find string
+		want bool
+	}{
+		{`foo\S+`, false},
+		{`\W\D\B`, false},
+		{`[A-Z]\w`, false},
+		{`(?i)Foo`, false},
+		{`\bFoo\(\d+\)`, true},
+		{`x(?:bar|Baz)`, true},
+		{`Foo(`, true},
+	}
+	for _, tt := range tests {
+		if got := RegexpHasUpperCase(tt.find); got != tt.want {
+			t.Errorf("RegexpHasUpperCase(%q) = %v, want %v", tt.find, got, tt.want)
+		}
+	}
+}
+
+func TestUndoGroup(t *testing.T) {
+	un := &Undo{}
+	un.BeginGroup()
+	for i := 0; i < 3; i++ {
+		tbe := &Edit{Reg: NewRegion(0, i, 0, i+1)}
+		tbe.Reg.Time.Now()
+		un.Save(tbe)
+	}
+	un.EndGroup()
+	gp := un.Stack[0].Group
+	for _, tbe := range un.Stack {
+		if tbe.Group != gp {
+			t.Errorf("edits in BeginGroup should have same group: %d != %d", tbe.Group, gp)
+		}
+	}
+	if un.Group == gp {
+		t.Errorf("EndGroup should start a new group")
+	}
+}
@@ -0,0 +1,120 @@
+// Copyright (c) 2020, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package textbuf
+
+import (
+	"bytes"
+	"regexp"
+	"sync"
+	"sync/atomic"
+)
+
+// BinarySniffLen is the number of bytes at the start of a file that are
+// checked for a zero byte, which marks the file as binary
+var BinarySniffLen = 8000
+
+// IsBinary returns true if the text looks like the contents of a binary file
+func IsBinary(txt []byte) bool {
+	n := len(txt)
+	if n > BinarySniffLen {
+		n = BinarySniffLen
+	}
+	return bytes.IndexByte(txt[:n], 0) >= 0
+}
+
+// BytesToRuneLines splits the text into lines of runes, without the newlines
+func BytesToRuneLines(txt []byte) [][]rune {
+	lns := bytes.Split(txt, []byte("\n"))
+	src := make([][]rune, len(lns))
+	for i, ln := range lns {
+		src[i] = bytes.Runes(bytes.TrimSuffix(ln, []byte("\r")))
+	}
+	return src
+}
+
+// SearchFileLinesRegexp looks for a regexp within a file, returning the
+// lines of the file and the matches -- if multiLine, matches can span lines
+// (see SearchMultiLineRegexp).  Binary files are skipped, returning nil.
+func SearchFileLinesRegexp(filename string, re *regexp.Regexp, multiLine bool) ([][]rune, []Match, error) {
+	txt, err := FileBytes(filename)
+	if err != nil || IsBinary(txt) {
+		return nil, nil, err
+	}
+	src := BytesToRuneLines(txt)
+	var ms []Match
+	if multiLine {
+		_, ms = SearchMultiLineRegexp(src, re)
+	} else {
+		_, ms = SearchRuneLinesRegexp(src, re)
+	}
+	return src, ms, nil
+}
+
+// SearchFilesRegexp searches each of the files received on the files channel
+// for given regexp, using nworkers goroutines in parallel, until the channel
+// is closed.  fun is called with the matches for each file having any, one
+// file at a time (but in the order the searches finish, and from the worker
+// goroutines) -- if it returns false the search stops, and the remaining
+// files are drained from the channel without being searched.
+func SearchFilesRegexp(files <-chan string, re *regexp.Regexp, multiLine bool, nworkers int, fun func(filename string, matches []Match) bool) {
+	if nworkers < 1 {
+		nworkers = 1
+	}
+	var stop int32
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	wg.Add(nworkers)
+	for w := 0; w < nworkers; w++ {
+		go func() {
+			defer wg.Done()
+			for fnm := range files {
+				if atomic.LoadInt32(&stop) != 0 {
+					continue
+				}
+				_, ms, _ := SearchFileLinesRegexp(fnm, re, multiLine)
+				if len(ms) == 0 {
+					continue
+				}
+				mu.Lock()
+				if atomic.LoadInt32(&stop) == 0 && !fun(fnm, ms) {
+					atomic.StoreInt32(&stop, 1)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// ReplaceMatches returns the lines with each of the matches replaced by
+// repl, which is expanded for each match using ReplaceRegexp if re is
+// non-nil.  The matches must be in order and not overlap, as returned by
+// the search functions.  The given lines are not modified.
+func ReplaceMatches(src [][]rune, matches []Match, re *regexp.Regexp, repl []byte) [][]rune {
+	lns := make([][]rune, len(src))
+	copy(lns, src)
+	for mi := len(matches) - 1; mi >= 0; mi-- { // last first, so earlier positions stay valid
+		reg := matches[mi].Reg
+		if reg.Start.Ln < 0 || reg.End.Ln >= len(lns) {
+			continue
+		}
+		rtxt := repl
+		if re != nil {
+			rtxt = ReplaceRegexp(src, reg, re, repl)
+		}
+		stl := lns[reg.Start.Ln]
+		edl := lns[reg.End.Ln]
+		if reg.Start.Ch > len(stl) || reg.End.Ch > len(edl) {
+			continue
+		}
+		nlns := BytesToRuneLines(rtxt)
+		pre := append([]rune(nil), stl[:reg.Start.Ch]...)
+		nlns[0] = append(pre, nlns[0]...)
+		nl := len(nlns) - 1
+		nlns[nl] = append(nlns[nl], edl[reg.End.Ch:]...)
+		lns = append(lns[:reg.Start.Ln], append(nlns, lns[reg.End.Ln+1:]...)...)
+	}
+	return lns
+}
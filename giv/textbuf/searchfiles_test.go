@@ -0,0 +1,111 @@
+// Copyright (c) 2020, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package textbuf
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+)
+
+func TestIgnore(t *testing.T) {
+	root := filepath.FromSlash("/repo")
+	ig := ParseIgnore([]byte("# comment\n*.o\n/build\nlogs/\ndocs/**/*.tmp\n!keep.o\n\n"), root)
+	ig = append(ig, ParseIgnore([]byte("gen_*\n"), filepath.Join(root, "sub"))...)
+	tests := []struct {
+		path  string
+		dir   bool
+		ignrd bool
+	}{
+		{"a.o", false, true},
+		{"x/y/a.o", false, true},
+		{"keep.o", false, false},
+		{"a.go", false, false},
+		{"build", true, true},
+		{"x/build", true, false},
+		{"logs", true, true},
+		{"logs", false, false},
+		{"x/logs", true, true},
+		{"docs/a.tmp", false, true},
+		{"docs/x/y/a.tmp", false, true},
+		{"a.tmp", false, false},
+		{"sub/gen_x.go", false, true},
+		{"gen_x.go", false, false},
+	}
+	for _, ts := range tests {
+		path := filepath.Join(root, filepath.FromSlash(ts.path))
+		if ig.Ignored(path, ts.dir) != ts.ignrd {
+			t.Errorf("Ignored(%v, dir: %v) should be: %v", ts.path, ts.dir, ts.ignrd)
+		}
+	}
+}
+
+func TestSearchFilesRegexp(t *testing.T) {
+	dir, err := ioutil.TempDir("", "searchfiles")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	cont := map[string]string{
+		"a.txt":   "foo bar\nnothing\nfoo\n",
+		"b.txt":   "no match here\n",
+		"c.txt":   "x foo\r\n",
+		"bin.dat": "foo\x00bar",
+	}
+	files := make(chan string)
+	go func() {
+		for nm, txt := range cont {
+			fnm := filepath.Join(dir, nm)
+			ioutil.WriteFile(fnm, []byte(txt), 0644)
+			files <- fnm
+		}
+		close(files)
+	}()
+	re, _ := SearchRegexpFor("foo", false, false, false, false)
+	var found []string
+	SearchFilesRegexp(files, re, false, 3, func(fnm string, ms []Match) bool {
+		found = append(found, filepath.Base(fnm))
+		if filepath.Base(fnm) == "a.txt" && len(ms) != 2 {
+			t.Errorf("expected 2 matches in a.txt, got: %v", len(ms))
+		}
+		return true
+	})
+	sort.Strings(found)
+	if len(found) != 2 || found[0] != "a.txt" || found[1] != "c.txt" {
+		t.Errorf("files found wrong: %v", found)
+	}
+}
+
+func TestReplaceMatches(t *testing.T) {
+	src := testLines("foo(1) foo(2)", "bar foo(3)", "end")
+	re, _ := SearchRegexpFor(`foo\((\d)\)`, true, false, false, false)
+	_, ms := SearchRuneLinesRegexp(src, re)
+	lns := ReplaceMatches(src, ms, re, []byte("f$1"))
+	if got := runeLinesString(lns); got != "f1 f2\nbar f3\nend" {
+		t.Errorf("replace: %q", got)
+	}
+	if got := runeLinesString(src); got != "foo(1) foo(2)\nbar foo(3)\nend" {
+		t.Errorf("source was modified: %q", got)
+	}
+	re, _ = SearchRegexpFor(`\)\nbar`, true, false, false, true)
+	_, ms = SearchMultiLineRegexp(src, re)
+	lns = ReplaceMatches(src, ms, nil, []byte(")\n\nbaz"))
+	if got := runeLinesString(lns); got != "foo(1) foo(2)\n\nbaz foo(3)\nend" {
+		t.Errorf("multi-line replace: %q", got)
+	}
+}
+
+func runeLinesString(lns [][]rune) string {
+	s := ""
+	for i, ln := range lns {
+		if i > 0 {
+			s += "\n"
+		}
+		s += string(ln)
+	}
+	return s
+}
@@ -19,12 +19,28 @@ var UndoGroupDelayMSec = 250
 
 // Undo is the TextBuf undo manager
 type Undo struct {
-	Off       bool       `desc:"if true, saving and using undos is turned off (e.g., inactive buffers)"`
-	Stack     []*Edit    `desc:"undo stack of edits"`
-	UndoStack []*Edit    `desc:"undo stack of *undo* edits -- added to whenever an Undo is done -- for emacs-style undo"`
-	Pos       int        `desc:"undo position in stack"`
-	Group     int        `desc:"group counter"`
-	Mu        sync.Mutex `json:"-" xml:"-" desc:"mutex protecting all updates"`
+	Off       bool          `desc:"if true, saving and using undos is turned off (e.g., inactive buffers)"`
+	Stack     []*Edit       `desc:"undo stack of edits"`
+	UndoStack []*Edit       `desc:"undo stack of *undo* edits -- added to whenever an Undo is done -- for emacs-style undo"`
+	Pos       int           `desc:"undo position in stack"`
+	Group     int           `desc:"group counter"`
+	Hold      bool          `desc:"if true, all edits are saved in the current group regardless of time between them -- see BeginGroup"`
+	SavePos   int           `desc:"position in stack corresponding to the last saved (or opened) version of the file -- -1 if that version is no longer on the stack"`
+	Branches  []*UndoBranch `desc:"previous versions of the stack that were abandoned when new edits were made after undoing -- these form the other branches of the undo tree"`
+	Mu        sync.Mutex    `json:"-" xml:"-" desc:"mutex protecting all updates"`
+}
+
+// UndoMaxBranches is the maximum number of abandoned undo branches to keep --
+// the oldest are discarded first
+var UndoMaxBranches = 50
+
+// UndoBranch is a previous version of the undo stack, which was abandoned
+// when new edits were made after undoing.  It shares the Edit records
+// up to the point where it diverged from the stack that replaced it.
+type UndoBranch struct {
+	Stack   []*Edit   `desc:"full stack of edits for this branch"`
+	SavePos int       `desc:"position in stack corresponding to the last saved version of the file, if it is only on this branch -- -1 otherwise"`
+	Time    time.Time `desc:"time when the branch was abandoned"`
 }
 
 // NewGroup increments the Group counter so subsequent undos will be grouped separately
@@ -34,16 +50,37 @@ func (un *Undo) NewGroup() {
 	un.Mu.Unlock()
 }
 
+// BeginGroup starts a new group that holds all subsequent edits until
+// EndGroup is called, so they are undone together (e.g., for replace-all)
+func (un *Undo) BeginGroup() {
+	un.Mu.Lock()
+	un.Group++
+	un.Hold = true
+	un.Mu.Unlock()
+}
+
+// EndGroup ends a group started by BeginGroup, so subsequent undos
+// will be grouped separately
+func (un *Undo) EndGroup() {
+	un.Mu.Lock()
+	un.Hold = false
+	un.Group++
+	un.Mu.Unlock()
+}
+
 // Reset clears all undo records
 func (un *Undo) Reset() {
 	un.Pos = 0
 	un.Group = 0
+	un.Hold = false
+	un.SavePos = 0
 	un.Stack = nil
 	un.UndoStack = nil
+	un.Branches = nil
 }
 
 // Save saves given edit to undo stack, with current group marker unless timer interval
-// exceeds UndoGroupDelayMSec since last item.
+// exceeds UndoGroupDelayMSec since last item (and not in Hold mode).
 func (un *Undo) Save(tbe *Edit) {
 	if un.Off {
 		return
@@ -54,9 +91,10 @@ func (un *Undo) Save(tbe *Edit) {
 		if UndoTrace {
 			fmt.Printf("Undo: resetting to pos: %v len was: %v\n", un.Pos, len(un.Stack))
 		}
-		un.Stack = un.Stack[:un.Pos]
+		un.saveBranch()
+		un.Stack = append([]*Edit(nil), un.Stack[:un.Pos]...)
 	}
-	if len(un.Stack) > 0 {
+	if len(un.Stack) > 0 && !un.Hold {
 		since := tbe.Reg.SinceMSec(&un.Stack[len(un.Stack)-1].Reg)
 		if since > UndoGroupDelayMSec {
 			un.Group++
@@ -73,6 +111,20 @@ func (un *Undo) Save(tbe *Edit) {
 	un.Pos = len(un.Stack)
 }
 
+// saveBranch saves the current stack as a new branch, discarding the
+// oldest branch if there are more than UndoMaxBranches.  Must be locked.
+func (un *Undo) saveBranch() {
+	br := &UndoBranch{Stack: un.Stack, SavePos: -1, Time: time.Now()}
+	if un.SavePos > un.Pos {
+		br.SavePos = un.SavePos
+		un.SavePos = -1
+	}
+	un.Branches = append(un.Branches, br)
+	if n := len(un.Branches) - UndoMaxBranches; n > 0 {
+		un.Branches = un.Branches[n:]
+	}
+}
+
 // UndoPop pops the top item off of the stack for use in Undo. returns nil if none.
 func (un *Undo) UndoPop() *Edit {
 	if un.Off {
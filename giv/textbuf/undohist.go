@@ -0,0 +1,298 @@
+// Copyright (c) 2020, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package textbuf
+
+import (
+	"compress/gzip"
+	"encoding/gob"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// UndoState identifies one state in the undo tree, at the boundary
+// between groups of edits, either in the current Stack or in one
+// of the abandoned Branches.
+type UndoState struct {
+	Branch  int       `desc:"index of the branch in Branches, or -1 for the current Stack"`
+	Pos     int       `desc:"position in the branch stack -- the state after the first Pos edits"`
+	Time    time.Time `desc:"time of the last edit leading to this state"`
+	NEdits  int       `desc:"number of edits in the group leading to this state"`
+	Desc    string    `desc:"description of the group of edits leading to this state"`
+	Current bool      `desc:"this is the current state of the buffer"`
+	Saved   bool      `desc:"this is the state last saved to (or opened from) the file"`
+}
+
+// States returns the distinct states in the undo tree: all the group
+// boundaries of the current Stack, followed by those of each branch,
+// most recent first, that are not shared with the current Stack or
+// another branch already listed.
+func (un *Undo) States() []UndoState {
+	un.Mu.Lock()
+	defer un.Mu.Unlock()
+	sts := []UndoState{{Branch: -1, Pos: 0, Desc: "<original>", Current: un.Pos == 0, Saved: un.SavePos == 0}}
+	seen := make(map[*Edit]bool)
+	add := func(br int, stk []*Edit, savePos int) {
+		gst := 0
+		for p := 1; p <= len(stk); p++ {
+			if p < len(stk) && stk[p].Group == stk[p-1].Group {
+				continue
+			}
+			last := stk[p-1]
+			if !seen[last] {
+				seen[last] = true
+				sts = append(sts, UndoState{Branch: br, Pos: p, Time: last.Reg.Time.Time(), NEdits: p - gst, Desc: editDesc(stk[gst]), Current: br < 0 && p == un.Pos, Saved: p == savePos})
+			}
+			gst = p
+		}
+	}
+	add(-1, un.Stack, un.SavePos)
+	for bi := len(un.Branches) - 1; bi >= 0; bi-- {
+		br := un.Branches[bi]
+		add(bi, br.Stack, br.SavePos)
+	}
+	return sts
+}
+
+// editDesc returns a short description of given edit
+func editDesc(tbe *Edit) string {
+	act := "Insert"
+	if tbe.Delete {
+		act = "Delete"
+	}
+	txt := []rune(strings.Replace(string(tbe.ToBytes()), "\n", "⏎", -1))
+	if len(txt) > 40 {
+		txt = append(txt[:40], '…')
+	}
+	return fmt.Sprintf("%s at %v: %s", act, tbe.Reg.Start, string(txt))
+}
+
+// CommonPos returns the length of the initial sequence of edits shared
+// between the current Stack and given branch.
+func (un *Undo) CommonPos(br int) int {
+	un.Mu.Lock()
+	defer un.Mu.Unlock()
+	return un.commonPos(br)
+}
+
+func (un *Undo) commonPos(br int) int {
+	bs := un.Branches[br].Stack
+	n := 0
+	for n < len(bs) && n < len(un.Stack) && bs[n] == un.Stack[n] {
+		n++
+	}
+	return n
+}
+
+// SwitchBranch makes given branch the current Stack, saving the current
+// Stack as a branch in its place.  The current Pos must be at or before
+// the CommonPos of the branch, i.e., the buffer state must be common to
+// both, and otherwise an error is returned.
+func (un *Undo) SwitchBranch(br int) error {
+	un.Mu.Lock()
+	defer un.Mu.Unlock()
+	if br < 0 || br >= len(un.Branches) {
+		return fmt.Errorf("textbuf.Undo SwitchBranch: branch index %d out of range", br)
+	}
+	cp := un.commonPos(br)
+	if un.Pos > cp {
+		return fmt.Errorf("textbuf.Undo SwitchBranch: position %d is past common position %d", un.Pos, cp)
+	}
+	b := un.Branches[br]
+	obr := &UndoBranch{Stack: un.Stack, Time: time.Now(), SavePos: -1}
+	if un.SavePos > cp {
+		obr.SavePos = un.SavePos
+		un.SavePos = -1
+	}
+	if b.SavePos >= 0 {
+		un.SavePos = b.SavePos
+	}
+	un.Stack = b.Stack
+	un.Branches[br] = obr
+	return nil
+}
+
+// MarkSaved records that the current Pos corresponds to the version
+// of the file on disk
+func (un *Undo) MarkSaved() {
+	un.Mu.Lock()
+	defer un.Mu.Unlock()
+	un.SavePos = un.Pos
+	for _, br := range un.Branches {
+		br.SavePos = -1
+	}
+}
+
+/////////////////////////////////////////////////////////////////////////////
+//   Persistent history
+
+// UndoHistVersion is the current version of the saved undo history format
+const UndoHistVersion = 1
+
+// undoHistEdit is the saved form of an Edit
+type undoHistEdit struct {
+	St, Ed Pos
+	Time   int64
+	Delete bool
+	Text   string
+	Group  int
+}
+
+// undoHistBranch is the saved form of an UndoBranch
+type undoHistBranch struct {
+	Stack   []int
+	SavePos int
+	Time    int64
+}
+
+// undoHist is the saved form of the undo history: each distinct edit is
+// stored once, and the stacks are lists of indexes into the edits.
+type undoHist struct {
+	Version  int
+	Hash     string
+	Edits    []undoHistEdit
+	Stack    []int
+	SavePos  int
+	Group    int
+	Branches []undoHistBranch
+}
+
+// WriteHistory writes the undo history to given writer, in a compact
+// gzipped binary format, along with given hash of the file contents,
+// which must be passed to ReadHistory to restore it.  The history is
+// saved relative to SavePos, which must be valid, as it is restored
+// only when reopening the file as saved.
+func (un *Undo) WriteHistory(w io.Writer, hash string) error {
+	un.Mu.Lock()
+	defer un.Mu.Unlock()
+	if un.SavePos < 0 {
+		return fmt.Errorf("textbuf.Undo WriteHistory: saved version of file is not in undo stack")
+	}
+	uh := &undoHist{Version: UndoHistVersion, Hash: hash, SavePos: un.SavePos, Group: un.Group}
+	idxs := make(map[*Edit]int)
+	toIdxs := func(stk []*Edit) []int {
+		il := make([]int, len(stk))
+		for i, tbe := range stk {
+			idx, has := idxs[tbe]
+			if !has {
+				idx = len(uh.Edits)
+				idxs[tbe] = idx
+				uh.Edits = append(uh.Edits, undoHistEdit{St: tbe.Reg.Start, Ed: tbe.Reg.End, Time: tbe.Reg.Time.Time().UnixNano(), Delete: tbe.Delete, Text: string(tbe.ToBytes()), Group: tbe.Group})
+			}
+			il[i] = idx
+		}
+		return il
+	}
+	uh.Stack = toIdxs(un.Stack)
+	for _, br := range un.Branches {
+		uh.Branches = append(uh.Branches, undoHistBranch{Stack: toIdxs(br.Stack), SavePos: br.SavePos, Time: br.Time.UnixNano()})
+	}
+	gz := gzip.NewWriter(w)
+	err := gob.NewEncoder(gz).Encode(uh)
+	if err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// ReadHistory reads undo history written by WriteHistory, replacing the
+// current history if the saved hash matches the given one, and otherwise
+// returning an error.  Pos is set to the SavePos, as the buffer is
+// assumed to hold the file as it was saved.
+func (un *Undo) ReadHistory(r io.Reader, hash string) error {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+	uh := &undoHist{}
+	err = gob.NewDecoder(gz).Decode(uh)
+	if err != nil {
+		return err
+	}
+	if uh.Version != UndoHistVersion {
+		return fmt.Errorf("textbuf.Undo ReadHistory: unsupported version: %d", uh.Version)
+	}
+	if uh.Hash != hash {
+		return fmt.Errorf("textbuf.Undo ReadHistory: file contents do not match saved history")
+	}
+	edits := make([]*Edit, len(uh.Edits))
+	for i, he := range uh.Edits {
+		tbe := &Edit{Delete: he.Delete, Group: he.Group}
+		tbe.Reg.Start = he.St
+		tbe.Reg.End = he.Ed
+		tbe.Reg.Time.SetTime(time.Unix(0, he.Time))
+		if he.Text != "" {
+			lns := strings.Split(he.Text, "\n")
+			tbe.Text = make([][]rune, len(lns))
+			for li, ln := range lns {
+				tbe.Text[li] = []rune(ln)
+			}
+		}
+		edits[i] = tbe
+	}
+	toStack := func(il []int) ([]*Edit, error) {
+		stk := make([]*Edit, len(il))
+		for i, idx := range il {
+			if idx < 0 || idx >= len(edits) {
+				return nil, fmt.Errorf("textbuf.Undo ReadHistory: edit index %d out of range", idx)
+			}
+			stk[i] = edits[idx]
+		}
+		return stk, nil
+	}
+	stk, err := toStack(uh.Stack)
+	if err != nil {
+		return err
+	}
+	if uh.SavePos < 0 || uh.SavePos > len(stk) {
+		return fmt.Errorf("textbuf.Undo ReadHistory: saved position %d out of range", uh.SavePos)
+	}
+	brs := make([]*UndoBranch, len(uh.Branches))
+	for i, hb := range uh.Branches {
+		bs, err := toStack(hb.Stack)
+		if err != nil {
+			return err
+		}
+		brs[i] = &UndoBranch{Stack: bs, SavePos: hb.SavePos, Time: time.Unix(0, hb.Time)}
+	}
+	un.Mu.Lock()
+	defer un.Mu.Unlock()
+	un.Stack = stk
+	un.Branches = brs
+	un.Pos = uh.SavePos
+	un.SavePos = uh.SavePos
+	un.Group = uh.Group + 1
+	un.Hold = false
+	un.UndoStack = nil
+	return nil
+}
+
+// SaveHistory saves the undo history to given file -- see WriteHistory
+func (un *Undo) SaveHistory(filename, hash string) error {
+	fp, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	err = un.WriteHistory(fp, hash)
+	cerr := fp.Close()
+	if err != nil {
+		os.Remove(filename)
+		return err
+	}
+	return cerr
+}
+
+// OpenHistory opens undo history from given file -- see ReadHistory
+func (un *Undo) OpenHistory(filename, hash string) error {
+	fp, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer fp.Close()
+	return un.ReadHistory(fp, hash)
+}
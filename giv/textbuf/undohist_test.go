@@ -0,0 +1,84 @@
+// Copyright (c) 2020, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package textbuf
+
+import (
+	"bytes"
+	"testing"
+)
+
+func testEdit(ln int, txt string) *Edit {
+	tbe := &Edit{Reg: NewRegion(ln, 0, ln, len(txt)), Text: testLines(txt)}
+	tbe.Reg.TimeNow()
+	return tbe
+}
+
+func testUndo() *Undo {
+	un := &Undo{}
+	for i, txt := range []string{"a", "bb", "ccc"} {
+		un.NewGroup()
+		un.Save(testEdit(i, txt))
+	}
+	un.MarkSaved()
+	un.UndoPop()
+	un.UndoPop()
+	un.NewGroup()
+	un.Save(testEdit(1, "dddd")) // abandons bb, ccc as a branch
+	return un
+}
+
+func TestUndoBranch(t *testing.T) {
+	un := testUndo()
+	if len(un.Stack) != 2 || len(un.Branches) != 1 || un.SavePos != -1 || un.Branches[0].SavePos != 3 {
+		t.Fatalf("branch not saved: stack: %d branches: %d savepos: %d", len(un.Stack), len(un.Branches), un.SavePos)
+	}
+	sts := un.States()
+	if len(sts) != 5 { // original, a, dddd, bb, ccc
+		t.Fatalf("expected 5 states, got: %v", sts)
+	}
+	if !sts[2].Current || sts[3].Branch != 0 || sts[3].Pos != 2 || !sts[4].Saved {
+		t.Errorf("states wrong: %v", sts)
+	}
+	if err := un.SwitchBranch(0); err == nil {
+		t.Errorf("switch branch should fail past common position")
+	}
+	un.UndoPop()
+	if err := un.SwitchBranch(0); err != nil {
+		t.Error(err)
+	}
+	if len(un.Stack) != 3 || un.SavePos != 3 || len(un.Branches[0].Stack) != 2 {
+		t.Errorf("switch branch wrong: stack: %d savepos: %d", len(un.Stack), un.SavePos)
+	}
+}
+
+func TestUndoHistory(t *testing.T) {
+	un := testUndo()
+	var b bytes.Buffer
+	if err := un.WriteHistory(&b, "hash"); err == nil {
+		t.Errorf("write history should fail when saved version is not on stack")
+	}
+	un.MarkSaved()
+	if err := un.WriteHistory(&b, "hash"); err != nil {
+		t.Fatal(err)
+	}
+	rb := bytes.NewReader(b.Bytes())
+	nu := &Undo{}
+	if err := nu.ReadHistory(rb, "other"); err == nil {
+		t.Errorf("read history should fail for different hash")
+	}
+	rb.Seek(0, 0)
+	if err := nu.ReadHistory(rb, "hash"); err != nil {
+		t.Fatal(err)
+	}
+	if nu.Pos != 2 || nu.SavePos != 2 || len(nu.Stack) != 2 || len(nu.Branches) != 1 {
+		t.Fatalf("history not restored: %+v", nu)
+	}
+	if nu.Stack[0] != nu.Branches[0].Stack[0] {
+		t.Errorf("shared edits not restored as shared")
+	}
+	if string(nu.Branches[0].Stack[2].ToBytes()) != "ccc" || nu.Stack[1].Reg != un.Stack[1].Reg {
+		t.Errorf("edits not restored")
+	}
+}
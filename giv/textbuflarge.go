@@ -0,0 +1,355 @@
+// Copyright (c) 2020, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package giv
+
+import (
+	"bytes"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/goki/gi/gi"
+	"github.com/goki/gi/giv/textbuf"
+	"github.com/goki/gi/mat32"
+	"github.com/goki/ki/ints"
+	"github.com/goki/ki/ki"
+)
+
+// TextBufLargeSize is the size in bytes above which files are opened in
+// large-file mode by TextBuf.Open -- see LargeFile
+var TextBufLargeSize = 32 * 1024 * 1024
+
+// TextBufLargeWindow is the number of lines of a large file that are loaded
+// into the buffer at a time
+var TextBufLargeWindow = 2000
+
+// TextBufLargeMargin is the number of lines from the edge of the window of
+// a large file within which moving the cursor moves the window
+var TextBufLargeMargin = 200
+
+// LargeFile is the state of a TextBuf in large-file mode, for files too
+// large to hold in memory as lines of runes and markup: the file is stored
+// in a textbuf.PieceTable, memory-mapped with the lines indexed only as
+// needed, and only a window of its lines is loaded into the buffer, where
+// it is viewed, edited and highlighted as usual.  The window moves to
+// follow the cursor and scrolling in the TextView (see
+// TextView.LargeWindowCheck and GoToFilePos), and the edits in the window
+// are stored in the table and written to the file on saving.  Line numbers
+// in the buffer are relative to the window (see TextBuf.FileLine), and Txt,
+// Search, Undo and the language server only apply to the window.  As the
+// undo history only applies to the lines in the window, the window does not
+// move while the buffer has unsaved edits -- they must be saved first.
+type LargeFile struct {
+	Table  *textbuf.PieceTable `desc:"storage for the whole file, with the edits from outside the current window"`
+	WinSt  int                 `desc:"line in the file of the first line of the window in the buffer"`
+	WinN   int                 `desc:"number of lines of the file in the window, as it was loaded or last stored"`
+	AtEnd  bool                `desc:"the window includes the end of the file"`
+	moving bool
+	warned bool
+}
+
+// IsLarge returns true if the buffer is in large-file mode -- see LargeFile
+func (tb *TextBuf) IsLarge() bool {
+	return tb.Large != nil
+}
+
+// FileLine returns the line in the file for given line in the buffer, which
+// is the same unless the buffer is in large-file mode
+func (tb *TextBuf) FileLine(ln int) int {
+	if tb == nil || tb.Large == nil {
+		return ln
+	}
+	return tb.Large.WinSt + ln
+}
+
+// RegionFromFile returns given region with lines in the file as a region in
+// the buffer, which is the same unless the buffer is in large-file mode
+func (tb *TextBuf) RegionFromFile(reg textbuf.Region) textbuf.Region {
+	if tb == nil || tb.Large == nil {
+		return reg
+	}
+	reg.Start.Ln -= tb.Large.WinSt
+	reg.End.Ln -= tb.Large.WinSt
+	return reg
+}
+
+// OpenLarge opens given file in large-file mode -- see LargeFile.  This is
+// called by Open for files larger than TextBufLargeSize.
+func (tb *TextBuf) OpenLarge(filename gi.FileName) error {
+	tb.Defaults()
+	tb.StopLangServer()
+	tb.CloseLarge()
+	pt, err := textbuf.OpenPieceTable(string(filename))
+	if err != nil {
+		vp := tb.ViewportFromView()
+		gi.PromptDialog(vp, gi.DlgOpts{Title: "File could not be Opened", Prompt: err.Error()}, gi.AddOk, gi.NoCancel, nil, nil)
+		log.Println(err)
+		return err
+	}
+	tb.Large = &LargeFile{Table: pt}
+	tb.Filename = filename
+	tb.Stat()
+	tb.SetName(string(filename))
+	tb.StartFileWatch()
+	tb.LargeSetWindow(0)
+	return nil
+}
+
+// CloseLarge ends large-file mode, if on, releasing the file
+func (tb *TextBuf) CloseLarge() {
+	if tb.Large == nil {
+		return
+	}
+	tb.Large.Table.Close()
+	tb.Large = nil
+}
+
+// largeStore stores the lines in the window into the table, if edited
+func (tb *TextBuf) largeStore() {
+	lf := tb.Large
+	tb.LinesMu.RLock()
+	lns := make([][]byte, tb.NLines)
+	for i, ln := range tb.Lines {
+		lns[i] = []byte(string(ln))
+	}
+	tb.LinesMu.RUnlock()
+	olns := lf.Table.Lines(lf.WinSt, lf.WinSt+lf.WinN)
+	same := len(olns) == len(lns)
+	for i := 0; same && i < len(lns); i++ {
+		same = bytes.Equal(olns[i], lns[i])
+	}
+	if same {
+		return
+	}
+	lf.Table.ReplaceLines(lf.WinSt, lf.WinSt+lf.WinN, lns)
+	lf.WinN = len(lns)
+}
+
+// LargeSetWindow moves the window of a buffer in large-file mode to start
+// at given line in the file (moved back if needed to fill the window at the
+// end of the file), and returns the actual starting line.  The cursors of
+// the views are moved so they stay on the same lines of the file where
+// possible.  The window does not move if the buffer has unsaved edits, as
+// the undo history would be lost -- see largeMoveOk.
+func (tb *TextBuf) LargeSetWindow(st int) int {
+	lf := tb.Large
+	if lf == nil {
+		return 0
+	}
+	if lf.WinN > 0 && !tb.largeMoveOk() {
+		return lf.WinSt
+	}
+	st = ints.MaxInt(st, 0)
+	lns := lf.Table.Lines(st, st+TextBufLargeWindow)
+	if len(lns) < TextBufLargeWindow && st > 0 {
+		st = ints.MaxInt(lf.Table.IndexedLines()-TextBufLargeWindow, 0)
+		lns = lf.Table.Lines(st, st+TextBufLargeWindow)
+	}
+	delta := st - lf.WinSt
+	lf.WinSt = st
+	lf.WinN = len(lns)
+	lf.AtEnd = len(lns) < TextBufLargeWindow || lf.Table.LineStart(st+len(lns)) >= lf.Table.Len()
+	for _, tv := range tb.Views {
+		tv.CursorPos.Ln = ints.MaxInt(tv.CursorPos.Ln-delta, 0)
+	}
+	if len(lns) == 0 {
+		lns = [][]byte{{}}
+	}
+	lf.moving = true
+	tb.SetTextLines(lns, false) // emits TextBufNew
+	lf.moving = false
+	tb.ReMarkup(false)
+	return st
+}
+
+// largeMoveOk returns true if the window of a buffer in large-file mode can
+// move, which is only if the buffer has no unsaved edits -- otherwise the
+// user is told, once until the buffer is saved, to save the edits first.
+func (tb *TextBuf) largeMoveOk() bool {
+	if !tb.IsChanged() {
+		return true
+	}
+	lf := tb.Large
+	if !lf.warned {
+		lf.warned = true
+		gi.PromptDialog(tb.ViewportFromView(), gi.DlgOpts{Title: "Save Edits to Move in Large File", Prompt: "Only the lines around the cursor of this large file are loaded for editing -- save the edits to these lines to move on to other parts of the file (the edits can no longer be undone then)."}, gi.AddOk, gi.NoCancel, nil, nil)
+	}
+	return false
+}
+
+// SaveLarge saves a buffer in large-file mode to given file, which is
+// written as a new file that then replaces any existing one, so the
+// original file stays valid for the table until it is reopened
+func (tb *TextBuf) SaveLarge(filename gi.FileName) error {
+	lf := tb.Large
+	tb.largeStore()
+	fnm := string(filename)
+	err := func() error {
+		tf, err := ioutil.TempFile(filepath.Dir(fnm), "."+filepath.Base(fnm)+".tmp")
+		if err != nil {
+			return err
+		}
+		if _, err = lf.Table.WriteTo(tf); err != nil {
+			tf.Close()
+			os.Remove(tf.Name())
+			return err
+		}
+		if err = tf.Close(); err != nil {
+			os.Remove(tf.Name())
+			return err
+		}
+		perm := os.FileMode(0644)
+		if st, err := os.Stat(fnm); err == nil {
+			perm = st.Mode().Perm()
+		}
+		os.Chmod(tf.Name(), perm)
+		return os.Rename(tf.Name(), fnm)
+	}()
+	if err != nil {
+		gi.PromptDialog(nil, gi.DlgOpts{Title: "Could not Save to File", Prompt: err.Error()}, gi.AddOk, gi.NoCancel, nil, nil)
+		log.Println(err)
+		return err
+	}
+	pt, err := textbuf.OpenPieceTable(fnm) // release the old file, with no edits to keep
+	if err == nil {
+		lf.Table.Close()
+		lf.Table = pt
+	}
+	tb.Filename = filename
+	tb.SetName(fnm)
+	tb.Stat()
+	tb.Undos.MarkSaved()
+	tb.ClearChanged()
+	lf.warned = false
+	tb.StartFileWatch()
+	return nil
+}
+
+// RevertLarge reopens the file of a buffer in large-file mode, keeping the
+// window at the same place in the file
+func (tb *TextBuf) RevertLarge() bool {
+	st := tb.Large.WinSt
+	pt, err := textbuf.OpenPieceTable(string(tb.Filename))
+	if err != nil {
+		log.Println(err)
+		return false
+	}
+	tb.Large.Table.Close()
+	tb.Large = &LargeFile{Table: pt, WinSt: st}
+	tb.Stat()
+	tb.ClearChanged()
+	tb.LargeSetWindow(st) // WinN = 0: nothing to store
+	tb.Undos.MarkSaved()
+	return true
+}
+
+// LargeWindowCheck moves the window of a buffer in large-file mode if given
+// cursor position is within TextBufLargeMargin lines of the edge of the
+// window (or outside of it), unless the window is at the start or end of
+// the file, so that the window follows the cursor through the file --
+// returns the position adjusted for any move of the window.
+func (tv *TextView) LargeWindowCheck(pos textbuf.Pos) textbuf.Pos {
+	tb := tv.Buf
+	if tb == nil || !tb.IsLarge() {
+		return pos
+	}
+	lf := tb.Large
+	if lf.moving {
+		return pos
+	}
+	nln := tb.NumLines()
+	if (pos.Ln >= TextBufLargeMargin || lf.WinSt == 0) && (pos.Ln < nln-TextBufLargeMargin || lf.AtEnd) {
+		return pos
+	}
+	fln := lf.WinSt + pos.Ln
+	st := tb.LargeSetWindow(fln - TextBufLargeWindow/2)
+	pos.Ln = fln - st
+	return pos
+}
+
+// GoToFilePos moves the cursor to given position, with the line in the
+// file, which is the line in the buffer unless it is in large-file mode,
+// where the window is moved there if needed -- returns the new cursor
+// position in the buffer, which stays within the window if it cannot move.
+func (tv *TextView) GoToFilePos(pos textbuf.Pos) textbuf.Pos {
+	tb := tv.Buf
+	if tb == nil {
+		return pos
+	}
+	if tb.IsLarge() {
+		lf := tb.Large
+		nln := tb.NumLines()
+		if (pos.Ln < lf.WinSt+TextBufLargeMargin && lf.WinSt > 0) || (pos.Ln >= lf.WinSt+nln-TextBufLargeMargin && !lf.AtEnd) {
+			tb.LargeSetWindow(pos.Ln - TextBufLargeWindow/2)
+		}
+		pos.Ln -= lf.WinSt
+	}
+	tv.SetCursorShow(pos)
+	return tv.CursorPos
+}
+
+// GoToLine moves the cursor to the start of given line in the file -- see
+// GoToFilePos
+func (tv *TextView) GoToLine(fln int) {
+	tv.GoToFilePos(textbuf.Pos{Ln: fln})
+}
+
+// LargeScrollEvents connects to the scrolling of the parent layout of the
+// view, so the window of a buffer in large-file mode follows scrolling, as
+// it does the cursor -- see LargeScrollCheck
+func (tv *TextView) LargeScrollEvents() {
+	ly := tv.ParentScrollLayout()
+	if ly == nil {
+		return
+	}
+	ly.ScrollSig.Connect(tv.This(), func(recv, send ki.Ki, sig int64, data interface{}) {
+		if mat32.Dims(sig) != mat32.Y {
+			return
+		}
+		tvv := recv.Embed(KiT_TextView).(*TextView)
+		tvv.LargeScrollCheck()
+	})
+}
+
+// LargeScrollCheck moves the window of a buffer in large-file mode when the
+// view has been scrolled (e.g., with the mouse wheel) to within
+// TextBufLargeMargin lines of the edge of the window, by moving the cursor
+// to the first or last visible line -- see LargeWindowCheck.  Nothing is
+// done while the buffer has unsaved edits, as the window cannot move.
+func (tv *TextView) LargeScrollCheck() {
+	tb := tv.Buf
+	if tb == nil || !tb.IsLarge() || tv.NLines == 0 || tb.Large.moving || tb.IsChanged() {
+		return
+	}
+	lf := tb.Large
+	nln := tb.NumLines()
+	stln := tv.FirstVisibleLine(0)
+	switch {
+	case stln < TextBufLargeMargin && lf.WinSt > 0:
+		tv.SetCursorShow(textbuf.Pos{Ln: stln})
+	case !lf.AtEnd:
+		if edln := tv.LastVisibleLine(stln); edln >= nln-TextBufLargeMargin {
+			tv.SetCursorShow(textbuf.Pos{Ln: edln})
+		}
+	}
+}
+
+// LargeStartDoc moves the window of a buffer in large-file mode to the start
+// of the file, for moving the cursor to the start of the text
+func (tv *TextView) LargeStartDoc() {
+	if tb := tv.Buf; tb != nil && tb.IsLarge() && tb.Large.WinSt > 0 {
+		tb.LargeSetWindow(0)
+	}
+}
+
+// LargeEndDoc moves the window of a buffer in large-file mode to the end of
+// the file, for moving the cursor to the end of the text -- this indexes
+// all the lines of the file
+func (tv *TextView) LargeEndDoc() {
+	if tb := tv.Buf; tb != nil && tb.IsLarge() && !tb.Large.AtEnd {
+		tb.LargeSetWindow(tb.Large.Table.NumLines())
+	}
+}
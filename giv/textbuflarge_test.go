@@ -0,0 +1,106 @@
+// Copyright (c) 2020, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package giv_test
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/goki/gi/gi"
+	"github.com/goki/gi/giv"
+	"github.com/goki/gi/giv/textbuf"
+)
+
+func TestTextBufLarge(t *testing.T) {
+	dir, err := ioutil.TempDir("", "textbuflarge")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	fnm := filepath.Join(dir, "large.txt")
+	var b bytes.Buffer
+	for i := 0; i < 20000; i++ {
+		fmt.Fprintf(&b, "line %d\n", i)
+	}
+	ioutil.WriteFile(fnm, b.Bytes(), 0644)
+
+	osz := giv.TextBufLargeSize
+	giv.TextBufLargeSize = 1024
+	defer func() { giv.TextBufLargeSize = osz }()
+
+	tb := giv.NewTextBuf()
+	var tv *giv.TextView
+	h := newTestWindow(t, func(mfr *gi.Frame) {
+		tv = giv.AddNewTextView(mfr, "tv")
+		tv.SetStretchMax()
+		tv.SetBuf(tb)
+	})
+	defer h.Close()
+	if err := tb.Open(gi.FileName(fnm)); err != nil {
+		t.Fatal(err)
+	}
+	h.WaitIdle()
+	if !tb.IsLarge() || tb.NumLines() != giv.TextBufLargeWindow {
+		t.Fatalf("not in large-file mode: large: %v, lines: %d", tb.IsLarge(), tb.NumLines())
+	}
+
+	// going to a line in the file moves the window there
+	tv.GoToLine(15000)
+	h.WaitIdle()
+	if got := tb.FileLine(tv.CursorPos.Ln); got != 15000 {
+		t.Errorf("file line of cursor after GoToLine(15000): %d", got)
+	}
+	if got := string(tb.Line(tv.CursorPos.Ln)); got != "line 15000" {
+		t.Errorf("line at cursor after GoToLine(15000): %q", got)
+	}
+	reg := tb.RegionFromFile(textbuf.Region{Start: textbuf.Pos{Ln: 15000}, End: textbuf.Pos{Ln: 15001}})
+	if reg.Start.Ln != tv.CursorPos.Ln || reg.End.Ln != tv.CursorPos.Ln+1 {
+		t.Errorf("RegionFromFile: %v, cursor: %v", reg, tv.CursorPos)
+	}
+
+	tv.CursorEndDoc()
+	h.WaitIdle()
+	if !tb.Large.AtEnd || tb.FileLine(tv.CursorPos.Ln) != 19999 {
+		t.Errorf("CursorEndDoc: at end: %v, file line: %d", tb.Large.AtEnd, tb.FileLine(tv.CursorPos.Ln))
+	}
+
+	// the window does not move while there are edits, which can be undone
+	tv.GoToLine(10000)
+	h.WaitIdle()
+	winst := tb.Large.WinSt
+	tb.InsertText(textbuf.Pos{Ln: tv.CursorPos.Ln}, []byte("edit "), true)
+	tv.GoToLine(0)
+	h.WaitIdle()
+	if tb.Large.WinSt != winst {
+		t.Errorf("window moved from %d to %d with unsaved edits", winst, tb.Large.WinSt)
+	}
+	if got := string(tb.Line(10000 - winst)); got != "edit line 10000" {
+		t.Errorf("edited line: %q", got)
+	}
+	tb.Undo()
+	if got := string(tb.Line(10000 - winst)); got != "line 10000" {
+		t.Errorf("edited line after undo: %q", got)
+	}
+
+	// after saving, the window moves again
+	tb.InsertText(textbuf.Pos{Ln: 10000 - winst}, []byte("edit "), true)
+	if err := tb.Save(); err != nil {
+		t.Fatal(err)
+	}
+	tv.CursorStartDoc()
+	h.WaitIdle()
+	if tb.Large.WinSt != 0 || tv.CursorPos.Ln != 0 {
+		t.Errorf("CursorStartDoc after saving: window start: %d, cursor: %v", tb.Large.WinSt, tv.CursorPos)
+	}
+	tv.GoToLine(10000)
+	h.WaitIdle()
+	if got := string(tb.Line(tv.CursorPos.Ln)); got != "edit line 10000" {
+		t.Errorf("saved edit after moving back: %q", got)
+	}
+}
@@ -5,10 +5,13 @@
 package giv
 
 import (
+	"bytes"
 	"fmt"
 	"image"
 	"image/draw"
 	"log"
+	"regexp"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -31,6 +34,7 @@ import (
 	"github.com/goki/ki/ki"
 	"github.com/goki/ki/kit"
 	"github.com/goki/pi/filecat"
+	"github.com/goki/pi/lex"
 	"github.com/goki/pi/token"
 )
 
@@ -50,6 +54,8 @@ type TextView struct {
 	Offs                   []float32                 `json:"-" xml:"-" desc:"starting offsets for top of each line"`
 	LineNoDigs             int                       `json:"-" xml:"-" desc:"number of line number digits needed"`
 	LineNoOff              float32                   `json:"-" xml:"-" desc:"horizontal offset for start of text after line numbers"`
+	MinimapOff             float32                   `json:"-" xml:"-" desc:"width of the minimap at the right edge of the view, if shown (per TextBuf Minimap option)"`
+	MinimapDiffs           textbuf.Diffs             `json:"-" xml:"-" desc:"diffs from the buffer to another version of it, shown as change markers in the minimap -- see SetMinimapDiffs"`
 	LineNoRender           gi.TextRender             `json:"-" xml:"-" desc:"render for line numbers"`
 	LinesSize              image.Point               `json:"-" xml:"-" desc:"total size of all lines as rendered"`
 	RenderSz               mat32.Vec2                `json:"-" xml:"-" desc:"size params to use in render call"`
@@ -63,6 +69,8 @@ type TextView struct {
 	PrevSelectReg          textbuf.Region            `json:"-" xml:"-" desc:"previous selection region, that was actually rendered -- needed to update render"`
 	Highlights             []textbuf.Region          `json:"-" xml:"-" desc:"highlighted regions, e.g., for search results"`
 	Scopelights            []textbuf.Region          `json:"-" xml:"-" desc:"highlighted regions, specific to scope markers"`
+	MultiCursors           []TextCursor              `json:"-" xml:"-" desc:"additional cursors for multi-cursor editing, beyond the main CursorPos and SelectReg -- edits apply to all cursors"`
+	Folds                  []textbuf.Region          `json:"-" xml:"-" desc:"currently folded regions -- the Start line remains visible and the following lines through the End line are hidden"`
 	SelectMode             bool                      `json:"-" xml:"-" desc:"if true, select text as cursor moves"`
 	ForceComplete          bool                      `json:"-" xml:"-" desc:"if true, complete regardless of any disqualifying reasons"`
 	ISearch                ISearch                   `json:"-" xml:"-" desc:"interactive search data"`
@@ -79,6 +87,12 @@ type TextView struct {
 	lastRecenter           int
 	lastAutoInsert         rune
 	lastFilename           gi.FileName
+	foldHidden             []bool
+	foldables              map[int]textbuf.Region
+	minimapDrag            bool
+	minimapImg             *image.RGBA
+	minimapRS              gi.RenderState
+	minimapScale           float32
 }
 
 var KiT_TextView = kit.Types.AddType(&TextView{}, TextViewProps)
@@ -209,6 +223,10 @@ const (
 	// TextViewLastWasUndo indicates that last key was an undo
 	TextViewLastWasUndo
 
+	// TextViewMinimapDirty indicates that the cached image of the minimap
+	// needs to be rendered again -- see SetMinimapDirty
+	TextViewMinimapDirty
+
 	TextViewFlagsN
 )
 
@@ -309,6 +327,9 @@ func (tv *TextView) Clear() {
 func (tv *TextView) ResetState() {
 	tv.SelectReset()
 	tv.Highlights = nil
+	tv.MultiCursors = nil
+	tv.Folds = nil
+	tv.foldables = nil
 	tv.ISearch.On = false
 	tv.QReplace.On = false
 	if tv.Buf == nil || tv.lastFilename != tv.Buf.Filename { // don't reset if reopening..
@@ -368,6 +389,7 @@ func (tv *TextView) LinesInserted(tbe *textbuf.Edit) {
 	tv.Offs = nof
 
 	tv.NLines += nsz
+	tv.updateFoldHidden()
 
 	tv.LayoutLines(tbe.Reg.Start.Ln, tbe.Reg.End.Ln, false)
 	tv.RenderAllLines()
@@ -383,6 +405,7 @@ func (tv *TextView) LinesDeleted(tbe *textbuf.Edit) {
 	tv.Offs = append(tv.Offs[:stln], tv.Offs[edln:]...)
 
 	tv.NLines -= dsz
+	tv.updateFoldHidden()
 
 	tv.LayoutLines(tbe.Reg.Start.Ln, tbe.Reg.Start.Ln, true)
 	tv.RenderAllLines()
@@ -391,6 +414,7 @@ func (tv *TextView) LinesDeleted(tbe *textbuf.Edit) {
 // TextViewBufSigRecv receives a signal from the buffer and updates view accordingly
 func TextViewBufSigRecv(rvwki, sbufki ki.Ki, sig int64, data interface{}) {
 	tv := rvwki.Embed(KiT_TextView).(*TextView)
+	tv.SetMinimapDirty()
 	if !tv.This().(gi.Node2D).IsVisible() {
 		return
 	}
@@ -405,6 +429,7 @@ func TextViewBufSigRecv(rvwki, sbufki ki.Ki, sig int64, data interface{}) {
 			return
 		}
 		tbe := data.(*textbuf.Edit)
+		rmfold := tv.AdjustFolds(tbe)
 		// fmt.Printf("tv %v got %v\n", tv.Nm, tbe.Reg.Start)
 		if tbe.Reg.Start.Ln != tbe.Reg.End.Ln {
 			// fmt.Printf("tv %v lines insert %v - %v\n", tv.Nm, tbe.Reg.Start, tbe.Reg.End)
@@ -419,11 +444,15 @@ func TextViewBufSigRecv(rvwki, sbufki ki.Ki, sig int64, data interface{}) {
 				tv.RenderLines(tbe.Reg.Start.Ln, tbe.Reg.End.Ln)
 			}
 		}
+		if rmfold {
+			tv.UpdateFolds()
+		}
 	case TextBufDelete:
 		if tv.Renders == nil { // not init yet
 			return
 		}
 		tbe := data.(*textbuf.Edit)
+		rmfold := tv.AdjustFolds(tbe)
 		if tbe.Reg.Start.Ln != tbe.Reg.End.Ln {
 			tv.LinesDeleted(tbe)
 		} else {
@@ -434,7 +463,11 @@ func TextViewBufSigRecv(rvwki, sbufki ki.Ki, sig int64, data interface{}) {
 				tv.RenderLines(tbe.Reg.Start.Ln, tbe.Reg.End.Ln)
 			}
 		}
+		if rmfold {
+			tv.UpdateFolds()
+		}
 	case TextBufMarkUpdt:
+		tv.foldables = nil
 		tv.SetNeedsRefresh() // comes from another goroutine
 	case TextBufClosed:
 		tv.SetBuf(nil)
@@ -483,7 +516,7 @@ func (tv *TextView) RenderSize() mat32.Vec2 {
 		tv.RenderSz = sz
 		// fmt.Printf("fallback rendersz: %v\n", tv.RenderSz)
 	}
-	tv.RenderSz.X -= tv.LineNoOff
+	tv.RenderSz.X -= tv.LineNoOff + tv.MinimapOff
 	// fmt.Printf("rendersz: %v\n", tv.RenderSz)
 	return tv.RenderSz
 }
@@ -517,6 +550,7 @@ func (tv *TextView) LayoutAllLines(inLayout bool) bool {
 		return false
 		// tv.StyleTextView() // this fails on mac
 	}
+	tv.SetMinimapDirty()
 	tv.lastFilename = tv.Buf.Filename
 
 	tv.Buf.Hi.TabSize = tv.Sty.Text.TabSize
@@ -524,6 +558,7 @@ func (tv *TextView) LayoutAllLines(inLayout bool) bool {
 	// fmt.Printf("layout all: %v\n", tv.Nm)
 
 	tv.NLines = tv.Buf.NumLines()
+	tv.updateFoldHidden()
 	nln := tv.NLines
 	if cap(tv.Renders) >= nln {
 		tv.Renders = tv.Renders[:nln]
@@ -555,8 +590,7 @@ func (tv *TextView) LayoutAllLines(inLayout bool) bool {
 			tv.HasLinks = true
 		}
 		tv.Offs[ln] = off
-		lsz := mat32.Max(tv.Renders[ln].Size.Y, tv.LineHeight)
-		off += lsz
+		off += tv.LineVisHeight(ln)
 		mxwd = mat32.Max(mxwd, tv.Renders[ln].Size.X)
 	}
 	tv.Buf.MarkupMu.RUnlock()
@@ -576,8 +610,8 @@ func (tv *TextView) SetSize() bool {
 	sty := &tv.Sty
 	spc := sty.BoxSpace()
 	rndsz := tv.RenderSz
-	rndsz.X += tv.LineNoOff
-	netsz := mat32.Vec2{float32(tv.LinesSize.X) + tv.LineNoOff, float32(tv.LinesSize.Y)}
+	rndsz.X += tv.LineNoOff + tv.MinimapOff
+	netsz := mat32.Vec2{float32(tv.LinesSize.X) + tv.LineNoOff + tv.MinimapOff, float32(tv.LinesSize.Y)}
 	cursz := tv.LayData.AllocSize.SubScalar(2 * spc)
 	if cursz.X < 10 || cursz.Y < 10 {
 		nwsz := netsz.Max(rndsz)
@@ -663,8 +697,7 @@ func (tv *TextView) LayoutLines(st, ed int, isDel bool) bool {
 		off := tv.Offs[ofst]
 		for ln := ofst; ln < tv.NLines; ln++ {
 			tv.Offs[ln] = off
-			lsz := mat32.Max(tv.Renders[ln].Size.Y, tv.LineHeight)
-			off += lsz
+			off += tv.LineVisHeight(ln)
 		}
 		extraHalf := tv.LineHeight * 0.5 * float32(tv.VisSize.Y)
 		nwSz := mat32.Vec2{mxwd, off + extraHalf}.ToPointCeil()
@@ -677,6 +710,246 @@ func (tv *TextView) LayoutLines(st, ed int, isDel bool) bool {
 	return rerend
 }
 
+///////////////////////////////////////////////////////////////////////////////
+//  Folding
+
+// Foldables returns the foldable regions of the buffer, keyed by starting
+// (header) line -- computed from TextBuf.FoldRegions and cached until the
+// next edit or markup update.
+func (tv *TextView) Foldables() map[int]textbuf.Region {
+	if tv.foldables != nil || tv.Buf == nil {
+		return tv.foldables
+	}
+	frs := tv.Buf.FoldRegions()
+	tv.foldables = make(map[int]textbuf.Region, len(frs))
+	for _, fr := range frs {
+		tv.foldables[fr.Start.Ln] = fr
+	}
+	return tv.foldables
+}
+
+// FoldableAt returns the foldable region starting at given line, or else the
+// innermost one containing it, and false if none.
+func (tv *TextView) FoldableAt(ln int) (textbuf.Region, bool) {
+	fm := tv.Foldables()
+	if fr, has := fm[ln]; has {
+		return fr, true
+	}
+	var bfr textbuf.Region
+	bst := -1
+	for st, fr := range fm {
+		if st < ln && ln <= fr.End.Ln && st > bst {
+			bst = st
+			bfr = fr
+		}
+	}
+	return bfr, bst >= 0
+}
+
+// FoldIdx returns the index in Folds of the fold starting at given line, or -1 if none
+func (tv *TextView) FoldIdx(ln int) int {
+	for i, f := range tv.Folds {
+		if f.Start.Ln == ln {
+			return i
+		}
+	}
+	return -1
+}
+
+// IsLineHidden returns true if given line is hidden within a fold
+func (tv *TextView) IsLineHidden(ln int) bool {
+	return ln >= 0 && ln < len(tv.foldHidden) && tv.foldHidden[ln]
+}
+
+// LineVisHeight returns the rendered height of given line, which is 0 if it
+// is hidden within a fold
+func (tv *TextView) LineVisHeight(ln int) float32 {
+	if tv.IsLineHidden(ln) {
+		return 0
+	}
+	return mat32.Max(tv.Renders[ln].Size.Y, tv.LineHeight)
+}
+
+// FoldHeaderLine returns the visible header line of the outermost fold that
+// hides given line, or the line itself if it is not hidden
+func (tv *TextView) FoldHeaderLine(ln int) int {
+	for tv.IsLineHidden(ln) {
+		hl := ln
+		for _, f := range tv.Folds {
+			if f.Start.Ln < hl && ln <= f.End.Ln {
+				hl = f.Start.Ln
+			}
+		}
+		if hl == ln {
+			break
+		}
+		ln = hl
+	}
+	return ln
+}
+
+// FoldNextVisLine returns the first line at or after given line that is not
+// hidden within a fold, or -1 if there are none
+func (tv *TextView) FoldNextVisLine(ln int) int {
+	for ln < tv.NLines && tv.IsLineHidden(ln) {
+		ln++
+	}
+	if ln >= tv.NLines {
+		return -1
+	}
+	return ln
+}
+
+// FoldClipRegion clips given region to the visible lines, for rendering,
+// returning false if it is entirely hidden within a fold
+func (tv *TextView) FoldClipRegion(reg textbuf.Region) (textbuf.Region, bool) {
+	if len(tv.Folds) == 0 {
+		return reg, true
+	}
+	if tv.IsLineHidden(reg.End.Ln) {
+		hl := tv.FoldHeaderLine(reg.End.Ln)
+		reg.End = textbuf.Pos{Ln: hl, Ch: tv.Buf.LineLen(hl)}
+	}
+	if tv.IsLineHidden(reg.Start.Ln) {
+		nln := tv.FoldNextVisLine(reg.Start.Ln)
+		if nln < 0 {
+			return reg, false
+		}
+		reg.Start = textbuf.Pos{Ln: nln}
+	}
+	if reg.End.IsLess(reg.Start) {
+		return reg, false
+	}
+	return reg, true
+}
+
+// updateFoldHidden updates the record of hidden lines from the Folds
+func (tv *TextView) updateFoldHidden() {
+	tv.SetMinimapDirty()
+	nln := tv.NLines
+	if cap(tv.foldHidden) >= nln {
+		tv.foldHidden = tv.foldHidden[:nln]
+		for i := range tv.foldHidden {
+			tv.foldHidden[i] = false
+		}
+	} else {
+		tv.foldHidden = make([]bool, nln)
+	}
+	for _, f := range tv.Folds {
+		ed := ints.MinInt(f.End.Ln, nln-1)
+		for ln := f.Start.Ln + 1; ln <= ed; ln++ {
+			tv.foldHidden[ln] = true
+		}
+	}
+}
+
+// AdjustFolds adjusts the Folds for given edit, removing any that it modified
+// -- returns true if any were removed.
+func (tv *TextView) AdjustFolds(tbe *textbuf.Edit) bool {
+	tv.foldables = nil
+	if len(tv.Folds) == 0 {
+		return false
+	}
+	rm := false
+	nf := tv.Folds[:0]
+	for _, f := range tv.Folds {
+		if af, ok := textbuf.AdjustFold(f, tbe); ok {
+			nf = append(nf, af)
+		} else {
+			rm = true
+		}
+	}
+	tv.Folds = nf
+	return rm
+}
+
+// UpdateFolds updates the hidden lines and line offsets after a change in the
+// Folds, and re-renders
+func (tv *TextView) UpdateFolds() {
+	tv.updateFoldHidden()
+	if len(tv.Offs) < tv.NLines || len(tv.Renders) < tv.NLines {
+		return
+	}
+	off := float32(0)
+	for ln := 0; ln < tv.NLines; ln++ {
+		tv.Offs[ln] = off
+		off += tv.LineVisHeight(ln)
+	}
+	extraHalf := tv.LineHeight * 0.5 * float32(tv.VisSize.Y)
+	nwSz := mat32.Vec2{float32(tv.LinesSize.X), off + extraHalf}.ToPointCeil()
+	tv.ResizeIfNeeded(nwSz)
+	tv.RenderAllLines()
+}
+
+// FoldLine folds the foldable region starting at given line, or else the
+// innermost one containing it -- returns false if there is nothing to fold.
+// If the cursor is within the folded lines, it is moved to the end of the
+// header line.
+func (tv *TextView) FoldLine(ln int) bool {
+	fr, ok := tv.FoldableAt(ln)
+	if !ok || tv.FoldIdx(fr.Start.Ln) >= 0 {
+		return false
+	}
+	tv.Folds = append(tv.Folds, fr)
+	tv.UpdateFolds()
+	if tv.IsLineHidden(tv.CursorPos.Ln) {
+		hl := tv.FoldHeaderLine(tv.CursorPos.Ln)
+		tv.SetCursorShow(textbuf.Pos{Ln: hl, Ch: tv.Buf.LineLen(hl)})
+	}
+	return true
+}
+
+// UnfoldLine removes the folds that start at or hide given line --
+// returns false if there were none
+func (tv *TextView) UnfoldLine(ln int) bool {
+	rm := false
+	nf := tv.Folds[:0]
+	for _, f := range tv.Folds {
+		if f.Start.Ln <= ln && ln <= f.End.Ln {
+			rm = true
+			continue
+		}
+		nf = append(nf, f)
+	}
+	tv.Folds = nf
+	if rm {
+		tv.UpdateFolds()
+	}
+	return rm
+}
+
+// FoldToggle unfolds the fold starting at given line if it is folded, and
+// otherwise folds the region at that line -- returns false if there was
+// nothing to fold or unfold.
+func (tv *TextView) FoldToggle(ln int) bool {
+	if fi := tv.FoldIdx(ln); fi >= 0 {
+		tv.Folds = append(tv.Folds[:fi], tv.Folds[fi+1:]...)
+		tv.UpdateFolds()
+		return true
+	}
+	return tv.FoldLine(ln)
+}
+
+// FoldAll folds all of the foldable regions in the buffer
+func (tv *TextView) FoldAll() {
+	fm := tv.Foldables()
+	tv.Folds = tv.Folds[:0]
+	for _, fr := range fm {
+		tv.Folds = append(tv.Folds, fr)
+	}
+	tv.UpdateFolds()
+	if tv.IsLineHidden(tv.CursorPos.Ln) {
+		hl := tv.FoldHeaderLine(tv.CursorPos.Ln)
+		tv.SetCursorShow(textbuf.Pos{Ln: hl, Ch: tv.Buf.LineLen(hl)})
+	}
+}
+
+// UnfoldAll removes all of the folds
+func (tv *TextView) UnfoldAll() {
+	tv.Folds = nil
+	tv.UpdateFolds()
+}
+
 ///////////////////////////////////////////////////////////////////////////////
 //  Cursor Navigation
 
@@ -718,11 +991,15 @@ func (tv *TextView) SetCursor(pos textbuf.Pos) {
 		tv.CursorPos = textbuf.PosZero
 		return
 	}
+	pos = tv.LargeWindowCheck(pos)
 	wupdt := tv.TopUpdateStart()
 	defer tv.TopUpdateEnd(wupdt)
 	cpln := tv.CursorPos.Ln
 	tv.ClearScopelights()
 	tv.CursorPos = tv.Buf.ValidPos(pos)
+	if tv.IsLineHidden(tv.CursorPos.Ln) {
+		tv.UnfoldLine(tv.CursorPos.Ln)
+	}
 	if cpln != tv.CursorPos.Ln && tv.HasLineNos() { // update cursor position highlight
 		rs := &tv.Viewport.Render
 		rs.PushBounds(tv.VpBBox)
@@ -865,9 +1142,9 @@ func (tv *TextView) CursorForward(steps int) {
 	for i := 0; i < steps; i++ {
 		tv.CursorPos.Ch++
 		if tv.CursorPos.Ch > tv.Buf.LineLen(tv.CursorPos.Ln) {
-			if tv.CursorPos.Ln < tv.NLines-1 {
+			if nln := tv.FoldNextVisLine(tv.CursorPos.Ln + 1); nln >= 0 {
 				tv.CursorPos.Ch = 0
-				tv.CursorPos.Ln++
+				tv.CursorPos.Ln = nln
 			} else {
 				tv.CursorPos.Ch = tv.Buf.LineLen(tv.CursorPos.Ln)
 			}
@@ -917,9 +1194,9 @@ func (tv *TextView) CursorForwardWord(steps int) {
 			}
 			tv.CursorPos.Ch = ch
 		} else {
-			if tv.CursorPos.Ln < tv.NLines-1 {
+			if nln := tv.FoldNextVisLine(tv.CursorPos.Ln + 1); nln >= 0 {
 				tv.CursorPos.Ch = 0
-				tv.CursorPos.Ln++
+				tv.CursorPos.Ln = nln
 			} else {
 				tv.CursorPos.Ch = tv.Buf.LineLen(tv.CursorPos.Ln)
 			}
@@ -959,11 +1236,11 @@ func (tv *TextView) CursorDown(steps int) {
 			}
 		}
 		if !gotwrap {
-			pos.Ln++
-			if pos.Ln >= tv.NLines {
-				pos.Ln = tv.NLines - 1
+			nln := tv.FoldNextVisLine(pos.Ln + 1)
+			if nln < 0 {
 				break
 			}
+			pos.Ln = nln
 			mxlen := ints.MinInt(tv.Buf.LineLen(pos.Ln), tv.CursorCol)
 			if tv.CursorCol < mxlen {
 				pos.Ch = tv.CursorCol
@@ -985,7 +1262,7 @@ func (tv *TextView) CursorPageDown(steps int) {
 	org := tv.CursorPos
 	for i := 0; i < steps; i++ {
 		lvln := tv.LastVisibleLine(tv.CursorPos.Ln)
-		tv.CursorPos.Ln = lvln
+		tv.CursorPos.Ln = tv.FoldHeaderLine(lvln)
 		if tv.CursorPos.Ln >= tv.NLines {
 			tv.CursorPos.Ln = tv.NLines - 1
 		}
@@ -1007,7 +1284,7 @@ func (tv *TextView) CursorBackward(steps int) {
 		tv.CursorPos.Ch--
 		if tv.CursorPos.Ch < 0 {
 			if tv.CursorPos.Ln > 0 {
-				tv.CursorPos.Ln--
+				tv.CursorPos.Ln = tv.FoldHeaderLine(tv.CursorPos.Ln - 1)
 				tv.CursorPos.Ch = tv.Buf.LineLen(tv.CursorPos.Ln)
 			} else {
 				tv.CursorPos.Ch = 0
@@ -1062,7 +1339,7 @@ func (tv *TextView) CursorBackwardWord(steps int) {
 			tv.CursorPos.Ch = ch
 		} else {
 			if tv.CursorPos.Ln > 0 {
-				tv.CursorPos.Ln--
+				tv.CursorPos.Ln = tv.FoldHeaderLine(tv.CursorPos.Ln - 1)
 				tv.CursorPos.Ch = tv.Buf.LineLen(tv.CursorPos.Ln)
 			} else {
 				tv.CursorPos.Ch = 0
@@ -1099,6 +1376,7 @@ func (tv *TextView) CursorUp(steps int) {
 				pos.Ln = 0
 				break
 			}
+			pos.Ln = tv.FoldHeaderLine(pos.Ln)
 			if wln := tv.WrappedLines(pos.Ln); wln > 1 { // just entered end of wrapped line
 				si := wln - 1
 				ri := tv.CursorCol
@@ -1127,7 +1405,7 @@ func (tv *TextView) CursorPageUp(steps int) {
 	org := tv.CursorPos
 	for i := 0; i < steps; i++ {
 		lvln := tv.FirstVisibleLine(tv.CursorPos.Ln)
-		tv.CursorPos.Ln = lvln
+		tv.CursorPos.Ln = tv.FoldHeaderLine(lvln)
 		if tv.CursorPos.Ln <= 0 {
 			tv.CursorPos.Ln = 0
 		}
@@ -1193,6 +1471,7 @@ func (tv *TextView) CursorStartLine() {
 func (tv *TextView) CursorStartDoc() {
 	wupdt := tv.TopUpdateStart()
 	defer tv.TopUpdateEnd(wupdt)
+	tv.LargeStartDoc()
 	tv.ValidateCursor()
 	org := tv.CursorPos
 	tv.CursorPos.Ln = 0
@@ -1241,9 +1520,10 @@ func (tv *TextView) CursorEndLine() {
 func (tv *TextView) CursorEndDoc() {
 	wupdt := tv.TopUpdateStart()
 	defer tv.TopUpdateEnd(wupdt)
+	tv.LargeEndDoc()
 	tv.ValidateCursor()
 	org := tv.CursorPos
-	tv.CursorPos.Ln = ints.MaxInt(tv.NLines-1, 0)
+	tv.CursorPos.Ln = tv.FoldHeaderLine(ints.MaxInt(tv.NLines-1, 0))
 	tv.CursorPos.Ch = tv.Buf.LineLen(tv.CursorPos.Ln)
 	tv.CursorCol = tv.CursorPos.Ch
 	tv.SetCursor(tv.CursorPos)
@@ -1531,10 +1811,50 @@ func (tv *TextView) Redo() {
 	tv.SavePosHistory(tv.CursorPos)
 }
 
+// UndoTo moves the buffer to given state in the undo tree -- see TextBuf.UndoTo
+func (tv *TextView) UndoTo(st textbuf.UndoState) {
+	wupdt := tv.TopUpdateStart()
+	defer tv.TopUpdateEnd(wupdt)
+	tbe := tv.Buf.UndoTo(st)
+	if tbe != nil {
+		tv.SetCursorShow(tbe.Reg.Start)
+	} else {
+		tv.ScrollCursorToCenterIfHidden()
+	}
+	tv.SavePosHistory(tv.CursorPos)
+}
+
+// UndoBrowse opens a dialog listing all the states in the undo tree,
+// including those on branches that were abandoned by editing after undoing,
+// and moves the buffer to the selected state
+func (tv *TextView) UndoBrowse() {
+	if tv.Buf == nil {
+		return
+	}
+	sts := tv.Buf.Undos.States()
+	curRow := -1
+	for i := range sts {
+		if sts[i].Current {
+			curRow = i
+		}
+	}
+	TableViewSelectDialog(tv.Viewport, &sts, DlgOpts{Title: "Undo History", Prompt: "Select a state to move the text to -- Branch is -1 for the current history, and otherwise indexes a branch abandoned by editing after undoing"}, curRow, nil,
+		tv.This(), func(recv, send ki.Ki, sig int64, data interface{}) {
+			if sig == int64(gi.DialogAccepted) {
+				ddlg := send.Embed(gi.KiT_Dialog).(*gi.Dialog)
+				si := TableViewSelectDialogValue(ddlg)
+				if si >= 0 {
+					tvv := recv.Embed(KiT_TextView).(*TextView)
+					tvv.UndoTo(sts[si])
+				}
+			}
+		})
+}
+
 ///////////////////////////////////////////////////////////////////////////////
 //    Search / Find
 
-// FindMatches finds the matches with given search string (literal, not regex)
+// FindMatches finds the matches with given search string (literal, not regex -- see FindRegexpMatches)
 // and case sensitivity, updates highlights for all.  returns false if none
 // found
 func (tv *TextView) FindMatches(find string, useCase, lexItems bool) ([]textbuf.Match, bool) {
@@ -1544,10 +1864,25 @@ func (tv *TextView) FindMatches(find string, useCase, lexItems bool) ([]textbuf.
 		return nil, false
 	}
 	_, matches := tv.Buf.Search([]byte(find), !useCase, lexItems)
+	return matches, tv.SetMatchHighlights(matches)
+}
+
+// FindRegexpMatches finds the matches for given regexp, updates
+// highlights for all.  lexItems only matches entire lexically tagged
+// items, and multiLine allows matches to span lines (see
+// textbuf.SearchRegexpFor).  returns false if none found
+func (tv *TextView) FindRegexpMatches(re *regexp.Regexp, lexItems, multiLine bool) ([]textbuf.Match, bool) {
+	_, matches := tv.Buf.SearchRegexp(re, lexItems, multiLine)
+	return matches, tv.SetMatchHighlights(matches)
+}
+
+// SetMatchHighlights sets the highlights to given matches (up to
+// TextViewMaxFindHighlights) and renders -- returns false if none
+func (tv *TextView) SetMatchHighlights(matches []textbuf.Match) bool {
 	if len(matches) == 0 {
 		tv.Highlights = nil
 		tv.RenderAllLines()
-		return matches, false
+		return false
 	}
 	hi := make([]textbuf.Region, len(matches))
 	for i, m := range matches {
@@ -1558,7 +1893,7 @@ func (tv *TextView) FindMatches(find string, useCase, lexItems bool) ([]textbuf.
 	}
 	tv.Highlights = hi
 	tv.RenderAllLines()
-	return matches, true
+	return true
 }
 
 // MatchFromPos finds the match at or after the given text position -- returns 0, false if none
@@ -1760,15 +2095,19 @@ func (tv *TextView) ISearchCancel() {
 
 // QReplace holds all the query-replace data
 type QReplace struct {
-	On       bool            `json:"-" xml:"-" desc:"if true, in interactive search mode"`
-	Find     string          `json:"-" xml:"-" desc:"current interactive search string"`
-	Replace  string          `json:"-" xml:"-" desc:"current interactive search string"`
-	UseCase  bool            `json:"-" xml:"-" desc:"pay attention to case in isearch -- triggered by typing an upper-case letter"`
-	LexItems bool            `json:"-" xml:"-" desc:"search only as entire lexically-tagged item boundaries -- key for replacing short local variables like i"`
-	Matches  []textbuf.Match `json:"-" xml:"-" desc:"current search matches"`
-	Pos      int             `json:"-" xml:"-" desc:"position within isearch matches"`
-	PrevPos  int             `json:"-" xml:"-" desc:"position in search list from previous search"`
-	StartPos textbuf.Pos     `json:"-" xml:"-" desc:"starting position for search -- returns there after on cancel"`
+	On        bool            `json:"-" xml:"-" desc:"if true, in interactive search mode"`
+	Find      string          `json:"-" xml:"-" desc:"current interactive search string"`
+	Replace   string          `json:"-" xml:"-" desc:"current interactive search string"`
+	UseCase   bool            `json:"-" xml:"-" desc:"pay attention to case in isearch -- triggered by typing an upper-case letter"`
+	LexItems  bool            `json:"-" xml:"-" desc:"search only as entire lexically-tagged item boundaries -- key for replacing short local variables like i"`
+	Regexp    bool            `json:"-" xml:"-" desc:"find is a regular expression, and replace can refer to capture groups using $1, ${name} etc (use $$ for a literal $)"`
+	WholeWord bool            `json:"-" xml:"-" desc:"only match find at word boundaries"`
+	MultiLine bool            `json:"-" xml:"-" desc:"matches can span multiple lines, e.g., using \\n in a regexp, and ^ and $ match at the start and end of each line"`
+	Re        *regexp.Regexp  `json:"-" xml:"-" desc:"compiled regexp used for finding matches"`
+	Matches   []textbuf.Match `json:"-" xml:"-" desc:"current search matches"`
+	Pos       int             `json:"-" xml:"-" desc:"position within isearch matches"`
+	PrevPos   int             `json:"-" xml:"-" desc:"position in search list from previous search"`
+	StartPos  textbuf.Pos     `json:"-" xml:"-" desc:"starting position for search -- returns there after on cancel"`
 }
 
 // PrevQReplaceFinds are the previous QReplace strings
@@ -1784,6 +2123,19 @@ func (tv *TextView) QReplaceSig() {
 
 // QReplaceDialog prompts the user for a query-replace items, with comboboxes with history
 func QReplaceDialog(avp *gi.Viewport2D, find string, lexitems bool, opts gi.DlgOpts, recv ki.Ki, fun ki.RecvFunc) *gi.Dialog {
+	return qReplaceDialog(avp, find, &QReplace{LexItems: lexitems}, false, opts, recv, fun)
+}
+
+// QReplaceOptsDialog prompts the user for a query-replace items, with
+// comboboxes with history, and checkboxes for all of the search options,
+// initialized from those in given QReplace -- see QReplaceOptsDialogValues
+func QReplaceOptsDialog(avp *gi.Viewport2D, find string, qr *QReplace, opts gi.DlgOpts, recv ki.Ki, fun ki.RecvFunc) *gi.Dialog {
+	return qReplaceDialog(avp, find, qr, true, opts, recv, fun)
+}
+
+// qReplaceDialog makes the query-replace dialog, with just the Lexical
+// Items option unless allOpts is set
+func qReplaceDialog(avp *gi.Viewport2D, find string, qr *QReplace, allOpts bool, opts gi.DlgOpts, recv ki.Ki, fun ki.RecvFunc) *gi.Dialog {
 	dlg := gi.NewStdDialog(opts, gi.AddOk, gi.AddCancel)
 	dlg.Modal = true
 
@@ -1808,9 +2160,26 @@ func QReplaceDialog(avp *gi.Viewport2D, find string, lexitems bool, opts gi.DlgO
 
 	lb := frame.InsertNewChild(gi.KiT_CheckBox, prIdx+3, "lexb").(*gi.CheckBox)
 	lb.SetText("Lexical Items")
-	lb.SetChecked(lexitems)
+	lb.SetChecked(qr.LexItems)
 	lb.Tooltip = "search matches entire lexically tagged items -- good for finding local variable names like 'i' and not matching everything"
 
+	if allOpts {
+		rb := frame.InsertNewChild(gi.KiT_CheckBox, prIdx+4, "regexp").(*gi.CheckBox)
+		rb.SetText("Regexp")
+		rb.SetChecked(qr.Regexp)
+		rb.Tooltip = "find is a regular expression, and replace can refer to capture groups using $1, ${name} etc (use $$ for a literal $)"
+
+		wb := frame.InsertNewChild(gi.KiT_CheckBox, prIdx+5, "word").(*gi.CheckBox)
+		wb.SetText("Whole Word")
+		wb.SetChecked(qr.WholeWord)
+		wb.Tooltip = "only match at word boundaries"
+
+		mb := frame.InsertNewChild(gi.KiT_CheckBox, prIdx+6, "multi").(*gi.CheckBox)
+		mb.SetText("Multi-Line")
+		mb.SetChecked(qr.MultiLine)
+		mb.Tooltip = "matches can span multiple lines, e.g., using \\n in a regexp, and ^ and $ match at the start and end of each line"
+	}
+
 	if recv != nil && fun != nil {
 		dlg.DialogSig.Connect(recv, fun)
 	}
@@ -1835,6 +2204,17 @@ func QReplaceDialogValues(dlg *gi.Dialog) (find, repl string, lexItems bool) {
 	return
 }
 
+// QReplaceOptsDialogValues gets the string values from a QReplaceOptsDialog,
+// and sets the option values in given QReplace
+func QReplaceOptsDialogValues(dlg *gi.Dialog, qr *QReplace) (find, repl string) {
+	find, repl, qr.LexItems = QReplaceDialogValues(dlg)
+	frame := dlg.Frame()
+	qr.Regexp = frame.ChildByName("regexp", 4).(*gi.CheckBox).IsChecked()
+	qr.WholeWord = frame.ChildByName("word", 5).(*gi.CheckBox).IsChecked()
+	qr.MultiLine = frame.ChildByName("multi", 6).(*gi.CheckBox).IsChecked()
+	return
+}
+
 // QReplacePrompt is an emacs-style query-replace mode -- this starts the process, prompting
 // user for items to search etc
 func (tv *TextView) QReplacePrompt() {
@@ -1842,29 +2222,46 @@ func (tv *TextView) QReplacePrompt() {
 	if tv.HasSelection() {
 		find = string(tv.Selection().ToBytes())
 	}
-	QReplaceDialog(tv.Viewport, find, tv.QReplace.LexItems, gi.DlgOpts{Title: "Query-Replace", Prompt: "Enter strings for find and replace, then select Ok -- with dialog dismissed press <b>y</b> to replace current match, <b>n</b> to skip, <b>Enter</b> or <b>q</b> to quit, <b>!</b> to replace-all remaining"}, tv.This(), func(recv, send ki.Ki, sig int64, data interface{}) {
+	QReplaceOptsDialog(tv.Viewport, find, &tv.QReplace, gi.DlgOpts{Title: "Query-Replace", Prompt: "Enter strings for find and replace, then select Ok -- with dialog dismissed press <b>y</b> to replace current match, <b>n</b> to skip, <b>Enter</b> or <b>q</b> to quit, <b>!</b> to replace-all remaining"}, tv.This(), func(recv, send ki.Ki, sig int64, data interface{}) {
 		dlg := send.(*gi.Dialog)
 		if sig == int64(gi.DialogAccepted) {
-			find, repl, lexItems := QReplaceDialogValues(dlg)
-			tv.QReplaceStart(find, repl, lexItems)
+			find, repl := QReplaceOptsDialogValues(dlg, &tv.QReplace)
+			tv.QReplaceStart(find, repl, tv.QReplace.LexItems)
 		}
 	})
 }
 
-// QReplaceStart starts query-replace using given find, replace strings
+// QReplaceStart starts query-replace using given find, replace strings,
+// with the current QReplace Regexp, WholeWord and MultiLine options
 func (tv *TextView) QReplaceStart(find, repl string, lexItems bool) {
 	tv.QReplace.On = true
 	tv.QReplace.Find = find
 	tv.QReplace.Replace = repl
 	tv.QReplace.LexItems = lexItems
 	tv.QReplace.StartPos = tv.CursorPos
-	tv.QReplace.UseCase = HasUpperCase(find)
+	if tv.QReplace.Regexp {
+		tv.QReplace.UseCase = textbuf.RegexpHasUpperCase(find)
+	} else {
+		tv.QReplace.UseCase = HasUpperCase(find)
+	}
 	tv.QReplace.Matches = nil
 	tv.QReplace.Pos = -1
+	tv.QReplace.Re = nil
 
 	gi.StringsInsertFirstUnique(&PrevQReplaceFinds, find, gi.Prefs.Params.SavedPathsMax)
 	gi.StringsInsertFirstUnique(&PrevQReplaceRepls, repl, gi.Prefs.Params.SavedPathsMax)
 
+	qr := &tv.QReplace
+	if find != "" && (qr.Regexp || qr.WholeWord || qr.MultiLine) {
+		re, err := textbuf.SearchRegexpFor(find, qr.Regexp, !qr.UseCase, qr.WholeWord, qr.MultiLine)
+		if err != nil {
+			qr.On = false
+			gi.PromptDialog(tv.Viewport, gi.DlgOpts{Title: "Invalid Regexp", Prompt: err.Error()}, gi.AddOk, gi.NoCancel, nil, nil)
+			return
+		}
+		qr.Re = re
+	}
+
 	tv.QReplaceMatches()
 	tv.QReplace.Pos, _ = tv.MatchFromPos(tv.QReplace.Matches, tv.CursorPos)
 	tv.QReplaceSelectMatch(tv.QReplace.Pos)
@@ -1874,7 +2271,12 @@ func (tv *TextView) QReplaceStart(find, repl string, lexItems bool) {
 // QReplaceMatches finds QReplace matches -- returns true if there are any
 func (tv *TextView) QReplaceMatches() bool {
 	got := false
-	tv.QReplace.Matches, got = tv.FindMatches(tv.QReplace.Find, tv.QReplace.UseCase, tv.QReplace.LexItems)
+	qr := &tv.QReplace
+	if qr.Re != nil {
+		qr.Matches, got = tv.FindRegexpMatches(qr.Re, qr.LexItems, qr.MultiLine)
+	} else {
+		qr.Matches, got = tv.FindMatches(qr.Find, qr.UseCase, qr.LexItems)
+	}
 	return got
 }
 
@@ -1918,21 +2320,30 @@ func (tv *TextView) QReplaceReplace(midx int) {
 	m := tv.QReplace.Matches[midx]
 	reg := tv.Buf.AdjustReg(m.Reg)
 	pos := reg.Start
+	repl := []byte(tv.QReplace.Replace)
+	if tv.QReplace.Re != nil && tv.QReplace.Regexp {
+		repl = tv.Buf.ReplaceRegexpText(reg, tv.QReplace.Re, tv.QReplace.Replace)
+	}
 	tv.Buf.DeleteText(reg.Start, reg.End, EditSignal)
-	tv.Buf.InsertText(pos, []byte(tv.QReplace.Replace), EditSignal)
-	tv.Highlights[midx] = textbuf.RegionNil
+	tv.Buf.InsertText(pos, repl, EditSignal)
+	if midx < len(tv.Highlights) {
+		tv.Highlights[midx] = textbuf.RegionNil
+	}
 	tv.SetCursor(pos)
 	tv.SavePosHistory(tv.CursorPos)
 	tv.ScrollCursorToCenterIfHidden()
 	tv.QReplaceSig()
 }
 
-// QReplaceReplaceAll replaces all remaining from index
+// QReplaceReplaceAll replaces all remaining from index, as a single
+// group of edits that is undone together
 func (tv *TextView) QReplaceReplaceAll(midx int) {
 	nm := len(tv.QReplace.Matches)
 	if midx >= nm {
 		return
 	}
+	tv.Buf.Undos.BeginGroup()
+	defer tv.Buf.Undos.EndGroup()
 	for mi := midx; mi < nm; mi++ {
 		tv.QReplaceReplace(mi)
 	}
@@ -2460,6 +2871,17 @@ func (tv *TextView) MakeContextMenu(m *gi.Menu) {
 				txf.Paste()
 			})
 		ac.SetInactiveState(oswin.TheApp.ClipBoard(tv.Viewport.Win.OSWin).IsEmpty())
+		m.AddAction(gi.ActOpts{Label: "Undo History..."},
+			tv.This(), func(recv, send ki.Ki, sig int64, data interface{}) {
+				txf := recv.Embed(KiT_TextView).(*TextView)
+				txf.UndoBrowse()
+			})
+		if tv.HasLangServer() {
+			tv.LSPContextMenu(m)
+		}
+		if tv.HasVcsChanges() {
+			tv.VcsChangeContextMenu(m)
+		}
 	} else {
 		ac = m.AddAction(gi.ActOpts{Label: "Clear"},
 			tv.This(), func(recv, send ki.Ki, sig int64, data interface{}) {
@@ -3125,8 +3547,11 @@ func (tv *TextView) RenderDepthBg(stln, edln int) {
 	nclrs := len(TextViewDepthColors)
 	lstdp := 0
 	for ln := stln; ln <= edln; ln++ {
+		if tv.IsLineHidden(ln) {
+			continue
+		}
 		lst := tv.CharStartPos(textbuf.Pos{Ln: ln}).Y // note: charstart pos includes descent
-		led := lst + math32.Max(tv.Renders[ln].Size.Y, tv.LineHeight)
+		led := lst + tv.LineVisHeight(ln)
 		if int(math32.Ceil(led)) < tv.VpBBox.Min.Y {
 			continue
 		}
@@ -3164,6 +3589,11 @@ func (tv *TextView) RenderDepthBg(stln, edln int) {
 // RenderSelect renders the selection region as a selected background color
 // -- always called within context of outer RenderLines or RenderAllLines
 func (tv *TextView) RenderSelect() {
+	for _, c := range tv.MultiCursors {
+		if !c.Sel.IsNil() {
+			tv.RenderRegionBox(c.Sel, TextViewSel)
+		}
+	}
 	if !tv.HasSelection() {
 		return
 	}
@@ -3244,6 +3674,10 @@ func (tv *TextView) RenderRegionBox(reg textbuf.Region, state TextViewStates) {
 
 // RenderRegionBoxSty renders a region in given style and background color
 func (tv *TextView) RenderRegionBoxSty(reg textbuf.Region, sty *gi.Style, bgclr *gi.ColorSpec) {
+	reg, vis := tv.FoldClipRegion(reg)
+	if !vis {
+		return
+	}
 	st := reg.Start
 	ed := reg.End
 	spos := tv.CharStartPos(st)
@@ -3330,7 +3764,7 @@ func (tv *TextView) VisSizes() {
 		tv.VisSize.Y = int(math32.Floor(float32(sz.Y) / tv.LineHeight))
 		tv.VisSize.X = int(math32.Floor(float32(sz.X) / sty.Font.Face.Metrics.Ch))
 	}
-	tv.LineNoDigs = ints.MaxInt(1+int(math32.Log10(float32(tv.NLines))), 3)
+	tv.LineNoDigs = ints.MaxInt(1+int(math32.Log10(float32(tv.Buf.FileLine(tv.NLines)))), 3)
 	if tv.Buf != nil && tv.Buf.Opts.LineNos {
 		tv.SetFlag(int(TextViewHasLineNos))
 		tv.LineNoOff = float32(tv.LineNoDigs+3)*sty.Font.Face.Metrics.Ch + spc // space for icon
@@ -3338,6 +3772,11 @@ func (tv *TextView) VisSizes() {
 		tv.ClearFlag(int(TextViewHasLineNos))
 		tv.LineNoOff = 0
 	}
+	if tv.Buf != nil && tv.Buf.Opts.Minimap {
+		tv.MinimapOff = float32(TextViewMinimapChars) * sty.Font.Face.Metrics.Ch
+	} else {
+		tv.MinimapOff = 0
+	}
 	tv.RenderSize()
 }
 
@@ -3377,7 +3816,7 @@ func (tv *TextView) RenderAllLinesInBounds() {
 	edln := -1
 	for ln := 0; ln < tv.NLines; ln++ {
 		lst := pos.Y + tv.Offs[ln]
-		led := lst + math32.Max(tv.Renders[ln].Size.Y, tv.LineHeight)
+		led := lst + tv.LineVisHeight(ln)
 		if int(math32.Ceil(led)) < tv.VpBBox.Min.Y {
 			continue
 		}
@@ -3398,6 +3837,9 @@ func (tv *TextView) RenderAllLinesInBounds() {
 	if tv.HasLineNos() {
 		tv.RenderLineNosBoxAll()
 		for ln := stln; ln <= edln; ln++ {
+			if tv.IsLineHidden(ln) {
+				continue
+			}
 			tv.RenderLineNo(ln, false, false) // don't re-render std fill boxes, no separate vp upload
 		}
 	}
@@ -3406,24 +3848,27 @@ func (tv *TextView) RenderAllLinesInBounds() {
 	tv.RenderHighlights(stln, edln)
 	tv.RenderScopelights(stln, edln)
 	tv.RenderSelect()
-	if tv.HasLineNos() {
-		tbb := tv.VpBBox
-		tbb.Min.X += int(tv.LineNoOff)
+	if tv.HasLineNos() || tv.HasMinimap() {
 		rs.Unlock()
-		rs.PushBounds(tbb)
+		rs.PushBounds(tv.TextBBox())
 		rs.Lock()
 	}
 	for ln := stln; ln <= edln; ln++ {
+		if tv.IsLineHidden(ln) {
+			continue
+		}
 		lst := pos.Y + tv.Offs[ln]
 		lp := pos
 		lp.Y = lst
 		lp.X += tv.LineNoOff
 		tv.Renders[ln].Render(rs, lp) // not top pos -- already has baseline offset
 	}
+	tv.RenderMultiCursors()
 	rs.Unlock()
-	if tv.HasLineNos() {
+	if tv.HasLineNos() || tv.HasMinimap() {
 		rs.PopBounds()
 	}
+	tv.RenderMinimap(false)
 }
 
 // RenderLineNosBoxAll renders the background for the line numbers in a darker shade
@@ -3508,7 +3953,7 @@ func (tv *TextView) RenderLineNo(ln int, defFill bool, vpUpload bool) {
 	fst.BgColor.SetColor(nil)
 	lfmt := fmt.Sprintf("%d", tv.LineNoDigs)
 	lfmt = "%" + lfmt + "d"
-	lnstr := fmt.Sprintf(lfmt, ln+1)
+	lnstr := fmt.Sprintf(lfmt, tv.Buf.FileLine(ln)+1)
 	tv.LineNoRender.SetString(lnstr, &fst, &sty.UnContext, &sty.Text, true, 0, 0)
 	pos := mat32.Vec2{}
 	lst := tv.CharStartPos(textbuf.Pos{Ln: ln}).Y // note: charstart pos includes descent
@@ -3516,6 +3961,8 @@ func (tv *TextView) RenderLineNo(ln int, defFill bool, vpUpload bool) {
 	pos.X = float32(tv.VpBBox.Min.X) + spc
 
 	tv.LineNoRender.Render(rs, pos)
+	tv.RenderFoldMarker(ln)
+	tv.RenderVcsChangeMarker(ln)
 	// todo: need an SvgRender interface that just takes an svg file or object
 	// and renders it to a given bitmap, and then just keep that around.
 	// if icnm, ok := tv.Buf.LineIcons[ln]; ok {
@@ -3543,6 +3990,338 @@ func (tv *TextView) RenderLineNo(ln int, defFill bool, vpUpload bool) {
 	}
 }
 
+// RenderFoldMarker renders the fold marker for given line in the line number
+// gutter, if it starts a foldable region: a right-pointing triangle if it is
+// folded, and a down-pointing one otherwise.  Clicking on the marker, or
+// KeyFunFold, toggles the fold.
+func (tv *TextView) RenderFoldMarker(ln int) {
+	folded := tv.FoldIdx(ln) >= 0
+	if !folded {
+		if _, has := tv.Foldables()[ln]; !has {
+			return
+		}
+	}
+	rs := &tv.Viewport.Render
+	pc := &rs.Paint
+	sty := &tv.Sty
+	spc := sty.BoxSpace()
+	ch := sty.Font.Face.Metrics.Ch
+	sz := 0.8 * ch
+	x := float32(tv.VpBBox.Min.X) + spc + float32(tv.LineNoDigs)*ch + 0.6*ch
+	y := tv.CharStartPos(textbuf.Pos{Ln: ln}).Y + 0.5*tv.LineHeight
+	var pts []mat32.Vec2
+	if folded {
+		pts = []mat32.Vec2{{x, y - 0.5*sz}, {x + 0.8*sz, y}, {x, y + 0.5*sz}}
+	} else {
+		pts = []mat32.Vec2{{x, y - 0.4*sz}, {x + sz, y - 0.4*sz}, {x + 0.5*sz, y + 0.4*sz}}
+	}
+	clr := sty.Font.Color.Highlight(40)
+	pc.StrokeStyle.SetColor(nil)
+	pc.FillStyle.SetColor(&clr)
+	pc.DrawPolygon(rs, pts)
+	pc.FillStrokeClear(rs)
+}
+
+///////////////////////////////////////////////////////////////////////////////
+//    Minimap
+
+// TextViewMinimapChars is the width of the minimap, in characters of the
+// TextView font -- it is shown when the Minimap editor option is on
+var TextViewMinimapChars = 12
+
+// TextViewMinimapCols is the number of text columns represented across the
+// width of the minimap -- anything beyond that is not shown
+var TextViewMinimapCols = 120
+
+// TextViewMinimapLineHeight is the maximum height of each line in the
+// minimap, in dots -- lines are scaled down further as needed to fit
+// the entire document
+var TextViewMinimapLineHeight = float32(3)
+
+// TextViewMinimapSpellColor is the color of spelling error markers in the minimap
+var TextViewMinimapSpellColor = gi.Color{R: 255, A: 255}
+
+// TextViewMinimapDiffColors are the colors of the diff markers in the
+// minimap, for each type of diff (replace, delete, insert) -- the same
+// as those used in DiffView
+var TextViewMinimapDiffColors = map[byte]gi.Color{
+	'r': {B: 255, A: 255},
+	'd': {R: 255, A: 255},
+	'i': {G: 128, A: 255},
+}
+
+// HasMinimap returns true if view is showing a minimap
+func (tv *TextView) HasMinimap() bool {
+	return tv.MinimapOff > 0
+}
+
+// TextBBox returns the bounding box of the visible text, excluding the line
+// numbers and minimap, in viewport coordinates
+func (tv *TextView) TextBBox() image.Rectangle {
+	tbb := tv.VpBBox
+	tbb.Min.X += int(tv.LineNoOff)
+	tbb.Max.X -= int(tv.MinimapOff)
+	return tbb
+}
+
+// MinimapBBox returns the bounding box of the minimap, at the right edge of
+// the visible area of the view, in viewport coordinates
+func (tv *TextView) MinimapBBox() image.Rectangle {
+	mbb := tv.VpBBox
+	mbb.Min.X = mbb.Max.X - int(tv.MinimapOff)
+	return mbb
+}
+
+// InMinimap returns true if given point, relative to the visible area of
+// the view (as from PointToRelPos), is within the minimap
+func (tv *TextView) InMinimap(pt image.Point) bool {
+	return tv.HasMinimap() && pt.X >= tv.VpBBox.Dx()-int(tv.MinimapOff)
+}
+
+// MinimapScale returns the factor that scales vertical positions in the
+// document (as in Offs) to positions in the minimap -- 0 if no minimap
+func (tv *TextView) MinimapScale() float32 {
+	if !tv.HasMinimap() || tv.NLines == 0 || len(tv.Offs) < tv.NLines || tv.LineHeight == 0 {
+		return 0
+	}
+	dht := tv.Offs[tv.NLines-1] + tv.LineVisHeight(tv.NLines-1)
+	sc := TextViewMinimapLineHeight / tv.LineHeight
+	if dht > 0 {
+		sc = mat32.Min(sc, float32(tv.MinimapBBox().Dy())/dht)
+	}
+	return sc
+}
+
+// MinimapScrollTo scrolls the view to center on the part of the document at
+// given vertical position within the minimap, relative to the top of the
+// visible area, as from a mouse click or drag in the minimap
+func (tv *TextView) MinimapScrollTo(y int) bool {
+	sc := tv.MinimapScale()
+	if sc == 0 {
+		return false
+	}
+	pos := tv.RenderStartPos().Y + float32(y)/sc
+	return tv.ScrollToVertCenter(int(pos))
+}
+
+// SetMinimapDiffs sets the diffs shown as change markers in the minimap, from
+// the buffer of this view (a) to another buffer (b), e.g., as returned by
+// TextBuf.DiffBufs (DiffView sets them for the aligned diffs of its two
+// views) -- these are not updated for subsequent edits, so they
+// should be set again as needed -- nil to clear
+func (tv *TextView) SetMinimapDiffs(diffs textbuf.Diffs) {
+	tv.MinimapDiffs = diffs
+	tv.SetMinimapDirty()
+	if tv.HasMinimap() {
+		tv.RenderAllLines()
+	}
+}
+
+// RenderMinimap renders the minimap overview of the document at the right
+// edge of the view: a scaled-down rendering of the text, with the visible
+// region shaded, and markers at the right for diffs, line colors, line
+// icons, spelling errors, and search matches.  The text and the markers
+// from the buffer are rendered into an image that is cached until the
+// buffer or the layout change (see MinimapImage), so only the visible
+// region and the highlights are rendered each time.  vpUpload uploads the
+// minimap region to the window, for use outside of a full render.
+func (tv *TextView) RenderMinimap(vpUpload bool) {
+	sc := tv.MinimapScale()
+	if sc == 0 || tv.Buf == nil {
+		return
+	}
+	mbb := tv.MinimapBBox()
+	if mbb.Empty() {
+		return
+	}
+	img := tv.MinimapImage(mbb.Size(), sc)
+	vp := tv.Viewport
+	rs := &vp.Render
+	pc := &rs.Paint
+	sty := &tv.Sty
+	rs.Lock()
+	pos := mat32.NewVec2FmPoint(mbb.Min)
+	msz := mat32.NewVec2FmPoint(mbb.Size())
+	pc.FillBoxColor(rs, pos, msz, sty.Font.BgColor.Color.Highlight(5))
+
+	// visible region
+	rst := tv.RenderStartPos()
+	vst := (float32(tv.VpBBox.Min.Y) - rst.Y) * sc
+	ved := (float32(tv.VpBBox.Max.Y) - rst.Y) * sc
+	pc.FillBoxColor(rs, mat32.Vec2{pos.X, pos.Y + vst}, mat32.Vec2{msz.X, mat32.Max(ved-vst, 2)}, sty.Font.BgColor.Color.Highlight(20))
+
+	draw.Draw(rs.Image, mbb, img, image.ZP, draw.Over)
+	for _, reg := range tv.Highlights {
+		tv.minimapMarker(rs, pos, msz, sc, reg.Start.Ln, reg.Start.Ln, gi.Prefs.Colors.Highlight)
+	}
+	rs.Unlock()
+	if vpUpload {
+		vprel := mbb.Min.Sub(tv.VpBBox.Min)
+		tWinBBox := tv.WinBBox.Add(vprel)
+		vp.This().(gi.Viewport).VpUploadRegion(mbb, tWinBBox)
+	}
+}
+
+// SetMinimapDirty marks the cached image of the minimap as needing to be
+// rendered again, e.g., after the buffer has changed -- see MinimapImage.
+// Can be called from any goroutine.
+func (tv *TextView) SetMinimapDirty() {
+	tv.SetFlag(int(TextViewMinimapDirty))
+}
+
+// minimapMarker renders a marker for given range of lines (inclusive) at the
+// right of the minimap at given position and size, at given scale
+func (tv *TextView) minimapMarker(rs *gi.RenderState, pos, msz mat32.Vec2, sc float32, st, ed int, clr gi.Color) {
+	if st < 0 || st >= tv.NLines || st >= len(tv.Offs) {
+		return
+	}
+	ed = ints.MinInt(ed, ints.MinInt(tv.NLines, len(tv.Offs))-1)
+	mkw := mat32.Max(3, 0.15*msz.X) // marker width
+	y := pos.Y + tv.Offs[st]*sc
+	ht := mat32.Max(2, (tv.Offs[ed]+tv.LineVisHeight(ed))*sc+pos.Y-y)
+	rs.Paint.FillBoxColor(rs, mat32.Vec2{pos.X + msz.X - mkw, y}, mat32.Vec2{mkw, ht}, clr)
+}
+
+// MinimapImage returns the image of the text of the minimap, of given size
+// at given scale, with the markers for the buffer (diffs, line colors, line
+// icons and spelling errors), on a transparent background -- it is only
+// rendered again when marked by SetMinimapDirty (e.g., for changes in the
+// buffer, layout or folds), or when the size or scale changes.
+func (tv *TextView) MinimapImage(sz image.Point, sc float32) *image.RGBA {
+	dirty := tv.HasFlag(int(TextViewMinimapDirty))
+	if !dirty && tv.minimapImg != nil && tv.minimapImg.Bounds().Size() == sz && tv.minimapScale == sc {
+		return tv.minimapImg
+	}
+	tv.ClearFlag(int(TextViewMinimapDirty))
+	if tv.minimapImg == nil || tv.minimapImg.Bounds().Size() != sz {
+		tv.minimapImg = image.NewRGBA(image.Rectangle{Max: sz})
+		tv.minimapRS.Init(sz.X, sz.Y, tv.minimapImg)
+		tv.minimapRS.Bounds = tv.minimapImg.Bounds()
+	} else {
+		draw.Draw(tv.minimapImg, tv.minimapImg.Bounds(), image.Transparent, image.ZP, draw.Src)
+	}
+	tv.minimapScale = sc
+	rs := &tv.minimapRS
+	pc := &rs.Paint
+	sty := &tv.Sty
+	msz := mat32.NewVec2FmPoint(sz)
+	mkw := mat32.Max(3, 0.15*msz.X) // marker width
+	txw := msz.X - mkw
+	cw := txw / float32(TextViewMinimapCols)
+	lht := mat32.Max(1, 0.7*tv.LineHeight*sc)
+	tabSz := sty.Text.TabSize
+	if tabSz <= 0 {
+		tabSz = 4
+	}
+	hs := tv.Buf.Hi.HiStyle
+	clrs := make(map[token.Tokens]gi.Color)
+	tokClr := func(tags lex.Line, ch int) gi.Color {
+		if hs == nil {
+			return sty.Font.Color
+		}
+		for i := len(tags) - 1; i >= 0; i-- { // innermost last
+			t := &tags[i]
+			if ch < t.St || ch >= t.Ed {
+				continue
+			}
+			clr, has := clrs[t.Tok.Tok]
+			if !has {
+				clr = hs.Tag(t.Tok.Tok).Color
+				clrs[t.Tok.Tok] = clr
+			}
+			if !clr.IsNil() {
+				return clr
+			}
+		}
+		return sty.Font.Color
+	}
+
+	tv.Buf.LinesMu.RLock()
+	tv.Buf.MarkupMu.RLock()
+	lasty := -1
+	for ln := 0; ln < tv.NLines && ln < len(tv.Buf.Lines) && ln < len(tv.Offs); ln++ {
+		if tv.IsLineHidden(ln) {
+			continue
+		}
+		y := tv.Offs[ln] * sc
+		if int(y) == lasty { // only the first line at each dot
+			continue
+		}
+		lasty = int(y)
+		if lasty >= sz.Y {
+			break
+		}
+		var tags lex.Line
+		if ln < len(tv.Buf.HiTags) {
+			tags = tv.Buf.HiTags[ln]
+		}
+		col := 0
+		rcol := -1 // start col of current run
+		var rclr gi.Color
+		txt := tv.Buf.Lines[ln]
+		for ch := 0; ch <= len(txt) && col < TextViewMinimapCols; ch++ {
+			ws := ch == len(txt) || unicode.IsSpace(txt[ch])
+			if rcol >= 0 && ws {
+				pc.FillBoxColor(rs, mat32.Vec2{float32(rcol) * cw, y}, mat32.Vec2{float32(col-rcol) * cw, lht}, rclr)
+				rcol = -1
+			}
+			if ch == len(txt) {
+				break
+			}
+			if !ws {
+				clr := tokClr(tags, ch)
+				if rcol >= 0 && clr != rclr {
+					pc.FillBoxColor(rs, mat32.Vec2{float32(rcol) * cw, y}, mat32.Vec2{float32(col-rcol) * cw, lht}, rclr)
+					rcol = -1
+				}
+				if rcol < 0 {
+					rcol = col
+					rclr = clr
+				}
+			}
+			if txt[ch] == '\t' {
+				col = ((col / tabSz) + 1) * tabSz
+			} else {
+				col++
+			}
+		}
+	}
+	tv.Buf.MarkupMu.RUnlock()
+
+	// markers
+	marker := func(st, ed int, clr gi.Color) {
+		tv.minimapMarker(rs, mat32.Vec2{}, msz, sc, st, ed, clr)
+	}
+	for _, df := range tv.MinimapDiffs {
+		if clr, has := TextViewMinimapDiffColors[df.Tag]; has {
+			marker(df.I1, df.I2-1, clr)
+		}
+	}
+	if len(tv.MinimapDiffs) == 0 && tv.HasVcsChanges() {
+		for _, df := range tv.Buf.VcsChanges() {
+			if clr, has := TextViewMinimapDiffColors[df.Tag]; has {
+				marker(df.J1, ints.MaxInt(df.J1, df.J2-1), clr)
+			}
+		}
+	}
+	for ln, clr := range tv.Buf.LineColors {
+		marker(ln, ln, clr)
+	}
+	for ln := range tv.Buf.LineIcons {
+		marker(ln, ln, gi.Prefs.Colors.Icon)
+	}
+	for ln, tags := range tv.Buf.Tags {
+		for _, t := range tags {
+			if t.Tok.Tok == token.TextSpellErr {
+				marker(ln, ln, TextViewMinimapSpellColor)
+				break
+			}
+		}
+	}
+	tv.Buf.LinesMu.RUnlock()
+	return tv.minimapImg
+}
+
 // RenderScrolls renders scrollbars if needed
 func (tv *TextView) RenderScrolls() {
 	if tv.HasFlag(int(TextViewRenderScrolls)) {
@@ -3585,7 +4364,7 @@ func (tv *TextView) RenderLines(st, ed int) bool {
 	visEd := -1
 	for ln := st; ln <= ed; ln++ {
 		lst := tv.CharStartPos(textbuf.Pos{Ln: ln}).Y // note: charstart pos includes descent
-		led := lst + math32.Max(tv.Renders[ln].Size.Y, tv.LineHeight)
+		led := lst + tv.LineVisHeight(ln)
 		if int(math32.Ceil(led)) < tv.VpBBox.Min.Y {
 			continue
 		}
@@ -3617,25 +4396,33 @@ func (tv *TextView) RenderLines(st, ed int) bool {
 
 		if tv.HasLineNos() {
 			for ln := visSt; ln <= visEd; ln++ {
+				if tv.IsLineHidden(ln) {
+					continue
+				}
 				tv.RenderLineNo(ln, true, false)
 			}
-			tbb := tv.VpBBox
-			tbb.Min.X += int(tv.LineNoOff)
+		}
+		if tv.HasLineNos() || tv.HasMinimap() {
 			rs.Unlock()
-			rs.PushBounds(tbb)
+			rs.PushBounds(tv.TextBBox())
 			rs.Lock()
 		}
 		for ln := visSt; ln <= visEd; ln++ {
+			if tv.IsLineHidden(ln) {
+				continue
+			}
 			lst := pos.Y + tv.Offs[ln]
 			lp := pos
 			lp.Y = lst
 			lp.X += tv.LineNoOff
 			tv.Renders[ln].Render(rs, lp) // not top pos -- already has baseline offset
 		}
+		tv.RenderMultiCursors()
 		rs.Unlock()
-		if tv.HasLineNos() {
+		if tv.HasLineNos() || tv.HasMinimap() {
 			rs.PopBounds()
 		}
+		tv.RenderMinimap(true)
 
 		tBBox := image.Rectangle{boxMin.ToPointFloor(), boxMax.ToPointCeil()}
 		vprel := tBBox.Min.Sub(tv.VpBBox.Min)
@@ -3717,7 +4504,7 @@ func (tv *TextView) PixelToCursor(pt image.Point) textbuf.Pos {
 		for ln := stln; ln < tv.NLines; ln++ {
 			ls := tv.CharStartPos(textbuf.Pos{Ln: ln}).Y - yoff
 			es := ls
-			es += math32.Max(tv.Renders[ln].Size.Y, tv.LineHeight)
+			es += tv.LineVisHeight(ln)
 			if pt.Y >= int(math32.Floor(ls)) && pt.Y < int(math32.Ceil(es)) {
 				got = true
 				cln = ln
@@ -3867,6 +4654,494 @@ func (tv *TextView) SetCursorFromMouse(pt image.Point, newPos textbuf.Pos, selMo
 	tv.RenderCursor(true)
 }
 
+///////////////////////////////////////////////////////////////////////////////
+//    Multiple Cursors
+
+// TextCursor is an additional cursor for multi-cursor editing in the
+// TextView, with an optional selected region that edits apply to
+type TextCursor struct {
+	Pos textbuf.Pos    `desc:"cursor position"`
+	Sel textbuf.Region `desc:"selected region -- empty if no selection"`
+}
+
+// StartPos returns the start of the selection if there is one, else the cursor position
+func (tc *TextCursor) StartPos() textbuf.Pos {
+	if !tc.Sel.IsNil() {
+		return tc.Sel.Start
+	}
+	return tc.Pos
+}
+
+// HasMultiCursors returns true if there are additional cursors beyond the main CursorPos
+func (tv *TextView) HasMultiCursors() bool {
+	return len(tv.MultiCursors) > 0
+}
+
+// AllCursors returns the main cursor, with the current selection, followed
+// by the additional MultiCursors
+func (tv *TextView) AllCursors() []TextCursor {
+	cs := make([]TextCursor, 0, len(tv.MultiCursors)+1)
+	mc := TextCursor{Pos: tv.CursorPos}
+	if tv.HasSelection() {
+		mc.Sel = tv.SelectReg
+	}
+	cs = append(cs, mc)
+	return append(cs, tv.MultiCursors...)
+}
+
+// SetAllCursors sets the main cursor and selection from the first element
+// of given list, and the MultiCursors from the rest, removing any duplicates,
+// and re-renders
+func (tv *TextView) SetAllCursors(cs []TextCursor) {
+	if len(cs) == 0 {
+		return
+	}
+	wupdt := tv.TopUpdateStart()
+	defer tv.TopUpdateEnd(wupdt)
+	mc := cs[0]
+	tv.MultiCursors = tv.MultiCursors[:0]
+	for _, c := range cs[1:] {
+		dup := c.Pos == mc.Pos
+		for _, oc := range tv.MultiCursors {
+			if oc.Pos == c.Pos {
+				dup = true
+				break
+			}
+		}
+		if !dup {
+			tv.MultiCursors = append(tv.MultiCursors, c)
+		}
+	}
+	if mc.Sel.IsNil() {
+		tv.SelectReg = textbuf.RegionNil
+	} else {
+		tv.SelectReg = mc.Sel
+	}
+	tv.SetCursorShow(mc.Pos)
+	tv.SetCursorCol(tv.CursorPos)
+	tv.RenderAllLines()
+}
+
+// ClearMultiCursors removes all of the additional cursors
+func (tv *TextView) ClearMultiCursors() {
+	if !tv.HasMultiCursors() {
+		return
+	}
+	tv.MultiCursors = nil
+	tv.RenderAllLines()
+}
+
+// MultiCursorAddLines adds cursors for multi-cursor editing: if the selection
+// spans multiple lines, a cursor is placed at the end of each line in it,
+// and otherwise a cursor is added on the line below the lowest cursor, at
+// the column of the main cursor.
+func (tv *TextView) MultiCursorAddLines() {
+	if tv.HasSelection() && !tv.SelectReg.IsSameLine() {
+		st := tv.SelectReg.Start.Ln
+		ed := tv.SelectReg.End.Ln
+		if tv.SelectReg.End.Ch == 0 {
+			ed--
+		}
+		var cs []TextCursor
+		for ln := ed; ln >= st; ln-- { // main cursor is on the last line
+			if tv.IsLineHidden(ln) {
+				continue
+			}
+			cs = append(cs, TextCursor{Pos: textbuf.Pos{Ln: ln, Ch: tv.Buf.LineLen(ln)}})
+		}
+		tv.SetAllCursors(cs)
+		return
+	}
+	cs := tv.AllCursors()
+	mxln := 0
+	for _, c := range cs {
+		mxln = ints.MaxInt(mxln, c.Pos.Ln)
+	}
+	nln := tv.FoldNextVisLine(mxln + 1)
+	if nln < 0 {
+		return
+	}
+	cs = append(cs, TextCursor{Pos: textbuf.Pos{Ln: nln, Ch: ints.MinInt(tv.CursorCol, tv.Buf.LineLen(nln))}})
+	tv.SetAllCursors(cs)
+}
+
+// MultiCursorAddNext adds a cursor selecting the next occurrence of the
+// (single-line) selected text after the main cursor, wrapping around at the
+// end of the buffer -- the new cursor becomes the main cursor.  If there is
+// no selection, the word at the cursor is selected first.
+func (tv *TextView) MultiCursorAddNext() {
+	if !tv.HasSelection() {
+		if tv.SelectWord() {
+			tv.SetCursorShow(tv.SelectReg.End)
+			tv.RenderAllLines()
+		}
+		return
+	}
+	if !tv.SelectReg.IsSameLine() {
+		return
+	}
+	find := tv.Selection().ToBytes()
+	_, matches := tv.Buf.Search(find, false, false)
+	cs := tv.AllCursors()
+	isSel := func(reg textbuf.Region) bool {
+		for _, c := range cs {
+			if c.Sel.Start == reg.Start {
+				return true
+			}
+		}
+		return false
+	}
+	mi := -1
+	for i := range matches {
+		if !matches[i].Reg.Start.IsLess(cs[0].Sel.End) && !isSel(matches[i].Reg) {
+			mi = i
+			break
+		}
+	}
+	if mi < 0 { // wrap around
+		for i := range matches {
+			if !isSel(matches[i].Reg) {
+				mi = i
+				break
+			}
+		}
+	}
+	if mi < 0 {
+		return
+	}
+	reg := matches[mi].Reg
+	tv.SetAllCursors(append([]TextCursor{{Pos: reg.End, Sel: reg}}, cs...))
+}
+
+// MultiCursorBlockSelect sets a rectangular (column) selection between given
+// positions, as a cursor with a selection on each line, where the main cursor
+// is on the line of the ed position.  Columns are character positions,
+// limited to the length of each line.
+func (tv *TextView) MultiCursorBlockSelect(st, ed textbuf.Pos) {
+	lc := ints.MinInt(st.Ch, ed.Ch)
+	rc := ints.MaxInt(st.Ch, ed.Ch)
+	stln := ints.MinInt(st.Ln, ed.Ln)
+	edln := ints.MaxInt(st.Ln, ed.Ln)
+	var mc *TextCursor
+	others := make([]TextCursor, 0, edln-stln+1)
+	for ln := stln; ln <= edln; ln++ {
+		if tv.IsLineHidden(ln) {
+			continue
+		}
+		lln := tv.Buf.LineLen(ln)
+		sc := ints.MinInt(lc, lln)
+		ec := ints.MinInt(rc, lln)
+		tc := TextCursor{Pos: textbuf.Pos{Ln: ln, Ch: ec}}
+		if ed.Ch < st.Ch {
+			tc.Pos.Ch = sc
+		}
+		if ec > sc {
+			tc.Sel = textbuf.NewRegion(ln, sc, ln, ec)
+		}
+		if ln == ed.Ln {
+			mc = &tc
+		} else {
+			others = append(others, tc)
+		}
+	}
+	var cs []TextCursor
+	if mc != nil {
+		cs = append(cs, *mc)
+	}
+	tv.SetAllCursors(append(cs, others...))
+}
+
+// MultiCursorEdit applies given edit function to each of the cursors, in
+// order from the end of the buffer backward, as one undo group.  The function
+// is passed the index of the cursor in document order, and makes the edits
+// for that cursor using TextBuf InsertText / DeleteText, updating its
+// position and selection -- it returns the edits, which are used to adjust
+// the positions of the other cursors.
+func (tv *TextView) MultiCursorEdit(fun func(idx int, tc *TextCursor) []*textbuf.Edit) {
+	wupdt := tv.TopUpdateStart()
+	defer tv.TopUpdateEnd(wupdt)
+	cs := tv.AllCursors()
+	ord := make([]int, len(cs))
+	for i := range ord {
+		ord[i] = i
+	}
+	sort.Slice(ord, func(i, j int) bool {
+		si := cs[ord[i]].StartPos()
+		sj := cs[ord[j]].StartPos()
+		return sj.IsLess(si)
+	})
+	func() {
+		bufUpdt, winUpdt, autoSave := tv.Buf.BatchUpdateStart()
+		defer tv.Buf.BatchUpdateEnd(bufUpdt, winUpdt, autoSave)
+		tv.Buf.Undos.BeginGroup()
+		defer tv.Buf.Undos.EndGroup()
+		for k, ci := range ord {
+			tbes := fun(len(ord)-1-k, &cs[ci])
+			for oi := range cs {
+				if oi != ci {
+					cs[oi].Pos, cs[oi].Sel = textbuf.AdjustCursor(cs[oi].Pos, cs[oi].Sel, tbes)
+				}
+			}
+		}
+	}()
+	tv.SetAllCursors(cs)
+}
+
+// multiCursorDeleteSel deletes the selection of given cursor, if any,
+// moving the cursor to its start
+func (tv *TextView) multiCursorDeleteSel(tc *TextCursor) *textbuf.Edit {
+	if tc.Sel.IsNil() {
+		return nil
+	}
+	tbe := tv.Buf.DeleteText(tc.Sel.Start, tc.Sel.End, EditSignal)
+	tc.Pos = tc.Sel.Start
+	tc.Sel = textbuf.RegionNil
+	return tbe
+}
+
+// MultiCursorInsert inserts given text at each of the cursors, replacing
+// any selected text
+func (tv *TextView) MultiCursorInsert(txt []byte) {
+	tv.MultiCursorEdit(func(idx int, tc *TextCursor) []*textbuf.Edit {
+		dtbe := tv.multiCursorDeleteSel(tc)
+		tbe := tv.Buf.InsertText(tc.Pos, txt, EditSignal)
+		if tbe != nil {
+			tc.Pos = tbe.Reg.End
+		}
+		return []*textbuf.Edit{dtbe, tbe}
+	})
+}
+
+// MultiCursorDelete deletes the selected text at each of the cursors, or
+// if none, the character after (forward) or before each cursor
+func (tv *TextView) MultiCursorDelete(forward bool) {
+	tv.MultiCursorEdit(func(idx int, tc *TextCursor) []*textbuf.Edit {
+		if !tc.Sel.IsNil() {
+			return []*textbuf.Edit{tv.multiCursorDeleteSel(tc)}
+		}
+		st := tc.Pos
+		ed := tc.Pos
+		if forward {
+			if ed.Ch < tv.Buf.LineLen(ed.Ln) {
+				ed.Ch++
+			} else if ed.Ln < tv.NLines-1 {
+				ed = textbuf.Pos{Ln: ed.Ln + 1}
+			} else {
+				return nil
+			}
+		} else {
+			if st.Ch > 0 {
+				st.Ch--
+			} else if st.Ln > 0 {
+				st.Ln--
+				st.Ch = tv.Buf.LineLen(st.Ln)
+			} else {
+				return nil
+			}
+		}
+		tc.Pos = st
+		return []*textbuf.Edit{tv.Buf.DeleteText(st, ed, EditSignal)}
+	})
+}
+
+// MultiCursorCopy copies the selected text of all the cursors to the
+// clipboard, in document order with each on a separate line, including an
+// empty line for each cursor without a selection, so MultiCursorPaste gives
+// each cursor its own text -- nothing is copied if no cursor has a
+// selection.
+func (tv *TextView) MultiCursorCopy() []byte {
+	cs := tv.AllCursors()
+	sort.Slice(cs, func(i, j int) bool {
+		si := cs[i].StartPos()
+		return si.IsLess(cs[j].StartPos())
+	})
+	hasSel := false
+	var cb []byte
+	for i, c := range cs {
+		if i > 0 {
+			cb = append(cb, '\n')
+		}
+		if c.Sel.IsNil() {
+			continue
+		}
+		hasSel = true
+		cb = append(cb, tv.Buf.Region(c.Sel.Start, c.Sel.End).ToBytes()...)
+	}
+	if !hasSel {
+		return nil
+	}
+	TextViewClipHistAdd(cb)
+	oswin.TheApp.ClipBoard(tv.Viewport.Win.OSWin).Write(mimedata.NewTextBytes(cb))
+	return cb
+}
+
+// MultiCursorCut copies the selected text of all the cursors to the
+// clipboard, and then deletes it
+func (tv *TextView) MultiCursorCut() {
+	if tv.MultiCursorCopy() == nil {
+		return
+	}
+	tv.MultiCursorEdit(func(idx int, tc *TextCursor) []*textbuf.Edit {
+		return []*textbuf.Edit{tv.multiCursorDeleteSel(tc)}
+	})
+}
+
+// MultiCursorPaste pastes the clipboard at each of the cursors -- if the
+// clipboard has as many lines as there are cursors (as copied by
+// MultiCursorCopy from the same cursors), each cursor gets one line, in
+// document order, and otherwise all of the text is pasted at each.
+func (tv *TextView) MultiCursorPaste() {
+	data := oswin.TheApp.ClipBoard(tv.Viewport.Win.OSWin).Read([]string{filecat.TextPlain})
+	if data == nil {
+		return
+	}
+	txt := data.TypeData(filecat.TextPlain)
+	lns := bytes.Split(txt, []byte("\n"))
+	if len(lns) != len(tv.AllCursors()) {
+		tv.MultiCursorInsert(txt)
+		return
+	}
+	tv.MultiCursorEdit(func(idx int, tc *TextCursor) []*textbuf.Edit {
+		dtbe := tv.multiCursorDeleteSel(tc)
+		tbe := tv.Buf.InsertText(tc.Pos, lns[idx], EditSignal)
+		if tbe != nil {
+			tc.Pos = tbe.Reg.End
+		}
+		return []*textbuf.Edit{dtbe, tbe}
+	})
+}
+
+// MultiCursorMove moves all of the cursors according to given key function,
+// which must be one of the arrow keys, Home or End, extending their selections
+// if shift is true -- returns false if the key function is not a move.
+func (tv *TextView) MultiCursorMove(kf gi.KeyFuns, shift bool) bool {
+	cs := tv.AllCursors()
+	for i := range cs {
+		tc := &cs[i]
+		pos := tc.Pos
+		anchor := pos
+		hasSel := !tc.Sel.IsNil()
+		if hasSel {
+			anchor = tc.Sel.Start
+			if pos == tc.Sel.Start {
+				anchor = tc.Sel.End
+			}
+		}
+		switch kf {
+		case gi.KeyFunMoveRight:
+			if hasSel && !shift {
+				pos = tc.Sel.End
+			} else if pos.Ch < tv.Buf.LineLen(pos.Ln) {
+				pos.Ch++
+			} else if nln := tv.FoldNextVisLine(pos.Ln + 1); nln >= 0 {
+				pos = textbuf.Pos{Ln: nln}
+			}
+		case gi.KeyFunMoveLeft:
+			if hasSel && !shift {
+				pos = tc.Sel.Start
+			} else if pos.Ch > 0 {
+				pos.Ch--
+			} else if pos.Ln > 0 {
+				pos.Ln = tv.FoldHeaderLine(pos.Ln - 1)
+				pos.Ch = tv.Buf.LineLen(pos.Ln)
+			}
+		case gi.KeyFunMoveUp:
+			if pos.Ln > 0 {
+				pos.Ln = tv.FoldHeaderLine(pos.Ln - 1)
+				pos.Ch = ints.MinInt(pos.Ch, tv.Buf.LineLen(pos.Ln))
+			}
+		case gi.KeyFunMoveDown:
+			if nln := tv.FoldNextVisLine(pos.Ln + 1); nln >= 0 {
+				pos.Ln = nln
+				pos.Ch = ints.MinInt(pos.Ch, tv.Buf.LineLen(pos.Ln))
+			}
+		case gi.KeyFunHome:
+			pos.Ch = 0
+		case gi.KeyFunEnd:
+			pos.Ch = tv.Buf.LineLen(pos.Ln)
+		default:
+			return false
+		}
+		tc.Pos = pos
+		tc.Sel = textbuf.RegionNil
+		if shift {
+			if pos.IsLess(anchor) {
+				tc.Sel = textbuf.NewRegionPos(pos, anchor)
+			} else {
+				tc.Sel = textbuf.NewRegionPos(anchor, pos)
+			}
+		}
+	}
+	tv.SetAllCursors(cs)
+	return true
+}
+
+// MultiCursorKeyInput handles key input when there are multiple cursors,
+// returning true if the key was handled.  Otherwise, unless it is a plain
+// modifier or other non-printing key, the additional cursors are removed,
+// and the key is processed normally for the main cursor.
+func (tv *TextView) MultiCursorKeyInput(kt *key.ChordEvent, kf gi.KeyFuns) bool {
+	ctrl := kt.HasAnyModifier(key.Control, key.Meta)
+	switch kf {
+	case gi.KeyFunMoveRight, gi.KeyFunMoveLeft, gi.KeyFunMoveUp, gi.KeyFunMoveDown, gi.KeyFunHome, gi.KeyFunEnd:
+		tv.MultiCursorMove(kf, kt.HasAnyModifier(key.Shift))
+	case gi.KeyFunMultiCursorLines, gi.KeyFunMultiCursorNext:
+		return false
+	case gi.KeyFunCopy:
+		tv.MultiCursorCopy()
+	case gi.KeyFunNil:
+		if !unicode.IsPrint(kt.Rune) || ctrl {
+			return false
+		}
+		if tv.IsInactive() {
+			return true
+		}
+		tv.MultiCursorInsert([]byte(string(kt.Rune)))
+	default:
+		if tv.IsInactive() || ctrl && (kf == gi.KeyFunEnter || kf == gi.KeyFunFocusNext) {
+			tv.ClearMultiCursors()
+			return false
+		}
+		switch kf {
+		case gi.KeyFunBackspace:
+			tv.MultiCursorDelete(false)
+		case gi.KeyFunDelete:
+			tv.MultiCursorDelete(true)
+		case gi.KeyFunCut:
+			tv.MultiCursorCut()
+		case gi.KeyFunPaste:
+			tv.MultiCursorPaste()
+		case gi.KeyFunEnter:
+			tv.MultiCursorInsert([]byte("\n"))
+		case gi.KeyFunFocusNext: // tab
+			tv.MultiCursorInsert(indent.Bytes(tv.Buf.Opts.IndentChar(), 1, tv.Sty.Text.TabSize))
+		default:
+			tv.ClearMultiCursors()
+			return false
+		}
+	}
+	kt.SetProcessed()
+	return true
+}
+
+// RenderMultiCursors renders the additional cursors, as static bars in the
+// cursor color (the main cursor is a blinking sprite)
+func (tv *TextView) RenderMultiCursors() {
+	if !tv.HasMultiCursors() {
+		return
+	}
+	rs := &tv.Viewport.Render
+	pc := &rs.Paint
+	sty := &tv.StateStyles[TextViewActive]
+	sz := mat32.Vec2{math32.Max(tv.CursorWidth.Dots, 2), tv.FontHeight}
+	for _, c := range tv.MultiCursors {
+		if tv.IsLineHidden(c.Pos.Ln) {
+			continue
+		}
+		pc.FillBoxColor(rs, tv.CharStartPos(c.Pos), sz, sty.Font.Color)
+	}
+}
+
 ///////////////////////////////////////////////////////////////////////////////
 //    KeyInput handling
 
@@ -3929,6 +5204,13 @@ func (tv *TextView) KeyInput(kt *key.ChordEvent) {
 		return
 	}
 
+	if tv.HasMultiCursors() && !tv.ISearch.On && !tv.QReplace.On {
+		if tv.MultiCursorKeyInput(kt, kf) {
+			tv.CancelComplete()
+			return
+		}
+	}
+
 	// cancelAll cancels search, completer, and..
 	cancelAll := func() {
 		tv.CancelComplete()
@@ -4058,6 +5340,26 @@ func (tv *TextView) KeyInput(kt *key.ChordEvent) {
 		cancelAll()
 		kt.SetProcessed()
 		tv.JumpToLinePrompt()
+	case gi.KeyFunFold:
+		cancelAll()
+		kt.SetProcessed()
+		tv.FoldToggle(tv.CursorPos.Ln)
+	case gi.KeyFunFoldAll:
+		cancelAll()
+		kt.SetProcessed()
+		tv.FoldAll()
+	case gi.KeyFunUnfoldAll:
+		cancelAll()
+		kt.SetProcessed()
+		tv.UnfoldAll()
+	case gi.KeyFunMultiCursorLines:
+		cancelAll()
+		kt.SetProcessed()
+		tv.MultiCursorAddLines()
+	case gi.KeyFunMultiCursorNext:
+		cancelAll()
+		kt.SetProcessed()
+		tv.MultiCursorAddNext()
 	case gi.KeyFunHistPrev:
 		cancelAll()
 		kt.SetProcessed()
@@ -4359,7 +5661,13 @@ func (tv *TextView) MouseEvent(me *mouse.Event) {
 	case mouse.Left:
 		if me.Action == mouse.Press {
 			me.SetProcessed()
-			if _, got := tv.OpenLinkAt(newPos); got {
+			tv.ClearMultiCursors()
+			tv.minimapDrag = false
+			if tv.InMinimap(pt) {
+				tv.minimapDrag = true
+				tv.MinimapScrollTo(pt.Y)
+			} else if tv.HasLineNos() && pt.X < int(tv.LineNoOff) && tv.FoldToggle(newPos.Ln) {
+			} else if _, got := tv.OpenLinkAt(newPos); got {
 			} else {
 				tv.SetCursorFromMouse(pt, newPos, me.SelectMode())
 				tv.SavePosHistory(tv.CursorPos)
@@ -4384,6 +5692,8 @@ func (tv *TextView) MouseEvent(me *mouse.Event) {
 				}
 			}
 			tv.RenderLines(tv.CursorPos.Ln, tv.CursorPos.Ln)
+		} else if me.Action == mouse.Release {
+			tv.minimapDrag = false
 		}
 	case mouse.Middle:
 		if !tv.IsInactive() && me.Action == mouse.Press {
@@ -4442,11 +5752,20 @@ func (tv *TextView) MouseDragEvent() {
 		me := d.(*mouse.DragEvent)
 		me.SetProcessed()
 		txf := recv.Embed(KiT_TextView).(*TextView)
+		pt := txf.PointToRelPos(me.Pos())
+		if txf.minimapDrag {
+			txf.MinimapScrollTo(pt.Y)
+			return
+		}
 		if !txf.SelectMode {
 			txf.SelectModeToggle()
 		}
-		pt := txf.PointToRelPos(me.Pos())
 		newPos := txf.PixelToCursor(pt)
+		if me.HasAnyModifier(key.Alt) { // block selection
+			txf.MultiCursorBlockSelect(txf.SelectStart, newPos)
+			txf.AutoScroll(pt.Add(txf.WinBBox.Min))
+			return
+		}
 		txf.SetCursorFromMouse(pt, newPos, mouse.SelectOne)
 	})
 }
@@ -4633,6 +5952,7 @@ func (tv *TextView) Render2D() {
 // ConnectEvents2D indirectly sets connections between mouse and key events and actions
 func (tv *TextView) ConnectEvents2D() {
 	tv.TextViewEvents()
+	tv.LargeScrollEvents()
 }
 
 // FocusChanged2D appropriate actions for various types of focus changes
@@ -15,12 +15,13 @@ func _() {
 	_ = x[TextViewHasLineNos-33]
 	_ = x[TextViewLastWasTabAI-34]
 	_ = x[TextViewLastWasUndo-35]
-	_ = x[TextViewFlagsN-36]
+	_ = x[TextViewMinimapDirty-36]
+	_ = x[TextViewFlagsN-37]
 }
 
-const _TextViewFlags_name = "TextViewNeedsRefreshTextViewInReLayoutTextViewRenderScrollsTextViewFocusActiveTextViewHasLineNosTextViewLastWasTabAITextViewLastWasUndoTextViewFlagsN"
+const _TextViewFlags_name = "TextViewNeedsRefreshTextViewInReLayoutTextViewRenderScrollsTextViewFocusActiveTextViewHasLineNosTextViewLastWasTabAITextViewLastWasUndoTextViewMinimapDirtyTextViewFlagsN"
 
-var _TextViewFlags_index = [...]uint8{0, 20, 38, 59, 78, 96, 116, 135, 149}
+var _TextViewFlags_index = [...]uint8{0, 20, 38, 59, 78, 96, 116, 135, 155, 169}
 
 func (i TextViewFlags) String() string {
 	i -= 29
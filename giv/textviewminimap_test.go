@@ -0,0 +1,88 @@
+// Copyright (c) 2020, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package giv_test
+
+import (
+	"image/color"
+	"testing"
+
+	"github.com/goki/gi/gi"
+	"github.com/goki/gi/giv"
+	"github.com/goki/gi/giv/textbuf"
+)
+
+func TestTextViewMinimap(t *testing.T) {
+	tb := giv.NewTextBuf()
+	tb.SetText([]byte("alpha one\nbeta two\ngamma three\n"))
+	var tv *giv.TextView
+	h := newTestWindow(t, func(mfr *gi.Frame) {
+		tv = giv.AddNewTextView(mfr, "tv")
+		tv.SetStretchMax()
+		tv.SetBuf(tb)
+		tb.Opts.Minimap = true // after SetBuf, which sets the prefs
+	})
+	defer h.Close()
+
+	if !tv.HasMinimap() {
+		t.Fatalf("no minimap")
+	}
+	sz := tv.MinimapBBox().Size()
+	sc := tv.MinimapScale()
+	img := tv.MinimapImage(sz, sc)
+	if img.Bounds().Size() != sz {
+		t.Fatalf("image size: %v != %v", img.Bounds().Size(), sz)
+	}
+	if img.RGBAAt(1, 1).A == 0 {
+		t.Errorf("no text rendered at the start of the first line")
+	}
+
+	// the image is cached, including across renders, until the buffer changes
+	mark := color.RGBA{1, 2, 3, 4}
+	img.SetRGBA(sz.X/2, sz.Y-1, mark)
+	tv.RenderMinimap(false)
+	if got := tv.MinimapImage(sz, sc); got.RGBAAt(sz.X/2, sz.Y-1) != mark {
+		t.Errorf("image rendered again without changes")
+	}
+	tb.InsertText(textbuf.Pos{}, []byte("x"), true)
+	h.WaitIdle()
+	if got := tv.MinimapImage(sz, sc); got.RGBAAt(sz.X/2, sz.Y-1) == mark {
+		t.Errorf("image not rendered again after an edit")
+	}
+
+	// diffs are shown as markers at the right
+	tv.SetMinimapDiffs(textbuf.Diffs{{Tag: 'd', I1: 0, I2: 1, J1: 0, J2: 0}})
+	h.WaitIdle()
+	got := tv.MinimapImage(tv.MinimapBBox().Size(), tv.MinimapScale())
+	if clr := got.RGBAAt(got.Bounds().Dx()-2, 1); clr != color.RGBA(giv.TextViewMinimapDiffColors['d']) {
+		t.Errorf("diff marker color: %v", clr)
+	}
+}
+
+func TestDiffViewMinimapDiffs(t *testing.T) {
+	var dv *giv.DiffView
+	h := newTestWindow(t, func(mfr *gi.Frame) {
+		dv = giv.AddNewDiffView(mfr, "dv")
+		dv.SetStretchMax()
+	})
+	defer h.Close()
+
+	dv.DiffStrings([]string{"a", "b", "c"}, []string{"a", "c", "d"})
+	av, bv := dv.TextViews()
+	want := textbuf.Diffs{
+		{Tag: 'e', I1: 0, I2: 1, J1: 0, J2: 1},
+		{Tag: 'd', I1: 1, I2: 2, J1: 1, J2: 2},
+		{Tag: 'e', I1: 2, I2: 3, J1: 2, J2: 3},
+		{Tag: 'i', I1: 3, I2: 4, J1: 3, J2: 4},
+	}
+	if av.MinimapDiffs.String() != want.String() {
+		t.Errorf("a diffs:\n%v\nwant:\n%v", av.MinimapDiffs, want)
+	}
+	if bv.MinimapDiffs.String() != want.Reverse().String() {
+		t.Errorf("b diffs:\n%v\nwant:\n%v", bv.MinimapDiffs, want.Reverse())
+	}
+	if bv.MinimapDiffs[1].Tag != 'i' {
+		t.Errorf("the lines deleted in a should be inserted in b: %v", bv.MinimapDiffs[1])
+	}
+}
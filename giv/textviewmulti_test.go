@@ -0,0 +1,62 @@
+// Copyright (c) 2020, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package giv_test
+
+import (
+	"testing"
+
+	"github.com/goki/gi/gi"
+	"github.com/goki/gi/giv"
+	"github.com/goki/gi/giv/textbuf"
+)
+
+func TestTextViewMultiCursor(t *testing.T) {
+	tb := giv.NewTextBuf()
+	tb.SetText([]byte("alpha one\nbeta two\ngamma three\n"))
+	var tv *giv.TextView
+	h := newTestWindow(t, func(mfr *gi.Frame) {
+		tv = giv.AddNewTextView(mfr, "tv")
+		tv.SetStretchMax()
+		tv.SetBuf(tb)
+	})
+	defer h.Close()
+
+	// block selection of the first word of each line, with the main cursor
+	// on the line of the end position, and no other cursor
+	tv.MultiCursorBlockSelect(textbuf.Pos{Ln: 0, Ch: 0}, textbuf.Pos{Ln: 2, Ch: 4})
+	cs := tv.AllCursors()
+	if len(cs) != 3 {
+		t.Fatalf("block select: %d cursors: %v", len(cs), cs)
+	}
+	if cs[0].Pos != (textbuf.Pos{Ln: 2, Ch: 4}) {
+		t.Errorf("block select: main cursor: %v", cs[0].Pos)
+	}
+	for _, c := range cs {
+		if c.Pos == textbuf.PosZero {
+			t.Errorf("block select: cursor at zero position: %v", cs)
+		}
+	}
+
+	// copy and paste between the same cursors, including one without a
+	// selection, gives each cursor its own text, with each as one undo group
+	cs[1].Sel = textbuf.RegionNil // line 0
+	tv.SetAllCursors(cs)
+	cb := tv.MultiCursorCopy()
+	if string(cb) != "\nbeta\ngamm" {
+		t.Errorf("copy: %q", cb)
+	}
+	tv.MultiCursorDelete(false) // selections, and the char before the cursor on line 0
+	if txt := string(tb.Text()); txt != "alpa one\n two\na three\n" {
+		t.Errorf("delete: %q", txt)
+	}
+	tv.MultiCursorPaste()
+	if txt := string(tb.Text()); txt != "alpa one\nbeta two\ngamma three\n" {
+		t.Errorf("paste: %q", txt)
+	}
+	tb.Undo()
+	if txt := string(tb.Text()); txt != "alpa one\n two\na three\n" {
+		t.Errorf("undo paste: %q", txt)
+	}
+}
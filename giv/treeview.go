@@ -349,6 +349,10 @@ const (
 	// Also emits a TreeViewChanged signal on the root node.
 	TreeViewFlagChanged
 
+	// TreeViewFlagFiltered means node is hidden by the filter on the tree
+	// (see SetFilter), because neither it nor any node under it matches
+	TreeViewFlagFiltered
+
 	TreeViewFlagsN
 )
 
@@ -388,6 +392,13 @@ const (
 	// TreeViewSelModeProp is a bool that, if true, automatically selects nodes
 	// when nodes are moved to via keyboard actions
 	TreeViewSelModeProp = "__SelectMode"
+
+	// TreeViewFilterProp is the *TreeFilter that is applied to the tree, if any
+	TreeViewFilterProp = "__Filter"
+
+	// TreeViewFilterClosedProp is a map of the closed state of the nodes
+	// before the filter was set, to restore when it is cleared
+	TreeViewFilterClosedProp = "__FilterClosed"
 )
 
 //////////////////////////////////////////////////////////////////////////////
@@ -621,15 +632,15 @@ func (tv *TreeView) MoveDown(selMode mouse.SelectModes) *TreeView {
 	if tv.IsClosed() || !tv.HasChildren() { // next sibling
 		return tv.MoveDownSibling(selMode)
 	} else {
-		if tv.HasChildren() {
-			nn := tv.Child(0).Embed(KiT_TreeView).(*TreeView)
-			if nn != nil {
+		for _, kid := range tv.Kids {
+			nn := kid.Embed(KiT_TreeView).(*TreeView)
+			if nn != nil && !nn.IsFiltered() {
 				nn.SelectUpdate(selMode)
 				return nn
 			}
 		}
+		return tv.MoveDownSibling(selMode)
 	}
-	return nil
 }
 
 // MoveDownAction moves the selection down to next element in the tree, using given
@@ -654,16 +665,16 @@ func (tv *TreeView) MoveDownSibling(selMode mouse.SelectModes) *TreeView {
 		return nil
 	}
 	myidx, ok := tv.IndexInParent()
-	if ok && myidx < len(*tv.Par.Children())-1 {
-		nn := tv.Par.Child(myidx + 1).Embed(KiT_TreeView).(*TreeView)
-		if nn != nil {
-			nn.SelectUpdate(selMode)
-			return nn
+	if ok {
+		for i := myidx + 1; i < len(*tv.Par.Children()); i++ {
+			nn := tv.Par.Child(i).Embed(KiT_TreeView).(*TreeView)
+			if nn != nil && !nn.IsFiltered() {
+				nn.SelectUpdate(selMode)
+				return nn
+			}
 		}
-	} else {
-		return tv.Par.Embed(KiT_TreeView).(*TreeView).MoveDownSibling(selMode) // try up
 	}
-	return nil
+	return tv.Par.Embed(KiT_TreeView).(*TreeView).MoveDownSibling(selMode) // try up
 }
 
 // MoveUp moves selection up to previous element in the tree, using given
@@ -673,20 +684,19 @@ func (tv *TreeView) MoveUp(selMode mouse.SelectModes) *TreeView {
 		return nil
 	}
 	myidx, ok := tv.IndexInParent()
-	if ok && myidx > 0 {
-		nn := tv.Par.Child(myidx - 1).Embed(KiT_TreeView).(*TreeView)
-		if nn != nil {
-			return nn.MoveToLastChild(selMode)
-		}
-	} else {
-		if tv.Par != nil {
-			nn := tv.Par.Embed(KiT_TreeView).(*TreeView)
-			if nn != nil {
-				nn.SelectUpdate(selMode)
-				return nn
+	if ok {
+		for i := myidx - 1; i >= 0; i-- {
+			nn := tv.Par.Child(i).Embed(KiT_TreeView).(*TreeView)
+			if nn != nil && !nn.IsFiltered() {
+				return nn.MoveToLastChild(selMode)
 			}
 		}
 	}
+	nn := tv.Par.Embed(KiT_TreeView).(*TreeView)
+	if nn != nil {
+		nn.SelectUpdate(selMode)
+		return nn
+	}
 	return nil
 }
 
@@ -772,16 +782,15 @@ func (tv *TreeView) MoveToLastChild(selMode mouse.SelectModes) *TreeView {
 		return nil
 	}
 	if !tv.IsClosed() && tv.HasChildren() {
-		nnk, err := tv.Children().ElemFromEndTry(0)
-		if err == nil {
-			nn := nnk.Embed(KiT_TreeView).(*TreeView)
-			return nn.MoveToLastChild(selMode)
+		for i := len(tv.Kids) - 1; i >= 0; i-- {
+			nn := tv.Kids[i].Embed(KiT_TreeView).(*TreeView)
+			if nn != nil && !nn.IsFiltered() {
+				return nn.MoveToLastChild(selMode)
+			}
 		}
-	} else {
-		tv.SelectUpdate(selMode)
-		return tv
 	}
-	return nil
+	tv.SelectUpdate(selMode)
+	return tv
 }
 
 // MoveHomeAction moves the selection up to top of the tree,
@@ -1786,7 +1795,7 @@ func (tv *TreeView) ConfigParts() {
 		// lbl.Sty.Template = "giv.TreeView.Label"
 		lbl.Props = nil
 		tv.Sty.Font.CopyNonDefaultProps(lbl.This()) // copy our properties to label
-		lbl.SetText(tv.Label())
+		lbl.SetText(tv.FilterLabel())
 		if mods {
 			tv.StylePart(gi.Node2D(lbl))
 		}
@@ -1804,7 +1813,7 @@ func (tv *TreeView) ConfigPartsIfNeeded() {
 		}
 	}
 	if lbl, ok := tv.LabelPart(); ok {
-		ltxt := tv.Label()
+		ltxt := tv.FilterLabel()
 		if lbl.Text != ltxt {
 			lbl.SetText(ltxt)
 		}
@@ -1969,7 +1978,7 @@ func (tv *TreeView) StyleTreeView() {
 	if !tv.HasChildren() {
 		tv.SetClosed()
 	}
-	if tv.HasClosedParent() {
+	if tv.HasClosedParent() || tv.IsFiltered() {
 		tv.ClearFlag(int(gi.CanFocus))
 		return
 	}
@@ -2028,7 +2037,7 @@ func (tv *TreeView) Style2D() {
 
 func (tv *TreeView) Size2D(iter int) {
 	tv.InitLayout2D()
-	if tv.HasClosedParent() {
+	if tv.HasClosedParent() || tv.IsFiltered() {
 		return // nothing
 	}
 	tv.SizeFromParts(iter) // get our size from parts
@@ -2060,7 +2069,7 @@ func (tv *TreeView) Layout2DParts(parBBox image.Rectangle, iter int) {
 }
 
 func (tv *TreeView) Layout2D(parBBox image.Rectangle, iter int) bool {
-	if tv.HasClosedParent() {
+	if tv.HasClosedParent() || tv.IsFiltered() {
 		tv.LayData.AllocPosRel.X = -1000000 // put it very far off screen..
 	}
 	tv.ConfigPartsIfNeeded()
@@ -2160,7 +2169,7 @@ func (tv *TreeView) PushBounds() bool {
 }
 
 func (tv *TreeView) Render2D() {
-	if tv.HasClosedParent() {
+	if tv.HasClosedParent() || tv.IsFiltered() {
 		tv.DisconnectAllEvents(gi.AllPris)
 		return // nothing
 	}
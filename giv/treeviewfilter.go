@@ -0,0 +1,471 @@
+// Copyright (c) 2020, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package giv
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/goki/gi/gi"
+	"github.com/goki/ki/ki"
+	"github.com/goki/ki/kit"
+)
+
+////////////////////////////////////////////////////////////////////////////////////////
+//  TreeFilter
+
+// TreeFilterModes are the ways that the pattern of a TreeFilter is matched
+type TreeFilterModes int32
+
+const (
+	// TreeFilterFuzzy matches text that contains all of the characters of
+	// the pattern, in order, ignoring case -- e.g., "trvw" matches "TreeView"
+	TreeFilterFuzzy TreeFilterModes = iota
+
+	// TreeFilterGlob matches all of the text to a shell glob pattern, where
+	// * matches any characters except /, ** matches any characters, ?
+	// matches any one character, and [...] matches a class of characters --
+	// a pattern without a / only matches the text after the last /
+	TreeFilterGlob
+
+	// TreeFilterRegexp matches text that contains a match to a regular expression
+	TreeFilterRegexp
+
+	TreeFilterModesN
+)
+
+//go:generate stringer -type=TreeFilterModes
+
+var KiT_TreeFilterModes = kit.Enums.AddEnumAltLower(TreeFilterModesN, kit.NotBitFlag, nil, "TreeFilter")
+
+// TreeFilter is a pattern for filtering the nodes of a TreeView, which
+// reports the ranges of the characters that it matches in a node's text,
+// so they can be highlighted
+type TreeFilter struct {
+	Pattern string          `desc:"the pattern as entered"`
+	Mode    TreeFilterModes `desc:"how the pattern is matched"`
+	re      *regexp.Regexp
+	fuzzy   []rune
+	base    bool
+}
+
+// NewTreeFilter returns a new filter for given pattern and mode -- returns
+// an error if the pattern is not valid for the mode
+func NewTreeFilter(pat string, mode TreeFilterModes) (*TreeFilter, error) {
+	tf := &TreeFilter{Pattern: pat, Mode: mode}
+	var err error
+	switch mode {
+	case TreeFilterFuzzy:
+		for _, r := range pat {
+			if !unicode.IsSpace(r) {
+				tf.fuzzy = append(tf.fuzzy, unicode.ToLower(r))
+			}
+		}
+	case TreeFilterGlob:
+		var rx string
+		rx, err = GlobToRegexp(pat)
+		if err == nil {
+			tf.re, err = regexp.Compile(rx)
+		}
+		tf.base = !strings.Contains(pat, "/")
+	case TreeFilterRegexp:
+		tf.re, err = regexp.Compile(pat)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("giv.NewTreeFilter: %v", err)
+	}
+	return tf, nil
+}
+
+// Match returns the ranges of the characters that the filter matches in
+// given text, as [start, end) byte offsets, or nil if it does not match
+// (a match with no characters to highlight is an empty, non-nil list)
+func (tf *TreeFilter) Match(txt string) [][2]int {
+	switch tf.Mode {
+	case TreeFilterFuzzy:
+		return tf.matchFuzzy(txt)
+	case TreeFilterGlob:
+		off := 0
+		if tf.base {
+			off = strings.LastIndex(txt, "/") + 1
+		}
+		mi := tf.re.FindStringSubmatchIndex(txt[off:])
+		if mi == nil {
+			return nil
+		}
+		rngs := [][2]int{}
+		for i := 2; i+1 < len(mi); i += 2 {
+			if mi[i] >= 0 && mi[i+1] > mi[i] {
+				rngs = append(rngs, [2]int{off + mi[i], off + mi[i+1]})
+			}
+		}
+		return rngs
+	case TreeFilterRegexp:
+		mis := tf.re.FindAllStringIndex(txt, -1)
+		if mis == nil {
+			return nil
+		}
+		rngs := [][2]int{}
+		for _, mi := range mis {
+			if mi[1] > mi[0] {
+				rngs = append(rngs, [2]int{mi[0], mi[1]})
+			}
+		}
+		return rngs
+	}
+	return nil
+}
+
+// matchFuzzy matches the characters of the pattern in order, taking the
+// first match for each
+func (tf *TreeFilter) matchFuzzy(txt string) [][2]int {
+	rngs := [][2]int{}
+	pi := 0
+	for i, r := range txt {
+		if pi == len(tf.fuzzy) {
+			break
+		}
+		if unicode.ToLower(r) != tf.fuzzy[pi] {
+			continue
+		}
+		pi++
+		ed := i + utf8.RuneLen(r)
+		if n := len(rngs); n > 0 && rngs[n-1][1] == i {
+			rngs[n-1][1] = ed
+		} else {
+			rngs = append(rngs, [2]int{i, ed})
+		}
+	}
+	if pi < len(tf.fuzzy) {
+		return nil
+	}
+	return rngs
+}
+
+// Markup returns the label, as HTML with the characters that are matched in
+// given text marked, if the label is the end of the text (e.g., the text is
+// the path to the label) -- returns the label as is if the text does not
+// match.
+func (tf *TreeFilter) Markup(txt, lbl string) string {
+	rngs := tf.Match(txt)
+	if rngs == nil || !strings.HasSuffix(txt, lbl) {
+		return lbl
+	}
+	off := len(txt) - len(lbl)
+	var b strings.Builder
+	cp := 0
+	for _, rg := range rngs {
+		st, ed := rg[0]-off, rg[1]-off
+		if ed <= 0 {
+			continue
+		}
+		if st < cp {
+			st = cp
+		}
+		b.Write(HTMLEscapeBytes([]byte(lbl[cp:st])))
+		b.WriteString("<mark>")
+		b.Write(HTMLEscapeBytes([]byte(lbl[st:ed])))
+		b.WriteString("</mark>")
+		cp = ed
+	}
+	b.Write(HTMLEscapeBytes([]byte(lbl[cp:])))
+	return b.String()
+}
+
+// GlobToRegexp returns a regular expression for given shell glob pattern,
+// matching all of the text -- * matches any characters except /, ** matches
+// any characters, ? matches any one character except /, [...] matches a
+// class of characters ([!...] for a negated class), and \ escapes the next
+// character.  The literal parts of the pattern and the classes are groups
+// in the regular expression, so that the characters they match can be found.
+func GlobToRegexp(pat string) (string, error) {
+	var b, lit strings.Builder
+	flush := func() {
+		if lit.Len() > 0 {
+			b.WriteString("(" + regexp.QuoteMeta(lit.String()) + ")")
+			lit.Reset()
+		}
+	}
+	b.WriteString("^")
+	rs := []rune(pat)
+	for i := 0; i < len(rs); i++ {
+		switch r := rs[i]; r {
+		case '*':
+			flush()
+			if i+1 < len(rs) && rs[i+1] == '*' {
+				b.WriteString(".*")
+				i++
+			} else {
+				b.WriteString("[^/]*")
+			}
+		case '?':
+			flush()
+			b.WriteString("[^/]")
+		case '[':
+			flush()
+			ed := i + 1
+			if ed < len(rs) && rs[ed] == '!' {
+				ed++
+			}
+			if ed < len(rs) && rs[ed] == ']' { // ] first is part of class
+				ed++
+			}
+			for ed < len(rs) && rs[ed] != ']' {
+				ed++
+			}
+			if ed >= len(rs) {
+				return "", fmt.Errorf("unterminated [ in glob pattern: %v", pat)
+			}
+			cls := string(rs[i+1 : ed])
+			if strings.HasPrefix(cls, "!") {
+				cls = "^" + cls[1:]
+			}
+			b.WriteString("([" + strings.Replace(cls, `\`, `\\`, -1) + "])")
+			i = ed
+		case '\\':
+			if i+1 < len(rs) {
+				i++
+				lit.WriteRune(rs[i])
+			} else {
+				lit.WriteRune(r)
+			}
+		default:
+			lit.WriteRune(r)
+		}
+	}
+	flush()
+	b.WriteString("$")
+	return b.String(), nil
+}
+
+////////////////////////////////////////////////////////////////////////////////////////
+//  TreeView filtering
+
+// TreeViewFilterer is an interface for TreeView types that filter on
+// something other than the label of the node -- e.g., FileTreeView uses the
+// path of the file relative to the root
+type TreeViewFilterer interface {
+	// FilterText returns the text to match the filter against
+	FilterText() string
+}
+
+// FilterText returns the text that the filter is matched against -- the label
+func (tv *TreeView) FilterText() string {
+	return tv.Label()
+}
+
+// IsFiltered returns whether this node is hidden by the filter on the tree
+func (tv *TreeView) IsFiltered() bool {
+	return tv.HasFlag(int(TreeViewFlagFiltered))
+}
+
+// Filter returns the filter that is applied to the tree, or nil if none
+func (tv *TreeView) Filter() *TreeFilter {
+	if tv.RootView == nil {
+		return nil
+	}
+	tf, _ := tv.RootView.Prop(TreeViewFilterProp).(*TreeFilter)
+	return tf
+}
+
+// FilterLabel returns the label to display, with the characters matched by
+// the filter on the tree marked
+func (tv *TreeView) FilterLabel() string {
+	tf := tv.Filter()
+	if tf == nil {
+		return tv.Label()
+	}
+	return tf.Markup(tv.This().(TreeViewFilterer).FilterText(), tv.Label())
+}
+
+// SetFilter filters the tree to show only the nodes whose FilterText
+// matches given pattern, and their parents, which are opened to show them
+// -- an empty pattern clears the filter.  The open / closed state of the
+// nodes before the filter is set is restored when it is cleared.  Returns
+// an error, and leaves the tree as it is, if the pattern is not valid.
+func (tv *TreeView) SetFilter(pat string, mode TreeFilterModes) error {
+	rn := tv.RootView
+	if pat == "" {
+		rn.ClearFilter()
+		return nil
+	}
+	tf, err := NewTreeFilter(pat, mode)
+	if err != nil {
+		return err
+	}
+	wupdt := rn.TopUpdateStart()
+	updt := rn.UpdateStart()
+	rn.SetFullReRender()
+	if rn.Prop(TreeViewFilterClosedProp) == nil {
+		closed := make(map[*TreeView]bool)
+		rn.FuncDownMeFirst(0, rn.This(), func(k ki.Ki, level int, d interface{}) bool {
+			if tvki := k.Embed(KiT_TreeView); tvki != nil {
+				tvk := tvki.(*TreeView)
+				closed[tvk] = tvk.IsClosed()
+			}
+			return true
+		})
+		rn.SetProp(TreeViewFilterClosedProp, closed)
+	}
+	rn.SetProp(TreeViewFilterProp, tf)
+	rn.filterTree(tf)
+	rn.UpdateEnd(updt)
+	rn.TopUpdateEnd(wupdt)
+	return nil
+}
+
+// filterTree sets the filtered state of this node and all of the nodes
+// under it -- returns true if this node or any under it is shown
+func (tv *TreeView) filterTree(tf *TreeFilter) bool {
+	kidMatch := false
+	for _, kid := range tv.Kids {
+		if tvki := kid.Embed(KiT_TreeView); tvki != nil {
+			if tvki.(*TreeView).filterTree(tf) {
+				kidMatch = true
+			}
+		}
+	}
+	match := tf.Match(tv.This().(TreeViewFilterer).FilterText()) != nil
+	tv.SetFlagState(!match && !kidMatch && tv != tv.RootView, int(TreeViewFlagFiltered))
+	if tv.HasChildren() {
+		tv.SetClosedState(!kidMatch)
+	}
+	return match || kidMatch
+}
+
+// ClearFilter clears the filter on the tree, showing all of the nodes in
+// the open / closed state they were in before the filter was set
+func (tv *TreeView) ClearFilter() {
+	rn := tv.RootView
+	closed, ok := rn.Prop(TreeViewFilterClosedProp).(map[*TreeView]bool)
+	if !ok {
+		return
+	}
+	wupdt := rn.TopUpdateStart()
+	updt := rn.UpdateStart()
+	rn.SetFullReRender()
+	rn.FuncDownMeFirst(0, rn.This(), func(k ki.Ki, level int, d interface{}) bool {
+		if tvki := k.Embed(KiT_TreeView); tvki != nil {
+			tvk := tvki.(*TreeView)
+			tvk.ClearFlag(int(TreeViewFlagFiltered))
+			if cl, has := closed[tvk]; has {
+				tvk.SetClosedState(cl)
+			}
+		}
+		return true
+	})
+	rn.DeleteProp(TreeViewFilterProp)
+	rn.DeleteProp(TreeViewFilterClosedProp)
+	rn.UpdateEnd(updt)
+	rn.TopUpdateEnd(wupdt)
+}
+
+// FilterText returns the path of the file relative to the root of the
+// tree, so the filter can match directories as well as names
+func (ftv *FileTreeView) FilterText() string {
+	fn := ftv.FileNode()
+	if fn == nil || fn.FRoot == nil {
+		return ftv.Label()
+	}
+	return fn.MyRelPath()
+}
+
+////////////////////////////////////////////////////////////////////////////////////////
+//  TreeFilterBar
+
+// TreeFilterBar is a bar with a field for filtering a TreeView (or
+// FileTreeView) as the pattern is typed, and a chooser for how the
+// pattern is matched.  Put it next to the tree view and call SetTreeView.
+type TreeFilterBar struct {
+	gi.Layout
+	TreeView *TreeView       `json:"-" xml:"-" desc:"the tree view that is filtered"`
+	Mode     TreeFilterModes `desc:"how the pattern is matched"`
+}
+
+var KiT_TreeFilterBar = kit.Types.AddType(&TreeFilterBar{}, TreeFilterBarProps)
+
+// AddNewTreeFilterBar adds a new treefilterbar to given parent node, with given name.
+func AddNewTreeFilterBar(parent ki.Ki, name string) *TreeFilterBar {
+	return parent.AddNewChild(KiT_TreeFilterBar, name).(*TreeFilterBar)
+}
+
+// TreeFilterTooltip is the tooltip for the field of a TreeFilterBar
+var TreeFilterTooltip = "show only the items matching this pattern, and their parents, as it is typed -- matched characters are highlighted"
+
+var TreeFilterBarProps = ki.Props{
+	"EnumType:Flag": gi.KiT_NodeFlags,
+	"max-width":     -1,
+}
+
+// SetTreeView sets the tree view to filter, and configures the bar
+func (fb *TreeFilterBar) SetTreeView(tv *TreeView) {
+	fb.TreeView = tv
+	fb.Config()
+}
+
+// Config configures the bar
+func (fb *TreeFilterBar) Config() {
+	fb.Lay = gi.LayoutHoriz
+	fb.SetProp("spacing", gi.StdDialogVSpaceUnits)
+	config := kit.TypeAndNameList{}
+	config.Add(gi.KiT_TextField, "filter")
+	config.Add(gi.KiT_ComboBox, "mode")
+	mods, updt := fb.ConfigChildren(config, ki.UniqueNames)
+	if !mods {
+		return
+	}
+	tf := fb.FilterField()
+	tf.Placeholder = "filter"
+	tf.Tooltip = TreeFilterTooltip
+	tf.SetStretchMaxWidth()
+	tf.TextFieldSig.ConnectOnly(fb.This(), func(recv, send ki.Ki, sig int64, data interface{}) {
+		fbb := recv.Embed(KiT_TreeFilterBar).(*TreeFilterBar)
+		tff := send.(*gi.TextField)
+		switch sig {
+		case int64(gi.TextFieldInsert), int64(gi.TextFieldBackspace), int64(gi.TextFieldDelete):
+			fbb.ApplyFilter(string(tff.EditTxt))
+		case int64(gi.TextFieldDone), int64(gi.TextFieldCleared):
+			fbb.ApplyFilter(tff.Txt)
+		}
+	})
+	cb := fb.ModeChooser()
+	cb.Tooltip = "how the pattern is matched: fuzzy = all of its characters in order, glob = shell pattern e.g., *.go, regexp = regular expression"
+	cb.ItemsFromEnum(KiT_TreeFilterModes, false, 0)
+	cb.SetCurIndex(int(fb.Mode))
+	cb.ComboSig.ConnectOnly(fb.This(), func(recv, send ki.Ki, sig int64, data interface{}) {
+		fbb := recv.Embed(KiT_TreeFilterBar).(*TreeFilterBar)
+		fbb.Mode = TreeFilterModes(sig)
+		fbb.ApplyFilter(string(fbb.FilterField().EditTxt))
+	})
+	fb.UpdateEnd(updt)
+}
+
+// FilterField returns the text field for the pattern
+func (fb *TreeFilterBar) FilterField() *gi.TextField {
+	return fb.ChildByName("filter", 0).(*gi.TextField)
+}
+
+// ModeChooser returns the chooser for the mode
+func (fb *TreeFilterBar) ModeChooser() *gi.ComboBox {
+	return fb.ChildByName("mode", 1).(*gi.ComboBox)
+}
+
+// ApplyFilter filters the tree view with given pattern -- while a pattern
+// is not valid, e.g., a regexp that is still being typed, the tree keeps
+// the last valid filter, and the error is shown in the field's tooltip
+func (fb *TreeFilterBar) ApplyFilter(pat string) {
+	if fb.TreeView == nil {
+		return
+	}
+	tf := fb.FilterField()
+	if err := fb.TreeView.SetFilter(pat, fb.Mode); err != nil {
+		tf.Tooltip = err.Error()
+		return
+	}
+	tf.Tooltip = TreeFilterTooltip
+}
@@ -0,0 +1,204 @@
+// Copyright (c) 2020, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package giv_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/goki/gi/gi"
+	"github.com/goki/gi/giv"
+	"github.com/goki/ki/ki"
+)
+
+func TestTreeFilterMatch(t *testing.T) {
+	fuzzy, glob, rex := giv.TreeFilterFuzzy, giv.TreeFilterGlob, giv.TreeFilterRegexp
+	tests := []struct {
+		pat  string
+		mode giv.TreeFilterModes
+		txt  string
+		want [][2]int
+	}{
+		{"trvw", fuzzy, "TreeView", [][2]int{{0, 2}, {4, 5}, {7, 8}}},
+		{"t v", fuzzy, "TreeView", [][2]int{{0, 1}, {4, 5}}},
+		{"TREE", fuzzy, "treeview", [][2]int{{0, 4}}},
+		{"tvx", fuzzy, "TreeView", nil},
+		{"wt", fuzzy, "TreeView", nil}, // out of order
+		{"", fuzzy, "TreeView", [][2]int{}},
+		{"fé", fuzzy, "café", [][2]int{{2, 5}}},
+
+		{"*.go", glob, "giv/treeview.go", [][2]int{{12, 15}}}, // base name only
+		{"*.go", glob, "giv/treeview.txt", nil},
+		{"*.go", glob, "a.go/x", nil},
+		{"g*", glob, "giv/treeview.go", nil},
+		{"giv/*.go", glob, "giv/a.go", [][2]int{{0, 4}, {5, 8}}}, // whole path
+		{"giv/*.go", glob, "x/giv/a.go", nil},
+		{"giv/*.go", glob, "giv/x/a.go", nil},
+		{"**/a.go", glob, "x/y/a.go", [][2]int{{3, 8}}},
+		{"?.go", glob, "a.go", [][2]int{{1, 4}}},
+		{"?.go", glob, "ab.go", nil},
+		{"[ab]*", glob, "x/b.txt", [][2]int{{2, 3}}},
+		{"[!ab]*", glob, "b.txt", nil},
+		{"[!ab]*", glob, "c.txt", [][2]int{{0, 1}}},
+		{"*", glob, "dir/", [][2]int{}},
+
+		{"e+", rex, "TreeView", [][2]int{{2, 4}, {6, 7}}},
+		{"^v", rex, "TreeView", nil},
+		{"(?i)^t", rex, "TreeView", [][2]int{{0, 1}}},
+		{"x*", rex, "abc", [][2]int{}}, // only empty matches
+	}
+	for _, tst := range tests {
+		tf, err := giv.NewTreeFilter(tst.pat, tst.mode)
+		if err != nil {
+			t.Errorf("NewTreeFilter(%q, %v): %v", tst.pat, tst.mode, err)
+			continue
+		}
+		if got := tf.Match(tst.txt); !reflect.DeepEqual(got, tst.want) {
+			t.Errorf("%v %q Match(%q) = %v, want %v", tst.mode, tst.pat, tst.txt, got, tst.want)
+		}
+	}
+	for _, pat := range []string{"[ab", "("} {
+		mode := glob
+		if pat == "(" {
+			mode = rex
+		}
+		if _, err := giv.NewTreeFilter(pat, mode); err == nil {
+			t.Errorf("NewTreeFilter(%q, %v): no error for invalid pattern", pat, mode)
+		}
+	}
+}
+
+func TestGlobToRegexp(t *testing.T) {
+	tests := []struct {
+		pat  string
+		want string
+	}{
+		{"*.go", `^[^/]*(\.go)$`},
+		{"**", `^.*$`},
+		{"a?b", `^(a)[^/](b)$`},
+		{"[!x]y", `^([^x])(y)$`},
+		{"[]a]", `^([]a])$`},
+		{`[\d]`, `^([\\d])$`},
+		{`\*x`, `^(\*x)$`},
+		{`a\`, `^(a\\)$`},
+		{"a+b", `^(a\+b)$`},
+	}
+	for _, tst := range tests {
+		got, err := giv.GlobToRegexp(tst.pat)
+		if err != nil || got != tst.want {
+			t.Errorf("GlobToRegexp(%q) = %q, %v, want %q", tst.pat, got, err, tst.want)
+		}
+	}
+	if _, err := giv.GlobToRegexp("a[bc"); err == nil {
+		t.Errorf("GlobToRegexp: no error for unterminated [")
+	}
+}
+
+func TestTreeFilterMarkup(t *testing.T) {
+	tests := []struct {
+		pat  string
+		txt  string
+		lbl  string
+		want string
+	}{
+		{"ab", "dir/a<b>", "a<b>", "<mark>a</mark>&lt;<mark>b</mark>&gt;"},
+		{"ab", "a&b", "a&b", "<mark>a</mark>&amp;<mark>b</mark>"},
+		{"da", "dir/a", "a", "<mark>a</mark>"}, // match in the directory is not shown
+		{"d", "dir/a", "a", "a"},
+		{"xy", "a<b>", "a<b>", "a<b>"}, // no match: label as is
+		{"a", "a/b", "c", "c"},         // label is not the end of the text
+	}
+	for _, tst := range tests {
+		tf, _ := giv.NewTreeFilter(tst.pat, giv.TreeFilterFuzzy)
+		if got := tf.Markup(tst.txt, tst.lbl); got != tst.want {
+			t.Errorf("%q Markup(%q, %q) = %q, want %q", tst.pat, tst.txt, tst.lbl, got, tst.want)
+		}
+	}
+}
+
+func TestTreeViewFilter(t *testing.T) {
+	src := ki.Node{}
+	src.InitName(&src, "root")
+	a := src.AddNewChild(ki.KiT_Node, "alpha")
+	a.AddNewChild(ki.KiT_Node, "apple")
+	b := src.AddNewChild(ki.KiT_Node, "beta")
+	b.AddNewChild(ki.KiT_Node, "banana")
+	var tv *giv.TreeView
+	h := newTestWindow(t, func(mfr *gi.Frame) {
+		tv = giv.AddNewTreeView(mfr, "tv")
+		tv.SetRootNode(&src)
+	})
+	defer h.Close()
+
+	views := map[string]*giv.TreeView{}
+	tv.FuncDownMeFirst(0, nil, func(k ki.Ki, level int, d interface{}) bool {
+		tvki := k.Embed(giv.KiT_TreeView)
+		if tvki == nil { // parts
+			return false
+		}
+		tvk := tvki.(*giv.TreeView)
+		views[tvk.Label()] = tvk
+		return true
+	})
+	views["alpha"].SetClosed()
+	views["beta"].SetOpen()
+
+	state := func() map[string]string {
+		st := map[string]string{}
+		for nm, v := range views {
+			switch {
+			case v.IsFiltered():
+				st[nm] = "filtered"
+			case v.HasChildren() && v.IsClosed():
+				st[nm] = "closed"
+			default:
+				st[nm] = "shown"
+			}
+		}
+		return st
+	}
+	before := state()
+
+	if err := tv.SetFilter("ppl", giv.TreeFilterFuzzy); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"root": "shown", "alpha": "shown", "apple": "shown", "beta": "filtered", "banana": "filtered"}
+	if got := state(); !reflect.DeepEqual(got, want) {
+		t.Errorf("filtered: %v, want %v", got, want)
+	}
+	if got := views["apple"].FilterLabel(); got != "a<mark>ppl</mark>e" {
+		t.Errorf("FilterLabel: %q", got)
+	}
+
+	// an invalid pattern leaves the filter as it is, and changing the filter
+	// keeps the state from before the first one
+	if err := tv.SetFilter("(", giv.TreeFilterRegexp); err == nil {
+		t.Errorf("SetFilter: no error for invalid pattern")
+	}
+	if got := state(); !reflect.DeepEqual(got, want) {
+		t.Errorf("after invalid pattern: %v, want %v", got, want)
+	}
+	if err := tv.SetFilter("ban*", giv.TreeFilterGlob); err != nil {
+		t.Fatal(err)
+	}
+	if st := state(); st["alpha"] != "filtered" || st["beta"] != "shown" {
+		t.Errorf("filter changed: %v", st)
+	}
+
+	// clearing restores the open / closed state from before the filter
+	tv.SetFilter("", giv.TreeFilterFuzzy)
+	if got := state(); !reflect.DeepEqual(got, before) {
+		t.Errorf("cleared: %v, want %v", got, before)
+	}
+	if before["alpha"] != "closed" || before["beta"] != "shown" {
+		t.Errorf("state before filter: %v", before)
+	}
+	if tv.Filter() != nil {
+		t.Errorf("filter not cleared")
+	}
+	if got := views["apple"].FilterLabel(); got != "apple" {
+		t.Errorf("FilterLabel after clear: %q", got)
+	}
+}
@@ -10,12 +10,13 @@ func _() {
 	var x [1]struct{}
 	_ = x[TreeViewFlagClosed-29]
 	_ = x[TreeViewFlagChanged-30]
-	_ = x[TreeViewFlagsN-31]
+	_ = x[TreeViewFlagFiltered-31]
+	_ = x[TreeViewFlagsN-32]
 }
 
-const _TreeViewFlags_name = "TreeViewFlagClosedTreeViewFlagChangedTreeViewFlagsN"
+const _TreeViewFlags_name = "TreeViewFlagClosedTreeViewFlagChangedTreeViewFlagFilteredTreeViewFlagsN"
 
-var _TreeViewFlags_index = [...]uint8{0, 18, 37, 51}
+var _TreeViewFlags_index = [...]uint8{0, 18, 37, 57, 71}
 
 func (i TreeViewFlags) String() string {
 	i -= 29
@@ -0,0 +1,286 @@
+// Copyright (c) 2020, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package giv
+
+import (
+	"fmt"
+	"html"
+	"log"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/goki/gi/gi"
+	"github.com/goki/gi/units"
+	"github.com/goki/ki/ki"
+	"github.com/goki/ki/kit"
+)
+
+// Validation of values is specified by these struct field tags, which are
+// checked by ValueViewBase.SetValue before setting a value, so that an
+// invalid value is never set, and by StructView and TableView, which show
+// the fields that are invalid, e.g., because they were never set:
+//
+//   minval:"0" maxval:"100" numeric range (also the limits of a SpinBox
+//                           without min and max tags)
+//   pattern:"^[a-z]+$"      regexp that the value as a string must match
+//   required:"+"            value must not be zero / empty
+//   minlen:"1" maxlen:"20"  number of characters of a string, or elements of a slice or map
+//   oneof:"red,green,blue"  comma-separated list of the valid values, as strings
+//
+// The min and max tags are not validation rules: they only limit the values
+// that a SpinBox steps through, and values outside of them can still be set.
+// In addition, a struct can implement the Validator interface to check
+// itself as a whole.
+
+// ValidTags are the struct field tags used for validation -- see
+// ValidateValue
+var ValidTags = []string{"minval", "maxval", "pattern", "required", "minlen", "maxlen", "oneof"}
+
+// Validator is an interface for structs that check their own values, e.g.,
+// for constraints across fields -- StructView and TableView call Validate
+// after each edit (i.e., after the value has been set), in addition to
+// checking the validation tags of the fields.  Return a *FieldError or
+// FieldErrors to have the errors shown on the fields -- any other error is
+// shown for the struct as a whole.
+type Validator interface {
+	Validate() error
+}
+
+// FieldError is a validation error for the given field of a struct -- see
+// Validator
+type FieldError struct {
+	Field string `desc:"name of the field, with the names of any parent add-fields fields separated by ."`
+	Err   error  `desc:"the error"`
+}
+
+func (fe *FieldError) Error() string {
+	return fe.Field + ": " + fe.Err.Error()
+}
+
+// FieldErrors is a list of validation errors for fields of a struct -- see
+// Validator
+type FieldErrors []*FieldError
+
+func (fe FieldErrors) Error() string {
+	strs := make([]string, len(fe))
+	for i, e := range fe {
+		strs[i] = e.Error()
+	}
+	return strings.Join(strs, "; ")
+}
+
+// HasValidTags returns true if any of the ValidTags is present, using the
+// given tag lookup function, e.g., the Lookup method of a reflect.StructTag
+// or the Tag method of a ValueView
+func HasValidTags(lookup func(tag string) (string, bool)) bool {
+	for _, tag := range ValidTags {
+		if _, has := lookup(tag); has {
+			return true
+		}
+	}
+	return false
+}
+
+// SpinBoxLimitTag returns the given min or max tag for the limit of a
+// SpinBox, or the minval or maxval validation tag if it is not present
+func (vv *ValueViewBase) SpinBoxLimitTag(tag string) (string, bool) {
+	if lim, has := vv.Tag(tag); has {
+		return lim, has
+	}
+	return vv.Tag(tag + "val")
+}
+
+// ValidateValue checks the given value (or pointer to it) against the
+// validation tags that are present using the given tag lookup function,
+// e.g., the Lookup method of a reflect.StructTag or the Tag method of a
+// ValueView -- returns an error describing the first rule that is not
+// satisfied, or nil if the value is valid.
+func ValidateValue(lookup func(tag string) (string, bool), val interface{}) error {
+	v := kit.NonPtrValue(reflect.ValueOf(val))
+	if rq, has := lookup("required"); has && rq != "-" && rq != "false" {
+		if kit.ValueIsZero(v) || ((v.Kind() == reflect.Slice || v.Kind() == reflect.Map) && v.Len() == 0) {
+			return fmt.Errorf("a value is required")
+		}
+	}
+	if vk := v.Kind(); vk >= reflect.Int && vk <= reflect.Float64 { // all the numeric kinds
+		fv, _ := kit.ToFloat(v.Interface())
+		if mintag, has := lookup("minval"); has {
+			if min, err := strconv.ParseFloat(mintag, 64); err == nil && fv < min {
+				return fmt.Errorf("must be at least %v", mintag)
+			}
+		}
+		if maxtag, has := lookup("maxval"); has {
+			if max, err := strconv.ParseFloat(maxtag, 64); err == nil && fv > max {
+				return fmt.Errorf("must be at most %v", maxtag)
+			}
+		}
+	}
+	ln := -1
+	what := "elements"
+	switch v.Kind() {
+	case reflect.String:
+		ln = utf8.RuneCountInString(v.String())
+		what = "characters"
+	case reflect.Slice, reflect.Array, reflect.Map:
+		ln = v.Len()
+	}
+	if ln >= 0 {
+		if mintag, has := lookup("minlen"); has {
+			if min, err := strconv.Atoi(mintag); err == nil && ln < min {
+				return fmt.Errorf("must have at least %v %v", min, what)
+			}
+		}
+		if maxtag, has := lookup("maxlen"); has {
+			if max, err := strconv.Atoi(maxtag); err == nil && ln > max {
+				return fmt.Errorf("must have at most %v %v", max, what)
+			}
+		}
+	}
+	if pat, has := lookup("pattern"); has {
+		if re := validRegexp(pat); re != nil && !re.MatchString(kit.ToString(v.Interface())) {
+			return fmt.Errorf("must match the pattern: %v", pat)
+		}
+	}
+	if oneof, has := lookup("oneof"); has {
+		str := strings.TrimSpace(kit.ToString(v.Interface()))
+		vals := strings.Split(oneof, ",")
+		for _, ov := range vals {
+			if strings.TrimSpace(ov) == str {
+				return nil
+			}
+		}
+		return fmt.Errorf("must be one of: %v", oneof)
+	}
+	return nil
+}
+
+var (
+	validRegexps   = map[string]*regexp.Regexp{}
+	validRegexpsMu sync.Mutex
+)
+
+// validRegexp returns the compiled regexp for given pattern tag, caching it
+// -- nil if it is invalid
+func validRegexp(pat string) *regexp.Regexp {
+	validRegexpsMu.Lock()
+	defer validRegexpsMu.Unlock()
+	if re, has := validRegexps[pat]; has {
+		return re
+	}
+	re, err := regexp.Compile(pat)
+	if err != nil {
+		log.Printf("giv.ValidateValue: programmer error -- invalid pattern tag: %v\n", err)
+	}
+	validRegexps[pat] = re
+	return re
+}
+
+// ValidateStruct checks all the fields of given struct (pointer) against
+// their validation tags (see ValidateValue), including the fields of any
+// view:"add-fields" fields (as Field.SubField), and then calls its Validate
+// method if it is a Validator.  Returns the errors by field name (nil if
+// none), and the error for the struct as a whole, if any.  Fields with a
+// view:"-" tag are not checked, as they cannot be edited.
+func ValidateStruct(structPtr interface{}) (flds map[string]error, err error) {
+	addErr := func(fnm string, ferr error) {
+		if flds == nil {
+			flds = make(map[string]error)
+		}
+		if _, has := flds[fnm]; !has {
+			flds[fnm] = ferr
+		}
+	}
+	var fieldsFunc func(stru interface{}, path string)
+	fieldsFunc = func(stru interface{}, path string) {
+		kit.FlatFieldsValueFunc(stru, func(fval interface{}, typ reflect.Type, field reflect.StructField, fieldVal reflect.Value) bool {
+			vwtag := field.Tag.Get("view")
+			if vwtag == "-" {
+				return true
+			}
+			if vwtag == "add-fields" && field.Type.Kind() == reflect.Struct {
+				fieldsFunc(fieldVal.Addr().Interface(), path+field.Name+".")
+				return true
+			}
+			if !HasValidTags(field.Tag.Lookup) {
+				return true
+			}
+			if ferr := ValidateValue(field.Tag.Lookup, fieldVal.Addr().Interface()); ferr != nil {
+				addErr(path+field.Name, ferr)
+			}
+			return true
+		})
+	}
+	fieldsFunc(structPtr, "")
+	if vl, ok := structPtr.(Validator); ok {
+		switch verr := vl.Validate().(type) {
+		case nil:
+		case *FieldError:
+			addErr(verr.Field, verr.Err)
+		case FieldErrors:
+			for _, fe := range verr {
+				addErr(fe.Field, fe.Err)
+			}
+		default:
+			err = verr
+		}
+	}
+	return
+}
+
+// ValidErrColor is the color used to show validation errors, in
+// ValidErrProps and the valid-err label of StructView
+var ValidErrColor = "#d00"
+
+// ValidErrProps are the style properties set on the widget of an invalid
+// value -- see SetValidErrStyle
+var ValidErrProps = ki.Props{
+	"border-color": ValidErrColor,
+	"border-width": units.NewPx(2),
+}
+
+// ValidErrTooltipProp is the property holding the original tooltip of the
+// widget of an invalid value, while the error is shown as its tooltip
+const ValidErrTooltipProp = "__ValidErrTooltip"
+
+// SetValidErrStyle shows the given validation error on the given widget,
+// setting the ValidErrProps and the error as its tooltip, or, if err is
+// nil, restores its normal style and tooltip -- does SetFullReRender if
+// changed, and returns true if so.
+func SetValidErrStyle(widg gi.Node2D, err error) bool {
+	wb := widg.AsWidget()
+	if wb == nil {
+		return false
+	}
+	ottip, has := wb.Prop(ValidErrTooltipProp).(string)
+	if err == nil {
+		if !has {
+			return false
+		}
+		wb.Tooltip = ottip
+		wb.DeleteProp(ValidErrTooltipProp)
+		for key := range ValidErrProps {
+			wb.DeleteProp(key)
+		}
+		wb.SetFullReRender()
+		return true
+	}
+	ttip := html.EscapeString(err.Error())
+	if has && wb.Tooltip == ttip {
+		return false
+	}
+	if !has {
+		wb.SetProp(ValidErrTooltipProp, wb.Tooltip)
+		for key, val := range ValidErrProps {
+			wb.SetProp(key, val)
+		}
+		wb.SetFullReRender()
+	}
+	wb.Tooltip = ttip
+	return true
+}
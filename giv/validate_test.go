@@ -0,0 +1,127 @@
+// Copyright (c) 2020, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package giv
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestValidateValue(t *testing.T) {
+	tests := []struct {
+		tag   reflect.StructTag
+		val   interface{}
+		valid bool
+	}{
+		{`required:"+"`, "", false},
+		{`required:"+"`, "x", true},
+		{`required:"+"`, 0, false},
+		{`required:"+"`, []int{}, false},
+		{`required:"+"`, map[string]int{"a": 1}, true},
+		{`required:"-"`, "", true},
+		{`minval:"0" maxval:"10"`, 5, true},
+		{`minval:"0" maxval:"10"`, -1, false},
+		{`minval:"0" maxval:"10"`, 11, false},
+		{`minval:"0" maxval:"10"`, 10.5, false},
+		{`minval:"0.5"`, float32(0.25), false},
+		{`maxval:"10"`, uint8(10), true},
+		{`minval:"1"`, "0", true},      // only numbers have a range
+		{`min:"0" max:"10"`, 11, true}, // spinbox limits only
+		{`minlen:"2" maxlen:"3"`, "ab", true},
+		{`minlen:"2" maxlen:"3"`, "a", false},
+		{`minlen:"2" maxlen:"3"`, "abcd", false},
+		{`maxlen:"3"`, "héé", true}, // characters, not bytes
+		{`minlen:"1"`, []string{}, false},
+		{`maxlen:"1"`, map[int]int{1: 1, 2: 2}, false},
+		{`pattern:"^[a-z]+$"`, "abc", true},
+		{`pattern:"^[a-z]+$"`, "aBc", false},
+		{`pattern:"^[0-9]{2}$"`, 42, true},
+		{`pattern:"("`, "x", true}, // invalid pattern is ignored
+		{`oneof:"red, green,blue"`, "green", true},
+		{`oneof:"red, green,blue"`, "Green", false},
+		{`oneof:"1,2"`, 2, true},
+		{`required:"+" minlen:"3"`, "", false},
+	}
+	for _, tst := range tests {
+		err := ValidateValue(tst.tag.Lookup, tst.val)
+		if (err == nil) != tst.valid {
+			t.Errorf("ValidateValue(%v, %#v): %v, want valid: %v", tst.tag, tst.val, err, tst.valid)
+		}
+		if tst.valid {
+			continue
+		}
+		pv := reflect.New(reflect.TypeOf(tst.val))
+		pv.Elem().Set(reflect.ValueOf(tst.val))
+		if perr := ValidateValue(tst.tag.Lookup, pv.Interface()); perr == nil {
+			t.Errorf("ValidateValue(%v, pointer to %#v): valid", tst.tag, tst.val)
+		}
+	}
+}
+
+type validTestInner struct {
+	Code string `pattern:"^[A-Z]{3}$"`
+}
+
+type validTestStruct struct {
+	Name   string         `required:"+"`
+	Age    int            `minval:"0" maxval:"150"`
+	Hidden string         `view:"-" required:"+"`
+	Inner  validTestInner `view:"add-fields"`
+	Start  int
+	End    int
+	Other  string
+}
+
+func (vs *validTestStruct) Validate() error {
+	switch {
+	case vs.End < vs.Start:
+		return &FieldError{Field: "End", Err: errors.New("must not be before Start")}
+	case vs.Other == "both":
+		return FieldErrors{{"Name", errors.New("name error")}, {"Other", errors.New("other error")}}
+	case vs.Other == "all":
+		return errors.New("struct error")
+	}
+	return nil
+}
+
+func TestValidateStruct(t *testing.T) {
+	tests := []struct {
+		stru validTestStruct
+		flds []string
+		err  bool
+	}{
+		{validTestStruct{Name: "a", Inner: validTestInner{"ABC"}}, nil, false},
+		{validTestStruct{Inner: validTestInner{"ABC"}}, []string{"Name"}, false},
+		{validTestStruct{Name: "a", Age: 200, Inner: validTestInner{"abc"}}, []string{"Age", "Inner.Code"}, false},
+		{validTestStruct{Name: "a", Inner: validTestInner{"ABC"}, Start: 2, End: 1}, []string{"End"}, false},
+		{validTestStruct{Name: "a", Inner: validTestInner{"ABC"}, Other: "both"}, []string{"Name", "Other"}, false},
+		{validTestStruct{Name: "a", Inner: validTestInner{"ABC"}, Other: "all"}, nil, true},
+	}
+	for ti, tst := range tests {
+		flds, err := ValidateStruct(&tst.stru)
+		var got []string
+		for fnm := range flds {
+			got = append(got, fnm)
+		}
+		if len(got) != len(tst.flds) {
+			t.Errorf("test %d: fields: %v, want %v", ti, flds, tst.flds)
+		}
+		for _, fnm := range tst.flds {
+			if flds[fnm] == nil {
+				t.Errorf("test %d: no error for %v: %v", ti, fnm, flds)
+			}
+		}
+		if (err != nil) != tst.err {
+			t.Errorf("test %d: struct error: %v", ti, err)
+		}
+	}
+	// the tag error comes first for a field, and the Validate errors after
+	vs := validTestStruct{Inner: validTestInner{"ABC"}, Other: "both"}
+	flds, _ := ValidateStruct(&vs)
+	if flds["Name"] == nil || flds["Name"].Error() != "a value is required" {
+		t.Errorf("Name error: %v", flds["Name"])
+	}
+}
@@ -449,7 +449,8 @@ type ValueView interface {
 
 	// SetValue assigns given value to this item (if not Inactive), using
 	// Ki.SetField for Ki types and kit.SetRobust otherwise -- emits a ViewSig
-	// signal when set.
+	// signal when set.  A value that fails the validation tags of the field
+	// is not set (see ValidateValue, CheckValid).
 	SetValue(val interface{}) bool
 
 	// SetTags sets tags for this valueview, for non-struct values, to
@@ -509,6 +510,7 @@ type ValueViewBase struct {
 	WidgetTyp reflect.Type         `desc:"type of widget to create -- cached during WidgetType method -- chosen based on the ValueView type and reflect.Value type -- see ValueViewer interface"`
 	Widget    gi.Node2D            `desc:"the widget used to display and edit the value in the interface -- this is created for us externally and we cache it during ConfigWidget"`
 	TmpSave   ValueView            `desc:"value view that needs to have SaveTmp called on it whenever a change is made to one of the underlying values -- pass this down to any sub-views created from a parent"`
+	ValidErr  error                `json:"-" xml:"-" desc:"the validation error for the last value given to SetValue, which was not set because of it -- nil if valid -- see CheckValid"`
 }
 
 var KiT_ValueViewBase = kit.Types.AddType(&ValueViewBase{}, ValueViewBaseProps)
@@ -615,6 +617,10 @@ func (vv *ValueViewBase) SetValue(val interface{}) bool {
 	if vv.This().(ValueView).IsInactive() {
 		return false
 	}
+	if vv.CheckValid(val) != nil {
+		vv.ViewSig.Emit(vv.This(), 0, nil)
+		return false
+	}
 	rval := false
 	if vv.Owner != nil {
 		switch vv.OwnKind {
@@ -694,6 +700,29 @@ func (vv *ValueViewBase) SetValue(val interface{}) bool {
 	return rval
 }
 
+// CheckValid checks given value, to be set by SetValue, against the
+// validation tags of this value (see ValidateValue), sets ValidErr to the
+// result, and shows it on the widget (see SetValidErrStyle) -- returns the
+// error if the value is not valid.  The widget keeps showing the invalid
+// value until it is updated.
+func (vv *ValueViewBase) CheckValid(val interface{}) error {
+	var err error
+	if !vv.IsMapKey && vv.Value.IsValid() && HasValidTags(vv.Tag) {
+		vtyp := kit.NonPtrType(vv.Value.Type())
+		if vtyp.Kind() != reflect.Interface {
+			nv := reflect.New(vtyp)
+			if kit.SetRobust(nv.Interface(), val) {
+				err = ValidateValue(vv.Tag, nv.Interface())
+			}
+		}
+	}
+	vv.ValidErr = err
+	if vv.Widget != nil && SetValidErrStyle(vv.Widget, err) {
+		vv.Widget.AsNode2D().UpdateSig()
+	}
+	return err
+}
+
 func (vv *ValueViewBase) SaveTmp() {
 	if vv.TmpSave == nil {
 		return
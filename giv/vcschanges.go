@@ -0,0 +1,453 @@
+// Copyright (c) 2020, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package giv
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/goki/gi/gi"
+	"github.com/goki/gi/giv/textbuf"
+	"github.com/goki/gi/mat32"
+	"github.com/goki/ki/ki"
+	"github.com/goki/pi/vci"
+	"github.com/ianbruene/go-difflib/difflib"
+)
+
+// VcsChangeState is the state of the change gutter for a TextBuf whose
+// file is in version control, which marks the lines that have been added,
+// modified or deleted relative to the committed (HEAD) version of the file,
+// and supports staging, unstaging and reverting each change (hunk).
+type VcsChangeState struct {
+	Repo      vci.Repo      `desc:"version control repository for the file -- nil if not in version control"`
+	Base      *TextBuf      `desc:"version of the file at HEAD, that the changes are relative to"`
+	Diffs     textbuf.Diffs `desc:"diffs from Base to the current text of the buffer -- updated with each markup"`
+	StatusFun func()        `desc:"function called after the index has been updated for the file by staging or unstaging, e.g., to update its version control status"`
+	Dirty     bool          `desc:"set when the text has changed since the Diffs were updated, so that they are only updated in the markup after an edit"`
+	Mu        sync.RWMutex  `desc:"mutex protecting Base, Diffs and Dirty"`
+}
+
+// SetDirty records that the text has changed since the Diffs were updated
+func (vc *VcsChangeState) SetDirty() {
+	vc.Mu.Lock()
+	vc.Dirty = true
+	vc.Mu.Unlock()
+}
+
+// SetVcsRepo sets the version control repository for the file in the
+// buffer, and loads the HEAD version of the file as the base for the
+// change gutter -- nil turns off the change gutter.
+func (tb *TextBuf) SetVcsRepo(repo vci.Repo) {
+	tb.Vcs.Repo = repo
+	tb.UpdateVcsBase()
+}
+
+// UpdateVcsBase re-loads the HEAD version of the file as the base for the
+// change gutter, e.g., after a commit.  If the file has not been committed
+// yet, all of its lines are shown as added.
+func (tb *TextBuf) UpdateVcsBase() {
+	var base *TextBuf
+	if tb.Vcs.Repo != nil && tb.Filename != "" {
+		fb, err := tb.Vcs.Repo.FileContents(string(tb.Filename), "")
+		if err != nil {
+			fb = nil
+		}
+		base = &TextBuf{}
+		base.InitName(base, "vcs-base")
+		base.SetText(fb)
+	}
+	tb.Vcs.Mu.Lock()
+	tb.Vcs.Base = base
+	tb.Vcs.Mu.Unlock()
+	tb.UpdateVcsChanges()
+}
+
+// UpdateVcsChanges updates the diffs from the HEAD version of the file to
+// the current text, shown in the change gutter.
+func (tb *TextBuf) UpdateVcsChanges() {
+	tb.Vcs.Mu.RLock()
+	base := tb.vcsBaseLines()
+	hasBase := tb.Vcs.Base != nil
+	tb.Vcs.Mu.RUnlock()
+	var dfs textbuf.Diffs
+	if hasBase {
+		dfs = textbuf.DiffLines(base, tb.Strings(false))
+	}
+	tb.Vcs.Mu.Lock()
+	tb.Vcs.Diffs = dfs
+	tb.Vcs.Dirty = false
+	tb.Vcs.Mu.Unlock()
+}
+
+// UpdateVcsChangesIfDirty calls UpdateVcsChanges if the text has changed
+// since the last update -- called after each markup, which happens more
+// than once per edit.
+func (tb *TextBuf) UpdateVcsChangesIfDirty() {
+	tb.Vcs.Mu.RLock()
+	dirty := tb.Vcs.Dirty
+	tb.Vcs.Mu.RUnlock()
+	if dirty {
+		tb.UpdateVcsChanges()
+	}
+}
+
+// vcsBaseLines returns the lines of the HEAD version of the file, which are
+// none if it has not been committed (or is empty), so that all of the lines
+// of the buffer are added, instead of replacing the blank line of the Base
+// -- must be called under Vcs.Mu
+func (tb *TextBuf) vcsBaseLines() []string {
+	if tb.Vcs.Base == nil || len(tb.Vcs.Base.Txt) == 0 {
+		return nil
+	}
+	return tb.Vcs.Base.Strings(false)
+}
+
+// VcsChanges returns the changes relative to the HEAD version of the file,
+// as diffs from the HEAD version (a) to the current text (b), without the
+// equal lines.
+func (tb *TextBuf) VcsChanges() textbuf.Diffs {
+	tb.Vcs.Mu.RLock()
+	defer tb.Vcs.Mu.RUnlock()
+	var dfs textbuf.Diffs
+	for _, df := range tb.Vcs.Diffs {
+		if df.Tag != 'e' {
+			dfs = append(dfs, df)
+		}
+	}
+	return dfs
+}
+
+// VcsChangeAt returns the change relative to the HEAD version of the file
+// that includes given line, including deleted lines just before it (or
+// after it, for the last line).
+func (tb *TextBuf) VcsChangeAt(ln int) (difflib.OpCode, bool) {
+	tb.Vcs.Mu.RLock()
+	defer tb.Vcs.Mu.RUnlock()
+	for _, df := range tb.Vcs.Diffs {
+		if df.Tag == 'e' {
+			continue
+		}
+		if ln >= df.J1 && ln < df.J2 {
+			return df, true
+		}
+		if df.J1 == df.J2 && (ln == df.J1 || (df.J1 == tb.NLines && ln == tb.NLines-1)) {
+			return df, true
+		}
+	}
+	return difflib.OpCode{}, false
+}
+
+// VcsRevertChange reverts the change at given line back to the HEAD version,
+// as an undoable edit.  Returns false if there is no change at that line.
+func (tb *TextBuf) VcsRevertChange(ln int) bool {
+	df, ok := tb.VcsChangeAt(ln)
+	if !ok {
+		return false
+	}
+	rdf := difflib.OpCode{Tag: df.Tag, I1: df.J1, I2: df.J2, J1: df.I1, J2: df.I2}
+	switch df.Tag {
+	case 'i':
+		rdf.Tag = 'd'
+	case 'd':
+		rdf.Tag = 'i'
+	}
+	tb.Vcs.Mu.RLock()
+	base := tb.Vcs.Base
+	tb.Vcs.Mu.RUnlock()
+	tb.Undos.BeginGroup()
+	tb.PatchFromBuf(base, textbuf.Diffs{rdf}, true)
+	tb.Undos.EndGroup()
+	tb.UpdateVcsChanges()
+	return true
+}
+
+// VcsStageChange stages the change at given line in the index, without
+// staging any other changes in the file -- the change is taken from the
+// current text of the buffer, even if not yet saved.  Only supported for git.
+func (tb *TextBuf) VcsStageChange(ln int) error {
+	df, ok := tb.VcsChangeAt(ln)
+	if !ok {
+		return fmt.Errorf("no change at line: %d", ln+1)
+	}
+	fnm := string(tb.Filename)
+	idx, err := GitIndexLines(tb.Vcs.Repo, fnm)
+	if err != nil {
+		if in, ierr := GitInIndex(tb.Vcs.Repo, fnm); ierr != nil || in {
+			return err
+		}
+		idx = nil // a new file, which is added to the index
+	}
+	cur := tb.Strings(false)
+	ov := textbuf.DiffLines(idx, cur).OverlapB(df.J1, df.J2)
+	if len(ov) == 0 {
+		return errors.New("change is already staged")
+	}
+	if err := GitSetIndexLines(tb.Vcs.Repo, fnm, textbuf.PatchLines(idx, cur, ov)); err != nil {
+		return err
+	}
+	if tb.Vcs.StatusFun != nil {
+		tb.Vcs.StatusFun()
+	}
+	return nil
+}
+
+// VcsUnstageChange removes any staged changes in the index that overlap the
+// change at given line, restoring the HEAD version of those lines in the
+// index, without changing the file.  Only supported for git.
+func (tb *TextBuf) VcsUnstageChange(ln int) error {
+	df, ok := tb.VcsChangeAt(ln)
+	if !ok {
+		return fmt.Errorf("no change at line: %d", ln+1)
+	}
+	fnm := string(tb.Filename)
+	idx, err := GitIndexLines(tb.Vcs.Repo, fnm)
+	if err != nil {
+		return err
+	}
+	tb.Vcs.Mu.RLock()
+	head := tb.vcsBaseLines()
+	tb.Vcs.Mu.RUnlock()
+	ov := textbuf.DiffLines(idx, head).OverlapB(df.I1, df.I2)
+	if len(ov) == 0 {
+		return errors.New("change is not staged")
+	}
+	if err := GitSetIndexLines(tb.Vcs.Repo, fnm, textbuf.PatchLines(idx, head, ov)); err != nil {
+		return err
+	}
+	if tb.Vcs.StatusFun != nil {
+		tb.Vcs.StatusFun()
+	}
+	return nil
+}
+
+/////////////////////////////////////////////////////////////////////////////
+//   git index
+
+// vcsRunner is implemented by repositories that can run commands in their
+// root directory, e.g., vci.GitRepo
+type vcsRunner interface {
+	RunFromDir(cmd string, args ...string) ([]byte, error)
+}
+
+// GitRun runs git with given args in the root directory of the repository,
+// which must be a git repository, returning its output.
+func GitRun(repo vci.Repo, args ...string) ([]byte, error) {
+	if repo == nil || repo.Vcs() != "git" {
+		return nil, errors.New("giv.GitRun: only supported for git repositories")
+	}
+	rr, ok := repo.(vcsRunner)
+	if !ok {
+		return nil, errors.New("giv.GitRun: repository cannot run commands")
+	}
+	out, err := rr.RunFromDir("git", args...)
+	if err != nil {
+		return out, fmt.Errorf("git %s: %v: %s", args[0], err, strings.TrimSpace(string(out)))
+	}
+	return out, nil
+}
+
+// GitIndexLines returns the lines of the version of the file in the git
+// index (staged), which is empty with an error if it is not in the index.
+// As with the lines of a TextBuf, the newline at the end of the last line
+// does not start another (empty) line.
+func GitIndexLines(repo vci.Repo, fname string) ([]string, error) {
+	out, err := GitRun(repo, "show", ":0:"+vci.RelPath(repo, fname))
+	if err != nil {
+		return nil, err
+	}
+	return vcsFileLines(out), nil
+}
+
+// vcsFileLines returns the lines of given file contents, as in a TextBuf
+func vcsFileLines(b []byte) []string {
+	if len(b) == 0 {
+		return nil
+	}
+	lns := textbuf.BytesToLineStrings(b, false)
+	if n := len(lns); lns[n-1] == "" {
+		lns = lns[:n-1]
+	}
+	return lns
+}
+
+// vcsFinalNewline returns false if given file does not end in a newline,
+// and true otherwise, including if it is empty or does not exist
+func vcsFinalNewline(fname string) bool {
+	f, err := os.Open(fname)
+	if err != nil {
+		return true
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil || fi.Size() == 0 {
+		return true
+	}
+	var b [1]byte
+	if _, err := f.ReadAt(b[:], fi.Size()-1); err != nil {
+		return true
+	}
+	return b[0] == '\n'
+}
+
+// GitInIndex returns true if the file is in the git index, i.e., it is
+// tracked or has been added
+func GitInIndex(repo vci.Repo, fname string) (bool, error) {
+	out, err := GitRun(repo, "ls-files", "-s", "--", vci.RelPath(repo, fname))
+	if err != nil {
+		return false, err
+	}
+	return len(strings.TrimSpace(string(out))) > 0, nil
+}
+
+// GitSetIndexLines sets the version of the file in the git index (staged)
+// to the given lines, each ending in a newline as when a TextBuf is saved,
+// except for the last line if the file itself does not end in a newline,
+// leaving the file itself unchanged.
+func GitSetIndexLines(repo vci.Repo, fname string, lines []string) error {
+	rel := vci.RelPath(repo, fname)
+	mode := "100644"
+	if out, err := GitRun(repo, "ls-files", "-s", "--", rel); err == nil {
+		if flds := strings.Fields(string(out)); len(flds) > 0 {
+			mode = flds[0]
+		}
+	}
+	tf, err := ioutil.TempFile("", "gi-stage-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tf.Name())
+	final := vcsFinalNewline(fname)
+	for i, ln := range lines {
+		if i < len(lines)-1 || final {
+			ln += "\n"
+		}
+		if _, err = tf.WriteString(ln); err != nil {
+			break
+		}
+	}
+	tf.Close()
+	if err != nil {
+		return err
+	}
+	out, err := GitRun(repo, "hash-object", "-w", "--path="+rel, tf.Name())
+	if err != nil {
+		return err
+	}
+	flds := strings.Fields(string(out)) // sha is last, after any warnings
+	if len(flds) == 0 {
+		return errors.New("giv.GitSetIndexLines: no object hash from git")
+	}
+	_, err = GitRun(repo, "update-index", "--add", "--cacheinfo", mode+","+flds[len(flds)-1]+","+rel)
+	return err
+}
+
+/////////////////////////////////////////////////////////////////////////////
+//   TextView change gutter
+
+// HasVcsChanges returns true if the buffer is showing the change gutter for
+// a file in version control
+func (tv *TextView) HasVcsChanges() bool {
+	if tv.Buf == nil || tv.Buf.Vcs.Repo == nil {
+		return false
+	}
+	tv.Buf.Vcs.Mu.RLock()
+	defer tv.Buf.Vcs.Mu.RUnlock()
+	return tv.Buf.Vcs.Base != nil
+}
+
+// RenderVcsChangeMarker renders the change gutter marker for given line, at
+// the right edge of the line number area: a bar for added or modified lines,
+// and a wedge where lines were deleted, in the same colors as DiffView.
+func (tv *TextView) RenderVcsChangeMarker(ln int) {
+	if !tv.HasVcsChanges() {
+		return
+	}
+	df, ok := tv.Buf.VcsChangeAt(ln)
+	if !ok {
+		return
+	}
+	clr, has := TextViewMinimapDiffColors[df.Tag]
+	if !has {
+		return
+	}
+	rs := &tv.Viewport.Render
+	pc := &rs.Paint
+	sty := &tv.Sty
+	spc := sty.BoxSpace()
+	ch := sty.Font.Face.Metrics.Ch
+	x := float32(tv.VpBBox.Min.X) + tv.LineNoOff - spc - 0.4*ch
+	y := tv.CharStartPos(textbuf.Pos{Ln: ln}).Y
+	if df.J1 < df.J2 {
+		pc.FillBoxColor(rs, mat32.Vec2{X: x, Y: y}, mat32.Vec2{X: 0.3 * ch, Y: tv.LineHeight}, clr)
+		return
+	}
+	if df.J1 > ln { // deleted after the last line
+		y += tv.LineHeight
+	}
+	sz := 0.5 * ch
+	pts := []mat32.Vec2{{X: x, Y: y - sz}, {X: x + 0.3*ch, Y: y}, {X: x, Y: y + sz}}
+	pc.StrokeStyle.SetColor(nil)
+	pc.FillStyle.SetColor(&clr)
+	pc.DrawPolygon(rs, pts)
+	pc.FillStrokeClear(rs)
+}
+
+// VcsChangeAction performs given change action ("revert", "stage" or
+// "unstage") on the change at the cursor, reporting any error in a dialog
+func (tv *TextView) VcsChangeAction(act string) {
+	if !tv.HasVcsChanges() {
+		return
+	}
+	ln := tv.CursorPos.Ln
+	var err error
+	switch act {
+	case "revert":
+		if !tv.Buf.VcsRevertChange(ln) {
+			err = fmt.Errorf("no change at line: %d", ln+1)
+		}
+	case "stage":
+		err = tv.Buf.VcsStageChange(ln)
+	case "unstage":
+		err = tv.Buf.VcsUnstageChange(ln)
+	}
+	if err != nil {
+		gi.PromptDialog(tv.Viewport, gi.DlgOpts{Title: "Could not " + strings.Title(act) + " Change", Prompt: err.Error()}, gi.AddOk, gi.NoCancel, nil, nil)
+	}
+}
+
+// VcsChangeContextMenu adds the change actions to the context menu
+func (tv *TextView) VcsChangeContextMenu(m *gi.Menu) {
+	m.AddSeparator("sep-vcs")
+	_, has := tv.Buf.VcsChangeAt(tv.CursorPos.Ln)
+	isGit := tv.Buf.Vcs.Repo.Vcs() == "git"
+	ac := m.AddAction(gi.ActOpts{Label: "Stage Change"},
+		tv.This(), func(recv, send ki.Ki, sig int64, data interface{}) {
+			txf := recv.Embed(KiT_TextView).(*TextView)
+			txf.VcsChangeAction("stage")
+		})
+	ac.SetActiveState(has && isGit)
+	ac = m.AddAction(gi.ActOpts{Label: "Unstage Change"},
+		tv.This(), func(recv, send ki.Ki, sig int64, data interface{}) {
+			txf := recv.Embed(KiT_TextView).(*TextView)
+			txf.VcsChangeAction("unstage")
+		})
+	ac.SetActiveState(has && isGit)
+	ac = m.AddAction(gi.ActOpts{Label: "Revert Change"},
+		tv.This(), func(recv, send ki.Ki, sig int64, data interface{}) {
+			txf := recv.Embed(KiT_TextView).(*TextView)
+			txf.VcsChangeAction("revert")
+		})
+	ac.SetActiveState(has)
+	ac = m.AddAction(gi.ActOpts{Label: "Staged Changes..."},
+		tv.This(), func(recv, send ki.Ki, sig int64, data interface{}) {
+			txf := recv.Embed(KiT_TextView).(*TextView)
+			VcsChangesViewDialog(txf.Buf.Vcs.Repo)
+		})
+	ac.SetActiveState(isGit)
+}
@@ -0,0 +1,242 @@
+// Copyright (c) 2020, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package giv
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/goki/gi/gi"
+	"github.com/goki/gi/giv/textbuf"
+	"github.com/goki/pi/vci"
+)
+
+var testVcsLines = []string{"one", "two", "three", "four", "five", "six"}
+
+// newTestGitRepo returns a new git repository in a temp dir (to be removed
+// by the caller), with a committed file of testVcsLines, and its name
+func newTestGitRepo(t *testing.T) (vci.Repo, string) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	dir, err := ioutil.TempDir("", "giv-vcs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir, _ = filepath.EvalSymlinks(dir)
+	fnm := filepath.Join(dir, "file.txt")
+	ioutil.WriteFile(fnm, []byte(strings.Join(testVcsLines, "\n")+"\n"), 0644)
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"config", "user.email", "test@example.com"},
+		{"config", "user.name", "Test"},
+		{"remote", "add", "origin", "https://example.com/test.git"},
+		{"add", "file.txt"},
+		{"commit", "-q", "-m", "initial"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			os.RemoveAll(dir)
+			t.Fatalf("git %v: %v: %s", args, err, out)
+		}
+	}
+	repo, err := vci.NewRepo("origin", dir)
+	if err != nil {
+		os.RemoveAll(dir)
+		t.Fatal(err)
+	}
+	return repo, fnm
+}
+
+// indexLines returns the lines of the file in the index, failing on error
+func indexLines(t *testing.T, repo vci.Repo, fnm string) []string {
+	t.Helper()
+	lns, err := GitIndexLines(repo, fnm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return lns
+}
+
+// replaceLines returns a copy of lines with the given lines replaced
+func replaceLines(lines []string, repl map[int]string) []string {
+	lns := append([]string{}, lines...)
+	for ln, s := range repl {
+		lns[ln] = s
+	}
+	return lns
+}
+
+func TestGitSetIndexLines(t *testing.T) {
+	repo, fnm := newTestGitRepo(t)
+	defer os.RemoveAll(repo.LocalPath())
+
+	if got := indexLines(t, repo, fnm); !reflect.DeepEqual(got, testVcsLines) {
+		t.Errorf("index lines: %v != %v", got, testVcsLines)
+	}
+	if in, err := GitInIndex(repo, fnm); err != nil || !in {
+		t.Errorf("committed file not in index: %v %v", in, err)
+	}
+
+	staged := replaceLines(testVcsLines, map[int]string{2: "THREE"})
+	if err := GitSetIndexLines(repo, fnm, staged); err != nil {
+		t.Fatal(err)
+	}
+	if got := indexLines(t, repo, fnm); !reflect.DeepEqual(got, staged) {
+		t.Errorf("index lines after set: %v != %v", got, staged)
+	}
+	if fb, _ := ioutil.ReadFile(fnm); string(fb) != strings.Join(testVcsLines, "\n")+"\n" {
+		t.Errorf("file changed by GitSetIndexLines: %q", fb)
+	}
+
+	// a new file is not in the index, until it is set
+	nfnm := filepath.Join(repo.LocalPath(), "new.txt")
+	ioutil.WriteFile(nfnm, []byte("new\n"), 0644)
+	if in, err := GitInIndex(repo, nfnm); err != nil || in {
+		t.Errorf("untracked file in index: %v %v", in, err)
+	}
+	if _, err := GitIndexLines(repo, nfnm); err == nil {
+		t.Errorf("no error for the index lines of an untracked file")
+	}
+	if err := GitSetIndexLines(repo, nfnm, []string{"new"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := indexLines(t, repo, nfnm); !reflect.DeepEqual(got, []string{"new"}) {
+		t.Errorf("index lines of new file: %v", got)
+	}
+}
+
+// newTestVcsBuf returns a new TextBuf with given file open, in given repo
+func newTestVcsBuf(t *testing.T, repo vci.Repo, fnm string) *TextBuf {
+	t.Helper()
+	tb := &TextBuf{}
+	tb.InitName(tb, "tb")
+	if err := tb.Open(gi.FileName(fnm)); err != nil {
+		t.Fatal(err)
+	}
+	tb.SetVcsRepo(repo)
+	return tb
+}
+
+func TestVcsStageChange(t *testing.T) {
+	repo, fnm := newTestGitRepo(t)
+	defer os.RemoveAll(repo.LocalPath())
+	tb := newTestVcsBuf(t, repo, fnm)
+	if chs := tb.VcsChanges(); len(chs) != 0 {
+		t.Errorf("changes in unedited file: %v", chs)
+	}
+
+	// two changes, not saved
+	tb.InsertText(textbuf.Pos{Ln: 1, Ch: 0}, []byte("2-"), EditNoSignal)
+	tb.InsertText(textbuf.Pos{Ln: 4, Ch: 0}, []byte("5-"), EditNoSignal)
+	if !tb.Vcs.Dirty {
+		t.Errorf("not Dirty after edits")
+	}
+	tb.UpdateVcsChangesIfDirty()
+	if tb.Vcs.Dirty {
+		t.Errorf("still Dirty after UpdateVcsChangesIfDirty")
+	}
+	if chs := tb.VcsChanges(); len(chs) != 2 {
+		t.Fatalf("changes after edits: %v", chs)
+	}
+
+	if err := tb.VcsStageChange(0); err == nil {
+		t.Errorf("no error staging an unchanged line")
+	}
+	if err := tb.VcsStageChange(1); err != nil {
+		t.Fatal(err)
+	}
+	want := replaceLines(testVcsLines, map[int]string{1: "2-two"})
+	if got := indexLines(t, repo, fnm); !reflect.DeepEqual(got, want) {
+		t.Errorf("index after staging line 2: %v != %v", got, want)
+	}
+	if err := tb.VcsStageChange(1); err == nil {
+		t.Errorf("no error staging a staged change")
+	}
+	if err := tb.VcsStageChange(4); err != nil {
+		t.Fatal(err)
+	}
+	want = replaceLines(want, map[int]string{4: "5-five"})
+	if got := indexLines(t, repo, fnm); !reflect.DeepEqual(got, want) {
+		t.Errorf("index after staging line 5: %v != %v", got, want)
+	}
+	if fb, _ := ioutil.ReadFile(fnm); string(fb) != strings.Join(testVcsLines, "\n")+"\n" {
+		t.Errorf("file changed by staging: %q", fb)
+	}
+
+	// unstaging restores the HEAD version of just that change
+	if err := tb.VcsUnstageChange(1); err != nil {
+		t.Fatal(err)
+	}
+	want = replaceLines(testVcsLines, map[int]string{4: "5-five"})
+	if got := indexLines(t, repo, fnm); !reflect.DeepEqual(got, want) {
+		t.Errorf("index after unstaging line 2: %v != %v", got, want)
+	}
+	if err := tb.VcsUnstageChange(1); err == nil {
+		t.Errorf("no error unstaging a change that is not staged")
+	}
+	if err := tb.VcsUnstageChange(4); err != nil {
+		t.Fatal(err)
+	}
+	if got := indexLines(t, repo, fnm); !reflect.DeepEqual(got, testVcsLines) {
+		t.Errorf("index after unstaging all: %v != %v", got, testVcsLines)
+	}
+}
+
+func TestVcsStageChangeNewFile(t *testing.T) {
+	repo, _ := newTestGitRepo(t)
+	defer os.RemoveAll(repo.LocalPath())
+	fnm := filepath.Join(repo.LocalPath(), "new.txt")
+	ioutil.WriteFile(fnm, []byte("a\nb\n"), 0644)
+	tb := newTestVcsBuf(t, repo, fnm)
+	if chs := tb.VcsChanges(); len(chs) != 1 || chs[0].Tag != 'i' {
+		t.Fatalf("changes in new file: %v", chs)
+	}
+	if err := tb.VcsStageChange(0); err != nil {
+		t.Fatal(err)
+	}
+	if got := indexLines(t, repo, fnm); !reflect.DeepEqual(got, []string{"a", "b"}) {
+		t.Errorf("index lines of staged new file: %v", got)
+	}
+	if err := tb.VcsUnstageChange(0); err != nil {
+		t.Fatal(err)
+	}
+	if got := indexLines(t, repo, fnm); len(got) != 0 {
+		t.Errorf("index lines of unstaged new file: %v", got)
+	}
+}
+
+func TestVcsStageChangeNoFinalNewline(t *testing.T) {
+	repo, fnm := newTestGitRepo(t)
+	defer os.RemoveAll(repo.LocalPath())
+	GitRun(repo, "rm", "-q", "--cached", "file.txt")
+	ioutil.WriteFile(fnm, []byte(strings.Join(testVcsLines, "\n")), 0644)
+	GitRun(repo, "add", "file.txt")
+	GitRun(repo, "commit", "-q", "-m", "no final newline")
+
+	// the change is saved, so staging it stages the whole file
+	lns := replaceLines(testVcsLines, map[int]string{5: "SIX"})
+	ioutil.WriteFile(fnm, []byte(strings.Join(lns, "\n")), 0644)
+	tb := newTestVcsBuf(t, repo, fnm)
+	if chs := tb.VcsChanges(); len(chs) != 1 {
+		t.Fatalf("changes: %v", chs)
+	}
+	if err := tb.VcsStageChange(5); err != nil {
+		t.Fatal(err)
+	}
+	if out, _ := GitRun(repo, "show", ":0:file.txt"); string(out) != strings.Join(lns, "\n") {
+		t.Errorf("index contents: %q", out)
+	}
+	if out, err := GitRun(repo, "diff", "--", "file.txt"); err != nil || len(out) != 0 {
+		t.Errorf("unstaged changes after staging the only change: %v %s", err, out)
+	}
+}
@@ -0,0 +1,267 @@
+// Copyright (c) 2020, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package giv
+
+import (
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/goki/gi/gi"
+	"github.com/goki/gi/giv/textbuf"
+	"github.com/goki/gi/units"
+	"github.com/goki/ki/ki"
+	"github.com/goki/ki/kit"
+	"github.com/goki/pi/vci"
+)
+
+// VcsChange is a file with changes in a git repository, in the index
+// (staged, to be committed) and / or in the working tree (not yet staged)
+type VcsChange struct {
+	File     string `width:"50" desc:"file, relative to the root of the repository"`
+	Staged   string `desc:"change in the index relative to HEAD, which will be committed"`
+	Unstaged string `desc:"change in the working tree relative to the index, which is not yet staged"`
+}
+
+// gitStatusNames are the names of the git status codes
+var gitStatusNames = map[byte]string{
+	'M': "Modified",
+	'A': "Added",
+	'D': "Deleted",
+	'R': "Renamed",
+	'C': "Copied",
+	'U': "Unmerged",
+	'?': "Untracked",
+}
+
+// VcsChanges returns the files with changes in the git repository, from
+// git status
+func VcsChanges(repo vci.Repo) ([]VcsChange, error) {
+	out, err := GitRun(repo, "status", "--porcelain")
+	if err != nil {
+		return nil, err
+	}
+	var chs []VcsChange
+	for _, ln := range strings.Split(string(out), "\n") {
+		if len(ln) < 4 {
+			continue
+		}
+		fnm := ln[3:]
+		if ai := strings.Index(fnm, " -> "); ai >= 0 { // rename
+			fnm = fnm[ai+4:]
+		}
+		if uq, err := strconv.Unquote(fnm); err == nil {
+			fnm = uq
+		}
+		chs = append(chs, VcsChange{File: fnm, Staged: gitStatusNames[ln[0]], Unstaged: gitStatusNames[ln[1]]})
+	}
+	return chs, nil
+}
+
+// VcsChangesView shows the files with staged and unstaged changes in a git
+// repository, with actions to stage and unstage whole files, show the
+// staged and unstaged diffs, and commit the staged changes.  Individual
+// changes can be staged from the change gutter in the TextView.
+type VcsChangesView struct {
+	gi.Layout
+	Repo    vci.Repo    `json:"-" xml:"-" copy:"-" desc:"version control system repository"`
+	Changes []VcsChange `desc:"current changes"`
+}
+
+var KiT_VcsChangesView = kit.Types.AddType(&VcsChangesView{}, VcsChangesViewProps)
+
+// Config configures to given repo
+func (cv *VcsChangesView) Config(repo vci.Repo) {
+	cv.Repo = repo
+	cv.Lay = gi.LayoutVert
+	config := kit.TypeAndNameList{}
+	config.Add(gi.KiT_ToolBar, "toolbar")
+	config.Add(KiT_TableView, "changes")
+	mods, updt := cv.ConfigChildren(config, ki.UniqueNames)
+	tv := cv.TableView()
+	if mods {
+		cv.ConfigToolBar()
+		tv.SliceViewSig.Connect(cv.This(), func(recv, send ki.Ki, sig int64, data interface{}) {
+			if sig == int64(SliceViewDoubleClicked) {
+				idx := data.(int)
+				if idx >= 0 && idx < len(cv.Changes) {
+					cv.Diff(idx, cv.Changes[idx].Staged != "")
+				}
+			}
+		})
+	} else {
+		updt = cv.UpdateStart()
+	}
+	cv.Changes, _ = VcsChanges(repo)
+	tv.SetStretchMax()
+	tv.SetInactive()
+	tv.SetSlice(&cv.Changes)
+	cv.UpdateEnd(updt)
+}
+
+// ToolBar returns the toolbar
+func (cv *VcsChangesView) ToolBar() *gi.ToolBar {
+	return cv.ChildByName("toolbar", 0).(*gi.ToolBar)
+}
+
+// TableView returns the tableview
+func (cv *VcsChangesView) TableView() *TableView {
+	return cv.ChildByName("changes", 1).(*TableView)
+}
+
+// Refresh updates the list of changes from the repository
+func (cv *VcsChangesView) Refresh() {
+	var err error
+	cv.Changes, err = VcsChanges(cv.Repo)
+	if err != nil {
+		gi.PromptDialog(cv.Viewport, gi.DlgOpts{Title: "Could not get Changes", Prompt: err.Error()}, gi.AddOk, gi.NoCancel, nil, nil)
+	}
+	cv.TableView().SetSlice(&cv.Changes)
+}
+
+// SelectedFile returns the full path of the currently selected file, if any
+func (cv *VcsChangesView) SelectedFile() (string, bool) {
+	idx := cv.TableView().SelectedIdx
+	if idx < 0 || idx >= len(cv.Changes) {
+		return "", false
+	}
+	return filepath.Join(cv.Repo.LocalPath(), cv.Changes[idx].File), true
+}
+
+// Stage stages all the changes in the selected file
+func (cv *VcsChangesView) Stage() {
+	fnm, ok := cv.SelectedFile()
+	if !ok {
+		return
+	}
+	if _, err := GitRun(cv.Repo, "add", "--", vci.RelPath(cv.Repo, fnm)); err != nil {
+		gi.PromptDialog(cv.Viewport, gi.DlgOpts{Title: "Could not Stage", Prompt: err.Error()}, gi.AddOk, gi.NoCancel, nil, nil)
+	}
+	cv.Refresh()
+}
+
+// Unstage unstages all the changes in the selected file, leaving the file
+// itself unchanged
+func (cv *VcsChangesView) Unstage() {
+	fnm, ok := cv.SelectedFile()
+	if !ok {
+		return
+	}
+	if _, err := GitRun(cv.Repo, "reset", "-q", "HEAD", "--", vci.RelPath(cv.Repo, fnm)); err != nil {
+		gi.PromptDialog(cv.Viewport, gi.DlgOpts{Title: "Could not Unstage", Prompt: err.Error()}, gi.AddOk, gi.NoCancel, nil, nil)
+	}
+	cv.Refresh()
+}
+
+// Diff shows the diffs for the change at given index: the staged changes
+// in the index relative to HEAD if staged, else the unstaged changes in the
+// working tree relative to the index
+func (cv *VcsChangesView) Diff(idx int, staged bool) {
+	if idx < 0 || idx >= len(cv.Changes) {
+		return
+	}
+	fnm := filepath.Join(cv.Repo.LocalPath(), cv.Changes[idx].File)
+	idxs, _ := GitIndexLines(cv.Repo, fnm)
+	var astr, bstr []string
+	var arev, brev string
+	if staged {
+		if fb, err := GitRun(cv.Repo, "show", "HEAD:"+cv.Changes[idx].File); err == nil {
+			astr = vcsFileLines(fb)
+		}
+		bstr = idxs
+		arev, brev = "HEAD", "index"
+	} else {
+		fb, err := textbuf.FileBytes(fnm)
+		if err != nil {
+			return
+		}
+		astr = idxs
+		bstr = vcsFileLines(fb)
+		arev, brev = "index", ""
+	}
+	title := "Unstaged Changes: "
+	if staged {
+		title = "Staged Changes: "
+	}
+	DiffViewDialog(cv.Viewport, astr, bstr, fnm, fnm, arev, brev, DlgOpts{Title: title + DirAndFile(fnm)})
+}
+
+// Commit commits the staged changes with given message
+func (cv *VcsChangesView) Commit(message string) {
+	if _, err := GitRun(cv.Repo, "commit", "-q", "-m", message); err != nil {
+		gi.PromptDialog(cv.Viewport, gi.DlgOpts{Title: "Could not Commit", Prompt: err.Error()}, gi.AddOk, gi.NoCancel, nil, nil)
+	}
+	cv.Refresh()
+}
+
+// ConfigToolBar
+func (cv *VcsChangesView) ConfigToolBar() {
+	tb := cv.ToolBar()
+	tb.AddAction(gi.ActOpts{Label: "Refresh", Icon: "update", Tooltip: "update the list of changes from the repository"}, cv.This(),
+		func(recv, send ki.Ki, sig int64, data interface{}) {
+			cvv := recv.Embed(KiT_VcsChangesView).(*VcsChangesView)
+			cvv.Refresh()
+		})
+	tb.AddSeparator("rsep")
+	tb.AddAction(gi.ActOpts{Label: "Stage", Icon: "plus", Tooltip: "stage all the changes in the selected file -- use the change gutter in the editor to stage individual changes"}, cv.This(),
+		func(recv, send ki.Ki, sig int64, data interface{}) {
+			cvv := recv.Embed(KiT_VcsChangesView).(*VcsChangesView)
+			cvv.Stage()
+		})
+	tb.AddAction(gi.ActOpts{Label: "Unstage", Icon: "minus", Tooltip: "unstage all the changes in the selected file, leaving the file unchanged"}, cv.This(),
+		func(recv, send ki.Ki, sig int64, data interface{}) {
+			cvv := recv.Embed(KiT_VcsChangesView).(*VcsChangesView)
+			cvv.Unstage()
+		})
+	tb.AddSeparator("dsep")
+	tb.AddAction(gi.ActOpts{Label: "Diff Staged", Icon: "file-sheet", Tooltip: "show the staged changes in the selected file, in the index relative to HEAD"}, cv.This(),
+		func(recv, send ki.Ki, sig int64, data interface{}) {
+			cvv := recv.Embed(KiT_VcsChangesView).(*VcsChangesView)
+			cvv.Diff(cvv.TableView().SelectedIdx, true)
+		})
+	tb.AddAction(gi.ActOpts{Label: "Diff Unstaged", Icon: "file-sheet", Tooltip: "show the unstaged changes in the selected file, in the working tree relative to the index"}, cv.This(),
+		func(recv, send ki.Ki, sig int64, data interface{}) {
+			cvv := recv.Embed(KiT_VcsChangesView).(*VcsChangesView)
+			cvv.Diff(cvv.TableView().SelectedIdx, false)
+		})
+	tb.AddSeparator("csep")
+	tb.AddAction(gi.ActOpts{Label: "Commit", Icon: "file-save", Tooltip: "commit the staged changes"}, cv.This(),
+		func(recv, send ki.Ki, sig int64, data interface{}) {
+			cvv := recv.Embed(KiT_VcsChangesView).(*VcsChangesView)
+			gi.StringPromptDialog(cvv.Viewport, "", "Enter commit message here..",
+				gi.DlgOpts{Title: "Commit Staged Changes", Prompt: "Please enter a commit message for the staged changes"},
+				cvv.This(), func(recv, send ki.Ki, sig int64, data interface{}) {
+					dlg := send.(*gi.Dialog)
+					if sig == int64(gi.DialogAccepted) {
+						msg := gi.StringPromptDialogValue(dlg)
+						cvv.Commit(msg)
+					}
+				})
+		})
+}
+
+// VcsChangesViewProps are style properties for VcsChangesView
+var VcsChangesViewProps = ki.Props{
+	"EnumType:Flag": gi.KiT_NodeFlags,
+	"max-width":     -1,
+	"max-height":    -1,
+}
+
+// VcsChangesViewDialog opens a VcsChangesView for given git repository
+func VcsChangesViewDialog(repo vci.Repo) *gi.Dialog {
+	title := "Staged Changes: " + DirAndFile(repo.LocalPath())
+	dlg := gi.NewStdDialog(gi.DlgOpts{Title: title}, gi.NoOk, gi.NoCancel)
+	frame := dlg.Frame()
+	_, prIdx := dlg.PromptWidget(frame)
+
+	cv := frame.InsertNewChild(KiT_VcsChangesView, prIdx+1, "vcschanges").(*VcsChangesView)
+	cv.Viewport = dlg.Embed(gi.KiT_Viewport2D).(*gi.Viewport2D)
+	cv.Config(repo)
+	dlg.SetProp("min-width", units.NewEm(50))
+	dlg.SetProp("min-height", units.NewEm(30))
+	dlg.UpdateEndNoSig(true)
+	dlg.Open(0, 0, nil, nil)
+	return dlg
+}
@@ -0,0 +1,812 @@
+// Copyright (c) 2020, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package giv
+
+import (
+	"fmt"
+	"image/color"
+	"reflect"
+
+	"github.com/goki/gi/gi"
+	"github.com/goki/gi/oswin"
+	"github.com/goki/gi/oswin/key"
+	"github.com/goki/gi/oswin/mimedata"
+	"github.com/goki/gi/oswin/mouse"
+	"github.com/goki/gi/units"
+	"github.com/goki/ki/ints"
+	"github.com/goki/ki/ki"
+	"github.com/goki/ki/kit"
+	"github.com/goki/pi/filecat"
+)
+
+////////////////////////////////////////////////////////////////////////////////////////
+//  TreeProvider
+
+// TreeProvider is a lazy source of hierarchical data for a VirtTreeView.
+// Nodes can be any value that the provider understands, e.g., a key in a
+// database hierarchy or a path on a remote system -- the view only holds
+// onto them and passes them back.  Children of a node are only requested
+// when that node is first opened, so arbitrarily large trees can be browsed
+// without building a ki tree for them first.
+type TreeProvider interface {
+	// Root returns the root node of the tree
+	Root() interface{}
+
+	// HasChildren returns true if given node has, or may have, children --
+	// it determines whether the node can be opened, and should be cheap as
+	// it is called without getting the children
+	HasChildren(node interface{}) bool
+
+	// Children returns the children of given node -- it is only called when
+	// the node is opened, and the result is cached by the view until the
+	// node is refreshed
+	Children(node interface{}) []interface{}
+
+	// Label returns the text to display for given node
+	Label(node interface{}) string
+
+	// Icon returns the icon to display for given node -- can be empty for none
+	Icon(node interface{}) gi.IconName
+}
+
+// KiTreeProvider is a TreeProvider for a ki tree, which allows very large
+// ki trees to be viewed in a VirtTreeView, instead of a TreeView that makes
+// a mirror TreeView node for every node in the tree.
+type KiTreeProvider struct {
+	RootNode ki.Ki `desc:"root of the ki tree to view"`
+}
+
+// Root returns the root ki node
+func (kp *KiTreeProvider) Root() interface{} {
+	return kp.RootNode
+}
+
+// HasChildren returns true if ki node has children
+func (kp *KiTreeProvider) HasChildren(node interface{}) bool {
+	return node.(ki.Ki).HasChildren()
+}
+
+// Children returns the children of the ki node
+func (kp *KiTreeProvider) Children(node interface{}) []interface{} {
+	kids := *node.(ki.Ki).Children()
+	ch := make([]interface{}, len(kids))
+	for i, k := range kids {
+		ch[i] = k
+	}
+	return ch
+}
+
+// Label returns the gi.Labeler label of the ki node if it has one, else its name
+func (kp *KiTreeProvider) Label(node interface{}) string {
+	if lbl := gi.ToLabeler(node); lbl != "" {
+		return lbl
+	}
+	return node.(ki.Ki).Name()
+}
+
+// Icon returns the "icon" property of the ki node, if set
+func (kp *KiTreeProvider) Icon(node interface{}) gi.IconName {
+	if icp := node.(ki.Ki).Prop("icon"); icp != nil {
+		return gi.IconName(kit.ToString(icp))
+	}
+	return ""
+}
+
+////////////////////////////////////////////////////////////////////////////////////////
+//  VirtTreeNode
+
+// VirtTreeNode is a node of the tree shown in a VirtTreeView, holding the
+// provider node and its open state, along with its children once they have
+// been fetched -- the children keep their own open state while their
+// parent is closed, so it is restored when the parent is opened again.
+type VirtTreeNode struct {
+	Node    interface{}     `json:"-" xml:"-" desc:"the provider node"`
+	Par     *VirtTreeNode   `json:"-" xml:"-" desc:"parent node -- nil for the root"`
+	Depth   int             `desc:"depth of the node in the tree -- root is 0"`
+	Open    bool            `desc:"whether the node is open, showing its children"`
+	Fetched bool            `desc:"whether the children have been fetched from the provider"`
+	Kids    []*VirtTreeNode `json:"-" xml:"-" desc:"the children, once fetched"`
+}
+
+////////////////////////////////////////////////////////////////////////////////////////
+//  VirtTreeView
+
+// VirtTreeView is a virtualized tree view, which only creates row widgets
+// for the rows that are visible, out of all the rows of the open nodes of
+// the tree.  The tree comes from a TreeProvider, which is only asked for the
+// children of a node when it is opened, so it can be used for trees that are
+// too large to mirror with a full TreeView, or that are not ki trees at all.
+// Use KiTreeProvider to view a large ki tree.
+// It is built on SliceViewBase in select-only (Inactive) mode, viewing the
+// Rows slice of open nodes, and emits TreeViewSig signals with the provider
+// node as the data, in addition to the standard SliceViewBase signals.
+type VirtTreeView struct {
+	SliceViewBase
+	Provider    TreeProvider    `copy:"-" view:"-" json:"-" xml:"-" desc:"the provider of the tree nodes"`
+	RootNode    *VirtTreeNode   `copy:"-" view:"-" json:"-" xml:"-" desc:"root of the tree"`
+	Rows        []*VirtTreeNode `copy:"-" view:"-" json:"-" xml:"-" desc:"all of the rows of the tree that are currently shown, i.e., the root and the children of open nodes, in order -- this is the slice that is viewed"`
+	OpenDepth   int             `xml:"open-depth" desc:"nodes above this depth are opened when the provider is set -- default is 1, so that only the root is opened"`
+	Indent      units.Value     `xml:"indent" desc:"amount to indent each level of the tree -- set from indent property"`
+	TreeViewSig ki.Signal       `copy:"-" json:"-" xml:"-" desc:"signal for TreeViewSelected, Opened, Closed -- data is the provider node"`
+}
+
+var KiT_VirtTreeView = kit.Types.AddType(&VirtTreeView{}, VirtTreeViewProps)
+
+// AddNewVirtTreeView adds a new virttreeview to given parent node, with given name.
+func AddNewVirtTreeView(parent ki.Ki, name string) *VirtTreeView {
+	vt := parent.AddNewChild(KiT_VirtTreeView, name).(*VirtTreeView)
+	vt.OpenDepth = 1
+	return vt
+}
+
+// check for interface impl
+var _ SliceViewer = (*VirtTreeView)(nil)
+
+func (vt *VirtTreeView) Disconnect() {
+	vt.SliceViewBase.Disconnect()
+	vt.TreeViewSig.DisconnectAll()
+}
+
+var VirtTreeViewProps = ki.Props{
+	"EnumType:Flag":    gi.KiT_NodeFlags,
+	"background-color": &gi.Prefs.Colors.Background,
+	"indent":           units.NewCh(4),
+	"max-width":        -1,
+	"max-height":       -1,
+}
+
+// SetProvider sets the provider of the tree to view, and opens the nodes
+// above OpenDepth
+func (vt *VirtTreeView) SetProvider(tp TreeProvider) {
+	if tp == nil {
+		vt.Provider = nil
+		vt.Slice = nil
+		return
+	}
+	updt := vt.UpdateStart()
+	vt.Provider = tp
+	vt.RootNode = &VirtTreeNode{Node: tp.Root()}
+	vt.openToDepth(vt.RootNode)
+	vt.Rows = vt.appendRows(nil, vt.RootNode)
+	vt.Slice = &vt.Rows
+	vt.SliceNPVal = kit.NonPtrValue(reflect.ValueOf(vt.Slice))
+	vt.SetInactive()
+	vt.NoAdd = true
+	vt.NoDelete = true
+	vt.ShowIndex = false
+	vt.InactKeyNav = true
+	vt.StartIdx = 0
+	vt.SelectedIdx = -1
+	vt.ResetSelectedIdxs()
+	vt.SelectMode = false
+	vt.SetFullReRender()
+	vt.Config()
+	vt.UpdateEnd(updt)
+}
+
+// openToDepth opens given node and its children, recursively, if they are
+// above OpenDepth
+func (vt *VirtTreeView) openToDepth(nd *VirtTreeNode) {
+	if nd.Depth >= vt.OpenDepth || !vt.NodeHasChildren(nd) {
+		return
+	}
+	nd.Open = true
+	vt.fetchKids(nd)
+	for _, k := range nd.Kids {
+		vt.openToDepth(k)
+	}
+}
+
+// fetchKids gets the children of given node from the provider, if not
+// already done
+func (vt *VirtTreeView) fetchKids(nd *VirtTreeNode) {
+	if nd.Fetched {
+		return
+	}
+	ch := vt.Provider.Children(nd.Node)
+	nd.Kids = make([]*VirtTreeNode, len(ch))
+	for i, c := range ch {
+		nd.Kids[i] = &VirtTreeNode{Node: c, Par: nd, Depth: nd.Depth + 1}
+	}
+	nd.Fetched = true
+}
+
+// appendRows appends given node and all of the shown nodes under it to rows
+func (vt *VirtTreeView) appendRows(rows []*VirtTreeNode, nd *VirtTreeNode) []*VirtTreeNode {
+	rows = append(rows, nd)
+	if !nd.Open {
+		return rows
+	}
+	vt.fetchKids(nd)
+	for _, k := range nd.Kids {
+		rows = vt.appendRows(rows, k)
+	}
+	return rows
+}
+
+// NodeHasChildren returns true if given node has children -- asks the
+// provider if the children have not yet been fetched
+func (vt *VirtTreeView) NodeHasChildren(nd *VirtTreeNode) bool {
+	if nd.Fetched {
+		return len(nd.Kids) > 0
+	}
+	return vt.Provider.HasChildren(nd.Node)
+}
+
+// NodeAt returns the tree node at given row index, or nil if out of range
+func (vt *VirtTreeView) NodeAt(idx int) *VirtTreeNode {
+	if idx < 0 || idx >= len(vt.Rows) {
+		return nil
+	}
+	return vt.Rows[idx]
+}
+
+// NodeIdx returns the row index of given tree node, or -1 if not shown
+func (vt *VirtTreeView) NodeIdx(nd *VirtTreeNode) int {
+	for i, r := range vt.Rows {
+		if r == nd {
+			return i
+		}
+	}
+	return -1
+}
+
+// SelectedNode returns the provider node that is selected, or nil if none
+func (vt *VirtTreeView) SelectedNode() interface{} {
+	if nd := vt.NodeAt(vt.SelectedIdx); nd != nil {
+		return nd.Node
+	}
+	return nil
+}
+
+// OpenIdx opens the node at given row index, getting its children from the
+// provider the first time, and shows them in the rows under it
+func (vt *VirtTreeView) OpenIdx(idx int) {
+	nd := vt.NodeAt(idx)
+	if nd == nil || nd.Open || !vt.NodeHasChildren(nd) {
+		return
+	}
+	nd.Open = true
+	vt.fetchKids(nd)
+	var sub []*VirtTreeNode
+	for _, k := range nd.Kids {
+		sub = vt.appendRows(sub, k)
+	}
+	if len(nd.Kids) == 0 { // provider was wrong about children
+		nd.Open = false
+	}
+	if len(sub) > 0 {
+		rows := make([]*VirtTreeNode, 0, len(vt.Rows)+len(sub))
+		rows = append(rows, vt.Rows[:idx+1]...)
+		rows = append(rows, sub...)
+		vt.Rows = append(rows, vt.Rows[idx+1:]...)
+		if vt.SelectedIdx > idx {
+			vt.SelectedIdx += len(sub)
+		}
+	}
+	vt.Update()
+	if nd.Open {
+		vt.TreeViewSig.Emit(vt.This(), int64(TreeViewOpened), nd.Node)
+	}
+}
+
+// CloseIdx closes the node at given row index, removing the rows under it
+func (vt *VirtTreeView) CloseIdx(idx int) {
+	nd := vt.NodeAt(idx)
+	if nd == nil || !nd.Open {
+		return
+	}
+	nd.Open = false
+	ed := idx + 1
+	for ed < len(vt.Rows) && vt.Rows[ed].Depth > nd.Depth {
+		ed++
+	}
+	n := ed - (idx + 1)
+	if n > 0 {
+		vt.Rows = append(vt.Rows[:idx+1], vt.Rows[ed:]...)
+		switch {
+		case vt.SelectedIdx >= ed:
+			vt.SelectedIdx -= n
+		case vt.SelectedIdx > idx:
+			vt.SelectedIdx = idx
+		}
+	}
+	vt.Update()
+	vt.TreeViewSig.Emit(vt.This(), int64(TreeViewClosed), nd.Node)
+}
+
+// ToggleOpenIdx opens the node at given row index if closed, and vice-versa
+func (vt *VirtTreeView) ToggleOpenIdx(idx int) {
+	nd := vt.NodeAt(idx)
+	if nd == nil {
+		return
+	}
+	if nd.Open {
+		vt.CloseIdx(idx)
+	} else {
+		vt.OpenIdx(idx)
+	}
+}
+
+// RefreshIdx discards the children of the node at given row index, and gets
+// them again from the provider if it is open -- use after the data under
+// that node has changed.  The open state of the nodes under it is lost.
+func (vt *VirtTreeView) RefreshIdx(idx int) {
+	nd := vt.NodeAt(idx)
+	if nd == nil {
+		return
+	}
+	wupdt := vt.TopUpdateStart()
+	defer vt.TopUpdateEnd(wupdt)
+	open := nd.Open
+	vt.CloseIdx(idx)
+	nd.Kids = nil
+	nd.Fetched = false
+	if open {
+		vt.OpenIdx(idx)
+	}
+}
+
+// Refresh gets the entire tree again from the provider
+func (vt *VirtTreeView) Refresh() {
+	if vt.Provider == nil {
+		return
+	}
+	vt.SetProvider(vt.Provider)
+}
+
+// SelectNodeIdx selects the node at given row index, scrolling to it as
+// needed, and emits the TreeViewSelected signal
+func (vt *VirtTreeView) SelectNodeIdx(idx int) {
+	nd := vt.NodeAt(idx)
+	if nd == nil {
+		return
+	}
+	vt.ScrollToIdx(idx)
+	vt.UpdateSelectIdx(idx, true)
+	vt.TreeViewSig.Emit(vt.This(), int64(TreeViewSelected), nd.Node)
+}
+
+// MoveRightAction opens the selected node, or selects its first child if it
+// is already open
+func (vt *VirtTreeView) MoveRightAction() {
+	idx := vt.SelectedIdx
+	nd := vt.NodeAt(idx)
+	if nd == nil {
+		return
+	}
+	if !nd.Open {
+		vt.OpenIdx(idx)
+	} else if len(nd.Kids) > 0 {
+		vt.SelectNodeIdx(idx + 1)
+	}
+}
+
+// MoveLeftAction closes the selected node, or selects its parent if it is
+// already closed
+func (vt *VirtTreeView) MoveLeftAction() {
+	idx := vt.SelectedIdx
+	nd := vt.NodeAt(idx)
+	if nd == nil {
+		return
+	}
+	if nd.Open {
+		vt.CloseIdx(idx)
+	} else if nd.Par != nil {
+		for pi := idx - 1; pi >= 0; pi-- {
+			if vt.Rows[pi] == nd.Par {
+				vt.SelectNodeIdx(pi)
+				break
+			}
+		}
+	}
+}
+
+// AcceptIdx toggles the node at given row index if it has children, and
+// otherwise emits the SliceViewDoubleClicked signal for it
+func (vt *VirtTreeView) AcceptIdx(idx int) {
+	nd := vt.NodeAt(idx)
+	if nd == nil {
+		return
+	}
+	if vt.NodeHasChildren(nd) {
+		vt.ToggleOpenIdx(idx)
+		return
+	}
+	vt.SliceViewSig.Emit(vt.This(), int64(SliceViewDoubleClicked), idx)
+}
+
+//////////////////////////////////////////////////////////////////////////////
+//  SliceViewer interface
+
+// Config configures the view
+func (vt *VirtTreeView) Config() {
+	vt.Lay = gi.LayoutVert
+	vt.SetProp("spacing", gi.StdDialogVSpaceUnits)
+	config := kit.TypeAndNameList{}
+	config.Add(gi.KiT_ToolBar, "toolbar")
+	config.Add(gi.KiT_Layout, "grid-lay")
+	mods, updt := vt.ConfigChildren(config, ki.UniqueNames)
+
+	gl := vt.GridLayout()
+	gl.Lay = gi.LayoutHoriz
+	gl.SetStretchMax() // for this to work, ALL layers above need it too
+	gconfig := kit.TypeAndNameList{}
+	gconfig.Add(gi.KiT_Frame, "grid")
+	gconfig.Add(gi.KiT_ScrollBar, "scrollbar")
+	gl.ConfigChildren(gconfig, ki.UniqueNames) // covered by above
+
+	vt.ConfigSliceGrid()
+	if mods {
+		vt.SetFullReRender()
+		vt.UpdateEnd(updt)
+	}
+}
+
+// ConfigSliceGrid configures the grid of rows, with one dummy row to get
+// the row height
+func (vt *VirtTreeView) ConfigSliceGrid() {
+	sg := vt.SliceGrid()
+	updt := sg.UpdateStart()
+	defer sg.UpdateEnd(updt)
+
+	sg.Lay = gi.LayoutGrid
+	sg.Stripes = gi.NoStripes
+	sg.SetProp("columns", 1)
+	// setting a pref here is key for giving it a scrollbar in larger context
+	sg.SetMinPrefHeight(units.NewEm(1.5))
+	sg.SetMinPrefWidth(units.NewEm(10))
+	sg.SetStretchMax() // for this to work, ALL layers above need it too
+
+	if vt.Provider == nil {
+		return
+	}
+	sz := vt.UpdtSliceSize()
+	if sz == 0 {
+		return
+	}
+	sg.DeleteChildren(ki.DestroyKids)
+	sg.Kids = make(ki.Slice, 1)
+	rw := vt.newRow(sg, 0)
+	vt.updtRow(rw, vt.Rows[0], false)
+	vt.ConfigScroll()
+}
+
+// RowWidgetNs returns number of widgets per row and offset for index label
+// -- each row is a single layout, with no index
+func (vt *VirtTreeView) RowWidgetNs() (nWidgPerRow, idxOff int) {
+	return 1, 0
+}
+
+// UpdtSliceSize updates and returns the number of rows shown
+func (vt *VirtTreeView) UpdtSliceSize() int {
+	vt.SliceNPVal = kit.NonPtrValue(reflect.ValueOf(vt.Slice))
+	vt.SliceSize = len(vt.Rows)
+	return vt.SliceSize
+}
+
+// newRow makes a new row layout, with the indent, branch, icon and label,
+// at given display row in the grid
+func (vt *VirtTreeView) newRow(sg *gi.Frame, row int) *gi.Layout {
+	rw := &gi.Layout{}
+	sg.SetChild(rw, row, fmt.Sprintf("row-%05d", row))
+	rw.Lay = gi.LayoutHoriz
+	rw.SetProp("spacing", units.NewCh(.5))
+	rw.SetProp("vtv-row", row) // all sigs deal with disp rows
+	rw.SetProp("vtv-depth", -1)
+	rw.SetStretchMaxWidth()
+
+	sp := gi.AddNewSpace(rw, "indent")
+	sp.SetFixedWidth(units.NewPx(0))
+
+	br := gi.AddNewCheckBox(rw, "branch")
+	br.SetProp("icon", "wedge-down")
+	br.SetProp("icon-off", "wedge-right")
+	br.SetProp("#icon0", TVBranchProps)
+	br.SetProp("#icon1", TVBranchProps)
+	br.SetProp("no-focus", true)
+	br.SetProp("margin", units.NewPx(0))
+	br.SetProp("padding", units.NewPx(0))
+	br.SetProp("background-color", color.Transparent)
+	br.SetProp("max-width", units.NewEm(.8))
+	br.SetProp("max-height", units.NewEm(.8))
+	br.SetProp("vtv-row", row)
+	br.ButtonSig.ConnectOnly(vt.This(), func(recv, send ki.Ki, sig int64, data interface{}) {
+		if sig == int64(gi.ButtonToggled) {
+			bb := send.(*gi.CheckBox)
+			vtv := recv.Embed(KiT_VirtTreeView).(*VirtTreeView)
+			vtv.ToggleOpenIdx(vtv.StartIdx + bb.Prop("vtv-row").(int))
+		}
+	})
+
+	ic := gi.AddNewIcon(rw, "icon", "")
+	ic.SetProp("width", units.NewEm(1))
+	ic.SetProp("height", units.NewEm(1))
+	ic.SetProp("fill", &gi.Prefs.Colors.Icon)
+	ic.SetProp("stroke", &gi.Prefs.Colors.Font)
+
+	lbl := gi.AddNewLabel(rw, "label", "")
+	lbl.SetProp("min-width", units.NewCh(16))
+	lbl.SetProp("vtv-row", row)
+	lbl.SetStretchMaxWidth()
+	lbl.Selectable = true
+	lbl.Redrawable = true
+	lbl.WidgetSig.ConnectOnly(vt.This(), func(recv, send ki.Ki, sig int64, data interface{}) {
+		if sig == int64(gi.WidgetSelected) {
+			wbb := send.(gi.Node2D).AsWidget()
+			vtv := recv.Embed(KiT_VirtTreeView).(*VirtTreeView)
+			vtv.SelectNodeIdx(vtv.StartIdx + wbb.Prop("vtv-row").(int))
+		}
+	})
+	return rw
+}
+
+// updtRow updates given row layout to show given tree node -- returns true
+// if the indent changed, which needs a new layout
+func (vt *VirtTreeView) updtRow(rw *gi.Layout, nd *VirtTreeNode, issel bool) bool {
+	relay := false
+	if rw.Prop("vtv-depth").(int) != nd.Depth {
+		rw.SetProp("vtv-depth", nd.Depth)
+		sp := rw.Child(0).(*gi.Space)
+		sp.SetFixedWidth(units.NewValue(vt.Indent.Val*float32(nd.Depth), vt.Indent.Un))
+		relay = true
+	}
+	br := rw.Child(1).(*gi.CheckBox)
+	if vt.NodeHasChildren(nd) {
+		br.ClearInvisible()
+		br.SetChecked(nd.Open)
+	} else {
+		br.SetInvisible()
+	}
+	ic := rw.Child(2).(*gi.Icon)
+	ic.SetIcon(string(vt.Provider.Icon(nd.Node)))
+	lbl := rw.Child(3).(*gi.Label)
+	lbl.CurBgColor = gi.Prefs.Colors.Background
+	lbl.SetText(vt.Provider.Label(nd.Node))
+	lbl.SetSelectedState(issel)
+	return relay
+}
+
+// UpdateSliceGrid updates the rows to show the tree nodes from StartIdx
+func (vt *VirtTreeView) UpdateSliceGrid() {
+	if vt.Provider == nil {
+		return
+	}
+	sz := vt.UpdtSliceSize()
+	if sz == 0 {
+		return
+	}
+	sg := vt.SliceGrid()
+	vt.DispRows = ints.MinInt(vt.SliceSize, vt.VisRows)
+
+	wupdt := vt.TopUpdateStart()
+	defer vt.TopUpdateEnd(wupdt)
+
+	updt := sg.UpdateStart()
+	defer sg.UpdateEnd(updt)
+
+	if vt.Values == nil || sg.NumChildren() != vt.DispRows { // shouldn't happen..
+		vt.LayoutSliceGrid()
+	}
+
+	if sz > vt.DispRows {
+		sb := vt.ScrollBar()
+		vt.StartIdx = int(sb.Value)
+		lastSt := sz - vt.DispRows
+		vt.StartIdx = ints.MinInt(lastSt, vt.StartIdx)
+		vt.StartIdx = ints.MaxInt(0, vt.StartIdx)
+	} else {
+		vt.StartIdx = 0
+	}
+
+	relay := false
+	for i := 0; i < vt.DispRows; i++ {
+		si := vt.StartIdx + i
+		var rw *gi.Layout
+		if sg.Kids[i] != nil {
+			rw = sg.Kids[i].(*gi.Layout)
+		} else {
+			rw = vt.newRow(sg, i)
+			relay = true
+		}
+		if vt.updtRow(rw, vt.Rows[si], si == vt.SelectedIdx) {
+			relay = true
+		}
+	}
+	if relay {
+		vt.SetFullReRender()
+	}
+	vt.UpdateScroll()
+}
+
+// StyleRow is not used, as rows are styled from the provider
+func (vt *VirtTreeView) StyleRow(svnp reflect.Value, widg gi.Node2D, idx, fidx int, vv ValueView) {
+}
+
+// RowFirstWidget returns the row layout for given display row
+func (vt *VirtTreeView) RowFirstWidget(row int) (*gi.WidgetBase, bool) {
+	if !vt.IsRowInBounds(row) {
+		return nil, false
+	}
+	sg := vt.SliceGrid()
+	if sg.Kids.IsValidIndex(row) != nil || sg.Kids[row] == nil {
+		return nil, false
+	}
+	return sg.Kids[row].(gi.Node2D).AsWidget(), true
+}
+
+// RowGrabFocus does not grab the focus, which stays on the view for key
+// navigation, and just returns the row layout for given display row
+func (vt *VirtTreeView) RowGrabFocus(row int) *gi.WidgetBase {
+	widg, _ := vt.RowFirstWidget(row)
+	return widg
+}
+
+// SelectRowWidgets sets the selection state of the label of given display row
+func (vt *VirtTreeView) SelectRowWidgets(row int, sel bool) {
+	rw, ok := vt.RowFirstWidget(row)
+	if !ok {
+		return
+	}
+	wupdt := vt.TopUpdateStart()
+	lbl := rw.Child(3).(*gi.Label)
+	lbl.SetSelectedState(sel)
+	lbl.UpdateSig()
+	vt.TopUpdateEnd(wupdt)
+}
+
+// SliceNewAt does nothing -- the tree cannot be edited
+func (vt *VirtTreeView) SliceNewAt(idx int) {
+}
+
+// SliceDeleteAt does nothing -- the tree cannot be edited
+func (vt *VirtTreeView) SliceDeleteAt(idx int, updt bool) {
+}
+
+// MimeDataType returns the data type for mime clipboard data, which is
+// the text of the labels
+func (vt *VirtTreeView) MimeDataType() string {
+	return filecat.TextPlain
+}
+
+// CopySelToMime copies the label of the selected node to mime data
+func (vt *VirtTreeView) CopySelToMime() mimedata.Mimes {
+	nd := vt.NodeAt(vt.SelectedIdx)
+	if nd == nil {
+		return nil
+	}
+	return mimedata.NewText(vt.Provider.Label(nd.Node))
+}
+
+// CopySel copies the label of the selected node to the clipboard
+func (vt *VirtTreeView) CopySel() {
+	md := vt.CopySelToMime()
+	if md != nil {
+		oswin.TheApp.ClipBoard(vt.Viewport.Win.OSWin).Write(md)
+	}
+}
+
+// PasteAssign does nothing -- the tree cannot be edited
+func (vt *VirtTreeView) PasteAssign(md mimedata.Mimes, idx int) {
+}
+
+// PasteAtIdx does nothing -- the tree cannot be edited
+func (vt *VirtTreeView) PasteAtIdx(md mimedata.Mimes, idx int) {
+}
+
+// ItemCtxtMenu pulls up the context menu for the node at given row index
+func (vt *VirtTreeView) ItemCtxtMenu(idx int) {
+	nd := vt.NodeAt(idx)
+	if nd == nil {
+		return
+	}
+	var men gi.Menu
+	if vt.NodeHasChildren(nd) {
+		lbl := "Open"
+		if nd.Open {
+			lbl = "Close"
+		}
+		men.AddAction(gi.ActOpts{Label: lbl, Data: idx},
+			vt.This(), func(recv, send ki.Ki, sig int64, data interface{}) {
+				vtv := recv.Embed(KiT_VirtTreeView).(*VirtTreeView)
+				vtv.ToggleOpenIdx(data.(int))
+			})
+	}
+	men.AddAction(gi.ActOpts{Label: "Refresh", Data: idx},
+		vt.This(), func(recv, send ki.Ki, sig int64, data interface{}) {
+			vtv := recv.Embed(KiT_VirtTreeView).(*VirtTreeView)
+			vtv.RefreshIdx(data.(int))
+		})
+	men.AddAction(gi.ActOpts{Label: "Copy", Data: idx},
+		vt.This(), func(recv, send ki.Ki, sig int64, data interface{}) {
+			vtv := recv.Embed(KiT_VirtTreeView).(*VirtTreeView)
+			vtv.CopySel()
+		})
+	pos := vt.IdxPos(idx)
+	gi.PopupMenu(men, pos.X, pos.Y, vt.Viewport, vt.Nm+"-menu")
+}
+
+//////////////////////////////////////////////////////////////////////////////
+//  Events
+
+func (vt *VirtTreeView) KeyInput(kt *key.ChordEvent) {
+	if gi.KeyEventTrace {
+		fmt.Printf("VirtTreeView KeyInput: %v\n", vt.PathUnique())
+	}
+	kf := gi.KeyFun(kt.Chord())
+	idx := vt.SelectedIdx
+	switch kf {
+	case gi.KeyFunMoveDown:
+		if idx+1 < vt.SliceSize {
+			vt.SelectNodeIdx(idx + 1)
+		}
+		kt.SetProcessed()
+	case gi.KeyFunMoveUp:
+		if idx > 0 {
+			vt.SelectNodeIdx(idx - 1)
+		}
+		kt.SetProcessed()
+	case gi.KeyFunPageDown:
+		vt.SelectNodeIdx(ints.MinInt(idx+vt.VisRows-1, vt.SliceSize-1))
+		kt.SetProcessed()
+	case gi.KeyFunPageUp:
+		vt.SelectNodeIdx(ints.MaxInt(idx-(vt.VisRows-1), 0))
+		kt.SetProcessed()
+	case gi.KeyFunHome:
+		vt.SelectNodeIdx(0)
+		kt.SetProcessed()
+	case gi.KeyFunEnd:
+		vt.SelectNodeIdx(vt.SliceSize - 1)
+		kt.SetProcessed()
+	case gi.KeyFunMoveRight:
+		vt.MoveRightAction()
+		kt.SetProcessed()
+	case gi.KeyFunMoveLeft:
+		vt.MoveLeftAction()
+		kt.SetProcessed()
+	case gi.KeyFunCopy:
+		vt.CopySel()
+		kt.SetProcessed()
+	case gi.KeyFunEnter, gi.KeyFunAccept:
+		vt.AcceptIdx(idx)
+		kt.SetProcessed()
+	}
+}
+
+func (vt *VirtTreeView) VirtTreeViewEvents() {
+	vt.SliceViewBaseEvents()
+	// replaces the SliceViewBase handlers
+	vt.ConnectEvent(oswin.MouseEvent, gi.LowRawPri, func(recv, send ki.Ki, sig int64, d interface{}) {
+		me := d.(*mouse.Event)
+		vtv := recv.Embed(KiT_VirtTreeView).(*VirtTreeView)
+		if me.Button == mouse.Left && me.Action == mouse.DoubleClick {
+			vtv.AcceptIdx(vtv.SelectedIdx)
+			me.SetProcessed()
+		}
+		if me.Button == mouse.Right && me.Action == mouse.Release {
+			vtv.ItemCtxtMenu(vtv.SelectedIdx)
+			me.SetProcessed()
+		}
+	})
+	vt.ConnectEvent(oswin.KeyChordEvent, gi.RegPri, func(recv, send ki.Ki, sig int64, d interface{}) {
+		vtv := recv.Embed(KiT_VirtTreeView).(*VirtTreeView)
+		kt := d.(*key.ChordEvent)
+		vtv.KeyInput(kt)
+	})
+}
+
+func (vt *VirtTreeView) ConnectEvents2D() {
+	vt.VirtTreeViewEvents()
+}
+
+func (vt *VirtTreeView) Style2D() {
+	vt.Indent.SetFmInheritProp("indent", vt.This(), ki.NoInherit, ki.TypeProps)
+	if val, has := vt.Props["open-depth"]; has {
+		if iv, ok := kit.ToInt(val); ok {
+			vt.OpenDepth = int(iv)
+		}
+	}
+	vt.SliceViewBase.Style2D()
+}
@@ -0,0 +1,129 @@
+// Copyright (c) 2020, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package giv_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/goki/gi/gi"
+	"github.com/goki/gi/giv"
+)
+
+// testTreeProvider is a TreeProvider of string nodes, whose children are
+// given by a map, and which records the nodes whose children were asked for
+type testTreeProvider struct {
+	kids    map[string][]string
+	fetched []string
+}
+
+func newTestTreeProvider() *testTreeProvider {
+	return &testTreeProvider{kids: map[string][]string{
+		"root": {"a", "b", "c"},
+		"a":    {"a0", "a1"},
+		"a0":   {"a00"},
+		"b":    {}, // says it has children, but has none
+	}}
+}
+
+func (tp *testTreeProvider) Root() interface{} { return "root" }
+
+func (tp *testTreeProvider) HasChildren(node interface{}) bool {
+	_, has := tp.kids[node.(string)]
+	return has
+}
+
+func (tp *testTreeProvider) Children(node interface{}) []interface{} {
+	tp.fetched = append(tp.fetched, node.(string))
+	var ch []interface{}
+	for _, k := range tp.kids[node.(string)] {
+		ch = append(ch, k)
+	}
+	return ch
+}
+
+func (tp *testTreeProvider) Label(node interface{}) string {
+	return strings.ToUpper(node.(string))
+}
+
+func (tp *testTreeProvider) Icon(node interface{}) gi.IconName { return "" }
+
+// virtTreeRows returns the nodes of the rows shown in the view
+func virtTreeRows(vt *giv.VirtTreeView) string {
+	nds := make([]string, len(vt.Rows))
+	for i, r := range vt.Rows {
+		nds[i] = r.Node.(string)
+	}
+	return strings.Join(nds, " ")
+}
+
+func TestVirtTreeView(t *testing.T) {
+	tp := newTestTreeProvider()
+	var vt *giv.VirtTreeView
+	h := newTestWindow(t, func(mfr *gi.Frame) {
+		vt = giv.AddNewVirtTreeView(mfr, "vt")
+		vt.SetProvider(tp)
+	})
+	defer h.Close()
+
+	check := func(what, rows string, sel int, fetched ...string) {
+		t.Helper()
+		if got := virtTreeRows(vt); got != rows {
+			t.Errorf("%v: rows: %q != %q", what, got, rows)
+		}
+		if vt.SliceSize != len(vt.Rows) {
+			t.Errorf("%v: SliceSize %d != %d rows", what, vt.SliceSize, len(vt.Rows))
+		}
+		if vt.SelectedIdx != sel {
+			t.Errorf("%v: SelectedIdx %d != %d", what, vt.SelectedIdx, sel)
+		}
+		if !reflect.DeepEqual(tp.fetched, fetched) {
+			t.Errorf("%v: children fetched for %v, want %v", what, tp.fetched, fetched)
+		}
+	}
+	check("set provider", "root a b c", -1, "root")
+
+	// opening inserts the children under the node, fetching them the first
+	// time, and shifts the selection after it
+	vt.SelectNodeIdx(3) // c
+	vt.OpenIdx(1)       // a
+	check("open a", "root a a0 a1 b c", 5, "root", "a")
+	vt.OpenIdx(2) // a0
+	check("open a0", "root a a0 a00 a1 b c", 6, "root", "a", "a0")
+
+	// closing removes all of the rows under the node, and keeps the
+	// selection on the same node
+	vt.CloseIdx(1) // a
+	check("close a", "root a b c", 3, "root", "a", "a0")
+
+	// opening again does not fetch again, and shows the nodes that were open
+	vt.OpenIdx(1)
+	check("open a again", "root a a0 a00 a1 b c", 6, "root", "a", "a0")
+
+	// closing a node with the selection in it selects the node
+	vt.SelectNodeIdx(3) // a00
+	vt.CloseIdx(1)
+	check("close a with a00 selected", "root a b c", 1, "root", "a", "a0")
+
+	// a node without children after all stays closed
+	vt.OpenIdx(2) // b
+	check("open b", "root a b c", 1, "root", "a", "a0", "b")
+	if vt.Rows[2].Open {
+		t.Errorf("b without children is open")
+	}
+
+	// refreshing fetches again, closing the node, and opening a node that
+	// is not shown, or does not have children, does nothing
+	vt.RefreshIdx(0)
+	check("refresh root", "root a b c", 0, "root", "a", "a0", "b", "root")
+	vt.OpenIdx(3) // c
+	vt.OpenIdx(10)
+	vt.CloseIdx(3)
+	check("open c", "root a b c", 0, "root", "a", "a0", "b", "root")
+	if got := vt.SelectedNode(); got != "root" {
+		t.Errorf("SelectedNode: %v != root", got)
+	}
+}
@@ -157,6 +157,23 @@ func (a Mat2) ExtractScale() (scx, scy float32) {
 	return scxv.X, scyv.Y
 }
 
+// Inverse returns the inverse of the matrix, which undoes its transform
+// -- returns the identity if the matrix is not invertible
+func (a Mat2) Inverse() Mat2 {
+	det := a.XX*a.YY - a.XY*a.YX
+	if det == 0 {
+		return Identity2D()
+	}
+	inv := Mat2{
+		a.YY / det, -a.YX / det,
+		-a.XY / det, a.XX / det,
+		0, 0,
+	}
+	inv.X0 = -(inv.XX*a.X0 + inv.XY*a.Y0)
+	inv.Y0 = -(inv.YX*a.X0 + inv.YY*a.Y0)
+	return inv
+}
+
 // ParseFloat32 logs any strconv.ParseFloat errors
 func ParseFloat32(pstr string) (float32, error) {
 	r, err := strconv.ParseFloat(pstr, 32)
@@ -23,7 +23,10 @@
 // designed to be called by the program's main function.  There can
 // be multiple different drivers, but currently OpenGL on top of
 // the glfw cross-platform library (i.e., the glos driver) is
-// the only one supported.  See internal/*driver for older
+// the only one supported for actual displays.  The offscreen driver
+// renders purely in memory without any GPU, for testing and other
+// headless uses -- build with the offscreen tag to select it in place
+// of glos.  See internal/*driver for older
 // shiny-based drivers that are completely OS-specific and do not
 // require cgo for Windows and X11 platforms (but do require it for mac).
 // These older drivers are no longer compatible with the current GPU-based
@@ -0,0 +1,422 @@
+// Copyright 2020 The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package offscreen
+
+import (
+	"fmt"
+	"image"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+
+	"github.com/goki/gi/oswin"
+	"github.com/goki/gi/oswin/clip"
+	"github.com/goki/gi/oswin/cursor"
+	"github.com/goki/gi/oswin/window"
+	"github.com/goki/ki/bitflag"
+)
+
+var theApp = &appImpl{
+	winlist:      make([]*windowImpl, 0),
+	screens:      make([]*oswin.Screen, 0),
+	name:         "GoGi",
+	quitCloseCnt: make(chan struct{}),
+}
+
+type appImpl struct {
+	mu            sync.Mutex
+	mainQueue     chan funcRun
+	mainDone      chan struct{}
+	stopOnce      sync.Once
+	winlist       []*windowImpl
+	screens       []*oswin.Screen
+	noScreens     bool        // if all screens have been removed, don't do anything..
+	ctxtwin       *windowImpl // context window, dynamically set, for e.g., pointer and other methods
+	name          string
+	about         string
+	prefsDir      string
+	platform      oswin.Platforms
+	platformSet   bool
+	urls          []string      // record of OpenURL calls, for testing
+	quitting      int32         // set to 1 when quitting and closing windows -- accessed atomically
+	quitCloseCnt  chan struct{} // counts windows to make sure all are closed before done
+	quitReqFunc   func()
+	quitCleanFunc func()
+}
+
+var mainCallback func(oswin.App)
+
+// Main is called from main thread when it is time to start running the
+// main loop.  When function f returns, the app ends automatically.
+// Any screens configured with AddScreen prior to calling Main are used --
+// otherwise a single DefaultScreen is created.
+func Main(f func(oswin.App)) {
+	mainCallback = f
+	theApp.initScreens()
+	oswin.TheApp = theApp
+	theApp.mainQueue = make(chan funcRun)
+	theApp.mainDone = make(chan struct{})
+	go func() {
+		mainCallback(theApp)
+		theApp.stopMain()
+	}()
+	theApp.mainLoop()
+}
+
+type funcRun struct {
+	f    func()
+	done chan bool
+}
+
+// RunOnMain runs given function on main thread
+func (app *appImpl) RunOnMain(f func()) {
+	if app.mainQueue == nil {
+		f()
+		return
+	}
+	done := make(chan bool)
+	select {
+	case app.mainQueue <- funcRun{f: f, done: done}:
+		<-done
+	case <-app.mainDone: // main loop has exited -- just run it here
+		f()
+	}
+}
+
+// GoRunOnMain runs given function on main thread and returns immediately
+func (app *appImpl) GoRunOnMain(f func()) {
+	go func() {
+		select {
+		case app.mainQueue <- funcRun{f: f, done: nil}:
+		case <-app.mainDone:
+		}
+	}()
+}
+
+// SendEmptyEvent sends an empty, blank event to global event processing
+// system -- there is no global event loop here, so it is sent to
+// the context window if there is one.
+func (app *appImpl) SendEmptyEvent() {
+	app.mu.Lock()
+	cw := app.ctxtwin
+	app.mu.Unlock()
+	if cw != nil {
+		cw.SendEmptyEvent()
+	}
+}
+
+// PollEvents is a no-op: all events come from Send calls on the
+// windows, so there is nothing to poll for.
+func (app *appImpl) PollEvents() {
+}
+
+// mainLoop runs functions sent to the main thread until stopMain is called.
+func (app *appImpl) mainLoop() {
+	for {
+		select {
+		case <-app.mainDone:
+			return
+		case f := <-app.mainQueue:
+			f.f()
+			if f.done != nil {
+				f.done <- true
+			}
+		}
+	}
+}
+
+// stopMain stops the main loop and thus terminates the app.
+// Safe to call multiple times.
+func (app *appImpl) stopMain() {
+	app.stopOnce.Do(func() {
+		close(app.mainDone)
+	})
+}
+
+////////////////////////////////////////////////////////
+//  Window
+
+func (app *appImpl) NewWindow(opts *oswin.NewWindowOptions) (oswin.Window, error) {
+	if len(app.winlist) == 0 && oswin.InitScreenLogicalDPIFunc != nil {
+		oswin.InitScreenLogicalDPIFunc()
+	}
+	if app.noScreens || len(app.screens) == 0 {
+		return nil, fmt.Errorf("offscreen NewWindow: no screens available")
+	}
+
+	if opts == nil {
+		opts = &oswin.NewWindowOptions{}
+	}
+	sc := app.screenAt(opts.Pos)
+	opts.FixupScreen(sc)
+
+	w := &windowImpl{
+		app:      app,
+		scrn:     sc,
+		runQueue: make(chan funcRun),
+		winClose: make(chan struct{}),
+		WindowBase: oswin.WindowBase{
+			Titl:        opts.GetTitle(),
+			Flag:        opts.Flags,
+			Pos:         opts.Pos,
+			DevPixRatio: sc.DevicePixelRatio,
+			PhysDPI:     sc.PhysicalDPI,
+			LogDPI:      sc.LogicalDPI,
+		},
+	}
+	w.setGeom(opts.Size)
+	w.winTex = newTexture(w.PxSize)
+	w.winTex.name = "WinTex"
+
+	bitflag.SetAtomic(&w.Flag, int(oswin.Focus)) // starts out focused
+
+	app.mu.Lock()
+	for _, ow := range app.winlist {
+		bitflag.ClearAtomic(&ow.Flag, int(oswin.Focus))
+	}
+	app.winlist = append(app.winlist, w)
+	app.ctxtwin = w
+	app.mu.Unlock()
+
+	go w.winLoop()
+
+	w.sendWindowEvent(window.Paint)
+	w.sendWindowEvent(window.Paint)
+
+	return w, nil
+}
+
+// screenAt returns the screen whose geometry contains given position, or
+// the first screen if none does
+func (app *appImpl) screenAt(pos image.Point) *oswin.Screen {
+	app.mu.Lock()
+	defer app.mu.Unlock()
+	for _, sc := range app.screens {
+		if pos.In(sc.Geometry) {
+			return sc
+		}
+	}
+	return app.screens[0]
+}
+
+func (app *appImpl) DeleteWin(w *windowImpl) {
+	app.mu.Lock()
+	defer app.mu.Unlock()
+	for i, wl := range app.winlist {
+		if wl == w {
+			app.winlist = append(app.winlist[:i], app.winlist[i+1:]...)
+			break
+		}
+	}
+	if app.ctxtwin == w {
+		app.ctxtwin = nil
+		if len(app.winlist) > 0 {
+			app.ctxtwin = app.winlist[len(app.winlist)-1]
+		}
+	}
+}
+
+func (app *appImpl) NScreens() int {
+	app.mu.Lock()
+	defer app.mu.Unlock()
+	return len(app.screens)
+}
+
+func (app *appImpl) Screen(scrN int) *oswin.Screen {
+	app.mu.Lock()
+	defer app.mu.Unlock()
+	sz := len(app.screens)
+	if scrN < sz {
+		return app.screens[scrN]
+	}
+	return nil
+}
+
+func (app *appImpl) ScreenByName(name string) *oswin.Screen {
+	app.mu.Lock()
+	defer app.mu.Unlock()
+	for _, sc := range app.screens {
+		if sc.Name == name {
+			return sc
+		}
+	}
+	return nil
+}
+
+func (app *appImpl) NoScreens() bool {
+	return app.noScreens
+}
+
+func (app *appImpl) NWindows() int {
+	app.mu.Lock()
+	defer app.mu.Unlock()
+	return len(app.winlist)
+}
+
+func (app *appImpl) Window(win int) oswin.Window {
+	app.mu.Lock()
+	defer app.mu.Unlock()
+	sz := len(app.winlist)
+	if win < sz {
+		return app.winlist[win]
+	}
+	return nil
+}
+
+func (app *appImpl) WindowByName(name string) oswin.Window {
+	app.mu.Lock()
+	defer app.mu.Unlock()
+	for _, win := range app.winlist {
+		if win.Name() == name {
+			return win
+		}
+	}
+	return nil
+}
+
+func (app *appImpl) WindowInFocus() oswin.Window {
+	app.mu.Lock()
+	defer app.mu.Unlock()
+	for _, win := range app.winlist {
+		if win.IsFocus() {
+			return win
+		}
+	}
+	return nil
+}
+
+func (app *appImpl) ContextWindow() oswin.Window {
+	app.mu.Lock()
+	cw := app.ctxtwin
+	app.mu.Unlock()
+	return cw
+}
+
+func (app *appImpl) NewTexture(win oswin.Window, size image.Point) oswin.Texture {
+	return newTexture(size)
+}
+
+func (app *appImpl) Name() string {
+	return app.name
+}
+
+func (app *appImpl) SetName(name string) {
+	app.name = name
+}
+
+func (app *appImpl) About() string {
+	return app.about
+}
+
+func (app *appImpl) SetAbout(about string) {
+	app.about = about
+}
+
+func (app *appImpl) Platform() oswin.Platforms {
+	return app.platform
+}
+
+// OpenURL just records the url -- nothing is actually opened.
+// See OpenedURLs.
+func (app *appImpl) OpenURL(url string) {
+	app.mu.Lock()
+	app.urls = append(app.urls, url)
+	app.mu.Unlock()
+}
+
+// PrefsDir returns a temporary directory unless set by SetPrefsDir,
+// so that running offscreen never touches the user's actual preferences.
+func (app *appImpl) PrefsDir() string {
+	if app.prefsDir == "" {
+		app.prefsDir = filepath.Join(os.TempDir(), "GoGiOffscreen")
+	}
+	return app.prefsDir
+}
+
+func (app *appImpl) GoGiPrefsDir() string {
+	pdir := filepath.Join(app.PrefsDir(), "GoGi")
+	os.MkdirAll(pdir, 0755)
+	return pdir
+}
+
+func (app *appImpl) AppPrefsDir() string {
+	pdir := filepath.Join(app.PrefsDir(), app.Name())
+	os.MkdirAll(pdir, 0755)
+	return pdir
+}
+
+func (app *appImpl) FontPaths() []string {
+	return platformFontPaths()
+}
+
+func (app *appImpl) ClipBoard(win oswin.Window) clip.Board {
+	app.setCtxtWin(win)
+	return &theClip
+}
+
+func (app *appImpl) Cursor(win oswin.Window) cursor.Cursor {
+	app.setCtxtWin(win)
+	return &theCursor
+}
+
+func (app *appImpl) setCtxtWin(win oswin.Window) {
+	if win == nil {
+		return
+	}
+	if w, ok := win.(*windowImpl); ok {
+		app.mu.Lock()
+		app.ctxtwin = w
+		app.mu.Unlock()
+	}
+}
+
+func (app *appImpl) SetQuitReqFunc(fun func()) {
+	app.quitReqFunc = fun
+}
+
+func (app *appImpl) SetQuitCleanFunc(fun func()) {
+	app.quitCleanFunc = fun
+}
+
+func (app *appImpl) QuitReq() {
+	if app.IsQuitting() {
+		return
+	}
+	if app.quitReqFunc != nil {
+		app.quitReqFunc()
+	} else {
+		app.Quit()
+	}
+}
+
+func (app *appImpl) IsQuitting() bool {
+	return atomic.LoadInt32(&app.quitting) != 0
+}
+
+func (app *appImpl) QuitClean() {
+	atomic.StoreInt32(&app.quitting, 1)
+	if app.quitCleanFunc != nil {
+		app.quitCleanFunc()
+	}
+	app.mu.Lock()
+	nwin := len(app.winlist)
+	for i := nwin - 1; i >= 0; i-- {
+		win := app.winlist[i]
+		go win.Close()
+	}
+	app.mu.Unlock()
+	for i := 0; i < nwin; i++ {
+		<-app.quitCloseCnt
+	}
+}
+
+func (app *appImpl) Quit() {
+	if app.IsQuitting() {
+		return
+	}
+	app.QuitClean()
+	app.stopMain()
+}
@@ -0,0 +1,23 @@
+// Copyright 2020 The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package offscreen provides a pure-Go oswin driver that renders into
+// in-memory images, with no dependence on glfw, X11 or OpenGL.  It is
+// used for running gi code in tests and on build machines without a
+// display, and for generating images of gui elements offscreen.
+//
+// Select it by building with the offscreen tag (go test -tags offscreen),
+// which makes driver.Main (and thus gimain.Main) use this driver, or call
+// offscreen.Main directly.
+//
+// Screens are configured by calling AddScreen prior to Main -- otherwise a
+// single screen of DefaultScreenSize and DefaultScreenDPI is created.
+// The clipboard is an in-memory buffer shared by all windows, and the cursor
+// only records its state.  Events are only generated in response to
+// window-level actions (resize, focus, close, etc) -- all input events
+// must be injected by calling Send on the Window.  The last published
+// frame of a window is available via WindowImage.
+//
+// There is no GPU available, so gpu.TheGPU is nil and gi3d cannot be used.
+package offscreen
@@ -0,0 +1,211 @@
+// Copyright 2020 The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package offscreen
+
+import (
+	"image"
+	"image/color"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/goki/gi/oswin"
+	"github.com/goki/gi/oswin/window"
+)
+
+func TestMain(m *testing.M) {
+	AddScreen("Left", image.Point{1920, 1080}, 96, 1)
+	AddScreen("Right", image.Point{2560, 1440}, 192, 2)
+	SetPrefsDir(os.TempDir())
+	code := 0
+	Main(func(a oswin.App) {
+		code = m.Run()
+	})
+	os.Exit(code)
+}
+
+// newTestWin opens a new window with given options
+func newTestWin(t *testing.T, opts *oswin.NewWindowOptions) oswin.Window {
+	t.Helper()
+	win, err := oswin.TheApp.NewWindow(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return win
+}
+
+// nextWindowEvent returns the next window event of given window, skipping
+// any other events
+func nextWindowEvent(t *testing.T, win oswin.Window) *window.Event {
+	t.Helper()
+	for {
+		ev, ok := win.PollEvent()
+		if !ok {
+			return nil
+		}
+		if we, ok := ev.(*window.Event); ok {
+			return we
+		}
+	}
+}
+
+// withTimeout runs given function, failing the test if it does not return
+// within a few seconds
+func withTimeout(t *testing.T, what string, fun func()) {
+	t.Helper()
+	done := make(chan struct{})
+	go func() {
+		fun()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("%s did not return", what)
+	}
+}
+
+func TestNewWindowScreen(t *testing.T) {
+	left := oswin.TheApp.ScreenByName("Left")
+	right := oswin.TheApp.ScreenByName("Right")
+	if left == nil || right == nil {
+		t.Fatalf("screens not found: %v %v", left, right)
+	}
+	if right.Geometry.Min.X != left.Geometry.Max.X {
+		t.Errorf("Right screen geometry %v is not to the right of %v", right.Geometry, left.Geometry)
+	}
+
+	tests := []struct {
+		pos  image.Point
+		want *oswin.Screen
+	}{
+		{image.Point{}, left},
+		{image.Point{100, 100}, left},
+		{image.Point{right.Geometry.Min.X + 10, 100}, right},
+		{image.Point{-10000, -10000}, left}, // off all screens
+	}
+	for _, tst := range tests {
+		win := newTestWin(t, &oswin.NewWindowOptions{Title: "screen", Size: image.Point{200, 100}, Pos: tst.pos})
+		win.Close()
+		if sc := win.Screen(); sc != tst.want {
+			t.Errorf("Pos %v: screen %v != %v", tst.pos, sc.Name, tst.want.Name)
+		}
+		if dpi := win.PhysicalDPI(); dpi != tst.want.PhysicalDPI {
+			t.Errorf("Pos %v: PhysicalDPI %v != %v", tst.pos, dpi, tst.want.PhysicalDPI)
+		}
+		if tst.pos != (image.Point{}) && win.Position() != tst.pos {
+			t.Errorf("Pos %v: Position %v", tst.pos, win.Position())
+		}
+	}
+}
+
+func TestWindowPublish(t *testing.T) {
+	win := newTestWin(t, &oswin.NewWindowOptions{Title: "publish", Size: image.Point{64, 32}})
+	defer win.Close()
+	if img := WindowImage(win); img != nil {
+		t.Errorf("WindowImage before Publish is not nil")
+	}
+	if n := PublishCount(win); n != 0 {
+		t.Errorf("PublishCount before Publish: %d != 0", n)
+	}
+	red := color.RGBA{255, 0, 0, 255}
+	win.Fill(image.Rectangle{Max: win.Size()}, red, oswin.Src)
+	win.Publish()
+	if n := PublishCount(win); n != 1 {
+		t.Errorf("PublishCount after Publish: %d != 1", n)
+	}
+	img := WindowImage(win)
+	if img == nil || img.Bounds().Size() != win.Size() {
+		t.Fatalf("WindowImage size after Publish: %v != %v", img, win.Size())
+	}
+	if c := img.RGBAAt(3, 3); c != red {
+		t.Errorf("WindowImage pixel %v != %v", c, red)
+	}
+	img.Set(3, 3, color.Black) // a copy
+	if c := WindowImage(win).RGBAAt(3, 3); c != red {
+		t.Errorf("WindowImage pixel after changing the copy %v != %v", c, red)
+	}
+}
+
+func TestWindowCloseOnWin(t *testing.T) {
+	win := newTestWin(t, &oswin.NewWindowOptions{Title: "close", Size: image.Point{64, 32}})
+	defer win.Close()
+	nwin := oswin.TheApp.NWindows()
+	withTimeout(t, "Close within RunOnWin", func() {
+		win.RunOnWin(win.Close)
+	})
+	if !win.IsClosed() {
+		t.Errorf("window is not closed")
+	}
+	if n := oswin.TheApp.NWindows(); n != nwin-1 {
+		t.Errorf("NWindows after Close: %d != %d", n, nwin-1)
+	}
+	var we *window.Event
+	for {
+		we = nextWindowEvent(t, win)
+		if we == nil || we.Action == window.Close {
+			break
+		}
+	}
+	if we == nil {
+		t.Errorf("no window.Close event")
+	}
+
+	// running on a closed window does not block, and does not run
+	ran := false
+	withTimeout(t, "RunOnWin after Close", func() {
+		win.RunOnWin(func() { ran = true })
+		win.GoRunOnWin(func() { ran = true })
+	})
+	time.Sleep(10 * time.Millisecond)
+	if ran {
+		t.Errorf("function was run on a closed window")
+	}
+	withTimeout(t, "second Close", win.Close)
+}
+
+func TestWindowCloseConcurrent(t *testing.T) {
+	win := newTestWin(t, &oswin.NewWindowOptions{Title: "concurrent", Size: image.Point{64, 32}})
+	defer win.Close()
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			win.RunOnWin(func() {})
+		}()
+		go func() {
+			defer wg.Done()
+			win.Close()
+		}()
+	}
+	withTimeout(t, "concurrent RunOnWin and Close", wg.Wait)
+}
+
+// TestQuitClean must be the last test, as it closes all the windows
+func TestQuitClean(t *testing.T) {
+	newTestWin(t, &oswin.NewWindowOptions{Title: "quit1", Size: image.Point{64, 32}})
+	newTestWin(t, &oswin.NewWindowOptions{Title: "quit2", Size: image.Point{64, 32}})
+	if oswin.TheApp.IsQuitting() {
+		t.Errorf("IsQuitting before QuitClean")
+	}
+	cleaned := false
+	oswin.TheApp.SetQuitCleanFunc(func() { cleaned = true })
+	withTimeout(t, "QuitClean", oswin.TheApp.QuitClean)
+	if !oswin.TheApp.IsQuitting() {
+		t.Errorf("not IsQuitting after QuitClean")
+	}
+	if !cleaned {
+		t.Errorf("quit clean func not called")
+	}
+	if n := oswin.TheApp.NWindows(); n != 0 {
+		t.Errorf("NWindows after QuitClean: %d != 0", n)
+	}
+	// not quitting any more, so the tests can be run again with -count
+	theApp.SetQuitCleanFunc(nil)
+	atomic.StoreInt32(&theApp.quitting, 0)
+}
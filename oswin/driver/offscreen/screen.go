@@ -0,0 +1,135 @@
+// Copyright 2020 The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package offscreen
+
+import (
+	"image"
+	"runtime"
+
+	"github.com/goki/gi/oswin"
+	"github.com/goki/gi/oswin/window"
+)
+
+// DefaultScreenSize is the size in raw pixels of the screen that is
+// created if no screens have been added via AddScreen prior to Main.
+var DefaultScreenSize = image.Point{1920, 1080}
+
+// DefaultScreenDPI is the physical and logical DPI of the screen that is
+// created if no screens have been added via AddScreen prior to Main.
+var DefaultScreenDPI = float32(96)
+
+// AddScreen adds a new virtual screen of given name, size in raw pixels,
+// physical dots-per-inch and device pixel ratio (1 for standard displays,
+// 2 for "retina" style high-DPI displays).  The logical DPI starts out
+// equal to the physical DPI.  Can be called prior to Main to configure
+// the set of screens, or at any point after to simulate a new monitor
+// being connected, in which case a window.ScreenUpdate event is sent to
+// the first window.
+func AddScreen(name string, size image.Point, dpi, devPixRatio float32) *oswin.Screen {
+	app := theApp
+	if devPixRatio <= 0 {
+		devPixRatio = 1
+	}
+	app.mu.Lock()
+	sc := &oswin.Screen{
+		ScreenNumber:     len(app.screens),
+		Name:             name,
+		Geometry:         image.Rectangle{Max: image.Point{int(float32(size.X) / devPixRatio), int(float32(size.Y) / devPixRatio)}},
+		DevicePixelRatio: devPixRatio,
+		PixSize:          size,
+		PhysicalDPI:      dpi,
+		LogicalDPI:       dpi,
+		Depth:            24,
+		RefreshRate:      60,
+		Orientation:      oswin.Landscape,
+	}
+	sc.PhysicalSize.X = int(25.4 * float32(size.X) / dpi)
+	sc.PhysicalSize.Y = int(25.4 * float32(size.Y) / dpi)
+	if size.Y > size.X {
+		sc.Orientation = oswin.Portrait
+	}
+	sc.NativeOrientation = sc.Orientation
+	sc.PrimaryOrientation = sc.Orientation
+	// lay out screens left-to-right
+	if n := len(app.screens); n > 0 {
+		lsc := app.screens[n-1]
+		sc.Geometry = sc.Geometry.Add(image.Point{lsc.Geometry.Max.X, 0})
+	}
+	app.screens = append(app.screens, sc)
+	app.noScreens = false
+	var fw *windowImpl
+	if len(app.winlist) > 0 {
+		fw = app.winlist[0]
+	}
+	app.mu.Unlock()
+	if fw != nil {
+		fw.sendWindowEvent(window.ScreenUpdate)
+	}
+	return sc
+}
+
+// RemoveScreens removes all the screens, simulating e.g., a closed
+// laptop with no external monitor -- the NoScreens flag is set, and
+// windows become invisible.  Use AddScreen to restore.
+func RemoveScreens() {
+	app := theApp
+	app.mu.Lock()
+	app.screens = app.screens[:0]
+	app.noScreens = true
+	app.mu.Unlock()
+}
+
+// SetPlatform sets the platform reported by the App -- defaults to
+// that of the actual OS the program is running on.  Use this to
+// test platform-specific behavior such as keyboard shortcuts.
+func SetPlatform(plat oswin.Platforms) {
+	theApp.platform = plat
+	theApp.platformSet = true
+}
+
+// SetPrefsDir sets the preferences directory returned by the App --
+// defaults to a GoGiOffscreen directory in the system temp directory.
+func SetPrefsDir(dir string) {
+	theApp.prefsDir = dir
+}
+
+// OpenedURLs returns the list of urls that have been passed to
+// App.OpenURL, in order.
+func OpenedURLs() []string {
+	theApp.mu.Lock()
+	defer theApp.mu.Unlock()
+	urls := make([]string, len(theApp.urls))
+	copy(urls, theApp.urls)
+	return urls
+}
+
+// initScreens makes sure there is at least one screen, and sets
+// the platform if not otherwise set.
+func (app *appImpl) initScreens() {
+	if !app.platformSet {
+		switch runtime.GOOS {
+		case "darwin":
+			app.platform = oswin.MacOS
+		case "windows":
+			app.platform = oswin.Windows
+		default:
+			app.platform = oswin.LinuxX11
+		}
+	}
+	if len(app.screens) == 0 {
+		AddScreen("Offscreen", DefaultScreenSize, DefaultScreenDPI, 1)
+	}
+}
+
+func platformFontPaths() []string {
+	switch theApp.platform {
+	case oswin.MacOS:
+		return []string{"/System/Library/Fonts", "/Library/Fonts"}
+	case oswin.Windows:
+		return []string{"C:\\Windows\\Fonts"}
+	default:
+		return []string{"/usr/share/fonts/truetype"}
+	}
+}
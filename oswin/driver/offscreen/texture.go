@@ -0,0 +1,297 @@
+// Copyright 2020 The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package offscreen
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	"os"
+	"sync"
+
+	"github.com/goki/gi/mat32"
+	"github.com/goki/gi/oswin"
+	"github.com/goki/gi/oswin/driver/internal/drawer"
+	xdraw "golang.org/x/image/draw"
+	"golang.org/x/image/math/f64"
+)
+
+// textureImpl is a purely in-memory texture backed by an image.RGBA --
+// all drawing operations are done in software on the CPU.
+type textureImpl struct {
+	mu      sync.Mutex
+	init    bool
+	name    string
+	size    image.Point
+	botZero bool
+	img     *image.RGBA
+}
+
+// newTexture returns a new texture of given size, with its image allocated
+func newTexture(size image.Point) *textureImpl {
+	tx := &textureImpl{size: size}
+	tx.img = image.NewRGBA(image.Rectangle{Max: size})
+	return tx
+}
+
+// Name returns the name of the texture (filename without extension
+// by default)
+func (tx *textureImpl) Name() string {
+	return tx.name
+}
+
+// SetName sets the name of the texture
+func (tx *textureImpl) SetName(name string) {
+	tx.name = name
+}
+
+// Open loads texture image from file.
+// format inferred from filename -- JPEG and PNG
+// supported by default.
+func (tx *textureImpl) Open(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	im, _, err := image.Decode(file)
+	if err != nil {
+		return err
+	}
+	return tx.SetImage(im)
+}
+
+// Image returns the current image, which is always an *image.RGBA
+func (tx *textureImpl) Image() image.Image {
+	if tx.img == nil {
+		return nil
+	}
+	return tx.img
+}
+
+// GrabImage returns the current contents of the texture -- which is the
+// same as Image for this driver.  Returned image points to single internal
+// image.RGBA used for this texture -- copy before modifying and to retain values.
+func (tx *textureImpl) GrabImage() image.Image {
+	return tx.Image()
+}
+
+// ImageFlipY flips the Y axis from a source image.RGBA into a dest.
+// both must be the same size else it panics.
+func (tx *textureImpl) ImageFlipY(dest, src *image.RGBA) {
+	if dest.Rect.Size() != src.Rect.Size() {
+		panic("ImageFlipY image sizes are not the same")
+	}
+	sz := dest.Rect.Size()
+	rsz := sz.X * 4
+	for y := 0; y < sz.Y; y++ {
+		sy := y * src.Stride
+		dy := (sz.Y - y - 1) * dest.Stride
+		copy(dest.Pix[dy:dy+rsz], src.Pix[sy:sy+rsz])
+	}
+}
+
+// SetImage sets entire contents of the Texture from given image
+// (including setting the size of the texture from that of the img).
+// The image is always copied.
+func (tx *textureImpl) SetImage(img image.Image) error {
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+	sz := img.Bounds().Size()
+	rgba := image.NewRGBA(image.Rectangle{Max: sz})
+	draw.Draw(rgba, rgba.Bounds(), img, img.Bounds().Min, draw.Src)
+	tx.img = rgba
+	tx.size = sz
+	return nil
+}
+
+// SetSubImage copies the sub-Image defined by src and sr to the texture,
+// such that sr.Min in src-space aligns with dp in dst-space.
+// The textures's contents are overwritten; the draw operator
+// is implicitly draw.Src.
+func (tx *textureImpl) SetSubImage(dp image.Point, src image.Image, sr image.Rectangle) error {
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+	if tx.img == nil {
+		return errors.New("offscreen Texture SetSubImage: texture has no image")
+	}
+	sr = sr.Intersect(src.Bounds())
+	dr := image.Rectangle{Min: dp, Max: dp.Add(sr.Size())}
+	draw.Draw(tx.img, dr, src, sr.Min, draw.Src)
+	return nil
+}
+
+// Size returns the size of the image
+func (tx *textureImpl) Size() image.Point {
+	return tx.size
+}
+
+func (tx *textureImpl) Bounds() image.Rectangle {
+	if tx == nil {
+		return image.ZR
+	}
+	return image.Rectangle{Max: tx.size}
+}
+
+// BotZero returns true if this texture has the Y=0 pixels at the bottom
+// of the image.  Otherwise, Y=0 is at the top, which is the default
+// for most images loaded from files.
+func (tx *textureImpl) BotZero() bool {
+	return tx.botZero
+}
+
+// SetBotZero sets whether this texture has the Y=0 pixels at the bottom
+// of the image.  Otherwise, Y=0 is at the top, which is the default
+// for most images loaded from files.
+func (tx *textureImpl) SetBotZero(botzero bool) {
+	tx.botZero = botzero
+}
+
+// SetSize sets the size of the texture, which re-allocates the image,
+// so existing contents are lost.
+func (tx *textureImpl) SetSize(size image.Point) {
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+	if tx.size == size && tx.img != nil {
+		return
+	}
+	tx.size = size
+	tx.img = image.NewRGBA(image.Rectangle{Max: size})
+}
+
+// Activate just records that the texture has been activated --
+// there is no GPU here.
+func (tx *textureImpl) Activate(texNo int) {
+	tx.init = true
+}
+
+// IsActive returns true if texture has already been Activate'd
+func (tx *textureImpl) IsActive() bool {
+	return tx.init
+}
+
+// Handle always returns 0 as there is no GPU handle
+func (tx *textureImpl) Handle() uint32 {
+	return 0
+}
+
+// Transfer returns false if there is no image to transfer -- otherwise
+// it is a no-op as the image is the texture.
+func (tx *textureImpl) Transfer(texNo int) bool {
+	if tx.img == nil {
+		return false
+	}
+	tx.init = true
+	return true
+}
+
+// Delete marks the texture as no longer active.  The image is retained
+// so that it can still be inspected.
+func (tx *textureImpl) Delete() {
+	tx.init = false
+}
+
+// ActivateFramebuffer is a no-op -- all drawing goes directly to the image.
+func (tx *textureImpl) ActivateFramebuffer() {
+}
+
+// DeActivateFramebuffer is a no-op -- all drawing goes directly to the image.
+func (tx *textureImpl) DeActivateFramebuffer() {
+}
+
+// DeleteFramebuffer is a no-op -- all drawing goes directly to the image.
+func (tx *textureImpl) DeleteFramebuffer() {
+}
+
+// FrameDepthAt always returns an error as there is no depth buffer
+func (tx *textureImpl) FrameDepthAt(x, y int) (float32, error) {
+	return 0, errors.New("offscreen Texture does not have a depth buffer")
+}
+
+////////////////////////////////////////////////
+//   Drawer
+
+func (tx *textureImpl) Draw(src2dst mat32.Mat3, src oswin.Texture, sr image.Rectangle, op draw.Op, opts *oswin.DrawOptions) {
+	if src == nil {
+		return
+	}
+	var simg image.Image
+	if stx, ok := src.(*textureImpl); ok {
+		if stx == tx { // can't draw onto self -- copy first
+			stx.mu.Lock()
+			cp := image.NewRGBA(stx.img.Rect)
+			copy(cp.Pix, stx.img.Pix)
+			stx.mu.Unlock()
+			simg = cp
+		} else {
+			stx.mu.Lock()
+			defer stx.mu.Unlock()
+			simg = stx.img
+		}
+	} else {
+		simg = src.Image()
+	}
+	if simg == nil {
+		return
+	}
+	if opts != nil && opts.FlipY {
+		simg, sr = flipYSub(simg, sr)
+	}
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+	drawImage(tx.img, src2dst, simg, sr, op)
+}
+
+func (tx *textureImpl) DrawUniform(src2dst mat32.Mat3, src color.Color, sr image.Rectangle, op draw.Op, opts *oswin.DrawOptions) {
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+	drawImage(tx.img, src2dst, image.NewUniform(src), sr, op)
+}
+
+func (tx *textureImpl) Copy(dp image.Point, src oswin.Texture, sr image.Rectangle, op draw.Op, opts *oswin.DrawOptions) {
+	drawer.Copy(tx, dp, src, sr, op, opts)
+}
+
+func (tx *textureImpl) Scale(dr image.Rectangle, src oswin.Texture, sr image.Rectangle, op draw.Op, opts *oswin.DrawOptions) {
+	drawer.Scale(tx, dr, src, sr, op, opts)
+}
+
+func (tx *textureImpl) Fill(dr image.Rectangle, src color.Color, op draw.Op) {
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+	draw.Draw(tx.img, dr, image.NewUniform(src), image.ZP, op)
+}
+
+// drawImage draws src onto dst using given column-major src2dst transform.
+// Pure integer translations use a direct copy, others are interpolated.
+func drawImage(dst *image.RGBA, src2dst mat32.Mat3, src image.Image, sr image.Rectangle, op draw.Op) {
+	if dst == nil {
+		return
+	}
+	tx, ty := src2dst[6], src2dst[7]
+	if src2dst[0] == 1 && src2dst[4] == 1 && src2dst[1] == 0 && src2dst[3] == 0 &&
+		tx == mat32.Floor(tx) && ty == mat32.Floor(ty) {
+		off := image.Point{int(tx), int(ty)}
+		draw.Draw(dst, sr.Add(off), src, sr.Min, op)
+		return
+	}
+	aff := f64.Aff3{
+		float64(src2dst[0]), float64(src2dst[3]), float64(src2dst[6]),
+		float64(src2dst[1]), float64(src2dst[4]), float64(src2dst[7]),
+	}
+	xdraw.ApproxBiLinear.Transform(dst, aff, src, sr, op, nil)
+}
+
+// flipYSub returns a copy of the sr region of src flipped in the Y axis,
+// and the corresponding source rectangle in the new image.
+func flipYSub(src image.Image, sr image.Rectangle) (image.Image, image.Rectangle) {
+	sz := sr.Size()
+	fl := image.NewRGBA(image.Rectangle{Max: sz})
+	for y := 0; y < sz.Y; y++ {
+		draw.Draw(fl, image.Rect(0, sz.Y-y-1, sz.X, sz.Y-y), src, image.Point{sr.Min.X, sr.Min.Y + y}, draw.Src)
+	}
+	return fl, fl.Bounds()
+}
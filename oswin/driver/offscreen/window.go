@@ -0,0 +1,492 @@
+// Copyright 2020 The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package offscreen
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"sync"
+
+	"github.com/goki/gi/mat32"
+	"github.com/goki/gi/oswin"
+	"github.com/goki/gi/oswin/driver/internal/event"
+	"github.com/goki/gi/oswin/window"
+	"github.com/goki/ki/bitflag"
+)
+
+type windowImpl struct {
+	oswin.WindowBase
+	event.Deque
+	app            *appImpl
+	scrn           *oswin.Screen
+	runQueue       chan funcRun
+	winClose       chan struct{} // closed when the window is closed, to end winLoop
+	winTex         *textureImpl  // texture for updating window contents
+	backBuf        *textureImpl  // window back buffer, target of Drawer methods
+	frontBuf       *image.RGBA   // last published frame
+	nPublish       int
+	closed         bool
+	mu             sync.Mutex
+	closeReqFunc   func(win oswin.Window)
+	closeCleanFunc func(win oswin.Window)
+	mousePos       image.Point
+	cursorEnabled  bool
+}
+
+// Handle returns the last published frame of the window, as an *image.RGBA.
+// Use WindowImage to get a copy that is safe to retain.
+func (w *windowImpl) Handle() interface{} {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.frontBuf
+}
+
+// OSHandle returns 0 as there is no underlying OS window.
+func (w *windowImpl) OSHandle() uintptr {
+	return 0
+}
+
+// MainMenu returns nil as there is no OS-level main menu.
+func (w *windowImpl) MainMenu() oswin.MainMenu {
+	return nil
+}
+
+func (w *windowImpl) IsClosed() bool {
+	if w == nil {
+		return true
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.closed
+}
+
+func (w *windowImpl) IsVisible() bool {
+	if w == nil || theApp.noScreens {
+		return false
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return !w.closed && w.winTex != nil && !w.IsMinimized()
+}
+
+// Activate always returns true for an open window -- there is no
+// gpu context to make current.
+func (w *windowImpl) Activate() bool {
+	return !w.IsClosed()
+}
+
+// DeActivate is a no-op.
+func (w *windowImpl) DeActivate() {
+}
+
+// setGeom sets the window size in window-manager units, and the
+// corresponding pixel size based on the device pixel ratio.
+func (w *windowImpl) setGeom(sz image.Point) {
+	w.WnSize = sz
+	w.PxSize.X = int(float32(sz.X) * w.DevPixRatio)
+	w.PxSize.Y = int(float32(sz.Y) * w.DevPixRatio)
+	if w.backBuf == nil {
+		w.backBuf = newTexture(w.PxSize)
+		w.backBuf.name = "BackBuf"
+	} else {
+		w.backBuf.SetSize(w.PxSize)
+	}
+	if w.winTex != nil {
+		w.winTex.SetSize(w.PxSize)
+	}
+}
+
+// for sending window.Event's
+func (w *windowImpl) sendWindowEvent(act window.Actions) {
+	winEv := window.Event{
+		Action: act,
+	}
+	winEv.Init()
+	w.Send(&winEv)
+}
+
+// winLoop is the window's own locked processing loop.
+func (w *windowImpl) winLoop() {
+outer:
+	for {
+		select {
+		case <-w.winClose:
+			break outer
+		case f := <-w.runQueue:
+			f.f()
+			if f.done != nil {
+				f.done <- true
+			}
+		}
+	}
+}
+
+// RunOnWin runs given function on the window's unique locked thread --
+// it is not run if the window is closed first.
+func (w *windowImpl) RunOnWin(f func()) {
+	if w.IsClosed() {
+		return
+	}
+	done := make(chan bool)
+	select {
+	case w.runQueue <- funcRun{f: f, done: done}:
+		<-done
+	case <-w.winClose:
+	}
+}
+
+// GoRunOnWin runs given function on window's unique locked thread and returns immediately
+func (w *windowImpl) GoRunOnWin(f func()) {
+	if w.IsClosed() {
+		return
+	}
+	go func() {
+		select {
+		case w.runQueue <- funcRun{f: f, done: nil}:
+		case <-w.winClose:
+		}
+	}()
+}
+
+// Publish copies the current back buffer to the front, where it is
+// available via WindowImage.
+func (w *windowImpl) Publish() {
+	if !w.IsVisible() {
+		return
+	}
+	w.backBuf.mu.Lock()
+	w.mu.Lock()
+	if w.frontBuf == nil || w.frontBuf.Rect != w.backBuf.img.Rect {
+		w.frontBuf = image.NewRGBA(w.backBuf.img.Rect)
+	}
+	copy(w.frontBuf.Pix, w.backBuf.img.Pix)
+	w.nPublish++
+	w.mu.Unlock()
+	w.backBuf.mu.Unlock()
+}
+
+// PublishTex draws the current WinTex texture to the window and then
+// calls Publish() -- this is the typical update call.
+func (w *windowImpl) PublishTex() {
+	if !w.IsVisible() {
+		return
+	}
+	w.Copy(image.ZP, w.winTex, w.winTex.Bounds(), oswin.Src, nil)
+	w.Publish()
+}
+
+// SendEmptyEvent sends an empty, blank event to this window, which just has
+// the effect of pushing the system along during cases when the window
+// event loop needs to be "pinged" to get things moving along..
+func (w *windowImpl) SendEmptyEvent() {
+	if w.IsClosed() {
+		return
+	}
+	oswin.SendCustomEvent(w, nil)
+}
+
+// WinTex() returns the current Texture of the same size as the window that
+// is typically used to update the window contents.
+func (w *windowImpl) WinTex() oswin.Texture {
+	return w.winTex
+}
+
+// SetWinTexSubImage calls SetSubImage on WinTex with given parameters.
+func (w *windowImpl) SetWinTexSubImage(dp image.Point, src image.Image, sr image.Rectangle) error {
+	if !w.IsVisible() {
+		return nil
+	}
+	return w.winTex.SetSubImage(dp, src, sr)
+}
+
+////////////////////////////////////////////////
+//   Drawer wrappers
+
+func (w *windowImpl) Draw(src2dst mat32.Mat3, src oswin.Texture, sr image.Rectangle, op draw.Op, opts *oswin.DrawOptions) {
+	if !w.IsVisible() {
+		return
+	}
+	w.backBuf.Draw(src2dst, src, sr, op, opts)
+}
+
+func (w *windowImpl) DrawUniform(src2dst mat32.Mat3, src color.Color, sr image.Rectangle, op draw.Op, opts *oswin.DrawOptions) {
+	if !w.IsVisible() {
+		return
+	}
+	w.backBuf.DrawUniform(src2dst, src, sr, op, opts)
+}
+
+func (w *windowImpl) Copy(dp image.Point, src oswin.Texture, sr image.Rectangle, op draw.Op, opts *oswin.DrawOptions) {
+	if !w.IsVisible() {
+		return
+	}
+	w.backBuf.Copy(dp, src, sr, op, opts)
+}
+
+func (w *windowImpl) Scale(dr image.Rectangle, src oswin.Texture, sr image.Rectangle, op draw.Op, opts *oswin.DrawOptions) {
+	if !w.IsVisible() {
+		return
+	}
+	w.backBuf.Scale(dr, src, sr, op, opts)
+}
+
+func (w *windowImpl) Fill(dr image.Rectangle, src color.Color, op draw.Op) {
+	if !w.IsVisible() {
+		return
+	}
+	w.backBuf.Fill(dr, src, op)
+}
+
+////////////////////////////////////////////////////////////
+//  Geom etc
+
+func (w *windowImpl) Screen() *oswin.Screen {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.scrn
+}
+
+func (w *windowImpl) Size() image.Point {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.PxSize
+}
+
+func (w *windowImpl) WinSize() image.Point {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.WnSize
+}
+
+func (w *windowImpl) Position() image.Point {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.Pos
+}
+
+func (w *windowImpl) PhysicalDPI() float32 {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.PhysDPI
+}
+
+func (w *windowImpl) LogicalDPI() float32 {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.LogDPI
+}
+
+func (w *windowImpl) SetLogicalDPI(dpi float32) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.LogDPI = dpi
+}
+
+func (w *windowImpl) SetTitle(title string) {
+	if w.IsClosed() {
+		return
+	}
+	w.mu.Lock()
+	w.Titl = title
+	w.mu.Unlock()
+}
+
+func (w *windowImpl) SetSize(sz image.Point) {
+	if w.IsClosed() {
+		return
+	}
+	w.mu.Lock()
+	w.setGeom(sz)
+	w.mu.Unlock()
+	w.sendWindowEvent(window.Resize)
+}
+
+func (w *windowImpl) SetPixSize(sz image.Point) {
+	if w.IsClosed() {
+		return
+	}
+	w.mu.Lock()
+	dpr := w.DevPixRatio
+	w.mu.Unlock()
+	sz.X = int(float32(sz.X) / dpr)
+	sz.Y = int(float32(sz.Y) / dpr)
+	w.SetSize(sz)
+}
+
+func (w *windowImpl) SetPos(pos image.Point) {
+	if w.IsClosed() {
+		return
+	}
+	w.mu.Lock()
+	w.Pos = pos
+	w.mu.Unlock()
+	w.sendWindowEvent(window.Move)
+}
+
+func (w *windowImpl) SetGeom(pos image.Point, sz image.Point) {
+	if w.IsClosed() {
+		return
+	}
+	w.mu.Lock()
+	w.Pos = pos
+	w.setGeom(sz)
+	w.mu.Unlock()
+	w.sendWindowEvent(window.Resize)
+}
+
+// SetScreen moves the window to given screen, updating its DPI and
+// pixel size accordingly, as happens when a window is dragged to
+// another monitor.
+func (w *windowImpl) SetScreen(sc *oswin.Screen) {
+	if w.IsClosed() || sc == nil {
+		return
+	}
+	w.mu.Lock()
+	w.scrn = sc
+	w.DevPixRatio = sc.DevicePixelRatio
+	w.PhysDPI = sc.PhysicalDPI
+	w.LogDPI = sc.LogicalDPI
+	w.Pos = sc.Geometry.Min
+	w.setGeom(w.WnSize)
+	w.mu.Unlock()
+	w.sendWindowEvent(window.Resize)
+}
+
+func (w *windowImpl) Raise() {
+	if w.IsClosed() {
+		return
+	}
+	if bitflag.HasAtomic(&w.Flag, int(oswin.Minimized)) {
+		bitflag.ClearAtomic(&w.Flag, int(oswin.Minimized))
+		w.sendWindowEvent(window.Minimize)
+	}
+	w.app.mu.Lock()
+	for _, ow := range w.app.winlist {
+		if ow != w && bitflag.HasAtomic(&ow.Flag, int(oswin.Focus)) {
+			bitflag.ClearAtomic(&ow.Flag, int(oswin.Focus))
+			ow.sendWindowEvent(window.DeFocus)
+		}
+	}
+	w.app.mu.Unlock()
+	if !bitflag.HasAtomic(&w.Flag, int(oswin.Focus)) {
+		bitflag.SetAtomic(&w.Flag, int(oswin.Focus))
+		w.sendWindowEvent(window.Focus)
+	}
+}
+
+func (w *windowImpl) Minimize() {
+	if w.IsClosed() {
+		return
+	}
+	bitflag.SetAtomic(&w.Flag, int(oswin.Minimized))
+	bitflag.ClearAtomic(&w.Flag, int(oswin.Focus))
+	w.sendWindowEvent(window.Minimize)
+}
+
+func (w *windowImpl) SetCloseReqFunc(fun func(win oswin.Window)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.closeReqFunc = fun
+}
+
+func (w *windowImpl) SetCloseCleanFunc(fun func(win oswin.Window)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.closeCleanFunc = fun
+}
+
+func (w *windowImpl) CloseReq() {
+	if theApp.IsQuitting() {
+		w.Close()
+		return
+	}
+	if w.closeReqFunc != nil {
+		w.closeReqFunc(w)
+	} else {
+		w.Close()
+	}
+}
+
+func (w *windowImpl) CloseClean() {
+	if w.closeCleanFunc != nil {
+		w.closeCleanFunc(w)
+	}
+}
+
+func (w *windowImpl) Close() {
+	// this is actually the final common pathway for closing here
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return
+	}
+	w.closed = true
+	w.mu.Unlock()
+	close(w.winClose) // break out of run loop -- does not block, e.g., within RunOnWin
+	w.CloseClean()
+	w.sendWindowEvent(window.Close)
+	theApp.DeleteWin(w)
+	if theApp.IsQuitting() {
+		theApp.quitCloseCnt <- struct{}{}
+	}
+}
+
+// SetMousePos records the mouse position -- there is no actual
+// mouse to move.
+func (w *windowImpl) SetMousePos(x, y float64) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.mousePos = image.Point{int(x), int(y)}
+}
+
+// SetCursorEnabled records the enabled state of the cursor.
+func (w *windowImpl) SetCursorEnabled(enabled, raw bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.cursorEnabled = enabled
+}
+
+////////////////////////////////////////////////////////////
+//  Offscreen-specific access
+
+// WindowImage returns a copy of the last frame published to given
+// window (via Publish or PublishTex), or nil if nothing has been
+// published yet or the window is not an offscreen window.
+func WindowImage(win oswin.Window) *image.RGBA {
+	w, ok := win.(*windowImpl)
+	if !ok {
+		return nil
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.frontBuf == nil {
+		return nil
+	}
+	img := image.NewRGBA(w.frontBuf.Rect)
+	copy(img.Pix, w.frontBuf.Pix)
+	return img
+}
+
+// PublishCount returns the number of times the given window has been
+// published -- can be used to wait for rendering to happen.
+// Returns -1 if not an offscreen window.
+func PublishCount(win oswin.Window) int {
+	w, ok := win.(*windowImpl)
+	if !ok {
+		return -1
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.nPublish
+}
+
+// MoveToScreen moves given offscreen window to given screen, with the
+// same effects as a user moving a window to a different monitor.
+func MoveToScreen(win oswin.Window, sc *oswin.Screen) {
+	if w, ok := win.(*windowImpl); ok {
+		w.SetScreen(sc)
+	}
+}
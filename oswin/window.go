@@ -449,7 +449,12 @@ func sanitizeUTF8(s string, n int) string {
 // Fixup fills in defaults and updates everything based on current screen and
 // window context Specific hardware can fine-tune this as well, in driver code
 func (o *NewWindowOptions) Fixup() {
-	sc := TheApp.Screen(0)
+	o.FixupScreen(TheApp.Screen(0))
+}
+
+// FixupScreen is Fixup for a window on the given screen, e.g., the one at
+// the requested Pos -- the window is sized and placed within its Geometry.
+func (o *NewWindowOptions) FixupScreen(sc *Screen) {
 	scsz := sc.Geometry.Size()
 
 	dialog, modal, _, _ := WindowFlagsToBool(o.Flags)
@@ -493,16 +498,16 @@ func (o *NewWindowOptions) Fixup() {
 				o.Pos.Y = lp.Y + 72    // and move down a bit
 			}
 		} else { // center in screen
-			o.Pos.X = scsz.X/2 - o.Size.X/2
-			o.Pos.Y = scsz.Y/2 - o.Size.Y/2
+			o.Pos.X = sc.Geometry.Min.X + scsz.X/2 - o.Size.X/2
+			o.Pos.Y = sc.Geometry.Min.Y + scsz.Y/2 - o.Size.Y/2
 		}
 	}
 
 	// final sanity fixes
-	if o.Pos.X+o.Size.X > scsz.X {
-		o.Pos.X = scsz.X - o.Size.X
+	if o.Pos.X+o.Size.X > sc.Geometry.Max.X {
+		o.Pos.X = sc.Geometry.Max.X - o.Size.X
 	}
-	if o.Pos.Y+o.Size.Y > scsz.Y {
-		o.Pos.Y = scsz.Y - o.Size.Y
+	if o.Pos.Y+o.Size.Y > sc.Geometry.Max.Y {
+		o.Pos.Y = sc.Geometry.Max.Y - o.Size.Y
 	}
 }
@@ -10,9 +10,12 @@ import (
 	"github.com/goki/ki/kit"
 )
 
-// todo: needs to be impl
-
-// ClipPath is used for holding a path that renders as a clip path
+// ClipPath is used for holding a path that renders as a clip path, for
+// nodes that refer to it via the clip-path property (e.g.,
+// clip-path="url(#clip1)") -- the children are rendered, and the node is
+// only drawn where they cover -- see StartEffects.  Set the clipPathUnits
+// property to objectBoundingBox to have the children coordinates be
+// relative to the bounding box of the clipped node.
 type ClipPath struct {
 	NodeBase
 }
@@ -28,3 +31,8 @@ func (g *ClipPath) CopyFieldsFrom(frm interface{}) {
 	fr := frm.(*ClipPath)
 	g.NodeBase.CopyFieldsFrom(&fr.NodeBase)
 }
+
+// Render2D does nothing: clip paths are only rendered by being applied to
+// other nodes
+func (g *ClipPath) Render2D() {
+}
@@ -0,0 +1,304 @@
+// Copyright (c) 2020, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package svg
+
+import (
+	"image"
+	"image/draw"
+	"log"
+	"strings"
+
+	"github.com/goki/gi/gi"
+	"github.com/goki/gi/mat32"
+	"github.com/goki/ki/kit"
+)
+
+// effects are applied to the rendering of a node as a whole, after it has
+// been rendered: filter, then clip-path, then mask -- the node is rendered
+// by itself into the cleared render image, which is then processed and
+// drawn over the prior contents of the image -- see StartEffects.
+
+// PropString returns the given property as a string, with default
+// value if not set
+func (g *NodeBase) PropString(key, def string) string {
+	pv, has := g.Props[key]
+	if !has {
+		return def
+	}
+	return strings.TrimSpace(kit.ToString(pv))
+}
+
+// PropFloat returns the given property as a float, with default value
+// if not set or not parseable -- percent values are returned as fractions
+func (g *NodeBase) PropFloat(key string, def float32) float32 {
+	str := g.PropString(key, "")
+	if str == "" {
+		return def
+	}
+	frac := float32(1)
+	if strings.HasSuffix(str, "%") {
+		str = strings.TrimSuffix(str, "%")
+		frac = .01
+	}
+	str = strings.TrimSuffix(str, "px")
+	v, err := mat32.ParseFloat32(str)
+	if err != nil {
+		return def
+	}
+	return v * frac
+}
+
+// PropFloats returns the given property as a list of floats, separated by
+// spaces and / or commas
+func (g *NodeBase) PropFloats(key string) []float32 {
+	str := g.PropString(key, "")
+	fs := strings.FieldsFunc(str, func(r rune) bool { return r == ' ' || r == ',' || r == '\t' || r == '\n' })
+	vals := make([]float32, 0, len(fs))
+	for _, f := range fs {
+		v, err := mat32.ParseFloat32(f)
+		if err != nil {
+			log.Printf("svg.PropFloats: %v: could not parse %v value: %v\n", g.Nm, key, str)
+			return nil
+		}
+		vals = append(vals, v)
+	}
+	return vals
+}
+
+// URLProp returns the element referred to by given property, which can be
+// a url(#name) string or a pointer to the element -- nil if not set, none,
+// or not found
+func (g *NodeBase) URLProp(key string) gi.Node2D {
+	pv, has := g.Props[key]
+	if !has {
+		return nil
+	}
+	switch pvv := pv.(type) {
+	case string:
+		if pvv == "" || pvv == "none" {
+			return nil
+		}
+		return g.FindSVGURL(pvv)
+	case gi.Node2D:
+		return pvv
+	}
+	log.Printf("gi.svg %v property should be a string url or pointer to element, instead is: %T\n", key, pv)
+	return nil
+}
+
+// FilterURL returns the Filter referred to by the filter property, if any
+func (g *NodeBase) FilterURL() *Filter {
+	flt, _ := g.URLProp("filter").(*Filter)
+	return flt
+}
+
+// ClipPathURL returns the ClipPath referred to by the clip-path property, if any
+func (g *NodeBase) ClipPathURL() *ClipPath {
+	cp, _ := g.URLProp("clip-path").(*ClipPath)
+	return cp
+}
+
+// MaskURL returns the Mask referred to by the mask property, if any
+func (g *NodeBase) MaskURL() *Mask {
+	msk, _ := g.URLProp("mask").(*Mask)
+	return msk
+}
+
+// Region returns the region in render image pixels covered by this effect
+// element (e.g., a filter or mask), for a node with given bounding box and
+// net transform, based on the x, y, width, height properties and the given
+// units property (e.g., filterUnits) -- the default objectBoundingBox units
+// are fractions of the bounding box, defaulting to 10% beyond it on all sides
+func (g *NodeBase) Region(unitsKey string, bbox image.Rectangle, xf mat32.Mat2) image.Rectangle {
+	if g.PropString(unitsKey, "") == "userSpaceOnUse" {
+		x := g.PropFloat("x", 0)
+		y := g.PropFloat("y", 0)
+		w := g.PropFloat("width", 0)
+		h := g.PropFloat("height", 0)
+		if w <= 0 || h <= 0 {
+			return image.ZR
+		}
+		return xformRect(xf, mat32.Vec2{X: x, Y: y}, mat32.Vec2{X: w, Y: h})
+	}
+	x := g.PropFloat("x", -.1)
+	y := g.PropFloat("y", -.1)
+	w := g.PropFloat("width", 1.2)
+	h := g.PropFloat("height", 1.2)
+	bw := float32(bbox.Dx())
+	bh := float32(bbox.Dy())
+	mnx := float32(bbox.Min.X) + x*bw
+	mny := float32(bbox.Min.Y) + y*bh
+	return image.Rect(int(mat32.Floor(mnx)), int(mat32.Floor(mny)), int(mat32.Ceil(mnx+w*bw)), int(mat32.Ceil(mny+h*bh)))
+}
+
+// xformRect returns the pixel bounding box of the rectangle at given
+// position and size, transformed by given transform
+func xformRect(xf mat32.Mat2, pos, size mat32.Vec2) image.Rectangle {
+	bb := mat32.NewEmptyBox2()
+	for _, pt := range []mat32.Vec2{pos, {X: pos.X + size.X, Y: pos.Y}, {X: pos.X, Y: pos.Y + size.Y}, pos.Add(size)} {
+		bb.ExpandByPoint(xf.MulVec2AsPt(pt))
+	}
+	return image.Rect(int(mat32.Floor(bb.Min.X)), int(mat32.Floor(bb.Min.Y)), int(mat32.Ceil(bb.Max.X)), int(mat32.Ceil(bb.Max.Y)))
+}
+
+// userBBox returns the bounding box in user coordinates, for given pixel
+// bounding box and net transform
+func userBBox(bbox image.Rectangle, xf mat32.Mat2) mat32.Box2 {
+	inv := xf.Inverse()
+	bb := mat32.NewEmptyBox2()
+	for _, pt := range []image.Point{bbox.Min, {bbox.Max.X, bbox.Min.Y}, {bbox.Min.X, bbox.Max.Y}, bbox.Max} {
+		bb.ExpandByPoint(inv.MulVec2AsPt(mat32.NewVec2FmPoint(pt)))
+	}
+	return bb
+}
+
+// EffectState holds the state needed to apply the filter, clip-path and
+// mask effects to the rendering of a node -- see StartEffects, EndEffects
+type EffectState struct {
+	Filter *Filter         `desc:"the filter being applied, if any"`
+	Clip   *ClipPath       `desc:"the clip path being applied, if any"`
+	Mask   *Mask           `desc:"the mask being applied, if any"`
+	Back   *image.RGBA     `desc:"copy of the render image prior to rendering the node, restored after applying effects"`
+	XForm  mat32.Mat2      `desc:"net transform of the node"`
+	Bounds image.Rectangle `desc:"bounds of the render image when the effects were started"`
+}
+
+// StartEffects checks if the node has a filter, clip-path or mask property,
+// and if so, saves a copy of the current render image and clears it, so
+// that the node then renders by itself into the image.  EndEffects must
+// then be called with the returned state after the node has been rendered.
+// Returns nil if there are no effects to apply.
+func (g *NodeBase) StartEffects() *EffectState {
+	if g.Viewport == nil {
+		return nil
+	}
+	es := &EffectState{Filter: g.FilterURL(), Clip: g.ClipPathURL(), Mask: g.MaskURL()}
+	if es.Filter == nil && es.Clip == nil && es.Mask == nil {
+		return nil
+	}
+	rs := &g.Viewport.Render
+	if rs.Image == nil {
+		return nil
+	}
+	rs.Lock()
+	defer rs.Unlock()
+	es.XForm = g.Pnt.XForm.Mul(rs.XForm)
+	es.Bounds = rs.Image.Bounds()
+	es.Back = image.NewRGBA(es.Bounds)
+	draw.Draw(es.Back, es.Bounds, rs.Image, es.Bounds.Min, draw.Src)
+	draw.Draw(rs.Image, es.Bounds, image.Transparent, image.ZP, draw.Src)
+	return es
+}
+
+// EndEffects applies the effects started by StartEffects to the node as
+// just rendered, restoring the prior render image and drawing the result
+// over it.  Does nothing if es is nil.
+func (g *NodeBase) EndEffects(es *EffectState) {
+	if es == nil {
+		return
+	}
+	rs := &g.Viewport.Render
+	rs.Lock()
+	img := rs.Image
+	if img.Bounds() != es.Bounds { // resized during render -- can't apply
+		rs.Unlock()
+		return
+	}
+	bb := g.BBox
+	if bb.Empty() {
+		for _, kid := range g.Kids {
+			if _, kg := gi.KiToNode2D(kid); kg != nil && !kg.BBox.Empty() {
+				bb = bb.Union(kg.BBox)
+			}
+		}
+	}
+	var res *image.RGBA
+	reg := es.Bounds
+	if es.Filter != nil {
+		res, reg = es.Filter.Apply(img, bb, es.XForm)
+	} else {
+		res = image.NewRGBA(image.Rectangle{Max: reg.Size()})
+		draw.Draw(res, res.Bounds(), img, reg.Min, draw.Src)
+	}
+	rs.Unlock()
+
+	if res != nil && es.Clip != nil {
+		cov := g.renderCoverage(&es.Clip.NodeBase, "clipPathUnits", es, bb, reg, false)
+		applyCoverage(res, cov)
+	}
+	if res != nil && es.Mask != nil {
+		lum := es.Mask.PropString("mask-type", "luminance") != "alpha"
+		cov := g.renderCoverage(&es.Mask.NodeBase, "maskContentUnits", es, bb, reg, lum)
+		mreg := es.Mask.Region("maskUnits", bb, es.XForm)
+		for y := 0; y < cov.Rect.Dy(); y++ {
+			for x := 0; x < cov.Rect.Dx(); x++ {
+				if !(image.Point{reg.Min.X + x, reg.Min.Y + y}).In(mreg) {
+					cov.Pix[y*cov.Stride+x] = 0
+				}
+			}
+		}
+		applyCoverage(res, cov)
+	}
+
+	rs.Lock()
+	draw.Draw(img, es.Bounds, es.Back, es.Bounds.Min, draw.Src)
+	if res != nil {
+		draw.Draw(img, reg, res, image.ZP, draw.Over)
+	}
+	rs.Unlock()
+}
+
+// renderCoverage renders the children of given clip path or mask element
+// into the cleared render image, in the coordinate system of this node
+// (scaled to its bounding box if the units property is objectBoundingBox),
+// and returns the resulting coverage within given region, as the alpha
+// channel, or the luminance times alpha if lum is set
+func (g *NodeBase) renderCoverage(el *NodeBase, unitsKey string, es *EffectState, bb, reg image.Rectangle, lum bool) *image.Alpha {
+	rs := &g.Viewport.Render
+	xf := el.Pnt.XForm.Mul(g.Pnt.XForm)
+	if el.PropString(unitsKey, "") == "objectBoundingBox" {
+		ub := userBBox(bb, es.XForm)
+		sz := ub.Size()
+		xf = mat32.Scale2D(sz.X, sz.Y).Mul(mat32.Translate2D(ub.Min.X, ub.Min.Y)).Mul(xf)
+	}
+	rs.Lock()
+	draw.Draw(rs.Image, es.Bounds, image.Transparent, image.ZP, draw.Src)
+	rs.PushXForm(xf)
+	rs.Unlock()
+	for _, kid := range el.Kids {
+		if kn, _ := gi.KiToNode2D(kid); kn != nil {
+			kn.Render2D()
+		}
+	}
+	rs.Lock()
+	defer rs.Unlock()
+	rs.PopXForm()
+	cov := image.NewAlpha(image.Rectangle{Max: reg.Size()})
+	for y := 0; y < reg.Dy(); y++ {
+		for x := 0; x < reg.Dx(); x++ {
+			c := rs.Image.RGBAAt(reg.Min.X+x, reg.Min.Y+y)
+			if lum { // colors are premultiplied, so this is luminance * alpha
+				cov.Pix[y*cov.Stride+x] = uint8(0.2125*float32(c.R) + 0.7154*float32(c.G) + 0.0721*float32(c.B) + .5)
+			} else {
+				cov.Pix[y*cov.Stride+x] = c.A
+			}
+		}
+	}
+	return cov
+}
+
+// applyCoverage multiplies the image by the given coverage, which must
+// be the same size
+func applyCoverage(img *image.RGBA, cov *image.Alpha) {
+	for y := 0; y < cov.Rect.Dy(); y++ {
+		for x := 0; x < cov.Rect.Dx(); x++ {
+			a := uint32(cov.Pix[y*cov.Stride+x])
+			pi := img.PixOffset(x, y)
+			for c := 0; c < 4; c++ {
+				img.Pix[pi+c] = uint8((uint32(img.Pix[pi+c])*a + 127) / 255)
+			}
+		}
+	}
+}
@@ -39,6 +39,7 @@ func (g *Ellipse) Render2D() {
 	if g.Viewport == nil {
 		g.This().(gi.Node2D).Init2D()
 	}
+	eff := g.StartEffects()
 	pc := &g.Pnt
 	rs := &g.Viewport.Render
 	rs.Lock()
@@ -51,4 +52,5 @@ func (g *Ellipse) Render2D() {
 	g.Render2DChildren()
 
 	rs.PopXFormLock()
+	g.EndEffects(eff)
 }
@@ -0,0 +1,103 @@
+// Copyright (c) 2020, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package svg
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+
+	"github.com/goki/gi/mat32"
+)
+
+func TestFilterDropShadow(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 40, 40))
+	sq := image.Rect(10, 10, 20, 20)
+	draw.Draw(img, sq, image.NewUniform(color.RGBA{255, 0, 0, 255}), image.ZP, draw.Src)
+
+	flt := &Filter{FilterType: "filter"}
+	flt.InitName(flt, "shadow")
+	flt.SetProp("x", "-50%")
+	flt.SetProp("y", "-50%")
+	flt.SetProp("width", "200%")
+	flt.SetProp("height", "200%")
+	blur := AddNewFilter(flt, "blur")
+	blur.FilterType = "feGaussianBlur"
+	blur.SetProp("in", "SourceAlpha")
+	blur.SetProp("stdDeviation", "1")
+	off := AddNewFilter(flt, "off")
+	off.FilterType = "feOffset"
+	off.SetProp("dx", "4")
+	off.SetProp("dy", "4")
+	off.SetProp("result", "offblur")
+	mrg := AddNewFilter(flt, "merge")
+	mrg.FilterType = "feMerge"
+	AddNewFilter(mrg, "n1").SetProp("in", "offblur")
+	AddNewFilter(mrg, "n2").SetProp("in", "SourceGraphic")
+	for _, k := range mrg.Kids {
+		k.(*Filter).FilterType = "feMergeNode"
+	}
+
+	res, reg := flt.Apply(img, sq, mat32.Identity2D())
+	if reg != image.Rect(5, 5, 25, 25) {
+		t.Fatalf("filter region: %v != %v", reg, image.Rect(5, 5, 25, 25))
+	}
+	at := func(x, y int) color.RGBA {
+		return res.RGBAAt(x-reg.Min.X, y-reg.Min.Y)
+	}
+	if c := at(15, 15); c.R < 250 || c.A < 250 {
+		t.Errorf("source graphic not preserved: %v", c)
+	}
+	if c := at(22, 22); c.A < 200 || c.R > 10 {
+		t.Errorf("shadow not dark and opaque: %v", c)
+	}
+	if c := at(6, 6); c.A != 0 {
+		t.Errorf("expected transparent outside shadow: %v", c)
+	}
+}
+
+func TestFilterPrimitives(t *testing.T) {
+	fi := newFilterImage(1, 1)
+	copy(fi.Pix, []float32{.5, .25, 0, .5}) // premultiplied (1, .5, 0) at .5 alpha
+
+	lum := fi.colorMatrix("luminanceToAlpha", nil)
+	if a := lum.Pix[3]; mat32.Abs(a-(0.2125+0.7154*.5)) > 1e-4 {
+		t.Errorf("luminanceToAlpha: %v", lum.Pix)
+	}
+	sat := fi.colorMatrix("saturate", []float32{1})
+	for i := range fi.Pix {
+		if mat32.Abs(sat.Pix[i]-fi.Pix[i]) > 1e-4 {
+			t.Errorf("saturate 1 should be identity: %v != %v", sat.Pix, fi.Pix)
+			break
+		}
+	}
+
+	bg := newFilterImage(1, 1)
+	copy(bg.Pix, []float32{0, 0, 1, 1})
+	over := fi.composite(bg, "over", 0, 0, 0, 0)
+	want := []float32{.5, .25, .5, 1}
+	for i := range want {
+		if mat32.Abs(over.Pix[i]-want[i]) > 1e-4 {
+			t.Errorf("composite over: %v != %v", over.Pix, want)
+			break
+		}
+	}
+	in := fi.composite(bg, "in", 0, 0, 0, 0)
+	for i := range fi.Pix {
+		if mat32.Abs(in.Pix[i]-fi.Pix[i]) > 1e-4 {
+			t.Errorf("composite in opaque: %v != %v", in.Pix, fi.Pix)
+			break
+		}
+	}
+	mul := fi.blend(bg, "multiply")
+	want = []float32{0, 0, .5, 1} // red * blue = black where overlapping, half coverage
+	for i := range want {
+		if mat32.Abs(mul.Pix[i]-want[i]) > 1e-4 {
+			t.Errorf("blend multiply: %v != %v", mul.Pix, want)
+			break
+		}
+	}
+}
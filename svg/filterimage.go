@@ -0,0 +1,352 @@
+// Copyright (c) 2020, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package svg
+
+import (
+	"image"
+	"image/color"
+
+	"github.com/chewxy/math32"
+	"github.com/goki/gi/mat32"
+)
+
+// filterImage is a float32 RGBA image with premultiplied alpha, in 0..1
+// range, used for computing filter primitives
+type filterImage struct {
+	W, H int
+	Pix  []float32
+}
+
+func newFilterImage(w, h int) *filterImage {
+	return &filterImage{W: w, H: h, Pix: make([]float32, 4*w*h)}
+}
+
+// filterImageFromRGBA returns the given region of given image as a filterImage
+func filterImageFromRGBA(img *image.RGBA, reg image.Rectangle) *filterImage {
+	fi := newFilterImage(reg.Dx(), reg.Dy())
+	for y := 0; y < fi.H; y++ {
+		si := img.PixOffset(reg.Min.X, reg.Min.Y+y)
+		di := 4 * y * fi.W
+		for x := 0; x < 4*fi.W; x++ {
+			fi.Pix[di+x] = float32(img.Pix[si+x]) / 255
+		}
+	}
+	return fi
+}
+
+// RGBA returns the image as an image.RGBA, with 0,0 origin
+func (fi *filterImage) RGBA() *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, fi.W, fi.H))
+	for i, v := range fi.Pix {
+		img.Pix[i] = uint8(mat32.Clamp(v, 0, 1)*255 + .5)
+	}
+	return img
+}
+
+// alphaOnly returns a copy of the image with just the alpha channel,
+// i.e., the SourceAlpha input
+func (fi *filterImage) alphaOnly() *filterImage {
+	res := newFilterImage(fi.W, fi.H)
+	for i := 3; i < len(fi.Pix); i += 4 {
+		res.Pix[i] = fi.Pix[i]
+	}
+	return res
+}
+
+// srgbToLinear converts an sRGB color component to linearRGB
+func srgbToLinear(c float32) float32 {
+	if c <= 0.04045 {
+		return c / 12.92
+	}
+	return mat32.Pow((c+0.055)/1.055, 2.4)
+}
+
+// linearToSRGB converts a linearRGB color component to sRGB
+func linearToSRGB(c float32) float32 {
+	if c <= 0.0031308 {
+		return c * 12.92
+	}
+	return 1.055*mat32.Pow(c, 1/2.4) - 0.055
+}
+
+// convColors applies given function to the unpremultiplied color components
+func (fi *filterImage) convColors(fun func(c float32) float32) {
+	for i := 0; i < len(fi.Pix); i += 4 {
+		a := fi.Pix[i+3]
+		if a == 0 {
+			continue
+		}
+		for c := 0; c < 3; c++ {
+			fi.Pix[i+c] = fun(fi.Pix[i+c]/a) * a
+		}
+	}
+}
+
+// toLinear converts the colors from sRGB to linearRGB
+func (fi *filterImage) toLinear() {
+	fi.convColors(srgbToLinear)
+}
+
+// toSRGB converts the colors from linearRGB to sRGB
+func (fi *filterImage) toSRGB() {
+	fi.convColors(linearToSRGB)
+}
+
+// gaussKernel returns a normalized gaussian kernel for given standard
+// deviation, extending out 3 sigma on each side
+func gaussKernel(sd float32) []float32 {
+	rad := int(mat32.Ceil(3 * sd))
+	kern := make([]float32, 2*rad+1)
+	sum := float32(0)
+	for i := range kern {
+		d := float32(i - rad)
+		kern[i] = math32.Exp(-(d * d) / (2 * sd * sd))
+		sum += kern[i]
+	}
+	for i := range kern {
+		kern[i] /= sum
+	}
+	return kern
+}
+
+// blur returns a gaussian blurred copy of the image, with given standard
+// deviations in x and y, in pixels -- zero values for both results in
+// the image passed through unchanged, and negative values are an error
+// resulting in transparent black, per the SVG spec
+func (fi *filterImage) blur(sdx, sdy float32) *filterImage {
+	if sdx < 0 || sdy < 0 {
+		return newFilterImage(fi.W, fi.H)
+	}
+	res := fi
+	if sdx > 0 {
+		res = res.convolve1D(gaussKernel(sdx), true)
+	}
+	if sdy > 0 {
+		res = res.convolve1D(gaussKernel(sdy), false)
+	}
+	if res == fi {
+		res = &filterImage{W: fi.W, H: fi.H, Pix: append([]float32(nil), fi.Pix...)}
+	}
+	return res
+}
+
+// convolve1D returns the image convolved with given symmetric kernel along
+// the x (horiz) or y axis -- pixels outside of the image are transparent
+func (fi *filterImage) convolve1D(kern []float32, horiz bool) *filterImage {
+	res := newFilterImage(fi.W, fi.H)
+	rad := len(kern) / 2
+	for y := 0; y < fi.H; y++ {
+		for x := 0; x < fi.W; x++ {
+			var sum [4]float32
+			for k, kv := range kern {
+				sx, sy := x, y
+				if horiz {
+					sx += k - rad
+					if sx < 0 || sx >= fi.W {
+						continue
+					}
+				} else {
+					sy += k - rad
+					if sy < 0 || sy >= fi.H {
+						continue
+					}
+				}
+				si := 4 * (sy*fi.W + sx)
+				sum[0] += kv * fi.Pix[si]
+				sum[1] += kv * fi.Pix[si+1]
+				sum[2] += kv * fi.Pix[si+2]
+				sum[3] += kv * fi.Pix[si+3]
+			}
+			copy(res.Pix[4*(y*fi.W+x):], sum[:])
+		}
+	}
+	return res
+}
+
+// offset returns a copy of the image shifted by given number of pixels
+func (fi *filterImage) offset(dx, dy int) *filterImage {
+	res := newFilterImage(fi.W, fi.H)
+	for y := 0; y < fi.H; y++ {
+		sy := y - dy
+		if sy < 0 || sy >= fi.H {
+			continue
+		}
+		for x := 0; x < fi.W; x++ {
+			sx := x - dx
+			if sx < 0 || sx >= fi.W {
+				continue
+			}
+			copy(res.Pix[4*(y*fi.W+x):4*(y*fi.W+x)+4], fi.Pix[4*(sy*fi.W+sx):])
+		}
+	}
+	return res
+}
+
+// flood returns an image of the same size filled with given color and
+// opacity, converted to linearRGB if linear is set
+func (fi *filterImage) flood(clr color.Color, opacity float32, linear bool) *filterImage {
+	res := newFilterImage(fi.W, fi.H)
+	c := color.NRGBAModel.Convert(clr).(color.NRGBA)
+	cv := [3]float32{float32(c.R) / 255, float32(c.G) / 255, float32(c.B) / 255}
+	if linear {
+		for i := range cv {
+			cv[i] = srgbToLinear(cv[i])
+		}
+	}
+	a := mat32.Clamp(opacity, 0, 1) * float32(c.A) / 255
+	for i := 0; i < len(res.Pix); i += 4 {
+		res.Pix[i] = cv[0] * a
+		res.Pix[i+1] = cv[1] * a
+		res.Pix[i+2] = cv[2] * a
+		res.Pix[i+3] = a
+	}
+	return res
+}
+
+// colorMatrix returns the image transformed by the color matrix of given
+// type (matrix, saturate, hueRotate, luminanceToAlpha) with given values,
+// which is applied to unpremultiplied colors
+func (fi *filterImage) colorMatrix(typ string, vals []float32) *filterImage {
+	var m [20]float32
+	m[0], m[6], m[12], m[18] = 1, 1, 1, 1 // identity
+	switch typ {
+	case "matrix":
+		if len(vals) == 20 {
+			copy(m[:], vals)
+		}
+	case "saturate":
+		s := float32(1)
+		if len(vals) > 0 {
+			s = vals[0]
+		}
+		m = [20]float32{
+			0.213 + 0.787*s, 0.715 - 0.715*s, 0.072 - 0.072*s, 0, 0,
+			0.213 - 0.213*s, 0.715 + 0.285*s, 0.072 - 0.072*s, 0, 0,
+			0.213 - 0.213*s, 0.715 - 0.715*s, 0.072 + 0.928*s, 0, 0,
+			0, 0, 0, 1, 0}
+	case "hueRotate":
+		ang := float32(0)
+		if len(vals) > 0 {
+			ang = mat32.DegToRad(vals[0])
+		}
+		cs := mat32.Cos(ang)
+		sn := mat32.Sin(ang)
+		m = [20]float32{
+			0.213 + cs*0.787 - sn*0.213, 0.715 - cs*0.715 - sn*0.715, 0.072 - cs*0.072 + sn*0.928, 0, 0,
+			0.213 - cs*0.213 + sn*0.143, 0.715 + cs*0.285 + sn*0.140, 0.072 - cs*0.072 - sn*0.283, 0, 0,
+			0.213 - cs*0.213 - sn*0.787, 0.715 - cs*0.715 + sn*0.715, 0.072 + cs*0.928 + sn*0.072, 0, 0,
+			0, 0, 0, 1, 0}
+	case "luminanceToAlpha":
+		m = [20]float32{
+			0, 0, 0, 0, 0,
+			0, 0, 0, 0, 0,
+			0, 0, 0, 0, 0,
+			0.2125, 0.7154, 0.0721, 0, 0}
+	}
+	res := newFilterImage(fi.W, fi.H)
+	for i := 0; i < len(fi.Pix); i += 4 {
+		var c [4]float32
+		a := fi.Pix[i+3]
+		if a > 0 {
+			c = [4]float32{fi.Pix[i] / a, fi.Pix[i+1] / a, fi.Pix[i+2] / a, a}
+		}
+		var o [4]float32
+		for r := 0; r < 4; r++ {
+			o[r] = mat32.Clamp(m[r*5]*c[0]+m[r*5+1]*c[1]+m[r*5+2]*c[2]+m[r*5+3]*c[3]+m[r*5+4], 0, 1)
+		}
+		res.Pix[i] = o[0] * o[3]
+		res.Pix[i+1] = o[1] * o[3]
+		res.Pix[i+2] = o[2] * o[3]
+		res.Pix[i+3] = o[3]
+	}
+	return res
+}
+
+// composite returns the image composited with in2 (the backdrop) using
+// given Porter-Duff operator (over, in, out, atop, xor), or arithmetic
+// with the k1..k4 coefficients
+func (fi *filterImage) composite(in2 *filterImage, op string, k1, k2, k3, k4 float32) *filterImage {
+	res := newFilterImage(fi.W, fi.H)
+	for i := 0; i < len(fi.Pix); i += 4 {
+		aa := fi.Pix[i+3]
+		ba := in2.Pix[i+3]
+		var fa, fb float32 // Porter-Duff factors for a and b
+		switch op {
+		case "in":
+			fa, fb = ba, 0
+		case "out":
+			fa, fb = 1-ba, 0
+		case "atop":
+			fa, fb = ba, 1-aa
+		case "xor":
+			fa, fb = 1-ba, 1-aa
+		case "arithmetic":
+			for c := 0; c < 4; c++ {
+				a := fi.Pix[i+c]
+				b := in2.Pix[i+c]
+				res.Pix[i+c] = mat32.Clamp(k1*a*b+k2*a+k3*b+k4, 0, 1)
+			}
+			for c := 0; c < 3; c++ { // premultiplied colors can't exceed alpha
+				res.Pix[i+c] = mat32.Min(res.Pix[i+c], res.Pix[i+3])
+			}
+			continue
+		default: // over
+			fa, fb = 1, 1-aa
+		}
+		for c := 0; c < 4; c++ {
+			res.Pix[i+c] = fi.Pix[i+c]*fa + in2.Pix[i+c]*fb
+		}
+	}
+	return res
+}
+
+// blendFunc returns the blended color for given blend mode, for backdrop
+// color cb and source color cs (unpremultiplied)
+func blendFunc(mode string, cb, cs float32) float32 {
+	switch mode {
+	case "multiply":
+		return cb * cs
+	case "screen":
+		return cb + cs - cb*cs
+	case "darken":
+		return mat32.Min(cb, cs)
+	case "lighten":
+		return mat32.Max(cb, cs)
+	case "overlay":
+		return blendFunc("hard-light", cs, cb)
+	case "hard-light":
+		if cs <= .5 {
+			return blendFunc("multiply", cb, 2*cs)
+		}
+		return blendFunc("screen", cb, 2*cs-1)
+	case "difference":
+		return mat32.Abs(cb - cs)
+	case "exclusion":
+		return cb + cs - 2*cb*cs
+	}
+	return cs // normal
+}
+
+// blend returns the image blended over in2 (the backdrop) using given
+// blend mode (normal, multiply, screen, darken, lighten, overlay,
+// hard-light, difference, exclusion)
+func (fi *filterImage) blend(in2 *filterImage, mode string) *filterImage {
+	res := newFilterImage(fi.W, fi.H)
+	for i := 0; i < len(fi.Pix); i += 4 {
+		as := fi.Pix[i+3]
+		ab := in2.Pix[i+3]
+		for c := 0; c < 3; c++ {
+			cs := fi.Pix[i+c]
+			cb := in2.Pix[i+c]
+			bl := float32(0)
+			if as > 0 && ab > 0 {
+				bl = as * ab * blendFunc(mode, cb/ab, cs/as)
+			}
+			res.Pix[i+c] = (1-ab)*cs + (1-as)*cb + bl
+		}
+		res.Pix[i+3] = as + ab - as*ab
+	}
+	return res
+}
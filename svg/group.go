@@ -55,6 +55,7 @@ func (g *Group) Render2D() {
 	if g.Viewport == nil {
 		g.This().(gi.Node2D).Init2D()
 	}
+	eff := g.StartEffects()
 	pc := &g.Pnt
 	rs := &g.Viewport.Render
 	rs.PushXFormLock(pc.XForm)
@@ -63,4 +64,5 @@ func (g *Group) Render2D() {
 	g.ComputeBBoxSVG()
 
 	rs.PopXFormLock()
+	g.EndEffects(eff)
 }
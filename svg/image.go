@@ -0,0 +1,180 @@
+// Copyright (c) 2020, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package svg
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"log"
+	"net/url"
+	"strings"
+
+	"github.com/goki/gi/gi"
+	"github.com/goki/gi/mat32"
+	"github.com/goki/ki/ki"
+	"github.com/goki/ki/kit"
+	"golang.org/x/image/draw"
+	"golang.org/x/image/math/f64"
+)
+
+// Image is an SVG image (bitmap), which can be embedded as a data URI or
+// linked as a PNG or JPEG file
+type Image struct {
+	NodeBase
+	Pos                 mat32.Vec2                 `xml:"{x,y}" desc:"position of the top-left of the image"`
+	Size                mat32.Vec2                 `xml:"{width,height}" desc:"rendered size of the image -- the image is scaled to fit within this size, according to PreserveAspectRatio"`
+	PreserveAspectRatio ViewBoxPreserveAspectRatio `xml:"preserveAspectRatio" desc:"how to scale and align the image within its Size -- zero value is the SVG default of xMidYMid meet"`
+	Filename            gi.FileName                `desc:"file name of image loaded -- set by OpenImage -- empty for embedded images, which are saved as data URIs"`
+	Pixels              *image.RGBA                `copy:"-" xml:"-" json:"-" view:"-" desc:"the image pixels"`
+}
+
+var KiT_Image = kit.Types.AddType(&Image{}, ki.Props{"EnumType:Flag": gi.KiT_NodeFlags})
+
+// AddNewImage adds a new image to given parent node, with given name, pos, and size.
+func AddNewImage(parent ki.Ki, name string, x, y, sx, sy float32) *Image {
+	g := parent.AddNewChild(KiT_Image, name).(*Image)
+	g.Pos.Set(x, y)
+	g.Size.Set(sx, sy)
+	return g
+}
+
+func (g *Image) CopyFieldsFrom(frm interface{}) {
+	fr := frm.(*Image)
+	g.NodeBase.CopyFieldsFrom(&fr.NodeBase)
+	g.Pos = fr.Pos
+	g.Size = fr.Size
+	g.PreserveAspectRatio = fr.PreserveAspectRatio
+	g.Filename = fr.Filename
+	g.Pixels = fr.Pixels
+}
+
+// SetImage sets the image pixels from given image -- if Size is not yet
+// set, it is set to the image size
+func (g *Image) SetImage(img image.Image) {
+	sz := img.Bounds().Size()
+	g.Pixels = image.NewRGBA(image.Rectangle{Max: sz})
+	draw.Draw(g.Pixels, g.Pixels.Bounds(), img, img.Bounds().Min, draw.Src)
+	if g.Size.X == 0 && g.Size.Y == 0 {
+		g.Size.Set(float32(sz.X), float32(sz.Y))
+	}
+}
+
+// OpenImage opens the image from given file name (PNG or JPEG)
+func (g *Image) OpenImage(filename gi.FileName) error {
+	img, err := gi.OpenImage(string(filename))
+	if err != nil {
+		log.Printf("svg.Image.OpenImage -- could not open file: %v, err: %v\n", filename, err)
+		return err
+	}
+	g.Filename = filename
+	g.SetImage(img)
+	return nil
+}
+
+// DecodeDataURL decodes an image from a data URL, e.g.,
+// data:image/png;base64,iVBOR...
+func DecodeDataURL(dataURL string) (image.Image, error) {
+	if !strings.HasPrefix(dataURL, "data:") {
+		return nil, fmt.Errorf("svg.DecodeDataURL: not a data URL")
+	}
+	ci := strings.IndexByte(dataURL, ',')
+	if ci < 0 {
+		return nil, fmt.Errorf("svg.DecodeDataURL: no data in data URL")
+	}
+	hdr := dataURL[5:ci]
+	data := dataURL[ci+1:]
+	var bs []byte
+	var err error
+	if strings.HasSuffix(hdr, ";base64") {
+		data = strings.Map(func(r rune) rune { // strip any whitespace
+			if r == ' ' || r == '\n' || r == '\r' || r == '\t' {
+				return -1
+			}
+			return r
+		}, data)
+		bs, err = base64.StdEncoding.DecodeString(data)
+	} else {
+		var us string
+		us, err = url.PathUnescape(data)
+		bs = []byte(us)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("svg.DecodeDataURL: %v", err)
+	}
+	img, _, err := image.Decode(bytes.NewReader(bs))
+	if err != nil {
+		return nil, fmt.Errorf("svg.DecodeDataURL: %v", err)
+	}
+	return img, nil
+}
+
+// EncodeDataURL returns the image encoded as a PNG data URL
+func EncodeDataURL(img image.Image) (string, error) {
+	var b bytes.Buffer
+	if err := png.Encode(&b, img); err != nil {
+		return "", err
+	}
+	return "data:image/png;base64," + base64.StdEncoding.EncodeToString(b.Bytes()), nil
+}
+
+// ImageTransform returns the transform from image pixels to user
+// coordinates, fitting the image within Pos and Size
+func (g *Image) ImageTransform() mat32.Mat2 {
+	sz := g.Pixels.Bounds().Size()
+	vb := ViewBox{Size: mat32.Vec2{X: float32(sz.X), Y: float32(sz.Y)}, PreserveAspectRatio: g.PreserveAspectRatio}
+	return vb.Transform(g.Pos, g.Size)
+}
+
+// DrawImage draws the image into given render state, with given opacity
+func (g *Image) DrawImage(rs *gi.RenderState, opacity float32) {
+	if g.Pixels == nil || g.Size.X <= 0 || g.Size.Y <= 0 || opacity <= 0 {
+		return
+	}
+	ixf := g.ImageTransform()
+	// only the part of the image within Pos, Size is visible (for slice)
+	vis := xformRect(ixf.Inverse(), g.Pos, g.Size).Intersect(g.Pixels.Bounds())
+	if vis.Empty() {
+		return
+	}
+	m := ixf.Mul(rs.XForm)
+	s2d := f64.Aff3{float64(m.XX), float64(m.XY), float64(m.X0), float64(m.YX), float64(m.YY), float64(m.Y0)}
+	bounds := rs.Image.Bounds()
+	if !rs.Bounds.Empty() {
+		bounds = bounds.Intersect(rs.Bounds)
+	}
+	dst := rs.Image.SubImage(bounds).(*image.RGBA)
+	var opts *draw.Options
+	if opacity < 1 {
+		opts = &draw.Options{SrcMask: image.NewUniform(color.Alpha{uint8(opacity * 255)})}
+	}
+	draw.BiLinear.Transform(dst, s2d, g.Pixels, vis, draw.Over, opts)
+	rs.LastRenderBBox = xformRect(rs.XForm, g.Pos, g.Size).Intersect(bounds)
+}
+
+func (g *Image) Render2D() {
+	if g.Viewport == nil {
+		g.This().(gi.Node2D).Init2D()
+	}
+	if g.Pixels == nil {
+		return
+	}
+	eff := g.StartEffects()
+	pc := &g.Pnt
+	rs := &g.Viewport.Render
+	rs.Lock()
+	rs.PushXForm(pc.XForm)
+	g.DrawImage(rs, pc.FontStyle.Opacity)
+	rs.Unlock()
+
+	g.ComputeBBoxSVG()
+	g.Render2DChildren()
+
+	rs.PopXFormLock()
+	g.EndEffects(eff)
+}
@@ -0,0 +1,119 @@
+// Copyright (c) 2020, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package svg
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"strings"
+	"testing"
+
+	"github.com/goki/gi/mat32"
+)
+
+func TestViewBoxTransform(t *testing.T) {
+	vb := ViewBox{Size: mat32.Vec2{X: 10, Y: 20}}
+	// default xMidYMid meet: scale 2, centered horizontally
+	xf := vb.Transform(mat32.Vec2{X: 0, Y: 0}, mat32.Vec2{X: 100, Y: 40})
+	if p := xf.MulVec2AsPt(mat32.Vec2{X: 0, Y: 0}); p != (mat32.Vec2{X: 40, Y: 0}) {
+		t.Errorf("meet origin: %v", p)
+	}
+	if p := xf.MulVec2AsPt(mat32.Vec2{X: 10, Y: 20}); p != (mat32.Vec2{X: 60, Y: 40}) {
+		t.Errorf("meet corner: %v", p)
+	}
+	if err := vb.PreserveAspectRatio.SetString("none"); err != nil {
+		t.Fatal(err)
+	}
+	xf = vb.Transform(mat32.Vec2{X: 0, Y: 0}, mat32.Vec2{X: 100, Y: 40})
+	if p := xf.MulVec2AsPt(mat32.Vec2{X: 10, Y: 20}); p != (mat32.Vec2{X: 100, Y: 40}) {
+		t.Errorf("none corner: %v", p)
+	}
+	if inv := xf.Mul(xf.Inverse()); inv != mat32.Identity2D() {
+		t.Errorf("inverse: %v", inv)
+	}
+}
+
+func TestDataURL(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 2, 2))
+	img.Set(1, 0, color.RGBA{255, 0, 0, 255})
+	du, err := EncodeDataURL(img)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dimg, err := DecodeDataURL(du)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r, _, _, a := dimg.At(1, 0).RGBA(); r != 0xffff || a != 0xffff {
+		t.Errorf("decoded pixel: %v", dimg.At(1, 0))
+	}
+	if _, err := DecodeDataURL("image.png"); err == nil {
+		t.Errorf("expected error for non data URL")
+	}
+}
+
+func TestReadImageSymbol(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 4, 2))
+	du, _ := EncodeDataURL(img)
+	src := `<svg xmlns="http://www.w3.org/2000/svg" xmlns:xlink="http://www.w3.org/1999/xlink" viewBox="0 0 100 100">
+<defs>
+<pattern id="dots" width="10" height="10" patternUnits="userSpaceOnUse"><circle cx="5" cy="5" r="2"/></pattern>
+<mask id="fade"><rect x="0" y="0" width="100" height="100" fill="white"/></mask>
+<symbol id="sq" viewBox="0 0 10 10"><rect x="0" y="0" width="10" height="10"/></symbol>
+</defs>
+<image x="1" y="2" width="8" height="4" xlink:href="` + du + `"/>
+<use xlink:href="#sq" x="20" y="30" width="20" height="20" fill="url(#dots)" mask="url(#fade)"/>
+</svg>`
+	sv := &SVG{}
+	sv.InitName(sv, "svg")
+	if err := sv.ReadXML(strings.NewReader(src)); err != nil {
+		t.Fatal(err)
+	}
+	im, ok := sv.Kids[0].(*Image)
+	if !ok {
+		t.Fatalf("expected Image, got: %T", sv.Kids[0])
+	}
+	if im.Pixels == nil || im.Pixels.Bounds().Size() != (image.Point{4, 2}) {
+		t.Errorf("image pixels not decoded: %v", im.Pixels)
+	}
+	if im.Pos != (mat32.Vec2{X: 1, Y: 2}) || im.Size != (mat32.Vec2{X: 8, Y: 4}) {
+		t.Errorf("image pos, size: %v %v", im.Pos, im.Size)
+	}
+	use, ok := sv.Kids[1].(*Use)
+	if !ok || len(use.Kids) != 1 {
+		t.Fatalf("expected Use with symbol contents, got: %v", sv.Kids[1])
+	}
+	grp, ok := use.Kids[0].(*Group)
+	if !ok || len(grp.Kids) != 1 {
+		t.Fatalf("expected group with symbol contents, got: %v", use.Kids[0])
+	}
+	if tr := grp.PropString("transform", ""); tr != "matrix(2,0,0,2,20,30)" {
+		t.Errorf("use transform: %v", tr)
+	}
+	if _, ok := sv.FindNamedElement("dots").(*Pattern); !ok {
+		t.Errorf("pattern not found in defs")
+	}
+	if _, ok := sv.FindNamedElement("fade").(*Mask); !ok {
+		t.Errorf("mask not found in defs")
+	}
+
+	var b bytes.Buffer
+	if err := sv.WriteXML(&b, false); err != nil {
+		t.Fatal(err)
+	}
+	out := b.String()
+	for _, want := range []string{
+		`<pattern id="dots" height="10" patternUnits="userSpaceOnUse" width="10">`,
+		`<mask id="fade">`,
+		`<symbol id="sq" viewBox="0 0 10 10">`,
+		`<image x="1" y="2" width="8" height="4" xlink:href="data:image/png;base64,`,
+		`<use x="20" y="30" width="20" height="20" xlink:href="#sq" fill="url(#dots)" mask="url(#fade)"></use>`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output does not contain: %v\n%v", want, out)
+		}
+	}
+}
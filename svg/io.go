@@ -11,18 +11,26 @@
 package svg
 
 import (
+	"bufio"
 	"encoding/xml"
 	"errors"
 	"fmt"
+	"image"
+	"image/color"
 	"io"
 	"log"
 	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/goki/gi/gi"
 	"github.com/goki/gi/mat32"
 	"github.com/goki/gi/units"
 	"github.com/goki/ki/ki"
+	"github.com/goki/ki/kit"
+	"github.com/srwiley/rasterx"
 	"golang.org/x/net/html/charset"
 )
 
@@ -56,6 +64,7 @@ func (svg *SVG) OpenXML(filename string) error {
 		log.Println(err)
 		return err
 	}
+	svg.Filename = gi.FileName(filename)
 	return svg.ReadXML(fp)
 }
 
@@ -77,6 +86,7 @@ func (svg *SVG) ReadXML(reader io.Reader) error {
 		t, err = decoder.Token()
 		if err != nil {
 			if err == io.EOF {
+				err = nil
 				break
 			}
 			log.Printf("gi.SVG parsing error: %v\n", err)
@@ -113,6 +123,7 @@ func (svg *SVG) UnmarshalXML(decoder *xml.Decoder, se xml.StartElement) error {
 	inTspn := false
 	var curTspn *Text
 	var defPrevPar gi.Node2D // previous parent before a def encountered
+	xnm := make(xmlNames)    // namespace prefixes, to preserve prefixed names
 
 	for {
 		var t xml.Token
@@ -133,6 +144,7 @@ func (svg *SVG) UnmarshalXML(decoder *xml.Decoder, se xml.StartElement) error {
 		switch se := t.(type) {
 		case xml.StartElement:
 			nm := se.Name.Local
+			xnm.addPrefixes(se.Attr)
 			switch {
 			case nm == "svg":
 				if curPar != svg.This() {
@@ -165,7 +177,7 @@ func (svg *SVG) UnmarshalXML(decoder *xml.Decoder, se xml.StartElement) error {
 						ht.ToDots(&csvg.Pnt.UnContext)
 						csvg.ViewBox.Size.Y = ht.Dots
 					default:
-						curPar.SetProp(attr.Name.Local, attr.Value)
+						curPar.SetProp(xnm.attrName(attr.Name), attr.Value)
 					}
 				}
 			case nm == "desc":
@@ -184,7 +196,7 @@ func (svg *SVG) UnmarshalXML(decoder *xml.Decoder, se xml.StartElement) error {
 					}
 					switch attr.Name.Local {
 					default:
-						curPar.SetProp(attr.Name.Local, attr.Value)
+						curPar.SetProp(xnm.attrName(attr.Name), attr.Value)
 					}
 				}
 			case nm == "rect":
@@ -208,7 +220,7 @@ func (svg *SVG) UnmarshalXML(decoder *xml.Decoder, se xml.StartElement) error {
 					case "ry":
 						ry, err = mat32.ParseFloat32(attr.Value)
 					default:
-						rect.SetProp(attr.Name.Local, attr.Value)
+						rect.SetProp(xnm.attrName(attr.Name), attr.Value)
 					}
 					if err != nil {
 						return err
@@ -232,7 +244,7 @@ func (svg *SVG) UnmarshalXML(decoder *xml.Decoder, se xml.StartElement) error {
 					case "r":
 						r, err = mat32.ParseFloat32(attr.Value)
 					default:
-						circle.SetProp(attr.Name.Local, attr.Value)
+						circle.SetProp(xnm.attrName(attr.Name), attr.Value)
 					}
 					if err != nil {
 						return err
@@ -257,7 +269,7 @@ func (svg *SVG) UnmarshalXML(decoder *xml.Decoder, se xml.StartElement) error {
 					case "ry":
 						ry, err = mat32.ParseFloat32(attr.Value)
 					default:
-						ellipse.SetProp(attr.Name.Local, attr.Value)
+						ellipse.SetProp(xnm.attrName(attr.Name), attr.Value)
 					}
 					if err != nil {
 						return err
@@ -282,7 +294,7 @@ func (svg *SVG) UnmarshalXML(decoder *xml.Decoder, se xml.StartElement) error {
 					case "y2":
 						y2, err = mat32.ParseFloat32(attr.Value)
 					default:
-						line.SetProp(attr.Name.Local, attr.Value)
+						line.SetProp(xnm.attrName(attr.Name), attr.Value)
 					}
 					if err != nil {
 						return err
@@ -313,7 +325,7 @@ func (svg *SVG) UnmarshalXML(decoder *xml.Decoder, se xml.StartElement) error {
 							polygon.Points = pvec
 						}
 					default:
-						polygon.SetProp(attr.Name.Local, attr.Value)
+						polygon.SetProp(xnm.attrName(attr.Name), attr.Value)
 					}
 					if err != nil {
 						return err
@@ -342,7 +354,7 @@ func (svg *SVG) UnmarshalXML(decoder *xml.Decoder, se xml.StartElement) error {
 							polyline.Points = pvec
 						}
 					default:
-						polyline.SetProp(attr.Name.Local, attr.Value)
+						polyline.SetProp(xnm.attrName(attr.Name), attr.Value)
 					}
 					if err != nil {
 						return err
@@ -358,7 +370,7 @@ func (svg *SVG) UnmarshalXML(decoder *xml.Decoder, se xml.StartElement) error {
 					case "d":
 						path.SetData(attr.Value)
 					default:
-						path.SetProp(attr.Name.Local, attr.Value)
+						path.SetProp(xnm.attrName(attr.Name), attr.Value)
 					}
 					if err != nil {
 						return err
@@ -428,7 +440,7 @@ func (svg *SVG) UnmarshalXML(decoder *xml.Decoder, se xml.StartElement) error {
 							txt.AdjustGlyphs = false
 						}
 					default:
-						txt.SetProp(attr.Name.Local, attr.Value)
+						txt.SetProp(xnm.attrName(attr.Name), attr.Value)
 					}
 					if err != nil {
 						return err
@@ -503,7 +515,7 @@ func (svg *SVG) UnmarshalXML(decoder *xml.Decoder, se xml.StartElement) error {
 					}
 					switch attr.Name.Local {
 					default:
-						cp.SetProp(attr.Name.Local, attr.Value)
+						cp.SetProp(xnm.attrName(attr.Name), attr.Value)
 					}
 				}
 			case nm == "marker":
@@ -525,7 +537,7 @@ func (svg *SVG) UnmarshalXML(decoder *xml.Decoder, se xml.StartElement) error {
 						szx, err = mat32.ParseFloat32(attr.Value)
 					case "markerHeight":
 						szy, err = mat32.ParseFloat32(attr.Value)
-					case "matrixUnits":
+					case "markerUnits", "matrixUnits":
 						if attr.Value == "strokeWidth" {
 							mrk.Units = StrokeWidth
 						} else {
@@ -543,7 +555,7 @@ func (svg *SVG) UnmarshalXML(decoder *xml.Decoder, se xml.StartElement) error {
 					case "orient":
 						mrk.Orient = attr.Value
 					default:
-						mrk.SetProp(attr.Name.Local, attr.Value)
+						mrk.SetProp(xnm.attrName(attr.Name), attr.Value)
 					}
 					if err != nil {
 						return err
@@ -554,20 +566,86 @@ func (svg *SVG) UnmarshalXML(decoder *xml.Decoder, se xml.StartElement) error {
 			case nm == "use":
 				link := gi.XMLAttr("href", se.Attr)
 				itm := curPar.FindNamedElement(link)
-				if itm != nil {
-					cln := itm.Clone().(gi.Node2D)
-					if cln != nil {
-						curPar.AddChild(cln)
-						for _, attr := range se.Attr {
-							if cln.AsNode2D().SetStdXMLAttr(attr.Name.Local, attr.Value) {
-								continue
+				if itm == nil {
+					break
+				}
+				use := AddNewUse(curPar, "use", link)
+				for _, attr := range se.Attr {
+					if use.SetStdXMLAttr(attr.Name.Local, attr.Value) {
+						continue
+					}
+					switch attr.Name.Local {
+					case "href":
+					case "x":
+						use.Pos.X, err = mat32.ParseFloat32(attr.Value)
+					case "y":
+						use.Pos.Y, err = mat32.ParseFloat32(attr.Value)
+					case "width":
+						use.Size.X, err = mat32.ParseFloat32(attr.Value)
+					case "height":
+						use.Size.Y, err = mat32.ParseFloat32(attr.Value)
+					default:
+						use.SetProp(xnm.attrName(attr.Name), attr.Value)
+					}
+					if err != nil {
+						return err
+					}
+				}
+				use.SetUsed(itm)
+			case nm == "image":
+				img := AddNewImage(curPar, "image", 0, 0, 0, 0)
+				for _, attr := range se.Attr {
+					if img.SetStdXMLAttr(attr.Name.Local, attr.Value) {
+						continue
+					}
+					switch attr.Name.Local {
+					case "x":
+						img.Pos.X, err = mat32.ParseFloat32(attr.Value)
+					case "y":
+						img.Pos.Y, err = mat32.ParseFloat32(attr.Value)
+					case "width":
+						img.Size.X, err = mat32.ParseFloat32(attr.Value)
+					case "height":
+						img.Size.Y, err = mat32.ParseFloat32(attr.Value)
+					case "preserveAspectRatio":
+						err = img.PreserveAspectRatio.SetString(attr.Value)
+					case "href":
+						if strings.HasPrefix(attr.Value, "data:") {
+							var im image.Image
+							if im, err = DecodeDataURL(attr.Value); err == nil {
+								img.SetImage(im)
 							}
-							switch attr.Name.Local {
-							default:
-								cln.SetProp(attr.Name.Local, attr.Value)
+						} else {
+							fn := attr.Value
+							if !filepath.IsAbs(fn) && svg.Filename != "" {
+								fn = filepath.Join(filepath.Dir(string(svg.Filename)), fn)
+							}
+							if img.OpenImage(gi.FileName(fn)) == nil {
+								img.Filename = gi.FileName(attr.Value) // keep as linked
 							}
 						}
+					default:
+						img.SetProp(xnm.attrName(attr.Name), attr.Value)
 					}
+					if err != nil {
+						log.Println(err)
+						err = nil
+					}
+				}
+			case nm == "pattern", nm == "mask", nm == "symbol":
+				switch nm {
+				case "pattern":
+					curPar = curPar.AddNewChild(KiT_Pattern, nm).(gi.Node2D)
+				case "mask":
+					curPar = curPar.AddNewChild(KiT_Mask, nm).(gi.Node2D)
+				default:
+					curPar = curPar.AddNewChild(KiT_Symbol, nm).(gi.Node2D)
+				}
+				for _, attr := range se.Attr {
+					if curPar.AsNode2D().SetStdXMLAttr(attr.Name.Local, attr.Value) {
+						continue
+					}
+					curPar.SetProp(xnm.attrName(attr.Name), attr.Value)
 				}
 			case nm == "Work":
 				fallthrough
@@ -589,13 +667,14 @@ func (svg *SVG) UnmarshalXML(decoder *xml.Decoder, se xml.StartElement) error {
 				curPar = curPar.AddNewChild(gi.KiT_MetaData2D, nm).(gi.Node2D)
 				md := curPar.(*gi.MetaData2D)
 				md.Class = nm
+				md.Element = xnm.name(se.Name)
 				for _, attr := range se.Attr {
 					if md.SetStdXMLAttr(attr.Name.Local, attr.Value) {
 						continue
 					}
 					switch attr.Name.Local {
 					default:
-						curPar.SetProp(attr.Name.Local, attr.Value)
+						curPar.SetProp(xnm.attrName(attr.Name), attr.Value)
 					}
 				}
 			case strings.HasPrefix(nm, "flow"):
@@ -609,7 +688,7 @@ func (svg *SVG) UnmarshalXML(decoder *xml.Decoder, se xml.StartElement) error {
 					}
 					switch attr.Name.Local {
 					default:
-						curPar.SetProp(attr.Name.Local, attr.Value)
+						curPar.SetProp(xnm.attrName(attr.Name), attr.Value)
 					}
 				}
 			case strings.HasPrefix(nm, "fe"):
@@ -627,7 +706,7 @@ func (svg *SVG) UnmarshalXML(decoder *xml.Decoder, se xml.StartElement) error {
 					}
 					switch attr.Name.Local {
 					default:
-						curPar.SetProp(attr.Name.Local, attr.Value)
+						curPar.SetProp(xnm.attrName(attr.Name), attr.Value)
 					}
 				}
 			default:
@@ -663,6 +742,7 @@ func (svg *SVG) UnmarshalXML(decoder *xml.Decoder, se xml.StartElement) error {
 			case "polyline":
 			case "path":
 			case "use":
+			case "image":
 			case "linearGradient":
 			case "radialGradient":
 			default:
@@ -682,15 +762,15 @@ func (svg *SVG) UnmarshalXML(decoder *xml.Decoder, se xml.StartElement) error {
 				}
 			}
 		case xml.CharData:
-			// (ok, md := curPar.(*MetaData2D); ok)
 			trspc := strings.TrimSpace(string(se))
+			md, isMd := curPar.(*gi.MetaData2D)
 			switch {
-			// case :
-			// 	md.MetaData = string(se)
 			case inTitle:
 				curSvg.Title += trspc
 			case inDesc:
 				curSvg.Desc += trspc
+			case isMd:
+				md.MetaData += trspc
 			case inTspn && curTspn != nil:
 				curTspn.Text = trspc
 			case inTxt && curTxt != nil:
@@ -710,3 +790,561 @@ func (svg *SVG) UnmarshalXML(decoder *xml.Decoder, se xml.StartElement) error {
 	}
 	return nil
 }
+
+/////////////////////////////////////////////////////////////////////////////
+//   Writing
+
+// xmlNames maps namespace URLs to prefixes, so that prefixed names (e.g.,
+// inkscape:label) can be preserved for writing back out.
+type xmlNames map[string]string
+
+// xmlNamespace is the standard namespace for xml: attributes (e.g., xml:space)
+const xmlNamespace = "http://www.w3.org/XML/1998/namespace"
+
+// addPrefixes records any xmlns:prefix namespace declarations in attrs
+func (xn xmlNames) addPrefixes(attrs []xml.Attr) {
+	for _, attr := range attrs {
+		if attr.Name.Space == "xmlns" {
+			xn[attr.Value] = attr.Name.Local
+		}
+	}
+}
+
+// name returns the name, with its namespace prefix if it has one
+func (xn xmlNames) name(n xml.Name) string {
+	switch {
+	case n.Space == "":
+		return n.Local
+	case n.Space == xmlNamespace:
+		return "xml:" + n.Local
+	}
+	if pfx, has := xn[n.Space]; has {
+		return pfx + ":" + n.Local
+	}
+	if !strings.ContainsAny(n.Space, ":/") { // undeclared prefix
+		return n.Space + ":" + n.Local
+	}
+	return n.Local
+}
+
+// attrName returns the name of attribute, with its namespace prefix
+// if it has one, including xmlns:prefix declarations
+func (xn xmlNames) attrName(n xml.Name) string {
+	if n.Space == "xmlns" {
+		return "xmlns:" + n.Local
+	}
+	return xn.name(n)
+}
+
+// SaveXML saves the svg to a XML-encoded file, using WriteXML
+func (svg *SVG) SaveXML(filename string) error {
+	fp, err := os.Create(filename)
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+	defer fp.Close()
+	bw := bufio.NewWriter(fp)
+	err = svg.WriteXML(bw, true)
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+	err = bw.Flush()
+	if err != nil {
+		log.Println(err)
+	}
+	return err
+}
+
+// WriteXML writes XML-formatted SVG output to io.Writer, encoding the full
+// SVG scenegraph, such that it can be read back in with ReadXML.
+func (svg *SVG) WriteXML(wr io.Writer, indent bool) error {
+	enc := xml.NewEncoder(wr)
+	if indent {
+		enc.Indent("", "  ")
+	}
+	_, err := io.WriteString(wr, xml.Header)
+	if err != nil {
+		return err
+	}
+	err = svg.MarshalXML(enc, xml.StartElement{Name: xml.Name{Local: "svg"}})
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+	err = enc.Flush()
+	if err == nil && indent {
+		_, err = io.WriteString(wr, "\n")
+	}
+	return err
+}
+
+// MarshalXML marshals the svg using xml.Encoder
+func (svg *SVG) MarshalXML(enc *xml.Encoder, se xml.StartElement) error {
+	se.Name.Local = "svg"
+	se.Attr = append(se.Attr, svg.svgAttrs(true)...)
+	if err := enc.EncodeToken(se); err != nil {
+		return err
+	}
+	if err := svg.marshalSVGContents(enc); err != nil {
+		return err
+	}
+	return enc.EncodeToken(se.End())
+}
+
+// svgAttrs returns the attributes for svg element -- top-level ones
+// also get the standard namespace declarations if not otherwise present
+func (svg *SVG) svgAttrs(top bool) []xml.Attr {
+	props := svg.Props
+	if top {
+		props = make(ki.Props, len(svg.Props)+2)
+		for key, val := range svg.Props {
+			props[key] = val
+		}
+		if _, has := props["xmlns"]; !has {
+			props["xmlns"] = "http://www.w3.org/2000/svg"
+		}
+		if _, has := props["xmlns:xlink"]; !has {
+			props["xmlns:xlink"] = "http://www.w3.org/1999/xlink"
+		}
+	}
+	var attrs []xml.Attr
+	attrs = xmlIDAttrs(attrs, svg.This().(gi.Node2D), "svg")
+	vb := &svg.ViewBox
+	if vb.Size != mat32.Vec2Zero {
+		attrs = xmlAddAttr(attrs, "viewBox", xmlFloats([]float32{vb.Min.X, vb.Min.Y, vb.Size.X, vb.Size.Y}, " "))
+		attrs = xmlAddAttr(attrs, "width", xmlFloat(vb.Size.X))
+		attrs = xmlAddAttr(attrs, "height", xmlFloat(vb.Size.Y))
+	}
+	return xmlPropAttrs(attrs, props)
+}
+
+// marshalSVGContents writes the title, desc, defs and children of svg
+func (svg *SVG) marshalSVGContents(enc *xml.Encoder) error {
+	if svg.Title != "" {
+		if err := xmlTextElement(enc, "title", svg.Title); err != nil {
+			return err
+		}
+	}
+	if svg.Desc != "" {
+		if err := xmlTextElement(enc, "desc", svg.Desc); err != nil {
+			return err
+		}
+	}
+	if svg.Defs.HasChildren() {
+		de := xml.StartElement{Name: xml.Name{Local: "defs"}}
+		if err := enc.EncodeToken(de); err != nil {
+			return err
+		}
+		if err := MarshalXMLChildren(enc, svg.Defs.This()); err != nil {
+			return err
+		}
+		if err := enc.EncodeToken(de.End()); err != nil {
+			return err
+		}
+	}
+	return MarshalXMLChildren(enc, svg.This())
+}
+
+// MarshalXMLChildren marshals all the children of given node, using
+// MarshalXMLNode
+func MarshalXMLChildren(enc *xml.Encoder, par ki.Ki) error {
+	for _, k := range *par.Children() {
+		if err := MarshalXMLNode(enc, k); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MarshalXMLNode marshals given svg node as the corresponding SVG element,
+// including all of its children.  Nodes that have no SVG equivalent are
+// skipped, with a log message.
+func MarshalXMLNode(enc *xml.Encoder, k ki.Ki) error {
+	gii, ok := k.(gi.Node2D)
+	if !ok {
+		return nil
+	}
+	se := xml.StartElement{}
+	var attrs []xml.Attr
+	text := ""
+	noKids := false
+	switch nd := k.(type) {
+	case *SVG:
+		se.Name.Local = "svg"
+		se.Attr = nd.svgAttrs(false)
+		if err := enc.EncodeToken(se); err != nil {
+			return err
+		}
+		if err := nd.marshalSVGContents(enc); err != nil {
+			return err
+		}
+		return enc.EncodeToken(se.End())
+	case *gi.Gradient:
+		return marshalXMLGradient(enc, nd)
+	case *gi.StyleSheet:
+		se.Name.Local = "style"
+		attrs = xmlIDAttrs(attrs, gii, "style")
+		attrs = xmlAddAttr(attrs, "type", "text/css")
+		if nd.Sheet != nil {
+			text = nd.Sheet.String()
+		}
+	case *gi.MetaData2D:
+		se.Name.Local = nd.Element
+		if se.Name.Local == "" {
+			se.Name.Local = nd.Nm
+		}
+		if lnm := se.Name.Local[strings.IndexByte(se.Name.Local, ':')+1:]; nd.Nm != lnm {
+			attrs = xmlAddAttr(attrs, "id", nd.Nm)
+		}
+		text = nd.MetaData
+	case *Group:
+		se.Name.Local = "g"
+		attrs = xmlIDAttrs(attrs, gii, "g")
+	case *Use:
+		se.Name.Local = "use"
+		attrs = xmlIDAttrs(attrs, gii, "use")
+		if nd.Pos != mat32.Vec2Zero {
+			attrs = xmlAddAttr(attrs, "x", xmlFloat(nd.Pos.X))
+			attrs = xmlAddAttr(attrs, "y", xmlFloat(nd.Pos.Y))
+		}
+		if nd.Size != mat32.Vec2Zero {
+			attrs = xmlAddAttr(attrs, "width", xmlFloat(nd.Size.X))
+			attrs = xmlAddAttr(attrs, "height", xmlFloat(nd.Size.Y))
+		}
+		attrs = xmlAddAttr(attrs, "xlink:href", nd.Link)
+		noKids = true // the children are a copy of the used element
+	case *Rect:
+		se.Name.Local = "rect"
+		attrs = xmlIDAttrs(attrs, gii, "rect")
+		attrs = xmlAddAttr(attrs, "x", xmlFloat(nd.Pos.X))
+		attrs = xmlAddAttr(attrs, "y", xmlFloat(nd.Pos.Y))
+		attrs = xmlAddAttr(attrs, "width", xmlFloat(nd.Size.X))
+		attrs = xmlAddAttr(attrs, "height", xmlFloat(nd.Size.Y))
+		if nd.Radius.X != 0 {
+			attrs = xmlAddAttr(attrs, "rx", xmlFloat(nd.Radius.X))
+		}
+		if nd.Radius.Y != 0 {
+			attrs = xmlAddAttr(attrs, "ry", xmlFloat(nd.Radius.Y))
+		}
+	case *Circle:
+		se.Name.Local = "circle"
+		attrs = xmlIDAttrs(attrs, gii, "circle")
+		attrs = xmlAddAttr(attrs, "cx", xmlFloat(nd.Pos.X))
+		attrs = xmlAddAttr(attrs, "cy", xmlFloat(nd.Pos.Y))
+		attrs = xmlAddAttr(attrs, "r", xmlFloat(nd.Radius))
+	case *Ellipse:
+		se.Name.Local = "ellipse"
+		attrs = xmlIDAttrs(attrs, gii, "ellipse")
+		attrs = xmlAddAttr(attrs, "cx", xmlFloat(nd.Pos.X))
+		attrs = xmlAddAttr(attrs, "cy", xmlFloat(nd.Pos.Y))
+		attrs = xmlAddAttr(attrs, "rx", xmlFloat(nd.Radii.X))
+		attrs = xmlAddAttr(attrs, "ry", xmlFloat(nd.Radii.Y))
+	case *Line:
+		se.Name.Local = "line"
+		attrs = xmlIDAttrs(attrs, gii, "line")
+		attrs = xmlAddAttr(attrs, "x1", xmlFloat(nd.Start.X))
+		attrs = xmlAddAttr(attrs, "y1", xmlFloat(nd.Start.Y))
+		attrs = xmlAddAttr(attrs, "x2", xmlFloat(nd.End.X))
+		attrs = xmlAddAttr(attrs, "y2", xmlFloat(nd.End.Y))
+	case *Polygon:
+		se.Name.Local = "polygon"
+		attrs = xmlIDAttrs(attrs, gii, "polygon")
+		attrs = xmlAddAttr(attrs, "points", xmlPoints(nd.Points))
+	case *Polyline:
+		se.Name.Local = "polyline"
+		attrs = xmlIDAttrs(attrs, gii, "polyline")
+		attrs = xmlAddAttr(attrs, "points", xmlPoints(nd.Points))
+	case *Path:
+		se.Name.Local = "path"
+		attrs = xmlIDAttrs(attrs, gii, "path")
+		attrs = xmlAddAttr(attrs, "d", PathDataString(nd.Data))
+	case *Text:
+		if _, inTxt := nd.Par.(*Text); inTxt {
+			se.Name.Local = "tspan"
+			attrs = xmlIDAttrs(attrs, gii, "tspan")
+		} else {
+			se.Name.Local = "text"
+			attrs = xmlIDAttrs(attrs, gii, "txt")
+		}
+		if len(nd.CharPosX) > 1 {
+			attrs = xmlAddAttr(attrs, "x", xmlFloats(nd.CharPosX, " "))
+		} else {
+			attrs = xmlAddAttr(attrs, "x", xmlFloat(nd.Pos.X))
+		}
+		if len(nd.CharPosY) > 1 {
+			attrs = xmlAddAttr(attrs, "y", xmlFloats(nd.CharPosY, " "))
+		} else {
+			attrs = xmlAddAttr(attrs, "y", xmlFloat(nd.Pos.Y))
+		}
+		if len(nd.CharPosDX) > 0 {
+			attrs = xmlAddAttr(attrs, "dx", xmlFloats(nd.CharPosDX, " "))
+		}
+		if len(nd.CharPosDY) > 0 {
+			attrs = xmlAddAttr(attrs, "dy", xmlFloats(nd.CharPosDY, " "))
+		}
+		if len(nd.CharRots) > 0 {
+			attrs = xmlAddAttr(attrs, "rotate", xmlFloats(nd.CharRots, " "))
+		}
+		if nd.TextLength != 0 {
+			attrs = xmlAddAttr(attrs, "textLength", xmlFloat(nd.TextLength))
+			if nd.AdjustGlyphs {
+				attrs = xmlAddAttr(attrs, "lengthAdjust", "spacingAndGlyphs")
+			}
+		}
+		text = nd.Text
+	case *ClipPath:
+		se.Name.Local = "clipPath"
+		attrs = xmlIDAttrs(attrs, gii, "clip-path")
+	case *Marker:
+		se.Name.Local = "marker"
+		attrs = xmlIDAttrs(attrs, gii, "marker")
+		attrs = xmlAddAttr(attrs, "refX", xmlFloat(nd.RefPos.X))
+		attrs = xmlAddAttr(attrs, "refY", xmlFloat(nd.RefPos.Y))
+		attrs = xmlAddAttr(attrs, "markerWidth", xmlFloat(nd.Size.X))
+		attrs = xmlAddAttr(attrs, "markerHeight", xmlFloat(nd.Size.Y))
+		if nd.Units == UserSpaceOnUse {
+			attrs = xmlAddAttr(attrs, "markerUnits", "userSpaceOnUse")
+		}
+		if vb := &nd.ViewBox; vb.Size != mat32.Vec2Zero {
+			attrs = xmlAddAttr(attrs, "viewBox", xmlFloats([]float32{vb.Min.X, vb.Min.Y, vb.Size.X, vb.Size.Y}, " "))
+		}
+		if nd.Orient != "" {
+			attrs = xmlAddAttr(attrs, "orient", nd.Orient)
+		}
+	case *Flow:
+		se.Name.Local = nd.FlowType
+		attrs = xmlIDAttrs(attrs, gii, nd.FlowType)
+	case *Filter:
+		se.Name.Local = nd.FilterType
+		attrs = xmlIDAttrs(attrs, gii, nd.FilterType)
+	case *Image:
+		se.Name.Local = "image"
+		attrs = xmlIDAttrs(attrs, gii, "image")
+		attrs = xmlAddAttr(attrs, "x", xmlFloat(nd.Pos.X))
+		attrs = xmlAddAttr(attrs, "y", xmlFloat(nd.Pos.Y))
+		attrs = xmlAddAttr(attrs, "width", xmlFloat(nd.Size.X))
+		attrs = xmlAddAttr(attrs, "height", xmlFloat(nd.Size.Y))
+		if nd.PreserveAspectRatio.Align != 0 {
+			attrs = xmlAddAttr(attrs, "preserveAspectRatio", nd.PreserveAspectRatio.String())
+		}
+		href := string(nd.Filename)
+		if href == "" && nd.Pixels != nil {
+			var err error
+			if href, err = EncodeDataURL(nd.Pixels); err != nil {
+				return err
+			}
+		}
+		if href != "" {
+			attrs = xmlAddAttr(attrs, "xlink:href", href)
+		}
+	case *Pattern:
+		se.Name.Local = "pattern"
+		attrs = xmlIDAttrs(attrs, gii, "pattern")
+	case *Mask:
+		se.Name.Local = "mask"
+		attrs = xmlIDAttrs(attrs, gii, "mask")
+	case *Symbol:
+		se.Name.Local = "symbol"
+		attrs = xmlIDAttrs(attrs, gii, "symbol")
+	}
+	if se.Name.Local == "" {
+		log.Printf("svg.MarshalXMLNode: node: %v of type: %v has no SVG element equivalent -- skipped\n", k.Name(), k.Type().Name())
+		return nil
+	}
+	se.Attr = xmlPropAttrs(attrs, *k.Properties())
+	if err := enc.EncodeToken(se); err != nil {
+		return err
+	}
+	if text != "" {
+		if err := enc.EncodeToken(xml.CharData(text)); err != nil {
+			return err
+		}
+	}
+	if !noKids {
+		if err := MarshalXMLChildren(enc, k); err != nil {
+			return err
+		}
+	}
+	return enc.EncodeToken(se.End())
+}
+
+// marshalXMLGradient marshals a gradient as a linear or radial gradient
+// element, including all of its stops
+func marshalXMLGradient(enc *xml.Encoder, gr *gi.Gradient) error {
+	g := gr.Grad.Gradient
+	if g == nil {
+		return nil
+	}
+	se := xml.StartElement{}
+	var attrs []xml.Attr
+	pts := g.Points
+	if g.IsRadial {
+		se.Name.Local = "radialGradient"
+		attrs = xmlIDAttrs(attrs, gr, "rad-grad")
+		attrs = xmlAddAttr(attrs, "cx", xmlFloat(float32(pts[0])))
+		attrs = xmlAddAttr(attrs, "cy", xmlFloat(float32(pts[1])))
+		attrs = xmlAddAttr(attrs, "fx", xmlFloat(float32(pts[2])))
+		attrs = xmlAddAttr(attrs, "fy", xmlFloat(float32(pts[3])))
+		attrs = xmlAddAttr(attrs, "r", xmlFloat(float32(pts[4])))
+	} else {
+		se.Name.Local = "linearGradient"
+		attrs = xmlIDAttrs(attrs, gr, "lin-grad")
+		attrs = xmlAddAttr(attrs, "x1", xmlFloat(float32(pts[0])))
+		attrs = xmlAddAttr(attrs, "y1", xmlFloat(float32(pts[1])))
+		attrs = xmlAddAttr(attrs, "x2", xmlFloat(float32(pts[2])))
+		attrs = xmlAddAttr(attrs, "y2", xmlFloat(float32(pts[3])))
+	}
+	if g.Units == rasterx.UserSpaceOnUse {
+		attrs = xmlAddAttr(attrs, "gradientUnits", "userSpaceOnUse")
+	} else {
+		attrs = xmlAddAttr(attrs, "gradientUnits", "objectBoundingBox")
+	}
+	switch g.Spread {
+	case rasterx.ReflectSpread:
+		attrs = xmlAddAttr(attrs, "spreadMethod", "reflect")
+	case rasterx.RepeatSpread:
+		attrs = xmlAddAttr(attrs, "spreadMethod", "repeat")
+	}
+	if m := g.Matrix; m != rasterx.Identity {
+		attrs = xmlAddAttr(attrs, "gradientTransform", "matrix("+xmlFloats([]float32{float32(m.A), float32(m.B), float32(m.C), float32(m.D), float32(m.E), float32(m.F)}, ",")+")")
+	}
+	se.Attr = attrs
+	if err := enc.EncodeToken(se); err != nil {
+		return err
+	}
+	for _, st := range g.Stops {
+		ss := xml.StartElement{Name: xml.Name{Local: "stop"}}
+		ss.Attr = xmlAddAttr(ss.Attr, "offset", xmlFloat(float32(st.Offset)))
+		ss.Attr = xmlAddAttr(ss.Attr, "stop-color", xmlColor(st.StopColor))
+		if st.Opacity != 1 {
+			ss.Attr = xmlAddAttr(ss.Attr, "stop-opacity", xmlFloat(float32(st.Opacity)))
+		}
+		if err := enc.EncodeToken(ss); err != nil {
+			return err
+		}
+		if err := enc.EncodeToken(ss.End()); err != nil {
+			return err
+		}
+	}
+	return enc.EncodeToken(se.End())
+}
+
+// xmlTextElement writes a simple element with given text content
+func xmlTextElement(enc *xml.Encoder, name, text string) error {
+	se := xml.StartElement{Name: xml.Name{Local: name}}
+	if err := enc.EncodeToken(se); err != nil {
+		return err
+	}
+	if err := enc.EncodeToken(xml.CharData(text)); err != nil {
+		return err
+	}
+	return enc.EncodeToken(se.End())
+}
+
+// xmlAddAttr adds given attribute name, value to attrs
+func xmlAddAttr(attrs []xml.Attr, name, val string) []xml.Attr {
+	return append(attrs, xml.Attr{Name: xml.Name{Local: name}, Value: val})
+}
+
+// xmlIDAttrs adds the id and class attributes for given node -- the id
+// is only written if the name differs from the default name given to
+// that element type by ReadXML, and likewise the class is skipped if it
+// is just that default name (as set for flow and filter elements)
+func xmlIDAttrs(attrs []xml.Attr, gii gi.Node2D, defNm string) []xml.Attr {
+	nb := gii.AsNode2D()
+	if nb.Nm != "" && nb.Nm != defNm {
+		attrs = xmlAddAttr(attrs, "id", nb.Nm)
+	}
+	if nb.Class != "" && nb.Class != defNm {
+		attrs = xmlAddAttr(attrs, "class", nb.Class)
+	}
+	return attrs
+}
+
+// xmlPropAttrs adds attributes for all the properties in props, in sorted
+// order with namespace declarations first -- sub-property maps (e.g., for
+// CSS selectors) are skipped
+func xmlPropAttrs(attrs []xml.Attr, props ki.Props) []xml.Attr {
+	keys := make([]string, 0, len(props))
+	for key := range props {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { // namespace declarations first
+		ins := strings.HasPrefix(keys[i], "xmlns")
+		jns := strings.HasPrefix(keys[j], "xmlns")
+		if ins != jns {
+			return ins
+		}
+		return keys[i] < keys[j]
+	})
+	for _, key := range keys {
+		if key == "" || key == "id" || key == "class" {
+			continue
+		}
+		var val string
+		switch pv := props[key].(type) {
+		case ki.Props:
+			continue
+		case string:
+			val = pv
+		case gi.Color:
+			val = xmlColor(pv)
+		case *gi.Color:
+			val = xmlColor(*pv)
+		case units.Value:
+			val = pv.String()
+		case float32:
+			val = xmlFloat(pv)
+		case fmt.Stringer:
+			val = pv.String()
+		default:
+			val = kit.ToString(pv)
+		}
+		attrs = xmlAddAttr(attrs, key, val)
+	}
+	return attrs
+}
+
+// xmlFloat returns the standard string representation of given number
+func xmlFloat(v float32) string {
+	return strconv.FormatFloat(float64(v), 'f', -1, 32)
+}
+
+// xmlFloats returns the string representation of given numbers, joined
+// with given separator
+func xmlFloats(vs []float32, sep string) string {
+	strs := make([]string, len(vs))
+	for i, v := range vs {
+		strs[i] = xmlFloat(v)
+	}
+	return strings.Join(strs, sep)
+}
+
+// xmlPoints returns the string representation of given points, as x,y pairs
+func xmlPoints(pts []mat32.Vec2) string {
+	strs := make([]string, len(pts))
+	for i, pt := range pts {
+		strs[i] = xmlFloat(pt.X) + "," + xmlFloat(pt.Y)
+	}
+	return strings.Join(strs, " ")
+}
+
+// xmlMatrix returns the matrix(a,b,c,d,e,f) representation of given transform
+func xmlMatrix(m mat32.Mat2) string {
+	return "matrix(" + xmlFloats([]float32{m.XX, m.YX, m.XY, m.YY, m.X0, m.Y0}, ",") + ")"
+}
+
+// xmlColor returns the #rrggbb hex representation of given color --
+// any transparency must be specified separately via opacity
+func xmlColor(clr color.Color) string {
+	if clr == nil {
+		return "none"
+	}
+	c := color.NRGBAModel.Convert(clr).(color.NRGBA)
+	if c.A == 0 {
+		return "none"
+	}
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
@@ -0,0 +1,135 @@
+// Copyright (c) 2020, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package svg
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/goki/gi/mat32"
+)
+
+var testSVG = `<?xml version="1.0"?>
+<svg xmlns="http://www.w3.org/2000/svg" xmlns:inkscape="http://www.inkscape.org/namespaces/inkscape" viewBox="0 0 100 50" width="100" height="50">
+<title>Test</title>
+<defs>
+<linearGradient id="grad1" x1="0" y1="0" x2="1" y2="0"><stop offset="0" stop-color="#ff0000"/><stop offset="1" stop-color="blue"/></linearGradient>
+<marker id="arrow" refX="2" refY="3" markerWidth="4" markerHeight="6" orient="auto"><path d="M 0 0 L 4 3 L 0 6 z"/></marker>
+</defs>
+<g id="layer1" inkscape:label="Layer 1" transform="translate(10,5)" fill="url(#grad1)">
+<rect x="1" y="2" width="3" height="4" style="stroke:red"/>
+<path id="p1" d="M 10 10 L 20 20 C 1 2 3 4 5 6 z" marker-end="url(#arrow)"/>
+<text x="5" y="6"><tspan>Hi</tspan></text>
+<circle cx="1" cy="2" r="3"/>
+<polygon points="1,2 3,4 5,6"/>
+</g>
+<sodipodi:namedview xmlns:sodipodi="http://sodipodi.sourceforge.net/DTD/sodipodi-0.dtd" pagecolor="#ffffff"/>
+</svg>`
+
+func TestWriteXML(t *testing.T) {
+	sv := &SVG{}
+	sv.InitName(sv, "svg")
+	if err := sv.ReadXML(strings.NewReader(testSVG)); err != nil {
+		t.Fatal(err)
+	}
+	var b bytes.Buffer
+	if err := sv.WriteXML(&b, true); err != nil {
+		t.Fatal(err)
+	}
+	out := b.String()
+	for _, want := range []string{
+		`<linearGradient id="grad1" x1="0" y1="0" x2="1" y2="0"`,
+		`<stop offset="1" stop-color="#0000ff">`,
+		`<g id="layer1" fill="url(#grad1)" inkscape:label="Layer 1" transform="translate(10,5)">`,
+		`<rect x="1" y="2" width="3" height="4" stroke="red">`,
+		`<path id="p1" d="M10 10 L20 20 C1 2 3 4 5 6 z" marker-end="url(#arrow)">`,
+		`<tspan x="5" y="6">Hi</tspan>`,
+		`<polygon points="1,2 3,4 5,6">`,
+		`<sodipodi:namedview xmlns:sodipodi=`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output does not contain: %v\n%v", want, out)
+		}
+	}
+
+	// reading back in and writing again must give the same result
+	s2 := &SVG{}
+	s2.InitName(s2, "svg")
+	if err := s2.ReadXML(&b); err != nil {
+		t.Fatal(err)
+	}
+	var b2 bytes.Buffer
+	if err := s2.WriteXML(&b2, true); err != nil {
+		t.Fatal(err)
+	}
+	if b2.String() != out {
+		t.Errorf("round trip differs, got:\n%v\nexpected:\n%v", b2.String(), out)
+	}
+}
+
+func TestUseXML(t *testing.T) {
+	src := `<svg xmlns="http://www.w3.org/2000/svg" xmlns:xlink="http://www.w3.org/1999/xlink" viewBox="0 0 100 100">
+<defs>
+<rect id="box" x="1" y="2" width="3" height="4"/>
+</defs>
+<use id="b1" xlink:href="#box" transform="scale(2)" fill="red"/>
+<use xlink:href="#box" x="10" y="20"/>
+</svg>`
+	sv := &SVG{}
+	sv.InitName(sv, "svg")
+	if err := sv.ReadXML(strings.NewReader(src)); err != nil {
+		t.Fatal(err)
+	}
+	if len(sv.Kids) != 2 {
+		t.Fatalf("expected 2 use elements, got: %v", sv.Kids)
+	}
+	u1, ok := sv.Kids[0].(*Use)
+	if !ok || u1.Name() != "b1" || u1.Link != "#box" || len(u1.Kids) != 1 {
+		t.Fatalf("first use: %v", sv.Kids[0])
+	}
+	if _, ok := u1.Kids[0].(*Rect); !ok {
+		t.Errorf("first use does not contain the used rect: %v", u1.Kids[0])
+	}
+	u2 := sv.Kids[1].(*Use)
+	if u2.Pos != (mat32.Vec2{X: 10, Y: 20}) || len(u2.Kids) != 1 {
+		t.Fatalf("second use: %v %v", u2.Pos, u2.Kids)
+	}
+	if tr := u2.Kids[0].(*Group).PropString("transform", ""); tr != "translate(10,20)" {
+		t.Errorf("second use translation: %v", tr)
+	}
+
+	var b bytes.Buffer
+	if err := sv.WriteXML(&b, false); err != nil {
+		t.Fatal(err)
+	}
+	out := b.String()
+	for _, want := range []string{
+		`<rect id="box" x="1" y="2" width="3" height="4"></rect>`,
+		`<use id="b1" xlink:href="#box" fill="red" transform="scale(2)"></use>`,
+		`<use x="10" y="20" xlink:href="#box"></use>`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output does not contain: %v\n%v", want, out)
+		}
+	}
+	if n := strings.Count(out, "<rect"); n != 1 {
+		t.Errorf("used rect written %d times, not just in defs:\n%v", n, out)
+	}
+
+	// reading back in gives the same use elements
+	s2 := &SVG{}
+	s2.InitName(s2, "svg")
+	if err := s2.ReadXML(&b); err != nil {
+		t.Fatal(err)
+	}
+	var b2 bytes.Buffer
+	if err := s2.WriteXML(&b2, false); err != nil {
+		t.Fatal(err)
+	}
+	if b2.String() != out {
+		t.Errorf("round trip differs, got:\n%v\nexpected:\n%v", b2.String(), out)
+	}
+}
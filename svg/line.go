@@ -40,6 +40,7 @@ func (g *Line) Render2D() {
 	if g.Viewport == nil {
 		g.This().(gi.Node2D).Init2D()
 	}
+	eff := g.StartEffects()
 	pc := &g.Pnt
 	rs := &g.Viewport.Render
 	rs.Lock()
@@ -60,4 +61,5 @@ func (g *Line) Render2D() {
 
 	g.Render2DChildren()
 	rs.PopXFormLock()
+	g.EndEffects(eff)
 }
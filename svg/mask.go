@@ -0,0 +1,39 @@
+// Copyright (c) 2020, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package svg
+
+import (
+	"github.com/goki/gi/gi"
+	"github.com/goki/ki/ki"
+	"github.com/goki/ki/kit"
+)
+
+// Mask is an SVG mask element, for nodes that refer to it via the mask
+// property (e.g., mask="url(#mask1)") -- the children are rendered, and
+// the luminance of the result (or the alpha if mask-type is alpha)
+// determines the opacity of the masked node -- see StartEffects.  The x, y,
+// width, height properties define the mask region, in maskUnits (default
+// objectBoundingBox), and maskContentUnits determines the coordinates of
+// the children (default userSpaceOnUse).
+type Mask struct {
+	NodeBase
+}
+
+var KiT_Mask = kit.Types.AddType(&Mask{}, ki.Props{"EnumType:Flag": gi.KiT_NodeFlags})
+
+// AddNewMask adds a new mask to given parent node, with given name.
+func AddNewMask(parent ki.Ki, name string) *Mask {
+	return parent.AddNewChild(KiT_Mask, name).(*Mask)
+}
+
+func (g *Mask) CopyFieldsFrom(frm interface{}) {
+	fr := frm.(*Mask)
+	g.NodeBase.CopyFieldsFrom(&fr.NodeBase)
+}
+
+// Render2D does nothing: masks are only rendered by being applied to
+// other nodes
+func (g *Mask) Render2D() {
+}
@@ -131,15 +131,13 @@ func ApplyCSSSVG(node gi.Node2D, key string, css ki.Props) bool {
 	return true
 }
 
-// StyleCSS applies css style properties to given SVG node, parsing
-// out type, .class, and #name selectors
+// StyleCSS applies css style properties to given SVG node, matching
+// type, .class, and #name selectors, along with full CSS selectors
+// (see gi.CSSRules), in order of specificity
 func StyleCSS(node gi.Node2D, css ki.Props) {
-	tyn := strings.ToLower(node.Type().Name()) // type is most general, first
-	ApplyCSSSVG(node, tyn, css)
-	cln := "." + strings.ToLower(node.AsNode2D().Class) // then class
-	ApplyCSSSVG(node, cln, css)
-	idnm := "#" + strings.ToLower(node.Name()) // then name
-	ApplyCSSSVG(node, idnm, css)
+	for _, r := range gi.CSSRules(node, css, "") {
+		ApplyCSSSVG(node, r.Key, css)
+	}
 }
 
 func (g *NodeBase) Style2D() {
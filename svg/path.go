@@ -9,6 +9,7 @@ import (
 	"log"
 	"math"
 	"strconv"
+	"strings"
 	"unicode"
 
 	"github.com/chewxy/math32"
@@ -70,6 +71,7 @@ func (g *Path) Render2D() {
 		return
 	}
 
+	eff := g.StartEffects()
 	pc := &g.Pnt
 	rs := &g.Viewport.Render
 	rs.Lock()
@@ -112,6 +114,7 @@ func (g *Path) Render2D() {
 
 	g.Render2DChildren()
 	rs.PopXFormLock()
+	g.EndEffects(eff)
 }
 
 // PathCmds are the commands within the path SVG drawing data type
@@ -720,6 +723,36 @@ var PathCmdMap = map[rune]PathCmds{
 	'z': Pcz,
 }
 
+// PathCmdRunes maps path command to rune -- the inverse of PathCmdMap
+var PathCmdRunes = map[PathCmds]rune{}
+
+func init() {
+	for r, cmd := range PathCmdMap {
+		PathCmdRunes[cmd] = r
+	}
+}
+
+// PathDataString returns the standard SVG string representation of the
+// compiled path data, e.g., for the d attribute of a path element
+func PathDataString(data []PathData) string {
+	var sb strings.Builder
+	sz := len(data)
+	for i := 0; i < sz; {
+		cmd, n := PathDataNextCmd(data, &i)
+		if sb.Len() > 0 {
+			sb.WriteByte(' ')
+		}
+		sb.WriteRune(PathCmdRunes[cmd])
+		for np := 0; np < n && i < sz; np++ {
+			if np > 0 {
+				sb.WriteByte(' ')
+			}
+			sb.WriteString(strconv.FormatFloat(float64(PathDataNext(data, &i)), 'f', -1, 32))
+		}
+	}
+	return sb.String()
+}
+
 // PathDecodeCmd decodes rune into corresponding command
 func PathDecodeCmd(r rune) PathCmds {
 	cmd, ok := PathCmdMap[r]
@@ -0,0 +1,137 @@
+// Copyright (c) 2020, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package svg
+
+import (
+	"image"
+	"image/color"
+
+	"github.com/goki/gi/gi"
+	"github.com/goki/gi/mat32"
+	"github.com/goki/ki/ki"
+	"github.com/goki/ki/kit"
+	"github.com/srwiley/rasterx"
+)
+
+// Pattern is an SVG pattern element, which renders its children as a
+// repeating tile, used as a fill or stroke color via url(#name) -- it
+// implements the gi.ColorPattern interface.  The tile is defined by the x,
+// y, width, height properties, in patternUnits (default objectBoundingBox),
+// with the children in patternContentUnits (default userSpaceOnUse), and
+// optionally a viewBox, preserveAspectRatio and patternTransform.
+type Pattern struct {
+	NodeBase
+}
+
+var KiT_Pattern = kit.Types.AddType(&Pattern{}, ki.Props{"EnumType:Flag": gi.KiT_NodeFlags})
+
+// AddNewPattern adds a new pattern to given parent node, with given name.
+func AddNewPattern(parent ki.Ki, name string) *Pattern {
+	return parent.AddNewChild(KiT_Pattern, name).(*Pattern)
+}
+
+func (g *Pattern) CopyFieldsFrom(frm interface{}) {
+	fr := frm.(*Pattern)
+	g.NodeBase.CopyFieldsFrom(&fr.NodeBase)
+}
+
+// Render2D does nothing: patterns are only rendered as colors
+func (g *Pattern) Render2D() {
+}
+
+// MaxPatternTile is the maximum size in pixels of a pattern tile
+var MaxPatternTile = 2048
+
+// PatternColor returns the color function for rendering the pattern with
+// given opacity, for an object with given render bounds and transform
+func (g *Pattern) PatternColor(opacity float32, bounds image.Rectangle, xform mat32.Mat2) rasterx.ColorFunc {
+	pos := mat32.Vec2{X: g.PropFloat("x", 0), Y: g.PropFloat("y", 0)}
+	size := mat32.Vec2{X: g.PropFloat("width", 0), Y: g.PropFloat("height", 0)}
+	var ub mat32.Box2
+	if g.PropString("patternUnits", "") != "userSpaceOnUse" || g.PropString("patternContentUnits", "") == "objectBoundingBox" {
+		ub = userBBox(bounds, xform)
+	}
+	if g.PropString("patternUnits", "") != "userSpaceOnUse" {
+		ubs := ub.Size()
+		pos = ub.Min.Add(pos.Mul(ubs))
+		size = size.Mul(ubs)
+	}
+	if size.X <= 0 || size.Y <= 0 {
+		return func(x, y int) color.Color { return color.Transparent }
+	}
+	pxf := xform
+	if pts := g.PropString("patternTransform", ""); pts != "" {
+		var ptf mat32.Mat2
+		if err := ptf.SetString(pts); err == nil {
+			pxf = ptf.Mul(xform)
+		}
+	}
+	// content transform, into tile coordinates at 0,0
+	cxf := mat32.Identity2D()
+	if vbs := g.PropFloats("viewBox"); len(vbs) == 4 {
+		vb := ViewBox{Min: mat32.Vec2{X: vbs[0], Y: vbs[1]}, Size: mat32.Vec2{X: vbs[2], Y: vbs[3]}}
+		if pa := g.PropString("preserveAspectRatio", ""); pa != "" {
+			vb.PreserveAspectRatio.SetString(pa)
+		}
+		cxf = vb.Transform(mat32.Vec2Zero, size)
+	} else if g.PropString("patternContentUnits", "") == "objectBoundingBox" {
+		ubs := ub.Size()
+		cxf = mat32.Scale2D(ubs.X, ubs.Y)
+	}
+	scx, scy := pxf.ExtractScale()
+	tw := mat32.ClampInt(int(mat32.Ceil(mat32.Abs(size.X*scx))), 1, MaxPatternTile)
+	th := mat32.ClampInt(int(mat32.Ceil(mat32.Abs(size.Y*scy))), 1, MaxPatternTile)
+	tile := g.RenderTile(tw, th, cxf.Mul(mat32.Scale2D(float32(tw)/size.X, float32(th)/size.Y)))
+
+	inv := pxf.Inverse()
+	alpha := mat32.Clamp(opacity, 0, 1)
+	return func(x, y int) color.Color {
+		up := inv.MulVec2AsPt(mat32.Vec2{X: float32(x) + .5, Y: float32(y) + .5}).Sub(pos)
+		tx := int(mat32.Floor(mat32.Mod(up.X, size.X) / size.X * float32(tw)))
+		ty := int(mat32.Floor(mat32.Mod(up.Y, size.Y) / size.Y * float32(th)))
+		if tx < 0 {
+			tx += tw
+		}
+		if ty < 0 {
+			ty += th
+		}
+		c := tile.RGBAAt(tx, ty)
+		if alpha < 1 {
+			c = color.RGBA{uint8(float32(c.R) * alpha), uint8(float32(c.G) * alpha), uint8(float32(c.B) * alpha), uint8(float32(c.A) * alpha)}
+		}
+		return c
+	}
+}
+
+// RenderTile renders the children of the pattern into a new image of given
+// size, using given transform from the content coordinates to the tile
+// pixels -- the children are temporarily rendered into their own viewport
+func (g *Pattern) RenderTile(width, height int, xf mat32.Mat2) *image.RGBA {
+	vp := gi.NewViewport2D(width, height)
+	vp.InitName(vp, g.Nm+"-tile")
+	rs := &vp.Render
+	rs.Bounds = vp.Pixels.Bounds()
+	rs.PushXForm(xf)
+	setViewport := func(tvp *gi.Viewport2D) {
+		g.FuncDownMeFirst(0, nil, func(k ki.Ki, level int, d interface{}) bool {
+			if k == g.This() {
+				return true
+			}
+			if _, nb := gi.KiToNode2D(k); nb != nil {
+				nb.Viewport = tvp
+			}
+			return true
+		})
+	}
+	setViewport(vp)
+	for _, kid := range g.Kids {
+		if kn, _ := gi.KiToNode2D(kid); kn != nil {
+			kn.Render2D()
+		}
+	}
+	setViewport(g.Viewport)
+	rs.PopXForm()
+	return vp.Pixels
+}
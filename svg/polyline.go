@@ -42,6 +42,7 @@ func (g *Polyline) Render2D() {
 	if sz < 2 {
 		return
 	}
+	eff := g.StartEffects()
 	pc := &g.Pnt
 	rs := &g.Viewport.Render
 	rs.PushXForm(pc.XForm)
@@ -73,4 +74,5 @@ func (g *Polyline) Render2D() {
 
 	g.Render2DChildren()
 	rs.PopXForm()
+	g.EndEffects(eff)
 }
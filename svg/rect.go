@@ -41,6 +41,7 @@ func (g *Rect) Render2D() {
 	if g.Viewport == nil {
 		g.This().(gi.Node2D).Init2D()
 	}
+	eff := g.StartEffects()
 	pc := &g.Pnt
 	rs := &g.Viewport.Render
 	rs.PushXForm(pc.XForm)
@@ -54,4 +55,5 @@ func (g *Rect) Render2D() {
 	g.ComputeBBoxSVG()
 	g.Render2DChildren()
 	rs.PopXForm()
+	g.EndEffects(eff)
 }
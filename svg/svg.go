@@ -24,13 +24,14 @@ import (
 // in UpdateStart / End loop.
 type SVG struct {
 	gi.Viewport2D
-	ViewBox ViewBox  `desc:"viewbox defines the coordinate system for the drawing"`
-	Norm    bool     `desc:"prop: norm = install a transform that renormalizes so that the specified ViewBox exactly fits within the allocated SVG size"`
-	InvertY bool     `desc:"prop: invert-y = when doing Norm transform, also flip the Y axis so that the smallest Y value is at the bottom of the SVG box, instead of being at the top as it is by default"`
-	Pnt     gi.Paint `json:"-" xml:"-" desc:"paint styles -- inherited by nodes"`
-	Defs    Group    `desc:"all defs defined elements go here (gradients, symbols, etc)"`
-	Title   string   `xml:"title" desc:"the title of the svg"`
-	Desc    string   `xml:"desc" desc:"the description of the svg"`
+	ViewBox  ViewBox     `desc:"viewbox defines the coordinate system for the drawing"`
+	Norm     bool        `desc:"prop: norm = install a transform that renormalizes so that the specified ViewBox exactly fits within the allocated SVG size"`
+	InvertY  bool        `desc:"prop: invert-y = when doing Norm transform, also flip the Y axis so that the smallest Y value is at the bottom of the SVG box, instead of being at the top as it is by default"`
+	Pnt      gi.Paint    `json:"-" xml:"-" desc:"paint styles -- inherited by nodes"`
+	Defs     Group       `desc:"all defs defined elements go here (gradients, symbols, etc)"`
+	Title    string      `xml:"title" desc:"the title of the svg"`
+	Desc     string      `xml:"desc" desc:"the description of the svg"`
+	Filename gi.FileName `xml:"-" desc:"file name of the svg, if opened from a file -- linked images are opened relative to this file"`
 }
 
 var KiT_SVG = kit.Types.AddType(&SVG{}, SVGProps)
@@ -54,6 +55,7 @@ func (svg *SVG) CopyFieldsFrom(frm interface{}) {
 	svg.Defs.CopyFrom(&fr.Defs)
 	svg.Title = fr.Title
 	svg.Desc = fr.Desc
+	svg.Filename = fr.Filename
 }
 
 // Paint satisfies the painter interface
@@ -0,0 +1,65 @@
+// Copyright (c) 2020, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package svg
+
+import (
+	"github.com/goki/gi/gi"
+	"github.com/goki/gi/mat32"
+	"github.com/goki/ki/ki"
+	"github.com/goki/ki/kit"
+)
+
+// Symbol is an SVG symbol element, which is not rendered itself, but is
+// instead instantiated by use elements -- on reading, each use of a symbol
+// becomes a Group with a copy of the symbol contents, transformed to fit
+// the use position and size, according to the symbol viewBox and
+// preserveAspectRatio properties.
+type Symbol struct {
+	NodeBase
+}
+
+var KiT_Symbol = kit.Types.AddType(&Symbol{}, ki.Props{"EnumType:Flag": gi.KiT_NodeFlags})
+
+// AddNewSymbol adds a new symbol to given parent node, with given name.
+func AddNewSymbol(parent ki.Ki, name string) *Symbol {
+	return parent.AddNewChild(KiT_Symbol, name).(*Symbol)
+}
+
+func (g *Symbol) CopyFieldsFrom(frm interface{}) {
+	fr := frm.(*Symbol)
+	g.NodeBase.CopyFieldsFrom(&fr.NodeBase)
+}
+
+// Render2D does nothing: symbols are only rendered via use
+func (g *Symbol) Render2D() {
+}
+
+// ViewBox returns the viewBox of the symbol from its viewBox and
+// preserveAspectRatio properties -- size is zero if not set
+func (g *Symbol) ViewBox() ViewBox {
+	var vb ViewBox
+	if vbs := g.PropFloats("viewBox"); len(vbs) == 4 {
+		vb.Min = mat32.Vec2{X: vbs[0], Y: vbs[1]}
+		vb.Size = mat32.Vec2{X: vbs[2], Y: vbs[3]}
+	}
+	if pa := g.PropString("preserveAspectRatio", ""); pa != "" {
+		vb.PreserveAspectRatio.SetString(pa)
+	}
+	return vb
+}
+
+// UseTransform returns the transform for an instance of the symbol used at
+// given position, and size -- zero size values default to the symbol
+// width, height properties if set, or 100% of the viewBox size
+func (g *Symbol) UseTransform(pos, size mat32.Vec2) mat32.Mat2 {
+	vb := g.ViewBox()
+	if size.X == 0 {
+		size.X = g.PropFloat("width", vb.Size.X)
+	}
+	if size.Y == 0 {
+		size.Y = g.PropFloat("height", vb.Size.Y)
+	}
+	return vb.Transform(pos, size)
+}
@@ -67,6 +67,7 @@ func (g *Text) Render2D() {
 	if g.Viewport == nil {
 		g.This().(gi.Node2D).Init2D()
 	}
+	eff := g.StartEffects()
 	pc := &g.Pnt
 	rs := &g.Viewport.Render
 	rs.PushXForm(pc.XForm)
@@ -143,4 +144,5 @@ func (g *Text) Render2D() {
 	}
 	g.Render2DChildren()
 	rs.PopXForm()
+	g.EndEffects(eff)
 }
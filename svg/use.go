@@ -0,0 +1,64 @@
+// Copyright (c) 2020, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package svg
+
+import (
+	"github.com/goki/gi/gi"
+	"github.com/goki/gi/mat32"
+	"github.com/goki/ki/ki"
+	"github.com/goki/ki/kit"
+)
+
+// Use is an SVG use element, which renders another element (or the
+// contents of a Symbol) found by its Link.  ReadXML adds a copy of the
+// used element as its children for rendering, and WriteXML writes just
+// the use element with its link, so the reference is preserved.
+type Use struct {
+	Group
+	Link string     `xml:"href" desc:"link to the used element, e.g., #name"`
+	Pos  mat32.Vec2 `xml:"{x,y}" desc:"position of the used element -- an additional translation"`
+	Size mat32.Vec2 `xml:"{width,height}" desc:"size of a used Symbol -- zero values default to the symbol size"`
+}
+
+var KiT_Use = kit.Types.AddType(&Use{}, ki.Props{"EnumType:Flag": gi.KiT_NodeFlags})
+
+// AddNewUse adds a new use element to given parent node, with given name
+// and link -- call SetUsed to add a copy of the used element.
+func AddNewUse(parent ki.Ki, name string, link string) *Use {
+	g := parent.AddNewChild(KiT_Use, name).(*Use)
+	g.Link = link
+	return g
+}
+
+func (g *Use) CopyFieldsFrom(frm interface{}) {
+	fr := frm.(*Use)
+	g.Group.CopyFieldsFrom(&fr.Group)
+	g.Link = fr.Link
+	g.Pos = fr.Pos
+	g.Size = fr.Size
+}
+
+// SetUsed replaces the children with a copy of given used element, at
+// the Pos and Size -- the contents of a Symbol are copied into a group
+// with the symbol viewBox transform.
+func (g *Use) SetUsed(itm gi.Node2D) {
+	g.DeleteChildren(ki.DestroyKids)
+	if sym, ok := itm.(*Symbol); ok {
+		grp := AddNewGroup(g.This(), "use")
+		grp.SetProp("transform", xmlMatrix(sym.UseTransform(g.Pos, g.Size)))
+		for _, kid := range sym.Kids {
+			grp.AddChild(kid.Clone())
+		}
+		return
+	}
+	cln := itm.Clone()
+	if g.Pos == mat32.Vec2Zero {
+		g.AddChild(cln)
+		return
+	}
+	grp := AddNewGroup(g.This(), "use")
+	grp.SetProp("transform", "translate("+xmlFloat(g.Pos.X)+","+xmlFloat(g.Pos.Y)+")")
+	grp.AddChild(cln)
+}
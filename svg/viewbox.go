@@ -5,6 +5,9 @@
 package svg
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/goki/gi/gi"
 	"github.com/goki/gi/mat32"
 	"github.com/goki/ki/kit"
@@ -20,8 +23,6 @@ type ViewBox struct {
 	PreserveAspectRatio ViewBoxPreserveAspectRatio `desc:"how to scale the view box within parent Viewport2D"`
 }
 
-// todo: need to implement the viewbox preserve aspect ratio logic!
-
 // Defaults returns viewbox to defaults
 func (vb *ViewBox) Defaults() {
 	vb.Min = mat32.Vec2Zero
@@ -75,3 +76,109 @@ type ViewBoxPreserveAspectRatio struct {
 	Align       ViewBoxAlign       `svg:"align" desc:"how to align x,y coordinates within viewbox"`
 	MeetOrSlice ViewBoxMeetOrSlice `svg:"meetOrSlice" desc:"how to scale the view box relative to the viewport"`
 }
+
+// SetString sets the preserve aspect ratio settings from the standard
+// SVG string representation, e.g., "xMidYMid meet" or "none"
+func (pa *ViewBoxPreserveAspectRatio) SetString(str string) error {
+	fs := strings.Fields(str)
+	if len(fs) == 0 {
+		return fmt.Errorf("svg.ViewBoxPreserveAspectRatio.SetString: empty string")
+	}
+	pa.MeetOrSlice = Meet
+	if len(fs) > 1 && fs[1] == "slice" {
+		pa.MeetOrSlice = Slice
+	}
+	al := fs[0]
+	if al == "none" {
+		pa.Align = NoAlign
+		return nil
+	}
+	if len(al) != 8 {
+		return fmt.Errorf("svg.ViewBoxPreserveAspectRatio.SetString: invalid align: %v", al)
+	}
+	pa.Align = 0
+	switch al[:4] {
+	case "xMin":
+		pa.Align |= XMin
+	case "xMid":
+		pa.Align |= XMid
+	case "xMax":
+		pa.Align |= XMax
+	default:
+		return fmt.Errorf("svg.ViewBoxPreserveAspectRatio.SetString: invalid align: %v", al)
+	}
+	switch al[4:] {
+	case "YMin":
+		pa.Align |= YMin
+	case "YMid":
+		pa.Align |= YMid
+	case "YMax":
+		pa.Align |= YMax
+	default:
+		return fmt.Errorf("svg.ViewBoxPreserveAspectRatio.SetString: invalid align: %v", al)
+	}
+	return nil
+}
+
+// String returns the standard SVG string representation -- an unset
+// (zero) Align is the SVG default of xMidYMid
+func (pa ViewBoxPreserveAspectRatio) String() string {
+	if pa.Align&NoAlign != 0 {
+		return "none"
+	}
+	str := "xMid"
+	switch {
+	case pa.Align&XMin != 0:
+		str = "xMin"
+	case pa.Align&XMax != 0:
+		str = "xMax"
+	}
+	switch {
+	case pa.Align&YMin != 0:
+		str += "YMin"
+	case pa.Align&YMax != 0:
+		str += "YMax"
+	default:
+		str += "YMid"
+	}
+	if pa.MeetOrSlice == Slice {
+		str += " slice"
+	}
+	return str
+}
+
+// Transform returns the transform that maps the ViewBox coordinates into
+// the rectangle at given position and size, according to the
+// PreserveAspectRatio settings
+func (vb *ViewBox) Transform(pos, size mat32.Vec2) mat32.Mat2 {
+	if vb.Size.X == 0 || vb.Size.Y == 0 {
+		return mat32.Translate2D(pos.X, pos.Y)
+	}
+	sx := size.X / vb.Size.X
+	sy := size.Y / vb.Size.Y
+	pa := vb.PreserveAspectRatio
+	var off mat32.Vec2
+	if pa.Align&NoAlign == 0 {
+		if (pa.MeetOrSlice == Meet) == (sx < sy) {
+			sy = sx
+		} else {
+			sx = sy
+		}
+		extra := size.Sub(vb.Size.Mul(mat32.Vec2{X: sx, Y: sy}))
+		switch {
+		case pa.Align&XMin != 0:
+		case pa.Align&XMax != 0:
+			off.X = extra.X
+		default:
+			off.X = .5 * extra.X
+		}
+		switch {
+		case pa.Align&YMin != 0:
+		case pa.Align&YMax != 0:
+			off.Y = extra.Y
+		default:
+			off.Y = .5 * extra.Y
+		}
+	}
+	return mat32.Translate2D(-vb.Min.X, -vb.Min.Y).Mul(mat32.Scale2D(sx, sy)).Mul(mat32.Translate2D(pos.X+off.X, pos.Y+off.Y))
+}